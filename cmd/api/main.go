@@ -1,5 +1,5 @@
 // @title NodeByte API
-// @version 0.2.0
+// @version 0.3.0
 // @description Comprehensive REST API for managing game server infrastructure with panel integration, async job queue management, and real-time sync operations
 // @termsOfService https://nodebyte.co.uk/legal/terms
 // @contact.name Contact Support
@@ -30,24 +30,32 @@ import (
 	"github.com/gofiber/fiber/v2/middleware/cors"
 	"github.com/gofiber/fiber/v2/middleware/logger"
 	"github.com/gofiber/fiber/v2/middleware/recover"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
 	"github.com/hibiken/asynq"
 	"github.com/joho/godotenv"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"github.com/spf13/cobra"
 
-	_ "github.com/nodebyte/backend/docs"
+	"github.com/nodebyte/backend/docs"
+	"github.com/nodebyte/backend/internal/buildinfo"
+	"github.com/nodebyte/backend/internal/cache"
 	"github.com/nodebyte/backend/internal/cli/api"
 	"github.com/nodebyte/backend/internal/config"
 	"github.com/nodebyte/backend/internal/crypto"
 	"github.com/nodebyte/backend/internal/database"
 	"github.com/nodebyte/backend/internal/handlers"
+	"github.com/nodebyte/backend/internal/hytale"
+	"github.com/nodebyte/backend/internal/middleware"
 	"github.com/nodebyte/backend/internal/queue"
 	"github.com/nodebyte/backend/internal/sentry"
 	"github.com/nodebyte/backend/internal/workers"
 )
 
 func main() {
+	// The served Swagger spec reports the build version, not the one it was generated with
+	docs.SwaggerInfo.Version = buildinfo.Version
+
 	rootCmd := &cobra.Command{
 		Use:   "api",
 		Short: "NodeByte Backend API Server",
@@ -68,7 +76,7 @@ func runServer() error {
 	initLogging()
 	cfg := loadConfig()
 
-	log.Info().Str("env", cfg.Env).Msg("Starting NodeByte Backend Service")
+	log.Info().Str("env", cfg.Env).Str("version", buildinfo.Version).Msg("Starting NodeByte Backend Service")
 
 	// Initialize database
 	db := connectDatabase(cfg)
@@ -84,6 +92,10 @@ func runServer() error {
 		log.Info().Msg("Loaded system settings from database")
 	}
 
+	// Apply the configured level now that log_level may have come from the database
+	zerolog.SetGlobalLevel(cfg.ZerologLevel())
+	log.Info().Str("level", cfg.LogLevel).Msg("Log level set")
+
 	log.Debug().
 		Str("pterodactyl_url", cfg.PterodactylURL).
 		Int("pterodactyl_api_key_len", len(cfg.PterodactylAPIKey)).
@@ -129,11 +141,12 @@ func loadConfig() *config.Config {
 
 // connectDatabase establishes a database connection.
 func connectDatabase(cfg *config.Config) *database.DB {
-	db, err := database.NewConnection(cfg.DatabaseURL)
+	slowQueryThreshold := time.Duration(cfg.SlowQueryThresholdMs) * time.Millisecond
+	db, err := database.NewConnection(cfg.DatabaseURL, slowQueryThreshold)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to connect to database")
 	}
-	log.Info().Msg("Connected to PostgreSQL database")
+	log.Info().Dur("slow_query_threshold", slowQueryThreshold).Msg("Connected to PostgreSQL database")
 	return db
 }
 
@@ -165,12 +178,22 @@ func initQueue(cfg *config.Config) (asynq.RedisClientOpt, *queue.Manager) {
 	asynqClient := asynq.NewClient(redisOpt)
 	log.Info().Msg("Connected to Redis")
 
-	return redisOpt, queue.NewManager(asynqClient)
+	queueMgr := queue.NewManagerWithRouting(asynqClient, cfg.QueueRouting())
+	queueMgr.SetTaskOptions(cfg.TaskOptions)
+	queueMgr.SetInspector(asynq.NewInspector(redisOpt))
+	routing := queueMgr.Routing()
+	log.Info().
+		Str("email_queue", routing.Email).
+		Str("webhook_queue", routing.Webhook).
+		Str("sync_queue", routing.Sync).
+		Msg("Task queue routing configured")
+
+	return redisOpt, queueMgr
 }
 
 // initSentry initializes the Sentry error tracking system.
 func initSentry(cfg *config.Config) fiber.Handler {
-	sentryHandler, err := sentry.InitSentry(cfg.SentryDSN, cfg.Env, "0.2.1")
+	sentryHandler, err := sentry.InitSentry(cfg.SentryDSN, cfg.SentryEnvironment, buildinfo.Version, cfg.SentryTracesSampleRate)
 	if err != nil {
 		log.Warn().Err(err).Msg("Failed to initialize Sentry")
 	}
@@ -179,57 +202,101 @@ func initSentry(cfg *config.Config) fiber.Handler {
 
 // startServer initializes and starts the HTTP server.
 func startServer(cfg *config.Config, db *database.DB, queueMgr *queue.Manager, sentryHandler fiber.Handler) error {
+	// X-Forwarded-Proto and friends are honored from every client unless
+	// proxy trust is disabled or limited to TRUSTED_PROXIES. The client IP
+	// header is only honored from TRUSTED_PROXIES.
+	var trustedProxies []string
+	if cfg.TrustProxyHeaders {
+		trustedProxies = cfg.TrustedProxies
+	}
+
 	app := fiber.New(fiber.Config{
-		AppName:      "NodeByte Backend v1.0.0",
-		ReadTimeout:  30 * time.Second,
-		WriteTimeout: 30 * time.Second,
-		IdleTimeout:  120 * time.Second,
+		AppName:                 "NodeByte Backend v" + buildinfo.Version,
+		ReadTimeout:             30 * time.Second,
+		WriteTimeout:            30 * time.Second,
+		IdleTimeout:             120 * time.Second,
+		EnableTrustedProxyCheck: !cfg.TrustProxyHeaders || len(trustedProxies) > 0,
+		TrustedProxies:          trustedProxies,
+		ProxyHeader:             cfg.ClientIPHeader(),
+		EnableIPValidation:      true,
 	})
 
 	// Setup middleware
 	setupMiddleware(app, sentryHandler, cfg)
 
+	redisConfig, _ := api.ParseRedisURL(cfg.RedisURL)
+	redisOpt := redisConfig.ToAsynqOpt()
+
+	// Response cache for public endpoints
+	responseCache := cache.New(redisOpt)
+	defer responseCache.Close()
+
+	// Per-route-group API rate limits, shared by every instance through Redis
+	apiLimiter := middleware.NewAPIRateLimiter(redisOpt, cfg.APIRateLimit)
+	defer apiLimiter.Close()
+
+	// Hytale staging/production mode, switchable at runtime from admin settings
+	hytaleEnv := hytale.NewEnvironment(cfg.HytaleUseStaging)
+
 	// Setup routes
-	apiKeyMiddleware := handlers.NewAPIKeyMiddleware(cfg.APIKey)
-	handlers.SetupRoutes(app, db, queueMgr, apiKeyMiddleware, cfg)
+	apiKeyMiddleware := handlers.NewAPIKeyMiddleware(cfg.StaticAPIKeys(), db)
+	readiness := handlers.NewReadiness()
+	handlers.SetupRoutes(app, db, queueMgr, apiKeyMiddleware, cfg, responseCache, apiLimiter, hytaleEnv, readiness)
 
 	// Start background services
-	redisConfig, _ := api.ParseRedisURL(cfg.RedisURL)
-	redisOpt := redisConfig.ToAsynqOpt()
 
 	workerServer := workers.NewServer(redisOpt, db, cfg)
-	scheduler := workers.NewScheduler(db, redisOpt, cfg)
+	scheduler := workers.NewScheduler(db, redisOpt, cfg, hytaleEnv)
 
 	go startWorkerServer(workerServer)
 	go startScheduler(scheduler)
 
 	// Setup graceful shutdown (including queue client cleanup)
-	setupGracefulShutdown(app, scheduler, workerServer, queueMgr)
+	shutdownDone := setupGracefulShutdown(app, cfg, readiness, scheduler, workerServer, queueMgr)
 
 	// Start server
 	port := getPort(cfg)
 	log.Info().Str("port", port).Msg("Starting HTTP server")
-	return app.Listen(":" + port)
+	if err := app.Listen(":" + port); err != nil {
+		return err
+	}
+
+	// Listen returns as soon as the HTTP server shuts down; wait for the
+	// background services to stop before the deferred cleanup closes the
+	// database and Redis connections they use
+	<-shutdownDone
+	return nil
 }
 
 // setupMiddleware configures HTTP middleware.
 func setupMiddleware(app *fiber.App, sentryHandler fiber.Handler, cfg *config.Config) {
+	// Last-resort net for panics outside the handler chain; handler panics
+	// are reported and answered by sentry.Recover below
 	app.Use(recover.New())
+	app.Use(requestid.New(requestid.Config{ContextKey: sentry.RequestIDLocal}))
 	if sentryHandler != nil {
 		app.Use(sentryHandler)
 	}
+	app.Use(sentry.Recover())
 	app.Use(logger.New(logger.Config{
-		Format: "[${time}] ${status} - ${latency} ${method} ${path}\n",
+		Format: "[${time}] ${status} - ${latency} ${method} ${path} ${locals:requestid}\n",
 	}))
 
 	// Log CORS origins for debugging
-	log.Info().Strs("cors_origins", cfg.CORSOrigins).Msg("CORS configured with origins")
+	log.Info().
+		Strs("cors_origins", cfg.CORSOrigins).
+		Strs("cors_allow_headers", cfg.CORSAllowHeaders).
+		Strs("cors_allow_methods", cfg.CORSAllowMethods).
+		Int("cors_max_age", cfg.CORSMaxAge).
+		Msg("CORS configured with origins")
 
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     strings.Join(cfg.CORSOrigins, ", "),
-		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, X-API-Key",
-		AllowMethods:     "GET, POST, PUT, DELETE, OPTIONS, PATCH",
+		AllowHeaders:     strings.Join(cfg.CORSAllowHeaders, ", "),
+		ExposeHeaders:    "X-Cache, X-Request-ID",
+		AllowMethods:     strings.Join(cfg.CORSAllowMethods, ", "),
 		AllowCredentials: true,
+		MaxAge:           cfg.CORSMaxAge,
 	}))
 }
 
@@ -247,16 +314,37 @@ func startScheduler(scheduler *workers.Scheduler) {
 	}
 }
 
-// setupGracefulShutdown configures graceful server shutdown.
-func setupGracefulShutdown(app *fiber.App, scheduler *workers.Scheduler, workerServer *workers.Server, queueMgr *queue.Manager) {
+// setupGracefulShutdown configures graceful server shutdown. On a signal
+// /ready reports draining for the drain period so load balancers stop
+// routing here, then in-flight requests are given the shutdown timeout to
+// finish before background services stop. The returned channel closes once
+// everything has stopped.
+func setupGracefulShutdown(app *fiber.App, cfg *config.Config, readiness *handlers.Readiness, scheduler *workers.Scheduler, workerServer *workers.Server, queueMgr *queue.Manager) <-chan struct{} {
+	done := make(chan struct{})
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	drain, timeout, sentryFlush := cfg.ShutdownDurations()
 
 	go func() {
+		defer close(done)
 		<-quit
+		readiness.Drain()
+		if drain > 0 {
+			log.Info().Dur("drain_period", drain).Msg("Draining traffic before shutdown")
+			// A second signal skips the rest of the drain period
+			select {
+			case <-time.After(drain):
+			case <-quit:
+			}
+		}
 		log.Info().Msg("Shutting down server...")
 
-		sentry.Flush(5 * time.Second)
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+
+		if err := app.ShutdownWithContext(ctx); err != nil {
+			log.Error().Err(err).Msg("Server forced to shutdown")
+		}
 
 		scheduler.Stop()
 		workerServer.Stop()
@@ -267,13 +355,9 @@ func setupGracefulShutdown(app *fiber.App, scheduler *workers.Scheduler, workerS
 			}
 		}
 
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-
-		if err := app.ShutdownWithContext(ctx); err != nil {
-			log.Error().Err(err).Msg("Server forced to shutdown")
-		}
+		sentry.Flush(sentryFlush)
 	}()
+	return done
 }
 
 // getPort returns the server port from config or defaults to 8080.
@@ -2,6 +2,7 @@ package main
 
 import (
 	"context"
+	"strconv"
 
 	"github.com/spf13/cobra"
 
@@ -17,11 +18,13 @@ func InitCmd() *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			databaseURL, _ := cmd.Flags().GetString("database")
 			schemasDir, _ := cmd.Flags().GetString("schemas")
+			wait, _ := cmd.Flags().GetDuration("wait")
 
-			initCmd, err := dbcli.NewInitCmd([]string{
+			initCmd, err := dbcli.NewInitCmd(append([]string{
 				"-database", databaseURL,
 				"-schemas", schemasDir,
-			})
+				"-wait", wait.String(),
+			}, outputArgs(cmd)...))
 			if err != nil {
 				return err
 			}
@@ -33,6 +36,7 @@ func InitCmd() *cobra.Command {
 
 	cmd.Flags().StringP("database", "d", "", "PostgreSQL connection string (or set DATABASE_URL env var)")
 	cmd.Flags().String("schemas", "", "Path to schemas directory (optional)")
+	cmd.Flags().Duration("wait", 0, "Wait up to this long for the database to accept connections (e.g. 60s)")
 
 	return cmd
 }
@@ -47,12 +51,14 @@ func MigrateCmd() *cobra.Command {
 			databaseURL, _ := cmd.Flags().GetString("database")
 			schemaFile, _ := cmd.Flags().GetString("schema")
 			schemasDir, _ := cmd.Flags().GetString("schemas")
+			wait, _ := cmd.Flags().GetDuration("wait")
 
-			migrateCmd, err := dbcli.NewMigrateCmd([]string{
+			migrateCmd, err := dbcli.NewMigrateCmd(append([]string{
 				"-database", databaseURL,
 				"-schema", schemaFile,
 				"-schemas", schemasDir,
-			})
+				"-wait", wait.String(),
+			}, outputArgs(cmd)...))
 			if err != nil {
 				return err
 			}
@@ -65,6 +71,7 @@ func MigrateCmd() *cobra.Command {
 	cmd.Flags().StringP("database", "d", "", "PostgreSQL connection string (or set DATABASE_URL env var)")
 	cmd.Flags().String("schema", "", "Specific schema file to migrate (optional)")
 	cmd.Flags().String("schemas", "", "Path to schemas directory (optional)")
+	cmd.Flags().Duration("wait", 0, "Wait up to this long for the database to accept connections (e.g. 60s)")
 
 	return cmd
 }
@@ -78,11 +85,13 @@ func ResetCmd() *cobra.Command {
 		RunE: func(cmd *cobra.Command, args []string) error {
 			databaseURL, _ := cmd.Flags().GetString("database")
 			schemasDir, _ := cmd.Flags().GetString("schemas")
+			wait, _ := cmd.Flags().GetDuration("wait")
 
-			resetCmd, err := dbcli.NewResetCmd([]string{
+			resetCmd, err := dbcli.NewResetCmd(append([]string{
 				"-database", databaseURL,
 				"-schemas", schemasDir,
-			})
+				"-wait", wait.String(),
+			}, outputArgs(cmd)...))
 			if err != nil {
 				return err
 			}
@@ -94,10 +103,58 @@ func ResetCmd() *cobra.Command {
 
 	cmd.Flags().StringP("database", "d", "", "PostgreSQL connection string (or set DATABASE_URL env var)")
 	cmd.Flags().String("schemas", "", "Path to schemas directory (optional)")
+	cmd.Flags().Duration("wait", 0, "Wait up to this long for the database to accept connections (e.g. 60s)")
 
 	return cmd
 }
 
+// VerifyCmd returns the verify subcommand.
+func VerifyCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "verify",
+		Short: "Check the database for drift from the schemas",
+		Long:  "Compare the tables, columns and indexes each schema creates with the database and report missing or unexpected objects. Exits non-zero on any mismatch.",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			databaseURL, _ := cmd.Flags().GetString("database")
+			schemasDir, _ := cmd.Flags().GetString("schemas")
+			wait, _ := cmd.Flags().GetDuration("wait")
+			allowExtra, _ := cmd.Flags().GetBool("allow-extra")
+
+			verifyCmd, err := dbcli.NewVerifyCmd(append([]string{
+				"-database", databaseURL,
+				"-schemas", schemasDir,
+				"-wait", wait.String(),
+				"-allow-extra=" + strconv.FormatBool(allowExtra),
+			}, outputArgs(cmd)...))
+			if err != nil {
+				return err
+			}
+
+			ctx := context.Background()
+			return verifyCmd.Run(ctx)
+		},
+	}
+
+	cmd.Flags().StringP("database", "d", "", "PostgreSQL connection string (or set DATABASE_URL env var)")
+	cmd.Flags().String("schemas", "", "Path to schemas directory (optional)")
+	cmd.Flags().Duration("wait", 0, "Wait up to this long for the database to accept connections (e.g. 60s)")
+	cmd.Flags().Bool("allow-extra", false, "Report objects no schema creates without failing")
+
+	return cmd
+}
+
+// outputArgs forwards the root command's output mode flags to the db command parser.
+func outputArgs(cmd *cobra.Command) []string {
+	jsonOut, _ := cmd.Flags().GetBool("json")
+	quiet, _ := cmd.Flags().GetBool("quiet")
+	verbose, _ := cmd.Flags().GetBool("verbose")
+	return []string{
+		"-json=" + strconv.FormatBool(jsonOut),
+		"-quiet=" + strconv.FormatBool(quiet),
+		"-verbose=" + strconv.FormatBool(verbose),
+	}
+}
+
 // ListCmd returns the list subcommand.
 func ListCmd() *cobra.Command {
 	cmd := &cobra.Command{
@@ -17,9 +17,13 @@ func main() {
 	rootCmd.AddCommand(MigrateCmd())
 	rootCmd.AddCommand(ResetCmd())
 	rootCmd.AddCommand(ListCmd())
+	rootCmd.AddCommand(VerifyCmd())
 
 	rootCmd.PersistentFlags().StringP("database", "d", "", "PostgreSQL connection string (or set DATABASE_URL env var)")
 	rootCmd.PersistentFlags().String("schemas", "", "Path to schemas directory (optional)")
+	rootCmd.PersistentFlags().Bool("json", false, "Print a JSON report of per-schema results instead of human-readable output")
+	rootCmd.PersistentFlags().BoolP("quiet", "q", false, "Only print failures")
+	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "Also print schema file paths and timings")
 
 	if err := rootCmd.Execute(); err != nil {
 		os.Exit(1)
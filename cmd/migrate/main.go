@@ -6,6 +6,7 @@ package main
 import (
 	"context"
 	"os"
+	"strconv"
 
 	"github.com/spf13/cobra"
 
@@ -21,11 +22,19 @@ func main() {
 			databaseURL, _ := cmd.Flags().GetString("database")
 			schemaFile, _ := cmd.Flags().GetString("schema")
 			schemasDir, _ := cmd.Flags().GetString("schemas")
+			wait, _ := cmd.Flags().GetDuration("wait")
+			jsonOut, _ := cmd.Flags().GetBool("json")
+			quiet, _ := cmd.Flags().GetBool("quiet")
+			verbose, _ := cmd.Flags().GetBool("verbose")
 
 			migrateCmd, err := dbcli.NewMigrateCmd([]string{
 				"-database", databaseURL,
 				"-schema", schemaFile,
 				"-schemas", schemasDir,
+				"-wait", wait.String(),
+				"-json=" + strconv.FormatBool(jsonOut),
+				"-quiet=" + strconv.FormatBool(quiet),
+				"-verbose=" + strconv.FormatBool(verbose),
 			})
 			if err != nil {
 				return err
@@ -39,6 +48,10 @@ func main() {
 	cmd.Flags().StringP("database", "d", "", "PostgreSQL connection string (or set DATABASE_URL env var)")
 	cmd.Flags().String("schema", "", "Specific schema file to migrate (optional)")
 	cmd.Flags().String("schemas", "", "Path to schemas directory (optional)")
+	cmd.Flags().Duration("wait", 0, "Wait up to this long for the database to accept connections (e.g. 60s)")
+	cmd.Flags().Bool("json", false, "Print a JSON report of per-schema results instead of human-readable output")
+	cmd.Flags().BoolP("quiet", "q", false, "Only print failures")
+	cmd.Flags().BoolP("verbose", "v", false, "Also print schema file paths and timings")
 
 	if err := cmd.Execute(); err != nil {
 		os.Exit(1)
@@ -72,7 +72,7 @@ const docTemplate = `{
                     },
                     {
                         "type": "integer",
-                        "default": 50,
+                        "default": 25,
                         "description": "Items per page",
                         "name": "pageSize",
                         "in": "query"
@@ -252,7 +252,7 @@ const docTemplate = `{
                     },
                     {
                         "type": "integer",
-                        "default": 50,
+                        "default": 25,
                         "description": "Items per page",
                         "name": "pageSize",
                         "in": "query"
@@ -741,7 +741,7 @@ const docTemplate = `{
                         "Bearer": []
                     }
                 ],
-                "description": "Returns list of configured Discord webhooks",
+                "description": "Returns a page of configured Discord webhooks, newest first, each with the number of successful and failed deliveries in the last 7 days, the time of the latest delivery and the latest failure's error",
                 "produces": [
                     "application/json"
                 ],
@@ -749,6 +749,51 @@ const docTemplate = `{
                     "Admin Settings"
                 ],
                 "summary": "Get webhooks",
+                "parameters": [
+                    {
+                        "enum": [
+                            "ADMIN",
+                            "USER",
+                            "PUBLIC"
+                        ],
+                        "type": "string",
+                        "description": "Filter by scope",
+                        "name": "scope",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "GAME_SERVER",
+                            "VPS",
+                            "SYSTEM",
+                            "BILLING",
+                            "SECURITY",
+                            "SUPPORT",
+                            "CUSTOM"
+                        ],
+                        "type": "string",
+                        "description": "Filter by type",
+                        "name": "type",
+                        "in": "query"
+                    },
+                    {
+                        "maximum": 100,
+                        "minimum": 1,
+                        "type": "integer",
+                        "default": 25,
+                        "description": "Page size (default 25)",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "minimum": 0,
+                        "type": "integer",
+                        "default": 0,
+                        "description": "Offset for pagination (default 0)",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
                 "responses": {
                     "200": {
                         "description": "Webhooks retrieved",
@@ -1549,7 +1594,7 @@ const docTemplate = `{
         },
         "/api/v1/auth/logout": {
             "post": {
-                "description": "Invalidates refresh token and terminates user session",
+                "description": "Invalidates the refresh token (from the body or the refresh_token cookie), clears the cookie and terminates user session",
                 "consumes": [
                     "application/json"
                 ],
@@ -1732,7 +1777,7 @@ const docTemplate = `{
         },
         "/api/v1/auth/refresh": {
             "post": {
-                "description": "Exchanges a valid refresh token for new access and refresh tokens",
+                "description": "Exchanges a valid refresh token, from the body or the refresh_token cookie, for new access and refresh tokens",
                 "consumes": [
                     "application/json"
                 ],
@@ -1745,10 +1790,9 @@ const docTemplate = `{
                 "summary": "Refresh Access Token",
                 "parameters": [
                     {
-                        "description": "Refresh token",
+                        "description": "Refresh token (falls back to the refresh_token cookie)",
                         "name": "refresh",
                         "in": "body",
-                        "required": true,
                         "schema": {
                             "$ref": "#/definitions/handlers.RefreshTokenRequest"
                         }
@@ -2314,7 +2358,7 @@ const docTemplate = `{
                     },
                     {
                         "type": "integer",
-                        "default": 12,
+                        "default": 25,
                         "description": "Items per page",
                         "name": "per_page",
                         "in": "query"
@@ -4451,7 +4495,7 @@ const docTemplate = `{
 
 // SwaggerInfo holds exported Swagger Info so clients can modify it
 var SwaggerInfo = &swag.Spec{
-	Version:          "0.2.0",
+	Version:          "0.3.0",
 	Host:             "core.nodebyte.host",
 	BasePath:         "/",
 	Schemes:          []string{"http", "https"},
@@ -29,6 +29,7 @@ type Claims struct {
 	IsSystemAdmin      bool     `json:"isSystemAdmin"`
 	PterodactylID      *int     `json:"pterodactylId,omitempty"`
 	EmailVerified      *string  `json:"emailVerified,omitempty"`
+	ImpersonatedBy     string   `json:"impersonatedBy,omitempty"` // admin user ID when issued via impersonation
 	jwt.RegisteredClaims
 }
 
@@ -78,6 +79,29 @@ func (s *JWTService) GenerateTokenPair(claims *Claims) (*TokenPair, error) {
 	}, nil
 }
 
+// GenerateImpersonationToken generates a short-lived, non-refreshable access
+// token for claims.UserID on behalf of adminID. The returned token ID (jti)
+// identifies the impersonation session so it can be ended early.
+func (s *JWTService) GenerateImpersonationToken(claims *Claims, adminID string, ttl time.Duration) (token string, tokenID string, err error) {
+	tokenID, err = s.generateRefreshToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
+	claims.ImpersonatedBy = adminID
+	claims.ID = tokenID
+	claims.IssuedAt = jwt.NewNumericDate(now)
+	claims.ExpiresAt = jwt.NewNumericDate(now.Add(ttl))
+	claims.NotBefore = jwt.NewNumericDate(now)
+
+	token, err = jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.secretKey)
+	if err != nil {
+		return "", "", err
+	}
+	return token, tokenID, nil
+}
+
 // ValidateAccessToken validates and parses an access token
 func (s *JWTService) ValidateAccessToken(tokenString string) (*Claims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
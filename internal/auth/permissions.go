@@ -0,0 +1,65 @@
+package auth
+
+import "strings"
+
+// Permissions granted to roles through the role catalog
+const (
+	PermAll = "*"
+
+	PermSyncRead    = "sync.read"
+	PermSyncTrigger = "sync.trigger"
+	PermSyncManage  = "sync.manage"
+
+	PermUsersRead        = "users.read"
+	PermUsersManage      = "users.manage"
+	PermUsersImpersonate = "users.impersonate"
+	PermRolesManage      = "roles.manage"
+
+	PermSettingsRead   = "settings.read"
+	PermSettingsWrite  = "settings.write"
+	PermWebhooksManage = "webhooks.manage"
+
+	PermServersRead   = "servers.read"
+	PermServersManage = "servers.manage"
+	PermNodesRead     = "nodes.read"
+	PermNodesManage   = "nodes.manage"
+	PermEggsRead      = "eggs.read"
+	PermEggsManage    = "eggs.manage"
+	PermStatsRead     = "stats.read"
+	PermHytaleRead    = "hytale.read"
+	PermHytaleManage  = "hytale.manage"
+)
+
+// RoleSuperAdmin is the role that carries the isSystemAdmin flag
+const RoleSuperAdmin = "SUPER_ADMIN"
+
+// HasSuperAdminRole reports whether roles include SUPER_ADMIN
+func HasSuperAdminRole(roles []string) bool {
+	for _, r := range roles {
+		if r == RoleSuperAdmin {
+			return true
+		}
+	}
+	return false
+}
+
+// GrantsRoleManagement reports whether permissions let their holder edit the
+// role catalog ("*", "roles.*" or roles.manage), and so grant themselves
+// anything
+func GrantsRoleManagement(permissions []string) bool {
+	return HasPermission(permissions, PermRolesManage)
+}
+
+// HasPermission reports whether the granted permissions include perm. A
+// granted "*" matches everything and "sync.*" matches every sync permission.
+func HasPermission(granted []string, perm string) bool {
+	for _, g := range granted {
+		if g == PermAll || g == perm {
+			return true
+		}
+		if prefix, ok := strings.CutSuffix(g, ".*"); ok && strings.HasPrefix(perm, prefix+".") {
+			return true
+		}
+	}
+	return false
+}
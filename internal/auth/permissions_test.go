@@ -0,0 +1,56 @@
+package auth
+
+import "testing"
+
+func TestHasPermission(t *testing.T) {
+	tests := []struct {
+		name    string
+		granted []string
+		perm    string
+		want    bool
+	}{
+		{name: "no permissions", granted: nil, perm: PermSyncRead, want: false},
+		{name: "exact match", granted: []string{PermSyncRead}, perm: PermSyncRead, want: true},
+		{name: "different permission", granted: []string{PermSyncRead}, perm: PermSyncTrigger, want: false},
+		{name: "global wildcard", granted: []string{PermAll}, perm: PermRolesManage, want: true},
+		{name: "scoped wildcard", granted: []string{"sync.*"}, perm: PermSyncManage, want: true},
+		{name: "scoped wildcard other scope", granted: []string{"sync.*"}, perm: PermSettingsWrite, want: false},
+		{name: "scoped wildcard needs dot", granted: []string{"users.*"}, perm: "usersx.read", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HasPermission(tt.granted, tt.perm); got != tt.want {
+				t.Errorf("HasPermission(%v, %q) = %v, want %v", tt.granted, tt.perm, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHasSuperAdminRole(t *testing.T) {
+	if HasSuperAdminRole(nil) || HasSuperAdminRole([]string{"MEMBER", "ADMINISTRATOR"}) {
+		t.Error("HasSuperAdminRole reported SUPER_ADMIN for roles without it")
+	}
+	if !HasSuperAdminRole([]string{"MEMBER", RoleSuperAdmin}) {
+		t.Error("HasSuperAdminRole missed SUPER_ADMIN")
+	}
+}
+
+func TestGrantsRoleManagement(t *testing.T) {
+	tests := []struct {
+		permissions []string
+		want        bool
+	}{
+		{permissions: nil, want: false},
+		{permissions: []string{PermUsersManage, PermSettingsWrite}, want: false},
+		{permissions: []string{PermRolesManage}, want: true},
+		{permissions: []string{"roles.*"}, want: true},
+		{permissions: []string{PermAll}, want: true},
+	}
+
+	for _, tt := range tests {
+		if got := GrantsRoleManagement(tt.permissions); got != tt.want {
+			t.Errorf("GrantsRoleManagement(%v) = %v, want %v", tt.permissions, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+// Package buildinfo identifies the running build. Its variables are set at
+// build time with -ldflags, for example
+//
+//	-X github.com/nodebyte/backend/internal/buildinfo.Version=0.3.0
+//
+// and are used for the Fiber app name, the Sentry release, the Swagger info,
+// /health and /api/version so they cannot drift apart.
+package buildinfo
+
+import "runtime/debug"
+
+var (
+	// Version is the application version
+	Version = "0.3.0"
+	// Commit is the git commit the binary was built from
+	Commit string
+	// BuildTime is when the binary was built, in RFC 3339
+	BuildTime string
+)
+
+// Info is the build identity reported by the API
+type Info struct {
+	Version   string
+	Commit    string
+	BuildTime string
+}
+
+// Current returns the build identity. An empty commit or build time is
+// filled from the VCS details the Go toolchain embeds in the binary.
+func Current() Info {
+	info := Info{Version: Version, Commit: Commit, BuildTime: BuildTime}
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return info
+	}
+	for _, s := range bi.Settings {
+		switch {
+		case s.Key == "vcs.revision" && info.Commit == "":
+			info.Commit = s.Value
+		case s.Key == "vcs.time" && info.BuildTime == "":
+			info.BuildTime = s.Value
+		}
+	}
+	return info
+}
@@ -0,0 +1,90 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+)
+
+// Cache stores JSON-encoded values in Redis. A nil *Cache is valid and
+// behaves as an always-empty cache, so callers work without Redis.
+type Cache struct {
+	client redis.UniversalClient
+}
+
+// New creates a cache using the same Redis connection settings as the queue
+func New(opt asynq.RedisClientOpt) *Cache {
+	client, ok := opt.MakeRedisClient().(redis.UniversalClient)
+	if !ok {
+		return nil
+	}
+	return &Cache{client: client}
+}
+
+// Get decodes the value stored at key into dest. It reports false when the
+// key does not exist.
+func (c *Cache) Get(ctx context.Context, key string, dest interface{}) (bool, error) {
+	if c == nil {
+		return false, nil
+	}
+
+	raw, err := c.client.Get(ctx, key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if err := json.Unmarshal(raw, dest); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Set stores value at key for the given duration
+func (c *Cache) Set(ctx context.Context, key string, value interface{}, ttl time.Duration) error {
+	if c == nil {
+		return nil
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return c.client.Set(ctx, key, raw, ttl).Err()
+}
+
+// SetNX stores value at key for the given duration only if key does not
+// already exist, reporting whether it was stored. A nil cache always stores.
+func (c *Cache) SetNX(ctx context.Context, key string, value interface{}, ttl time.Duration) (bool, error) {
+	if c == nil {
+		return true, nil
+	}
+
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return false, err
+	}
+	return c.client.SetNX(ctx, key, raw, ttl).Result()
+}
+
+// Delete removes key. A nil cache does nothing.
+func (c *Cache) Delete(ctx context.Context, key string) error {
+	if c == nil {
+		return nil
+	}
+	return c.client.Del(ctx, key).Err()
+}
+
+// Close releases the underlying Redis connection
+func (c *Cache) Close() error {
+	if c == nil {
+		return nil
+	}
+	return c.client.Close()
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 )
@@ -13,10 +14,16 @@ import (
 type Client struct {
 	conn       *pgx.Conn
 	schemasDir string
+	out        *Output
 }
 
-// New creates a new database client and establishes a connection.
-func New(ctx context.Context, databaseURL, schemasDir string) (*Client, error) {
+// New creates a new database client and establishes a connection. Progress
+// is reported to out, or printed in the default pretty mode when out is nil.
+func New(ctx context.Context, databaseURL, schemasDir string, out *Output) (*Client, error) {
+	if out == nil {
+		out = NewOutput("", OutputPretty, false)
+	}
+
 	conn, err := pgx.Connect(ctx, databaseURL)
 	if err != nil {
 		return nil, fmt.Errorf("connect to database: %w", err)
@@ -34,6 +41,7 @@ func New(ctx context.Context, databaseURL, schemasDir string) (*Client, error) {
 	return &Client{
 		conn:       conn,
 		schemasDir: schemasDir,
+		out:        out,
 	}, nil
 }
 
@@ -45,32 +53,93 @@ func (c *Client) Close(ctx context.Context) error {
 	return nil
 }
 
-// Migrate applies a single schema file to the database.
-func (c *Client) Migrate(ctx context.Context, schemaFile string) error {
+// MigrateAllError reports a failed MigrateAll. Every schema it applied was
+// rolled back.
+type MigrateAllError struct {
+	Failed     string   // schema that failed
+	RolledBack []string // schemas applied before the failure, now rolled back
+	Skipped    []string // schemas after the failure that were not attempted
+	Err        error
+}
+
+func (e *MigrateAllError) Error() string {
+	return fmt.Sprintf("migrate %s: %v", e.Failed, e.Err)
+}
+
+func (e *MigrateAllError) Unwrap() error {
+	return e.Err
+}
+
+// readSchema returns the SQL in a schema file.
+func (c *Client) readSchema(schemaFile string) (string, error) {
 	filePath := filepath.Join(c.schemasDir, schemaFile)
 
 	if _, err := os.Stat(filePath); err != nil {
-		return fmt.Errorf("schema file not found: %s", filePath)
+		return "", fmt.Errorf("schema file not found: %s", filePath)
 	}
 
 	sqlBytes, err := os.ReadFile(filePath)
 	if err != nil {
-		return fmt.Errorf("read schema file: %w", err)
+		return "", fmt.Errorf("read schema file: %w", err)
+	}
+	return string(sqlBytes), nil
+}
+
+// Migrate applies a single schema file to the database.
+func (c *Client) Migrate(ctx context.Context, schemaFile string) error {
+	sql, err := c.readSchema(schemaFile)
+	if err != nil {
+		return err
 	}
 
-	if _, err := c.conn.Exec(ctx, string(sqlBytes)); err != nil {
+	if _, err := c.conn.Exec(ctx, sql); err != nil {
 		return fmt.Errorf("execute schema: %w", err)
 	}
 
 	return nil
 }
 
-// MigrateAll applies all schemas to the database.
+// MigrateAll applies all schemas in a single transaction, reporting each one
+// as it is applied. If any schema fails the whole transaction is rolled back,
+// leaving the database as it was, and a *MigrateAllError is returned.
 func (c *Client) MigrateAll(ctx context.Context) error {
-	for _, schema := range SchemaList {
-		if err := c.Migrate(ctx, schema); err != nil {
-			return fmt.Errorf("migrate %s: %w", schema, err)
+	// Read every file up front so a missing schema fails before any SQL runs
+	sqls := make([]string, len(SchemaList))
+	for i, schema := range SchemaList {
+		sql, err := c.readSchema(schema)
+		if err != nil {
+			c.out.Schema(schema, 0, err)
+			c.out.RolledBack(nil, SchemaList[i+1:])
+			return &MigrateAllError{Failed: schema, Skipped: SchemaList[i+1:], Err: err}
+		}
+		sqls[i] = sql
+	}
+
+	tx, err := c.conn.Begin(ctx)
+	if err != nil {
+		return fmt.Errorf("begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx) // no-op once committed
+
+	for i, schema := range SchemaList {
+		c.out.Verbosef("   %s\n", filepath.Join(c.schemasDir, schema))
+		start := time.Now()
+		if _, err := tx.Exec(ctx, sqls[i]); err != nil {
+			err = fmt.Errorf("execute schema: %w", err)
+			c.out.Schema(schema, time.Since(start), err)
+			c.out.RolledBack(SchemaList[:i], SchemaList[i+1:])
+			return &MigrateAllError{
+				Failed:     schema,
+				RolledBack: SchemaList[:i],
+				Skipped:    SchemaList[i+1:],
+				Err:        err,
+			}
 		}
+		c.out.Schema(schema, time.Since(start), nil)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("commit schemas: %w", err)
 	}
 	return nil
 }
@@ -5,12 +5,15 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"time"
 )
 
 // InitCmd handles database initialization.
 type InitCmd struct {
 	DatabaseURL string
 	SchemasDir  string
+	Wait        time.Duration
+	Out         *Output
 }
 
 // NewInitCmd creates a new init command with parsed flags.
@@ -19,6 +22,8 @@ func NewInitCmd(args []string) (*InitCmd, error) {
 	fs.Usage = func() {}
 	databaseURL := fs.String("database", os.Getenv("DATABASE_URL"), "PostgreSQL connection string")
 	schemasDir := fs.String("schemas", "", "Path to schemas directory (optional)")
+	wait := fs.Duration("wait", 0, "Wait up to this long for the database to accept connections (e.g. 60s)")
+	output := outputFlags(fs, "init")
 
 	if err := fs.Parse(args); err != nil {
 		return nil, err
@@ -31,34 +36,44 @@ func NewInitCmd(args []string) (*InitCmd, error) {
 	return &InitCmd{
 		DatabaseURL: *databaseURL,
 		SchemasDir:  *schemasDir,
+		Wait:        *wait,
+		Out:         output(),
 	}, nil
 }
 
 // Run executes the init command.
 func (c *InitCmd) Run(ctx context.Context) error {
-	client, err := New(ctx, c.DatabaseURL, c.SchemasDir)
+	return c.Out.Finish(c.run(ctx))
+}
+
+func (c *InitCmd) run(ctx context.Context) error {
+	if err := waitForDatabaseURL(ctx, c.Out, c.DatabaseURL, c.Wait); err != nil {
+		return err
+	}
+
+	client, err := New(ctx, c.DatabaseURL, c.SchemasDir, c.Out)
 	if err != nil {
 		return err
 	}
 	defer client.Close(ctx)
 
-	fmt.Println("============================================================================")
-	fmt.Println("NodeByte Database Initialization")
-	fmt.Println("============================================================================")
-	fmt.Println()
-	fmt.Printf("📦 Initializing database with %d schemas...\n", len(SchemaList))
-	fmt.Println()
+	c.Out.Println("============================================================================")
+	c.Out.Println("NodeByte Database Initialization")
+	c.Out.Println("============================================================================")
+	c.Out.Println()
+	c.Out.Printf("📦 Initializing database with %d schemas...\n", len(SchemaList))
+	c.Out.Println()
 
 	if err := client.MigrateAll(ctx); err != nil {
-		fmt.Printf("❌ Migration failed: %v\n", err)
+		c.Out.MigrateAllFailure(err)
 		return err
 	}
 
-	fmt.Println()
-	fmt.Println("============================================================================")
-	fmt.Println("✅ Database initialization complete!")
-	fmt.Println("============================================================================")
-	fmt.Println()
+	c.Out.Println()
+	c.Out.Println("============================================================================")
+	c.Out.Println("✅ Database initialization complete!")
+	c.Out.Println("============================================================================")
+	c.Out.Println()
 
 	return nil
 }
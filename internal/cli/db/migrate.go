@@ -6,8 +6,10 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 )
 
 // MigrateCmd handles database schema migration.
@@ -16,6 +18,8 @@ type MigrateCmd struct {
 	SchemaFile  string
 	SchemasDir  string
 	Interactive bool
+	Wait        time.Duration
+	Out         *Output
 }
 
 // NewMigrateCmd creates a new migrate command with parsed flags.
@@ -25,6 +29,8 @@ func NewMigrateCmd(args []string) (*MigrateCmd, error) {
 	databaseURL := fs.String("database", os.Getenv("DATABASE_URL"), "PostgreSQL connection string")
 	schemaFile := fs.String("schema", "", "Specific schema file to migrate (optional)")
 	schemasDir := fs.String("schemas", "", "Path to schemas directory (optional)")
+	wait := fs.Duration("wait", 0, "Wait up to this long for the database to accept connections (e.g. 60s)")
+	output := outputFlags(fs, "migrate")
 
 	if err := fs.Parse(args); err != nil {
 		return nil, err
@@ -34,32 +40,43 @@ func NewMigrateCmd(args []string) (*MigrateCmd, error) {
 		return nil, fmt.Errorf("no database URL provided (use -database flag or DATABASE_URL env var)")
 	}
 
+	out := output()
 	return &MigrateCmd{
 		DatabaseURL: *databaseURL,
 		SchemaFile:  *schemaFile,
 		SchemasDir:  *schemasDir,
-		Interactive: *schemaFile == "",
+		Interactive: *schemaFile == "" && out.Mode == OutputPretty,
+		Wait:        *wait,
+		Out:         out,
 	}, nil
 }
 
 // Run executes the migrate command.
 func (c *MigrateCmd) Run(ctx context.Context) error {
-	client, err := New(ctx, c.DatabaseURL, c.SchemasDir)
+	return c.Out.Finish(c.run(ctx))
+}
+
+func (c *MigrateCmd) run(ctx context.Context) error {
+	if err := waitForDatabaseURL(ctx, c.Out, c.DatabaseURL, c.Wait); err != nil {
+		return err
+	}
+
+	client, err := New(ctx, c.DatabaseURL, c.SchemasDir, c.Out)
 	if err != nil {
 		return err
 	}
 	defer client.Close(ctx)
 
-	fmt.Println("✅ Connected to database")
-	fmt.Println()
+	c.Out.Println("✅ Connected to database")
+	c.Out.Println()
 
 	if c.SchemaFile != "" {
 		// Migrate specific schema
 		if err := client.ValidateSchema(c.SchemaFile); err != nil {
 			return err
 		}
-		fmt.Printf("📦 Migrating: %s\n", c.SchemaFile)
-		fmt.Println()
+		c.Out.Printf("📦 Migrating: %s\n", c.SchemaFile)
+		c.Out.Println()
 		return c.migrateSingleSchema(ctx, client, c.SchemaFile)
 	}
 
@@ -72,7 +89,7 @@ func (c *MigrateCmd) Run(ctx context.Context) error {
 	selection := c.promptSelection()
 
 	if selection == "0" {
-		fmt.Println("Exiting...")
+		c.Out.Println("Exiting...")
 		return nil
 	}
 
@@ -85,30 +102,29 @@ func (c *MigrateCmd) Run(ctx context.Context) error {
 
 // migrateSingleSchema migrates a single schema with error handling.
 func (c *MigrateCmd) migrateSingleSchema(ctx context.Context, client *Client, schema string) error {
-	if err := client.Migrate(ctx, schema); err != nil {
+	if err := c.migrateSchema(ctx, client, schema); err != nil {
 		return fmt.Errorf("failed to migrate %s: %w", schema, err)
 	}
-	fmt.Printf("✅ Successfully migrated: %s\n", schema)
 	return nil
 }
 
 // migrateAll migrates all available schemas.
 func (c *MigrateCmd) migrateAll(ctx context.Context, client *Client) error {
-	fmt.Println("Migrating all schemas...")
-	fmt.Println()
+	c.Out.Println("Migrating all schemas...")
+	c.Out.Println()
 
-	for _, schema := range SchemaList {
-		if err := c.migrateSchemaSilent(ctx, client, schema); err != nil {
-			fmt.Printf("❌ Failed to migrate %s: %v\n", schema, err)
-			return err
+	for i, schema := range SchemaList {
+		if err := c.migrateSchema(ctx, client, schema); err != nil {
+			c.Out.RolledBack(nil, SchemaList[i+1:])
+			return fmt.Errorf("failed to migrate %s: %w", schema, err)
 		}
 	}
 
-	fmt.Println()
-	fmt.Println("============================================================================")
-	fmt.Println("✅ Migration complete")
-	fmt.Println("============================================================================")
-	fmt.Println()
+	c.Out.Println()
+	c.Out.Println("============================================================================")
+	c.Out.Println("✅ Migration complete")
+	c.Out.Println("============================================================================")
+	c.Out.Println()
 
 	return nil
 }
@@ -116,60 +132,57 @@ func (c *MigrateCmd) migrateAll(ctx context.Context, client *Client) error {
 // migrateSelected migrates schemas based on user selection.
 func (c *MigrateCmd) migrateSelected(ctx context.Context, client *Client, selection string) error {
 	selections := strings.Split(selection, ",")
-	fmt.Println()
+	c.Out.Println()
 
 	for _, sel := range selections {
 		sel = strings.TrimSpace(sel)
 		idx, err := strconv.Atoi(sel)
 		if err != nil || idx < 1 || idx > len(SchemaList) {
-			fmt.Printf("⚠️  Invalid selection: %s\n", sel)
+			c.Out.Printf("⚠️  Invalid selection: %s\n", sel)
 			continue
 		}
 
 		schema := SchemaList[idx-1]
-		if err := c.migrateSchemaSilent(ctx, client, schema); err != nil {
-			fmt.Printf("❌ Failed to migrate %s: %v\n", schema, err)
-			return err
+		if err := c.migrateSchema(ctx, client, schema); err != nil {
+			return fmt.Errorf("failed to migrate %s: %w", schema, err)
 		}
 	}
 
-	fmt.Println()
-	fmt.Println("============================================================================")
-	fmt.Println("✅ Migration complete")
-	fmt.Println("============================================================================")
-	fmt.Println()
+	c.Out.Println()
+	c.Out.Println("============================================================================")
+	c.Out.Println("✅ Migration complete")
+	c.Out.Println("============================================================================")
+	c.Out.Println()
 
 	return nil
 }
 
-// migrateSchemaSilent migrates a schema with minimal output.
-func (c *MigrateCmd) migrateSchemaSilent(ctx context.Context, client *Client, schema string) error {
-	fmt.Printf("📦 %s ... ", schema)
-	if err := client.Migrate(ctx, schema); err != nil {
-		fmt.Printf("❌\n   Error: %v\n", err)
-		return err
-	}
-	fmt.Println("✅")
-	return nil
+// migrateSchema applies one schema and reports its result and duration.
+func (c *MigrateCmd) migrateSchema(ctx context.Context, client *Client, schema string) error {
+	c.Out.Verbosef("   %s\n", filepath.Join(client.schemasDir, schema))
+	start := time.Now()
+	err := client.Migrate(ctx, schema)
+	c.Out.Schema(schema, time.Since(start), err)
+	return err
 }
 
 // promptSelection displays the migration menu and reads user input.
 func (c *MigrateCmd) promptSelection() string {
-	fmt.Println("============================================================================")
-	fmt.Println("NodeByte Schema Migration")
-	fmt.Println("============================================================================")
-	fmt.Println()
-	fmt.Println("Available schemas:")
-	fmt.Println()
+	c.Out.Println("============================================================================")
+	c.Out.Println("NodeByte Schema Migration")
+	c.Out.Println("============================================================================")
+	c.Out.Println()
+	c.Out.Println("Available schemas:")
+	c.Out.Println()
 
 	for i, schema := range SchemaList {
-		fmt.Printf("  [%d] %s\n", i+1, schema)
+		c.Out.Printf("  [%d] %s\n", i+1, schema)
 	}
-	fmt.Println("  [0] Exit")
-	fmt.Println()
-	fmt.Println("Which schema(s) would you like to migrate?")
-	fmt.Println("Enter schema numbers (comma-separated) or press Enter to migrate all:")
-	fmt.Print("> ")
+	c.Out.Println("  [0] Exit")
+	c.Out.Println()
+	c.Out.Println("Which schema(s) would you like to migrate?")
+	c.Out.Println("Enter schema numbers (comma-separated) or press Enter to migrate all:")
+	c.Out.Prompt("> ")
 
 	reader := bufio.NewReader(os.Stdin)
 	input, _ := reader.ReadString('\n')
@@ -0,0 +1,269 @@
+package db
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// OutputMode selects how the db tool reports progress and results.
+type OutputMode int
+
+const (
+	OutputPretty OutputMode = iota // banners and per-schema checkmarks (default)
+	OutputQuiet                    // only failures, on stderr
+	OutputJSON                     // a single JSON report on stdout when the command finishes
+)
+
+// Per-schema result statuses.
+const (
+	SchemaApplied    = "applied"
+	SchemaFailed     = "failed"
+	SchemaRolledBack = "rolled_back"
+	SchemaSkipped    = "skipped"
+	SchemaVerified   = "verified"
+	SchemaDrifted    = "drifted" // verify found objects the schema creates missing
+)
+
+// SchemaResult is the outcome of applying one schema.
+type SchemaResult struct {
+	Schema     string   `json:"schema"`
+	Status     string   `json:"status"`
+	Error      string   `json:"error,omitempty"`
+	Missing    []string `json:"missing,omitempty"` // objects verify could not find
+	DurationMs int64    `json:"durationMs"`
+}
+
+// Report is the JSON document written in OutputJSON mode.
+type Report struct {
+	Command    string         `json:"command"`
+	Success    bool           `json:"success"`
+	Schemas    []SchemaResult `json:"schemas"`
+	Unexpected []string       `json:"unexpected,omitempty"` // objects verify found that no schema creates
+	Error      string         `json:"error,omitempty"`
+	DurationMs int64          `json:"durationMs"`
+}
+
+// Output writes db tool progress in the selected mode and collects
+// per-schema results for the JSON report.
+type Output struct {
+	Mode    OutputMode
+	Verbose bool
+
+	command string
+	started time.Time
+	stdout  io.Writer
+	stderr  io.Writer
+	schemas []SchemaResult
+	extra   []string
+}
+
+// NewOutput creates an output for command in the given mode.
+func NewOutput(command string, mode OutputMode, verbose bool) *Output {
+	return &Output{
+		Mode:    mode,
+		Verbose: verbose,
+		command: command,
+		started: time.Now(),
+		stdout:  os.Stdout,
+		stderr:  os.Stderr,
+		schemas: []SchemaResult{},
+	}
+}
+
+// outputFlags registers -json, -quiet and -verbose on fs. The returned
+// function builds the Output once fs has been parsed.
+func outputFlags(fs *flag.FlagSet, command string) func() *Output {
+	jsonOut := fs.Bool("json", false, "Print a JSON report of per-schema results instead of human-readable output")
+	quiet := fs.Bool("quiet", false, "Only print failures")
+	verbose := fs.Bool("verbose", false, "Also print schema file paths and timings")
+
+	return func() *Output {
+		mode := OutputPretty
+		switch {
+		case *jsonOut:
+			mode = OutputJSON
+		case *quiet:
+			mode = OutputQuiet
+		}
+		return NewOutput(command, mode, *verbose)
+	}
+}
+
+// Println prints decorative output, shown only in pretty mode.
+func (o *Output) Println(a ...any) {
+	if o.Mode == OutputPretty {
+		fmt.Fprintln(o.stdout, a...)
+	}
+}
+
+// Printf prints decorative output, shown only in pretty mode.
+func (o *Output) Printf(format string, a ...any) {
+	if o.Mode == OutputPretty {
+		fmt.Fprintf(o.stdout, format, a...)
+	}
+}
+
+// Verbosef prints detail shown only in pretty mode with -verbose.
+func (o *Output) Verbosef(format string, a ...any) {
+	if o.Mode == OutputPretty && o.Verbose {
+		fmt.Fprintf(o.stdout, format, a...)
+	}
+}
+
+// Prompt prints an interactive prompt, on stderr in JSON mode so the report
+// on stdout stays parseable.
+func (o *Output) Prompt(format string, a ...any) {
+	w := o.stdout
+	if o.Mode == OutputJSON {
+		w = o.stderr
+	}
+	fmt.Fprintf(w, format, a...)
+}
+
+// Schema records the result of applying a schema and reports it.
+func (o *Output) Schema(schema string, duration time.Duration, err error) {
+	result := SchemaResult{Schema: schema, Status: SchemaApplied, DurationMs: duration.Milliseconds()}
+	if err != nil {
+		result.Status = SchemaFailed
+		result.Error = err.Error()
+	}
+	o.schemas = append(o.schemas, result)
+
+	switch o.Mode {
+	case OutputPretty:
+		if err != nil {
+			fmt.Fprintf(o.stdout, "❌ %s\n   Error: %v\n", schema, err)
+			return
+		}
+		if o.Verbose {
+			fmt.Fprintf(o.stdout, "✅ %s (%s)\n", schema, duration.Round(time.Millisecond))
+			return
+		}
+		fmt.Fprintf(o.stdout, "✅ %s\n", schema)
+	case OutputQuiet:
+		if err != nil {
+			fmt.Fprintf(o.stderr, "FAILED %s: %v\n", schema, err)
+		}
+	}
+}
+
+// RolledBack marks schemas reported as applied as rolled back, and records
+// schemas that were never attempted.
+func (o *Output) RolledBack(rolledBack, skipped []string) {
+	undone := make(map[string]bool, len(rolledBack))
+	for _, s := range rolledBack {
+		undone[s] = true
+	}
+	for i := range o.schemas {
+		if o.schemas[i].Status == SchemaApplied && undone[o.schemas[i].Schema] {
+			o.schemas[i].Status = SchemaRolledBack
+		}
+	}
+	for _, s := range skipped {
+		o.schemas = append(o.schemas, SchemaResult{Schema: s, Status: SchemaSkipped})
+	}
+}
+
+// Verified records the objects of a schema that verify could not find and
+// reports them.
+func (o *Output) Verified(schema string, missing []string) {
+	result := SchemaResult{Schema: schema, Status: SchemaVerified}
+	if len(missing) > 0 {
+		result.Status = SchemaDrifted
+		result.Missing = missing
+	}
+	o.schemas = append(o.schemas, result)
+
+	switch o.Mode {
+	case OutputPretty:
+		if len(missing) == 0 {
+			fmt.Fprintf(o.stdout, "✅ %s\n", schema)
+			return
+		}
+		fmt.Fprintf(o.stdout, "❌ %s\n", schema)
+		for _, object := range missing {
+			fmt.Fprintf(o.stdout, "   Missing %s\n", object)
+		}
+	case OutputQuiet:
+		for _, object := range missing {
+			fmt.Fprintf(o.stderr, "MISSING %s: %s\n", schema, object)
+		}
+	}
+}
+
+// Unexpected records database objects no schema creates and reports them.
+// They are shown as warnings when allowed.
+func (o *Output) Unexpected(objects []string, allowed bool) {
+	o.extra = objects
+	if len(objects) == 0 {
+		return
+	}
+
+	switch o.Mode {
+	case OutputPretty:
+		mark := "❌"
+		if allowed {
+			mark = "⚠️ "
+		}
+		fmt.Fprintln(o.stdout)
+		fmt.Fprintf(o.stdout, "%s Unexpected objects (%d):\n", mark, len(objects))
+		for _, object := range objects {
+			fmt.Fprintf(o.stdout, "   %s\n", object)
+		}
+	case OutputQuiet:
+		if allowed {
+			return
+		}
+		for _, object := range objects {
+			fmt.Fprintf(o.stderr, "UNEXPECTED %s\n", object)
+		}
+	}
+}
+
+// MigrateAllFailure explains what a failed MigrateAll left behind.
+func (o *Output) MigrateAllFailure(err error) {
+	var migrateErr *MigrateAllError
+	if !errors.As(err, &migrateErr) {
+		return
+	}
+
+	o.Println()
+	o.Printf("❌ %s failed; nothing was applied\n", migrateErr.Failed)
+	if len(migrateErr.RolledBack) > 0 {
+		o.Printf("   Rolled back (%d): %s\n", len(migrateErr.RolledBack), strings.Join(migrateErr.RolledBack, ", "))
+	}
+	if len(migrateErr.Skipped) > 0 {
+		o.Printf("   Not attempted (%d): %s\n", len(migrateErr.Skipped), strings.Join(migrateErr.Skipped, ", "))
+	}
+}
+
+// Finish writes the JSON report in JSON mode and returns err unchanged.
+func (o *Output) Finish(err error) error {
+	if o.Mode != OutputJSON {
+		return err
+	}
+
+	report := Report{
+		Command:    o.command,
+		Success:    err == nil,
+		Schemas:    o.schemas,
+		Unexpected: o.extra,
+		DurationMs: time.Since(o.started).Milliseconds(),
+	}
+	if err != nil {
+		report.Error = err.Error()
+	}
+
+	enc := json.NewEncoder(o.stdout)
+	enc.SetIndent("", "  ")
+	if encErr := enc.Encode(report); encErr != nil && err == nil {
+		return encErr
+	}
+	return err
+}
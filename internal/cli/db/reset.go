@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/jackc/pgx/v5"
 )
@@ -15,6 +16,8 @@ import (
 type ResetCmd struct {
 	DatabaseURL string
 	SchemasDir  string
+	Wait        time.Duration
+	Out         *Output
 }
 
 // NewResetCmd creates a new reset command with parsed flags.
@@ -23,6 +26,8 @@ func NewResetCmd(args []string) (*ResetCmd, error) {
 	fs.Usage = func() {}
 	databaseURL := fs.String("database", os.Getenv("DATABASE_URL"), "PostgreSQL connection string")
 	schemasDir := fs.String("schemas", "", "Path to schemas directory (optional)")
+	wait := fs.Duration("wait", 0, "Wait up to this long for the database to accept connections (e.g. 60s)")
+	output := outputFlags(fs, "reset")
 
 	if err := fs.Parse(args); err != nil {
 		return nil, err
@@ -35,11 +40,17 @@ func NewResetCmd(args []string) (*ResetCmd, error) {
 	return &ResetCmd{
 		DatabaseURL: *databaseURL,
 		SchemasDir:  *schemasDir,
+		Wait:        *wait,
+		Out:         output(),
 	}, nil
 }
 
 // Run executes the reset command.
 func (c *ResetCmd) Run(ctx context.Context) error {
+	return c.Out.Finish(c.run(ctx))
+}
+
+func (c *ResetCmd) run(ctx context.Context) error {
 	// Parse database name from connection string
 	connConfig, err := pgx.ParseConfig(c.DatabaseURL)
 	if err != nil {
@@ -50,26 +61,29 @@ func (c *ResetCmd) Run(ctx context.Context) error {
 
 	// Confirm before resetting
 	if !c.confirmReset(dbName) {
-		fmt.Println("❌ Confirmation failed. Database not reset.")
+		c.Out.Println("❌ Confirmation failed. Database not reset.")
 		return nil
 	}
 
 	// Connect to postgres (not the target database)
 	pgConfig := *connConfig
 	pgConfig.Database = "postgres"
+	if err := WaitForDatabase(ctx, c.Out, &pgConfig, c.Wait); err != nil {
+		return err
+	}
 	conn, err := pgx.ConnectConfig(ctx, &pgConfig)
 	if err != nil {
 		return fmt.Errorf("connect to PostgreSQL: %w", err)
 	}
 	defer conn.Close(ctx)
 
-	fmt.Println()
-	fmt.Printf("🔄 Dropping database '%s'...\n", dbName)
+	c.Out.Println()
+	c.Out.Printf("🔄 Dropping database '%s'...\n", dbName)
 	if _, err := conn.Exec(ctx, fmt.Sprintf("DROP DATABASE IF EXISTS %s;", dbName)); err != nil {
 		return fmt.Errorf("drop database: %w", err)
 	}
 
-	fmt.Printf("🔄 Creating database '%s'...\n", dbName)
+	c.Out.Printf("🔄 Creating database '%s'...\n", dbName)
 	if _, err := conn.Exec(ctx, fmt.Sprintf("CREATE DATABASE %s;", dbName)); err != nil {
 		return fmt.Errorf("create database: %w", err)
 	}
@@ -81,13 +95,14 @@ func (c *ResetCmd) Run(ctx context.Context) error {
 	}
 	defer newConn.Close(ctx)
 
-	fmt.Println()
-	fmt.Printf("📦 Initializing database with %d schemas...\n", len(SchemaList))
-	fmt.Println()
+	c.Out.Println()
+	c.Out.Printf("📦 Initializing database with %d schemas...\n", len(SchemaList))
+	c.Out.Println()
 
 	client := &Client{
 		conn:       newConn,
 		schemasDir: findSchemasDir(),
+		out:        c.Out,
 	}
 
 	if client.schemasDir == "" && c.SchemasDir != "" {
@@ -98,27 +113,24 @@ func (c *ResetCmd) Run(ctx context.Context) error {
 		return fmt.Errorf("schemas directory not found")
 	}
 
-	for _, schema := range SchemaList {
-		if err := client.Migrate(ctx, schema); err != nil {
-			fmt.Printf("❌ %s\n   Error: %v\n", schema, err)
-			return err
-		}
-		fmt.Printf("✅ %s\n", schema)
+	if err := client.MigrateAll(ctx); err != nil {
+		c.Out.MigrateAllFailure(err)
+		return err
 	}
 
-	fmt.Println()
-	fmt.Println("============================================================================")
-	fmt.Println("✅ Database reset and initialization complete!")
-	fmt.Println("============================================================================")
-	fmt.Println()
+	c.Out.Println()
+	c.Out.Println("============================================================================")
+	c.Out.Println("✅ Database reset and initialization complete!")
+	c.Out.Println("============================================================================")
+	c.Out.Println()
 
 	return nil
 }
 
 // confirmReset prompts the user to confirm database reset.
 func (c *ResetCmd) confirmReset(dbName string) bool {
-	fmt.Printf("⚠️  WARNING: This will DROP and recreate the database '%s'\n", dbName)
-	fmt.Print("Are you SURE? Type the database name to confirm: ")
+	c.Out.Printf("⚠️  WARNING: This will DROP and recreate the database '%s'\n", dbName)
+	c.Out.Prompt("Are you SURE? Type the database name to confirm: ")
 
 	reader := bufio.NewReader(os.Stdin)
 	input, _ := reader.ReadString('\n')
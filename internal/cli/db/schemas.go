@@ -17,4 +17,32 @@ var SchemaList = []string{
 	"schema_13_hytale_server_link.sql",
 	"schema_14_partners.sql",
 	"schema_15_careers.sql",
+	"schema_16_admin_audit.sql",
+	"schema_17_roles.sql",
+	"schema_18_server_status_events.sql",
+	"schema_19_invites.sql",
+	"schema_20_server_tags.sql",
+	"schema_21_sync_changes.sql",
+	"schema_22_api_keys.sql",
+	"schema_23_server_resource_snapshots.sql",
+	"schema_24_plans.sql",
+	"schema_25_egg_variable_overrides.sql",
+	"schema_26_server_suspension.sql",
+	"schema_27_server_tombstones.sql",
+	"schema_28_notification_preferences.sql",
+	"schema_29_notifications.sql",
+	"schema_30_server_admin_notes.sql",
+	"schema_31_sync_failure_streak.sql",
+	"schema_32_webhook_deliveries.sql",
+	"schema_33_user_sync_conflicts.sql",
+	"schema_34_server_primary_address.sql",
+	"schema_35_sync_confirmations.sql",
+	"schema_36_hytale_account_owner.sql",
+	"schema_37_user_pending_email.sql",
+	"schema_38_egg_image_startup.sql",
+	"schema_39_password_reset_required.sql",
+	"schema_40_webhook_templates.sql",
+	"schema_41_hytale_server_log_level.sql",
+	"schema_42_sync_confirmation_actor.sql",
+	"schema_43_hytale_audit_columns.sql",
 }
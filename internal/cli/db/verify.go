@@ -0,0 +1,475 @@
+package db
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// SchemaObjects are the tables, columns and indexes a set of schema files
+// creates, keyed by name. Each object records the schema file that created it.
+type SchemaObjects struct {
+	Columns map[string]map[string]string // table -> column -> schema file
+	Indexes map[string]string            // index -> schema file
+}
+
+// NewSchemaObjects returns an empty object set.
+func NewSchemaObjects() *SchemaObjects {
+	return &SchemaObjects{
+		Columns: map[string]map[string]string{},
+		Indexes: map[string]string{},
+	}
+}
+
+var (
+	createTableRe = regexp.MustCompile(`(?is)^CREATE\s+TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?(` + identPattern + `)\s*\(`)
+	alterTableRe  = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+(?:IF\s+EXISTS\s+)?(?:ONLY\s+)?(` + identPattern + `)\s+(.*)$`)
+	createIndexRe = regexp.MustCompile(`(?is)^CREATE\s+(?:UNIQUE\s+)?INDEX\s+(?:CONCURRENTLY\s+)?(?:IF\s+NOT\s+EXISTS\s+)?(` + identPattern + `)\s+ON\s+(?:ONLY\s+)?(` + identPattern + `)`)
+	dropIndexRe   = regexp.MustCompile(`(?is)^DROP\s+INDEX\s+(?:CONCURRENTLY\s+)?(?:IF\s+EXISTS\s+)?(.*?)(?:\s+(?:CASCADE|RESTRICT))?$`)
+	dropTableRe   = regexp.MustCompile(`(?is)^DROP\s+TABLE\s+(?:IF\s+EXISTS\s+)?(.*?)(?:\s+(?:CASCADE|RESTRICT))?$`)
+
+	addColumnRe    = regexp.MustCompile(`(?is)^ADD\s+(?:COLUMN\s+)?(?:IF\s+NOT\s+EXISTS\s+)?(` + identPattern + `)`)
+	dropColumnRe   = regexp.MustCompile(`(?is)^DROP\s+(?:COLUMN\s+)?(?:IF\s+EXISTS\s+)?(` + identPattern + `)`)
+	renameColumnRe = regexp.MustCompile(`(?is)^RENAME\s+(?:COLUMN\s+)?(` + identPattern + `)\s+TO\s+(` + identPattern + `)$`)
+	renameTableRe  = regexp.MustCompile(`(?is)^RENAME\s+TO\s+(` + identPattern + `)$`)
+)
+
+// identPattern matches a plain or double-quoted SQL identifier.
+const identPattern = `"[^"]+"|[A-Za-z_][A-Za-z0-9_$.]*`
+
+// tableConstraintKeywords start table constraints rather than columns in a
+// CREATE TABLE body or ALTER TABLE ADD action.
+var tableConstraintKeywords = map[string]bool{
+	"CONSTRAINT": true, "PRIMARY": true, "UNIQUE": true, "FOREIGN": true,
+	"CHECK": true, "EXCLUDE": true, "LIKE": true,
+}
+
+// Parse adds the objects created by sql, the contents of schema file, and
+// removes the ones it drops. Statements are applied in order, so renames and
+// drops in later files are reflected.
+func (o *SchemaObjects) Parse(schema, sql string) {
+	for _, stmt := range splitStatements(sql) {
+		switch {
+		case createTableRe.MatchString(stmt):
+			m := createTableRe.FindStringSubmatchIndex(stmt)
+			table := normalizeIdent(stmt[m[2]:m[3]])
+			if _, exists := o.Columns[table]; exists {
+				continue // IF NOT EXISTS leaves the first definition in place
+			}
+			columns := map[string]string{}
+			body := stmt[m[1]:]
+			if end := strings.LastIndex(body, ")"); end >= 0 {
+				body = body[:end]
+			}
+			for _, item := range splitTopLevel(body, ',') {
+				if name, ok := columnName(item); ok {
+					columns[name] = schema
+				}
+			}
+			o.Columns[table] = columns
+
+		case createIndexRe.MatchString(stmt):
+			m := createIndexRe.FindStringSubmatch(stmt)
+			o.Indexes[normalizeIdent(m[1])] = schema
+
+		case dropIndexRe.MatchString(stmt):
+			for _, name := range splitTopLevel(dropIndexRe.FindStringSubmatch(stmt)[1], ',') {
+				delete(o.Indexes, normalizeIdent(name))
+			}
+
+		case dropTableRe.MatchString(stmt):
+			for _, name := range splitTopLevel(dropTableRe.FindStringSubmatch(stmt)[1], ',') {
+				delete(o.Columns, normalizeIdent(name))
+			}
+
+		case alterTableRe.MatchString(stmt):
+			m := alterTableRe.FindStringSubmatch(stmt)
+			o.alterTable(schema, normalizeIdent(m[1]), m[2])
+		}
+	}
+}
+
+// alterTable applies the column changes of an ALTER TABLE statement.
+func (o *SchemaObjects) alterTable(schema, table, actions string) {
+	if m := renameTableRe.FindStringSubmatch(strings.TrimSpace(actions)); m != nil {
+		if columns, ok := o.Columns[table]; ok {
+			delete(o.Columns, table)
+			o.Columns[normalizeIdent(m[1])] = columns
+		}
+		return
+	}
+
+	columns, ok := o.Columns[table]
+	if !ok {
+		columns = map[string]string{}
+		o.Columns[table] = columns
+	}
+	for _, action := range splitTopLevel(actions, ',') {
+		action = strings.TrimSpace(action)
+		switch {
+		case renameColumnRe.MatchString(action):
+			m := renameColumnRe.FindStringSubmatch(action)
+			from, to := normalizeIdent(m[1]), normalizeIdent(m[2])
+			if source, ok := columns[from]; ok {
+				delete(columns, from)
+				columns[to] = source
+			}
+		case addColumnRe.MatchString(action):
+			name := addColumnRe.FindStringSubmatch(action)[1]
+			if !tableConstraintKeywords[strings.ToUpper(name)] {
+				columns[normalizeIdent(name)] = schema
+			}
+		case dropColumnRe.MatchString(action):
+			name := dropColumnRe.FindStringSubmatch(action)[1]
+			if !tableConstraintKeywords[strings.ToUpper(name)] {
+				delete(columns, normalizeIdent(name))
+			}
+		}
+	}
+}
+
+// columnName returns the column defined by a CREATE TABLE body item, or
+// false for table constraints.
+func columnName(item string) (string, bool) {
+	fields := strings.Fields(item)
+	if len(fields) == 0 || tableConstraintKeywords[strings.ToUpper(fields[0])] {
+		return "", false
+	}
+	return normalizeIdent(fields[0]), true
+}
+
+// normalizeIdent folds an identifier the way PostgreSQL does: quoted names
+// keep their case, unquoted ones are lowercased. A schema prefix is dropped.
+func normalizeIdent(ident string) string {
+	ident = strings.TrimSpace(ident)
+	if strings.HasPrefix(ident, `"`) && strings.HasSuffix(ident, `"`) && len(ident) >= 2 {
+		return ident[1 : len(ident)-1]
+	}
+	if i := strings.LastIndex(ident, "."); i >= 0 {
+		ident = ident[i+1:]
+	}
+	return strings.ToLower(ident)
+}
+
+// splitStatements strips comments from sql and splits it into statements on
+// semicolons outside quotes and dollar-quoted bodies.
+func splitStatements(sql string) []string {
+	var statements []string
+	var current strings.Builder
+	inString, inIdent := false, false
+	dollarTag := ""
+
+	flush := func() {
+		if stmt := strings.TrimSpace(current.String()); stmt != "" {
+			statements = append(statements, stmt)
+		}
+		current.Reset()
+	}
+
+	for i := 0; i < len(sql); i++ {
+		ch := sql[i]
+		switch {
+		case dollarTag != "":
+			if strings.HasPrefix(sql[i:], dollarTag) {
+				current.WriteString(dollarTag)
+				i += len(dollarTag) - 1
+				dollarTag = ""
+				continue
+			}
+		case inString:
+			inString = ch != '\''
+		case inIdent:
+			inIdent = ch != '"'
+		case ch == '-' && strings.HasPrefix(sql[i:], "--"):
+			for i < len(sql) && sql[i] != '\n' {
+				i++
+			}
+			current.WriteByte('\n')
+			continue
+		case ch == '\'':
+			inString = true
+		case ch == '"':
+			inIdent = true
+		case ch == '$':
+			if end := strings.IndexByte(sql[i+1:], '$'); end >= 0 && isDollarTag(sql[i+1:i+1+end]) {
+				dollarTag = sql[i : i+end+2]
+				current.WriteString(dollarTag)
+				i += end + 1
+				continue
+			}
+		case ch == ';':
+			flush()
+			continue
+		}
+		current.WriteByte(ch)
+	}
+	flush()
+	return statements
+}
+
+// isDollarTag reports whether tag can appear between the dollars of a
+// dollar-quote opener
+func isDollarTag(tag string) bool {
+	for _, r := range tag {
+		if !(r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// splitTopLevel splits s on sep outside parentheses and quotes.
+func splitTopLevel(s string, sep byte) []string {
+	var parts []string
+	depth, start := 0, 0
+	inString, inIdent := false, false
+	for i := 0; i < len(s); i++ {
+		switch ch := s[i]; {
+		case inString:
+			inString = ch != '\''
+		case inIdent:
+			inIdent = ch != '"'
+		case ch == '\'':
+			inString = true
+		case ch == '"':
+			inIdent = true
+		case ch == '(':
+			depth++
+		case ch == ')':
+			depth--
+		case ch == sep && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	parts = append(parts, s[start:])
+	return parts
+}
+
+// Drift lists the differences between the expected schema objects and the
+// database.
+type Drift struct {
+	Missing    map[string][]string // schema file -> objects it creates that are missing
+	Unexpected []string            // objects in the database no schema creates
+}
+
+// HasMismatch reports whether any expected object is missing, or, unless
+// allowExtra is set, whether the database has unexpected objects.
+func (d *Drift) HasMismatch(allowExtra bool) bool {
+	return len(d.Missing) > 0 || (!allowExtra && len(d.Unexpected) > 0)
+}
+
+// ExpectedObjects parses every schema in SchemaList.
+func (c *Client) ExpectedObjects() (*SchemaObjects, error) {
+	objects := NewSchemaObjects()
+	for _, schema := range SchemaList {
+		sql, err := c.readSchema(schema)
+		if err != nil {
+			return nil, err
+		}
+		objects.Parse(schema, sql)
+	}
+	return objects, nil
+}
+
+// Verify compares the tables, columns and indexes in the current schema of
+// the database with the ones the schema files create. Indexes that back
+// constraints are created implicitly and are not compared.
+func (c *Client) Verify(ctx context.Context) (*Drift, error) {
+	expected, err := c.ExpectedObjects()
+	if err != nil {
+		return nil, err
+	}
+
+	actualColumns := map[string]map[string]bool{}
+	rows, err := c.conn.Query(ctx, `
+		SELECT c.table_name, c.column_name
+		FROM information_schema.columns c
+		JOIN information_schema.tables t
+			ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+		WHERE c.table_schema = current_schema() AND t.table_type = 'BASE TABLE'
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("list columns: %w", err)
+	}
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("list columns: %w", err)
+		}
+		if actualColumns[table] == nil {
+			actualColumns[table] = map[string]bool{}
+		}
+		actualColumns[table][column] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list columns: %w", err)
+	}
+
+	actualIndexes := map[string]bool{}
+	rows, err = c.conn.Query(ctx, `
+		SELECT i.relname
+		FROM pg_index x
+		JOIN pg_class i ON i.oid = x.indexrelid
+		JOIN pg_namespace n ON n.oid = i.relnamespace
+		WHERE n.nspname = current_schema()
+		  AND NOT EXISTS (SELECT 1 FROM pg_constraint k WHERE k.conindid = x.indexrelid)
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("list indexes: %w", err)
+	}
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("list indexes: %w", err)
+		}
+		actualIndexes[name] = true
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list indexes: %w", err)
+	}
+
+	return compareObjects(expected, actualColumns, actualIndexes), nil
+}
+
+// compareObjects diffs the expected objects against the database's.
+func compareObjects(expected *SchemaObjects, actualColumns map[string]map[string]bool, actualIndexes map[string]bool) *Drift {
+	drift := &Drift{Missing: map[string][]string{}, Unexpected: []string{}}
+	missing := func(schema, object string) {
+		drift.Missing[schema] = append(drift.Missing[schema], object)
+	}
+
+	for table, columns := range expected.Columns {
+		actual, exists := actualColumns[table]
+		for column, schema := range columns {
+			switch {
+			case !exists:
+				missing(schema, "column "+table+"."+column+" (table "+table+" does not exist)")
+			case !actual[column]:
+				missing(schema, "column "+table+"."+column)
+			}
+		}
+	}
+	for index, schema := range expected.Indexes {
+		if !actualIndexes[index] {
+			missing(schema, "index "+index)
+		}
+	}
+
+	for table, columns := range actualColumns {
+		wanted, known := expected.Columns[table]
+		if !known {
+			drift.Unexpected = append(drift.Unexpected, "table "+table)
+			continue
+		}
+		for column := range columns {
+			if _, ok := wanted[column]; !ok {
+				drift.Unexpected = append(drift.Unexpected, "column "+table+"."+column)
+			}
+		}
+	}
+	for index := range actualIndexes {
+		if _, ok := expected.Indexes[index]; !ok {
+			drift.Unexpected = append(drift.Unexpected, "index "+index)
+		}
+	}
+
+	for schema := range drift.Missing {
+		sort.Strings(drift.Missing[schema])
+	}
+	sort.Strings(drift.Unexpected)
+	return drift
+}
+
+// VerifyCmd checks the database against the objects the schemas create.
+type VerifyCmd struct {
+	DatabaseURL string
+	SchemasDir  string
+	AllowExtra  bool
+	Wait        time.Duration
+	Out         *Output
+}
+
+// NewVerifyCmd creates a new verify command with parsed flags.
+func NewVerifyCmd(args []string) (*VerifyCmd, error) {
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	fs.Usage = func() {}
+	databaseURL := fs.String("database", os.Getenv("DATABASE_URL"), "PostgreSQL connection string")
+	schemasDir := fs.String("schemas", "", "Path to schemas directory (optional)")
+	allowExtra := fs.Bool("allow-extra", false, "Report objects no schema creates without failing")
+	wait := fs.Duration("wait", 0, "Wait up to this long for the database to accept connections (e.g. 60s)")
+	output := outputFlags(fs, "verify")
+
+	if err := fs.Parse(args); err != nil {
+		return nil, err
+	}
+
+	if *databaseURL == "" {
+		return nil, fmt.Errorf("no database URL provided (use -database flag or DATABASE_URL env var)")
+	}
+
+	return &VerifyCmd{
+		DatabaseURL: *databaseURL,
+		SchemasDir:  *schemasDir,
+		AllowExtra:  *allowExtra,
+		Wait:        *wait,
+		Out:         output(),
+	}, nil
+}
+
+// Run executes the verify command.
+func (c *VerifyCmd) Run(ctx context.Context) error {
+	return c.Out.Finish(c.run(ctx))
+}
+
+func (c *VerifyCmd) run(ctx context.Context) error {
+	if err := waitForDatabaseURL(ctx, c.Out, c.DatabaseURL, c.Wait); err != nil {
+		return err
+	}
+
+	client, err := New(ctx, c.DatabaseURL, c.SchemasDir, c.Out)
+	if err != nil {
+		return err
+	}
+	defer client.Close(ctx)
+
+	c.Out.Println("============================================================================")
+	c.Out.Println("NodeByte Schema Verification")
+	c.Out.Println("============================================================================")
+	c.Out.Println()
+
+	drift, err := client.Verify(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, schema := range SchemaList {
+		c.Out.Verified(schema, drift.Missing[schema])
+	}
+	c.Out.Unexpected(drift.Unexpected, c.AllowExtra)
+
+	if drift.HasMismatch(c.AllowExtra) {
+		missing := 0
+		for _, objects := range drift.Missing {
+			missing += len(objects)
+		}
+		return fmt.Errorf("schema mismatch: %d missing, %d unexpected objects", missing, len(drift.Unexpected))
+	}
+
+	c.Out.Println()
+	c.Out.Println("============================================================================")
+	c.Out.Println("✅ Database matches the schemas")
+	c.Out.Println("============================================================================")
+	c.Out.Println()
+
+	return nil
+}
@@ -0,0 +1,82 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Backoff between readiness checks while waiting for the database
+const (
+	waitInitialBackoff = 500 * time.Millisecond
+	waitMaxBackoff     = 5 * time.Second
+)
+
+// WaitForDatabase pings the database with SELECT 1 until it answers or
+// timeout passes, backing off between attempts. A zero timeout returns
+// immediately without checking, leaving connection errors to the caller.
+func WaitForDatabase(ctx context.Context, out *Output, connConfig *pgx.ConnConfig, timeout time.Duration) error {
+	if timeout <= 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	out.Printf("⏳ Waiting up to %s for database at %s:%d...\n", timeout, connConfig.Host, connConfig.Port)
+
+	backoff := waitInitialBackoff
+	for attempt := 1; ; attempt++ {
+		err := pingDatabase(ctx, connConfig)
+		if err == nil {
+			out.Println("✅ Database is ready")
+			return nil
+		}
+
+		out.Printf("   Attempt %d: %s (retrying in %s)\n", attempt, lastLine(err.Error()), backoff)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("database not ready after %s: %w", timeout, err)
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, waitMaxBackoff)
+	}
+}
+
+// pingDatabase opens a connection and runs SELECT 1
+func pingDatabase(ctx context.Context, connConfig *pgx.ConnConfig) error {
+	conn, err := pgx.ConnectConfig(ctx, connConfig)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	var one int
+	return conn.QueryRow(ctx, "SELECT 1").Scan(&one)
+}
+
+// lastLine returns the last line of a multi-line connection error, which
+// holds the underlying cause
+func lastLine(msg string) string {
+	msg = strings.TrimSpace(msg)
+	if i := strings.LastIndex(msg, "\n"); i >= 0 {
+		return strings.TrimSpace(msg[i+1:])
+	}
+	return msg
+}
+
+// waitForDatabaseURL parses databaseURL and waits for it as WaitForDatabase does
+func waitForDatabaseURL(ctx context.Context, out *Output, databaseURL string, timeout time.Duration) error {
+	if timeout <= 0 {
+		return nil
+	}
+	connConfig, err := pgx.ParseConfig(databaseURL)
+	if err != nil {
+		return fmt.Errorf("parse database URL: %w", err)
+	}
+	return WaitForDatabase(ctx, out, connConfig, timeout)
+}
@@ -4,63 +4,251 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"net/mail"
+	"net/netip"
+	"net/url"
 	"os"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/rs/zerolog"
 
 	"github.com/nodebyte/backend/internal/crypto"
 	"github.com/nodebyte/backend/internal/database"
+	"github.com/nodebyte/backend/internal/panels"
+	"github.com/nodebyte/backend/internal/queue"
 )
 
 // Config holds all configuration for the backend service
 type Config struct {
 	// Environment
-	Env  string
-	Port string
+	Env      string `env:"ENV"`
+	Port     string `env:"BACKEND_PORT"`
+	LogLevel string `env:"LOG_LEVEL"` // debug, info, warn or error
 
 	// Database
-	DatabaseURL string
+	DatabaseURL          string `env:"DATABASE_URL" secret:"url"`
+	SlowQueryThresholdMs int    `env:"SLOW_QUERY_THRESHOLD_MS"` // queries slower than this are logged at warn level (0 disables)
 
 	// Redis
-	RedisURL string
+	RedisURL string `env:"REDIS_URL" secret:"url"`
 
 	// Security
-	APIKey string
+	APIKey  string   `env:"BACKEND_API_KEY" secret:"true"`
+	APIKeys []APIKey `env:"API_KEYS" secret:"true"` // extra labeled keys from API_KEYS
 
 	// CORS
-	CORSOrigins []string
+	CORSOrigins      []string `env:"CORS_ORIGINS"`
+	CORSAllowHeaders []string `env:"CORS_ALLOW_HEADERS"`
+	CORSAllowMethods []string `env:"CORS_ALLOW_METHODS"`
+	CORSMaxAge       int      `env:"CORS_MAX_AGE"` // seconds browsers may cache preflight responses (0 disables caching)
+
+	// Auth cookie policy
+	CookieSecure   bool   `env:"COOKIE_SECURE"`    // defaults to true in production
+	CookieSameSite string `env:"COOKIE_SAME_SITE"` // Strict, Lax or None (None requires CookieSecure)
+	CookieDomain   string `env:"COOKIE_DOMAIN"`    // empty scopes cookies to the API host
+
+	// Reverse proxy trust for X-Forwarded-Proto and related headers. Client
+	// IPs are only read from ProxyHeader on requests from TrustedProxies.
+	TrustProxyHeaders bool     `env:"TRUST_PROXY_HEADERS"`
+	TrustedProxies    []string `env:"TRUSTED_PROXIES"` // when set, only these IPs or CIDRs are trusted
+	ProxyHeader       string   `env:"PROXY_HEADER"`    // header the trusted proxies put the client IP in
 
 	// Pterodactyl Panel
-	PterodactylURL          string
-	PterodactylAPIKey       string
-	PterodactylClientAPIKey string
+	PterodactylURL          string `env:"PTERODACTYL_URL"`
+	PterodactylAPIKey       string `env:"PTERODACTYL_API_KEY" secret:"true"`
+	PterodactylClientAPIKey string `env:"PTERODACTYL_CLIENT_API_KEY" secret:"true"`
+
+	// Idle connections kept to the panel; 0 uses the panels package default
+	PanelMaxIdleConns        int `env:"PANEL_MAX_IDLE_CONNS"`
+	PanelMaxIdleConnsPerHost int `env:"PANEL_MAX_IDLE_CONNS_PER_HOST"`
+	PanelIdleConnTimeout     int `env:"PANEL_IDLE_CONN_TIMEOUT"` // seconds before an idle connection is closed
 
 	// Virtfusion Panel
-	VirtfusionURL    string
-	VirtfusionAPIKey string
+	VirtfusionURL    string `env:"VIRTFUSION_URL"`
+	VirtfusionAPIKey string `env:"VIRTFUSION_API_KEY" secret:"true"`
 
 	// Cloudflare Access (for panel proxying)
-	CFAccessClientID     string
-	CFAccessClientSecret string
+	CFAccessClientID     string `env:"CF_ACCESS_CLIENT_ID"`
+	CFAccessClientSecret string `env:"CF_ACCESS_CLIENT_SECRET" secret:"true"`
 
 	// Email (Resend)
-	ResendAPIKey string
-	EmailFrom    string
+	ResendAPIKey string `env:"RESEND_API_KEY" secret:"true"`
+	EmailFrom    string `env:"EMAIL_FROM"` // full sender, used when EMAIL_FROM_ADDRESS is unset
+
+	// Sender identity; EmailFromAddress takes precedence over EmailFrom
+	EmailFromName        string            `env:"EMAIL_FROM_NAME"`
+	EmailFromAddress     string            `env:"EMAIL_FROM_ADDRESS"`
+	EmailReplyTo         string            `env:"EMAIL_REPLY_TO"`
+	EmailTemplateReplyTo map[string]string `env:"EMAIL_TEMPLATE_REPLY_TO"` // template name -> reply-to address
+
+	// Support tickets; ticket URLs replace {id} with the ticket ID
+	SupportEmail          string `env:"SUPPORT_EMAIL"` // staff inbox for unassigned tickets
+	SupportTicketURL      string `env:"SUPPORT_TICKET_URL"`
+	SupportStaffTicketURL string `env:"SUPPORT_STAFF_TICKET_URL"`
 
 	// Sync settings
-	SyncBatchSize         int
-	AutoSyncEnabled       bool
-	AutoSyncInterval      int // in seconds (loaded from database or env; env can be in minutes/seconds)
-	SyncSubusersEnabled   bool
-	SyncSubusersBatchSize int
+	SyncBatchSize             int    `env:"SYNC_BATCH_SIZE"`
+	SyncPerPage               int    `env:"SYNC_PER_PAGE"`               // page size for panel fetches (Pterodactyl caps this at 100)
+	SyncAllocationConcurrency int    `env:"SYNC_ALLOCATION_CONCURRENCY"` // nodes whose allocations are fetched at once
+	AutoSyncEnabled           bool   `env:"AUTO_SYNC_ENABLED"`
+	AutoSyncInterval          int    `env:"AUTO_SYNC_INTERVAL"` // in seconds (loaded from database or env; env can be in minutes/seconds)
+	AutoSyncCron              string `env:"AUTO_SYNC_CRON"`     // standard 5-field cron expression; takes precedence over AutoSyncInterval when set
+	SyncSubusersEnabled       bool   `env:"SYNC_SUBUSERS_ENABLED"`
+	SyncSubusersBatchSize     int    `env:"SYNC_SUBUSERS_BATCH_SIZE"`
+	SyncMaxDuration           int    `env:"SYNC_MAX_DURATION"` // minutes a sync may stay unfinished before it is considered stuck
+
+	// Escalated sync failure alerts: once this many syncs in a row have
+	// failed, the failure webhook is sent as an alert with the mention
+	SyncFailureAlertThreshold int    `env:"SYNC_FAILURE_ALERT_THRESHOLD"` // 0 disables escalation
+	SyncFailureAlertMention   string `env:"SYNC_FAILURE_ALERT_MENTION"`   // e.g. @here or <@&roleID>; empty mentions no one
+
+	// Server tombstones: servers missing from the panel are marked deleted
+	// instead of removed, and purged once absent long enough
+	SyncServerTombstones     bool `env:"SYNC_SERVER_TOMBSTONES"`
+	SyncTombstoneGracePeriod int  `env:"SYNC_TOMBSTONE_GRACE_PERIOD"` // hours a server stays tombstoned before it can be purged
+	SyncTombstoneMinMissing  int  `env:"SYNC_TOMBSTONE_MIN_MISSING"`  // syncs in a row a server must be missing from before it is purged
+
+	// Destructive admin sync actions (pruning syncs, cancel) return a
+	// short-lived confirmation token that must be sent back to proceed
+	SyncConfirmDestructive bool `env:"SYNC_CONFIRM_DESTRUCTIVE"`
+
+	// Server power state polling for uptime history, servers.status and
+	// resource snapshots
+	ServerStatusPollInterval int `env:"SERVER_STATUS_POLL_INTERVAL"`  // seconds between power state polls (0 disables)
+	ServerStateSyncBatchSize int `env:"SERVER_STATE_SYNC_BATCH_SIZE"` // status updates written per database batch
+	ServerStateSyncRate      int `env:"SERVER_STATE_SYNC_RATE"`       // client API requests per second
+
+	// Queue each task group is enqueued on (critical, default, or low)
+	EmailQueue   string `env:"EMAIL_QUEUE"`
+	WebhookQueue string `env:"WEBHOOK_QUEUE"`
+	SyncQueue    string `env:"SYNC_QUEUE"`
+
+	// Per-event windows in which repeat webhook dispatches to the same webhook
+	// are skipped; the "*" entry applies to events without their own window
+	WebhookDebounce map[string]time.Duration `env:"WEBHOOK_DEBOUNCE"`
+	// Webhook URLs sent to at once when a notification goes to every webhook
+	WebhookConcurrency int `env:"WEBHOOK_CONCURRENCY"`
+
+	// Retry, timeout, deadline and retention options per task type
+	TaskOptions queue.TaskOptionSet `env:"TASK_OPTIONS"`
+
+	// Auth token lifetimes (in minutes)
+	VerificationTokenTTL  int `env:"VERIFICATION_TOKEN_TTL"`
+	PasswordResetTokenTTL int `env:"PASSWORD_RESET_TOKEN_TTL"`
+	MagicLinkTokenTTL     int `env:"MAGIC_LINK_TOKEN_TTL"`
+
+	// Role assigned to newly registered users
+	DefaultRole string `env:"DEFAULT_ROLE"`
+
+	// Registration email domain restrictions ("*.example.com" matches subdomains)
+	RegistrationAllowedDomains []string `env:"REGISTRATION_ALLOWED_DOMAINS"` // empty allows every domain
+	RegistrationBlockedDomains []string `env:"REGISTRATION_BLOCKED_DOMAINS"`
+	InviteOnly                 bool     `env:"INVITE_ONLY"` // registration requires a valid invite code
 
 	// Hytale OAuth
-	HytaleUseStaging bool
+	HytaleUseStaging bool `env:"HYTALE_USE_STAGING"`
+	// Days shipped Hytale server logs are kept before pruning
+	HytaleLogRetentionDays int `env:"HYTALE_LOG_RETENTION_DAYS"`
+	// Minutes before expiry that OAuth tokens and game sessions are refreshed
+	HytaleRefreshLeadMinutes int `env:"HYTALE_REFRESH_LEAD_MINUTES"`
+
+	// API rate limiting: requests per minute each user (or IP, when
+	// anonymous) may make to a route group. System admins and API keys are
+	// exempt.
+	APIRateLimitEnabled bool           `env:"API_RATE_LIMIT_ENABLED"`
+	APIRateLimits       map[string]int `env:"API_RATE_LIMITS"` // group -> requests per minute; 0 lifts a group's limit
+
+	// Seconds the public stats endpoints serve a cached response before
+	// refreshing it
+	CacheTimeout int `env:"CACHE_TIMEOUT"`
+
+	// Internal accounts and servers left out of the public stats endpoints;
+	// admin stats always count everything
+	PublicStatsExcludeAdmins   bool     `env:"PUBLIC_STATS_EXCLUDE_ADMINS"`    // admin accounts and the servers they own
+	PublicStatsExcludedTags    []string `env:"PUBLIC_STATS_EXCLUDED_TAGS"`     // global server tags marking internal or test servers
+	PublicStatsActiveUsersOnly bool     `env:"PUBLIC_STATS_ACTIVE_USERS_ONLY"` // leave out deactivated users
+
+	// Page sizes for list endpoints
+	PaginationDefaultLimit int `env:"PAGINATION_DEFAULT_LIMIT"`
+	PaginationMaxLimit     int `env:"PAGINATION_MAX_LIMIT"`
 
 	// Sentry Error Tracking
-	SentryDSN string
+	SentryDSN              string  `env:"SENTRY_DSN" secret:"true"`
+	SentryEnvironment      string  `env:"SENTRY_ENVIRONMENT"`        // defaults to Env
+	SentryTracesSampleRate float64 `env:"SENTRY_TRACES_SAMPLE_RATE"` // fraction of transactions traced (0-1)
+	SentryFlushTimeout     int     `env:"SENTRY_FLUSH_TIMEOUT"`      // seconds to wait for queued events on shutdown
+
+	// Graceful shutdown (in seconds)
+	ShutdownDrainPeriod int `env:"SHUTDOWN_DRAIN_PERIOD"` // /ready reports 503 this long before connections are refused
+	ShutdownTimeout     int `env:"SHUTDOWN_TIMEOUT"`      // wait for in-flight requests before forcing shutdown
+
+	// Where each field's value came from, keyed by field name; see Effective
+	sources map[string]string
+}
+
+// Default CORS preflight settings, matching the values previously hardcoded
+// in the API server.
+const (
+	DefaultCORSAllowHeaders = "Origin, Content-Type, Accept, Authorization, X-API-Key"
+	DefaultCORSAllowMethods = "GET, POST, PUT, DELETE, OPTIONS, PATCH"
+)
+
+// Default list endpoint page sizes
+const (
+	DefaultPaginationLimit    = 25
+	DefaultPaginationMaxLimit = 100
+)
+
+// API rate limit route groups
+const (
+	RateLimitGroupAuth      = "auth"      // public sign-in, registration and recovery routes
+	RateLimitGroupPublic    = "public"    // other unauthenticated routes, such as public stats
+	RateLimitGroupDashboard = "dashboard" // bearer-authenticated dashboard routes
+)
+
+// DefaultAPIRateLimits are the requests per minute allowed per route group
+// when API_RATE_LIMITS does not set them
+var DefaultAPIRateLimits = map[string]int{
+	RateLimitGroupAuth:      30,
+	RateLimitGroupPublic:    120,
+	RateLimitGroupDashboard: 300,
 }
 
+// DefaultCacheTimeout is how many seconds public stats stay fresh when
+// cache_timeout is unset
+const DefaultCacheTimeout = 60
+
+// DefaultPublicStatsExcludedTags lists the global server tags left out of
+// public stats when PUBLIC_STATS_EXCLUDED_TAGS is not set
+const DefaultPublicStatsExcludedTags = "internal"
+
+// DefaultCookieSameSite is the SameSite mode used for auth cookies when
+// COOKIE_SAME_SITE is not set
+const DefaultCookieSameSite = "Lax"
+
+// Default support ticket links for ticket owners and staff
+const (
+	DefaultSupportTicketURL      = "https://nodebyte.host/dashboard/support/{id}"
+	DefaultSupportStaffTicketURL = "https://nodebyte.host/admin/support/{id}"
+)
+
+// DefaultProxyHeader is the header client IPs are read from on requests
+// from trusted proxies when PROXY_HEADER is not set
+const DefaultProxyHeader = "X-Real-IP"
+
+// LogLevels are the accepted LOG_LEVEL values, most verbose first
+var LogLevels = []string{"debug", "info", "warn", "error"}
+
+// DefaultRole is the role given to new users when DEFAULT_ROLE is not set
+const DefaultRole = "MEMBER"
+
+// requiredCORSHeaders are always allowed so configured header lists cannot
+// lock out bearer or API key authentication.
+var requiredCORSHeaders = []string{"Authorization", "X-API-Key"}
+
 // Load reads configuration from environment variables
 func Load() (*Config, error) {
 	cfg := &Config{
@@ -69,14 +257,26 @@ func Load() (*Config, error) {
 		DatabaseURL: os.Getenv("DATABASE_URL"),
 		RedisURL:    getEnv("REDIS_URL", "localhost:6379"),
 		APIKey:      os.Getenv("BACKEND_API_KEY"),
+		APIKeys:     parseAPIKeys(os.Getenv("API_KEYS")),
 		CORSOrigins: parseCORSOrigins(getEnv("CORS_ORIGINS", "http://localhost:3000,https://nodebyte.host")),
 
+		// Database diagnostics
+		SlowQueryThresholdMs: getEnvInt("SLOW_QUERY_THRESHOLD_MS", 0),
+
+		// CORS preflight
+		CORSAllowHeaders: withRequiredCORSHeaders(parseCORSOrigins(getEnv("CORS_ALLOW_HEADERS", DefaultCORSAllowHeaders))),
+		CORSAllowMethods: parseCORSOrigins(getEnv("CORS_ALLOW_METHODS", DefaultCORSAllowMethods)),
+		CORSMaxAge:       getEnvInt("CORS_MAX_AGE", 0),
+
 		// Panel settings
-		PterodactylURL:          os.Getenv("PTERODACTYL_URL"),
-		PterodactylAPIKey:       os.Getenv("PTERODACTYL_API_KEY"),
-		PterodactylClientAPIKey: os.Getenv("PTERODACTYL_CLIENT_API_KEY"),
-		VirtfusionURL:           os.Getenv("VIRTFUSION_URL"),
-		VirtfusionAPIKey:        os.Getenv("VIRTFUSION_API_KEY"),
+		PterodactylURL:           normalizePanelURL(os.Getenv("PTERODACTYL_URL")),
+		PterodactylAPIKey:        os.Getenv("PTERODACTYL_API_KEY"),
+		PterodactylClientAPIKey:  os.Getenv("PTERODACTYL_CLIENT_API_KEY"),
+		PanelMaxIdleConns:        getEnvInt("PANEL_MAX_IDLE_CONNS", panels.DefaultConnectionPool.MaxIdleConns),
+		PanelMaxIdleConnsPerHost: getEnvInt("PANEL_MAX_IDLE_CONNS_PER_HOST", panels.DefaultConnectionPool.MaxIdleConnsPerHost),
+		PanelIdleConnTimeout:     getEnvInt("PANEL_IDLE_CONN_TIMEOUT", int(panels.DefaultConnectionPool.IdleConnTimeout.Seconds())),
+		VirtfusionURL:            os.Getenv("VIRTFUSION_URL"),
+		VirtfusionAPIKey:         os.Getenv("VIRTFUSION_API_KEY"),
 
 		// Cloudflare
 		CFAccessClientID:     os.Getenv("CF_ACCESS_CLIENT_ID"),
@@ -86,18 +286,164 @@ func Load() (*Config, error) {
 		ResendAPIKey: os.Getenv("RESEND_API_KEY"),
 		EmailFrom:    getEnv("EMAIL_FROM", "NodeByte <noreply@nodebyte.host>"),
 
+		EmailFromName:    os.Getenv("EMAIL_FROM_NAME"),
+		EmailFromAddress: os.Getenv("EMAIL_FROM_ADDRESS"),
+		EmailReplyTo:     os.Getenv("EMAIL_REPLY_TO"),
+
+		// Support tickets
+		SupportEmail:          os.Getenv("SUPPORT_EMAIL"),
+		SupportTicketURL:      getEnv("SUPPORT_TICKET_URL", DefaultSupportTicketURL),
+		SupportStaffTicketURL: getEnv("SUPPORT_STAFF_TICKET_URL", DefaultSupportStaffTicketURL),
+
 		// Sync
-		SyncBatchSize:         getEnvInt("SYNC_BATCH_SIZE", 100),
-		AutoSyncEnabled:       getEnvBool("AUTO_SYNC_ENABLED", false),
-		AutoSyncInterval:      getEnvInt("AUTO_SYNC_INTERVAL", 3600) * 60, // Env in minutes (converted to seconds)
-		SyncSubusersEnabled:   getEnvBool("SYNC_SUBUSERS_ENABLED", true),
-		SyncSubusersBatchSize: getEnvInt("SYNC_SUBUSERS_BATCH_SIZE", 25),
+		SyncBatchSize:             getEnvInt("SYNC_BATCH_SIZE", 100),
+		SyncPerPage:               getEnvInt("SYNC_PER_PAGE", 100),
+		SyncAllocationConcurrency: getEnvInt("SYNC_ALLOCATION_CONCURRENCY", 4),
+		AutoSyncEnabled:           getEnvBool("AUTO_SYNC_ENABLED", false),
+		AutoSyncInterval:          getEnvInt("AUTO_SYNC_INTERVAL", 3600) * 60, // Env in minutes (converted to seconds)
+		AutoSyncCron:              getEnv("AUTO_SYNC_CRON", ""),
+		SyncSubusersEnabled:       getEnvBool("SYNC_SUBUSERS_ENABLED", true),
+		SyncSubusersBatchSize:     getEnvInt("SYNC_SUBUSERS_BATCH_SIZE", 25),
+		SyncMaxDuration:           getEnvInt("SYNC_MAX_DURATION", 120),
+		SyncFailureAlertThreshold: getEnvInt("SYNC_FAILURE_ALERT_THRESHOLD", 3),
+		SyncFailureAlertMention:   os.Getenv("SYNC_FAILURE_ALERT_MENTION"),
+
+		// Server tombstones
+		SyncServerTombstones:     getEnvBool("SYNC_SERVER_TOMBSTONES", false),
+		SyncTombstoneGracePeriod: getEnvInt("SYNC_TOMBSTONE_GRACE_PERIOD", 72),
+		SyncTombstoneMinMissing:  getEnvInt("SYNC_TOMBSTONE_MIN_MISSING", 3),
+
+		// Destructive sync confirmation
+		SyncConfirmDestructive: getEnvBool("SYNC_CONFIRM_DESTRUCTIVE", false),
+
+		// Server status polling
+		ServerStatusPollInterval: getEnvInt("SERVER_STATUS_POLL_INTERVAL", 60),
+
+		// Server power state reconciliation
+		ServerStateSyncBatchSize: getEnvInt("SERVER_STATE_SYNC_BATCH_SIZE", 50),
+		ServerStateSyncRate:      getEnvInt("SERVER_STATE_SYNC_RATE", 5),
+
+		// Task queue routing
+		EmailQueue:   getEnv("EMAIL_QUEUE", queue.DefaultRouting.Email),
+		WebhookQueue: getEnv("WEBHOOK_QUEUE", queue.DefaultRouting.Webhook),
+		SyncQueue:    getEnv("SYNC_QUEUE", queue.DefaultRouting.Sync),
+
+		WebhookConcurrency: getEnvInt("WEBHOOK_CONCURRENCY", 5),
+
+		// Auth tokens
+		VerificationTokenTTL:  getEnvInt("VERIFICATION_TOKEN_TTL", int(database.TokenExpiration.Minutes())),
+		PasswordResetTokenTTL: getEnvInt("PASSWORD_RESET_TOKEN_TTL", int(database.TokenExpiration.Minutes())),
+		MagicLinkTokenTTL:     getEnvInt("MAGIC_LINK_TOKEN_TTL", int(database.MagicLinkExpiration.Minutes())),
+
+		// Roles
+		DefaultRole: database.NormalizeRoleName(getEnv("DEFAULT_ROLE", DefaultRole)),
+
+		// Registration
+		RegistrationAllowedDomains: parseDomainList(os.Getenv("REGISTRATION_ALLOWED_DOMAINS")),
+		RegistrationBlockedDomains: parseDomainList(os.Getenv("REGISTRATION_BLOCKED_DOMAINS")),
+		InviteOnly:                 getEnvBool("INVITE_ONLY", false),
 
 		// Hytale
-		HytaleUseStaging: getEnvBool("HYTALE_USE_STAGING", false),
+		HytaleUseStaging:         getEnvBool("HYTALE_USE_STAGING", false),
+		HytaleLogRetentionDays:   getEnvInt("HYTALE_LOG_RETENTION_DAYS", 30),
+		HytaleRefreshLeadMinutes: getEnvInt("HYTALE_REFRESH_LEAD_MINUTES", DefaultHytaleRefreshLeadMinutes),
+
+		// API rate limiting
+		APIRateLimitEnabled: getEnvBool("API_RATE_LIMIT_ENABLED", true),
+
+		// Public stats exclusions
+		CacheTimeout:               getEnvInt("CACHE_TIMEOUT", DefaultCacheTimeout),
+		PublicStatsExcludeAdmins:   getEnvBool("PUBLIC_STATS_EXCLUDE_ADMINS", true),
+		PublicStatsExcludedTags:    parseServerTags(getEnv("PUBLIC_STATS_EXCLUDED_TAGS", DefaultPublicStatsExcludedTags)),
+		PublicStatsActiveUsersOnly: getEnvBool("PUBLIC_STATS_ACTIVE_USERS_ONLY", true),
+
+		// Pagination
+		PaginationDefaultLimit: getEnvInt("PAGINATION_DEFAULT_LIMIT", DefaultPaginationLimit),
+		PaginationMaxLimit:     getEnvInt("PAGINATION_MAX_LIMIT", DefaultPaginationMaxLimit),
 
 		// Sentry
-		SentryDSN: os.Getenv("SENTRY_DSN"),
+		SentryDSN:              os.Getenv("SENTRY_DSN"),
+		SentryTracesSampleRate: getEnvFloat("SENTRY_TRACES_SAMPLE_RATE", 0.1),
+		SentryFlushTimeout:     getEnvInt("SENTRY_FLUSH_TIMEOUT", 5),
+
+		// Graceful shutdown
+		ShutdownDrainPeriod: getEnvInt("SHUTDOWN_DRAIN_PERIOD", 0),
+		ShutdownTimeout:     getEnvInt("SHUTDOWN_TIMEOUT", 10),
+	}
+	cfg.SentryEnvironment = getEnv("SENTRY_ENVIRONMENT", cfg.Env)
+
+	defaultLogLevel := "info"
+	if cfg.Env == "development" {
+		defaultLogLevel = "debug"
+	}
+	cfg.LogLevel = normalizeLogLevel(getEnv("LOG_LEVEL", defaultLogLevel))
+	if cfg.LogLevel == "" {
+		return nil, errors.New("LOG_LEVEL must be debug, info, warn or error")
+	}
+
+	cfg.CookieSecure = getEnvBool("COOKIE_SECURE", cfg.Env == "production")
+	cfg.CookieSameSite = normalizeSameSite(getEnv("COOKIE_SAME_SITE", DefaultCookieSameSite))
+	cfg.CookieDomain = os.Getenv("COOKIE_DOMAIN")
+	cfg.TrustProxyHeaders = getEnvBool("TRUST_PROXY_HEADERS", false)
+	cfg.ProxyHeader = getEnv("PROXY_HEADER", DefaultProxyHeader)
+	if !validProxyHeader(cfg.ProxyHeader) {
+		return nil, errors.New("PROXY_HEADER must be a header the proxy overwrites, such as X-Real-IP or CF-Connecting-IP; X-Forwarded-For is client-controlled")
+	}
+	if cfg.AutoSyncCron != "" {
+		if _, err := ParseAutoSyncCron(cfg.AutoSyncCron); err != nil {
+			return nil, fmt.Errorf("AUTO_SYNC_CRON: %w", err)
+		}
+	}
+	trustedProxies, err := parseTrustedProxies(os.Getenv("TRUSTED_PROXIES"))
+	if err != nil {
+		return nil, fmt.Errorf("TRUSTED_PROXIES: %w", err)
+	}
+	cfg.TrustedProxies = trustedProxies
+
+	if cfg.CookieSameSite == "" {
+		return nil, errors.New("COOKIE_SAME_SITE must be Strict, Lax or None")
+	}
+	if cfg.CookieSameSite == "None" && !cfg.CookieSecure {
+		return nil, errors.New("COOKIE_SAME_SITE=None requires COOKIE_SECURE=true")
+	}
+
+	taskOptions, err := queue.ParseTaskOptions(os.Getenv("TASK_OPTIONS"))
+	if err != nil {
+		return nil, fmt.Errorf("TASK_OPTIONS: %w", err)
+	}
+	cfg.TaskOptions = taskOptions
+
+	webhookDebounce, err := parseDebounceWindows(os.Getenv("WEBHOOK_DEBOUNCE"))
+	if err != nil {
+		return nil, fmt.Errorf("WEBHOOK_DEBOUNCE: %w", err)
+	}
+	cfg.WebhookDebounce = webhookDebounce
+
+	rateLimits, err := parseRateLimits(os.Getenv("API_RATE_LIMITS"))
+	if err != nil {
+		return nil, fmt.Errorf("API_RATE_LIMITS: %w", err)
+	}
+	cfg.APIRateLimits = rateLimits
+
+	templateReplyTo, err := parseTemplateReplyTo(os.Getenv("EMAIL_TEMPLATE_REPLY_TO"))
+	if err != nil {
+		return nil, fmt.Errorf("EMAIL_TEMPLATE_REPLY_TO: %w", err)
+	}
+	cfg.EmailTemplateReplyTo = templateReplyTo
+	cfg.recordEnvSources()
+
+	if err := cfg.validateEmailIdentity(); err != nil {
+		return nil, err
+	}
+	if err := cfg.validateSupportSettings(); err != nil {
+		return nil, err
+	}
+
+	if cfg.SentryTracesSampleRate < 0 || cfg.SentryTracesSampleRate > 1 {
+		return nil, errors.New("SENTRY_TRACES_SAMPLE_RATE must be between 0 and 1")
+	}
+	if cfg.ShutdownDrainPeriod < 0 || cfg.ShutdownTimeout < 0 || cfg.SentryFlushTimeout < 0 {
+		return nil, errors.New("SHUTDOWN_DRAIN_PERIOD, SHUTDOWN_TIMEOUT and SENTRY_FLUSH_TIMEOUT must not be negative")
 	}
 
 	// Validate required fields
@@ -125,6 +471,15 @@ func getEnvInt(key string, defaultValue int) int {
 	return defaultValue
 }
 
+func getEnvFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if result, err := strconv.ParseFloat(value, 64); err == nil {
+			return result
+		}
+	}
+	return defaultValue
+}
+
 func getEnvBool(key string, defaultValue bool) bool {
 	if value := os.Getenv(key); value != "" {
 		return value == "true" || value == "1" || value == "yes"
@@ -142,6 +497,113 @@ func parseCORSOrigins(originsStr string) []string {
 	return origins
 }
 
+// APIKey is a labeled backend API key from the environment
+type APIKey struct {
+	Label string
+	Key   string
+}
+
+// DefaultAPIKeyLabel labels the key from BACKEND_API_KEY
+const DefaultAPIKeyLabel = "default"
+
+// maxAPIKeyLabelLength caps how long a label before the first ':' may be
+const maxAPIKeyLabelLength = 64
+
+// isAPIKeyLabel reports whether s can label an API_KEYS entry: a letter
+// followed by letters, digits, '-', '_' or '.'
+func isAPIKeyLabel(s string) bool {
+	if s == "" || len(s) > maxAPIKeyLabelLength {
+		return false
+	}
+	for i, r := range s {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z':
+		case i > 0 && (r >= '0' && r <= '9' || r == '-' || r == '_' || r == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// parseAPIKeys parses a comma-separated list of label:key pairs. Only the
+// first ':' separates the label, and only when what precedes it is a valid
+// label, so keys may contain ':'. Entries without a label are labeled by
+// their position.
+func parseAPIKeys(value string) []APIKey {
+	var keys []APIKey
+	for i, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		label, key, ok := strings.Cut(entry, ":")
+		if !ok || !isAPIKeyLabel(strings.TrimSpace(label)) {
+			label, key = "", entry
+		}
+		label, key = strings.TrimSpace(label), strings.TrimSpace(key)
+		if key == "" {
+			continue
+		}
+		if label == "" {
+			label = "key-" + strconv.Itoa(i+1)
+		}
+		keys = append(keys, APIKey{Label: label, Key: key})
+	}
+	return keys
+}
+
+// StaticAPIKeys returns the API keys configured in the environment:
+// BACKEND_API_KEY (labeled "default") followed by API_KEYS
+func (cfg *Config) StaticAPIKeys() []APIKey {
+	keys := make([]APIKey, 0, len(cfg.APIKeys)+1)
+	if cfg.APIKey != "" {
+		keys = append(keys, APIKey{Label: DefaultAPIKeyLabel, Key: cfg.APIKey})
+	}
+	return append(keys, cfg.APIKeys...)
+}
+
+// parseDomainList splits a comma-separated domain list, lower-casing entries
+func parseDomainList(value string) []string {
+	var domains []string
+	for _, d := range strings.Split(value, ",") {
+		if trimmed := strings.ToLower(strings.TrimSpace(d)); trimmed != "" {
+			domains = append(domains, trimmed)
+		}
+	}
+	return domains
+}
+
+// parseServerTags splits a comma-separated tag list, normalizing each tag the
+// way server tags are stored
+func parseServerTags(value string) []string {
+	tags := []string{}
+	for _, t := range strings.Split(value, ",") {
+		if tag := database.NormalizeServerTag(t); tag != "" {
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// withRequiredCORSHeaders appends any required CORS headers missing from the
+// given list. Header names are compared case-insensitively.
+func withRequiredCORSHeaders(headers []string) []string {
+	for _, required := range requiredCORSHeaders {
+		found := false
+		for _, h := range headers {
+			if strings.EqualFold(h, required) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			headers = append(headers, required)
+		}
+	}
+	return headers
+}
+
 // MergeFromDB loads configuration overrides from the `config` table in the
 // main application database. Values stored in the DB will overwrite the
 // corresponding fields on the provided Config when present.
@@ -184,56 +646,651 @@ func (cfg *Config) MergeFromDB(db *database.DB, encryptor *crypto.Encryptor) err
 			fmt.Printf("WARNING: Sensitive field '%s' in database but encryptor not available. Value may be encrypted.\n", key)
 		}
 
-		switch key {
-		case "pterodactyl_url":
-			if value != "" {
-				cfg.PterodactylURL = value
-			}
-		case "pterodactyl_api_key":
-			if value != "" {
-				cfg.PterodactylAPIKey = value
-			}
-		case "pterodactyl_client_api_key":
-			if value != "" {
-				cfg.PterodactylClientAPIKey = value
-			}
-		case "virtfusion_url":
-			if value != "" {
-				cfg.VirtfusionURL = value
-			}
-		case "virtfusion_api_key":
-			if value != "" {
-				cfg.VirtfusionAPIKey = value
-			}
-		case "cf_access_client_id":
-			if value != "" {
-				cfg.CFAccessClientID = value
-			}
-		case "cf_access_client_secret":
-			if value != "" {
-				cfg.CFAccessClientSecret = value
-			}
-		case "resend_api_key":
-			if value != "" {
-				cfg.ResendAPIKey = value
-			}
-		case "email_from":
-			if value != "" {
-				cfg.EmailFrom = value
-			}
-		case "sync_batch_size":
-			if n, err := strconv.Atoi(value); err == nil && n > 0 {
-				cfg.SyncBatchSize = n
-			}
-		case "auto_sync_enabled":
-			cfg.AutoSyncEnabled = (value == "true" || value == "1")
-		case "auto_sync_interval":
-			// Database stores interval in seconds
-			if n, err := strconv.Atoi(value); err == nil && n > 0 {
-				cfg.AutoSyncInterval = n
+		before := *cfg
+		cfg.applyDBValue(key, value)
+		cfg.recordChanges(&before, SourceDB)
+	}
+
+	return nil
+}
+
+// applyDBValue applies one `config` table entry, ignoring unknown keys and
+// invalid values
+func (cfg *Config) applyDBValue(key, value string) {
+	switch key {
+	case "log_level":
+		if level := normalizeLogLevel(value); level != "" {
+			cfg.LogLevel = level
+		}
+	case "pterodactyl_url":
+		if value != "" {
+			cfg.PterodactylURL = normalizePanelURL(value)
+		}
+	case "pterodactyl_api_key":
+		if value != "" {
+			cfg.PterodactylAPIKey = value
+		}
+	case "pterodactyl_client_api_key":
+		if value != "" {
+			cfg.PterodactylClientAPIKey = value
+		}
+	case "panel_max_idle_conns":
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			cfg.PanelMaxIdleConns = n
+		}
+	case "panel_max_idle_conns_per_host":
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			cfg.PanelMaxIdleConnsPerHost = n
+		}
+	case "panel_idle_conn_timeout":
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			cfg.PanelIdleConnTimeout = n
+		}
+	case "virtfusion_url":
+		if value != "" {
+			cfg.VirtfusionURL = value
+		}
+	case "virtfusion_api_key":
+		if value != "" {
+			cfg.VirtfusionAPIKey = value
+		}
+	case "cf_access_client_id":
+		if value != "" {
+			cfg.CFAccessClientID = value
+		}
+	case "cf_access_client_secret":
+		if value != "" {
+			cfg.CFAccessClientSecret = value
+		}
+	case "resend_api_key":
+		if value != "" {
+			cfg.ResendAPIKey = value
+		}
+	case "email_from":
+		if _, err := mail.ParseAddress(value); err == nil {
+			cfg.EmailFrom = value
+		}
+	case "email_from_name":
+		cfg.EmailFromName = strings.TrimSpace(value)
+	case "email_from_address":
+		if value == "" || validEmailAddress(value) {
+			cfg.EmailFromAddress = value
+		}
+	case "email_reply_to":
+		if value == "" || validEmailAddress(value) {
+			cfg.EmailReplyTo = value
+		}
+	case "email_template_reply_to":
+		if replyTo, err := parseTemplateReplyTo(value); err == nil {
+			cfg.EmailTemplateReplyTo = replyTo
+		}
+	case "trusted_proxies":
+		if proxies, err := parseTrustedProxies(value); err == nil {
+			cfg.TrustedProxies = proxies
+		}
+	case "proxy_header":
+		if value = strings.TrimSpace(value); validProxyHeader(value) {
+			cfg.ProxyHeader = value
+		}
+	case "support_email":
+		if value == "" || validEmailAddress(value) {
+			cfg.SupportEmail = value
+		}
+	case "support_ticket_url":
+		if validTicketURL(value) {
+			cfg.SupportTicketURL = value
+		}
+	case "support_staff_ticket_url":
+		if validTicketURL(value) {
+			cfg.SupportStaffTicketURL = value
+		}
+	case "sync_batch_size":
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			cfg.SyncBatchSize = n
+		}
+	case "sync_allocation_concurrency":
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			cfg.SyncAllocationConcurrency = n
+		}
+	case "sync_per_page":
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			cfg.SyncPerPage = n
+		}
+	case "auto_sync_enabled":
+		cfg.AutoSyncEnabled = (value == "true" || value == "1")
+	case "auto_sync_interval":
+		// Database stores interval in seconds
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			cfg.AutoSyncInterval = n
+		}
+	case "auto_sync_cron":
+		// Empty switches back to interval mode; an invalid schedule is ignored
+		if _, err := ParseAutoSyncCron(value); value == "" || err == nil {
+			cfg.AutoSyncCron = value
+		}
+	case "sync_max_duration":
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			cfg.SyncMaxDuration = n
+		}
+	case "sync_failure_alert_threshold":
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+			cfg.SyncFailureAlertThreshold = n
+		}
+	case "sync_failure_alert_mention":
+		cfg.SyncFailureAlertMention = value
+	case "sync_server_tombstones":
+		cfg.SyncServerTombstones = (value == "true" || value == "1")
+	case "sync_tombstone_grace_period":
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+			cfg.SyncTombstoneGracePeriod = n
+		}
+	case "sync_tombstone_min_missing":
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			cfg.SyncTombstoneMinMissing = n
+		}
+	case "sync_confirm_destructive":
+		cfg.SyncConfirmDestructive = (value == "true" || value == "1")
+	case "server_status_poll_interval":
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+			cfg.ServerStatusPollInterval = n
+		}
+	case "server_state_sync_batch_size":
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			cfg.ServerStateSyncBatchSize = n
+		}
+	case "server_state_sync_rate":
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			cfg.ServerStateSyncRate = n
+		}
+	case "email_queue":
+		if queue.ValidQueue(value) {
+			cfg.EmailQueue = value
+		}
+	case "webhook_queue":
+		if queue.ValidQueue(value) {
+			cfg.WebhookQueue = value
+		}
+	case "sync_queue":
+		if queue.ValidQueue(value) {
+			cfg.SyncQueue = value
+		}
+	case "webhook_debounce":
+		if windows, err := parseDebounceWindows(value); err == nil {
+			cfg.WebhookDebounce = windows
+		}
+	case "api_rate_limit_enabled":
+		cfg.APIRateLimitEnabled = (value == "true" || value == "1")
+	case "api_rate_limits":
+		if limits, err := parseRateLimits(value); err == nil {
+			cfg.APIRateLimits = limits
+		}
+	case "webhook_concurrency":
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			cfg.WebhookConcurrency = n
+		}
+	case "task_options":
+		if opts, err := queue.ParseTaskOptions(value); err == nil {
+			cfg.TaskOptions = opts
+		}
+	case "cors_allow_headers":
+		if headers := parseCORSOrigins(value); len(headers) > 0 {
+			cfg.CORSAllowHeaders = withRequiredCORSHeaders(headers)
+		}
+	case "cors_allow_methods":
+		if methods := parseCORSOrigins(value); len(methods) > 0 {
+			cfg.CORSAllowMethods = methods
+		}
+	case "cors_max_age":
+		if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+			cfg.CORSMaxAge = n
+		}
+	case "verification_token_ttl":
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			cfg.VerificationTokenTTL = n
+		}
+	case "password_reset_token_ttl":
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			cfg.PasswordResetTokenTTL = n
+		}
+	case "magic_link_token_ttl":
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			cfg.MagicLinkTokenTTL = n
+		}
+	case "registration_allowed_domains":
+		if domains := parseDomainList(value); len(domains) > 0 {
+			cfg.RegistrationAllowedDomains = domains
+		}
+	case "registration_blocked_domains":
+		if domains := parseDomainList(value); len(domains) > 0 {
+			cfg.RegistrationBlockedDomains = domains
+		}
+	case "cache_timeout":
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			cfg.CacheTimeout = n
+		}
+	case "public_stats_exclude_admins":
+		cfg.PublicStatsExcludeAdmins = (value == "true" || value == "1")
+	case "public_stats_excluded_tags":
+		// Empty is valid here and counts tagged servers again
+		cfg.PublicStatsExcludedTags = parseServerTags(value)
+	case "public_stats_active_users_only":
+		cfg.PublicStatsActiveUsersOnly = (value == "true" || value == "1")
+	case "invite_only":
+		cfg.InviteOnly = (value == "true" || value == "1")
+	case "hytale_use_staging":
+		cfg.HytaleUseStaging = (value == "true" || value == "1")
+	case "hytale_refresh_lead_minutes":
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			cfg.HytaleRefreshLeadMinutes = n
+		}
+	case "pagination_default_limit":
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			cfg.PaginationDefaultLimit = n
+		}
+	case "pagination_max_limit":
+		if n, err := strconv.Atoi(value); err == nil && n > 0 {
+			cfg.PaginationMaxLimit = n
+		}
+	case "default_role":
+		if role := database.NormalizeRoleName(value); role != "" {
+			cfg.DefaultRole = role
+		}
+	}
+}
+
+// parseDebounceWindows parses comma-separated "<event>=<duration>" pairs such
+// as "settings.updated=30s,*=5s". Empty input yields no windows.
+func parseDebounceWindows(value string) (map[string]time.Duration, error) {
+	windows := map[string]time.Duration{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		event, raw, ok := strings.Cut(entry, "=")
+		event = strings.TrimSpace(event)
+		if !ok || event == "" {
+			return nil, fmt.Errorf("invalid entry %q, expected <event>=<duration>", entry)
+		}
+		window, err := time.ParseDuration(strings.TrimSpace(raw))
+		if err != nil || window < 0 {
+			return nil, fmt.Errorf("invalid window for %q", event)
+		}
+		windows[event] = window
+	}
+	return windows, nil
+}
+
+// parseRateLimits parses comma-separated "<group>=<requests per minute>" pairs
+// such as "auth=20,dashboard=600". Groups must be one of the route groups in
+// DefaultAPIRateLimits. Empty input yields no overrides.
+func parseRateLimits(value string) (map[string]int, error) {
+	limits := map[string]int{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		group, raw, ok := strings.Cut(entry, "=")
+		group = strings.TrimSpace(group)
+		if !ok || group == "" {
+			return nil, fmt.Errorf("invalid entry %q, expected <group>=<requests per minute>", entry)
+		}
+		if _, known := DefaultAPIRateLimits[group]; !known {
+			return nil, fmt.Errorf("unknown route group %q", group)
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(raw))
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("invalid limit for %q", group)
+		}
+		limits[group] = n
+	}
+	return limits, nil
+}
+
+// validEmailAddress reports whether value is a bare email address such as
+// support@example.com, without a display name
+func validEmailAddress(value string) bool {
+	addr, err := mail.ParseAddress(value)
+	return err == nil && addr.Name == "" && addr.Address == value
+}
+
+// parseTemplateReplyTo parses comma-separated "<template>=<address>" pairs such
+// as "account-imported=support@example.com". Empty input yields no overrides.
+func parseTemplateReplyTo(value string) (map[string]string, error) {
+	replyTo := map[string]string{}
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		template, address, ok := strings.Cut(entry, "=")
+		template, address = strings.TrimSpace(template), strings.TrimSpace(address)
+		if !ok || template == "" {
+			return nil, fmt.Errorf("invalid entry %q, expected <template>=<address>", entry)
+		}
+		if !validEmailAddress(address) {
+			return nil, fmt.Errorf("invalid address for %q", template)
+		}
+		replyTo[template] = address
+	}
+	return replyTo, nil
+}
+
+// validateEmailIdentity checks the sender and reply-to settings
+func (cfg *Config) validateEmailIdentity() error {
+	if cfg.EmailFromAddress != "" && !validEmailAddress(cfg.EmailFromAddress) {
+		return errors.New("EMAIL_FROM_ADDRESS must be an email address")
+	}
+	if cfg.EmailFromAddress == "" {
+		if _, err := mail.ParseAddress(cfg.EmailFrom); err != nil {
+			return errors.New(`EMAIL_FROM must be an address such as "NodeByte <noreply@example.com>"`)
+		}
+	}
+	if cfg.EmailReplyTo != "" && !validEmailAddress(cfg.EmailReplyTo) {
+		return errors.New("EMAIL_REPLY_TO must be an email address")
+	}
+	return nil
+}
+
+// EmailSender returns the From header and reply-to address for emails sent
+// with template. The reply-to is empty when none is configured.
+func (cfg *Config) EmailSender(template string) (from, replyTo string) {
+	from = cfg.EmailFrom
+	if cfg.EmailFromAddress != "" {
+		from = (&mail.Address{Name: cfg.EmailFromName, Address: cfg.EmailFromAddress}).String()
+	}
+	replyTo = cfg.EmailReplyTo
+	if override, ok := cfg.EmailTemplateReplyTo[template]; ok {
+		replyTo = override
+	}
+	return from, replyTo
+}
+
+// parseTrustedProxies parses a comma-separated list of proxy IPs and CIDRs
+// such as "10.0.0.0/8, 192.168.1.10". Empty input trusts no proxy for
+// client IPs.
+func parseTrustedProxies(value string) ([]string, error) {
+	proxies := parseCORSOrigins(value)
+	for _, proxy := range proxies {
+		if strings.Contains(proxy, "/") {
+			if _, err := netip.ParsePrefix(proxy); err != nil {
+				return nil, fmt.Errorf("invalid CIDR %q", proxy)
 			}
+		} else if _, err := netip.ParseAddr(proxy); err != nil {
+			return nil, fmt.Errorf("invalid IP %q", proxy)
 		}
 	}
+	return proxies, nil
+}
+
+// validProxyHeader reports whether header can carry client IPs. The client
+// IP is read from the whole header value, so X-Forwarded-For, which proxies
+// append to and whose leftmost entry the client controls, is refused.
+func validProxyHeader(header string) bool {
+	return header != "" && !strings.EqualFold(header, "X-Forwarded-For")
+}
+
+// ClientIPHeader returns the header client IPs are read from, or "" to use
+// the connection's remote address. The header is only used once trusted
+// proxies are listed, so clients cannot spoof their address by default.
+func (cfg *Config) ClientIPHeader() string {
+	if !cfg.TrustProxyHeaders || len(cfg.TrustedProxies) == 0 {
+		return ""
+	}
+	return cfg.ProxyHeader
+}
+
+// validTicketURL reports whether value is an http(s) URL containing the {id}
+// placeholder
+func validTicketURL(value string) bool {
+	if !strings.Contains(value, "{id}") {
+		return false
+	}
+	u, err := url.Parse(strings.ReplaceAll(value, "{id}", "id"))
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
 
+// validateSupportSettings checks the support inbox and ticket URLs
+func (cfg *Config) validateSupportSettings() error {
+	if cfg.SupportEmail != "" && !validEmailAddress(cfg.SupportEmail) {
+		return errors.New("SUPPORT_EMAIL must be an email address")
+	}
+	if !validTicketURL(cfg.SupportTicketURL) {
+		return errors.New("SUPPORT_TICKET_URL must be an http(s) URL containing {id}")
+	}
+	if !validTicketURL(cfg.SupportStaffTicketURL) {
+		return errors.New("SUPPORT_STAFF_TICKET_URL must be an http(s) URL containing {id}")
+	}
 	return nil
 }
+
+// TicketURL returns the link to a support ticket, for staff or for the
+// ticket's owner
+func (cfg *Config) TicketURL(ticketID string, staff bool) string {
+	link := cfg.SupportTicketURL
+	if staff {
+		link = cfg.SupportStaffTicketURL
+	}
+	return strings.ReplaceAll(link, "{id}", url.PathEscape(ticketID))
+}
+
+// WebhookDebounceWindow returns how long repeat dispatches of event to the
+// same webhook are skipped after one is sent; 0 disables debouncing
+func (cfg *Config) WebhookDebounceWindow(event string) time.Duration {
+	if window, ok := cfg.WebhookDebounce[event]; ok {
+		return window
+	}
+	return cfg.WebhookDebounce["*"]
+}
+
+// APIRateLimit returns the requests per minute allowed per caller on a route
+// group; 0 means the group is not limited
+func (cfg *Config) APIRateLimit(group string) int {
+	if !cfg.APIRateLimitEnabled {
+		return 0
+	}
+	if limit, ok := cfg.APIRateLimits[group]; ok {
+		return limit
+	}
+	return DefaultAPIRateLimits[group]
+}
+
+// normalizeSameSite returns the canonical SameSite mode for value (any case),
+// or "" if it is not Strict, Lax or None
+func normalizeSameSite(value string) string {
+	for _, mode := range []string{"Strict", "Lax", "None"} {
+		if strings.EqualFold(strings.TrimSpace(value), mode) {
+			return mode
+		}
+	}
+	return ""
+}
+
+// normalizeLogLevel returns value lowercased if it is one of LogLevels, or ""
+func normalizeLogLevel(value string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	for _, level := range LogLevels {
+		if value == level {
+			return level
+		}
+	}
+	return ""
+}
+
+// ParseLogLevel converts one of LogLevels (any case) to a zerolog level
+func ParseLogLevel(value string) (zerolog.Level, error) {
+	level := normalizeLogLevel(value)
+	if level == "" {
+		return zerolog.NoLevel, fmt.Errorf("invalid log level %q, expected one of %s", value, strings.Join(LogLevels, ", "))
+	}
+	return zerolog.ParseLevel(level)
+}
+
+// ZerologLevel returns the configured log level
+func (cfg *Config) ZerologLevel() zerolog.Level {
+	level, err := ParseLogLevel(cfg.LogLevel)
+	if err != nil {
+		return zerolog.InfoLevel
+	}
+	return level
+}
+
+// normalizePanelURL normalizes a panel base URL, keeping the raw value when
+// it is not a valid URL so the misconfiguration stays visible
+func normalizePanelURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	if normalized, err := panels.NormalizeBaseURL(raw); err == nil {
+		return normalized
+	}
+	return raw
+}
+
+// ParseAutoSyncCron parses an auto-sync schedule written as a standard
+// 5-field cron expression (e.g. "0 4 * * *" for 4 AM daily)
+func ParseAutoSyncCron(spec string) (cron.Schedule, error) {
+	return cron.ParseStandard(spec)
+}
+
+// SyncMaxAge returns how long a sync may run before it is treated as stuck
+func (cfg *Config) SyncMaxAge() time.Duration {
+	if cfg.SyncMaxDuration <= 0 {
+		return 120 * time.Minute
+	}
+	return time.Duration(cfg.SyncMaxDuration) * time.Minute
+}
+
+// StatsCacheTTL returns how long a cached public stats response stays fresh
+func (cfg *Config) StatsCacheTTL() time.Duration {
+	if cfg.CacheTimeout <= 0 {
+		return DefaultCacheTimeout * time.Second
+	}
+	return time.Duration(cfg.CacheTimeout) * time.Second
+}
+
+// TombstoneGracePeriod returns how long a tombstoned server is kept before
+// the janitor may purge it
+func (cfg *Config) TombstoneGracePeriod() time.Duration {
+	if cfg.SyncTombstoneGracePeriod < 0 {
+		return 0
+	}
+	return time.Duration(cfg.SyncTombstoneGracePeriod) * time.Hour
+}
+
+// DefaultHytaleRefreshLeadMinutes refreshes Hytale's 1-hour tokens and game
+// sessions 45 minutes into their lifetime
+const DefaultHytaleRefreshLeadMinutes = 15
+
+// HytaleRefreshLead returns how long before expiry Hytale OAuth tokens and
+// game sessions are refreshed
+func (cfg *Config) HytaleRefreshLead() time.Duration {
+	if cfg.HytaleRefreshLeadMinutes <= 0 {
+		return DefaultHytaleRefreshLeadMinutes * time.Minute
+	}
+	return time.Duration(cfg.HytaleRefreshLeadMinutes) * time.Minute
+}
+
+// ShutdownDurations returns how long /ready reports draining before the
+// server stops, how long in-flight requests are waited for, and how long
+// queued Sentry events are flushed for
+func (cfg *Config) ShutdownDurations() (drain, timeout, sentryFlush time.Duration) {
+	return time.Duration(cfg.ShutdownDrainPeriod) * time.Second,
+		time.Duration(cfg.ShutdownTimeout) * time.Second,
+		time.Duration(cfg.SentryFlushTimeout) * time.Second
+}
+
+// PanelConnectionPool returns the idle connection limits for panel clients
+func (cfg *Config) PanelConnectionPool() panels.ConnectionPool {
+	return panels.ConnectionPool{
+		MaxIdleConns:        cfg.PanelMaxIdleConns,
+		MaxIdleConnsPerHost: cfg.PanelMaxIdleConnsPerHost,
+		IdleConnTimeout:     time.Duration(cfg.PanelIdleConnTimeout) * time.Second,
+	}
+}
+
+// QueueRouting returns the queue each task group is enqueued on. Unknown
+// queue names fall back to queue.DefaultRouting.
+func (cfg *Config) QueueRouting() queue.Routing {
+	return queue.Routing{
+		Email:   cfg.EmailQueue,
+		Webhook: cfg.WebhookQueue,
+		Sync:    cfg.SyncQueue,
+	}
+}
+
+// TokenTTL returns how long a token of the given type stays valid. Email
+// verification and email change tokens share the verification lifetime.
+// Unset or invalid values fall back to the database package defaults.
+func (cfg *Config) TokenTTL(tokenType string) time.Duration {
+	minutes := 0
+	fallback := database.TokenExpiration
+
+	switch tokenType {
+	case database.VerificationTokenType, database.EmailChangeTokenType:
+		minutes = cfg.VerificationTokenTTL
+	case database.PasswordResetTokenType:
+		minutes = cfg.PasswordResetTokenTTL
+	case database.MagicLinkTokenType:
+		minutes = cfg.MagicLinkTokenTTL
+		fallback = database.MagicLinkExpiration
+	}
+
+	if minutes <= 0 {
+		return fallback
+	}
+	return time.Duration(minutes) * time.Minute
+}
+
+// PaginationLimits returns the default and maximum page sizes for list
+// endpoints. Unset values fall back to the built-in defaults, and the default
+// never exceeds the maximum.
+func (cfg *Config) PaginationLimits() (defaultLimit, maxLimit int) {
+	defaultLimit, maxLimit = cfg.PaginationDefaultLimit, cfg.PaginationMaxLimit
+	if defaultLimit <= 0 {
+		defaultLimit = DefaultPaginationLimit
+	}
+	if maxLimit <= 0 {
+		maxLimit = DefaultPaginationMaxLimit
+	}
+	if defaultLimit > maxLimit {
+		defaultLimit = maxLimit
+	}
+	return defaultLimit, maxLimit
+}
+
+// EmailDomainAllowed reports whether an email address may be used to register.
+// Blocked domains take precedence over allowed ones, and an empty allow-list
+// permits every domain that is not blocked.
+func (cfg *Config) EmailDomainAllowed(email string) bool {
+	at := strings.LastIndex(email, "@")
+	if at < 0 {
+		return false
+	}
+	domain := strings.ToLower(strings.TrimSpace(email[at+1:]))
+
+	for _, pattern := range cfg.RegistrationBlockedDomains {
+		if domainMatches(domain, pattern) {
+			return false
+		}
+	}
+	if len(cfg.RegistrationAllowedDomains) == 0 {
+		return true
+	}
+	for _, pattern := range cfg.RegistrationAllowedDomains {
+		if domainMatches(domain, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// domainMatches reports whether domain matches pattern. "*.example.com"
+// matches any subdomain of example.com but not example.com itself.
+func domainMatches(domain, pattern string) bool {
+	if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+		return strings.HasSuffix(domain, "."+suffix)
+	}
+	return domain == pattern
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"net/url"
+	"os"
+	"reflect"
+)
+
+// Sources of a configuration value, as reported by Effective
+const (
+	SourceDefault = "default" // built-in default
+	SourceEnv     = "env"     // environment variable
+	SourceDB      = "db"      // `config` table, applied by MergeFromDB
+)
+
+// maskedValue replaces secret values in the effective configuration
+const maskedValue = "********"
+
+// EffectiveValue is one loaded configuration value and where it came from
+type EffectiveValue struct {
+	Name   string      `json:"name"`
+	Env    string      `json:"env,omitempty"` // environment variable that sets the value
+	Value  interface{} `json:"value"`
+	Source string      `json:"source"`
+	Secret bool        `json:"secret,omitempty"` // value is masked
+}
+
+// recordEnvSources marks every field whose environment variable is set
+func (cfg *Config) recordEnvSources() {
+	t := reflect.TypeOf(*cfg)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if env := field.Tag.Get("env"); env != "" && os.Getenv(env) != "" {
+			cfg.setSource(field.Name, SourceEnv)
+		}
+	}
+}
+
+// recordChanges marks every field that differs from before as coming from source
+func (cfg *Config) recordChanges(before *Config, source string) {
+	t := reflect.TypeOf(*cfg)
+	current, previous := reflect.ValueOf(cfg).Elem(), reflect.ValueOf(before).Elem()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if !reflect.DeepEqual(current.Field(i).Interface(), previous.Field(i).Interface()) {
+			cfg.setSource(field.Name, source)
+		}
+	}
+}
+
+func (cfg *Config) setSource(field, source string) {
+	if cfg.sources == nil {
+		cfg.sources = map[string]string{}
+	}
+	cfg.sources[field] = source
+}
+
+// Effective returns every configuration value with its source, in field
+// order. Secrets are masked and URL credentials are stripped, so the result
+// is safe to show to admins.
+func (cfg *Config) Effective() []EffectiveValue {
+	t := reflect.TypeOf(*cfg)
+	v := reflect.ValueOf(*cfg)
+
+	values := make([]EffectiveValue, 0, t.NumField())
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+
+		source := cfg.sources[field.Name]
+		if source == "" {
+			source = SourceDefault
+		}
+
+		value := v.Field(i).Interface()
+		secret := field.Tag.Get("secret")
+		switch {
+		case secret == "url":
+			value = redactURL(v.Field(i).String())
+		case secret != "" && !v.Field(i).IsZero():
+			value = maskedValue
+		}
+
+		values = append(values, EffectiveValue{
+			Name:   field.Name,
+			Env:    field.Tag.Get("env"),
+			Value:  value,
+			Source: source,
+			Secret: secret != "",
+		})
+	}
+	return values
+}
+
+// redactURL masks the password of a connection URL, or the whole value if
+// it cannot be parsed
+func redactURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		return maskedValue
+	}
+	return u.Redacted()
+}
@@ -0,0 +1,97 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Admin audit actions
+const (
+	AuditImpersonationStarted = "IMPERSONATION_STARTED"
+	AuditImpersonationEnded   = "IMPERSONATION_ENDED"
+	AuditRoleCreated          = "ROLE_CREATED"
+	AuditRoleUpdated          = "ROLE_UPDATED"
+	AuditRoleDeleted          = "ROLE_DELETED"
+	AuditInviteCreated        = "INVITE_CREATED"
+	AuditInviteRevoked        = "INVITE_REVOKED"
+	AuditUsersImported        = "USERS_IMPORTED"
+	AuditNodeMaintenance      = "NODE_MAINTENANCE"
+	AuditAPIKeyCreated        = "API_KEY_CREATED"
+	AuditAPIKeyRevoked        = "API_KEY_REVOKED"
+	AuditAPIKeyUsed           = "API_KEY_USED"
+	AuditEggVariableUpdated   = "EGG_VARIABLE_UPDATED"
+	AuditHytaleTokensPushed   = "HYTALE_TOKENS_PUSHED"
+	AuditServerSuspended      = "SERVER_SUSPENDED"
+	AuditServerUnsuspended    = "SERVER_UNSUSPENDED"
+	AuditAllocationsCreated   = "ALLOCATIONS_CREATED"
+	AuditLogLevelChanged      = "LOG_LEVEL_CHANGED"
+	AuditServerNotesUpdated   = "SERVER_NOTES_UPDATED"
+	AuditUserMigrationSet     = "USER_MIGRATION_SET"
+)
+
+// AdminAuditEntry describes an administrator action to record
+type AdminAuditEntry struct {
+	ActorID    string
+	Action     string
+	TargetType string
+	TargetID   string
+	Details    map[string]interface{}
+	IPAddress  string
+	UserAgent  string
+}
+
+// CreateAdminAuditLog records an administrator action
+func (db *DB) CreateAdminAuditLog(ctx context.Context, entry AdminAuditEntry) error {
+	details := []byte("{}")
+	if entry.Details != nil {
+		var err error
+		if details, err = json.Marshal(entry.Details); err != nil {
+			return err
+		}
+	}
+
+	_, err := db.Pool.Exec(ctx, `
+		INSERT INTO admin_audit_logs (id, "actorId", action, "targetType", "targetId", details, "ipAddress", "userAgent", "createdAt")
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW())
+	`, uuid.New().String(), NewNullString(entry.ActorID), entry.Action, NewNullString(entry.TargetType),
+		NewNullString(entry.TargetID), string(details), NewNullString(entry.IPAddress), NewNullString(entry.UserAgent))
+	return err
+}
+
+// CreateImpersonationSession records an issued impersonation token by its id
+func (db *DB) CreateImpersonationSession(ctx context.Context, id, adminID, userID string, expiresAt time.Time) error {
+	_, err := db.Pool.Exec(ctx, `
+		INSERT INTO impersonation_sessions (id, "adminId", "userId", "expiresAt", "createdAt")
+		VALUES ($1, $2, $3, $4, NOW())
+	`, id, adminID, userID, expiresAt)
+	return err
+}
+
+// IsImpersonationSessionActive reports whether an impersonation session has
+// neither ended nor expired
+func (db *DB) IsImpersonationSessionActive(ctx context.Context, id string) (bool, error) {
+	var active bool
+	err := db.Pool.QueryRow(ctx, `
+		SELECT EXISTS(
+			SELECT 1 FROM impersonation_sessions
+			WHERE id = $1 AND "endedAt" IS NULL AND "expiresAt" > NOW()
+		)
+	`, id).Scan(&active)
+	return active, err
+}
+
+// EndImpersonationSession marks an impersonation session as ended. It returns
+// false if the session was not found or had already ended.
+func (db *DB) EndImpersonationSession(ctx context.Context, id string) (bool, error) {
+	res, err := db.Pool.Exec(ctx, `
+		UPDATE impersonation_sessions SET "endedAt" = NOW()
+		WHERE id = $1 AND "endedAt" IS NULL
+	`, id)
+	if err != nil {
+		return false, err
+	}
+	return res.RowsAffected() > 0, nil
+}
@@ -0,0 +1,164 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+const (
+	// apiKeyPrefix marks generated backend API keys
+	apiKeyPrefix = "nbk_"
+	// apiKeySecretBytes is how many random bytes a generated key holds
+	apiKeySecretBytes = 32
+	// apiKeyDisplayLength is how much of a key is kept for identifying it
+	apiKeyDisplayLength = 12
+	// apiKeyUsedInterval is how stale lastUsedAt may get before a request
+	// writes it again
+	apiKeyUsedInterval = time.Minute
+	// APIKeyScopeFull grants access to every API-key protected route
+	APIKeyScopeFull = "full"
+)
+
+// APIKey is a stored backend API key. The key itself is never stored.
+type APIKey struct {
+	ID         string     `json:"id"`
+	Label      string     `json:"label"`
+	KeyPrefix  string     `json:"keyPrefix"`
+	Scope      string     `json:"scope"`
+	CreatedBy  string     `json:"createdBy,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	RevokedAt  *time.Time `json:"revokedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// HashAPIKey returns the hex SHA-256 of an API key as stored in api_keys
+func HashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// generateAPIKey returns a new random API key
+func generateAPIKey() (string, error) {
+	b := make([]byte, apiKeySecretBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + hex.EncodeToString(b), nil
+}
+
+// isGeneratedAPIKey reports whether secret has the shape of a generated key,
+// so malformed keys are turned away without a database lookup
+func isGeneratedAPIKey(secret string) bool {
+	if len(secret) != len(apiKeyPrefix)+2*apiKeySecretBytes || !strings.HasPrefix(secret, apiKeyPrefix) {
+		return false
+	}
+	_, err := hex.DecodeString(secret[len(apiKeyPrefix):])
+	return err == nil
+}
+
+const apiKeyColumns = `id, label, "keyPrefix", scope, COALESCE("createdBy", ''), "lastUsedAt", "revokedAt", "createdAt"`
+
+func scanAPIKey(row interface{ Scan(...any) error }) (*APIKey, error) {
+	var k APIKey
+	if err := row.Scan(&k.ID, &k.Label, &k.KeyPrefix, &k.Scope, &k.CreatedBy, &k.LastUsedAt, &k.RevokedAt, &k.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+// CreateAPIKey stores a new API key with a generated secret. The secret is
+// returned alongside the stored key and cannot be recovered later.
+func (db *DB) CreateAPIKey(ctx context.Context, label, createdBy string) (*APIKey, string, error) {
+	secret, err := generateAPIKey()
+	if err != nil {
+		return nil, "", err
+	}
+	row := db.Pool.QueryRow(ctx, `
+		INSERT INTO api_keys (id, label, "keyHash", "keyPrefix", scope, "createdBy", "createdAt")
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		RETURNING `+apiKeyColumns,
+		uuid.New().String(), label, HashAPIKey(secret), secret[:apiKeyDisplayLength], APIKeyScopeFull,
+		NewNullString(createdBy))
+	key, err := scanAPIKey(row)
+	if err != nil {
+		return nil, "", err
+	}
+	return key, secret, nil
+}
+
+// ListAPIKeys returns stored API keys, newest first. Revoked keys are only
+// included when includeRevoked is set.
+func (db *DB) ListAPIKeys(ctx context.Context, includeRevoked bool) ([]APIKey, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+apiKeyColumns+`
+		FROM api_keys
+		WHERE $1 OR "revokedAt" IS NULL
+		ORDER BY "createdAt" DESC
+	`, includeRevoked)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []APIKey{}
+	for rows.Next() {
+		k, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *k)
+	}
+	return keys, rows.Err()
+}
+
+// RevokeAPIKey revokes an API key so it is no longer accepted. It returns
+// false if the key does not exist or was already revoked.
+func (db *DB) RevokeAPIKey(ctx context.Context, id string) (bool, error) {
+	res, err := db.Pool.Exec(ctx, `
+		UPDATE api_keys SET "revokedAt" = NOW() WHERE id = $1 AND "revokedAt" IS NULL
+	`, id)
+	if err != nil {
+		return false, err
+	}
+	return res.RowsAffected() > 0, nil
+}
+
+// UseAPIKey looks up an active API key by its secret and records that it was
+// used. lastUsedAt is only written once it is more than apiKeyUsedInterval
+// old, so busy integrations do not write on every request. It returns nil if
+// the key is malformed, unknown or revoked.
+func (db *DB) UseAPIKey(ctx context.Context, secret string) (*APIKey, error) {
+	if !isGeneratedAPIKey(secret) {
+		return nil, nil
+	}
+
+	var stale bool
+	var k APIKey
+	err := db.Pool.QueryRow(ctx, `
+		SELECT `+apiKeyColumns+`, "lastUsedAt" IS NULL OR "lastUsedAt" < NOW() - make_interval(secs => $2)
+		FROM api_keys
+		WHERE "keyHash" = $1 AND "revokedAt" IS NULL
+	`, HashAPIKey(secret), apiKeyUsedInterval.Seconds()).Scan(
+		&k.ID, &k.Label, &k.KeyPrefix, &k.Scope, &k.CreatedBy, &k.LastUsedAt, &k.RevokedAt, &k.CreatedAt, &stale)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if stale {
+		if _, err := db.Pool.Exec(ctx, `UPDATE api_keys SET "lastUsedAt" = NOW() WHERE id = $1`, k.ID); err != nil {
+			return nil, err
+		}
+	}
+	return &k, nil
+}
@@ -5,16 +5,24 @@ import (
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// ErrEmailTaken is returned when an email change would give the user an
+// address another user already has
+var ErrEmailTaken = errors.New("email already in use")
+
 const (
 	VerificationTokenType  = "email_verification"
 	PasswordResetTokenType = "password_reset"
 	MagicLinkTokenType     = "magic_link"
+	EmailChangeTokenType   = "email_change"
 	TokenExpiration        = 24 * time.Hour
 	MagicLinkExpiration    = 30 * time.Minute
 )
@@ -38,6 +46,7 @@ func (db *DB) QueryUserByEmail(ctx context.Context, email string) (*User, error)
 			id, email, password, username, "firstName", "lastName", 
 			roles, "isPterodactylAdmin", "isVirtfusionAdmin", "isSystemAdmin",
 			"pterodactylId", "emailVerified", "isActive", "avatarUrl",
+			COALESCE("passwordResetRequired", false),
 			"createdAt", "updatedAt", "lastLoginAt"
 		FROM users 
 		WHERE email = $1`,
@@ -48,6 +57,7 @@ func (db *DB) QueryUserByEmail(ctx context.Context, email string) (*User, error)
 		&user.Roles, &user.IsPterodactylAdmin, &user.IsVirtfusionAdmin,
 		&user.IsSystemAdmin, &user.PterodactylID, &user.EmailVerified,
 		&user.IsActive, &user.AvatarURL,
+		&user.PasswordResetRequired,
 		&user.CreatedAt, &user.UpdatedAt, &user.LastLoginAt,
 	)
 
@@ -67,6 +77,7 @@ func (db *DB) QueryUserByID(ctx context.Context, id string) (*User, error) {
 			id, email, password, username, "firstName", "lastName", 
 			roles, "isPterodactylAdmin", "isVirtfusionAdmin", "isSystemAdmin",
 			"pterodactylId", "emailVerified", "isActive", "avatarUrl",
+			COALESCE("passwordResetRequired", false),
 			"createdAt", "updatedAt", "lastLoginAt"
 		FROM users 
 		WHERE id = $1`,
@@ -77,6 +88,7 @@ func (db *DB) QueryUserByID(ctx context.Context, id string) (*User, error) {
 		&user.Roles, &user.IsPterodactylAdmin, &user.IsVirtfusionAdmin,
 		&user.IsSystemAdmin, &user.PterodactylID, &user.EmailVerified,
 		&user.IsActive, &user.AvatarURL,
+		&user.PasswordResetRequired,
 		&user.CreatedAt, &user.UpdatedAt, &user.LastLoginAt,
 	)
 
@@ -240,7 +252,10 @@ func (db *DB) ResetUserPassword(ctx context.Context, userID, token, newPassword
 	// Update password and delete token in transaction
 	_, err = db.Pool.Exec(ctx,
 		`BEGIN;
-		UPDATE users SET password = $1, "updatedAt" = NOW() WHERE id = $2;
+		UPDATE users SET password = $1,
+			"emailVerified" = CASE WHEN "passwordResetRequired" THEN COALESCE("emailVerified", NOW()) ELSE "emailVerified" END,
+			"passwordResetRequired" = false, "updatedAt" = NOW()
+		WHERE id = $2;
 		DELETE FROM verification_tokens WHERE identifier = $2 AND type = $3;
 		COMMIT;`,
 		string(hashedPassword), userID, PasswordResetTokenType,
@@ -291,6 +306,101 @@ func (db *DB) ConsumeMagicLinkToken(ctx context.Context, token string) (string,
 	return userID, nil
 }
 
+// StoreEmailChangeRequest records newEmail as the user's pending email and
+// returns a confirmation token for it that is valid for expiration. Any
+// earlier pending request is replaced.
+func (db *DB) StoreEmailChangeRequest(ctx context.Context, userID, newEmail string, expiration time.Duration) (string, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	_, err = tx.Exec(ctx,
+		`UPDATE users SET "pendingEmail" = $1, "updatedAt" = NOW() WHERE id = $2`,
+		newEmail, userID,
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to store pending email: %w", err)
+	}
+
+	_, err = tx.Exec(ctx,
+		`DELETE FROM verification_tokens WHERE identifier = $1 AND type = $2`,
+		userID, EmailChangeTokenType,
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to clear previous email change tokens: %w", err)
+	}
+
+	token := generateRandomToken()
+	_, err = tx.Exec(ctx,
+		`INSERT INTO verification_tokens (identifier, token, type, expires) VALUES ($1, $2, $3, $4)`,
+		userID, hashToken(token), EmailChangeTokenType, time.Now().Add(expiration),
+	)
+	if err != nil {
+		return "", fmt.Errorf("failed to store verification token: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// ConfirmEmailChange consumes an email change token and swaps the user's
+// email for the pending one. It returns the new email address, or
+// ErrEmailTaken if another user took the address after it was requested.
+func (db *DB) ConfirmEmailChange(ctx context.Context, token string) (string, error) {
+	hashedToken := hashToken(token)
+
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback(ctx)
+
+	var userID string
+	err = tx.QueryRow(ctx,
+		`DELETE FROM verification_tokens
+		WHERE token = $1 AND type = $2 AND expires > NOW()
+		RETURNING identifier`,
+		hashedToken, EmailChangeTokenType,
+	).Scan(&userID)
+	if err != nil {
+		return "", fmt.Errorf("invalid or expired token")
+	}
+
+	var newEmail string
+	err = tx.QueryRow(ctx,
+		`UPDATE users
+		SET email = "pendingEmail", "pendingEmail" = NULL, "emailVerified" = NOW(), "updatedAt" = NOW()
+		WHERE id = $1 AND "pendingEmail" IS NOT NULL
+			AND NOT EXISTS (SELECT 1 FROM users other WHERE other.email = users."pendingEmail" AND other.id <> users.id)
+		RETURNING email`,
+		userID,
+	).Scan(&newEmail)
+	if errors.Is(err, pgx.ErrNoRows) {
+		var pending *string
+		if err := tx.QueryRow(ctx, `SELECT "pendingEmail" FROM users WHERE id = $1`, userID).Scan(&pending); err == nil && pending != nil {
+			return "", ErrEmailTaken
+		}
+		return "", fmt.Errorf("no pending email change")
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return "", ErrEmailTaken
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to confirm email change: %w", err)
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return "", err
+	}
+
+	return newEmail, nil
+}
+
 // UpdateLastLogin updates the user's last login timestamp
 func (db *DB) UpdateLastLogin(ctx context.Context, userID string) error {
 	_, err := db.Pool.Exec(ctx,
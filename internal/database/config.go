@@ -2,8 +2,14 @@ package database
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 )
 
+// ErrConfigModified is returned when config changed after the caller's version
+var ErrConfigModified = errors.New("config modified since last read")
+
 // GetConfig retrieves a configuration value
 func (db *DB) GetConfig(ctx context.Context, key string) (string, error) {
 	var value string
@@ -25,6 +31,60 @@ func (db *DB) SetConfig(ctx context.Context, key, value string) error {
 	return err
 }
 
+// GetConfigVersion returns the latest config update time. It acts as the
+// settings version for optimistic concurrency checks.
+func (db *DB) GetConfigVersion(ctx context.Context) (time.Time, error) {
+	var version time.Time
+	err := db.Pool.QueryRow(ctx, `SELECT COALESCE(MAX("updatedAt"), 'epoch'::timestamp) FROM config`).Scan(&version)
+	return version, err
+}
+
+// SetConfigsIfUnmodified saves values in one transaction. It returns
+// ErrConfigModified if any of the keys being written was updated after
+// version; changes to other keys do not conflict. A zero version skips the
+// check.
+func (db *DB) SetConfigsIfUnmodified(ctx context.Context, values map[string]string, version time.Time) error {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	// Serialise concurrent saves so the version check and writes are atomic
+	if _, err := tx.Exec(ctx, `LOCK TABLE config IN SHARE ROW EXCLUSIVE MODE`); err != nil {
+		return err
+	}
+
+	if !version.IsZero() {
+		keys := make([]string, 0, len(values))
+		for key := range values {
+			keys = append(keys, key)
+		}
+		var current time.Time
+		if err := tx.QueryRow(ctx, `
+			SELECT COALESCE(MAX("updatedAt"), 'epoch'::timestamp) FROM config WHERE key = ANY($1)
+		`, keys).Scan(&current); err != nil {
+			return err
+		}
+		if current.After(version) {
+			return ErrConfigModified
+		}
+	}
+
+	for key, value := range values {
+		_, err := tx.Exec(ctx, `
+			INSERT INTO config (id, key, value, "updatedAt")
+			VALUES (gen_random_uuid()::text, $1, $2, NOW())
+			ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, "updatedAt" = NOW()
+		`, key, value)
+		if err != nil {
+			return fmt.Errorf("failed to save setting %s: %w", key, err)
+		}
+	}
+
+	return tx.Commit(ctx)
+}
+
 // GetAllConfigs retrieves all configuration as a map
 func (db *DB) GetAllConfigs(ctx context.Context) (map[string]string, error) {
 	rows, err := db.Pool.Query(ctx, `SELECT key, value FROM config`)
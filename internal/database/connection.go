@@ -13,8 +13,9 @@ type DB struct {
 	Pool *pgxpool.Pool
 }
 
-// NewConnection creates a new database connection pool
-func NewConnection(databaseURL string) (*DB, error) {
+// NewConnection creates a new database connection pool. Queries slower than
+// slowQueryThreshold are logged; a zero threshold disables slow query logging.
+func NewConnection(databaseURL string, slowQueryThreshold time.Duration) (*DB, error) {
 	config, err := pgxpool.ParseConfig(databaseURL)
 	if err != nil {
 		return nil, err
@@ -27,6 +28,10 @@ func NewConnection(databaseURL string) (*DB, error) {
 	config.MaxConnIdleTime = 30 * time.Minute
 	config.HealthCheckPeriod = time.Minute
 
+	if slowQueryThreshold > 0 {
+		config.ConnConfig.Tracer = &slowQueryTracer{threshold: slowQueryThreshold}
+	}
+
 	// Create connection pool
 	pool, err := pgxpool.NewWithConfig(context.Background(), config)
 	if err != nil {
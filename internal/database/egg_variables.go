@@ -0,0 +1,112 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// EggVariableOverrides are admin-set values that take precedence over the
+// synced ones. A nil field keeps the panel's value.
+type EggVariableOverrides struct {
+	UserViewable *bool `json:"userViewable"`
+	UserEditable *bool `json:"userEditable"`
+	DisplayOrder *int  `json:"displayOrder"`
+}
+
+// EggVariableDetail is a synced egg variable with its local overrides applied
+type EggVariableDetail struct {
+	ID                int                  `json:"id"`
+	EggID             int                  `json:"eggId"`
+	Name              string               `json:"name"`
+	Description       string               `json:"description"`
+	EnvVariable       string               `json:"envVariable"`
+	DefaultValue      string               `json:"defaultValue"`
+	Rules             string               `json:"rules"`
+	UserViewable      bool                 `json:"userViewable"` // effective value
+	UserEditable      bool                 `json:"userEditable"` // effective value
+	PanelUserViewable bool                 `json:"panelUserViewable"`
+	PanelUserEditable bool                 `json:"panelUserEditable"`
+	Overrides         EggVariableOverrides `json:"overrides"`
+}
+
+const eggVariableColumns = `id, "eggId", name, COALESCE(description, ''), "envVariable", COALESCE("defaultValue", ''), COALESCE(rules, ''),
+	COALESCE("userViewable", true), COALESCE("userEditable", true),
+	"overrideUserViewable", "overrideUserEditable", "displayOrder"`
+
+func scanEggVariable(row interface{ Scan(...any) error }) (*EggVariableDetail, error) {
+	var v EggVariableDetail
+	if err := row.Scan(&v.ID, &v.EggID, &v.Name, &v.Description, &v.EnvVariable, &v.DefaultValue, &v.Rules,
+		&v.PanelUserViewable, &v.PanelUserEditable,
+		&v.Overrides.UserViewable, &v.Overrides.UserEditable, &v.Overrides.DisplayOrder); err != nil {
+		return nil, err
+	}
+
+	v.UserViewable, v.UserEditable = v.PanelUserViewable, v.PanelUserEditable
+	if v.Overrides.UserViewable != nil {
+		v.UserViewable = *v.Overrides.UserViewable
+	}
+	if v.Overrides.UserEditable != nil {
+		v.UserEditable = *v.Overrides.UserEditable
+	}
+	return &v, nil
+}
+
+// EggExists reports whether an egg with the given ID exists
+func (db *DB) EggExists(ctx context.Context, id int) (bool, error) {
+	var exists bool
+	err := db.Pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM eggs WHERE id = $1)`, id).Scan(&exists)
+	return exists, err
+}
+
+// ListEggVariables returns an egg's variables in display order, then by ID
+func (db *DB) ListEggVariables(ctx context.Context, eggID int) ([]EggVariableDetail, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+eggVariableColumns+`
+		FROM egg_variables
+		WHERE "eggId" = $1
+		ORDER BY "displayOrder" NULLS LAST, id
+	`, eggID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	variables := []EggVariableDetail{}
+	for rows.Next() {
+		v, err := scanEggVariable(rows)
+		if err != nil {
+			return nil, err
+		}
+		variables = append(variables, *v)
+	}
+	return variables, rows.Err()
+}
+
+// GetEggVariable returns one of an egg's variables, or nil if there is none
+func (db *DB) GetEggVariable(ctx context.Context, eggID, variableID int) (*EggVariableDetail, error) {
+	v, err := scanEggVariable(db.Pool.QueryRow(ctx,
+		`SELECT `+eggVariableColumns+` FROM egg_variables WHERE id = $1 AND "eggId" = $2`,
+		variableID, eggID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return v, err
+}
+
+// SetEggVariableOverrides replaces a variable's overrides and returns the
+// updated variable, or nil if the egg has no such variable. The sync only
+// writes the panel columns, so overrides survive later syncs.
+func (db *DB) SetEggVariableOverrides(ctx context.Context, eggID, variableID int, o EggVariableOverrides) (*EggVariableDetail, error) {
+	v, err := scanEggVariable(db.Pool.QueryRow(ctx, `
+		UPDATE egg_variables
+		SET "overrideUserViewable" = $3, "overrideUserEditable" = $4, "displayOrder" = $5, "updatedAt" = NOW()
+		WHERE id = $1 AND "eggId" = $2
+		RETURNING `+eggVariableColumns,
+		variableID, eggID, o.UserViewable, o.UserEditable, o.DisplayOrder))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return v, err
+}
@@ -12,26 +12,27 @@ import (
 type AuditLogType string
 
 const (
-	AuditTokenCreated     AuditLogType = "TOKEN_CREATED"
-	AuditTokenRefreshed   AuditLogType = "TOKEN_REFRESHED"
-	AuditTokenDeleted     AuditLogType = "TOKEN_DELETED"
-	AuditAuthFailed       AuditLogType = "AUTH_FAILED"
-	AuditSessionCreated   AuditLogType = "SESSION_CREATED"
-	AuditSessionRefreshed AuditLogType = "SESSION_REFRESHED"
-	AuditSessionDeleted   AuditLogType = "SESSION_DELETED"
-	AuditProfileSelected  AuditLogType = "PROFILE_SELECTED"
+	AuditDeviceCodeRequested AuditLogType = "DEVICE_CODE_REQUESTED"
+	AuditTokenCreated        AuditLogType = "TOKEN_CREATED"
+	AuditTokenRefreshed      AuditLogType = "TOKEN_REFRESHED"
+	AuditTokenDeleted        AuditLogType = "TOKEN_DELETED"
+	AuditAuthFailed          AuditLogType = "AUTH_FAILED"
+	AuditSessionCreated      AuditLogType = "SESSION_CREATED"
+	AuditSessionRefreshed    AuditLogType = "SESSION_REFRESHED"
+	AuditSessionDeleted      AuditLogType = "SESSION_DELETED"
+	AuditProfileSelected     AuditLogType = "PROFILE_SELECTED"
 )
 
 // HytaleAuditLog represents an audit log entry for Hytale operations
 type HytaleAuditLog struct {
-	ID        string
-	AccountID string  // Hytale account UUID
-	ProfileID *string // Game profile UUID (optional)
-	EventType AuditLogType
-	Details   *string // JSON details about the event
-	IPAddress *string // IP address of the request origin
-	UserAgent *string // User agent string
-	CreatedAt time.Time
+	ID        string       `json:"id"`
+	AccountID string       `json:"account_id"`           // Hytale account UUID
+	ProfileID *string      `json:"profile_id,omitempty"` // Game profile UUID (optional)
+	EventType AuditLogType `json:"event_type"`
+	Details   *string      `json:"details,omitempty"`    // JSON details about the event
+	IPAddress *string      `json:"ip_address,omitempty"` // IP address of the request origin
+	UserAgent *string      `json:"user_agent,omitempty"` // User agent string
+	CreatedAt time.Time    `json:"created_at"`
 }
 
 // HytaleAuditLogRepository handles audit log database operations
@@ -44,6 +45,27 @@ func NewHytaleAuditLogRepository(db *DB) *HytaleAuditLogRepository {
 	return &HytaleAuditLogRepository{db: db}
 }
 
+// LogEvent records an audit log entry with its full request context
+func (r *HytaleAuditLogRepository) LogEvent(ctx context.Context, entry *HytaleAuditLog) error {
+	query := `
+		INSERT INTO hytale_audit_logs (account_id, profile_id, event_type, details, ip_address, user_agent, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, NOW())
+	`
+
+	_, err := r.db.Pool.Exec(ctx, query, entry.AccountID, entry.ProfileID, string(entry.EventType),
+		entry.Details, entry.IPAddress, entry.UserAgent)
+	if err != nil {
+		log.Error().
+			Err(err).
+			Str("account_id", entry.AccountID).
+			Str("event", string(entry.EventType)).
+			Msg("Failed to log Hytale audit event")
+		return err
+	}
+
+	return nil
+}
+
 // LogTokenCreated logs a token creation event
 func (r *HytaleAuditLogRepository) LogTokenCreated(ctx context.Context, accountID string, profileID *string, ipAddress *string) error {
 	query := `
@@ -268,6 +290,48 @@ func (r *HytaleAuditLogRepository) GetAuditLogs(ctx context.Context, accountID s
 	return logs, rows.Err()
 }
 
+// ListAuditLogs returns audit logs newest first, optionally filtered by
+// account and event type, along with the total number of matching entries
+func (r *HytaleAuditLogRepository) ListAuditLogs(ctx context.Context, accountID string, eventType AuditLogType, limit, offset int) ([]HytaleAuditLog, int, error) {
+	where := `WHERE ($1 = '' OR account_id::text = $1) AND ($2 = '' OR event_type = $2)`
+	args := []interface{}{accountID, string(eventType)}
+
+	var total int
+	if err := r.db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM hytale_audit_logs `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.Pool.Query(ctx, `
+		SELECT id, account_id, profile_id, event_type, details, ip_address, user_agent, created_at
+		FROM hytale_audit_logs `+where+`
+		ORDER BY created_at DESC
+		LIMIT $3 OFFSET $4`, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	logs := []HytaleAuditLog{}
+	for rows.Next() {
+		var entry HytaleAuditLog
+		if err := rows.Scan(
+			&entry.ID,
+			&entry.AccountID,
+			&entry.ProfileID,
+			&entry.EventType,
+			&entry.Details,
+			&entry.IPAddress,
+			&entry.UserAgent,
+			&entry.CreatedAt,
+		); err != nil {
+			return nil, 0, err
+		}
+		logs = append(logs, entry)
+	}
+
+	return logs, total, rows.Err()
+}
+
 // GetLatestAuditLog gets the most recent audit log for an account
 func (r *HytaleAuditLogRepository) GetLatestAuditLog(ctx context.Context, accountID string) (*HytaleAuditLog, error) {
 	query := `
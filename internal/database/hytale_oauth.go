@@ -3,7 +3,10 @@ package database
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"time"
+
+	"github.com/jackc/pgx/v5"
 )
 
 // HytaleOAuthToken represents stored Hytale OAuth tokens
@@ -101,6 +104,27 @@ func (r *HytaleOAuthRepository) GetOAuthToken(ctx context.Context, accountID str
 	return token, nil
 }
 
+// SetOAuthTokenOwner records the user who authorized an account. A user who
+// completes the device code flow for an account takes it over from any
+// previous owner.
+func (r *HytaleOAuthRepository) SetOAuthTokenOwner(ctx context.Context, accountID, userID string) error {
+	_, err := r.db.Pool.Exec(ctx,
+		`UPDATE hytale_oauth_tokens SET "userId" = $2, updated_at = $3 WHERE account_id = $1`,
+		accountID, userID, time.Now(),
+	)
+	return err
+}
+
+// IsOAuthTokenOwner reports whether userID authorized the account
+func (r *HytaleOAuthRepository) IsOAuthTokenOwner(ctx context.Context, accountID, userID string) (bool, error) {
+	var owned bool
+	err := r.db.Pool.QueryRow(ctx,
+		`SELECT EXISTS (SELECT 1 FROM hytale_oauth_tokens WHERE account_id::text = $1 AND "userId" = $2)`,
+		accountID, userID,
+	).Scan(&owned)
+	return owned, err
+}
+
 // UpdateProfileUUID updates the selected profile UUID
 func (r *HytaleOAuthRepository) UpdateProfileUUID(ctx context.Context, accountID string, profileUUID string) error {
 	_, err := r.db.Pool.Exec(ctx,
@@ -132,7 +156,7 @@ func (r *HytaleOAuthRepository) SaveGameSession(ctx context.Context, session *Hy
 	if result.RowsAffected() == 0 {
 		_, err := r.db.Pool.Exec(ctx,
 			`INSERT INTO hytale_game_sessions 
-			(id, account_id, profile_uuid, server_id, session_token, identity_token, 
+			(id, account_id, profile_uuid, "serverId", session_token, identity_token, 
 			 expires_at, created_at, updated_at)
 			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)`,
 			generateUUID(), session.AccountID, session.ProfileUUID, session.ServerID,
@@ -149,7 +173,7 @@ func (r *HytaleOAuthRepository) GetGameSession(ctx context.Context, accountID, p
 	session := &HytaleGameSession{}
 
 	err := r.db.Pool.QueryRow(ctx,
-		`SELECT id, account_id, profile_uuid, server_id, session_token, identity_token, 
+		`SELECT id, account_id, profile_uuid, "serverId", session_token, identity_token, 
 		 expires_at, created_at, updated_at
 		FROM hytale_game_sessions
 		WHERE account_id = $1 AND profile_uuid = $2`,
@@ -166,6 +190,123 @@ func (r *HytaleOAuthRepository) GetGameSession(ctx context.Context, accountID, p
 	return session, nil
 }
 
+// GetGameSessionByServer retrieves the game session linked to a server
+func (r *HytaleOAuthRepository) GetGameSessionByServer(ctx context.Context, serverID string) (*HytaleGameSession, error) {
+	session := &HytaleGameSession{}
+
+	err := r.db.Pool.QueryRow(ctx,
+		`SELECT id, account_id, profile_uuid, "serverId", session_token, identity_token,
+		 expires_at, created_at, updated_at
+		FROM hytale_game_sessions
+		WHERE "serverId" = $1
+		ORDER BY updated_at DESC
+		LIMIT 1`,
+		serverID,
+	).Scan(
+		&session.ID, &session.AccountID, &session.ProfileUUID, &session.ServerID, &session.SessionToken,
+		&session.IdentityToken, &session.ExpiresAt, &session.CreatedAt, &session.UpdatedAt,
+	)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return session, nil
+}
+
+// LinkedGameSession is a game session linked to a server, with the server's
+// panel UUID (empty if the server is not managed by the panel)
+type LinkedGameSession struct {
+	HytaleGameSession
+	ServerUUID string
+}
+
+// ListLinkedGameSessions retrieves every game session linked to a server
+func (r *HytaleOAuthRepository) ListLinkedGameSessions(ctx context.Context) ([]LinkedGameSession, error) {
+	rows, err := r.db.Pool.Query(ctx,
+		`SELECT gs.id, gs.account_id, gs.profile_uuid, gs."serverId", gs.session_token, gs.identity_token,
+		 gs.expires_at, gs.created_at, gs.updated_at, COALESCE(s.uuid, '')
+		FROM hytale_game_sessions gs
+		JOIN servers s ON s.id = gs."serverId"
+		ORDER BY gs."serverId"`,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []LinkedGameSession
+	for rows.Next() {
+		var session LinkedGameSession
+		err := rows.Scan(
+			&session.ID, &session.AccountID, &session.ProfileUUID, &session.ServerID, &session.SessionToken,
+			&session.IdentityToken, &session.ExpiresAt, &session.CreatedAt, &session.UpdatedAt, &session.ServerUUID,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+// LinkGameSessionToServer links a game session to a server so refreshed
+// tokens are pushed to it. A server is linked to at most one session, so any
+// other session linked to the server is unlinked. It returns nil if the
+// session does not exist.
+func (r *HytaleOAuthRepository) LinkGameSessionToServer(ctx context.Context, accountID, profileUUID, serverID string) (*HytaleGameSession, error) {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE hytale_game_sessions SET "serverId" = NULL, updated_at = $4
+		WHERE "serverId" = $3 AND NOT (account_id = $1 AND profile_uuid = $2)`,
+		accountID, profileUUID, serverID, time.Now(),
+	); err != nil {
+		return nil, err
+	}
+
+	session := &HytaleGameSession{}
+	err = tx.QueryRow(ctx,
+		`UPDATE hytale_game_sessions SET "serverId" = $3, updated_at = $4
+		WHERE account_id = $1 AND profile_uuid = $2
+		RETURNING id, account_id, profile_uuid, "serverId", session_token, identity_token,
+		 expires_at, created_at, updated_at`,
+		accountID, profileUUID, serverID, time.Now(),
+	).Scan(
+		&session.ID, &session.AccountID, &session.ProfileUUID, &session.ServerID, &session.SessionToken,
+		&session.IdentityToken, &session.ExpiresAt, &session.CreatedAt, &session.UpdatedAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// UnlinkServerGameSessions removes the game session link from a server and
+// returns how many sessions were unlinked
+func (r *HytaleOAuthRepository) UnlinkServerGameSessions(ctx context.Context, serverID string) (int64, error) {
+	res, err := r.db.Pool.Exec(ctx,
+		`UPDATE hytale_game_sessions SET "serverId" = NULL, updated_at = $2 WHERE "serverId" = $1`,
+		serverID, time.Now(),
+	)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected(), nil
+}
+
 // DeleteGameSession deletes a game session
 func (r *HytaleOAuthRepository) DeleteGameSession(ctx context.Context, accountID, profileUUID string) error {
 	_, err := r.db.Pool.Exec(ctx,
@@ -176,16 +317,32 @@ func (r *HytaleOAuthRepository) DeleteGameSession(ctx context.Context, accountID
 	return err
 }
 
-// GetAllOAuthTokens retrieves all OAuth tokens (for refresh scheduler)
+// GetAllOAuthTokens retrieves all OAuth tokens
 func (r *HytaleOAuthRepository) GetAllOAuthTokens(ctx context.Context) ([]*HytaleOAuthToken, error) {
-	rows, err := r.db.Pool.Query(ctx,
+	return r.queryOAuthTokens(ctx,
 		`SELECT id, account_id, access_token, refresh_token, access_token_expiry,
 		 profile_uuid, scope, created_at, updated_at, last_refreshed_at
 		FROM hytale_oauth_tokens
 		WHERE refresh_token IS NOT NULL AND refresh_token != ''
 		ORDER BY updated_at ASC`,
 	)
+}
+
+// GetOAuthTokensExpiringBefore retrieves refreshable OAuth tokens whose access
+// token expires before the given time, soonest first (for refresh scheduler)
+func (r *HytaleOAuthRepository) GetOAuthTokensExpiringBefore(ctx context.Context, before time.Time) ([]*HytaleOAuthToken, error) {
+	return r.queryOAuthTokens(ctx,
+		`SELECT id, account_id, access_token, refresh_token, access_token_expiry,
+		 profile_uuid, scope, created_at, updated_at, last_refreshed_at
+		FROM hytale_oauth_tokens
+		WHERE refresh_token IS NOT NULL AND refresh_token != '' AND access_token_expiry < $1
+		ORDER BY access_token_expiry ASC`,
+		before,
+	)
+}
 
+func (r *HytaleOAuthRepository) queryOAuthTokens(ctx context.Context, query string, args ...interface{}) ([]*HytaleOAuthToken, error) {
+	rows, err := r.db.Pool.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -208,15 +365,31 @@ func (r *HytaleOAuthRepository) GetAllOAuthTokens(ctx context.Context) ([]*Hytal
 	return tokens, rows.Err()
 }
 
-// GetAllGameSessions retrieves all active game sessions (for refresh scheduler)
+// GetAllGameSessions retrieves all active game sessions
 func (r *HytaleOAuthRepository) GetAllGameSessions(ctx context.Context) ([]*HytaleGameSession, error) {
-	rows, err := r.db.Pool.Query(ctx,
-		`SELECT id, account_id, profile_uuid, server_id, session_token, identity_token, 
+	return r.queryGameSessions(ctx,
+		`SELECT id, account_id, profile_uuid, "serverId", session_token, identity_token, 
 		 expires_at, created_at, updated_at
 		FROM hytale_game_sessions
 		ORDER BY updated_at ASC`,
 	)
+}
+
+// GetGameSessionsExpiringBefore retrieves game sessions that expire before the
+// given time, soonest first (for refresh scheduler)
+func (r *HytaleOAuthRepository) GetGameSessionsExpiringBefore(ctx context.Context, before time.Time) ([]*HytaleGameSession, error) {
+	return r.queryGameSessions(ctx,
+		`SELECT id, account_id, profile_uuid, "serverId", session_token, identity_token,
+		 expires_at, created_at, updated_at
+		FROM hytale_game_sessions
+		WHERE expires_at < $1
+		ORDER BY expires_at ASC`,
+		before,
+	)
+}
 
+func (r *HytaleOAuthRepository) queryGameSessions(ctx context.Context, query string, args ...interface{}) ([]*HytaleGameSession, error) {
+	rows, err := r.db.Pool.Query(ctx, query, args...)
 	if err != nil {
 		return nil, err
 	}
@@ -249,13 +422,102 @@ func (r *HytaleOAuthRepository) UpdateGameSessionRefresh(ctx context.Context, ac
 	return err
 }
 
-// UpdateGameSessionTokens updates the session and identity tokens for a game session
-func (r *HytaleOAuthRepository) UpdateGameSessionTokens(ctx context.Context, accountID, profileUUID, sessionToken, identityToken string) error {
+// UpdateGameSessionTokens updates the session and identity tokens and expiry for a game session
+func (r *HytaleOAuthRepository) UpdateGameSessionTokens(ctx context.Context, accountID, profileUUID, sessionToken, identityToken string, expiresAt time.Time) error {
 	_, err := r.db.Pool.Exec(ctx,
 		`UPDATE hytale_game_sessions 
-		SET session_token = $3, identity_token = $4, updated_at = $5
+		SET session_token = $3, identity_token = $4, expires_at = $5, updated_at = $6
 		WHERE account_id = $1 AND profile_uuid = $2`,
-		accountID, profileUUID, sessionToken, identityToken, time.Now(),
+		accountID, profileUUID, sessionToken, identityToken, expiresAt, time.Now(),
 	)
 	return err
 }
+
+// Hytale account session statuses reported by ListAccounts
+const (
+	HytaleSessionActive  = "active"  // At least one game session has not yet expired
+	HytaleSessionExpired = "expired" // Game sessions exist but all have expired
+	HytaleSessionNone    = "none"    // No game session has been created
+)
+
+// HytaleAccountSummary is an admin view of a stored Hytale account. Tokens are never included.
+type HytaleAccountSummary struct {
+	AccountID         string     `json:"account_id"`
+	AccessTokenExpiry time.Time  `json:"access_token_expiry"`
+	ProfileUUID       *string    `json:"profile_uuid,omitempty"` // Selected game profile UUID
+	Scope             string     `json:"scope"`
+	LastRefreshedAt   *time.Time `json:"last_refreshed_at,omitempty"`
+	SessionStatus     string     `json:"session_status"`
+	SessionCount      int        `json:"session_count"`
+	SessionExpiresAt  *time.Time `json:"session_expires_at,omitempty"` // Latest game session expiry
+	CreatedAt         time.Time  `json:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at"`
+}
+
+// ListAccounts returns stored Hytale accounts ordered by access token expiry, soonest first.
+// A positive expiresWithin limits results to accounts whose access token expires before
+// now+expiresWithin, including ones that have already expired.
+func (r *HytaleOAuthRepository) ListAccounts(ctx context.Context, expiresWithin time.Duration, limit, offset int) ([]HytaleAccountSummary, int, error) {
+	where := `WHERE ($1::timestamp IS NULL OR t.access_token_expiry < $1)`
+	var before *time.Time
+	if expiresWithin > 0 {
+		cutoff := time.Now().Add(expiresWithin)
+		before = &cutoff
+	}
+	args := []interface{}{before}
+
+	var total int
+	if err := r.db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM hytale_oauth_tokens t `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := r.db.Pool.Query(ctx, `
+		SELECT t.account_id::text, t.access_token_expiry, t.profile_uuid::text, t.scope,
+		       t.last_refreshed_at, t.created_at, t.updated_at,
+		       COUNT(s.id), MAX(s.expires_at)
+		FROM hytale_oauth_tokens t
+		LEFT JOIN hytale_game_sessions s ON s.account_id = t.account_id
+		`+where+`
+		GROUP BY t.id
+		ORDER BY t.access_token_expiry ASC
+		LIMIT $2 OFFSET $3`, append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	accounts := []HytaleAccountSummary{}
+	for rows.Next() {
+		var account HytaleAccountSummary
+		if err := rows.Scan(
+			&account.AccountID,
+			&account.AccessTokenExpiry,
+			&account.ProfileUUID,
+			&account.Scope,
+			&account.LastRefreshedAt,
+			&account.CreatedAt,
+			&account.UpdatedAt,
+			&account.SessionCount,
+			&account.SessionExpiresAt,
+		); err != nil {
+			return nil, 0, err
+		}
+		account.SessionStatus = hytaleSessionStatus(account.SessionExpiresAt, now)
+		accounts = append(accounts, account)
+	}
+
+	return accounts, total, rows.Err()
+}
+
+// hytaleSessionStatus derives an account's session status from its latest game session expiry
+func hytaleSessionStatus(latestExpiry *time.Time, now time.Time) string {
+	switch {
+	case latestExpiry == nil:
+		return HytaleSessionNone
+	case latestExpiry.After(now):
+		return HytaleSessionActive
+	default:
+		return HytaleSessionExpired
+	}
+}
@@ -3,6 +3,7 @@ package database
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/jackc/pgx/v5"
@@ -10,12 +11,50 @@ import (
 
 // HytaleServerLog represents a stored game server log entry
 type HytaleServerLog struct {
-	ID           int64      `db:"id"`
-	ServerUUID   string     `db:"server_uuid"`
-	AccountID    string     `db:"account_id"`
-	LogLine      string     `db:"log_line"`
-	LogTimestamp *time.Time `db:"log_timestamp"`
-	CreatedAt    time.Time  `db:"created_at"`
+	ID           int64      `db:"id" json:"id"`
+	ServerUUID   string     `db:"server_uuid" json:"serverUuid"`
+	AccountID    string     `db:"account_id" json:"accountId,omitempty"`
+	LogLine      string     `db:"log_line" json:"line"`
+	Level        string     `db:"level" json:"level"`
+	LogTimestamp *time.Time `db:"log_timestamp" json:"timestamp"`
+	CreatedAt    time.Time  `db:"created_at" json:"createdAt"`
+}
+
+// HytaleServerLogEntry is a single log line shipped by a game server
+type HytaleServerLogEntry struct {
+	Level     string
+	Message   string
+	Timestamp time.Time
+}
+
+// Hytale server log levels
+const (
+	HytaleLogLevelTrace = "TRACE"
+	HytaleLogLevelDebug = "DEBUG"
+	HytaleLogLevelInfo  = "INFO"
+	HytaleLogLevelWarn  = "WARN"
+	HytaleLogLevelError = "ERROR"
+	HytaleLogLevelFatal = "FATAL"
+)
+
+// NormalizeHytaleLogLevel maps a shipped log level, including the Java
+// logging names the Hytale server uses (SEVERE, WARNING, FINE, ...), to one of
+// the stored levels. Unknown levels are stored as INFO.
+func NormalizeHytaleLogLevel(level string) string {
+	switch strings.ToUpper(strings.TrimSpace(level)) {
+	case "TRACE", "FINEST", "FINER":
+		return HytaleLogLevelTrace
+	case "DEBUG", "FINE", "CONFIG":
+		return HytaleLogLevelDebug
+	case "WARN", "WARNING":
+		return HytaleLogLevelWarn
+	case "ERROR", "SEVERE":
+		return HytaleLogLevelError
+	case "FATAL":
+		return HytaleLogLevelFatal
+	default:
+		return HytaleLogLevelInfo
+	}
 }
 
 // HytaleLogSyncState tracks synchronization state for log persistence
@@ -48,7 +87,7 @@ func (r *HytaleServerLogsRepository) SaveLogs(ctx context.Context, serverUUID, a
 	batch := &pgx.Batch{}
 	for _, log := range logs {
 		batch.Queue(
-			`INSERT INTO hytale_server_logs (server_uuid, account_id, log_line, log_timestamp)
+			`INSERT INTO hytale_server_logs ("serverUuid", "accountId", "logLine", "logTimestamp")
 			 VALUES ($1, $2, $3, CURRENT_TIMESTAMP)`,
 			serverUUID, accountID, log,
 		)
@@ -66,13 +105,86 @@ func (r *HytaleServerLogsRepository) SaveLogs(ctx context.Context, serverUUID, a
 	return nil
 }
 
+// SaveLogEntries stores a batch of leveled log lines shipped by a server.
+// Entries without a timestamp are stamped with the current time.
+func (r *HytaleServerLogsRepository) SaveLogEntries(ctx context.Context, serverUUID, accountID string, entries []HytaleServerLogEntry) (int64, error) {
+	if len(entries) == 0 {
+		return 0, nil
+	}
+
+	now := time.Now()
+	rows := make([][]interface{}, len(entries))
+	for i, e := range entries {
+		ts := e.Timestamp
+		if ts.IsZero() {
+			ts = now
+		}
+		rows[i] = []interface{}{serverUUID, accountID, e.Message, NormalizeHytaleLogLevel(e.Level), ts, now}
+	}
+
+	count, err := r.db.Pool.CopyFrom(ctx,
+		pgx.Identifier{"hytale_server_logs"},
+		[]string{"serverUuid", "accountId", "logLine", "level", "logTimestamp", "createdAt"},
+		pgx.CopyFromRows(rows),
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to save log entries: %w", err)
+	}
+
+	return count, nil
+}
+
+// ListLogs returns a server's logs newest first, optionally limited to one
+// level, along with the total number of matching lines
+func (r *HytaleServerLogsRepository) ListLogs(ctx context.Context, serverUUID, level string, limit, offset int) ([]*HytaleServerLog, int64, error) {
+	// The level condition is only added when filtering so either index on
+	// ("serverUuid"[, level], "logTimestamp") can serve the query
+	where := `WHERE "serverUuid" = $1`
+	args := []interface{}{serverUUID}
+	if level != "" {
+		where += ` AND level = $2`
+		args = append(args, NormalizeHytaleLogLevel(level))
+	}
+
+	var total int64
+	if err := r.db.Pool.QueryRow(ctx,
+		`SELECT COUNT(*) FROM hytale_server_logs `+where, args...).Scan(&total); err != nil {
+		return nil, 0, fmt.Errorf("failed to count logs: %w", err)
+	}
+
+	rows, err := r.db.Pool.Query(ctx, fmt.Sprintf(`
+		SELECT id, "serverUuid", "accountId", "logLine", level, "logTimestamp", "createdAt"
+		FROM hytale_server_logs %s
+		ORDER BY "logTimestamp" DESC, id DESC
+		LIMIT $%d OFFSET $%d`, where, len(args)+1, len(args)+2), append(args, limit, offset)...)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to query logs: %w", err)
+	}
+	defer rows.Close()
+
+	logs := []*HytaleServerLog{}
+	for rows.Next() {
+		log := &HytaleServerLog{}
+		if err := rows.Scan(&log.ID, &log.ServerUUID, &log.AccountID, &log.LogLine, &log.Level, &log.LogTimestamp, &log.CreatedAt); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan log row: %w", err)
+		}
+		logs = append(logs, log)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, 0, fmt.Errorf("error scanning logs: %w", err)
+	}
+
+	return logs, total, nil
+}
+
 // GetLogsByServer retrieves logs for a specific server
 func (r *HytaleServerLogsRepository) GetLogsByServer(ctx context.Context, serverUUID string, limit int, offset int) ([]*HytaleServerLog, error) {
 	query := `
-		SELECT id, server_uuid, account_id, log_line, log_timestamp, created_at
+		SELECT id, "serverUuid", "accountId", "logLine", level, "logTimestamp", "createdAt"
 		FROM hytale_server_logs
-		WHERE server_uuid = $1
-		ORDER BY created_at DESC
+		WHERE "serverUuid" = $1
+		ORDER BY "createdAt" DESC
 		LIMIT $2 OFFSET $3
 	`
 
@@ -85,7 +197,7 @@ func (r *HytaleServerLogsRepository) GetLogsByServer(ctx context.Context, server
 	var logs []*HytaleServerLog
 	for rows.Next() {
 		log := &HytaleServerLog{}
-		if err := rows.Scan(&log.ID, &log.ServerUUID, &log.AccountID, &log.LogLine, &log.LogTimestamp, &log.CreatedAt); err != nil {
+		if err := rows.Scan(&log.ID, &log.ServerUUID, &log.AccountID, &log.LogLine, &log.Level, &log.LogTimestamp, &log.CreatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan log row: %w", err)
 		}
 		logs = append(logs, log)
@@ -101,10 +213,10 @@ func (r *HytaleServerLogsRepository) GetLogsByServer(ctx context.Context, server
 // GetLogsAfterTime retrieves logs created after a specific time
 func (r *HytaleServerLogsRepository) GetLogsAfterTime(ctx context.Context, serverUUID string, after time.Time, limit int) ([]*HytaleServerLog, error) {
 	query := `
-		SELECT id, server_uuid, account_id, log_line, log_timestamp, created_at
+		SELECT id, "serverUuid", "accountId", "logLine", level, "logTimestamp", "createdAt"
 		FROM hytale_server_logs
-		WHERE server_uuid = $1 AND created_at > $2
-		ORDER BY created_at ASC
+		WHERE "serverUuid" = $1 AND "createdAt" > $2
+		ORDER BY "createdAt" ASC
 		LIMIT $3
 	`
 
@@ -117,7 +229,7 @@ func (r *HytaleServerLogsRepository) GetLogsAfterTime(ctx context.Context, serve
 	var logs []*HytaleServerLog
 	for rows.Next() {
 		log := &HytaleServerLog{}
-		if err := rows.Scan(&log.ID, &log.ServerUUID, &log.AccountID, &log.LogLine, &log.LogTimestamp, &log.CreatedAt); err != nil {
+		if err := rows.Scan(&log.ID, &log.ServerUUID, &log.AccountID, &log.LogLine, &log.Level, &log.LogTimestamp, &log.CreatedAt); err != nil {
 			return nil, fmt.Errorf("failed to scan log row: %w", err)
 		}
 		logs = append(logs, log)
@@ -133,7 +245,7 @@ func (r *HytaleServerLogsRepository) GetLogsAfterTime(ctx context.Context, serve
 // DeleteOldLogs removes logs older than the specified time
 func (r *HytaleServerLogsRepository) DeleteOldLogs(ctx context.Context, olderThan time.Time) (int64, error) {
 	result, err := r.db.Pool.Exec(ctx,
-		`DELETE FROM hytale_server_logs WHERE created_at < $1`, olderThan)
+		`DELETE FROM hytale_server_logs WHERE "createdAt" < $1`, olderThan)
 	if err != nil {
 		return 0, fmt.Errorf("failed to delete old logs: %w", err)
 	}
@@ -145,7 +257,7 @@ func (r *HytaleServerLogsRepository) DeleteOldLogs(ctx context.Context, olderTha
 func (r *HytaleServerLogsRepository) CountLogsByServer(ctx context.Context, serverUUID string) (int64, error) {
 	var count int64
 	err := r.db.Pool.QueryRow(ctx,
-		`SELECT COUNT(*) FROM hytale_server_logs WHERE server_uuid = $1`, serverUUID).Scan(&count)
+		`SELECT COUNT(*) FROM hytale_server_logs WHERE "serverUuid" = $1`, serverUUID).Scan(&count)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count logs: %w", err)
 	}
@@ -156,17 +268,17 @@ func (r *HytaleServerLogsRepository) CountLogsByServer(ctx context.Context, serv
 func (r *HytaleServerLogsRepository) GetOrCreateSyncState(ctx context.Context, serverUUID string) (*HytaleLogSyncState, error) {
 	state := &HytaleLogSyncState{}
 	err := r.db.Pool.QueryRow(ctx,
-		`SELECT id, server_uuid, last_sync_time, last_line_id, sync_status, error_message, updated_at
-		 FROM hytale_log_sync_state WHERE server_uuid = $1`,
+		`SELECT id, "serverUuid", "lastSyncTime", "lastLineId", "syncStatus", "errorMessage", "updatedAt"
+		 FROM hytale_log_sync_state WHERE "serverUuid" = $1`,
 		serverUUID).Scan(&state.ID, &state.ServerUUID, &state.LastSyncTime, &state.LastLineID,
 		&state.SyncStatus, &state.ErrorMessage, &state.UpdatedAt)
 
 	if err == pgx.ErrNoRows {
 		// Create new sync state
 		err = r.db.Pool.QueryRow(ctx,
-			`INSERT INTO hytale_log_sync_state (server_uuid, last_sync_time, sync_status)
+			`INSERT INTO hytale_log_sync_state ("serverUuid", "lastSyncTime", "syncStatus")
 			 VALUES ($1, CURRENT_TIMESTAMP, 'pending')
-			 RETURNING id, server_uuid, last_sync_time, last_line_id, sync_status, error_message, updated_at`,
+			 RETURNING id, "serverUuid", "lastSyncTime", "lastLineId", "syncStatus", "errorMessage", "updatedAt"`,
 			serverUUID).Scan(&state.ID, &state.ServerUUID, &state.LastSyncTime, &state.LastLineID,
 			&state.SyncStatus, &state.ErrorMessage, &state.UpdatedAt)
 
@@ -187,8 +299,8 @@ func (r *HytaleServerLogsRepository) GetOrCreateSyncState(ctx context.Context, s
 func (r *HytaleServerLogsRepository) UpdateSyncState(ctx context.Context, serverUUID string, status string, errorMsg *string) error {
 	_, err := r.db.Pool.Exec(ctx,
 		`UPDATE hytale_log_sync_state 
-		 SET last_sync_time = CURRENT_TIMESTAMP, sync_status = $1, error_message = $2, updated_at = CURRENT_TIMESTAMP
-		 WHERE server_uuid = $3`,
+		 SET "lastSyncTime" = CURRENT_TIMESTAMP, "syncStatus" = $1, "errorMessage" = $2, "updatedAt" = CURRENT_TIMESTAMP
+		 WHERE "serverUuid" = $3`,
 		status, errorMsg, serverUUID)
 
 	if err != nil {
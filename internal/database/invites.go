@@ -0,0 +1,154 @@
+package database
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// inviteCodeAlphabet omits characters that are easily confused (0/O, 1/I/L)
+const inviteCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+// inviteCodeLength is the number of characters in a generated invite code
+const inviteCodeLength = 12
+
+// Invite is a registration invite code
+type Invite struct {
+	ID         string     `json:"id"`
+	Code       string     `json:"code"`
+	Email      string     `json:"email,omitempty"`
+	MaxUses    int        `json:"maxUses"`
+	Uses       int        `json:"uses"`
+	ExpiresAt  *time.Time `json:"expiresAt"`
+	RevokedAt  *time.Time `json:"revokedAt"`
+	Note       string     `json:"note,omitempty"`
+	CreatedBy  string     `json:"createdBy,omitempty"`
+	LastUsedAt *time.Time `json:"lastUsedAt"`
+	CreatedAt  time.Time  `json:"createdAt"`
+}
+
+// NewInvite describes an invite to create
+type NewInvite struct {
+	Email     string
+	MaxUses   int
+	ExpiresAt *time.Time
+	Note      string
+	CreatedBy string
+}
+
+// generateInviteCode returns a random, human-friendly invite code
+func generateInviteCode() (string, error) {
+	b := make([]byte, inviteCodeLength)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	for i := range b {
+		b[i] = inviteCodeAlphabet[int(b[i])%len(inviteCodeAlphabet)]
+	}
+	return string(b), nil
+}
+
+// NormalizeInviteCode upper-cases and trims a user-supplied invite code
+func NormalizeInviteCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+const inviteColumns = `id, code, COALESCE(email, ''), "maxUses", uses, "expiresAt", "revokedAt",
+	COALESCE(note, ''), COALESCE("createdBy", ''), "lastUsedAt", "createdAt"`
+
+func scanInvite(row interface{ Scan(...any) error }) (*Invite, error) {
+	var inv Invite
+	if err := row.Scan(&inv.ID, &inv.Code, &inv.Email, &inv.MaxUses, &inv.Uses, &inv.ExpiresAt, &inv.RevokedAt,
+		&inv.Note, &inv.CreatedBy, &inv.LastUsedAt, &inv.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &inv, nil
+}
+
+// CreateInvite stores a new invite with a generated code
+func (db *DB) CreateInvite(ctx context.Context, input NewInvite) (*Invite, error) {
+	if input.MaxUses < 1 {
+		input.MaxUses = 1
+	}
+	code, err := generateInviteCode()
+	if err != nil {
+		return nil, err
+	}
+
+	row := db.Pool.QueryRow(ctx, `
+		INSERT INTO invites (id, code, email, "maxUses", "expiresAt", note, "createdBy", "createdAt")
+		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW())
+		RETURNING `+inviteColumns,
+		uuid.New().String(), code, NewNullString(strings.ToLower(strings.TrimSpace(input.Email))),
+		input.MaxUses, input.ExpiresAt, NewNullString(input.Note), NewNullString(input.CreatedBy))
+	return scanInvite(row)
+}
+
+// ListInvites returns invites, newest first. Revoked, expired and used-up
+// invites are only included when includeInactive is set.
+func (db *DB) ListInvites(ctx context.Context, includeInactive bool) ([]Invite, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT `+inviteColumns+`
+		FROM invites
+		WHERE $1 OR ("revokedAt" IS NULL AND uses < "maxUses" AND ("expiresAt" IS NULL OR "expiresAt" > NOW()))
+		ORDER BY "createdAt" DESC
+	`, includeInactive)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invites := []Invite{}
+	for rows.Next() {
+		inv, err := scanInvite(rows)
+		if err != nil {
+			return nil, err
+		}
+		invites = append(invites, *inv)
+	}
+	return invites, rows.Err()
+}
+
+// RevokeInvite revokes an invite so it can no longer be redeemed. It returns
+// false if the invite does not exist or was already revoked.
+func (db *DB) RevokeInvite(ctx context.Context, id string) (bool, error) {
+	res, err := db.Pool.Exec(ctx, `
+		UPDATE invites SET "revokedAt" = NOW() WHERE id = $1 AND "revokedAt" IS NULL
+	`, id)
+	if err != nil {
+		return false, err
+	}
+	return res.RowsAffected() > 0, nil
+}
+
+// ConsumeInvite atomically uses one redemption of an invite for email. It
+// returns the invite ID, or an empty string if the code is unknown, revoked,
+// expired, used up or bound to another address.
+func (db *DB) ConsumeInvite(ctx context.Context, code, email string) (string, error) {
+	var id string
+	err := db.Pool.QueryRow(ctx, `
+		UPDATE invites SET uses = uses + 1, "lastUsedAt" = NOW()
+		WHERE code = $1
+		AND "revokedAt" IS NULL
+		AND uses < "maxUses"
+		AND ("expiresAt" IS NULL OR "expiresAt" > NOW())
+		AND (email IS NULL OR email = LOWER($2))
+		RETURNING id
+	`, NormalizeInviteCode(code), strings.TrimSpace(email)).Scan(&id)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return "", nil
+	}
+	return id, err
+}
+
+// ReleaseInvite gives back a redemption taken by ConsumeInvite, used when
+// registration fails after the invite was consumed
+func (db *DB) ReleaseInvite(ctx context.Context, id string) error {
+	_, err := db.Pool.Exec(ctx, `UPDATE invites SET uses = GREATEST(uses - 1, 0) WHERE id = $1`, id)
+	return err
+}
@@ -7,26 +7,27 @@ import (
 
 // User represents a user in the system
 type User struct {
-	ID                 string
-	Email              string
-	Password           sql.NullString
-	Username           sql.NullString
-	FirstName          sql.NullString
-	LastName           sql.NullString
-	Roles              []string
-	IsPterodactylAdmin bool
-	IsVirtfusionAdmin  bool
-	IsSystemAdmin      bool
-	PterodactylID      sql.NullInt64
-	VirtfusionID       sql.NullInt64
-	IsMigrated         bool
-	EmailVerified      sql.NullTime
-	IsActive           bool
-	AvatarURL          sql.NullString
-	CreatedAt          time.Time
-	UpdatedAt          time.Time
-	LastLoginAt        sql.NullTime
-	LastSyncedAt       sql.NullTime
+	ID                    string
+	Email                 string
+	Password              sql.NullString
+	Username              sql.NullString
+	FirstName             sql.NullString
+	LastName              sql.NullString
+	Roles                 []string
+	IsPterodactylAdmin    bool
+	IsVirtfusionAdmin     bool
+	IsSystemAdmin         bool
+	PterodactylID         sql.NullInt64
+	VirtfusionID          sql.NullInt64
+	IsMigrated            bool
+	EmailVerified         sql.NullTime
+	IsActive              bool
+	AvatarURL             sql.NullString
+	PasswordResetRequired bool
+	CreatedAt             time.Time
+	UpdatedAt             time.Time
+	LastLoginAt           sql.NullTime
+	LastSyncedAt          sql.NullTime
 }
 
 // NewNullString creates a sql.NullString from a string value
@@ -177,8 +178,36 @@ type SyncLog struct {
 	ItemsFailed int        `json:"itemsFailed"`
 	Error       *string    `json:"error"`
 	Metadata    string     `json:"metadata"`
+	Steps       []SyncStep `json:"steps"`
 	StartedAt   time.Time  `json:"startedAt"`
 	CompletedAt *time.Time `json:"completedAt"`
+
+	// Throughput of the running step; nil once the sync has finished
+	Throughput *SyncThroughput `json:"throughput,omitempty"`
+}
+
+// SyncStep is one entry in a sync log's per-step breakdown, stored under the
+// "steps" key of the metadata
+type SyncStep struct {
+	Step           string     `json:"step"`
+	Status         string     `json:"status"` // RUNNING, COMPLETED or FAILED
+	ItemsTotal     int        `json:"itemsTotal"`
+	ItemsProcessed int        `json:"itemsProcessed"`
+	StartedAt      time.Time  `json:"startedAt"`
+	UpdatedAt      *time.Time `json:"updatedAt,omitempty"` // time of the last progress update
+	ItemsPerSecond float64    `json:"itemsPerSecond,omitempty"`
+	CompletedAt    *time.Time `json:"completedAt,omitempty"`
+	Error          string     `json:"error,omitempty"`
+}
+
+// SyncThroughput is the rate and estimated time remaining of a running step
+type SyncThroughput struct {
+	Step           string  `json:"step"`
+	ItemsTotal     int     `json:"itemsTotal"`
+	ItemsProcessed int     `json:"itemsProcessed"`
+	ItemsPerSecond float64 `json:"itemsPerSecond"`
+	ElapsedSeconds int     `json:"elapsedSeconds"`       // since the step started
+	EtaSeconds     *int    `json:"etaSeconds,omitempty"` // nil while the rate or total is unknown
 }
 
 // Config represents a system configuration key-value pair
@@ -0,0 +1,151 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Notification categories. Security notifications (password resets, email
+// verification, sign-in links) are mandatory and cannot be turned off.
+const (
+	NotificationSecurity     = "security"
+	NotificationBilling      = "billing"
+	NotificationServerStatus = "server_status"
+	NotificationSupport      = "support"
+	NotificationMarketing    = "marketing"
+)
+
+// NotificationCategory is a kind of notification and its default delivery
+type NotificationCategory struct {
+	Name      string
+	Email     bool
+	InApp     bool
+	Mandatory bool
+}
+
+// NotificationCategories lists every category with its defaults, in the
+// order they are shown to users
+var NotificationCategories = []NotificationCategory{
+	{Name: NotificationSecurity, Email: true, InApp: true, Mandatory: true},
+	{Name: NotificationBilling, Email: true, InApp: true},
+	{Name: NotificationServerStatus, Email: true, InApp: true},
+	{Name: NotificationSupport, Email: true, InApp: true},
+	{Name: NotificationMarketing, Email: false, InApp: false},
+}
+
+// ErrUnknownNotificationCategory is returned for a category not in
+// NotificationCategories
+var ErrUnknownNotificationCategory = errors.New("unknown notification category")
+
+// ErrMandatoryNotificationCategory is returned when turning off a category
+// that is always delivered
+var ErrMandatoryNotificationCategory = errors.New("notification category cannot be turned off")
+
+// NotificationPreference is how a user receives one category
+type NotificationPreference struct {
+	Category  string     `json:"category"`
+	Email     bool       `json:"email"`
+	InApp     bool       `json:"inApp"`
+	Mandatory bool       `json:"mandatory"`
+	UpdatedAt *time.Time `json:"updatedAt"` // nil while the defaults apply
+}
+
+// LookupNotificationCategory returns the category called name
+func LookupNotificationCategory(name string) (NotificationCategory, bool) {
+	for _, c := range NotificationCategories {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return NotificationCategory{}, false
+}
+
+// GetNotificationPreferences returns a user's preference for every category,
+// filling in defaults for categories they have not changed
+func (db *DB) GetNotificationPreferences(ctx context.Context, userID string) ([]NotificationPreference, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT category, email, "inApp", "updatedAt"
+		FROM notification_preferences
+		WHERE "userId" = $1
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	stored := map[string]NotificationPreference{}
+	for rows.Next() {
+		var p NotificationPreference
+		if err := rows.Scan(&p.Category, &p.Email, &p.InApp, &p.UpdatedAt); err != nil {
+			return nil, err
+		}
+		stored[p.Category] = p
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	prefs := make([]NotificationPreference, 0, len(NotificationCategories))
+	for _, c := range NotificationCategories {
+		p, ok := stored[c.Name]
+		if !ok || c.Mandatory {
+			p = NotificationPreference{Category: c.Name, Email: c.Email, InApp: c.InApp}
+		}
+		p.Mandatory = c.Mandatory
+		prefs = append(prefs, p)
+	}
+	return prefs, nil
+}
+
+// SetNotificationPreference stores how a user receives one category
+func (db *DB) SetNotificationPreference(ctx context.Context, userID, category string, email, inApp bool) error {
+	c, ok := LookupNotificationCategory(category)
+	if !ok {
+		return ErrUnknownNotificationCategory
+	}
+	if c.Mandatory {
+		if !email || !inApp {
+			return ErrMandatoryNotificationCategory
+		}
+		return nil
+	}
+
+	_, err := db.Pool.Exec(ctx, `
+		INSERT INTO notification_preferences ("userId", category, email, "inApp", "updatedAt")
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT ("userId", category) DO UPDATE SET
+			email = EXCLUDED.email,
+			"inApp" = EXCLUDED."inApp",
+			"updatedAt" = NOW()
+	`, userID, category, email, inApp)
+	return err
+}
+
+// EmailNotificationAllowed reports whether the account with the given email
+// address accepts emails in category. Mandatory and unknown categories are
+// always allowed; categories the user has not changed, and addresses without
+// an account, use the category's default.
+func (db *DB) EmailNotificationAllowed(ctx context.Context, address, category string) (bool, error) {
+	c, ok := LookupNotificationCategory(category)
+	if !ok || c.Mandatory {
+		return true, nil
+	}
+
+	var allowed bool
+	err := db.Pool.QueryRow(ctx, `
+		SELECT np.email
+		FROM notification_preferences np
+		JOIN users u ON u.id = np."userId"
+		WHERE LOWER(u.email) = LOWER($1) AND np.category = $2
+	`, address, category).Scan(&allowed)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return c.Email, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return allowed, nil
+}
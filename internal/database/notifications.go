@@ -0,0 +1,148 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// In-app notification types
+const (
+	NotificationServerSuspended      = "server_suspended"
+	NotificationServerUnsuspended    = "server_unsuspended"
+	NotificationServerRemoved        = "server_removed"
+	NotificationServerAddressChanged = "server_address_changed"
+	NotificationTicketReply          = "ticket_reply"
+)
+
+// Notification is an in-app notification shown in the dashboard
+type Notification struct {
+	ID        int64     `json:"id"`
+	Type      string    `json:"type"`
+	Title     string    `json:"title"`
+	Body      string    `json:"body"`
+	Read      bool      `json:"read"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// NewNotification is a notification to write for one or more users
+type NewNotification struct {
+	Category string // preference category that decides whether it is stored
+	Type     string
+	Title    string
+	Body     string
+}
+
+// NotifyUsers stores n for each user whose in-app preference for its
+// category allows it, and returns how many were stored
+func (db *DB) NotifyUsers(ctx context.Context, userIDs []string, n NewNotification) (int64, error) {
+	if len(userIDs) == 0 {
+		return 0, nil
+	}
+	category, ok := LookupNotificationCategory(n.Category)
+	if !ok {
+		return 0, ErrUnknownNotificationCategory
+	}
+
+	// Mandatory categories ignore stored preferences; others fall back to
+	// the category default when the user has not changed it
+	res, err := db.Pool.Exec(ctx, `
+		INSERT INTO notifications ("userId", type, title, body)
+		SELECT u.id, $2, $3, $4
+		FROM users u
+		WHERE u.id = ANY($1) AND ($5 OR COALESCE(
+			(SELECT np."inApp" FROM notification_preferences np WHERE np."userId" = u.id AND np.category = $6),
+			$7))
+	`, userIDs, n.Type, n.Title, n.Body, category.Mandatory, category.Name, category.InApp)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected(), nil
+}
+
+// ListNotifications returns a page of a user's notifications, newest first,
+// and how many match in total
+func (db *DB) ListNotifications(ctx context.Context, userID string, unreadOnly bool, limit, offset int) ([]Notification, int, error) {
+	var total int
+	if err := db.Pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM notifications WHERE "userId" = $1 AND (NOT $2 OR NOT read)
+	`, userID, unreadOnly).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT id, type, title, body, read, "createdAt"
+		FROM notifications
+		WHERE "userId" = $1 AND (NOT $2 OR NOT read)
+		ORDER BY "createdAt" DESC, id DESC
+		LIMIT $3 OFFSET $4
+	`, userID, unreadOnly, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	notifications := []Notification{}
+	for rows.Next() {
+		var n Notification
+		if err := rows.Scan(&n.ID, &n.Type, &n.Title, &n.Body, &n.Read, &n.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		notifications = append(notifications, n)
+	}
+	return notifications, total, rows.Err()
+}
+
+// CountUnreadNotifications returns how many of a user's notifications are unread
+func (db *DB) CountUnreadNotifications(ctx context.Context, userID string) (int, error) {
+	var count int
+	err := db.Pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM notifications WHERE "userId" = $1 AND NOT read
+	`, userID).Scan(&count)
+	return count, err
+}
+
+// MarkNotificationsRead marks the given notifications of a user read, or all
+// of them when ids is empty, and returns how many changed
+func (db *DB) MarkNotificationsRead(ctx context.Context, userID string, ids []int64) (int64, error) {
+	if ids == nil {
+		ids = []int64{}
+	}
+	res, err := db.Pool.Exec(ctx, `
+		UPDATE notifications SET read = true
+		WHERE "userId" = $1 AND NOT read AND (cardinality($2::bigint[]) = 0 OR id = ANY($2))
+	`, userID, ids)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected(), nil
+}
+
+// ServerOwnership is a server's name and owner, for notifying the owner
+type ServerOwnership struct {
+	ServerID string
+	Name     string
+	OwnerID  string
+}
+
+// ServerOwners returns the owners of the given servers. Servers without an
+// owner are left out.
+func (db *DB) ServerOwners(ctx context.Context, serverIDs []string) ([]ServerOwnership, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT id, name, "ownerId" FROM servers
+		WHERE id = ANY($1) AND "ownerId" IS NOT NULL
+	`, serverIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var owners []ServerOwnership
+	for rows.Next() {
+		var o ServerOwnership
+		if err := rows.Scan(&o.ServerID, &o.Name, &o.OwnerID); err != nil {
+			return nil, err
+		}
+		owners = append(owners, o)
+	}
+	return owners, rows.Err()
+}
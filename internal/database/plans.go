@@ -0,0 +1,197 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Plan resources, as reported in QuotaExceededError
+const (
+	PlanResourceServers = "servers"
+	PlanResourceMemory  = "memory"
+	PlanResourceDisk    = "disk"
+	PlanResourceCPU     = "cpu"
+)
+
+// ErrPlanNameTaken is returned when a new plan's name is already used
+var ErrPlanNameTaken = errors.New("plan name already in use")
+
+// Plan caps the resources a user's servers may use. A nil limit is unlimited.
+type Plan struct {
+	ID          string    `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	MaxServers  *int      `json:"maxServers"`
+	MaxMemory   *int      `json:"maxMemory"` // MB
+	MaxDisk     *int      `json:"maxDisk"`   // MB
+	MaxCPU      *int      `json:"maxCpu"`    // % of one core
+	IsDefault   bool      `json:"isDefault"`
+	CreatedAt   time.Time `json:"createdAt"`
+}
+
+// PlanUsage is what a proposed action adds, or what a user already uses.
+// Memory and disk are in MB and CPU in percent, like server limits.
+type PlanUsage struct {
+	Servers int `json:"servers"`
+	Memory  int `json:"memory"`
+	Disk    int `json:"disk"`
+	CPU     int `json:"cpu"`
+}
+
+// QuotaExceededError reports the first plan limit a proposed action would exceed
+type QuotaExceededError struct {
+	Plan      string `json:"plan"`
+	Resource  string `json:"resource"`
+	Limit     int    `json:"limit"`
+	Used      int    `json:"used"`
+	Requested int    `json:"requested"`
+}
+
+func (e *QuotaExceededError) Error() string {
+	return fmt.Sprintf("%s plan allows %d %s; %d in use and %d requested", e.Plan, e.Limit, e.Resource, e.Used, e.Requested)
+}
+
+// Check returns a QuotaExceededError if adding requested to used would exceed
+// one of the plan's limits
+func (p *Plan) Check(used, requested PlanUsage) error {
+	checks := []struct {
+		resource        string
+		limit           *int
+		used, requested int
+	}{
+		{PlanResourceServers, p.MaxServers, used.Servers, requested.Servers},
+		{PlanResourceMemory, p.MaxMemory, used.Memory, requested.Memory},
+		{PlanResourceDisk, p.MaxDisk, used.Disk, requested.Disk},
+		{PlanResourceCPU, p.MaxCPU, used.CPU, requested.CPU},
+	}
+	for _, c := range checks {
+		if c.limit != nil && c.requested > 0 && c.used+c.requested > *c.limit {
+			return &QuotaExceededError{Plan: p.Name, Resource: c.resource, Limit: *c.limit, Used: c.used, Requested: c.requested}
+		}
+	}
+	return nil
+}
+
+const planColumns = `id, name, COALESCE(description, ''), "maxServers", "maxMemory", "maxDisk", "maxCpu", "isDefault", "createdAt"`
+
+func scanPlan(row interface{ Scan(...any) error }) (*Plan, error) {
+	var p Plan
+	if err := row.Scan(&p.ID, &p.Name, &p.Description, &p.MaxServers, &p.MaxMemory, &p.MaxDisk, &p.MaxCPU, &p.IsDefault, &p.CreatedAt); err != nil {
+		return nil, err
+	}
+	return &p, nil
+}
+
+// ListPlans returns all plans by name
+func (db *DB) ListPlans(ctx context.Context) ([]Plan, error) {
+	rows, err := db.Pool.Query(ctx, `SELECT `+planColumns+` FROM plans ORDER BY name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	plans := []Plan{}
+	for rows.Next() {
+		p, err := scanPlan(rows)
+		if err != nil {
+			return nil, err
+		}
+		plans = append(plans, *p)
+	}
+	return plans, rows.Err()
+}
+
+// CreatePlan stores a new plan. Making it the default clears the flag on the
+// previous default plan. It returns ErrPlanNameTaken if the name is in use.
+func (db *DB) CreatePlan(ctx context.Context, p Plan) (*Plan, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	if p.IsDefault {
+		if _, err := tx.Exec(ctx, `UPDATE plans SET "isDefault" = false, "updatedAt" = NOW() WHERE "isDefault"`); err != nil {
+			return nil, err
+		}
+	}
+
+	created, err := scanPlan(tx.QueryRow(ctx, `
+		INSERT INTO plans (id, name, description, "maxServers", "maxMemory", "maxDisk", "maxCpu", "isDefault")
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING `+planColumns,
+		uuid.New().String(), p.Name, NewNullString(p.Description), p.MaxServers, p.MaxMemory, p.MaxDisk, p.MaxCPU, p.IsDefault))
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+		return nil, ErrPlanNameTaken
+	}
+	if err != nil {
+		return nil, err
+	}
+	return created, tx.Commit(ctx)
+}
+
+// PlanExists reports whether a plan with the given ID exists
+func (db *DB) PlanExists(ctx context.Context, id string) (bool, error) {
+	var exists bool
+	err := db.Pool.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM plans WHERE id = $1)`, id).Scan(&exists)
+	return exists, err
+}
+
+// SetUserPlan assigns a plan to a user; an empty planID removes it so the
+// default plan applies. It returns false if the user does not exist.
+func (db *DB) SetUserPlan(ctx context.Context, userID, planID string) (bool, error) {
+	res, err := db.Pool.Exec(ctx,
+		`UPDATE users SET "planId" = $1, "updatedAt" = NOW() WHERE id = $2`,
+		NewNullString(planID), userID)
+	if err != nil {
+		return false, err
+	}
+	return res.RowsAffected() > 0, nil
+}
+
+// GetUserPlan returns the user's plan, or the default plan when none is
+// assigned. It returns nil when neither exists, meaning no limits apply.
+func (db *DB) GetUserPlan(ctx context.Context, userID string) (*Plan, error) {
+	p, err := scanPlan(db.Pool.QueryRow(ctx, `
+		SELECT `+planColumns+` FROM plans
+		WHERE id = (SELECT "planId" FROM users WHERE id = $1) OR "isDefault"
+		ORDER BY "isDefault" ASC
+		LIMIT 1
+	`, userID))
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	return p, err
+}
+
+// GetPlanUsage returns the servers a user owns and their summed limits
+func (db *DB) GetPlanUsage(ctx context.Context, userID string) (PlanUsage, error) {
+	var u PlanUsage
+	err := db.Pool.QueryRow(ctx, `
+		SELECT COUNT(*), COALESCE(SUM(memory), 0), COALESCE(SUM(disk), 0), COALESCE(SUM(cpu), 0)
+		FROM servers WHERE "ownerId" = $1 AND "deletedAt" IS NULL
+	`, userID).Scan(&u.Servers, &u.Memory, &u.Disk, &u.CPU)
+	return u, err
+}
+
+// CheckPlanAllowance checks a proposed action against the user's remaining
+// plan allowance. It returns a *QuotaExceededError when a limit would be
+// exceeded and nil when the action fits or the user has no plan.
+func (db *DB) CheckPlanAllowance(ctx context.Context, userID string, requested PlanUsage) error {
+	plan, err := db.GetUserPlan(ctx, userID)
+	if err != nil || plan == nil {
+		return err
+	}
+	used, err := db.GetPlanUsage(ctx, userID)
+	if err != nil {
+		return err
+	}
+	return plan.Check(used, requested)
+}
@@ -0,0 +1,54 @@
+package database
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestPlanCheck(t *testing.T) {
+	limit := func(n int) *int { return &n }
+	plan := &Plan{Name: "Starter", MaxServers: limit(2), MaxMemory: limit(4096), MaxDisk: nil, MaxCPU: limit(200)}
+
+	tests := []struct {
+		name      string
+		used      PlanUsage
+		requested PlanUsage
+		want      *QuotaExceededError
+	}{
+		{name: "within every limit", used: PlanUsage{Servers: 1, Memory: 1024, CPU: 100}, requested: PlanUsage{Servers: 1, Memory: 1024, CPU: 100}},
+		{name: "exactly at the limit", used: PlanUsage{Memory: 3072}, requested: PlanUsage{Memory: 1024}},
+		{name: "unlimited resource", used: PlanUsage{Disk: 1 << 20}, requested: PlanUsage{Disk: 1 << 20}},
+		{name: "nothing requested while over", used: PlanUsage{Servers: 5}, requested: PlanUsage{Memory: 512}},
+		{
+			name: "servers exceeded", used: PlanUsage{Servers: 2}, requested: PlanUsage{Servers: 1},
+			want: &QuotaExceededError{Plan: "Starter", Resource: PlanResourceServers, Limit: 2, Used: 2, Requested: 1},
+		},
+		{
+			name: "first exceeded limit is reported", used: PlanUsage{Memory: 4000, CPU: 200}, requested: PlanUsage{Memory: 512, CPU: 50},
+			want: &QuotaExceededError{Plan: "Starter", Resource: PlanResourceMemory, Limit: 4096, Used: 4000, Requested: 512},
+		},
+		{
+			name: "cpu exceeded", used: PlanUsage{CPU: 150}, requested: PlanUsage{CPU: 51},
+			want: &QuotaExceededError{Plan: "Starter", Resource: PlanResourceCPU, Limit: 200, Used: 150, Requested: 51},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := plan.Check(tt.used, tt.requested)
+			if tt.want == nil {
+				if err != nil {
+					t.Fatalf("Check() = %v, want nil", err)
+				}
+				return
+			}
+			var got *QuotaExceededError
+			if !errors.As(err, &got) {
+				t.Fatalf("Check() = %v, want %+v", err, tt.want)
+			}
+			if *got != *tt.want {
+				t.Errorf("Check() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// slowQuerySQLPrefixLen caps how much of a slow query's SQL is logged
+const slowQuerySQLPrefixLen = 120
+
+// slowQueryCount counts queries that exceeded the slow query threshold since startup
+var slowQueryCount atomic.Int64
+
+// SlowQueryCount returns the number of slow queries logged since startup
+func SlowQueryCount() int64 {
+	return slowQueryCount.Load()
+}
+
+type queryStartKey struct{}
+
+type queryStart struct {
+	sql     string
+	started time.Time
+}
+
+// slowQueryTracer logs queries that take longer than threshold at warn level
+type slowQueryTracer struct {
+	threshold time.Duration
+}
+
+// TraceQueryStart records the query start time on the context
+func (t *slowQueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, queryStartKey{}, queryStart{sql: data.SQL, started: time.Now()})
+}
+
+// TraceQueryEnd logs the query if it exceeded the threshold
+func (t *slowQueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	start, ok := ctx.Value(queryStartKey{}).(queryStart)
+	if !ok {
+		return
+	}
+
+	duration := time.Since(start.started)
+	if duration < t.threshold {
+		return
+	}
+
+	slowQueryCount.Add(1)
+
+	event := log.Warn().
+		Str("query", summarizeSQL(start.sql)).
+		Dur("duration", duration).
+		Dur("threshold", t.threshold)
+	if data.Err != nil {
+		event = event.Err(data.Err)
+	}
+	event.Msg("Slow database query")
+}
+
+// summarizeSQL collapses whitespace and truncates a query for logging
+func summarizeSQL(sql string) string {
+	summary := strings.Join(strings.Fields(sql), " ")
+	if len(summary) > slowQuerySQLPrefixLen {
+		summary = summary[:slowQuerySQLPrefixLen] + "..."
+	}
+	return summary
+}
@@ -0,0 +1,151 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// Role is an entry in the role catalog
+type Role struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	Permissions []string  `json:"permissions"`
+	IsSystem    bool      `json:"isSystem"`
+	UserCount   int       `json:"userCount"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+// NormalizeRoleName trims and upper-cases a role name so "support_team" and
+// "SUPPORT_TEAM" refer to the same catalog entry
+func NormalizeRoleName(name string) string {
+	return strings.ToUpper(strings.TrimSpace(name))
+}
+
+// ListRoles returns the role catalog with the number of users holding each role
+func (db *DB) ListRoles(ctx context.Context) ([]Role, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT r.name, COALESCE(r.description, ''), r.permissions, r."isSystem",
+			(SELECT COUNT(*) FROM users u WHERE r.name = ANY(u.roles)),
+			r."createdAt", r."updatedAt"
+		FROM roles r
+		ORDER BY r."isSystem" DESC, r.name
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := []Role{}
+	for rows.Next() {
+		var r Role
+		if err := rows.Scan(&r.Name, &r.Description, &r.Permissions, &r.IsSystem, &r.UserCount, &r.CreatedAt, &r.UpdatedAt); err != nil {
+			return nil, err
+		}
+		roles = append(roles, r)
+	}
+	return roles, rows.Err()
+}
+
+// GetRole returns a single role by name
+func (db *DB) GetRole(ctx context.Context, name string) (*Role, error) {
+	var r Role
+	err := db.Pool.QueryRow(ctx, `
+		SELECT r.name, COALESCE(r.description, ''), r.permissions, r."isSystem",
+			(SELECT COUNT(*) FROM users u WHERE r.name = ANY(u.roles)),
+			r."createdAt", r."updatedAt"
+		FROM roles r
+		WHERE r.name = $1
+	`, name).Scan(&r.Name, &r.Description, &r.Permissions, &r.IsSystem, &r.UserCount, &r.CreatedAt, &r.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
+
+// CreateRole adds a role to the catalog. It returns false if a role with the
+// same name already exists.
+func (db *DB) CreateRole(ctx context.Context, name, description string, permissions []string) (bool, error) {
+	if permissions == nil {
+		permissions = []string{}
+	}
+	res, err := db.Pool.Exec(ctx, `
+		INSERT INTO roles (name, description, permissions, "isSystem", "createdAt", "updatedAt")
+		VALUES ($1, $2, $3, false, NOW(), NOW())
+		ON CONFLICT (name) DO NOTHING
+	`, name, NewNullString(description), permissions)
+	if err != nil {
+		return false, err
+	}
+	return res.RowsAffected() > 0, nil
+}
+
+// UpdateRole replaces a role's description and permissions. It returns false
+// if the role does not exist.
+func (db *DB) UpdateRole(ctx context.Context, name, description string, permissions []string) (bool, error) {
+	if permissions == nil {
+		permissions = []string{}
+	}
+	res, err := db.Pool.Exec(ctx, `
+		UPDATE roles SET description = $2, permissions = $3, "updatedAt" = NOW()
+		WHERE name = $1
+	`, name, NewNullString(description), permissions)
+	if err != nil {
+		return false, err
+	}
+	return res.RowsAffected() > 0, nil
+}
+
+// DeleteRole removes a non-system role from the catalog. It returns false if
+// the role does not exist or is a system role.
+func (db *DB) DeleteRole(ctx context.Context, name string) (bool, error) {
+	res, err := db.Pool.Exec(ctx, `DELETE FROM roles WHERE name = $1 AND "isSystem" = false`, name)
+	if err != nil {
+		return false, err
+	}
+	return res.RowsAffected() > 0, nil
+}
+
+// UnknownRoles returns the names that are not in the role catalog
+func (db *DB) UnknownRoles(ctx context.Context, names []string) ([]string, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT n FROM unnest($1::text[]) AS n
+		WHERE NOT EXISTS (SELECT 1 FROM roles r WHERE r.name = n)
+	`, names)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var unknown []string
+	for rows.Next() {
+		var n string
+		if err := rows.Scan(&n); err != nil {
+			return nil, err
+		}
+		unknown = append(unknown, n)
+	}
+	return unknown, rows.Err()
+}
+
+// PermissionsForRoles returns the union of the permissions granted to the given roles
+func (db *DB) PermissionsForRoles(ctx context.Context, roles []string) ([]string, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT DISTINCT unnest(permissions) FROM roles WHERE name = ANY($1::text[])
+	`, roles)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var permissions []string
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, p)
+	}
+	return permissions, rows.Err()
+}
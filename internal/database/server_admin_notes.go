@@ -0,0 +1,49 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// ServerAdminNotes is the internal admin annotation on a server
+type ServerAdminNotes struct {
+	Notes     *string    `json:"notes"`
+	UpdatedAt *time.Time `json:"updatedAt"` // nil if the notes were never edited
+}
+
+// GetServerAdminNotes returns a server's admin notes, or nil if the server
+// does not exist
+func (db *DB) GetServerAdminNotes(ctx context.Context, serverID string) (*ServerAdminNotes, error) {
+	var n ServerAdminNotes
+	err := db.Pool.QueryRow(ctx, `SELECT "adminNotes", "adminNotesUpdatedAt" FROM servers WHERE id = $1`, serverID).
+		Scan(&n.Notes, &n.UpdatedAt)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &n, nil
+}
+
+// SetServerAdminNotes replaces a server's admin notes; empty notes are stored
+// as NULL. Only the notes' own timestamp is touched, not the server's
+// "updatedAt". It reports false if the server does not exist.
+func (db *DB) SetServerAdminNotes(ctx context.Context, serverID, notes string) (bool, error) {
+	var notesArg *string
+	if notes != "" {
+		notesArg = &notes
+	}
+
+	tag, err := db.Pool.Exec(ctx, `
+		UPDATE servers SET "adminNotes" = $2, "adminNotesUpdatedAt" = NOW()
+		WHERE id = $1
+	`, serverID, notesArg)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
@@ -0,0 +1,135 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// bytesPerMB converts reported byte counts to the MB used for server limits
+const bytesPerMB = 1024 * 1024
+
+// ServerResourceSnapshot is the latest live resource usage reported for a server
+type ServerResourceSnapshot struct {
+	ServerID    string
+	State       string
+	MemoryBytes int64
+	DiskBytes   int64
+	CPUAbsolute float64
+}
+
+// ResourceUsage compares what a user's servers are allocated with what they use
+type ResourceUsage struct {
+	Allocated int64 `json:"allocated"`
+	Used      int64 `json:"used"`
+	Unlimited bool  `json:"unlimited"` // at least one server has no limit, so allocated is a lower bound
+}
+
+// UserResourceUsage is the aggregate resource usage across a user's servers.
+// Memory and disk are in MB and CPU in percent of one core.
+type UserResourceUsage struct {
+	Servers          int           `json:"servers"`
+	ReportingServers int           `json:"reportingServers"` // servers with a usage snapshot
+	Memory           ResourceUsage `json:"memory"`
+	Disk             ResourceUsage `json:"disk"`
+	CPU              ResourceUsage `json:"cpu"`
+	SnapshotAt       *time.Time    `json:"snapshotAt"` // oldest snapshot included; nil when none
+}
+
+// UpsertServerResourceSnapshots stores the latest usage for each server,
+// replacing any earlier snapshot
+func (db *DB) UpsertServerResourceSnapshots(ctx context.Context, snapshots []ServerResourceSnapshot) error {
+	if len(snapshots) == 0 {
+		return nil
+	}
+
+	ids := make([]string, len(snapshots))
+	states := make([]string, len(snapshots))
+	memory := make([]int64, len(snapshots))
+	disk := make([]int64, len(snapshots))
+	cpu := make([]float64, len(snapshots))
+	for i, s := range snapshots {
+		ids[i], states[i], memory[i], disk[i], cpu[i] = s.ServerID, s.State, s.MemoryBytes, s.DiskBytes, s.CPUAbsolute
+	}
+
+	_, err := db.Pool.Exec(ctx, `
+		INSERT INTO server_resource_snapshots ("serverId", state, "memoryBytes", "diskBytes", "cpuAbsolute", "updatedAt")
+		SELECT u.id, u.state, u.memory, u.disk, u.cpu, NOW()
+		FROM unnest($1::text[], $2::text[], $3::bigint[], $4::bigint[], $5::float8[]) AS u(id, state, memory, disk, cpu)
+		ON CONFLICT ("serverId") DO UPDATE SET
+			state = EXCLUDED.state,
+			"memoryBytes" = EXCLUDED."memoryBytes",
+			"diskBytes" = EXCLUDED."diskBytes",
+			"cpuAbsolute" = EXCLUDED."cpuAbsolute",
+			"updatedAt" = EXCLUDED."updatedAt"
+	`, ids, states, memory, disk, cpu)
+	return err
+}
+
+// GetUserResourceUsage sums the limits and latest usage snapshots of the
+// servers a user owns
+func (db *DB) GetUserResourceUsage(ctx context.Context, userID string) (*UserResourceUsage, error) {
+	var u UserResourceUsage
+	var usedMemory, usedDisk int64
+	var usedCPU float64
+	err := db.Pool.QueryRow(ctx, `
+		SELECT
+			COUNT(*), COUNT(r."serverId"),
+			COALESCE(SUM(s.memory), 0), COALESCE(BOOL_OR(COALESCE(s.memory, 0) = 0), false),
+			COALESCE(SUM(s.disk), 0), COALESCE(BOOL_OR(COALESCE(s.disk, 0) = 0), false),
+			COALESCE(SUM(s.cpu), 0), COALESCE(BOOL_OR(COALESCE(s.cpu, 0) = 0), false),
+			COALESCE(SUM(r."memoryBytes"), 0)::bigint, COALESCE(SUM(r."diskBytes"), 0)::bigint,
+			COALESCE(SUM(r."cpuAbsolute"), 0), MIN(r."updatedAt")
+		FROM servers s
+		LEFT JOIN server_resource_snapshots r ON r."serverId" = s.id
+		WHERE s."ownerId" = $1 AND s."deletedAt" IS NULL
+	`, userID).Scan(
+		&u.Servers, &u.ReportingServers,
+		&u.Memory.Allocated, &u.Memory.Unlimited,
+		&u.Disk.Allocated, &u.Disk.Unlimited,
+		&u.CPU.Allocated, &u.CPU.Unlimited,
+		&usedMemory, &usedDisk, &usedCPU, &u.SnapshotAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	u.Memory.Used = usedMemory / bytesPerMB
+	u.Disk.Used = usedDisk / bytesPerMB
+	u.CPU.Used = int64(usedCPU + 0.5)
+	return &u, nil
+}
+
+// ServerStatusSnapshot is a server's stored status and latest recorded power state
+type ServerStatusSnapshot struct {
+	ServerID    string
+	UUID        *string
+	Status      string
+	IsSuspended bool
+	State       *string    // nil when no snapshot has been recorded
+	StateAt     *time.Time // when State was recorded
+}
+
+// GetServerStatusSnapshots returns the stored status of the given servers,
+// limited to those ownerID owns unless all is set. Unknown IDs are omitted.
+func (db *DB) GetServerStatusSnapshots(ctx context.Context, serverIDs []string, ownerID string, all bool) ([]ServerStatusSnapshot, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT s.id, s.uuid, COALESCE(s.status, 'unknown'), COALESCE(s."isSuspended", false), r.state, r."updatedAt"
+		FROM servers s
+		LEFT JOIN server_resource_snapshots r ON r."serverId" = s.id
+		WHERE s.id = ANY($1) AND (s."ownerId" = $2 OR $3) AND s."deletedAt" IS NULL
+	`, serverIDs, ownerID, all)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	snapshots := []ServerStatusSnapshot{}
+	for rows.Next() {
+		var s ServerStatusSnapshot
+		if err := rows.Scan(&s.ServerID, &s.UUID, &s.Status, &s.IsSuspended, &s.State, &s.StateAt); err != nil {
+			return nil, err
+		}
+		snapshots = append(snapshots, s)
+	}
+	return snapshots, rows.Err()
+}
@@ -0,0 +1,181 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// ServerStateRunning is the panel power state counted as up
+const ServerStateRunning = "running"
+
+// ServerStatusEvent is a recorded power state transition
+type ServerStatusEvent struct {
+	PreviousState string    `json:"previousState,omitempty"`
+	State         string    `json:"state"`
+	CreatedAt     time.Time `json:"createdAt"`
+}
+
+// ServerOutage is a period during which a server was not running
+type ServerOutage struct {
+	Start           time.Time  `json:"start"`
+	End             *time.Time `json:"end"` // nil while the outage is ongoing
+	DurationSeconds int64      `json:"durationSeconds"`
+	State           string     `json:"state"`
+}
+
+// UptimeSummary describes a server's uptime over a time range
+type UptimeSummary struct {
+	From           time.Time      `json:"from"`
+	To             time.Time      `json:"to"`
+	UptimePercent  *float64       `json:"uptimePercent"` // nil when no state is known for the range
+	UptimeSeconds  int64          `json:"uptimeSeconds"`
+	TrackedSeconds int64          `json:"trackedSeconds"`
+	CurrentState   string         `json:"currentState,omitempty"`
+	Outages        []ServerOutage `json:"outages"`
+}
+
+// LatestServerStates returns the most recently recorded state of every server
+// that has status events, keyed by server ID
+func (db *DB) LatestServerStates(ctx context.Context) (map[string]string, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT DISTINCT ON ("serverId") "serverId", state
+		FROM server_status_events
+		ORDER BY "serverId", "createdAt" DESC
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	states := make(map[string]string)
+	for rows.Next() {
+		var serverID, state string
+		if err := rows.Scan(&serverID, &state); err != nil {
+			return nil, err
+		}
+		states[serverID] = state
+	}
+	return states, rows.Err()
+}
+
+// RecordServerStatusEvent stores a power state transition
+func (db *DB) RecordServerStatusEvent(ctx context.Context, serverID, previousState, state string) error {
+	_, err := db.Pool.Exec(ctx, `
+		INSERT INTO server_status_events ("serverId", "previousState", state, "createdAt")
+		VALUES ($1, $2, $3, NOW())
+	`, serverID, NewNullString(previousState), state)
+	return err
+}
+
+// GetServerStatusEvents returns the state a server was in at since (empty if
+// unknown) and the transitions recorded after it, oldest first
+func (db *DB) GetServerStatusEvents(ctx context.Context, serverID string, since time.Time) (string, []ServerStatusEvent, error) {
+	var initialState string
+	err := db.Pool.QueryRow(ctx, `
+		SELECT COALESCE((
+			SELECT state FROM server_status_events
+			WHERE "serverId" = $1 AND "createdAt" <= $2
+			ORDER BY "createdAt" DESC
+			LIMIT 1
+		), '')
+	`, serverID, since).Scan(&initialState)
+	if err != nil {
+		return "", nil, err
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT COALESCE("previousState", ''), state, "createdAt"
+		FROM server_status_events
+		WHERE "serverId" = $1 AND "createdAt" > $2
+		ORDER BY "createdAt" ASC
+	`, serverID, since)
+	if err != nil {
+		return "", nil, err
+	}
+	defer rows.Close()
+
+	events := []ServerStatusEvent{}
+	for rows.Next() {
+		var e ServerStatusEvent
+		if err := rows.Scan(&e.PreviousState, &e.State, &e.CreatedAt); err != nil {
+			return "", nil, err
+		}
+		events = append(events, e)
+	}
+	return initialState, events, rows.Err()
+}
+
+// DeleteServerStatusEventsBefore removes status events older than cutoff,
+// keeping each server's latest event so its current state stays known
+func (db *DB) DeleteServerStatusEventsBefore(ctx context.Context, cutoff time.Time) (int64, error) {
+	res, err := db.Pool.Exec(ctx, `
+		DELETE FROM server_status_events e
+		WHERE e."createdAt" < $1
+		AND EXISTS (
+			SELECT 1 FROM server_status_events newer
+			WHERE newer."serverId" = e."serverId" AND newer."createdAt" > e."createdAt"
+		)
+	`, cutoff)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected(), nil
+}
+
+// SummarizeUptime computes uptime over [from, to] from the state at from and
+// the transitions that follow, oldest first. Time before the first known state
+// is not tracked, and consecutive non-running states form a single outage.
+func SummarizeUptime(initialState string, events []ServerStatusEvent, from, to time.Time) UptimeSummary {
+	summary := UptimeSummary{From: from, To: to, Outages: []ServerOutage{}}
+
+	state := initialState
+	cursor := from
+	var outage *ServerOutage
+	if state != "" && state != ServerStateRunning {
+		// The range starts mid-outage
+		outage = &ServerOutage{Start: from, State: state}
+	}
+
+	advance := func(until time.Time) {
+		if state != "" && until.After(cursor) {
+			elapsed := int64(until.Sub(cursor).Seconds())
+			summary.TrackedSeconds += elapsed
+			if state == ServerStateRunning {
+				summary.UptimeSeconds += elapsed
+			}
+		}
+		cursor = until
+	}
+
+	for _, e := range events {
+		if e.CreatedAt.Before(from) || e.CreatedAt.After(to) {
+			continue
+		}
+		advance(e.CreatedAt)
+
+		switch {
+		case e.State != ServerStateRunning && outage == nil:
+			outage = &ServerOutage{Start: e.CreatedAt, State: e.State}
+		case e.State == ServerStateRunning && outage != nil:
+			end := e.CreatedAt
+			outage.End = &end
+			outage.DurationSeconds = int64(end.Sub(outage.Start).Seconds())
+			summary.Outages = append(summary.Outages, *outage)
+			outage = nil
+		}
+		state = e.State
+	}
+
+	advance(to)
+	if outage != nil {
+		outage.DurationSeconds = int64(to.Sub(outage.Start).Seconds())
+		summary.Outages = append(summary.Outages, *outage)
+	}
+
+	summary.CurrentState = state
+	if summary.TrackedSeconds > 0 {
+		pct := float64(summary.UptimeSeconds) / float64(summary.TrackedSeconds) * 100
+		summary.UptimePercent = &pct
+	}
+	return summary
+}
@@ -0,0 +1,32 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// ServerSuspension is why and when a server was suspended
+type ServerSuspension struct {
+	Reason      *string    `json:"reason"`
+	SuspendedAt *time.Time `json:"suspendedAt"`
+}
+
+// SetServerSuspended records a server as suspended with reason, or clears
+// its suspension. An empty reason is stored as NULL.
+func (db *DB) SetServerSuspended(ctx context.Context, serverID string, suspended bool, reason string) error {
+	var reasonArg *string
+	if suspended && reason != "" {
+		reasonArg = &reason
+	}
+
+	_, err := db.Pool.Exec(ctx, `
+		UPDATE servers SET
+			"isSuspended" = $2,
+			status = CASE WHEN $2 THEN 'suspended' WHEN status = 'suspended' THEN 'online' ELSE status END,
+			"suspensionReason" = $3,
+			"suspendedAt" = CASE WHEN $2 THEN NOW() END,
+			"updatedAt" = NOW()
+		WHERE id = $1
+	`, serverID, suspended, reasonArg)
+	return err
+}
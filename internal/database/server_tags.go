@@ -0,0 +1,111 @@
+package database
+
+import (
+	"context"
+	"strings"
+	"time"
+)
+
+// ServerTag is a label on a server, either personal to a user or global
+type ServerTag struct {
+	Tag       string    `json:"tag"`
+	Global    bool      `json:"global"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// NormalizeServerTag lower-cases and trims a user-supplied tag
+func NormalizeServerTag(tag string) string {
+	return strings.ToLower(strings.TrimSpace(tag))
+}
+
+// ListServerTags returns the tags on a server visible to userID: their own
+// tags plus global tags
+func (db *DB) ListServerTags(ctx context.Context, serverID, userID string) ([]ServerTag, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT tag, "userId" IS NULL, "createdAt"
+		FROM server_tags
+		WHERE "serverId" = $1 AND ("userId" IS NULL OR "userId" = $2)
+		ORDER BY tag
+	`, serverID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	tags := []ServerTag{}
+	for rows.Next() {
+		var t ServerTag
+		if err := rows.Scan(&t.Tag, &t.Global, &t.CreatedAt); err != nil {
+			return nil, err
+		}
+		tags = append(tags, t)
+	}
+	return tags, rows.Err()
+}
+
+// TagsForServers returns the tags visible to userID for each of the given
+// servers, keyed by server ID
+func (db *DB) TagsForServers(ctx context.Context, serverIDs []string, userID string) (map[string][]string, error) {
+	tags := make(map[string][]string)
+	if len(serverIDs) == 0 {
+		return tags, nil
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT DISTINCT "serverId", tag
+		FROM server_tags
+		WHERE "serverId" = ANY($1) AND ("userId" IS NULL OR "userId" = $2)
+		ORDER BY "serverId", tag
+	`, serverIDs, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var serverID, tag string
+		if err := rows.Scan(&serverID, &tag); err != nil {
+			return nil, err
+		}
+		tags[serverID] = append(tags[serverID], tag)
+	}
+	return tags, rows.Err()
+}
+
+// CountServerTags returns how many tags userID has on a server (global tags
+// when userID is empty)
+func (db *DB) CountServerTags(ctx context.Context, serverID, userID string) (int, error) {
+	var count int
+	err := db.Pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM server_tags
+		WHERE "serverId" = $1 AND COALESCE("userId", '') = $2
+	`, serverID, userID).Scan(&count)
+	return count, err
+}
+
+// AddServerTag tags a server for userID, or globally when userID is empty.
+// It returns false if the tag already exists.
+func (db *DB) AddServerTag(ctx context.Context, serverID, userID, tag string) (bool, error) {
+	res, err := db.Pool.Exec(ctx, `
+		INSERT INTO server_tags ("serverId", "userId", tag, "createdAt")
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT ("serverId", COALESCE("userId", ''), tag) DO NOTHING
+	`, serverID, NewNullString(userID), NormalizeServerTag(tag))
+	if err != nil {
+		return false, err
+	}
+	return res.RowsAffected() > 0, nil
+}
+
+// RemoveServerTag removes a tag userID put on a server, or a global tag when
+// userID is empty. It returns false if there was no such tag.
+func (db *DB) RemoveServerTag(ctx context.Context, serverID, userID, tag string) (bool, error) {
+	res, err := db.Pool.Exec(ctx, `
+		DELETE FROM server_tags
+		WHERE "serverId" = $1 AND COALESCE("userId", '') = $2 AND tag = $3
+	`, serverID, userID, NormalizeServerTag(tag))
+	if err != nil {
+		return false, err
+	}
+	return res.RowsAffected() > 0, nil
+}
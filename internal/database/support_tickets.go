@@ -0,0 +1,80 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// TicketContact is a user on either side of a support ticket
+type TicketContact struct {
+	UserID string
+	Email  string
+	Name   string // first name, falling back to username
+}
+
+// SupportTicketParties is a ticket with the people its notifications go to
+type SupportTicketParties struct {
+	ID           string
+	TicketNumber string
+	Title        string
+	Priority     string
+	Owner        TicketContact
+	Assignee     *TicketContact // nil while the ticket is unassigned
+}
+
+// SupportTicketReply is a reply on a support ticket
+type SupportTicketReply struct {
+	ID         string
+	UserID     string
+	IsInternal bool // staff-only note, never sent to the ticket owner
+}
+
+// GetSupportTicketParties returns a ticket with its owner and assignee, or
+// nil if the ticket does not exist
+func (db *DB) GetSupportTicketParties(ctx context.Context, ticketID string) (*SupportTicketParties, error) {
+	var t SupportTicketParties
+	var assigneeID, assigneeEmail, assigneeName *string
+	err := db.Pool.QueryRow(ctx, `
+		SELECT t.id, t."ticketNumber", t.title, COALESCE(t.priority, ''),
+			o.id, o.email, COALESCE(o."firstName", o.username, ''),
+			a.id, a.email, COALESCE(a."firstName", a.username, '')
+		FROM support_tickets t
+		JOIN users o ON o.id = t."userId"
+		LEFT JOIN users a ON a.id = t."assignedToId"
+		WHERE t.id = $1
+	`, ticketID).Scan(
+		&t.ID, &t.TicketNumber, &t.Title, &t.Priority,
+		&t.Owner.UserID, &t.Owner.Email, &t.Owner.Name,
+		&assigneeID, &assigneeEmail, &assigneeName,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if assigneeID != nil {
+		t.Assignee = &TicketContact{UserID: *assigneeID, Email: *assigneeEmail, Name: *assigneeName}
+	}
+	return &t, nil
+}
+
+// GetSupportTicketReply returns a live reply on ticketID, or nil if there is
+// no such reply
+func (db *DB) GetSupportTicketReply(ctx context.Context, ticketID, replyID string) (*SupportTicketReply, error) {
+	var r SupportTicketReply
+	err := db.Pool.QueryRow(ctx, `
+		SELECT id, "userId", COALESCE("isInternal", false)
+		FROM support_ticket_replies
+		WHERE id = $1 AND "ticketId" = $2 AND "deletedAt" IS NULL
+	`, replyID, ticketID).Scan(&r.ID, &r.UserID, &r.IsInternal)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &r, nil
+}
@@ -0,0 +1,131 @@
+package database
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Sync change actions
+const (
+	SyncChangeCreated = "created"
+	SyncChangeUpdated = "updated"
+	SyncChangeDeleted = "deleted"
+)
+
+// MaxSyncChangesPerRun caps how many changes are stored for one sync run so a
+// first sync against a large panel does not flood the table
+const MaxSyncChangesPerRun = 10000
+
+// SyncChange is an entity created, updated or deleted by a sync run
+type SyncChange struct {
+	EntityType string    `json:"entityType"`
+	EntityID   string    `json:"entityId"`
+	Action     string    `json:"action"`
+	Name       string    `json:"name,omitempty"`
+	CreatedAt  time.Time `json:"createdAt"`
+}
+
+// RecordSyncChanges stores changes for a sync run, dropping any beyond
+// MaxSyncChangesPerRun. It returns how many were stored.
+func (r *SyncRepository) RecordSyncChanges(ctx context.Context, syncLogID string, changes []SyncChange) (int, error) {
+	if len(changes) == 0 {
+		return 0, nil
+	}
+
+	var existing int
+	if err := r.db.Pool.QueryRow(ctx,
+		`SELECT COUNT(*) FROM sync_changes WHERE "syncLogId" = $1`, syncLogID,
+	).Scan(&existing); err != nil {
+		return 0, err
+	}
+	room := MaxSyncChangesPerRun - existing
+	if room <= 0 {
+		return 0, nil
+	}
+	if len(changes) > room {
+		changes = changes[:room]
+	}
+
+	now := time.Now()
+	n, err := r.db.Pool.CopyFrom(ctx,
+		pgx.Identifier{"sync_changes"},
+		[]string{"syncLogId", "entityType", "entityId", "action", "name", "createdAt"},
+		pgx.CopyFromSlice(len(changes), func(i int) ([]any, error) {
+			c := changes[i]
+			return []any{syncLogID, c.EntityType, c.EntityID, c.Action, NewNullString(c.Name), now}, nil
+		}),
+	)
+	return int(n), err
+}
+
+// GetSyncChanges returns a page of a sync run's changes, optionally filtered
+// by entity type and action, along with the total matching count
+func (r *SyncRepository) GetSyncChanges(ctx context.Context, syncLogID, entityType, action string, limit, offset int) ([]SyncChange, int, error) {
+	where := `WHERE "syncLogId" = $1`
+	args := []interface{}{syncLogID}
+	if entityType != "" {
+		args = append(args, entityType)
+		where += ` AND "entityType" = $` + strconv.Itoa(len(args))
+	}
+	if action != "" {
+		args = append(args, action)
+		where += ` AND action = $` + strconv.Itoa(len(args))
+	}
+
+	var total int
+	if err := r.db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM sync_changes `+where, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	args = append(args, limit, offset)
+	rows, err := r.db.Pool.Query(ctx, `
+		SELECT "entityType", "entityId", action, COALESCE(name, ''), "createdAt"
+		FROM sync_changes `+where+`
+		ORDER BY id
+		LIMIT $`+strconv.Itoa(len(args)-1)+` OFFSET $`+strconv.Itoa(len(args)), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	changes := []SyncChange{}
+	for rows.Next() {
+		var c SyncChange
+		if err := rows.Scan(&c.EntityType, &c.EntityID, &c.Action, &c.Name, &c.CreatedAt); err != nil {
+			return nil, 0, err
+		}
+		changes = append(changes, c)
+	}
+	return changes, total, rows.Err()
+}
+
+// SummarizeSyncChanges counts a sync run's changes by entity type and action
+func (r *SyncRepository) SummarizeSyncChanges(ctx context.Context, syncLogID string) (map[string]map[string]int, error) {
+	rows, err := r.db.Pool.Query(ctx, `
+		SELECT "entityType", action, COUNT(*)
+		FROM sync_changes
+		WHERE "syncLogId" = $1
+		GROUP BY "entityType", action
+	`, syncLogID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	summary := make(map[string]map[string]int)
+	for rows.Next() {
+		var entityType, action string
+		var count int
+		if err := rows.Scan(&entityType, &action, &count); err != nil {
+			return nil, err
+		}
+		if summary[entityType] == nil {
+			summary[entityType] = make(map[string]int)
+		}
+		summary[entityType][action] = count
+	}
+	return summary, rows.Err()
+}
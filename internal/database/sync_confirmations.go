@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// Destructive admin sync actions that can require confirmation
+const (
+	SyncConfirmSync    = "sync"    // a single sync type that prunes stale data
+	SyncConfirmBatch   = "batch"   // a batch sync with a pruning step
+	SyncConfirmCancel  = "cancel"  // cancelling the running sync
+	SyncConfirmResume  = "resume"  // resuming a failed full sync
+	SyncConfirmRelease = "release" // failing the syncs that hold the sync lock
+)
+
+// CreateSyncConfirmation issues a token confirming action on target for the
+// given admin, valid for ttl. Expired tokens are cleared at the same time.
+func (db *DB) CreateSyncConfirmation(ctx context.Context, action, target, actorID string, ttl time.Duration) (string, time.Time, error) {
+	if _, err := db.Pool.Exec(ctx, `DELETE FROM sync_confirmations WHERE "expiresAt" < NOW()`); err != nil {
+		return "", time.Time{}, err
+	}
+
+	token := generateRandomToken()
+	expiresAt := time.Now().Add(ttl)
+	_, err := db.Pool.Exec(ctx, `
+		INSERT INTO sync_confirmations ("tokenHash", action, target, "actorId", "expiresAt")
+		VALUES ($1, $2, $3, $4, $5)
+	`, hashToken(token), action, target, actorID, expiresAt)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	return token, expiresAt, nil
+}
+
+// ConsumeSyncConfirmation uses up a token issued to actorID for action on
+// target. It reports false if the token is unknown, expired, already used or
+// was issued for something else.
+func (db *DB) ConsumeSyncConfirmation(ctx context.Context, token, action, target, actorID string) (bool, error) {
+	tag, err := db.Pool.Exec(ctx, `
+		UPDATE sync_confirmations SET "usedAt" = NOW()
+		WHERE "tokenHash" = $1 AND action = $2 AND target = $3 AND "actorId" = $4
+			AND "usedAt" IS NULL AND "expiresAt" > NOW()
+	`, hashToken(token), action, target, actorID)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
+
+// LastCompletedSyncStart returns when the latest completed sync of one of
+// syncTypes started, or nil when none has completed
+func (db *DB) LastCompletedSyncStart(ctx context.Context, syncTypes []string) (*time.Time, error) {
+	var startedAt *time.Time
+	err := db.Pool.QueryRow(ctx, `
+		SELECT MAX("startedAt") FROM sync_logs WHERE status = 'COMPLETED' AND type = ANY($1)
+	`, syncTypes).Scan(&startedAt)
+	return startedAt, err
+}
+
+// CountStaleServers counts panel servers not refreshed since the sync that
+// started at since, which the panel no longer reported. With liveOnly,
+// servers already marked deleted are left out, as a tombstoning sync does not
+// count them again.
+func (db *DB) CountStaleServers(ctx context.Context, since time.Time, liveOnly bool) (int, error) {
+	var count int
+	err := db.Pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM servers
+		WHERE "updatedAt" < $1 AND "pterodactylId" IS NOT NULL
+			AND "panelType" = 'pterodactyl' AND (NOT $2 OR "deletedAt" IS NULL)
+	`, since, liveOnly).Scan(&count)
+	return count, err
+}
+
+// CountStaleNodes counts nodes not refreshed since the sync that started at
+// since. Nodes still referenced by servers are kept by the sync, so this is
+// an upper bound.
+func (db *DB) CountStaleNodes(ctx context.Context, since time.Time) (int, error) {
+	var count int
+	err := db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM nodes WHERE "updatedAt" < $1`, since).Scan(&count)
+	return count, err
+}
+
+// CountStaleLocations counts locations not refreshed since the sync that
+// started at since. Locations still referenced by nodes are kept by the sync,
+// so this is an upper bound.
+func (db *DB) CountStaleLocations(ctx context.Context, since time.Time) (int, error) {
+	var count int
+	err := db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM locations WHERE "updatedAt" < $1`, since).Scan(&count)
+	return count, err
+}
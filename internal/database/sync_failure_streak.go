@@ -0,0 +1,34 @@
+package database
+
+import "context"
+
+// RecordSyncFailure counts a failed sync towards the consecutive failure
+// streak and returns the streak's new length. A sync that already counted
+// (a retried task failing again) leaves the streak unchanged.
+func (db *DB) RecordSyncFailure(ctx context.Context, syncLogID string) (int, error) {
+	var streak int
+	err := db.Pool.QueryRow(ctx, `
+		INSERT INTO sync_failure_streak (id, "consecutiveFailures", "lastFailedSyncId", "updatedAt")
+		VALUES (1, 1, $1, NOW())
+		ON CONFLICT (id) DO UPDATE SET
+			"consecutiveFailures" = CASE
+				WHEN sync_failure_streak."lastFailedSyncId" = EXCLUDED."lastFailedSyncId"
+					AND sync_failure_streak."consecutiveFailures" > 0
+				THEN sync_failure_streak."consecutiveFailures"
+				ELSE sync_failure_streak."consecutiveFailures" + 1
+			END,
+			"lastFailedSyncId" = EXCLUDED."lastFailedSyncId",
+			"updatedAt" = NOW()
+		RETURNING "consecutiveFailures"
+	`, syncLogID).Scan(&streak)
+	return streak, err
+}
+
+// ResetSyncFailures ends the consecutive failure streak after a successful sync
+func (db *DB) ResetSyncFailures(ctx context.Context) error {
+	_, err := db.Pool.Exec(ctx, `
+		UPDATE sync_failure_streak SET "consecutiveFailures" = 0, "updatedAt" = NOW()
+		WHERE "consecutiveFailures" > 0
+	`)
+	return err
+}
@@ -3,7 +3,10 @@ package database
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"math"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -50,6 +53,11 @@ func (r *SyncRepository) CreateSyncLog(ctx context.Context, syncType, status str
 	return syncLog, nil
 }
 
+// syncLogKeptMetadata lists the metadata keys UpdateSyncLog never replaces:
+// the step breakdown and the options a sync was started with, which a
+// resume carries over
+var syncLogKeptMetadata = []string{"steps", "requested_by", "skip_users", "batch_steps", "resumed_from", "start_step"}
+
 // UpdateSyncLog updates a sync log entry
 func (r *SyncRepository) UpdateSyncLog(ctx context.Context, syncLogID, status string, itemsTotal, itemsSynced, itemsFailed *int, metadata map[string]interface{}) error {
 	var metadataJSON []byte
@@ -80,7 +88,13 @@ func (r *SyncRepository) UpdateSyncLog(ctx context.Context, syncLogID, status st
 	}
 
 	if len(metadataJSON) > 0 {
-		query += `, metadata = $` + strconv.Itoa(len(args)+1)
+		// Keep the per-step breakdown, which is maintained by UpdateSyncStep,
+		// and the options the sync was started with
+		kept := make([]string, 0, len(syncLogKeptMetadata))
+		for _, key := range syncLogKeptMetadata {
+			kept = append(kept, fmt.Sprintf(`'%s', metadata->'%s'`, key, key))
+		}
+		query += `, metadata = $` + strconv.Itoa(len(args)+1) + `::jsonb || jsonb_strip_nulls(jsonb_build_object(` + strings.Join(kept, ", ") + `))`
 		args = append(args, string(metadataJSON))
 	}
 
@@ -117,12 +131,50 @@ func (r *SyncRepository) GetSyncLogs(ctx context.Context, limit int, offset int,
 		if err != nil {
 			continue
 		}
+		log.parseSteps()
 		logs = append(logs, log)
 	}
 
 	return logs, nil
 }
 
+// CountSyncLogs returns the number of sync logs, optionally of one type
+func (r *SyncRepository) CountSyncLogs(ctx context.Context, syncType string) (int, error) {
+	var count int
+	err := r.db.Pool.QueryRow(ctx,
+		`SELECT COUNT(*) FROM sync_logs WHERE $1 = '' OR type = $1`, syncType,
+	).Scan(&count)
+	return count, err
+}
+
+// EachSyncLog calls fn with every sync log, optionally of one type, newest
+// first. Rows are read as fn consumes them rather than loaded up front, so
+// the full history can be exported. Steps are not parsed. It stops at the
+// first error fn returns.
+func (r *SyncRepository) EachSyncLog(ctx context.Context, syncType string, fn func(SyncLog) error) error {
+	rows, err := r.db.Pool.Query(ctx, `
+		SELECT id, type, status, "itemsTotal", "itemsSynced", "itemsFailed", error, metadata, "startedAt", "completedAt"
+		FROM sync_logs
+		WHERE $1 = '' OR type = $1
+		ORDER BY "startedAt" DESC
+	`, syncType)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var log SyncLog
+		if err := rows.Scan(&log.ID, &log.Type, &log.Status, &log.ItemsTotal, &log.ItemsSynced, &log.ItemsFailed, &log.Error, &log.Metadata, &log.StartedAt, &log.CompletedAt); err != nil {
+			return err
+		}
+		if err := fn(log); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
 // GetSyncLog retrieves a specific sync log by ID
 func (r *SyncRepository) GetSyncLog(ctx context.Context, syncLogID string) (*SyncLog, error) {
 	var log SyncLog
@@ -134,10 +186,232 @@ func (r *SyncRepository) GetSyncLog(ctx context.Context, syncLogID string) (*Syn
 	if err != nil {
 		return nil, err
 	}
+	log.parseSteps()
 
 	return &log, nil
 }
 
+// Outcome returns how long a finished sync ran and its error message, if any.
+// The duration comes from the recorded metadata, falling back to the start
+// and completion times.
+func (l *SyncLog) Outcome() (time.Duration, string) {
+	var metadata struct {
+		Duration float64 `json:"duration"`
+		Error    string  `json:"error"`
+	}
+	json.Unmarshal([]byte(l.Metadata), &metadata)
+
+	duration := time.Duration(metadata.Duration * float64(time.Second))
+	if duration == 0 && l.CompletedAt != nil {
+		duration = l.CompletedAt.Sub(l.StartedAt)
+	}
+
+	errMsg := metadata.Error
+	if l.Error != nil && *l.Error != "" {
+		errMsg = *l.Error
+	}
+	return duration, errMsg
+}
+
+// UpdateSyncStep applies update to the named step of a sync log's per-step
+// breakdown, adding the step as RUNNING if it is not recorded yet. The sync
+// log row is locked for the read-modify-write so concurrent progress updates
+// are applied one after the other instead of overwriting each other.
+func (r *SyncRepository) UpdateSyncStep(ctx context.Context, syncLogID, step string, update func(*SyncStep)) error {
+	tx, err := r.db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	var raw []byte
+	query := `SELECT COALESCE(metadata->'steps', '[]'::jsonb) FROM sync_logs WHERE id = $1 FOR UPDATE`
+	if err := tx.QueryRow(ctx, query, syncLogID).Scan(&raw); err != nil {
+		return err
+	}
+
+	var steps []SyncStep
+	if err := json.Unmarshal(raw, &steps); err != nil {
+		steps = nil
+	}
+
+	idx := -1
+	for i := range steps {
+		if steps[i].Step == step {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		steps = append(steps, SyncStep{Step: step, Status: "RUNNING", StartedAt: time.Now()})
+		idx = len(steps) - 1
+	}
+	update(&steps[idx])
+
+	stepsJSON, err := json.Marshal(steps)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(ctx, `
+		UPDATE sync_logs SET metadata = jsonb_set(COALESCE(metadata, '{}'::jsonb), '{steps}', $2::jsonb)
+		WHERE id = $1
+	`, syncLogID, string(stepsJSON))
+	if err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// syncRateSmoothing weights the newest sample in a step's rolling rate
+const syncRateSmoothing = 0.3
+
+// RecordProgress updates the step's item counts and its rolling rate, an
+// exponentially weighted average of the rate between progress updates
+func (s *SyncStep) RecordProgress(itemsTotal, itemsProcessed int, now time.Time) {
+	since, done := s.StartedAt, 0
+	if s.UpdatedAt != nil && itemsProcessed >= s.ItemsProcessed {
+		since, done = *s.UpdatedAt, s.ItemsProcessed
+	}
+
+	if elapsed := now.Sub(since).Seconds(); elapsed > 0 {
+		rate := float64(itemsProcessed-done) / elapsed
+		if s.UpdatedAt == nil || s.ItemsPerSecond == 0 {
+			s.ItemsPerSecond = rate
+		} else {
+			s.ItemsPerSecond = syncRateSmoothing*rate + (1-syncRateSmoothing)*s.ItemsPerSecond
+		}
+	}
+
+	s.ItemsTotal = itemsTotal
+	s.ItemsProcessed = itemsProcessed
+	s.UpdatedAt = &now
+}
+
+// throughput returns the rate and time remaining of the step at now. The
+// estimate counts down from the last progress update.
+func (s SyncStep) throughput(now time.Time) *SyncThroughput {
+	t := &SyncThroughput{
+		Step:           s.Step,
+		ItemsTotal:     s.ItemsTotal,
+		ItemsProcessed: s.ItemsProcessed,
+		ItemsPerSecond: s.ItemsPerSecond,
+		ElapsedSeconds: int(now.Sub(s.StartedAt).Seconds()),
+	}
+
+	remaining := s.ItemsTotal - s.ItemsProcessed
+	if s.ItemsTotal > 0 && s.ItemsPerSecond > 0 && remaining >= 0 {
+		eta := float64(remaining) / s.ItemsPerSecond
+		if s.UpdatedAt != nil {
+			eta -= now.Sub(*s.UpdatedAt).Seconds()
+		}
+		seconds := int(math.Ceil(math.Max(eta, 0)))
+		t.EtaSeconds = &seconds
+	}
+	return t
+}
+
+// parseSteps fills Steps from the "steps" key of the metadata, and
+// Throughput from the running step while the sync is active
+func (l *SyncLog) parseSteps() {
+	var metadata struct {
+		Steps []SyncStep `json:"steps"`
+	}
+	json.Unmarshal([]byte(l.Metadata), &metadata)
+
+	l.Steps = metadata.Steps
+	if l.Steps == nil {
+		l.Steps = []SyncStep{}
+	}
+
+	l.Throughput = nil
+	if l.CompletedAt != nil {
+		return
+	}
+	for i := len(l.Steps) - 1; i >= 0; i-- {
+		if l.Steps[i].Status == "RUNNING" {
+			l.Throughput = l.Steps[i].throughput(time.Now())
+			return
+		}
+	}
+}
+
+// activeSyncStatuses lists the sync log statuses that hold the sync lock
+const activeSyncStatuses = `('PENDING', 'RUNNING', 'in_progress')`
+
+// GetActiveSyncLog returns the most recently started sync that has not
+// finished. It returns pgx.ErrNoRows when no sync holds the lock.
+func (r *SyncRepository) GetActiveSyncLog(ctx context.Context) (*SyncLog, error) {
+	var log SyncLog
+	query := `SELECT id, type, status, "itemsTotal", "itemsSynced", "itemsFailed", error, metadata, "startedAt", "completedAt"
+		FROM sync_logs WHERE status IN ` + activeSyncStatuses + ` ORDER BY "startedAt" DESC LIMIT 1`
+
+	err := r.db.Pool.QueryRow(ctx, query).Scan(
+		&log.ID, &log.Type, &log.Status, &log.ItemsTotal, &log.ItemsSynced, &log.ItemsFailed, &log.Error, &log.Metadata, &log.StartedAt, &log.CompletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	log.parseSteps()
+
+	return &log, nil
+}
+
+// FailActiveSyncs marks every unfinished sync started before startedBefore as
+// FAILED with the given reason, releasing the sync lock. The syncs are also
+// flagged as cancelled so a worker that is still alive stops at its next step.
+// Returns the ids of the syncs that were failed.
+func (r *SyncRepository) FailActiveSyncs(ctx context.Context, startedBefore time.Time, reason string) ([]string, error) {
+	rows, err := r.db.Pool.Query(ctx, `
+		UPDATE sync_logs SET
+			status = 'FAILED',
+			error = $2,
+			"completedAt" = NOW(),
+			"cancelledAt" = COALESCE("cancelledAt", NOW())
+		WHERE status IN `+activeSyncStatuses+` AND "startedAt" < $1
+		RETURNING id
+	`, startedBefore, reason)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// PurgeServerTombstones deletes servers tombstoned before deletedBefore that
+// have been missing from at least minMissing syncs in a row. Returns the ids
+// of the servers that were deleted.
+func (r *SyncRepository) PurgeServerTombstones(ctx context.Context, deletedBefore time.Time, minMissing int) ([]string, error) {
+	rows, err := r.db.Pool.Query(ctx, `
+		DELETE FROM servers
+		WHERE "deletedAt" IS NOT NULL AND "deletedAt" < $1 AND "missingSyncs" >= $2
+		RETURNING id
+	`, deletedBefore, minMissing)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
 // IsSyncCancelled checks if a sync has been marked for cancellation
 func (r *SyncRepository) IsSyncCancelled(ctx context.Context, syncLogID string) (bool, error) {
 	var cancelledAt *time.Time
@@ -0,0 +1,62 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/nodebyte/backend/internal/auth"
+)
+
+// ImportedUser is an account created by an admin bulk import
+type ImportedUser struct {
+	Email     string
+	Username  string
+	FirstName string
+	LastName  string
+	Roles     []string
+}
+
+// CreateImportedUsers inserts a batch of imported users in a single
+// transaction. Imported accounts have no password and must set one through
+// password reset before they can log in. Emails that already exist are left
+// untouched. A SUPER_ADMIN role makes the user a system admin, so callers
+// must only pass it for imports run by a system admin. It returns the IDs of the created users keyed by email.
+func (db *DB) CreateImportedUsers(ctx context.Context, users []ImportedUser) (map[string]string, error) {
+	tx, err := db.Pool.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	now := time.Now()
+	created := make(map[string]string, len(users))
+	for _, u := range users {
+		var id string
+		err := tx.QueryRow(ctx, `
+			INSERT INTO users
+			(id, email, username, "firstName", "lastName", roles, "isSystemAdmin",
+			"isActive", "passwordResetRequired", "createdAt", "updatedAt")
+			VALUES ($1, $2, $3, $4, $5, $6, $7, true, true, $8, $8)
+			ON CONFLICT (email) DO NOTHING
+			RETURNING id`,
+			generateUUID(), u.Email, NewNullString(u.Username), NewNullString(u.FirstName),
+			NewNullString(u.LastName), u.Roles, auth.HasSuperAdminRole(u.Roles), now,
+		).Scan(&id)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to import %s: %w", u.Email, err)
+		}
+		created[u.Email] = id
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return nil, err
+	}
+	return created, nil
+}
@@ -0,0 +1,53 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// UserMigrationState is a user's migrated flag and the criteria behind it. A
+// user counts as migrated once their account is linked to a panel account
+// and their email is verified, i.e. they can sign in here and see their panel
+// servers. The user sync sets the flag for users who meet this; admins can
+// set or clear it by hand.
+type UserMigrationState struct {
+	IsMigrated    bool `json:"isMigrated"`
+	PanelLinked   bool `json:"panelLinked"`
+	EmailVerified bool `json:"emailVerified"`
+}
+
+// Eligible reports whether the user meets the migration criteria
+func (s UserMigrationState) Eligible() bool {
+	return s.PanelLinked && s.EmailVerified
+}
+
+// GetUserMigrationState returns a user's migration state, or nil if the user
+// does not exist
+func (db *DB) GetUserMigrationState(ctx context.Context, userID string) (*UserMigrationState, error) {
+	var s UserMigrationState
+	err := db.Pool.QueryRow(ctx, `
+		SELECT "isMigrated", "pterodactylId" IS NOT NULL, "emailVerified" IS NOT NULL
+		FROM users WHERE id = $1
+	`, userID).Scan(&s.IsMigrated, &s.PanelLinked, &s.EmailVerified)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+// SetUserMigrated sets a user's migrated flag. It reports false if the user
+// does not exist.
+func (db *DB) SetUserMigrated(ctx context.Context, userID string, migrated bool) (bool, error) {
+	tag, err := db.Pool.Exec(ctx, `
+		UPDATE users SET "isMigrated" = $2, "updatedAt" = NOW() WHERE id = $1
+	`, userID, migrated)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
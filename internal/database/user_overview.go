@@ -0,0 +1,142 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// UserProfile is the account detail shown to support staff
+type UserProfile struct {
+	ID             string     `json:"id"`
+	Email          string     `json:"email"`
+	Username       *string    `json:"username"`
+	FirstName      *string    `json:"firstName"`
+	LastName       *string    `json:"lastName"`
+	CompanyName    *string    `json:"companyName"`
+	PhoneNumber    *string    `json:"phoneNumber"`
+	BillingEmail   *string    `json:"billingEmail"`
+	Roles          []string   `json:"roles"`
+	IsActive       bool       `json:"isActive"`
+	IsSystemAdmin  bool       `json:"isSystemAdmin"`
+	EmailVerified  bool       `json:"emailVerified"`
+	PterodactylID  *int       `json:"pterodactylId"`
+	AccountStatus  string     `json:"accountStatus"`
+	AccountBalance float64    `json:"accountBalance"`
+	CreatedAt      time.Time  `json:"createdAt"`
+	LastLoginAt    *time.Time `json:"lastLoginAt"`
+}
+
+// UserServerSummary is one server in a user's overview
+type UserServerSummary struct {
+	ID          string            `json:"id"`
+	UUID        *string           `json:"uuid"`
+	Name        string            `json:"name"`
+	Status      string            `json:"status"`
+	IsSuspended bool              `json:"isSuspended"`
+	Suspension  *ServerSuspension `json:"suspension,omitempty"`
+	Node        *string           `json:"node"`
+	Egg         *string           `json:"egg"`
+	CreatedAt   time.Time         `json:"createdAt"`
+}
+
+// UserSessionSummary is a login session without its token
+type UserSessionSummary struct {
+	ID        string    `json:"id"`
+	CreatedAt time.Time `json:"createdAt"`
+	Expires   time.Time `json:"expires"`
+	Active    bool      `json:"active"`
+}
+
+// GetUserProfile returns a user's profile, or nil if the user does not exist
+func (db *DB) GetUserProfile(ctx context.Context, userID string) (*UserProfile, error) {
+	p := &UserProfile{}
+	err := db.Pool.QueryRow(ctx, `
+		SELECT id, email, username, "firstName", "lastName", "companyName", "phoneNumber", "billingEmail",
+		       COALESCE(roles, '{}'), COALESCE("isActive", true), COALESCE("isSystemAdmin", false),
+		       "emailVerified" IS NOT NULL, "pterodactylId", COALESCE("accountStatus", 'active'),
+		       COALESCE("accountBalance", 0)::FLOAT8, "createdAt", "lastLoginAt"
+		FROM users WHERE id = $1
+	`, userID).Scan(
+		&p.ID, &p.Email, &p.Username, &p.FirstName, &p.LastName, &p.CompanyName, &p.PhoneNumber, &p.BillingEmail,
+		&p.Roles, &p.IsActive, &p.IsSystemAdmin,
+		&p.EmailVerified, &p.PterodactylID, &p.AccountStatus,
+		&p.AccountBalance, &p.CreatedAt, &p.LastLoginAt,
+	)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// ListUserServers returns the servers a user owns, newest first
+func (db *DB) ListUserServers(ctx context.Context, userID string) ([]UserServerSummary, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT s.id, s.uuid, s.name, COALESCE(s.status, 'unknown'), COALESCE(s."isSuspended", false),
+		       s."suspensionReason", s."suspendedAt", n.name, e.name, s."createdAt"
+		FROM servers s
+		LEFT JOIN nodes n ON s."nodeId" = n.id
+		LEFT JOIN eggs e ON s."eggId" = e.id
+		WHERE s."ownerId" = $1 AND s."deletedAt" IS NULL
+		ORDER BY s."createdAt" DESC
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	servers := []UserServerSummary{}
+	for rows.Next() {
+		var s UserServerSummary
+		var suspension ServerSuspension
+		if err := rows.Scan(&s.ID, &s.UUID, &s.Name, &s.Status, &s.IsSuspended,
+			&suspension.Reason, &suspension.SuspendedAt, &s.Node, &s.Egg, &s.CreatedAt); err != nil {
+			return nil, err
+		}
+		if s.IsSuspended {
+			s.Suspension = &suspension
+		}
+		servers = append(servers, s)
+	}
+	return servers, rows.Err()
+}
+
+// CountOpenTickets returns how many of a user's support tickets are still open
+func (db *DB) CountOpenTickets(ctx context.Context, userID string) (int, error) {
+	var count int
+	err := db.Pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM support_tickets
+		WHERE "userId" = $1 AND status IN ('open', 'pending', 'in_progress')
+	`, userID).Scan(&count)
+	return count, err
+}
+
+// RecentUserSessions returns a user's latest login sessions, newest first
+func (db *DB) RecentUserSessions(ctx context.Context, userID string, limit int) ([]UserSessionSummary, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT id, "createdAt", expires, expires > NOW()
+		FROM sessions
+		WHERE "userId" = $1
+		ORDER BY "createdAt" DESC
+		LIMIT $2
+	`, userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []UserSessionSummary{}
+	for rows.Next() {
+		var s UserSessionSummary
+		if err := rows.Scan(&s.ID, &s.CreatedAt, &s.Expires, &s.Active); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	return sessions, rows.Err()
+}
@@ -0,0 +1,99 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// User sync conflict kinds
+const (
+	// UserConflictEmailTaken means the panel user's email belongs to a local
+	// user linked to a different panel account
+	UserConflictEmailTaken = "EMAIL_TAKEN"
+	// UserConflictEmailChanged means the panel account is linked to a local
+	// user with a different email
+	UserConflictEmailChanged = "EMAIL_CHANGED"
+)
+
+// UserSyncConflict is a panel user the user sync did not merge into the
+// local user it collided with
+type UserSyncConflict struct {
+	ID                 int64      `json:"id"`
+	Kind               string     `json:"kind"`
+	UserID             string     `json:"userId"`
+	Email              string     `json:"email"`
+	LocalPterodactylID *int       `json:"localPterodactylId"`
+	PanelEmail         string     `json:"panelEmail"`
+	PanelPterodactylID int        `json:"panelPterodactylId"`
+	SyncLogID          *string    `json:"syncLogId"`
+	CreatedAt          time.Time  `json:"createdAt"`
+	LastSeenAt         time.Time  `json:"lastSeenAt"`
+	ResolvedAt         *time.Time `json:"resolvedAt"`
+	ResolvedBy         *string    `json:"resolvedBy"`
+}
+
+// RecordUserSyncConflict stores a conflict for review, or refreshes the open
+// conflict it repeats
+func (db *DB) RecordUserSyncConflict(ctx context.Context, c UserSyncConflict) error {
+	_, err := db.Pool.Exec(ctx, `
+		INSERT INTO user_sync_conflicts (
+			kind, "userId", email, "localPterodactylId", "panelEmail", "panelPterodactylId", "syncLogId"
+		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (kind, "userId", "panelPterodactylId") WHERE "resolvedAt" IS NULL DO UPDATE SET
+			email = EXCLUDED.email,
+			"localPterodactylId" = EXCLUDED."localPterodactylId",
+			"panelEmail" = EXCLUDED."panelEmail",
+			"syncLogId" = COALESCE(EXCLUDED."syncLogId", user_sync_conflicts."syncLogId"),
+			"lastSeenAt" = NOW()
+	`, c.Kind, c.UserID, c.Email, c.LocalPterodactylID, c.PanelEmail, c.PanelPterodactylID, c.SyncLogID)
+	return err
+}
+
+// ListUserSyncConflicts returns a page of conflicts, most recently seen
+// first, and the total matching. Resolved conflicts are only included when
+// includeResolved is set.
+func (db *DB) ListUserSyncConflicts(ctx context.Context, includeResolved bool, limit, offset int) ([]UserSyncConflict, int, error) {
+	var total int
+	if err := db.Pool.QueryRow(ctx, `
+		SELECT COUNT(*) FROM user_sync_conflicts WHERE $1 OR "resolvedAt" IS NULL
+	`, includeResolved).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT id, kind, "userId", email, "localPterodactylId", "panelEmail", "panelPterodactylId",
+			"syncLogId", "createdAt", "lastSeenAt", "resolvedAt", "resolvedBy"
+		FROM user_sync_conflicts
+		WHERE $1 OR "resolvedAt" IS NULL
+		ORDER BY "lastSeenAt" DESC, id DESC
+		LIMIT $2 OFFSET $3
+	`, includeResolved, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	conflicts := []UserSyncConflict{}
+	for rows.Next() {
+		var c UserSyncConflict
+		if err := rows.Scan(&c.ID, &c.Kind, &c.UserID, &c.Email, &c.LocalPterodactylID, &c.PanelEmail,
+			&c.PanelPterodactylID, &c.SyncLogID, &c.CreatedAt, &c.LastSeenAt, &c.ResolvedAt, &c.ResolvedBy); err != nil {
+			return nil, 0, err
+		}
+		conflicts = append(conflicts, c)
+	}
+	return conflicts, total, rows.Err()
+}
+
+// ResolveUserSyncConflict marks an open conflict resolved. It reports false if
+// there is no open conflict with that ID.
+func (db *DB) ResolveUserSyncConflict(ctx context.Context, id int64, resolvedBy string) (bool, error) {
+	tag, err := db.Pool.Exec(ctx, `
+		UPDATE user_sync_conflicts SET "resolvedAt" = NOW(), "resolvedBy" = NULLIF($2, '')
+		WHERE id = $1 AND "resolvedAt" IS NULL
+	`, id, resolvedBy)
+	if err != nil {
+		return false, err
+	}
+	return tag.RowsAffected() > 0, nil
+}
@@ -0,0 +1,104 @@
+package database
+
+import (
+	"context"
+	"time"
+)
+
+// WebhookDeliveryStats counts a webhook's deliveries since a point in time
+type WebhookDeliveryStats struct {
+	Succeeded      int        `json:"succeeded"`
+	Failed         int        `json:"failed"`
+	LastDeliveryAt *time.Time `json:"lastDeliveryAt"`
+	LastError      *string    `json:"lastError"` // error of the latest failed delivery
+}
+
+// AdminWebhook is a Discord webhook with its recent delivery stats
+type AdminWebhook struct {
+	ID              string               `json:"id"`
+	Name            string               `json:"name"`
+	WebhookURL      string               `json:"webhookUrl"`
+	Type            string               `json:"type"`
+	Scope           string               `json:"scope"`
+	Description     string               `json:"description"`
+	MessageTemplate string               `json:"messageTemplate"`
+	Enabled         bool                 `json:"enabled"`
+	TestSuccessAt   *time.Time           `json:"testSuccessAt"`
+	CreatedAt       time.Time            `json:"createdAt"`
+	Deliveries      WebhookDeliveryStats `json:"deliveries"`
+}
+
+// RecordWebhookDelivery stores the outcome of sending event to a webhook; a
+// nil sendErr records a success
+func (db *DB) RecordWebhookDelivery(ctx context.Context, webhookID, event string, sendErr error) error {
+	var errMsg *string
+	if sendErr != nil {
+		msg := sendErr.Error()
+		errMsg = &msg
+	}
+	_, err := db.Pool.Exec(ctx, `
+		INSERT INTO webhook_deliveries ("webhookId", event, success, error)
+		VALUES ($1, $2, $3, $4)
+	`, webhookID, event, sendErr == nil, errMsg)
+	return err
+}
+
+// ListAdminWebhooks returns a page of webhooks, newest first, optionally
+// filtered by scope and type, with delivery stats since the given time. It
+// also returns the total number of matching webhooks.
+func (db *DB) ListAdminWebhooks(ctx context.Context, scope, webhookType string, since time.Time, limit, offset int) ([]AdminWebhook, int, error) {
+	const filter = `WHERE ($1 = '' OR w.scope = $1) AND ($2 = '' OR w.type = $2)`
+
+	var total int
+	if err := db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM discord_webhooks w `+filter, scope, webhookType).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := db.Pool.Query(ctx, `
+		SELECT w.id, w.name, w."webhookUrl", w.type, w.scope, COALESCE(w.description, ''),
+			COALESCE(w."messageTemplate", ''), w.enabled, w."testSuccessAt", w."createdAt",
+			COALESCE(d.succeeded, 0), COALESCE(d.failed, 0), d."lastDeliveryAt",
+			(SELECT error FROM webhook_deliveries
+			 WHERE "webhookId" = w.id AND NOT success AND "createdAt" >= $3
+			 ORDER BY "createdAt" DESC LIMIT 1)
+		FROM discord_webhooks w
+		LEFT JOIN (
+			SELECT "webhookId",
+				COUNT(*) FILTER (WHERE success) AS succeeded,
+				COUNT(*) FILTER (WHERE NOT success) AS failed,
+				MAX("createdAt") AS "lastDeliveryAt"
+			FROM webhook_deliveries
+			WHERE "createdAt" >= $3
+			GROUP BY "webhookId"
+		) d ON d."webhookId" = w.id
+		`+filter+`
+		ORDER BY w."createdAt" DESC
+		LIMIT $4 OFFSET $5
+	`, scope, webhookType, since, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	webhooks := []AdminWebhook{}
+	for rows.Next() {
+		var w AdminWebhook
+		if err := rows.Scan(&w.ID, &w.Name, &w.WebhookURL, &w.Type, &w.Scope, &w.Description,
+			&w.MessageTemplate, &w.Enabled, &w.TestSuccessAt, &w.CreatedAt,
+			&w.Deliveries.Succeeded, &w.Deliveries.Failed, &w.Deliveries.LastDeliveryAt, &w.Deliveries.LastError); err != nil {
+			return nil, 0, err
+		}
+		webhooks = append(webhooks, w)
+	}
+	return webhooks, total, rows.Err()
+}
+
+// PruneWebhookDeliveries deletes delivery records older than before and
+// returns how many were removed
+func (db *DB) PruneWebhookDeliveries(ctx context.Context, before time.Time) (int64, error) {
+	tag, err := db.Pool.Exec(ctx, `DELETE FROM webhook_deliveries WHERE "createdAt" < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
@@ -2,8 +2,23 @@ package database
 
 import (
 	"context"
+	"fmt"
+	"text/template"
 )
 
+// MaxWebhookTemplateLength caps the size of a webhook message template
+const MaxWebhookTemplateLength = 2000
+
+// ParseWebhookTemplate parses a webhook message template. Templates use Go
+// text/template syntax and receive the event data fields plus "event", for
+// example "{{.name}} was suspended{{with .reason}}: {{.}}{{end}}".
+func ParseWebhookTemplate(text string) (*template.Template, error) {
+	if len(text) > MaxWebhookTemplateLength {
+		return nil, fmt.Errorf("template must be at most %d characters", MaxWebhookTemplateLength)
+	}
+	return template.New("webhook").Parse(text)
+}
+
 // DiscordWebhookInput represents input for creating/updating webhooks
 type DiscordWebhookInput struct {
 	Name        string `json:"name"`
@@ -15,6 +30,38 @@ type DiscordWebhookInput struct {
 	Enabled     bool   `json:"enabled"`
 }
 
+// SyncWebhook is a webhook that receives sync result notifications
+type SyncWebhook struct {
+	ID  string
+	URL string
+}
+
+// GetSyncNotificationWebhooks returns the enabled admin SYSTEM webhooks that
+// are notified when a sync finishes or system settings change
+func (db *DB) GetSyncNotificationWebhooks(ctx context.Context) ([]SyncWebhook, error) {
+	rows, err := db.Pool.Query(ctx, `
+		SELECT id, "webhookUrl"
+		FROM discord_webhooks
+		WHERE enabled = true
+		AND type = 'SYSTEM'
+		AND scope = 'ADMIN'
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var webhooks []SyncWebhook
+	for rows.Next() {
+		var w SyncWebhook
+		if err := rows.Scan(&w.ID, &w.URL); err != nil {
+			return nil, err
+		}
+		webhooks = append(webhooks, w)
+	}
+	return webhooks, rows.Err()
+}
+
 // GetDiscordWebhooks retrieves all Discord webhooks
 func (db *DB) GetDiscordWebhooks(ctx context.Context) ([]interface{}, error) {
 	rows, err := db.Pool.Query(ctx, "SELECT id, \"webhookUrl\", name FROM \"discord_webhooks\" WHERE \"deletedAt\" IS NULL ORDER BY \"createdAt\" DESC")
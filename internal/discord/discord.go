@@ -0,0 +1,177 @@
+// Package discord builds and sends Discord webhook messages.
+package discord
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// Embed colors used for sync results
+const (
+	ColorSuccess = 3066993  // Green
+	ColorFailure = 15158332 // Red
+	ColorWarning = 16776960 // Yellow
+	ColorAlert   = 10038562 // Dark red, for repeated failures
+)
+
+// Message represents a Discord webhook message
+type Message struct {
+	Username  string  `json:"username,omitempty"`
+	AvatarURL string  `json:"avatar_url,omitempty"`
+	Content   string  `json:"content,omitempty"`
+	Embeds    []Embed `json:"embeds,omitempty"`
+}
+
+// Embed represents a Discord embed
+type Embed struct {
+	Title       string       `json:"title,omitempty"`
+	Description string       `json:"description,omitempty"`
+	URL         string       `json:"url,omitempty"`
+	Color       int          `json:"color,omitempty"`
+	Timestamp   string       `json:"timestamp,omitempty"`
+	Footer      *EmbedFooter `json:"footer,omitempty"`
+	Author      *EmbedAuthor `json:"author,omitempty"`
+	Fields      []EmbedField `json:"fields,omitempty"`
+}
+
+// EmbedFooter represents a Discord embed footer
+type EmbedFooter struct {
+	Text    string `json:"text,omitempty"`
+	IconURL string `json:"icon_url,omitempty"`
+}
+
+// EmbedAuthor represents a Discord embed author
+type EmbedAuthor struct {
+	Name    string `json:"name,omitempty"`
+	URL     string `json:"url,omitempty"`
+	IconURL string `json:"icon_url,omitempty"`
+}
+
+// EmbedField represents a Discord embed field
+type EmbedField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline,omitempty"`
+}
+
+// SyncResultMessage builds the notification sent when a sync finishes with
+// status COMPLETED, FAILED or CANCELLED. syncErr is shown when not empty.
+func SyncResultMessage(status string, duration time.Duration, syncErr string) Message {
+	color := ColorSuccess
+	statusEmoji := "✅"
+	statusText := "Completed Successfully"
+
+	switch status {
+	case "FAILED":
+		color = ColorFailure
+		statusEmoji = "❌"
+		statusText = "Failed"
+	case "CANCELLED":
+		color = ColorWarning
+		statusEmoji = "⚠️"
+		statusText = "Cancelled"
+	}
+
+	fields := []EmbedField{
+		{Name: "Status", Value: fmt.Sprintf("%s %s", statusEmoji, statusText), Inline: true},
+		{Name: "Duration", Value: fmt.Sprintf("%.2f seconds", duration.Seconds()), Inline: true},
+	}
+	if syncErr != "" {
+		fields = append(fields, EmbedField{Name: "Error", Value: syncErr})
+	}
+
+	return Message{
+		Embeds: []Embed{{
+			Title:       "🔄 Sync Operation " + statusText,
+			Description: "Panel synchronization has " + strings.ToLower(statusText),
+			Color:       color,
+			Fields:      fields,
+			Timestamp:   time.Now().Format(time.RFC3339),
+			Footer:      &EmbedFooter{Text: "NodeByte Sync System"},
+		}},
+	}
+}
+
+// SyncFailureAlertMessage builds the escalated notification sent in place of
+// a normal failure once streak syncs in a row have failed. mention, when not
+// empty, is posted alongside the embed so it pings.
+func SyncFailureAlertMessage(duration time.Duration, syncErr string, streak int, mention string) Message {
+	msg := SyncResultMessage("FAILED", duration, syncErr)
+	embed := &msg.Embeds[0]
+	embed.Title = "🚨 Sync Failing Repeatedly"
+	embed.Description = fmt.Sprintf("Panel synchronization has failed %d times in a row", streak)
+	embed.Color = ColorAlert
+	embed.Fields = append(embed.Fields, EmbedField{Name: "Consecutive Failures", Value: fmt.Sprint(streak), Inline: true})
+	msg.Content = mention
+	return msg
+}
+
+// BroadcastResult reports how a message sent to several webhooks fared
+type BroadcastResult struct {
+	Sent   int
+	Failed map[string]error // send error by webhook URL
+}
+
+// Broadcast sends a message to every webhook URL, at most concurrency at a
+// time (1 if concurrency is not positive), and waits for all sends to finish.
+// One failing webhook does not stop the others.
+func Broadcast(ctx context.Context, client *http.Client, webhookURLs []string, message Message, concurrency int) BroadcastResult {
+	var g errgroup.Group
+	g.SetLimit(max(concurrency, 1))
+
+	var mu sync.Mutex
+	result := BroadcastResult{Failed: map[string]error{}}
+	for _, url := range webhookURLs {
+		g.Go(func() error {
+			err := Send(ctx, client, url, message)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				result.Failed[url] = err
+			} else {
+				result.Sent++
+			}
+			return nil
+		})
+	}
+	g.Wait()
+	return result
+}
+
+// Send posts a message to a Discord webhook URL. Rate limiting and error
+// statuses are returned as errors so queued sends are retried.
+func Send(ctx context.Context, client *http.Client, webhookURL string, message Message) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send webhook: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return fmt.Errorf("rate limited by Discord")
+	}
+	if resp.StatusCode >= 400 {
+		return fmt.Errorf("Discord returned status %d", resp.StatusCode)
+	}
+	return nil
+}
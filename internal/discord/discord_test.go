@@ -0,0 +1,130 @@
+package discord
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSyncResultMessage(t *testing.T) {
+	tests := []struct {
+		status, syncErr string
+		color, fields   int
+		title           string
+	}{
+		{"COMPLETED", "", ColorSuccess, 2, "🔄 Sync Operation Completed Successfully"},
+		{"FAILED", "panel unreachable", ColorFailure, 3, "🔄 Sync Operation Failed"},
+		{"CANCELLED", "", ColorWarning, 2, "🔄 Sync Operation Cancelled"},
+	}
+	for _, tt := range tests {
+		msg := SyncResultMessage(tt.status, 1500*time.Millisecond, tt.syncErr)
+		if len(msg.Embeds) != 1 {
+			t.Fatalf("%s: got %d embeds, want 1", tt.status, len(msg.Embeds))
+		}
+		embed := msg.Embeds[0]
+		if embed.Color != tt.color || embed.Title != tt.title || len(embed.Fields) != tt.fields {
+			t.Errorf("%s: got color %d, title %q, %d fields", tt.status, embed.Color, embed.Title, len(embed.Fields))
+		}
+		if embed.Fields[1].Value != "1.50 seconds" {
+			t.Errorf("%s: duration field = %q, want %q", tt.status, embed.Fields[1].Value, "1.50 seconds")
+		}
+	}
+}
+
+func TestSend(t *testing.T) {
+	var got Message
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("Content-Type = %q, want application/json", r.Header.Get("Content-Type"))
+		}
+		json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	msg := Message{Content: "hello"}
+	if err := Send(context.Background(), srv.Client(), srv.URL, msg); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if got.Content != "hello" {
+		t.Errorf("webhook received content %q, want %q", got.Content, "hello")
+	}
+}
+
+func TestSendErrorStatus(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTooManyRequests)
+	}))
+	defer srv.Close()
+
+	if err := Send(context.Background(), srv.Client(), srv.URL, Message{}); err == nil {
+		t.Error("Send() succeeded on 429, want error")
+	}
+}
+
+func TestBroadcast(t *testing.T) {
+	var inFlight, peak, received int32
+	ok := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&inFlight, 1)
+		for {
+			p := atomic.LoadInt32(&peak)
+			if n <= p || atomic.CompareAndSwapInt32(&peak, p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		atomic.AddInt32(&inFlight, -1)
+		atomic.AddInt32(&received, 1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ok.Close()
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer failing.Close()
+
+	urls := []string{failing.URL}
+	for i := 0; i < 8; i++ {
+		urls = append(urls, ok.URL)
+	}
+
+	result := Broadcast(context.Background(), ok.Client(), urls, Message{Content: "hello"}, 2)
+	if result.Sent != 8 {
+		t.Errorf("Broadcast() sent = %d, want 8", result.Sent)
+	}
+	if _, failed := result.Failed[failing.URL]; !failed || len(result.Failed) != 1 {
+		t.Errorf("Broadcast() failed = %v, want only %s", result.Failed, failing.URL)
+	}
+	if received != 8 {
+		t.Errorf("webhooks received %d messages, want 8", received)
+	}
+	if peak > 2 {
+		t.Errorf("peak concurrent sends = %d, want at most 2", peak)
+	}
+}
+
+func TestSyncFailureAlertMessage(t *testing.T) {
+	msg := SyncFailureAlertMessage(2*time.Second, "panel unreachable", 4, "<@&42>")
+	if msg.Content != "<@&42>" {
+		t.Errorf("content = %q, want the mention", msg.Content)
+	}
+	if len(msg.Embeds) != 1 {
+		t.Fatalf("got %d embeds, want 1", len(msg.Embeds))
+	}
+	embed := msg.Embeds[0]
+	if embed.Color != ColorAlert {
+		t.Errorf("color = %d, want %d", embed.Color, ColorAlert)
+	}
+	last := embed.Fields[len(embed.Fields)-1]
+	if last.Name != "Consecutive Failures" || last.Value != "4" {
+		t.Errorf("last field = %+v, want 4 consecutive failures", last)
+	}
+
+	if msg := SyncFailureAlertMessage(time.Second, "", 3, ""); msg.Content != "" {
+		t.Errorf("content = %q, want no mention", msg.Content)
+	}
+}
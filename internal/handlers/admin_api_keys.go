@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+
+	"github.com/nodebyte/backend/internal/database"
+)
+
+// maxAPIKeyLabelLength caps the length of an API key label
+const maxAPIKeyLabelLength = 100
+
+// AdminAPIKeyHandler handles backend API key management
+type AdminAPIKeyHandler struct {
+	db *database.DB
+}
+
+// NewAdminAPIKeyHandler creates a new admin API key handler
+func NewAdminAPIKeyHandler(db *database.DB) *AdminAPIKeyHandler {
+	return &AdminAPIKeyHandler{db: db}
+}
+
+// CreateAPIKeyRequest represents an API key creation request
+type CreateAPIKeyRequest struct {
+	Label string `json:"label"` // who or what uses the key, e.g. "website"
+}
+
+// GetAPIKeys returns stored backend API keys
+// @Summary List API keys (admin)
+// @Description Returns backend API keys created in the admin panel, newest first, without their secrets. Revoked keys are included only with all=true. Keys from BACKEND_API_KEY and API_KEYS are not listed.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param all query bool false "Include revoked keys"
+// @Success 200 {object} SuccessResponse "API keys retrieved"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/api-keys [get]
+func (h *AdminAPIKeyHandler) GetAPIKeys(c *fiber.Ctx) error {
+	keys, err := h.db.ListAPIKeys(c.Context(), c.QueryBool("all", false))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch API keys")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch API keys",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"apiKeys": keys,
+	})
+}
+
+// CreateAPIKey creates a labeled backend API key
+// @Summary Create API key (admin)
+// @Description Creates a backend API key for X-API-Key authentication. The key is returned only in this response; store it securely.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body CreateAPIKeyRequest true "Key label"
+// @Success 201 {object} SuccessResponse "API key created"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/api-keys [post]
+func (h *AdminAPIKeyHandler) CreateAPIKey(c *fiber.Ctx) error {
+	var req CreateAPIKeyRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	req.Label = strings.TrimSpace(req.Label)
+	if req.Label == "" || len(req.Label) > maxAPIKeyLabelLength {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "label is required and must be at most 100 characters",
+		})
+	}
+
+	adminID, _ := c.Locals("userID").(string)
+	apiKey, secret, err := h.db.CreateAPIKey(c.Context(), req.Label, adminID)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create API key")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create API key",
+		})
+	}
+
+	h.audit(c, database.AuditAPIKeyCreated, apiKey.ID, map[string]interface{}{
+		"label": apiKey.Label,
+	})
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"apiKey":  apiKey,
+		"key":     secret,
+	})
+}
+
+// RevokeAPIKey revokes a backend API key
+// @Summary Revoke API key (admin)
+// @Description Revokes an API key; requests using it are rejected immediately
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "API key ID"
+// @Success 200 {object} SuccessResponse "API key revoked"
+// @Failure 404 {object} ErrorResponse "API key not found or already revoked"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/api-keys/{id} [delete]
+func (h *AdminAPIKeyHandler) RevokeAPIKey(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	revoked, err := h.db.RevokeAPIKey(c.Context(), id)
+	if err != nil {
+		log.Error().Err(err).Str("api_key_id", id).Msg("Failed to revoke API key")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to revoke API key",
+		})
+	}
+	if !revoked {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "API key not found or already revoked",
+		})
+	}
+
+	h.audit(c, database.AuditAPIKeyRevoked, id, nil)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "API key revoked",
+	})
+}
+
+// audit records an API key change; failures are logged but do not fail the request
+func (h *AdminAPIKeyHandler) audit(c *fiber.Ctx, action, keyID string, details map[string]interface{}) {
+	actorID, _ := c.Locals("userID").(string)
+	if err := h.db.CreateAdminAuditLog(c.Context(), database.AdminAuditEntry{
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: "api_key",
+		TargetID:   keyID,
+		Details:    details,
+		IPAddress:  c.IP(),
+		UserAgent:  c.Get("User-Agent"),
+	}); err != nil {
+		log.Error().Err(err).Str("api_key_id", keyID).Msg("Failed to write API key audit log")
+	}
+}
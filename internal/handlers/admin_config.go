@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+
+	"github.com/nodebyte/backend/internal/config"
+	"github.com/nodebyte/backend/internal/database"
+)
+
+// AdminConfigHandler exposes the configuration the server is running with
+type AdminConfigHandler struct {
+	cfg *config.Config
+	db  *database.DB
+}
+
+// NewAdminConfigHandler creates a new admin config handler
+func NewAdminConfigHandler(cfg *config.Config, db *database.DB) *AdminConfigHandler {
+	return &AdminConfigHandler{cfg: cfg, db: db}
+}
+
+// GetEffectiveConfig returns the loaded configuration and where each value came from
+// @Summary Get effective configuration (system admin)
+// @Description Returns every configuration value the server loaded at startup, after database settings were merged over environment variables, with its source (env, db, or default) and environment variable name. Secrets are masked and URL passwords stripped. Settings saved after startup are not reflected until restart.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} SuccessResponse "Effective configuration retrieved"
+// @Failure 403 {object} ErrorResponse "System admin access required"
+// @Router /api/admin/config/effective [get]
+func (h *AdminConfigHandler) GetEffectiveConfig(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"success": true,
+		"config":  h.cfg.Effective(),
+	})
+}
+
+// LogLevelRequest changes the log level at runtime
+type LogLevelRequest struct {
+	Level string `json:"level"` // debug, info, warn or error
+}
+
+// GetLogLevel returns the log level currently in effect
+// @Summary Get log level (system admin)
+// @Description Returns the log level in effect and the configured level it resets to on restart.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} SuccessResponse "Log level retrieved"
+// @Failure 403 {object} ErrorResponse "System admin access required"
+// @Router /api/admin/log-level [get]
+func (h *AdminConfigHandler) GetLogLevel(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"success":    true,
+		"level":      zerolog.GlobalLevel().String(),
+		"configured": h.cfg.LogLevel,
+		"levels":     config.LogLevels,
+	})
+}
+
+// SetLogLevel changes the log level without a restart
+// @Summary Set log level (system admin)
+// @Description Changes the global log level immediately, e.g. to debug while diagnosing a live issue. The change is not saved; the server returns to the configured LOG_LEVEL or log_level setting on restart.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body LogLevelRequest true "New log level"
+// @Success 200 {object} SuccessResponse "Log level changed"
+// @Failure 400 {object} ErrorResponse "Invalid log level"
+// @Failure 403 {object} ErrorResponse "System admin access required"
+// @Router /api/admin/log-level [post]
+func (h *AdminConfigHandler) SetLogLevel(c *fiber.Ctx) error {
+	var req LogLevelRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	level, err := config.ParseLogLevel(req.Level)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":  err.Error(),
+			"levels": config.LogLevels,
+		})
+	}
+
+	previous := zerolog.GlobalLevel()
+	zerolog.SetGlobalLevel(level)
+
+	actorID, _ := c.Locals("userID").(string)
+	// Logged at warn so the change shows up even when raising the level
+	log.Warn().Str("admin_id", actorID).Str("from", previous.String()).Str("to", level.String()).Msg("Log level changed")
+
+	if err := h.db.CreateAdminAuditLog(c.Context(), database.AdminAuditEntry{
+		ActorID:    actorID,
+		Action:     database.AuditLogLevelChanged,
+		TargetType: "config",
+		TargetID:   "log_level",
+		Details:    map[string]interface{}{"from": previous.String(), "to": level.String()},
+		IPAddress:  c.IP(),
+		UserAgent:  c.Get("User-Agent"),
+	}); err != nil {
+		log.Error().Err(err).Msg("Failed to write log level audit log")
+	}
+
+	return c.JSON(fiber.Map{
+		"success":    true,
+		"level":      level.String(),
+		"previous":   previous.String(),
+		"configured": h.cfg.LogLevel,
+	})
+}
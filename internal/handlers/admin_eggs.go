@@ -3,22 +3,27 @@ package handlers
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
 	"github.com/rs/zerolog/log"
 
+	"github.com/nodebyte/backend/internal/config"
 	"github.com/nodebyte/backend/internal/database"
 )
 
 // AdminEggHandler handles admin egg/nest operations
 type AdminEggHandler struct {
-	db *database.DB
+	db         *database.DB
+	pageLimits PageLimits
 }
 
 // NewAdminEggHandler creates a new admin egg handler
-func NewAdminEggHandler(db *database.DB) *AdminEggHandler {
-	return &AdminEggHandler{db: db}
+func NewAdminEggHandler(db *database.DB, cfg *config.Config) *AdminEggHandler {
+	return &AdminEggHandler{db: db, pageLimits: configuredPageLimits(cfg)}
 }
 
 // AdminNestResponse represents a nest for admin view
@@ -41,6 +46,7 @@ type AdminEggResponse struct {
 	Name        string `json:"name"`
 	Description string `json:"description"`
 	Author      string `json:"author"`
+	DockerImage string `json:"dockerImage"`
 	NestID      int    `json:"nestId"`
 	NestName    string `json:"nestName"`
 	ServerCount int    `json:"serverCount"`
@@ -48,17 +54,41 @@ type AdminEggResponse struct {
 	UpdatedAt   string `json:"updatedAt"`
 }
 
+// AdminEggDetailResponse represents a single egg including its startup command
+type AdminEggDetailResponse struct {
+	AdminEggResponse
+	Startup string `json:"startup"`
+}
+
+// usageSort returns the ORDER BY expression for an egg or nest listing.
+// sort=usage orders by server count, most used first unless order=asc, and
+// falls back to byName for ties; anything else orders by byName alone.
+func usageSort(c *fiber.Ctx, byName string) string {
+	if c.Query("sort") != "usage" {
+		return byName
+	}
+	direction := "DESC"
+	if strings.ToLower(c.Query("order")) == "asc" {
+		direction = "ASC"
+	}
+	return "server_count " + direction + ", " + byName
+}
+
 // GetNests returns all nests with egg counts and server counts
+// @Summary List nests (admin)
+// @Description Returns nests with their egg count and the number of synced servers using any of their eggs. Pass sort=usage to order by server count (most used first, order=asc for least used).
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param search query string false "Filter by name"
+// @Param sort query string false "name (default) or usage"
+// @Param order query string false "desc (default) or asc, for sort=usage"
+// @Success 200 {object} SuccessResponse "Nests retrieved"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/nests [get]
 func (h *AdminEggHandler) GetNests(c *fiber.Ctx) error {
 	search := c.Query("search", "")
-	page := c.QueryInt("page", 1)
-	pageSize := c.QueryInt("pageSize", 25)
-	if page < 1 {
-		page = 1
-	}
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 25
-	}
+	pagination := parsePagination(c, h.pageLimits)
 
 	args := []interface{}{}
 	where := `WHERE 1=1`
@@ -75,8 +105,7 @@ func (h *AdminEggHandler) GetNests(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to count nests"})
 	}
 
-	offset := (page - 1) * pageSize
-	args = append(args, pageSize, offset)
+	args = append(args, pagination.Limit, pagination.Offset)
 	lp := fmt.Sprintf("$%d", len(args)-1)
 	op := fmt.Sprintf("$%d", len(args))
 
@@ -88,7 +117,7 @@ func (h *AdminEggHandler) GetNests(c *fiber.Ctx) error {
 			n."createdAt", n."updatedAt"
 		FROM nests n
 		` + where + `
-		ORDER BY n.name ASC
+		ORDER BY ` + usageSort(c, "n.name ASC") + `
 		LIMIT ` + lp + ` OFFSET ` + op
 
 	rows, err := h.db.Pool.Query(context.Background(), query, args...)
@@ -114,29 +143,32 @@ func (h *AdminEggHandler) GetNests(c *fiber.Ctx) error {
 		nests = append(nests, n)
 	}
 
-	totalPages := (total + pageSize - 1) / pageSize
+	meta := pagination.Meta(total)
 	return c.JSON(fiber.Map{
-		"success": true,
-		"nests":   nests,
-		"pagination": fiber.Map{
-			"page": page, "pageSize": pageSize,
-			"total": total, "totalPages": totalPages,
-		},
+		"success":    true,
+		"nests":      nests,
+		"pagination": meta.pageBlock(),
+		"meta":       meta,
 	})
 }
 
 // GetEggs returns paginated list of eggs with nest name and server count
+// @Summary List eggs (admin)
+// @Description Returns eggs with their nest and the number of synced servers using each. Pass sort=usage to order by server count (most used first, order=asc for least used) to find eggs worth promoting or deprecating.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param search query string false "Filter by name or description"
+// @Param nestId query int false "Only eggs in this nest"
+// @Param sort query string false "name (default) or usage"
+// @Param order query string false "desc (default) or asc, for sort=usage"
+// @Success 200 {object} SuccessResponse "Eggs retrieved"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/eggs [get]
 func (h *AdminEggHandler) GetEggs(c *fiber.Ctx) error {
 	search := c.Query("search", "")
 	nestID := c.Query("nestId", "")
-	page := c.QueryInt("page", 1)
-	pageSize := c.QueryInt("pageSize", 25)
-	if page < 1 {
-		page = 1
-	}
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 25
-	}
+	pagination := parsePagination(c, h.pageLimits)
 
 	args := []interface{}{}
 	where := `WHERE 1=1`
@@ -157,21 +189,21 @@ func (h *AdminEggHandler) GetEggs(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to count eggs"})
 	}
 
-	offset := (page - 1) * pageSize
-	args = append(args, pageSize, offset)
+	args = append(args, pagination.Limit, pagination.Offset)
 	lp := fmt.Sprintf("$%d", len(args)-1)
 	op := fmt.Sprintf("$%d", len(args))
 
 	query := `
 		SELECT
 			e.id, e.uuid, e.name, COALESCE(e.description,''), COALESCE(e.author,''),
+			COALESCE(e."dockerImage",''),
 			e."nestId", COALESCE(n.name,''),
 			(SELECT COUNT(*) FROM servers s WHERE s."eggId" = e.id) AS server_count,
 			e."createdAt", e."updatedAt"
 		FROM eggs e
 		LEFT JOIN nests n ON n.id = e."nestId"
 		` + where + `
-		ORDER BY n.name ASC, e.name ASC
+		ORDER BY ` + usageSort(c, "n.name ASC, e.name ASC") + `
 		LIMIT ` + lp + ` OFFSET ` + op
 
 	rows, err := h.db.Pool.Query(context.Background(), query, args...)
@@ -186,6 +218,7 @@ func (h *AdminEggHandler) GetEggs(c *fiber.Ctx) error {
 		var createdAt, updatedAt time.Time
 		if err := rows.Scan(
 			&eg.ID, &eg.UUID, &eg.Name, &eg.Description, &eg.Author,
+			&eg.DockerImage,
 			&eg.NestID, &eg.NestName,
 			&eg.ServerCount,
 			&createdAt, &updatedAt,
@@ -198,13 +231,189 @@ func (h *AdminEggHandler) GetEggs(c *fiber.Ctx) error {
 		eggs = append(eggs, eg)
 	}
 
-	totalPages := (total + pageSize - 1) / pageSize
+	meta := pagination.Meta(total)
+	return c.JSON(fiber.Map{
+		"success":    true,
+		"eggs":       eggs,
+		"pagination": meta.pageBlock(),
+		"meta":       meta,
+	})
+}
+
+// GetEgg returns a single egg with its docker image and startup command
+func (h *AdminEggHandler) GetEgg(c *fiber.Ctx) error {
+	id, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid egg ID"})
+	}
+
+	var eg AdminEggDetailResponse
+	var createdAt, updatedAt time.Time
+	err = h.db.Pool.QueryRow(context.Background(), `
+		SELECT
+			e.id, e.uuid, e.name, COALESCE(e.description,''), COALESCE(e.author,''),
+			COALESCE(e."dockerImage",''), COALESCE(e.startup,''),
+			e."nestId", COALESCE(n.name,''),
+			(SELECT COUNT(*) FROM servers s WHERE s."eggId" = e.id) AS server_count,
+			e."createdAt", e."updatedAt"
+		FROM eggs e
+		LEFT JOIN nests n ON n.id = e."nestId"
+		WHERE e.id = $1
+	`, id).Scan(
+		&eg.ID, &eg.UUID, &eg.Name, &eg.Description, &eg.Author,
+		&eg.DockerImage, &eg.Startup,
+		&eg.NestID, &eg.NestName,
+		&eg.ServerCount,
+		&createdAt, &updatedAt,
+	)
+	if err == pgx.ErrNoRows {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Egg not found"})
+	}
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch egg: " + err.Error()})
+	}
+	eg.CreatedAt = createdAt.Format(time.RFC3339)
+	eg.UpdatedAt = updatedAt.Format(time.RFC3339)
+
 	return c.JSON(fiber.Map{
 		"success": true,
-		"eggs":    eggs,
-		"pagination": fiber.Map{
-			"page": page, "pageSize": pageSize,
-			"total": total, "totalPages": totalPages,
+		"egg":     eg,
+	})
+}
+
+// UpdateEggVariableRequest sets local overrides on a synced egg variable.
+// Omitted fields keep their current override; reset clears every override
+// before the given fields are applied.
+type UpdateEggVariableRequest struct {
+	UserViewable *bool `json:"userViewable"`
+	UserEditable *bool `json:"userEditable"`
+	DisplayOrder *int  `json:"displayOrder"`
+	Reset        bool  `json:"reset"`
+}
+
+// GetEggVariables returns an egg's variables with their local overrides
+// @Summary List egg variables (admin)
+// @Description Returns the egg's synced variables in display order. userViewable and userEditable are the effective values; the panel values and any local overrides are reported separately.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Egg ID"
+// @Success 200 {object} SuccessResponse "Variables retrieved"
+// @Failure 400 {object} ErrorResponse "Invalid egg ID"
+// @Failure 404 {object} ErrorResponse "Egg not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/eggs/{id}/variables [get]
+func (h *AdminEggHandler) GetEggVariables(c *fiber.Ctx) error {
+	eggID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid egg ID"})
+	}
+
+	exists, err := h.db.EggExists(c.Context(), eggID)
+	if err != nil {
+		log.Error().Err(err).Int("egg_id", eggID).Msg("Failed to look up egg")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch egg variables"})
+	}
+	if !exists {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Egg not found"})
+	}
+
+	variables, err := h.db.ListEggVariables(c.Context(), eggID)
+	if err != nil {
+		log.Error().Err(err).Int("egg_id", eggID).Msg("Failed to fetch egg variables")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch egg variables"})
+	}
+
+	return c.JSON(fiber.Map{
+		"success":   true,
+		"variables": variables,
+	})
+}
+
+// UpdateEggVariable sets local visibility and ordering overrides on an egg variable
+// @Summary Update egg variable overrides (admin)
+// @Description Overrides whether users can view or edit a variable and where it is listed. Overrides are kept locally and are not replaced by the next sync.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Egg ID"
+// @Param varId path int true "Variable ID"
+// @Param body body UpdateEggVariableRequest true "Overrides"
+// @Success 200 {object} SuccessResponse "Variable updated"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 404 {object} ErrorResponse "Variable not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/eggs/{id}/variables/{varId} [patch]
+func (h *AdminEggHandler) UpdateEggVariable(c *fiber.Ctx) error {
+	eggID, err := c.ParamsInt("id")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid egg ID"})
+	}
+	variableID, err := c.ParamsInt("varId")
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid variable ID"})
+	}
+
+	var req UpdateEggVariableRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if req.DisplayOrder != nil && *req.DisplayOrder < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "displayOrder must not be negative"})
+	}
+
+	current, err := h.db.GetEggVariable(c.Context(), eggID, variableID)
+	if err != nil {
+		log.Error().Err(err).Int("variable_id", variableID).Msg("Failed to fetch egg variable")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update egg variable"})
+	}
+	if current == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Variable not found"})
+	}
+
+	overrides := current.Overrides
+	if req.Reset {
+		overrides = database.EggVariableOverrides{}
+	}
+	if req.UserViewable != nil {
+		overrides.UserViewable = req.UserViewable
+	}
+	if req.UserEditable != nil {
+		overrides.UserEditable = req.UserEditable
+	}
+	if req.DisplayOrder != nil {
+		overrides.DisplayOrder = req.DisplayOrder
+	}
+
+	updated, err := h.db.SetEggVariableOverrides(c.Context(), eggID, variableID, overrides)
+	if err != nil {
+		log.Error().Err(err).Int("variable_id", variableID).Msg("Failed to update egg variable")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update egg variable"})
+	}
+	if updated == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Variable not found"})
+	}
+
+	actorID, _ := c.Locals("userID").(string)
+	if err := h.db.CreateAdminAuditLog(c.Context(), database.AdminAuditEntry{
+		ActorID:    actorID,
+		Action:     database.AuditEggVariableUpdated,
+		TargetType: "egg_variable",
+		TargetID:   strconv.Itoa(variableID),
+		Details: map[string]interface{}{
+			"eggId":       eggID,
+			"envVariable": updated.EnvVariable,
+			"overrides":   updated.Overrides,
 		},
+		IPAddress: c.IP(),
+		UserAgent: c.Get("User-Agent"),
+	}); err != nil {
+		log.Error().Err(err).Int("variable_id", variableID).Msg("Failed to write egg variable audit log")
+	}
+
+	return c.JSON(fiber.Map{
+		"success":  true,
+		"variable": updated,
 	})
 }
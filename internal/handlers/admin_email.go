@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/nodebyte/backend/internal/workers"
+)
+
+// AdminEmailHandler handles email tooling for admins
+type AdminEmailHandler struct{}
+
+// NewAdminEmailHandler creates a new admin email handler
+func NewAdminEmailHandler() *AdminEmailHandler {
+	return &AdminEmailHandler{}
+}
+
+// EmailPreviewRequest is the body of an email preview request
+type EmailPreviewRequest struct {
+	Template string            `json:"template"`
+	Subject  string            `json:"subject"` // optional, defaults to the template's subject
+	Data     map[string]string `json:"data"`
+}
+
+// PreviewEmail renders an email template without sending it
+// @Summary Preview an email template (admin)
+// @Description Renders a template with the given data exactly as the email worker would and returns the subject and HTML. Nothing is sent. The template must exist and every data key it renders must be present.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body EmailPreviewRequest true "Template and sample data"
+// @Success 200 {object} SuccessResponse "Rendered email"
+// @Failure 400 {object} ErrorResponse "Unknown template or missing data keys"
+// @Router /api/admin/email/preview [post]
+func (h *AdminEmailHandler) PreviewEmail(c *fiber.Ctx) error {
+	var req EmailPreviewRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	tmpl, ok := workers.LookupEmailTemplate(req.Template)
+	if !ok {
+		names := make([]string, 0, len(workers.EmailTemplates))
+		for _, t := range workers.EmailTemplates {
+			names = append(names, t.Name)
+		}
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":     "Unknown email template",
+			"templates": names,
+		})
+	}
+	if missing := tmpl.MissingData(req.Data); len(missing) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error":    "Missing template data",
+			"missing":  missing,
+			"required": tmpl.Required,
+		})
+	}
+
+	preview, err := workers.PreviewEmail(req.Template, req.Subject, req.Data)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"preview": preview,
+	})
+}
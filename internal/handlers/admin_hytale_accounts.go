@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+
+	"github.com/nodebyte/backend/internal/database"
+)
+
+// AdminHytaleAccountsHandler handles Hytale account listing for admins
+type AdminHytaleAccountsHandler struct {
+	oauthRepo *database.HytaleOAuthRepository
+}
+
+// NewAdminHytaleAccountsHandler creates a new admin Hytale accounts handler
+func NewAdminHytaleAccountsHandler(db *database.DB) *AdminHytaleAccountsHandler {
+	return &AdminHytaleAccountsHandler{oauthRepo: database.NewHytaleOAuthRepository(db)}
+}
+
+// GetHytaleAccounts returns stored Hytale accounts with token and session state
+// @Summary List Hytale accounts (admin)
+// @Description Returns stored Hytale accounts with access token expiry, selected profile, and game session status (active, expired, none), soonest expiry first. Tokens are never returned.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param expiresWithin query string false "Only accounts whose access token expires within this window (e.g. 30m, 24h, 7d), including expired ones"
+// @Param limit query int false "Page size (default 50, max 500)"
+// @Param offset query int false "Offset"
+// @Success 200 {object} SuccessResponse "Accounts retrieved"
+// @Failure 400 {object} ErrorResponse "Invalid expiry window"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/hytale/accounts [get]
+func (h *AdminHytaleAccountsHandler) GetHytaleAccounts(c *fiber.Ctx) error {
+	expiresWithin, err := parseExpiryWindow(c.Query("expiresWithin"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	pagination := parsePagination(c, PageLimits{Default: 50, Max: 500})
+
+	accounts, total, err := h.oauthRepo.ListAccounts(c.Context(), expiresWithin, pagination.Limit, pagination.Offset)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch Hytale accounts")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch Hytale accounts",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success":  true,
+		"accounts": accounts,
+		"meta":     pagination.Meta(total),
+	})
+}
+
+// parseExpiryWindow parses an expiry window such as "30m", "24h" or "7d"; empty means no filter
+func parseExpiryWindow(value string) (time.Duration, error) {
+	if value == "" {
+		return 0, nil
+	}
+
+	var d time.Duration
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid expiresWithin %q", value)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(value); err != nil {
+			return 0, fmt.Errorf("invalid expiresWithin %q", value)
+		}
+	}
+
+	if d <= 0 {
+		return 0, fmt.Errorf("expiresWithin must be positive")
+	}
+	return d, nil
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/nodebyte/backend/internal/database"
+)
+
+// AdminHytaleAuditHandler handles Hytale audit log retrieval for admins
+type AdminHytaleAuditHandler struct {
+	auditRepo *database.HytaleAuditLogRepository
+}
+
+// NewAdminHytaleAuditHandler creates a new admin Hytale audit handler
+func NewAdminHytaleAuditHandler(db *database.DB) *AdminHytaleAuditHandler {
+	return &AdminHytaleAuditHandler{auditRepo: database.NewHytaleAuditLogRepository(db)}
+}
+
+// GetHytaleAuditLogs returns Hytale OAuth and game session audit events
+// @Summary List Hytale audit logs (admin)
+// @Description Returns Hytale auth activity (device code requests, token grants and refreshes, auth failures, profile selection, game session changes), newest first
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param accountId query string false "Filter by Hytale account UUID"
+// @Param eventType query string false "Filter by event type (e.g. TOKEN_REFRESHED)"
+// @Param limit query int false "Page size (default 100, max 500)"
+// @Param offset query int false "Offset"
+// @Success 200 {object} SuccessResponse "Audit logs retrieved"
+// @Failure 400 {object} ErrorResponse "Invalid account ID"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/hytale/audit [get]
+func (h *AdminHytaleAuditHandler) GetHytaleAuditLogs(c *fiber.Ctx) error {
+	accountID := c.Query("accountId")
+	if accountID != "" {
+		if _, err := uuid.Parse(accountID); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "accountId must be a UUID",
+			})
+		}
+	}
+	eventType := database.AuditLogType(strings.ToUpper(c.Query("eventType")))
+
+	pagination := parsePagination(c, PageLimits{Default: 100, Max: 500})
+
+	logs, total, err := h.auditRepo.ListAuditLogs(c.Context(), accountID, eventType, pagination.Limit, pagination.Offset)
+	if err != nil {
+		log.Error().Err(err).Str("account_id", accountID).Msg("Failed to fetch Hytale audit logs")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch Hytale audit logs",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"logs":    logs,
+		"total":   total,
+		"limit":   pagination.Limit,
+		"offset":  pagination.Offset,
+		"meta":    pagination.Meta(total),
+	})
+}
@@ -0,0 +1,69 @@
+package handlers
+
+import (
+	"errors"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+
+	"github.com/nodebyte/backend/internal/config"
+	"github.com/nodebyte/backend/internal/queue"
+)
+
+// AdminHytalePushHandler queues Hytale session token pushes to linked servers
+type AdminHytalePushHandler struct {
+	queueManager *queue.Manager
+	cfg          *config.Config
+}
+
+// NewAdminHytalePushHandler creates a new admin Hytale token push handler
+func NewAdminHytalePushHandler(queueManager *queue.Manager, cfg *config.Config) *AdminHytalePushHandler {
+	return &AdminHytalePushHandler{
+		queueManager: queueManager,
+		cfg:          cfg,
+	}
+}
+
+// PushAllTokens queues a push of current session tokens to every linked server
+// @Summary Push Hytale tokens to all linked servers (admin)
+// @Description Queues a job that writes the current session and identity tokens of every game session linked to a server into that server's environment, at most 5 servers per second. Servers whose session has expired or that are not managed by the panel are skipped. One server failing does not stop the others; each outcome is logged and the totals are recorded in the admin audit log. Only one push may be queued or running at a time.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Success 202 {object} SuccessResponse "Push queued, with its task ID"
+// @Failure 409 {object} ErrorResponse "A push is already queued or running"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 503 {object} ErrorResponse "Panel client API key not configured"
+// @Router /api/admin/hytale/push-all [post]
+func (h *AdminHytalePushHandler) PushAllTokens(c *fiber.Ctx) error {
+	if h.cfg.PterodactylURL == "" || h.cfg.PterodactylClientAPIKey == "" {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Panel client API key not configured",
+		})
+	}
+
+	actorID, _ := c.Locals("userID").(string)
+	info, err := h.queueManager.EnqueueHytaleTokenPush(queue.HytalePushTokensPayload{
+		ActorID:   actorID,
+		IPAddress: c.IP(),
+		UserAgent: c.Get("User-Agent"),
+	})
+	if errors.Is(err, asynq.ErrDuplicateTask) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "A token push is already running",
+		})
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to enqueue Hytale token push")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to queue token push",
+		})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"success": true,
+		"taskId":  info.ID,
+		"message": "Token push queued",
+	})
+}
@@ -0,0 +1,70 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+
+	"github.com/nodebyte/backend/internal/database"
+)
+
+// AdminHytaleServerLogsHandler handles Hytale server log retrieval for support staff
+type AdminHytaleServerLogsHandler struct {
+	db       *database.DB
+	logsRepo *database.HytaleServerLogsRepository
+}
+
+// NewAdminHytaleServerLogsHandler creates a new admin Hytale server logs handler
+func NewAdminHytaleServerLogsHandler(db *database.DB) *AdminHytaleServerLogsHandler {
+	return &AdminHytaleServerLogsHandler{
+		db:       db,
+		logsRepo: database.NewHytaleServerLogsRepository(db),
+	}
+}
+
+// GetServerLogs returns the logs a Hytale server has shipped
+// @Summary List Hytale server logs (admin)
+// @Description Returns log lines shipped by a Hytale server, newest first. The server may be given by its ID or panel UUID.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param serverId path string true "Server ID or UUID"
+// @Param level query string false "Filter by level (TRACE, DEBUG, INFO, WARN, ERROR, FATAL)"
+// @Param limit query int false "Page size (default 100, max 1000)"
+// @Param offset query int false "Offset"
+// @Success 200 {object} SuccessResponse "Logs retrieved"
+// @Failure 404 {object} ErrorResponse "Server not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/hytale/servers/{serverId}/logs [get]
+func (h *AdminHytaleServerLogsHandler) GetServerLogs(c *fiber.Ctx) error {
+	ctx := c.Context()
+
+	pagination := parsePagination(c, PageLimits{Default: 100, Max: maxLogBatchSize})
+
+	var serverUUID *string
+	err := h.db.Pool.QueryRow(ctx,
+		`SELECT uuid FROM servers WHERE id = $1 OR uuid = $1 LIMIT 1`, c.Params("serverId"),
+	).Scan(&serverUUID)
+	if err != nil || serverUUID == nil || *serverUUID == "" {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Server not found",
+		})
+	}
+
+	logs, total, err := h.logsRepo.ListLogs(ctx, *serverUUID, c.Query("level"), pagination.Limit, pagination.Offset)
+	if err != nil {
+		log.Error().Err(err).Str("server_uuid", *serverUUID).Msg("Failed to fetch Hytale server logs")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch server logs",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success":    true,
+		"serverUuid": *serverUUID,
+		"logs":       logs,
+		"total":      total,
+		"limit":      pagination.Limit,
+		"offset":     pagination.Offset,
+		"meta":       pagination.Meta(int(total)),
+	})
+}
@@ -0,0 +1,177 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+
+	"github.com/nodebyte/backend/internal/database"
+)
+
+// maxInviteUses caps how many registrations a single invite may admit
+const maxInviteUses = 1000
+
+// AdminInviteHandler handles registration invite management
+type AdminInviteHandler struct {
+	db *database.DB
+}
+
+// NewAdminInviteHandler creates a new admin invite handler
+func NewAdminInviteHandler(db *database.DB) *AdminInviteHandler {
+	return &AdminInviteHandler{db: db}
+}
+
+// CreateInviteRequest represents an invite creation request
+type CreateInviteRequest struct {
+	Email          string `json:"email,omitempty"`          // only this address may redeem the invite
+	MaxUses        int    `json:"maxUses,omitempty"`        // defaults to 1
+	ExpiresInHours int    `json:"expiresInHours,omitempty"` // 0 never expires
+	Note           string `json:"note,omitempty"`
+}
+
+// GetInvites returns registration invites
+// @Summary List invites (admin)
+// @Description Returns registration invites, newest first. Revoked, expired and used-up invites are included only with all=true.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param all query bool false "Include inactive invites"
+// @Success 200 {object} SuccessResponse "Invites retrieved"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/invites [get]
+func (h *AdminInviteHandler) GetInvites(c *fiber.Ctx) error {
+	invites, err := h.db.ListInvites(c.Context(), c.QueryBool("all", false))
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch invites")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch invites",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"invites": invites,
+	})
+}
+
+// CreateInvite creates a registration invite with a generated code
+// @Summary Create invite (admin)
+// @Description Creates a registration invite code, optionally bound to an email address, limited in uses and expiring after a number of hours
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body CreateInviteRequest true "Invite options"
+// @Success 201 {object} SuccessResponse "Invite created"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/invites [post]
+func (h *AdminInviteHandler) CreateInvite(c *fiber.Ctx) error {
+	var req CreateInviteRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if req.Email != "" {
+		if err := validateEmail(req.Email); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid email",
+			})
+		}
+	}
+	if req.MaxUses < 0 || req.MaxUses > maxInviteUses {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "maxUses must be between 1 and 1000",
+		})
+	}
+	if req.ExpiresInHours < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "expiresInHours cannot be negative",
+		})
+	}
+
+	var expiresAt *time.Time
+	if req.ExpiresInHours > 0 {
+		t := time.Now().Add(time.Duration(req.ExpiresInHours) * time.Hour)
+		expiresAt = &t
+	}
+
+	adminID, _ := c.Locals("userID").(string)
+	invite, err := h.db.CreateInvite(c.Context(), database.NewInvite{
+		Email:     req.Email,
+		MaxUses:   req.MaxUses,
+		ExpiresAt: expiresAt,
+		Note:      req.Note,
+		CreatedBy: adminID,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create invite")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create invite",
+		})
+	}
+
+	h.audit(c, database.AuditInviteCreated, invite.ID, map[string]interface{}{
+		"email":   invite.Email,
+		"maxUses": invite.MaxUses,
+	})
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"invite":  invite,
+	})
+}
+
+// RevokeInvite revokes a registration invite
+// @Summary Revoke invite (admin)
+// @Description Revokes an invite so its code can no longer be redeemed
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Invite ID"
+// @Success 200 {object} SuccessResponse "Invite revoked"
+// @Failure 404 {object} ErrorResponse "Invite not found or already revoked"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/invites/{id} [delete]
+func (h *AdminInviteHandler) RevokeInvite(c *fiber.Ctx) error {
+	id := c.Params("id")
+
+	revoked, err := h.db.RevokeInvite(c.Context(), id)
+	if err != nil {
+		log.Error().Err(err).Str("invite_id", id).Msg("Failed to revoke invite")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to revoke invite",
+		})
+	}
+	if !revoked {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Invite not found or already revoked",
+		})
+	}
+
+	h.audit(c, database.AuditInviteRevoked, id, nil)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Invite revoked",
+	})
+}
+
+// audit records an invite change; failures are logged but do not fail the request
+func (h *AdminInviteHandler) audit(c *fiber.Ctx, action, inviteID string, details map[string]interface{}) {
+	actorID, _ := c.Locals("userID").(string)
+	if err := h.db.CreateAdminAuditLog(c.Context(), database.AdminAuditEntry{
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: "invite",
+		TargetID:   inviteID,
+		Details:    details,
+		IPAddress:  c.IP(),
+		UserAgent:  c.Get("User-Agent"),
+	}); err != nil {
+		log.Error().Err(err).Str("invite_id", inviteID).Msg("Failed to write invite audit log")
+	}
+}
@@ -0,0 +1,254 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+
+	"github.com/nodebyte/backend/internal/database"
+	"github.com/nodebyte/backend/internal/panels"
+)
+
+// Per-port outcomes of a bulk allocation request
+const (
+	AllocationCreated = "created"
+	AllocationExists  = "exists" // already on the node, left unchanged
+	AllocationFailed  = "failed"
+)
+
+// BulkAllocationRequest creates one allocation per port in an inclusive range
+type BulkAllocationRequest struct {
+	IP        string `json:"ip"`
+	Alias     string `json:"alias,omitempty"`
+	StartPort int    `json:"startPort"`
+	EndPort   int    `json:"endPort"` // defaults to startPort
+}
+
+// AllocationPortResult is the outcome for one requested port
+type AllocationPortResult struct {
+	Port         int    `json:"port"`
+	Status       string `json:"status"`
+	AllocationID int    `json:"allocationId,omitempty"`
+	Error        string `json:"error,omitempty"`
+}
+
+// validate checks the IP and port range and returns a message for the
+// first problem found
+func (r *BulkAllocationRequest) validate() string {
+	if r.EndPort == 0 {
+		r.EndPort = r.StartPort
+	}
+	switch {
+	case net.ParseIP(r.IP) == nil:
+		return "ip must be a valid IPv4 or IPv6 address"
+	case r.StartPort < panels.MinAllocationPort || r.EndPort > panels.MaxAllocationPort:
+		return fmt.Sprintf("ports must be between %d and %d", panels.MinAllocationPort, panels.MaxAllocationPort)
+	case r.EndPort < r.StartPort:
+		return "endPort must not be below startPort"
+	case r.EndPort-r.StartPort+1 > panels.MaxAllocationPortSet:
+		return fmt.Sprintf("at most %d ports can be created at once", panels.MaxAllocationPortSet)
+	}
+	return ""
+}
+
+// BulkCreateAllocations creates allocations for a port range on a node
+// @Summary Bulk create node allocations
+// @Description Creates an allocation for every port in startPort..endPort on the given IP in the panel, then stores the new allocations locally. Ports already allocated on the node are reported as "exists" and left alone; the rest are reported as "created" or "failed". At most 1000 ports, between 1024 and 65535.
+// @Tags Admin Nodes
+// @Accept json
+// @Produce json
+// @Security Bearer
+// @Param id path string true "Node ID"
+// @Param body body BulkAllocationRequest true "IP and port range"
+// @Success 200 {object} object "Per-port results"
+// @Failure 400 {object} object "Invalid node ID, IP or port range"
+// @Failure 401 {object} object "Unauthorized"
+// @Failure 404 {object} object "Node not found"
+// @Failure 502 {object} object "Panel request failed"
+// @Failure 503 {object} object "Panel not configured"
+// @Router /api/admin/nodes/{id}/allocations/bulk [post]
+func (h *AdminNodeHandler) BulkCreateAllocations(c *fiber.Ctx) error {
+	nodeID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid node ID"})
+	}
+
+	var req BulkAllocationRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if msg := req.validate(); msg != "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": msg})
+	}
+
+	if h.pteroClient == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"error": "Panel not configured"})
+	}
+
+	var exists bool
+	if err := h.db.Pool.QueryRow(c.Context(),
+		`SELECT EXISTS(SELECT 1 FROM nodes WHERE id = $1)`, nodeID,
+	).Scan(&exists); err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch node"})
+	}
+	if !exists {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Node not found"})
+	}
+
+	before, err := h.pteroClient.GetAllAllocationsForNode(c.Context(), nodeID)
+	if err != nil {
+		log.Error().Err(err).Int("node_id", nodeID).Msg("Failed to fetch node allocations from panel")
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{"error": "Failed to fetch allocations from panel"})
+	}
+	existing := allocationsByPort(before, req.IP)
+
+	missing := []int{}
+	for port := req.StartPort; port <= req.EndPort; port++ {
+		if _, ok := existing[port]; !ok {
+			missing = append(missing, port)
+		}
+	}
+
+	// The panel creates the whole batch or nothing, and returns no body, so
+	// the node is re-read to find the new allocation IDs
+	var createErr error
+	created := map[int]panels.PteroAllocation{}
+	if len(missing) > 0 {
+		createErr = h.pteroClient.CreateAllocations(c.Context(), nodeID, panels.CreateAllocationsRequest{
+			IP:    req.IP,
+			Alias: req.Alias,
+			Ports: portRanges(missing),
+		})
+		if createErr != nil {
+			log.Error().Err(createErr).Int("node_id", nodeID).Msg("Failed to create allocations in panel")
+		}
+
+		after, err := h.pteroClient.GetAllAllocationsForNode(c.Context(), nodeID)
+		if err != nil {
+			log.Error().Err(err).Int("node_id", nodeID).Msg("Failed to fetch node allocations from panel")
+			return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{"error": "Failed to fetch allocations from panel"})
+		}
+		for port, alloc := range allocationsByPort(after, req.IP) {
+			if _, ok := existing[port]; !ok {
+				created[port] = alloc
+			}
+		}
+	}
+
+	if err := h.upsertAllocations(c.Context(), nodeID, created); err != nil {
+		log.Error().Err(err).Int("node_id", nodeID).Msg("Failed to store new allocations")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Allocations were created in the panel but could not be stored; run a node sync"})
+	}
+
+	results := make([]AllocationPortResult, 0, req.EndPort-req.StartPort+1)
+	counts := map[string]int{}
+	for port := req.StartPort; port <= req.EndPort; port++ {
+		result := AllocationPortResult{Port: port}
+		if alloc, ok := existing[port]; ok {
+			result.Status = AllocationExists
+			result.AllocationID = alloc.Attributes.ID
+		} else if alloc, ok := created[port]; ok {
+			result.Status = AllocationCreated
+			result.AllocationID = alloc.Attributes.ID
+		} else {
+			result.Status = AllocationFailed
+			result.Error = "not created by the panel"
+			if createErr != nil {
+				result.Error = createErr.Error()
+			}
+		}
+		counts[result.Status]++
+		results = append(results, result)
+	}
+
+	actorID, _ := c.Locals("userID").(string)
+	if counts[AllocationCreated] > 0 {
+		if err := h.db.CreateAdminAuditLog(c.Context(), database.AdminAuditEntry{
+			ActorID:    actorID,
+			Action:     database.AuditAllocationsCreated,
+			TargetType: "node",
+			TargetID:   strconv.Itoa(nodeID),
+			Details: map[string]interface{}{
+				"ip":        req.IP,
+				"startPort": req.StartPort,
+				"endPort":   req.EndPort,
+				"created":   counts[AllocationCreated],
+			},
+			IPAddress: c.IP(),
+			UserAgent: c.Get("User-Agent"),
+		}); err != nil {
+			log.Error().Err(err).Int("node_id", nodeID).Msg("Failed to write allocation audit log")
+		}
+	}
+
+	status := fiber.StatusOK
+	if counts[AllocationFailed] > 0 && counts[AllocationCreated] == 0 {
+		status = fiber.StatusBadGateway
+	}
+	return c.Status(status).JSON(fiber.Map{
+		"success":  counts[AllocationFailed] == 0,
+		"nodeId":   nodeID,
+		"ip":       req.IP,
+		"created":  counts[AllocationCreated],
+		"existing": counts[AllocationExists],
+		"failed":   counts[AllocationFailed],
+		"results":  results,
+	})
+}
+
+// upsertAllocations stores allocations created in the panel
+func (h *AdminNodeHandler) upsertAllocations(ctx context.Context, nodeID int, allocations map[int]panels.PteroAllocation) error {
+	for _, alloc := range allocations {
+		a := alloc.Attributes
+		if _, err := h.db.Pool.Exec(ctx, `
+			INSERT INTO allocations (id, ip, port, alias, notes, "isAssigned", "nodeId", "createdAt", "updatedAt")
+			VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW())
+			ON CONFLICT (id) DO UPDATE SET
+				ip = EXCLUDED.ip,
+				port = EXCLUDED.port,
+				alias = EXCLUDED.alias,
+				notes = EXCLUDED.notes,
+				"isAssigned" = EXCLUDED."isAssigned",
+				"nodeId" = EXCLUDED."nodeId",
+				"updatedAt" = NOW()
+		`, a.ID, a.IP, a.Port, a.Alias, a.Notes, a.Assigned, nodeID); err != nil {
+			return fmt.Errorf("failed to upsert allocation %d: %w", a.ID, err)
+		}
+	}
+	return nil
+}
+
+// allocationsByPort indexes a node's allocations on ip by port
+func allocationsByPort(allocations []panels.PteroAllocation, ip string) map[int]panels.PteroAllocation {
+	target := net.ParseIP(ip)
+	byPort := map[int]panels.PteroAllocation{}
+	for _, alloc := range allocations {
+		if net.ParseIP(alloc.Attributes.IP).Equal(target) {
+			byPort[alloc.Attributes.Port] = alloc
+		}
+	}
+	return byPort
+}
+
+// portRanges collapses ascending ports into the panel's port list format,
+// e.g. [25565 25566 25567 25570] becomes ["25565-25567" "25570"]
+func portRanges(ports []int) []string {
+	ranges := []string{}
+	for i := 0; i < len(ports); {
+		j := i
+		for j+1 < len(ports) && ports[j+1] == ports[j]+1 {
+			j++
+		}
+		if i == j {
+			ranges = append(ranges, strconv.Itoa(ports[i]))
+		} else {
+			ranges = append(ranges, fmt.Sprintf("%d-%d", ports[i], ports[j]))
+		}
+		i = j + 1
+	}
+	return ranges
+}
@@ -3,22 +3,30 @@ package handlers
 import (
 	"context"
 	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/rs/zerolog/log"
 
+	"github.com/nodebyte/backend/internal/config"
 	"github.com/nodebyte/backend/internal/database"
+	"github.com/nodebyte/backend/internal/panels"
+	"github.com/nodebyte/backend/internal/queue"
 )
 
 // AdminNodeHandler handles admin node operations
 type AdminNodeHandler struct {
-	db *database.DB
+	db           *database.DB
+	pteroClient  *panels.PterodactylClient
+	queueManager *queue.Manager
+	pageLimits   PageLimits
 }
 
-// NewAdminNodeHandler creates a new admin node handler
-func NewAdminNodeHandler(db *database.DB) *AdminNodeHandler {
-	return &AdminNodeHandler{db: db}
+// NewAdminNodeHandler creates a new admin node handler. pteroClient may be
+// nil when no panel is configured, in which case changes are local only.
+func NewAdminNodeHandler(db *database.DB, pteroClient *panels.PterodactylClient, queueManager *queue.Manager, cfg *config.Config) *AdminNodeHandler {
+	return &AdminNodeHandler{db: db, pteroClient: pteroClient, queueManager: queueManager, pageLimits: configuredPageLimits(cfg)}
 }
 
 // AdminNodeResponse represents a node for admin view
@@ -55,7 +63,7 @@ type AdminNodeResponse struct {
 // @Param search query string false "Search by name or FQDN"
 // @Param maintenance query string false "Filter by maintenance mode" Enums(all, yes, no) default(all)
 // @Param page query int false "Page number" default(1)
-// @Param pageSize query int false "Items per page" default(25)
+// @Param limit query int false "Items per page (pageSize is also accepted)" default(25)
 // @Success 200 {object} object "Nodes list with pagination"
 // @Failure 401 {object} object "Unauthorized"
 // @Failure 500 {object} object "Internal server error"
@@ -63,15 +71,7 @@ type AdminNodeResponse struct {
 func (h *AdminNodeHandler) GetNodes(c *fiber.Ctx) error {
 	search := c.Query("search", "")
 	maintenance := c.Query("maintenance", "all") // all, yes, no
-	page := c.QueryInt("page", 1)
-	pageSize := c.QueryInt("pageSize", 25)
-
-	if page < 1 {
-		page = 1
-	}
-	if pageSize < 1 || pageSize > 100 {
-		pageSize = 25
-	}
+	pagination := parsePagination(c, h.pageLimits)
 
 	args := []interface{}{}
 	where := `WHERE 1=1`
@@ -94,8 +94,7 @@ func (h *AdminNodeHandler) GetNodes(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to count nodes"})
 	}
 
-	offset := (page - 1) * pageSize
-	args = append(args, pageSize, offset)
+	args = append(args, pagination.Limit, pagination.Offset)
 	lp := fmt.Sprintf("$%d", len(args)-1)
 	op := fmt.Sprintf("$%d", len(args))
 
@@ -106,7 +105,7 @@ func (h *AdminNodeHandler) GetNodes(c *fiber.Ctx) error {
 			n.memory, n."memoryOverallocate", n.disk, n."diskOverallocate",
 			n."daemonListenPort", n."daemonSftpPort",
 			n."locationId", COALESCE(l."shortCode",''),
-			(SELECT COUNT(*) FROM servers s WHERE s."nodeId" = n.id) AS server_count,
+			(SELECT COUNT(*) FROM servers s WHERE s."nodeId" = n.id AND s."deletedAt" IS NULL) AS server_count,
 			(SELECT COUNT(*) FROM allocations a WHERE a."nodeId" = n.id) AS alloc_count,
 			n."createdAt", n."updatedAt"
 		FROM nodes n
@@ -142,14 +141,12 @@ func (h *AdminNodeHandler) GetNodes(c *fiber.Ctx) error {
 		nodes = append(nodes, nd)
 	}
 
-	totalPages := (total + pageSize - 1) / pageSize
+	meta := pagination.Meta(total)
 	return c.JSON(fiber.Map{
-		"success": true,
-		"nodes":   nodes,
-		"pagination": fiber.Map{
-			"page": page, "pageSize": pageSize,
-			"total": total, "totalPages": totalPages,
-		},
+		"success":    true,
+		"nodes":      nodes,
+		"pagination": meta.pageBlock(),
+		"meta":       meta,
 	})
 }
 
@@ -162,7 +159,7 @@ func (h *AdminNodeHandler) GetNodes(c *fiber.Ctx) error {
 // @Param id path string true "Node ID"
 // @Param assigned query string false "Filter by assignment status" Enums(all, yes, no) default(all)
 // @Param page query int false "Page number" default(1)
-// @Param pageSize query int false "Items per page" default(50)
+// @Param limit query int false "Items per page (pageSize is also accepted)" default(25)
 // @Success 200 {object} object "Allocations list with pagination"
 // @Failure 401 {object} object "Unauthorized"
 // @Failure 500 {object} object "Internal server error"
@@ -170,15 +167,7 @@ func (h *AdminNodeHandler) GetNodes(c *fiber.Ctx) error {
 func (h *AdminNodeHandler) GetNodeAllocations(c *fiber.Ctx) error {
 	nodeID := c.Params("id")
 	assigned := c.Query("assigned", "all") // all, yes, no
-	page := c.QueryInt("page", 1)
-	pageSize := c.QueryInt("pageSize", 50)
-
-	if page < 1 {
-		page = 1
-	}
-	if pageSize < 1 || pageSize > 200 {
-		pageSize = 50
-	}
+	pagination := parsePagination(c, h.pageLimits)
 
 	where := `WHERE a."nodeId" = $1`
 	args := []interface{}{nodeID}
@@ -197,8 +186,7 @@ func (h *AdminNodeHandler) GetNodeAllocations(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to count allocations"})
 	}
 
-	offset := (page - 1) * pageSize
-	args = append(args, pageSize, offset)
+	args = append(args, pagination.Limit, pagination.Offset)
 	lp := fmt.Sprintf("$%d", len(args)-1)
 	op := fmt.Sprintf("$%d", len(args))
 
@@ -238,40 +226,97 @@ func (h *AdminNodeHandler) GetNodeAllocations(c *fiber.Ctx) error {
 		allocs = append(allocs, a)
 	}
 
-	totalPages := (total + pageSize - 1) / pageSize
+	meta := pagination.Meta(total)
 	return c.JSON(fiber.Map{
 		"success":     true,
 		"allocations": allocs,
-		"pagination": fiber.Map{
-			"page": page, "pageSize": pageSize,
-			"total": total, "totalPages": totalPages,
-		},
+		"pagination":  meta.pageBlock(),
+		"meta":        meta,
 	})
 }
 
-// ToggleNodeMaintenance toggles maintenance mode on a node
+// NodeMaintenanceRequest represents a node maintenance mode change
+type NodeMaintenanceRequest struct {
+	Enabled      *bool `json:"enabled,omitempty"`      // toggles the current state when omitted
+	NotifyOwners bool  `json:"notifyOwners,omitempty"` // email owners of servers on the node
+}
+
+// ToggleNodeMaintenance sets or toggles maintenance mode on a node
 // @Summary Toggle node maintenance mode
-// @Description Toggles the maintenance mode flag on a specific node
+// @Description Sets maintenance mode on the node in the panel and updates the local row. Without a body the current state is toggled. With notifyOwners, owners of servers on the node are emailed.
 // @Tags Admin Nodes
+// @Accept json
 // @Produce json
 // @Security Bearer
 // @Param id path string true "Node ID"
-// @Success 200 {object} object "Maintenance mode toggled"
+// @Param body body NodeMaintenanceRequest false "Maintenance options"
+// @Success 200 {object} object "Maintenance mode updated"
+// @Failure 400 {object} object "Invalid node ID or request body"
 // @Failure 401 {object} object "Unauthorized"
+// @Failure 404 {object} object "Node not found"
+// @Failure 502 {object} object "Panel request failed"
 // @Failure 500 {object} object "Internal server error"
 // @Router /api/admin/nodes/{id}/maintenance [post]
 func (h *AdminNodeHandler) ToggleNodeMaintenance(c *fiber.Ctx) error {
-	nodeID := c.Params("id")
+	nodeID, err := strconv.Atoi(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid node ID"})
+	}
 
-	var enabled bool
-	if err := h.db.Pool.QueryRow(context.Background(),
-		`UPDATE nodes SET "isMaintenanceMode" = NOT "isMaintenanceMode", "updatedAt" = NOW()
-		 WHERE id = $1 RETURNING "isMaintenanceMode"`,
-		nodeID,
-	).Scan(&enabled); err != nil {
+	var req NodeMaintenanceRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+	}
+
+	var name string
+	var current bool
+	if err := h.db.Pool.QueryRow(c.Context(),
+		`SELECT name, "isMaintenanceMode" FROM nodes WHERE id = $1`, nodeID,
+	).Scan(&name, &current); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{"error": "Node not found"})
+	}
+
+	enabled := !current
+	if req.Enabled != nil {
+		enabled = *req.Enabled
+	}
+
+	// Push the change to the panel first so the local row never claims a
+	// state the panel does not have
+	if h.pteroClient != nil {
+		if _, err := h.pteroClient.SetNodeMaintenanceMode(c.Context(), nodeID, enabled); err != nil {
+			log.Error().Err(err).Int("node_id", nodeID).Msg("Failed to update node maintenance mode in panel")
+			return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{"error": "Failed to update node in panel"})
+		}
+	}
+
+	if _, err := h.db.Pool.Exec(c.Context(),
+		`UPDATE nodes SET "isMaintenanceMode" = $1, "updatedAt" = NOW() WHERE id = $2`,
+		enabled, nodeID,
+	); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to toggle maintenance mode"})
 	}
 
+	actorID, _ := c.Locals("userID").(string)
+	if err := h.db.CreateAdminAuditLog(c.Context(), database.AdminAuditEntry{
+		ActorID:    actorID,
+		Action:     database.AuditNodeMaintenance,
+		TargetType: "node",
+		TargetID:   strconv.Itoa(nodeID),
+		Details:    map[string]interface{}{"maintenanceMode": enabled},
+		IPAddress:  c.IP(),
+		UserAgent:  c.Get("User-Agent"),
+	}); err != nil {
+		log.Error().Err(err).Int("node_id", nodeID).Msg("Failed to write node audit log")
+	}
+
+	notified := 0
+	if req.NotifyOwners && enabled != current {
+		notified = h.notifyNodeOwners(c.Context(), nodeID, name, enabled)
+	}
+
 	status := "disabled"
 	if enabled {
 		status = "enabled"
@@ -279,10 +324,61 @@ func (h *AdminNodeHandler) ToggleNodeMaintenance(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{
 		"success":         true,
 		"maintenanceMode": enabled,
+		"ownersNotified":  notified,
 		"message":         "Maintenance mode " + status,
 	})
 }
 
+// notifyNodeOwners emails the owners of servers on a node about a
+// maintenance change and returns how many emails were queued
+func (h *AdminNodeHandler) notifyNodeOwners(ctx context.Context, nodeID int, nodeName string, enabled bool) int {
+	if h.queueManager == nil {
+		return 0
+	}
+
+	rows, err := h.db.Pool.Query(ctx, `
+		SELECT u.email, COALESCE(u."firstName", ''), string_agg(s.name, ', ' ORDER BY s.name)
+		FROM servers s
+		JOIN users u ON u.id = s."ownerId"
+		WHERE s."nodeId" = $1 AND s."deletedAt" IS NULL AND u."isActive" = true
+		GROUP BY u.email, u."firstName"
+	`, nodeID)
+	if err != nil {
+		log.Error().Err(err).Int("node_id", nodeID).Msg("Failed to fetch node server owners")
+		return 0
+	}
+	defer rows.Close()
+
+	subject := "Scheduled maintenance on your server's node"
+	if !enabled {
+		subject = "Maintenance complete on your server's node"
+	}
+
+	queued := 0
+	for rows.Next() {
+		var email, name, servers string
+		if err := rows.Scan(&email, &name, &servers); err != nil {
+			continue
+		}
+		if _, err := h.queueManager.EnqueueEmail(queue.EmailPayload{
+			To:       email,
+			Subject:  subject,
+			Template: "node-maintenance",
+			Data: map[string]string{
+				"name":        name,
+				"node":        nodeName,
+				"servers":     servers,
+				"maintenance": strconv.FormatBool(enabled),
+			},
+		}); err != nil {
+			log.Error().Err(err).Str("email", email).Msg("Failed to queue node maintenance email")
+			continue
+		}
+		queued++
+	}
+	return queued
+}
+
 // GetLocations returns all locations (simple list, no pagination needed)
 // @Summary List all locations
 // @Description Returns all Pterodactyl panel locations with their node counts
@@ -333,7 +429,7 @@ func (h *AdminNodeHandler) GetLocations(c *fiber.Ctx) error {
 // @Param nodeId query string false "Filter by node ID"
 // @Param search query string false "Search by IP, alias, or port"
 // @Param page query int false "Page number" default(1)
-// @Param pageSize query int false "Items per page" default(50)
+// @Param limit query int false "Items per page (pageSize is also accepted)" default(25)
 // @Success 200 {object} object "Allocations list with pagination"
 // @Failure 401 {object} object "Unauthorized"
 // @Failure 500 {object} object "Internal server error"
@@ -342,15 +438,7 @@ func (h *AdminNodeHandler) GetAllAllocations(c *fiber.Ctx) error {
 	assigned := c.Query("assigned", "all") // all, yes, no
 	nodeID := c.Query("nodeId", "")
 	search := c.Query("search", "") // IP or alias search
-	page := c.QueryInt("page", 1)
-	pageSize := c.QueryInt("pageSize", 50)
-
-	if page < 1 {
-		page = 1
-	}
-	if pageSize < 1 || pageSize > 200 {
-		pageSize = 50
-	}
+	pagination := parsePagination(c, h.pageLimits)
 
 	where := `WHERE 1=1`
 	args := []interface{}{}
@@ -379,8 +467,7 @@ func (h *AdminNodeHandler) GetAllAllocations(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to count allocations"})
 	}
 
-	offset := (page - 1) * pageSize
-	args = append(args, pageSize, offset)
+	args = append(args, pagination.Limit, pagination.Offset)
 	lp := fmt.Sprintf("$%d", len(args)-1)
 	op := fmt.Sprintf("$%d", len(args))
 
@@ -430,13 +517,11 @@ func (h *AdminNodeHandler) GetAllAllocations(c *fiber.Ctx) error {
 		allocs = append(allocs, a)
 	}
 
-	totalPages := (total + pageSize - 1) / pageSize
+	meta := pagination.Meta(total)
 	return c.JSON(fiber.Map{
 		"success":     true,
 		"allocations": allocs,
-		"pagination": fiber.Map{
-			"page": page, "pageSize": pageSize,
-			"total": total, "totalPages": totalPages,
-		},
+		"pagination":  meta.pageBlock(),
+		"meta":        meta,
 	})
 }
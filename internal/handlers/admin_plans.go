@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+
+	"github.com/nodebyte/backend/internal/database"
+)
+
+// AdminPlanHandler handles plan management
+type AdminPlanHandler struct {
+	db *database.DB
+}
+
+// NewAdminPlanHandler creates a new admin plan handler
+func NewAdminPlanHandler(db *database.DB) *AdminPlanHandler {
+	return &AdminPlanHandler{db: db}
+}
+
+// CreatePlanRequest represents a plan creation request. Omitted limits are unlimited.
+type CreatePlanRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	MaxServers  *int   `json:"maxServers"`
+	MaxMemory   *int   `json:"maxMemory"` // MB
+	MaxDisk     *int   `json:"maxDisk"`   // MB
+	MaxCPU      *int   `json:"maxCpu"`    // % of one core
+	IsDefault   bool   `json:"isDefault"`
+}
+
+// SetUserPlanRequest assigns a plan to a user; an empty planId removes it
+type SetUserPlanRequest struct {
+	UserID string `json:"userId"`
+	PlanID string `json:"planId"`
+}
+
+// GetPlans returns all plans
+// @Summary List plans (admin)
+// @Description Returns the plan catalog. A null limit is unlimited.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} SuccessResponse "Plans retrieved"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/plans [get]
+func (h *AdminPlanHandler) GetPlans(c *fiber.Ctx) error {
+	plans, err := h.db.ListPlans(c.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch plans")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch plans",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"plans":   plans,
+	})
+}
+
+// CreatePlan creates a plan
+// @Summary Create plan (admin)
+// @Description Creates a plan with optional server, memory (MB), disk (MB) and CPU (%) limits summed across a user's servers. Omitted limits are unlimited. isDefault makes it the plan for users without one, replacing the previous default.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body CreatePlanRequest true "Plan"
+// @Success 201 {object} SuccessResponse "Plan created"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 409 {object} ErrorResponse "Plan name already in use"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/plans [post]
+func (h *AdminPlanHandler) CreatePlan(c *fiber.Ctx) error {
+	var req CreatePlanRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "name is required",
+		})
+	}
+	for _, limit := range []*int{req.MaxServers, req.MaxMemory, req.MaxDisk, req.MaxCPU} {
+		if limit != nil && *limit < 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "limits must not be negative",
+			})
+		}
+	}
+
+	plan, err := h.db.CreatePlan(c.Context(), database.Plan{
+		Name:        req.Name,
+		Description: req.Description,
+		MaxServers:  req.MaxServers,
+		MaxMemory:   req.MaxMemory,
+		MaxDisk:     req.MaxDisk,
+		MaxCPU:      req.MaxCPU,
+		IsDefault:   req.IsDefault,
+	})
+	if errors.Is(err, database.ErrPlanNameTaken) {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "A plan with this name already exists",
+		})
+	}
+	if err != nil {
+		log.Error().Err(err).Str("name", req.Name).Msg("Failed to create plan")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create plan",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"plan":    plan,
+	})
+}
+
+// SetUserPlan assigns a plan to a user
+// @Summary Assign user plan (admin)
+// @Description Assigns a plan to a user. An empty planId removes the assignment so the default plan applies.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body SetUserPlanRequest true "User and plan"
+// @Success 200 {object} SuccessResponse "Plan assigned"
+// @Failure 400 {object} ErrorResponse "Invalid request or unknown plan"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/users/plan [post]
+func (h *AdminPlanHandler) SetUserPlan(c *fiber.Ctx) error {
+	var req SetUserPlanRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if req.UserID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "userId is required",
+		})
+	}
+
+	if req.PlanID != "" {
+		exists, err := h.db.PlanExists(c.Context(), req.PlanID)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to look up plan")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to assign plan",
+			})
+		}
+		if !exists {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Unknown plan",
+			})
+		}
+	}
+
+	updated, err := h.db.SetUserPlan(c.Context(), req.UserID, req.PlanID)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", req.UserID).Msg("Failed to assign plan")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to assign plan",
+		})
+	}
+	if !updated {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"data": fiber.Map{
+			"userId": req.UserID,
+			"planId": req.PlanID,
+		},
+	})
+}
@@ -0,0 +1,56 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+
+	"github.com/nodebyte/backend/internal/queue"
+)
+
+// maxDeadLetterLimit caps how many archived tasks are read from each queue
+const maxDeadLetterLimit = 1000
+
+// AdminQueueHandler handles task queue inspection for admins
+type AdminQueueHandler struct {
+	queueManager *queue.Manager
+}
+
+// NewAdminQueueHandler creates a new admin queue handler
+func NewAdminQueueHandler(queueManager *queue.Manager) *AdminQueueHandler {
+	return &AdminQueueHandler{queueManager: queueManager}
+}
+
+// GetDeadLetterTasks returns tasks that exhausted their retries, grouped by type
+// @Summary List dead-letter tasks (admin)
+// @Description Returns archived tasks from every queue with their type, queue, last error, retry count and payload (secret fields redacted), grouped by task type with the largest group first
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param limit query int false "Tasks read per queue (default 100, larger values are capped at 1000)"
+// @Success 200 {object} SuccessResponse "Dead-letter tasks retrieved"
+// @Failure 400 {object} ErrorResponse "Invalid limit"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/queues/dead-letter [get]
+func (h *AdminQueueHandler) GetDeadLetterTasks(c *fiber.Ctx) error {
+	limit := c.QueryInt("limit", 100)
+	if limit <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "limit must be a positive number",
+		})
+	}
+	limit = min(limit, maxDeadLetterLimit)
+
+	tasks, err := h.queueManager.DeadLetterTasks(limit)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list dead-letter tasks")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to list dead-letter tasks",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"groups":  queue.GroupDeadLetterTasks(tasks),
+		"total":   len(tasks),
+	})
+}
@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"regexp"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+
+	"github.com/nodebyte/backend/internal/auth"
+	"github.com/nodebyte/backend/internal/database"
+)
+
+// roleNamePattern restricts role names to upper-case identifiers such as SUPPORT_TEAM
+var roleNamePattern = regexp.MustCompile(`^[A-Z][A-Z0-9_]{0,49}$`)
+
+// AdminRoleHandler handles role catalog management
+type AdminRoleHandler struct {
+	db *database.DB
+}
+
+// NewAdminRoleHandler creates a new admin role handler
+func NewAdminRoleHandler(db *database.DB) *AdminRoleHandler {
+	return &AdminRoleHandler{db: db}
+}
+
+// RoleRequest represents a role create or update request
+type RoleRequest struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Permissions []string `json:"permissions"`
+}
+
+// GetRoles returns the role catalog
+// @Summary List roles (admin)
+// @Description Returns every role in the catalog with its permissions and the number of users holding it
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} SuccessResponse "Roles retrieved"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/roles [get]
+func (h *AdminRoleHandler) GetRoles(c *fiber.Ctx) error {
+	roles, err := h.db.ListRoles(c.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch roles")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch roles",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"roles":   roles,
+	})
+}
+
+// CreateRole adds a role to the catalog
+// @Summary Create role (admin)
+// @Description Adds a role to the catalog. Names are upper-cased and may contain letters, digits and underscores.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body RoleRequest true "Role"
+// @Success 201 {object} SuccessResponse "Role created"
+// @Failure 400 {object} ErrorResponse "Invalid role"
+// @Failure 403 {object} ErrorResponse "Granting * or roles.manage requires a system admin"
+// @Failure 409 {object} ErrorResponse "Role already exists"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/roles [post]
+func (h *AdminRoleHandler) CreateRole(c *fiber.Ctx) error {
+	var req RoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	name := database.NormalizeRoleName(req.Name)
+	if !roleNamePattern.MatchString(name) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "name must start with a letter and contain only letters, digits and underscores",
+		})
+	}
+	if roleGrantDenied(c, req.Permissions) {
+		return roleGrantForbidden(c)
+	}
+
+	created, err := h.db.CreateRole(c.Context(), name, req.Description, req.Permissions)
+	if err != nil {
+		log.Error().Err(err).Str("role", name).Msg("Failed to create role")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create role",
+		})
+	}
+	if !created {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Role already exists",
+		})
+	}
+
+	h.audit(c, database.AuditRoleCreated, name, map[string]interface{}{"permissions": req.Permissions})
+
+	role, err := h.db.GetRole(c.Context(), name)
+	if err != nil {
+		log.Error().Err(err).Str("role", name).Msg("Failed to fetch created role")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch role",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"success": true,
+		"role":    role,
+	})
+}
+
+// UpdateRole replaces a role's description and permissions
+// @Summary Update role (admin)
+// @Description Replaces the description and permissions of a role. Role names cannot be changed.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Role name"
+// @Param body body RoleRequest true "Role"
+// @Success 200 {object} SuccessResponse "Role updated"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 403 {object} ErrorResponse "Granting * or roles.manage requires a system admin"
+// @Failure 404 {object} ErrorResponse "Role not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/roles/{name} [put]
+func (h *AdminRoleHandler) UpdateRole(c *fiber.Ctx) error {
+	name := database.NormalizeRoleName(c.Params("name"))
+
+	var req RoleRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if roleGrantDenied(c, req.Permissions) {
+		return roleGrantForbidden(c)
+	}
+
+	updated, err := h.db.UpdateRole(c.Context(), name, req.Description, req.Permissions)
+	if err != nil {
+		log.Error().Err(err).Str("role", name).Msg("Failed to update role")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update role",
+		})
+	}
+	if !updated {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Role not found",
+		})
+	}
+
+	h.audit(c, database.AuditRoleUpdated, name, map[string]interface{}{"permissions": req.Permissions})
+
+	role, err := h.db.GetRole(c.Context(), name)
+	if err != nil {
+		log.Error().Err(err).Str("role", name).Msg("Failed to fetch updated role")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch role",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"role":    role,
+	})
+}
+
+// DeleteRole removes a custom role from the catalog
+// @Summary Delete role (admin)
+// @Description Removes a role from the catalog. Built-in roles and roles still assigned to users cannot be deleted.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param name path string true "Role name"
+// @Success 200 {object} SuccessResponse "Role deleted"
+// @Failure 404 {object} ErrorResponse "Role not found"
+// @Failure 409 {object} ErrorResponse "Role is built in or still assigned"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/roles/{name} [delete]
+func (h *AdminRoleHandler) DeleteRole(c *fiber.Ctx) error {
+	name := database.NormalizeRoleName(c.Params("name"))
+
+	role, err := h.db.GetRole(c.Context(), name)
+	if err == pgx.ErrNoRows {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Role not found",
+		})
+	}
+	if err != nil {
+		log.Error().Err(err).Str("role", name).Msg("Failed to fetch role")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch role",
+		})
+	}
+
+	if role.IsSystem {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Built-in roles cannot be deleted",
+		})
+	}
+	if role.UserCount > 0 {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":     "Role is still assigned to users",
+			"userCount": role.UserCount,
+		})
+	}
+
+	if _, err := h.db.DeleteRole(c.Context(), name); err != nil {
+		log.Error().Err(err).Str("role", name).Msg("Failed to delete role")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete role",
+		})
+	}
+
+	h.audit(c, database.AuditRoleDeleted, name, nil)
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Role deleted",
+	})
+}
+
+// roleGrantDenied reports whether the caller may not give a role
+// permissions: "*" and roles.manage let the role's holders grant themselves
+// anything, so only system admins may hand them out
+func roleGrantDenied(c *fiber.Ctx, permissions []string) bool {
+	isSystemAdmin, _ := c.Locals("isSystemAdmin").(bool)
+	return !isSystemAdmin && auth.GrantsRoleManagement(permissions)
+}
+
+// roleGrantForbidden answers a role change refused by roleGrantDenied
+func roleGrantForbidden(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+		"error": "System admin access required to grant * or roles.manage",
+	})
+}
+
+// audit records a role catalog change; failures are logged but do not fail the request
+func (h *AdminRoleHandler) audit(c *fiber.Ctx, action, role string, details map[string]interface{}) {
+	actorID, _ := c.Locals("userID").(string)
+	if err := h.db.CreateAdminAuditLog(c.Context(), database.AdminAuditEntry{
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: "role",
+		TargetID:   role,
+		Details:    details,
+		IPAddress:  c.IP(),
+		UserAgent:  c.Get("User-Agent"),
+	}); err != nil {
+		log.Error().Err(err).Str("role", role).Msg("Failed to write role audit log")
+	}
+}
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+
+	"github.com/nodebyte/backend/internal/database"
+)
+
+// maxServerNotesLength caps a server's admin notes
+const maxServerNotesLength = 5000
+
+// UpdateServerNotesRequest replaces a server's admin notes
+type UpdateServerNotesRequest struct {
+	Notes string `json:"notes"` // empty clears the notes
+}
+
+// GetServerNotes returns a server's internal admin notes
+// @Summary Get server admin notes (admin)
+// @Description Returns the internal notes admins keep on a server. Notes are never shown to the server owner and are not touched by the panel sync.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Server ID"
+// @Success 200 {object} SuccessResponse "Server notes"
+// @Failure 404 {object} ErrorResponse "Server not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/servers/{id}/notes [get]
+func (h *AdminServerHandler) GetServerNotes(c *fiber.Ctx) error {
+	notes, err := h.db.GetServerAdminNotes(c.Context(), c.Params("id"))
+	if err != nil {
+		log.Error().Err(err).Str("server_id", c.Params("id")).Msg("Failed to fetch server notes")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch server notes",
+		})
+	}
+	if notes == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Server not found",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"notes":   notes,
+	})
+}
+
+// UpdateServerNotes replaces a server's internal admin notes
+// @Summary Update server admin notes (admin)
+// @Description Replaces the internal notes admins keep on a server; an empty value clears them. Notes are never shown to the server owner and survive panel syncs.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Server ID"
+// @Param body body UpdateServerNotesRequest true "New notes"
+// @Success 200 {object} SuccessResponse "Updated notes"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 404 {object} ErrorResponse "Server not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/servers/{id}/notes [put]
+func (h *AdminServerHandler) UpdateServerNotes(c *fiber.Ctx) error {
+	var req UpdateServerNotesRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	req.Notes = strings.TrimSpace(req.Notes)
+	if utf8.RuneCountInString(req.Notes) > maxServerNotesLength {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("notes must be at most %d characters", maxServerNotesLength),
+		})
+	}
+
+	serverID := c.Params("id")
+	found, err := h.db.SetServerAdminNotes(c.Context(), serverID, req.Notes)
+	if err != nil {
+		log.Error().Err(err).Str("server_id", serverID).Msg("Failed to update server notes")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update server notes",
+		})
+	}
+	if !found {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Server not found",
+		})
+	}
+
+	actorID, _ := c.Locals("userID").(string)
+	if err := h.db.CreateAdminAuditLog(c.Context(), database.AdminAuditEntry{
+		ActorID:    actorID,
+		Action:     database.AuditServerNotesUpdated,
+		TargetType: "server",
+		TargetID:   serverID,
+		Details:    map[string]interface{}{"cleared": req.Notes == ""},
+		IPAddress:  c.IP(),
+		UserAgent:  c.Get("User-Agent"),
+	}); err != nil {
+		log.Error().Err(err).Str("server_id", serverID).Msg("Failed to write server notes audit log")
+	}
+
+	notes, err := h.db.GetServerAdminNotes(c.Context(), serverID)
+	if err != nil || notes == nil {
+		log.Error().Err(err).Str("server_id", serverID).Msg("Failed to fetch updated server notes")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch updated server notes",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"notes":   notes,
+	})
+}
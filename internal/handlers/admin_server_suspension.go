@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+
+	"github.com/nodebyte/backend/internal/database"
+)
+
+const (
+	// maxBulkSuspendServers caps the servers changed by one request
+	maxBulkSuspendServers = 100
+
+	// maxSuspensionReasonLength caps the reason shown to server owners
+	maxSuspensionReasonLength = 500
+)
+
+// BulkSuspendRequest lists the servers to suspend or unsuspend
+type BulkSuspendRequest struct {
+	ServerIDs []string `json:"serverIds"`
+	Reason    string   `json:"reason"` // shown to the server owner; ignored when unsuspending
+}
+
+// BulkSuspendResult is the outcome for one server of a bulk suspension
+type BulkSuspendResult struct {
+	ServerID string `json:"serverId"`
+	Success  bool   `json:"success"`
+	Error    string `json:"error,omitempty"`
+}
+
+// SuspendServers suspends servers in bulk
+// @Summary Suspend servers (admin)
+// @Description Suspends each server in the panel and records the reason, which is shown to the server owner. Servers that fail are reported per server and do not stop the rest.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body BulkSuspendRequest true "Servers and suspension reason"
+// @Success 200 {object} SuccessResponse "Per-server results"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Router /api/admin/servers/suspend [post]
+func (h *AdminServerHandler) SuspendServers(c *fiber.Ctx) error {
+	return h.setServersSuspended(c, true)
+}
+
+// UnsuspendServers lifts the suspension of servers in bulk
+// @Summary Unsuspend servers (admin)
+// @Description Unsuspends each server in the panel and clears its recorded suspension reason. Servers that fail are reported per server and do not stop the rest.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body BulkSuspendRequest true "Servers to unsuspend"
+// @Success 200 {object} SuccessResponse "Per-server results"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Router /api/admin/servers/unsuspend [post]
+func (h *AdminServerHandler) UnsuspendServers(c *fiber.Ctx) error {
+	return h.setServersSuspended(c, false)
+}
+
+// setServersSuspended applies a bulk suspension change, updating the panel
+// before the local row so the backend never claims a state the panel lacks
+func (h *AdminServerHandler) setServersSuspended(c *fiber.Ctx, suspended bool) error {
+	var req BulkSuspendRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+	}
+	if len(req.ServerIDs) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "serverIds is required"})
+	}
+	if len(req.ServerIDs) > maxBulkSuspendServers {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("At most %d servers can be changed at once", maxBulkSuspendServers),
+		})
+	}
+	req.Reason = strings.TrimSpace(req.Reason)
+	if len(req.Reason) > maxSuspensionReasonLength {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("reason must be at most %d characters", maxSuspensionReasonLength),
+		})
+	}
+
+	action := database.AuditServerUnsuspended
+	if suspended {
+		action = database.AuditServerSuspended
+	}
+	actorID, _ := c.Locals("userID").(string)
+
+	results := make([]BulkSuspendResult, 0, len(req.ServerIDs))
+	changed := make([]string, 0, len(req.ServerIDs))
+	for _, serverID := range req.ServerIDs {
+		result := BulkSuspendResult{ServerID: serverID}
+		if err := h.setServerSuspended(c, serverID, suspended, req.Reason); err != nil {
+			result.Error = err.Error()
+			results = append(results, result)
+			continue
+		}
+		result.Success = true
+		results = append(results, result)
+		changed = append(changed, serverID)
+
+		if err := h.db.CreateAdminAuditLog(c.Context(), database.AdminAuditEntry{
+			ActorID:    actorID,
+			Action:     action,
+			TargetType: "server",
+			TargetID:   serverID,
+			Details:    map[string]interface{}{"reason": req.Reason},
+			IPAddress:  c.IP(),
+			UserAgent:  c.Get("User-Agent"),
+		}); err != nil {
+			log.Error().Err(err).Str("server_id", serverID).Msg("Failed to write server suspension audit log")
+		}
+	}
+
+	h.notifySuspensionChange(c.Context(), changed, suspended, req.Reason)
+
+	return c.JSON(fiber.Map{
+		"success":   true,
+		"results":   results,
+		"succeeded": len(changed),
+		"failed":    len(results) - len(changed),
+	})
+}
+
+// notifySuspensionChange tells the owners of the given servers that they were
+// suspended or unsuspended. Failures are only logged.
+func (h *AdminServerHandler) notifySuspensionChange(ctx context.Context, serverIDs []string, suspended bool, reason string) {
+	if len(serverIDs) == 0 {
+		return
+	}
+	owners, err := h.db.ServerOwners(ctx, serverIDs)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch server owners for suspension notifications")
+		return
+	}
+
+	for _, o := range owners {
+		n := database.NewNotification{
+			Category: database.NotificationServerStatus,
+			Type:     database.NotificationServerUnsuspended,
+			Title:    "Server unsuspended",
+			Body:     fmt.Sprintf("Your server %q is no longer suspended.", o.Name),
+		}
+		if suspended {
+			n.Type = database.NotificationServerSuspended
+			n.Title = "Server suspended"
+			n.Body = fmt.Sprintf("Your server %q has been suspended.", o.Name)
+			if reason != "" {
+				n.Body += " Reason: " + reason
+			}
+		}
+		if _, err := h.db.NotifyUsers(ctx, []string{o.OwnerID}, n); err != nil {
+			log.Error().Err(err).Str("server_id", o.ServerID).Msg("Failed to write suspension notification")
+		}
+	}
+}
+
+// setServerSuspended suspends or unsuspends one server in the panel and
+// records the change locally
+func (h *AdminServerHandler) setServerSuspended(c *fiber.Ctx, serverID string, suspended bool, reason string) error {
+	var pterodactylID *int
+	if err := h.db.Pool.QueryRow(c.Context(),
+		`SELECT "pterodactylId" FROM servers WHERE id = $1`, serverID,
+	).Scan(&pterodactylID); err != nil {
+		return fmt.Errorf("server not found")
+	}
+
+	if h.pteroClient != nil && pterodactylID != nil {
+		var err error
+		if suspended {
+			err = h.pteroClient.SuspendServer(c.Context(), *pterodactylID)
+		} else {
+			err = h.pteroClient.UnsuspendServer(c.Context(), *pterodactylID)
+		}
+		if err != nil {
+			log.Error().Err(err).Str("server_id", serverID).Bool("suspended", suspended).Msg("Failed to update server suspension in panel")
+			return fmt.Errorf("failed to update server in panel")
+		}
+	}
+
+	if err := h.db.SetServerSuspended(c.Context(), serverID, suspended, reason); err != nil {
+		log.Error().Err(err).Str("server_id", serverID).Msg("Failed to record server suspension")
+		return fmt.Errorf("failed to record suspension")
+	}
+	return nil
+}
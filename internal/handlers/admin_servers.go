@@ -7,39 +7,54 @@ import (
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/nodebyte/backend/internal/database"
 	"github.com/rs/zerolog/log"
+
+	"github.com/nodebyte/backend/internal/config"
+	"github.com/nodebyte/backend/internal/database"
+	"github.com/nodebyte/backend/internal/panels"
+	"github.com/nodebyte/backend/internal/workers"
 )
 
 // AdminServerHandler handles admin server operations
 type AdminServerHandler struct {
-	db *database.DB
+	db          *database.DB
+	pteroClient *panels.PterodactylClient
+	syncer      *workers.SyncHandler
+	pageLimits  PageLimits
 }
 
-// NewAdminServerHandler creates a new admin server handler
-func NewAdminServerHandler(db *database.DB) *AdminServerHandler {
-	return &AdminServerHandler{db: db}
+// NewAdminServerHandler creates a new admin server handler. Without a panel
+// client single-server resyncs are unavailable and suspensions are only
+// recorded locally.
+func NewAdminServerHandler(db *database.DB, pteroClient *panels.PterodactylClient, cfg *config.Config) *AdminServerHandler {
+	h := &AdminServerHandler{db: db, pteroClient: pteroClient, pageLimits: configuredPageLimits(cfg)}
+	if pteroClient != nil {
+		h.syncer = workers.NewSyncHandler(db, pteroClient, cfg)
+	}
+	return h
 }
 
 // AdminServerResponse represents a server for admin view
 type AdminServerResponse struct {
-	ID            string     `json:"id"`
-	ServerType    string     `json:"serverType"`
-	PterodactylID int        `json:"pterodactylId"`
-	UUID          string     `json:"uuid"`
-	Name          string     `json:"name"`
-	Description   string     `json:"description"`
-	Status        string     `json:"status"`
-	IsSuspended   bool       `json:"isSuspended"`
-	PanelType     string     `json:"panelType"`
-	Owner         *OwnerInfo `json:"owner"`
-	Node          *NodeInfo  `json:"node"`
-	Egg           *EggInfo   `json:"egg"`
-	Memory        int        `json:"memory"`
-	Disk          int        `json:"disk"`
-	CPU           int        `json:"cpu"`
-	CreatedAt     string     `json:"createdAt"`
-	UpdatedAt     string     `json:"updatedAt"`
+	ID            string                     `json:"id"`
+	ServerType    string                     `json:"serverType"`
+	PterodactylID int                        `json:"pterodactylId"`
+	UUID          string                     `json:"uuid"`
+	Name          string                     `json:"name"`
+	Description   string                     `json:"description"`
+	AdminNotes    *string                    `json:"adminNotes"` // internal, never shown to the owner
+	Status        string                     `json:"status"`
+	IsSuspended   bool                       `json:"isSuspended"`
+	Suspension    *database.ServerSuspension `json:"suspension,omitempty"` // set while suspended
+	PanelType     string                     `json:"panelType"`
+	Owner         *OwnerInfo                 `json:"owner"`
+	Node          *NodeInfo                  `json:"node"`
+	Egg           *EggInfo                   `json:"egg"`
+	Memory        int                        `json:"memory"`
+	Disk          int                        `json:"disk"`
+	CPU           int                        `json:"cpu"`
+	CreatedAt     string                     `json:"createdAt"`
+	UpdatedAt     string                     `json:"updatedAt"`
 }
 
 // OwnerInfo represents server owner information
@@ -70,8 +85,6 @@ type GetServersRequest struct {
 	ServerType string `query:"serverType"` // all, game_server, vps, email, web_hosting
 	Sort       string `query:"sort"`       // name, created, status
 	Order      string `query:"order"`      // asc, desc
-	Page       int    `query:"page"`
-	PageSize   int    `query:"pageSize"`
 }
 
 // GetServers returns paginated list of all servers with filtering
@@ -83,18 +96,10 @@ func (h *AdminServerHandler) GetServers(c *fiber.Ctx) error {
 		ServerType: c.Query("serverType", "all"),
 		Sort:       c.Query("sort", "created"),
 		Order:      c.Query("order", "desc"),
-		Page:       c.QueryInt("page", 1),
-		PageSize:   c.QueryInt("pageSize", 25),
 	}
+	pagination := parsePagination(c, h.pageLimits)
 
-	if req.Page < 1 {
-		req.Page = 1
-	}
-	if req.PageSize < 1 || req.PageSize > 100 {
-		req.PageSize = 25
-	}
-
-	whereClause := `WHERE 1=1`
+	whereClause := `WHERE s."deletedAt" IS NULL`
 	args := []interface{}{}
 
 	// Apply search filter
@@ -145,25 +150,11 @@ func (h *AdminServerHandler) GetServers(c *fiber.Ctx) error {
 	}
 
 	// Pagination args
-	offset := (req.Page - 1) * req.PageSize
-	args = append(args, req.PageSize, offset)
+	args = append(args, pagination.Limit, pagination.Offset)
 	limitPlaceholder := fmt.Sprintf("$%d", len(args)-1)
 	offsetPlaceholder := fmt.Sprintf("$%d", len(args))
 
-	query := `
-		SELECT
-			s.id, COALESCE(s."serverType", 'game_server'), s."pterodactylId", COALESCE(s.uuid, ''), s.name,
-			COALESCE(s.description, ''), s.status, s."isSuspended",
-			s.memory, s.disk, s.cpu, COALESCE(s."panelType", 'pterodactyl'),
-			s."createdAt", s."updatedAt",
-			u.id, u.email, u.username,
-			n.id, n.name, n.fqdn,
-			e.id, e.name, nest.name
-		FROM servers s
-		LEFT JOIN users u ON s."ownerId" = u.id
-		LEFT JOIN nodes n ON s."nodeId" = n.id
-		LEFT JOIN eggs e ON s."eggId" = e.id
-		LEFT JOIN nests nest ON e."nestId" = nest.id
+	query := adminServerSelect + `
 		` + whereClause + `
 		ORDER BY ` + sortField + ` ` + sortOrder + `
 		LIMIT ` + limitPlaceholder + ` OFFSET ` + offsetPlaceholder
@@ -178,76 +169,159 @@ func (h *AdminServerHandler) GetServers(c *fiber.Ctx) error {
 
 	servers := []AdminServerResponse{}
 	for rows.Next() {
-		var server AdminServerResponse
-		var pterodactylId *int
-		var uuid, ownerID, ownerEmail, ownerUsername *string
-		var nodeID *int
-		var nodeName, nodeFQDN *string
-		var eggID *int
-		var eggName, nestName *string
-		var createdAt, updatedAt time.Time
-
-		err := rows.Scan(
-			&server.ID, &server.ServerType, &pterodactylId, &uuid, &server.Name,
-			&server.Description, &server.Status, &server.IsSuspended,
-			&server.Memory, &server.Disk, &server.CPU, &server.PanelType,
-			&createdAt, &updatedAt,
-			&ownerID, &ownerEmail, &ownerUsername,
-			&nodeID, &nodeName, &nodeFQDN,
-			&eggID, &eggName, &nestName,
-		)
+		server, err := scanAdminServer(rows)
 		if err != nil {
 			log.Warn().Err(err).Msg("Failed to scan server row")
 			continue
 		}
+		servers = append(servers, *server)
+	}
 
-		if pterodactylId != nil {
-			server.PterodactylID = *pterodactylId
-		}
-		if uuid != nil {
-			server.UUID = *uuid
+	meta := pagination.Meta(totalCount)
+	return c.JSON(fiber.Map{
+		"success":    true,
+		"servers":    servers,
+		"pagination": meta.pageBlock(),
+		"meta":       meta,
+	})
+}
+
+// adminServerSelect selects the columns read by scanAdminServer; callers
+// append their WHERE clause
+const adminServerSelect = `
+		SELECT
+			s.id, COALESCE(s."serverType", 'game_server'), s."pterodactylId", COALESCE(s.uuid, ''), s.name,
+			COALESCE(s.description, ''), s."adminNotes", s.status, s."isSuspended", s."suspensionReason", s."suspendedAt",
+			s.memory, s.disk, s.cpu, COALESCE(s."panelType", 'pterodactyl'),
+			s."createdAt", s."updatedAt",
+			u.id, u.email, u.username,
+			n.id, n.name, n.fqdn,
+			e.id, e.name, nest.name
+		FROM servers s
+		LEFT JOIN users u ON s."ownerId" = u.id
+		LEFT JOIN nodes n ON s."nodeId" = n.id
+		LEFT JOIN eggs e ON s."eggId" = e.id
+		LEFT JOIN nests nest ON e."nestId" = nest.id`
+
+// scanAdminServer reads a row selected by adminServerSelect
+func scanAdminServer(row interface{ Scan(...any) error }) (*AdminServerResponse, error) {
+	var server AdminServerResponse
+	var pterodactylId *int
+	var uuid, ownerID, ownerEmail, ownerUsername *string
+	var nodeID *int
+	var nodeName, nodeFQDN *string
+	var eggID *int
+	var eggName, nestName *string
+	var suspension database.ServerSuspension
+	var createdAt, updatedAt time.Time
+
+	err := row.Scan(
+		&server.ID, &server.ServerType, &pterodactylId, &uuid, &server.Name,
+		&server.Description, &server.AdminNotes, &server.Status, &server.IsSuspended, &suspension.Reason, &suspension.SuspendedAt,
+		&server.Memory, &server.Disk, &server.CPU, &server.PanelType,
+		&createdAt, &updatedAt,
+		&ownerID, &ownerEmail, &ownerUsername,
+		&nodeID, &nodeName, &nodeFQDN,
+		&eggID, &eggName, &nestName,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	if pterodactylId != nil {
+		server.PterodactylID = *pterodactylId
+	}
+	if uuid != nil {
+		server.UUID = *uuid
+	}
+	server.CreatedAt = createdAt.Format(time.RFC3339)
+	server.UpdatedAt = updatedAt.Format(time.RFC3339)
+	if server.IsSuspended {
+		server.Suspension = &suspension
+	}
+
+	if ownerID != nil {
+		server.Owner = &OwnerInfo{
+			ID:       *ownerID,
+			Email:    *ownerEmail,
+			Username: *ownerUsername,
 		}
-		server.CreatedAt = createdAt.Format(time.RFC3339)
-		server.UpdatedAt = updatedAt.Format(time.RFC3339)
-
-		if ownerID != nil {
-			server.Owner = &OwnerInfo{
-				ID:       *ownerID,
-				Email:    *ownerEmail,
-				Username: *ownerUsername,
-			}
+	}
+	if nodeID != nil {
+		server.Node = &NodeInfo{
+			ID:   *nodeID,
+			Name: *nodeName,
+			FQDN: *nodeFQDN,
 		}
-		if nodeID != nil {
-			server.Node = &NodeInfo{
-				ID:   *nodeID,
-				Name: *nodeName,
-				FQDN: *nodeFQDN,
-			}
+	}
+	if eggID != nil {
+		nest := ""
+		if nestName != nil {
+			nest = *nestName
 		}
-		if eggID != nil {
-			nest := ""
-			if nestName != nil {
-				nest = *nestName
-			}
-			server.Egg = &EggInfo{
-				ID:   *eggID,
-				Name: *eggName,
-				Nest: nest,
-			}
+		server.Egg = &EggInfo{
+			ID:   *eggID,
+			Name: *eggName,
+			Nest: nest,
 		}
+	}
+	return &server, nil
+}
 
-		servers = append(servers, server)
+// ResyncServer refreshes a single server from the panel
+// @Summary Resync a server (admin)
+// @Description Fetches one server from the panel and refreshes its row, allocations and databases without running a full sync, then returns the refreshed record. Allocations the panel no longer assigns to the server are unlinked and databases it no longer reports are removed.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Server ID"
+// @Success 200 {object} SuccessResponse "Refreshed server and what the resync changed"
+// @Failure 400 {object} ErrorResponse "Server is not synced from the panel"
+// @Failure 404 {object} ErrorResponse "Server not found"
+// @Failure 502 {object} ErrorResponse "Panel request failed"
+// @Failure 503 {object} ErrorResponse "Panel not configured"
+// @Router /api/admin/servers/{id}/resync [post]
+func (h *AdminServerHandler) ResyncServer(c *fiber.Ctx) error {
+	if h.syncer == nil {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Pterodactyl panel not configured",
+		})
+	}
+
+	var pterodactylID *int
+	if err := h.db.Pool.QueryRow(c.Context(),
+		`SELECT "pterodactylId" FROM servers WHERE id = $1`, c.Params("id"),
+	).Scan(&pterodactylID); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Server not found",
+		})
+	}
+	if pterodactylID == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Server is not synced from the panel",
+		})
+	}
+
+	result, err := h.syncer.ResyncServer(c.Context(), *pterodactylID)
+	if err != nil {
+		log.Error().Err(err).Int("pterodactyl_id", *pterodactylID).Msg("Failed to resync server")
+		return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error": "Failed to resync server: " + err.Error(),
+		})
+	}
+
+	server, err := scanAdminServer(h.db.Pool.QueryRow(c.Context(), adminServerSelect+`
+		WHERE s.id = $1`, result.ServerID))
+	if err != nil {
+		log.Error().Err(err).Str("server_id", result.ServerID).Msg("Failed to fetch resynced server")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch resynced server",
+		})
 	}
 
-	totalPages := (totalCount + req.PageSize - 1) / req.PageSize
 	return c.JSON(fiber.Map{
 		"success": true,
-		"servers": servers,
-		"pagination": fiber.Map{
-			"page":       req.Page,
-			"pageSize":   req.PageSize,
-			"total":      totalCount,
-			"totalPages": totalPages,
-		},
+		"server":  server,
+		"resync":  result,
 	})
 }
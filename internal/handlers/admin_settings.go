@@ -1,10 +1,10 @@
 package handlers
 
 import (
-	"bytes"
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"regexp"
@@ -15,8 +15,12 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/rs/zerolog/log"
 
+	"github.com/nodebyte/backend/internal/config"
 	"github.com/nodebyte/backend/internal/crypto"
 	"github.com/nodebyte/backend/internal/database"
+	"github.com/nodebyte/backend/internal/discord"
+	"github.com/nodebyte/backend/internal/hytale"
+	"github.com/nodebyte/backend/internal/panels"
 )
 
 const MASKED_VALUE = "••••••••••••••••••••"
@@ -24,9 +28,11 @@ const MASKED_VALUE = "•••••••••••••••••••
 type AdminSettingsHandler struct {
 	db        *database.DB
 	encryptor *crypto.Encryptor
+	hytaleEnv *hytale.Environment
+	cfg       *config.Config
 }
 
-func NewAdminSettingsHandler(db *database.DB) *AdminSettingsHandler {
+func NewAdminSettingsHandler(db *database.DB, hytaleEnv *hytale.Environment, cfg *config.Config) *AdminSettingsHandler {
 	encryptor, err := crypto.NewEncryptorFromEnv()
 	if err != nil {
 		fmt.Printf("Warning: Encryption not configured: %v\n", err)
@@ -35,6 +41,8 @@ func NewAdminSettingsHandler(db *database.DB) *AdminSettingsHandler {
 	return &AdminSettingsHandler{
 		db:        db,
 		encryptor: encryptor,
+		hytaleEnv: hytaleEnv,
+		cfg:       cfg,
 	}
 }
 
@@ -76,6 +84,18 @@ type SystemSettings struct {
 	CacheTimeout int `json:"cacheTimeout"`
 	SyncInterval int `json:"syncInterval"`
 
+	// Token lifetimes in minutes (applied on restart)
+	VerificationTokenTTL  int `json:"verificationTokenTtl"`
+	PasswordResetTokenTTL int `json:"passwordResetTokenTtl"`
+	MagicLinkTokenTTL     int `json:"magicLinkTokenTtl"`
+
+	// Role given to new registrations (applied on restart)
+	DefaultRole string `json:"defaultRole"`
+
+	// Hytale staging services instead of production (applied immediately;
+	// omit to leave unchanged)
+	HytaleUseStaging *bool `json:"hytaleUseStaging"`
+
 	// Admin
 	AdminEmail string `json:"adminEmail"`
 	SiteName   string `json:"siteName"`
@@ -159,9 +179,17 @@ func (h *AdminSettingsHandler) GetAdminSettings(c *fiber.Ctx) error {
 	virtfusionStatus := h.testVirtfusionConnection(settings.VirtfusionUrl, settings.VirtfusionApiKey)
 	databaseStatus := h.testDatabaseConnection(c)
 
+	// Version lets the client detect concurrent edits when saving
+	version, err := h.db.GetConfigVersion(c.Context())
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to get settings version")
+	}
+	c.Set(fiber.HeaderLastModified, version.UTC().Format(http.TimeFormat))
+
 	return c.JSON(fiber.Map{
 		"success":           true,
 		"settings":          settings,
+		"version":           version.Format(time.RFC3339Nano),
 		"pterodactylStatus": pterodactylStatus,
 		"virtfusionStatus":  virtfusionStatus,
 		"databaseStatus":    databaseStatus,
@@ -178,31 +206,57 @@ func (h *AdminSettingsHandler) GetAdminSettings(c *fiber.Ctx) error {
 
 // SaveAdminSettings saves system settings
 // @Summary Save admin settings
-// @Description Updates configuration in Config table and handles GitHub repos merging
+// @Description Updates configuration in Config table and handles GitHub repos merging.
+// @Description Pass the version from GET (or an If-Unmodified-Since header) to reject the save with 409 if settings changed since they were loaded.
 // @Tags Admin Settings
 // @Accept json
 // @Produce json
 // @Param body body SystemSettings true "Settings to save"
 // @Success 200 {object} map[string]interface{} "Settings saved successfully"
-// @Failure 400 {object} map[string]string "Invalid request"
+// @Failure 400 {object} map[string]interface{} "Invalid request; invalid values are listed per field under fields"
 // @Failure 401 {object} map[string]string "Unauthorized"
+// @Failure 409 {object} map[string]interface{} "Settings changed since they were loaded"
 // @Failure 500 {object} map[string]string "Internal error"
 // @Router /api/admin/settings [post]
 // @Security Bearer
 func (h *AdminSettingsHandler) SaveAdminSettings(c *fiber.Ctx) error {
 	var req struct {
 		SystemSettings
-		GithubRepositoriesMerge bool `json:"githubRepositoriesMerge"`
+		GithubRepositoriesMerge bool   `json:"githubRepositoriesMerge"`
+		Version                 string `json:"version"`
 	}
 
 	if err := c.BodyParser(&req); err != nil {
 		log.Error().Err(err).Msg("Failed to parse admin settings request body")
+		if fields := settingsBodyFieldError(err); fields != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   "Invalid settings",
+				"fields":  fields,
+			})
+		}
 		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
 			"success": false,
 			"error":   fmt.Sprintf("Invalid request body: %v", err),
 		})
 	}
 
+	if fields := validateSystemSettings(req.SystemSettings); len(fields) > 0 {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   "Invalid settings",
+			"fields":  fields,
+		})
+	}
+
+	version, err := parseSettingsVersion(req.Version, c.Get(fiber.HeaderIfUnmodifiedSince))
+	if err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   fmt.Sprintf("Invalid settings version: %v", err),
+		})
+	}
+
 	// Get the admin user ID from context
 	userID, ok := c.Locals("userID").(string)
 	if !ok {
@@ -265,19 +319,51 @@ func (h *AdminSettingsHandler) SaveAdminSettings(c *fiber.Ctx) error {
 		settingsMap["github_repositories"] = string(reposJSON)
 	}
 
-	// Track changes: map of key -> {old: value, new: value}
-	changedFields := make(map[string]map[string]string)
+	// The panel URL must be a well-formed http(s) URL; store it normalized
+	if panelURL, ok := settingsMap["pterodactyl_url"]; ok {
+		normalized, err := panels.NormalizeBaseURL(panelURL)
+		if err != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   fmt.Sprintf("Invalid Pterodactyl URL: %v", err),
+			})
+		}
+		settingsMap["pterodactyl_url"] = normalized
+	}
 
-	// Save all configs
-	for key, value := range settingsMap {
-		oldValue := oldConfigs[key]
-		if err := h.db.SetConfig(c.Context(), key, value); err != nil {
-			log.Error().Err(err).Str("key", key).Msg("Failed to save config setting")
+	// The default role must reference the role catalog
+	if role, ok := settingsMap["default_role"]; ok {
+		if unknown, err := h.db.UnknownRoles(c.Context(), []string{role}); err != nil {
+			log.Error().Err(err).Msg("Failed to validate default role")
 			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
 				"success": false,
-				"error":   fmt.Sprintf("Failed to save setting %s: %v", key, err),
+				"error":   "Failed to validate default role",
+			})
+		} else if len(unknown) > 0 {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   fmt.Sprintf("Unknown default role: %s", role),
 			})
 		}
+	}
+
+	// Track changes: map of key -> {old: value, new: value}
+	changedFields := make(map[string]map[string]string)
+
+	// Save all configs, rejecting the save if another admin changed them first
+	if err := h.db.SetConfigsIfUnmodified(c.Context(), settingsMap, version); err != nil {
+		if errors.Is(err, database.ErrConfigModified) {
+			return h.settingsConflict(c)
+		}
+		log.Error().Err(err).Msg("Failed to save config settings")
+		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
+	for key, value := range settingsMap {
+		oldValue := oldConfigs[key]
 		// Track changes only if value actually changed
 		if oldValue != value {
 			changedFields[key] = map[string]string{
@@ -289,6 +375,14 @@ func (h *AdminSettingsHandler) SaveAdminSettings(c *fiber.Ctx) error {
 
 	log.Info().Str("userID", userID).Interface("changes", changedFields).Msg("Admin settings updated successfully")
 
+	// Switch the Hytale OAuth clients without a restart
+	if v, ok := settingsMap["hytale_use_staging"]; ok && h.hytaleEnv != nil {
+		if staging := parseBool(v); staging != h.hytaleEnv.Staging() {
+			h.hytaleEnv.SetStaging(staging)
+			log.Info().Str("userID", userID).Str("environment", h.hytaleEnv.Name()).Msg("Hytale environment switched")
+		}
+	}
+
 	// Get updated settings
 	updatedConfigs, err := h.db.GetAllConfigs(c.Context())
 	if err != nil {
@@ -300,16 +394,57 @@ func (h *AdminSettingsHandler) SaveAdminSettings(c *fiber.Ctx) error {
 	}
 	settings := h.configsToSettings(updatedConfigs)
 
+	newVersion, err := h.db.GetConfigVersion(c.Context())
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to get settings version")
+	}
+
 	// Dispatch webhook notification for settings update (non-blocking)
-	go h.dispatchSettingsUpdateWebhook(c.Context(), userID, changedFields)
+	go h.dispatchSettingsUpdateWebhook(context.Background(), userID, changedFields)
 
 	return c.JSON(fiber.Map{
 		"success":  true,
 		"message":  "Settings saved successfully",
 		"settings": settings,
+		"version":  newVersion.Format(time.RFC3339Nano),
+	})
+}
+
+// settingsConflict responds with 409 and the current settings so the client
+// can merge its changes and retry
+func (h *AdminSettingsHandler) settingsConflict(c *fiber.Ctx) error {
+	configs, err := h.db.GetAllConfigs(c.Context())
+	if err != nil {
+		configs = make(map[string]string)
+	}
+	version, _ := h.db.GetConfigVersion(c.Context())
+
+	return c.Status(http.StatusConflict).JSON(fiber.Map{
+		"success":  false,
+		"error":    "Settings were changed by another admin since you loaded them",
+		"settings": h.configsToSettings(configs),
+		"version":  version.Format(time.RFC3339Nano),
 	})
 }
 
+// parseSettingsVersion returns the settings version a save is based on, from
+// the body version or an If-Unmodified-Since header. A zero time means the
+// client sent neither and the save is unconditional.
+func parseSettingsVersion(bodyVersion, ifUnmodifiedSince string) (time.Time, error) {
+	if bodyVersion != "" {
+		return time.Parse(time.RFC3339Nano, bodyVersion)
+	}
+	if ifUnmodifiedSince != "" {
+		since, err := http.ParseTime(ifUnmodifiedSince)
+		if err != nil {
+			return time.Time{}, err
+		}
+		// HTTP dates have second precision, so allow the rest of that second
+		return since.Add(time.Second - time.Nanosecond), nil
+	}
+	return time.Time{}, nil
+}
+
 // ResetAdminSettings resets sensitive settings
 // @Summary Reset admin settings
 // @Description Clears sensitive API keys and tokens
@@ -639,12 +774,27 @@ func (h *AdminSettingsHandler) configsToSettings(configs map[string]string) Syst
 		DiscordNotifications:    parseBool(getValue(configs, "discord_notifications_enabled")),
 		CacheTimeout:            parseInt(getValue(configs, "cache_timeout"), 60),
 		SyncInterval:            parseInt(getValue(configs, "sync_interval"), 3600),
+		VerificationTokenTTL:    parseInt(getValue(configs, "verification_token_ttl"), int(database.TokenExpiration.Minutes())),
+		PasswordResetTokenTTL:   parseInt(getValue(configs, "password_reset_token_ttl"), int(database.TokenExpiration.Minutes())),
+		MagicLinkTokenTTL:       parseInt(getValue(configs, "magic_link_token_ttl"), int(database.MagicLinkExpiration.Minutes())),
+		DefaultRole:             getValue(configs, "default_role", config.DefaultRole),
+		HytaleUseStaging:        h.hytaleStaging(configs),
 		AdminEmail:              getValue(configs, "admin_email"),
 		SiteName:                getValue(configs, "site_name", "NodeByte Hosting"),
 		SiteUrl:                 getValue(configs, "site_url"),
 	}
 }
 
+// hytaleStaging reports the active Hytale mode, falling back to the stored
+// setting when no environment is wired in
+func (h *AdminSettingsHandler) hytaleStaging(configs map[string]string) *bool {
+	staging := parseBool(getValue(configs, "hytale_use_staging"))
+	if h.hytaleEnv != nil {
+		staging = h.hytaleEnv.Staging()
+	}
+	return &staging
+}
+
 func (h *AdminSettingsHandler) structToConfigMap(s SystemSettings) map[string]string {
 	configMap := make(map[string]string)
 
@@ -695,6 +845,22 @@ func (h *AdminSettingsHandler) structToConfigMap(s SystemSettings) map[string]st
 	configMap["cache_timeout"] = fmt.Sprintf("%d", s.CacheTimeout)
 	configMap["sync_interval"] = fmt.Sprintf("%d", s.SyncInterval)
 
+	if s.VerificationTokenTTL > 0 {
+		configMap["verification_token_ttl"] = fmt.Sprintf("%d", s.VerificationTokenTTL)
+	}
+	if s.PasswordResetTokenTTL > 0 {
+		configMap["password_reset_token_ttl"] = fmt.Sprintf("%d", s.PasswordResetTokenTTL)
+	}
+	if s.MagicLinkTokenTTL > 0 {
+		configMap["magic_link_token_ttl"] = fmt.Sprintf("%d", s.MagicLinkTokenTTL)
+	}
+	if role := database.NormalizeRoleName(s.DefaultRole); role != "" {
+		configMap["default_role"] = role
+	}
+	if s.HytaleUseStaging != nil {
+		configMap["hytale_use_staging"] = fmt.Sprintf("%v", *s.HytaleUseStaging)
+	}
+
 	if s.AdminEmail != "" {
 		configMap["admin_email"] = s.AdminEmail
 	}
@@ -867,28 +1033,15 @@ func (h *AdminSettingsHandler) dispatchSettingsUpdateWebhook(ctx context.Context
 	}
 
 	// Get all enabled SYSTEM webhooks
-	query := `
-		SELECT "webhookUrl" 
-		FROM discord_webhooks  
-		WHERE enabled = true  
-		AND type = 'SYSTEM'  
-		AND scope = 'ADMIN'
-	`
-
-	rows, err := h.db.Pool.Query(ctx, query)
+	webhooks, err := h.db.GetSyncNotificationWebhooks(ctx)
 	if err != nil {
 		log.Warn().Err(err).Msg("Failed to fetch webhooks for settings update")
 		return
 	}
-	defer rows.Close()
 
-	var webhookURLs []string
-	for rows.Next() {
-		var url string
-		if err := rows.Scan(&url); err != nil {
-			continue
-		}
-		webhookURLs = append(webhookURLs, url)
+	webhookURLs := make([]string, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		webhookURLs = append(webhookURLs, webhook.URL)
 	}
 
 	if len(webhookURLs) == 0 {
@@ -916,58 +1069,36 @@ func (h *AdminSettingsHandler) dispatchSettingsUpdateWebhook(ctx context.Context
 		changesText.WriteString(fmt.Sprintf("• **%s**\n  `%s` → `%s`\n", key, oldVal, newVal))
 	}
 
-	// Prepare webhook payload
-	payload := map[string]interface{}{
-		"embeds": []map[string]interface{}{
-			{
-				"title":       "⚙️ System Settings Updated",
-				"description": "Administrator has updated system configuration",
-				"color":       3066993, // Green
-				"fields": []map[string]interface{}{
-					{
-						"name":   "Modified By",
-						"value":  displayName,
-						"inline": true,
-					},
-					{
-						"name":   "Total Changes",
-						"value":  fmt.Sprintf("%d setting(s)", len(changedFields)),
-						"inline": true,
-					},
-					{
-						"name":   "Changed Settings",
-						"value":  changesText.String(),
-						"inline": false,
-					},
-					{
-						"name":   "Updated At",
-						"value":  time.Now().Format(time.RFC3339),
-						"inline": true,
-					},
-				},
-				"timestamp": time.Now().Format(time.RFC3339),
-				"footer": map[string]string{
-					"text": "NodeByte System",
-				},
+	// Prepare webhook message
+	message := discord.Message{
+		Embeds: []discord.Embed{{
+			Title:       "⚙️ System Settings Updated",
+			Description: "Administrator has updated system configuration",
+			Color:       discord.ColorSuccess,
+			Fields: []discord.EmbedField{
+				{Name: "Modified By", Value: displayName, Inline: true},
+				{Name: "Total Changes", Value: fmt.Sprintf("%d setting(s)", len(changedFields)), Inline: true},
+				{Name: "Changed Settings", Value: changesText.String()},
+				{Name: "Updated At", Value: time.Now().Format(time.RFC3339), Inline: true},
 			},
-		},
+			Timestamp: time.Now().Format(time.RFC3339),
+			Footer:    &discord.EmbedFooter{Text: "NodeByte System"},
+		}},
 	}
 
-	payloadBytes, _ := json.Marshal(payload)
-
-	// Send to all webhooks in parallel
-	for _, webhookURL := range webhookURLs {
-		go func(url string) {
-			resp, err := http.Post(url, "application/json", bytes.NewReader(payloadBytes))
-			if err != nil {
-				log.Warn().Err(err).Str("webhook_url", url).Msg("Failed to send settings update webhook")
-				return
-			}
-			defer resp.Body.Close()
-
-			if resp.StatusCode != http.StatusNoContent {
-				log.Warn().Int("status", resp.StatusCode).Str("webhook_url", url).Msg("Webhook returned non-204 status")
-			}
-		}(webhookURL)
+	// Send to WEBHOOK_CONCURRENCY webhooks at a time
+	client := &http.Client{Timeout: 10 * time.Second}
+	result := discord.Broadcast(ctx, client, webhookURLs, message, h.cfg.WebhookConcurrency)
+	for url, err := range result.Failed {
+		log.Warn().Err(err).Str("webhook_url", url).Msg("Failed to send settings update webhook")
+	}
+	for _, webhook := range webhooks {
+		if err := h.db.RecordWebhookDelivery(ctx, webhook.ID, "settings.updated", result.Failed[webhook.URL]); err != nil {
+			log.Warn().Err(err).Str("webhook_id", webhook.ID).Msg("Failed to record webhook delivery")
+		}
 	}
+	log.Info().
+		Int("sent", result.Sent).
+		Int("failed", len(result.Failed)).
+		Msg("Settings update webhooks dispatched")
 }
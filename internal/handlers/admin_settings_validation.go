@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+
+	"github.com/nodebyte/backend/internal/panels"
+)
+
+// Bounds for numeric admin settings
+const (
+	minCacheTimeout = 1     // seconds
+	maxCacheTimeout = 86400 // one day
+	minSyncInterval = 60    // seconds
+	maxSyncInterval = 604800
+	maxTokenTTL     = 10080 // minutes, one week
+)
+
+// validateSystemSettings checks a settings payload before it is saved and
+// returns an error message per invalid field, keyed by JSON field name.
+// Empty string settings are left unchanged by a save, so only non-empty
+// values are checked.
+func validateSystemSettings(s SystemSettings) map[string]string {
+	fields := map[string]string{}
+
+	if s.PterodactylUrl != "" {
+		if _, err := panels.NormalizeBaseURL(s.PterodactylUrl); err != nil {
+			fields["pterodactylUrl"] = err.Error()
+		}
+	}
+	checkSettingsURL(fields, "virtfusionUrl", s.VirtfusionUrl)
+	checkSettingsURL(fields, "siteUrl", s.SiteUrl)
+
+	checkSettingsRange(fields, "cacheTimeout", s.CacheTimeout, minCacheTimeout, maxCacheTimeout)
+	checkSettingsRange(fields, "syncInterval", s.SyncInterval, minSyncInterval, maxSyncInterval)
+
+	// Token lifetimes of 0 keep the current value
+	if s.VerificationTokenTTL != 0 {
+		checkSettingsRange(fields, "verificationTokenTtl", s.VerificationTokenTTL, 1, maxTokenTTL)
+	}
+	if s.PasswordResetTokenTTL != 0 {
+		checkSettingsRange(fields, "passwordResetTokenTtl", s.PasswordResetTokenTTL, 1, maxTokenTTL)
+	}
+	if s.MagicLinkTokenTTL != 0 {
+		checkSettingsRange(fields, "magicLinkTokenTtl", s.MagicLinkTokenTTL, 1, maxTokenTTL)
+	}
+
+	if s.AdminEmail != "" && validateEmail(s.AdminEmail) != nil {
+		fields["adminEmail"] = "must be a valid email address"
+	}
+
+	return fields
+}
+
+// checkSettingsURL records an error unless raw is empty or an absolute
+// http(s) URL
+func checkSettingsURL(fields map[string]string, name, raw string) {
+	if raw == "" {
+		return
+	}
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		fields[name] = "must be an absolute http or https URL"
+	}
+}
+
+// checkSettingsRange records an error unless low <= v <= high
+func checkSettingsRange(fields map[string]string, name string, v, low, high int) {
+	if v < low || v > high {
+		fields[name] = fmt.Sprintf("must be between %d and %d", low, high)
+	}
+}
+
+// settingsBodyFieldError maps a JSON type mismatch in the settings body to
+// the offending field, e.g. a string sent for syncInterval
+func settingsBodyFieldError(err error) map[string]string {
+	var typeErr *json.UnmarshalTypeError
+	if !errors.As(err, &typeErr) || typeErr.Field == "" {
+		return nil
+	}
+	return map[string]string{typeErr.Field: "must be a " + typeErr.Type.String()}
+}
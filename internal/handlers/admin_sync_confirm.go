@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+
+	"github.com/nodebyte/backend/internal/config"
+	"github.com/nodebyte/backend/internal/database"
+	"github.com/nodebyte/backend/internal/queue"
+)
+
+// syncConfirmationTTL is how long a destructive sync confirmation token stays valid
+const syncConfirmationTTL = 2 * time.Minute
+
+// SyncConfirmRequest carries the confirmation token for a sync cancel or resume
+type SyncConfirmRequest struct {
+	Confirm string `json:"confirm"` // token from the CONFIRMATION_REQUIRED response
+}
+
+// SyncImpact describes what a destructive sync action is about to do
+type SyncImpact struct {
+	Summary          string `json:"summary"`
+	StaleServers     *int   `json:"staleServers,omitempty"`
+	StaleNodes       *int   `json:"staleNodes,omitempty"`     // upper bound; referenced nodes are kept
+	StaleLocations   *int   `json:"staleLocations,omitempty"` // upper bound; referenced locations are kept
+	ServerTombstones bool   `json:"serverTombstones"`         // stale servers are marked deleted rather than removed
+}
+
+// syncPrunes lists the entities each sync type removes when the panel no
+// longer reports them. A servers sync also retries node and location cleanup
+// afterwards.
+var syncPrunes = map[string][]string{
+	"full":      {"servers", "nodes", "locations"},
+	"locations": {"locations"},
+	"nodes":     {"nodes"},
+	"servers":   {"servers", "nodes", "locations"},
+}
+
+// batchPrunes returns the entities a batch with the given steps removes
+func batchPrunes(steps []string) []string {
+	prunes := map[string]bool{}
+	for _, step := range steps {
+		switch step {
+		case "servers":
+			prunes["servers"], prunes["nodes"], prunes["locations"] = true, true, true
+		case "nodes", "locations":
+			prunes[step] = true
+		}
+	}
+	var entities []string
+	for _, entity := range []string{"servers", "nodes", "locations"} {
+		if prunes[entity] {
+			entities = append(entities, entity)
+		}
+	}
+	return entities
+}
+
+// syncConfirmActor identifies who a confirmation token is issued to: the
+// signed-in admin, or the API key on API-key routes
+func syncConfirmActor(c *fiber.Ctx) string {
+	if userID, _ := c.Locals("userID").(string); userID != "" {
+		return userID
+	}
+	if keyID, _ := c.Locals("apiKeyID").(string); keyID != "" {
+		return "apikey:" + keyID
+	}
+	label, _ := c.Locals("apiKeyLabel").(string)
+	return "apikey:" + label
+}
+
+// syncConfirmationStore issues and consumes sync confirmation tokens;
+// *database.DB implements it
+type syncConfirmationStore interface {
+	CreateSyncConfirmation(ctx context.Context, action, target, actorID string, ttl time.Duration) (string, time.Time, error)
+	ConsumeSyncConfirmation(ctx context.Context, token, action, target, actorID string) (bool, error)
+}
+
+// requireSyncConfirmation gates a destructive sync action when
+// SYNC_CONFIRM_DESTRUCTIVE is on. Without a token it answers 428 with a new
+// token and the impact; with one it checks the token was issued to this
+// caller for the same action and target. It returns true when the action may
+// proceed; otherwise the response has been written.
+func requireSyncConfirmation(c *fiber.Ctx, store syncConfirmationStore, cfg *config.Config, action, target, token string, impact func(ctx context.Context) SyncImpact) (bool, error) {
+	if cfg == nil || !cfg.SyncConfirmDestructive {
+		return true, nil
+	}
+	actorID := syncConfirmActor(c)
+
+	if token != "" {
+		ok, err := store.ConsumeSyncConfirmation(c.Context(), token, action, target, actorID)
+		if err != nil {
+			log.Error().Err(err).Str("action", action).Msg("Failed to check sync confirmation")
+			return false, c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+				Success: false,
+				Error:   "Failed to check confirmation",
+			})
+		}
+		if !ok {
+			return false, c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Success: false,
+				Error:   "Confirmation token is invalid, expired, already used or was issued for another action",
+				Code:    "CONFIRMATION_INVALID",
+			})
+		}
+		return true, nil
+	}
+
+	token, expiresAt, err := store.CreateSyncConfirmation(c.Context(), action, target, actorID, syncConfirmationTTL)
+	if err != nil {
+		log.Error().Err(err).Str("action", action).Msg("Failed to create sync confirmation")
+		return false, c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Failed to create confirmation",
+		})
+	}
+	return false, c.Status(fiber.StatusPreconditionRequired).JSON(fiber.Map{
+		"success":           false,
+		"error":             "This action is destructive; send the confirmation token back as confirm to proceed",
+		"code":              "CONFIRMATION_REQUIRED",
+		"confirmationToken": token,
+		"expiresAt":         expiresAt,
+		"impact":            impact(c.Context()),
+	})
+}
+
+// staleSyncTypes lists the sync types that refresh each pruned entity
+var staleSyncTypes = map[string][]string{
+	"servers":   {"full", "servers"},
+	"nodes":     {"full", "nodes"},
+	"locations": {"full", "locations"},
+}
+
+// pruneImpact previews how many stale rows a sync would prune from local
+// tables alone: rows the last completed sync covering the entity did not
+// refresh. Anything removed from the panel since that sync is not counted,
+// and entities that were never fully synced are left out of the counts.
+func pruneImpact(ctx context.Context, db *database.DB, cfg *config.Config, entities []string) SyncImpact {
+	impact := SyncImpact{ServerTombstones: cfg.SyncServerTombstones}
+
+	var parts []string
+	var unknown []string
+	for _, entity := range entities {
+		count, err := countStale(ctx, db, cfg, entity)
+		if err != nil || count == nil {
+			if err != nil {
+				log.Warn().Err(err).Str("entity", entity).Msg("Failed to count stale entities for sync confirmation")
+			}
+			unknown = append(unknown, entity)
+			continue
+		}
+		switch entity {
+		case "servers":
+			impact.StaleServers = count
+			if impact.ServerTombstones {
+				parts = append(parts, fmt.Sprintf("mark %d stale servers deleted", *count))
+			} else {
+				parts = append(parts, fmt.Sprintf("prune %d stale servers", *count))
+			}
+		case "nodes":
+			impact.StaleNodes = count
+			parts = append(parts, fmt.Sprintf("prune up to %d stale nodes", *count))
+		case "locations":
+			impact.StaleLocations = count
+			parts = append(parts, fmt.Sprintf("prune up to %d stale locations", *count))
+		}
+	}
+
+	switch {
+	case len(parts) == 0:
+		impact.Summary = fmt.Sprintf("This will prune stale %s no longer on the panel; they could not be counted", strings.Join(unknown, ", "))
+	case len(unknown) > 0:
+		impact.Summary = fmt.Sprintf("This will %s, and prune stale %s that could not be counted", strings.Join(parts, ", "), strings.Join(unknown, ", "))
+	default:
+		impact.Summary = "This will " + strings.Join(parts, ", ")
+	}
+	if len(parts) > 0 {
+		impact.Summary += ", as of the last completed sync, plus anything removed from the panel since"
+	}
+	return impact
+}
+
+// countStale counts local rows of entity the last completed sync covering it
+// did not refresh. It returns nil when no such sync has completed.
+func countStale(ctx context.Context, db *database.DB, cfg *config.Config, entity string) (*int, error) {
+	since, err := db.LastCompletedSyncStart(ctx, staleSyncTypes[entity])
+	if err != nil || since == nil {
+		return nil, err
+	}
+
+	var count int
+	switch entity {
+	case "servers":
+		count, err = db.CountStaleServers(ctx, *since, cfg.SyncServerTombstones)
+	case "nodes":
+		count, err = db.CountStaleNodes(ctx, *since)
+	case "locations":
+		count, err = db.CountStaleLocations(ctx, *since)
+	default:
+		return nil, fmt.Errorf("unknown entity %q", entity)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &count, nil
+}
+
+// resumeSteps returns the full sync steps a resume from startStep runs
+func resumeSteps(startStep string) []string {
+	if i := queue.FullSyncStepIndex(startStep); i >= 0 {
+		return queue.FullSyncSteps[i:]
+	}
+	return nil
+}
+
+// cancelImpact describes the sync a cancel would stop
+func cancelImpact(syncLog database.SyncLog) SyncImpact {
+	summary := fmt.Sprintf("This will cancel the %s %s sync started %s", strings.ToLower(syncLog.Status), syncLog.Type, syncLog.StartedAt.UTC().Format(time.RFC3339))
+	if len(syncLog.Steps) > 0 {
+		completed := 0
+		for _, step := range syncLog.Steps {
+			if step.Status == "COMPLETED" {
+				completed++
+			}
+		}
+		summary += fmt.Sprintf(" after %d completed steps; the running step stops partway", completed)
+	}
+	return SyncImpact{Summary: summary}
+}
+
+// releaseImpact describes the sync a lock release would fail
+func releaseImpact(active database.SyncLog) SyncImpact {
+	return SyncImpact{Summary: fmt.Sprintf(
+		"This will mark the %s %s sync started %s, and any other unfinished sync, as FAILED",
+		strings.ToLower(active.Status), active.Type, active.StartedAt.UTC().Format(time.RFC3339),
+	)}
+}
@@ -0,0 +1,142 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/nodebyte/backend/internal/config"
+	"github.com/nodebyte/backend/internal/database"
+)
+
+func TestBatchPrunes(t *testing.T) {
+	tests := []struct {
+		steps []string
+		want  []string
+	}{
+		{steps: nil, want: nil},
+		{steps: []string{"allocations", "nests", "users"}, want: nil},
+		{steps: []string{"locations"}, want: []string{"locations"}},
+		{steps: []string{"nodes", "locations"}, want: []string{"nodes", "locations"}},
+		{steps: []string{"servers"}, want: []string{"servers", "nodes", "locations"}},
+		{steps: []string{"users", "servers", "nodes"}, want: []string{"servers", "nodes", "locations"}},
+	}
+	for _, tt := range tests {
+		if got := batchPrunes(tt.steps); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("batchPrunes(%v) = %v, want %v", tt.steps, got, tt.want)
+		}
+	}
+}
+
+func TestResumeSteps(t *testing.T) {
+	tests := []struct {
+		start string
+		want  []string
+	}{
+		{start: "locations", want: []string{"locations", "nodes", "allocations", "nests", "users", "servers", "subusers"}},
+		{start: "users", want: []string{"users", "servers", "subusers"}},
+		{start: "subusers", want: []string{"subusers"}},
+		{start: "starting", want: nil},
+		{start: "", want: nil},
+	}
+	for _, tt := range tests {
+		if got := resumeSteps(tt.start); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("resumeSteps(%q) = %v, want %v", tt.start, got, tt.want)
+		}
+	}
+
+	// A resume past the servers step no longer prunes anything
+	if got := batchPrunes(resumeSteps("subusers")); got != nil {
+		t.Errorf("batchPrunes(resumeSteps(subusers)) = %v, want nil", got)
+	}
+}
+
+// memorySyncConfirmations is an in-memory syncConfirmationStore
+type memorySyncConfirmations struct {
+	tokens map[string]string // token -> action|target|actor
+	used   map[string]bool
+}
+
+func (m *memorySyncConfirmations) CreateSyncConfirmation(_ context.Context, action, target, actorID string, _ time.Duration) (string, time.Time, error) {
+	token := fmt.Sprintf("token-%d", len(m.tokens))
+	m.tokens[token] = action + "|" + target + "|" + actorID
+	return token, time.Now().Add(syncConfirmationTTL), nil
+}
+
+func (m *memorySyncConfirmations) ConsumeSyncConfirmation(_ context.Context, token, action, target, actorID string) (bool, error) {
+	if m.used[token] || m.tokens[token] != action+"|"+target+"|"+actorID {
+		return false, nil
+	}
+	m.used[token] = true
+	return true, nil
+}
+
+func TestRequireSyncConfirmationConsumesTokens(t *testing.T) {
+	store := &memorySyncConfirmations{tokens: map[string]string{}, used: map[string]bool{}}
+	cfg := &config.Config{SyncConfirmDestructive: true}
+
+	app := fiber.New()
+	app.Post("/:actor/:target", func(c *fiber.Ctx) error {
+		c.Locals("userID", c.Params("actor"))
+		impact := func(context.Context) SyncImpact { return SyncImpact{Summary: "impact"} }
+		if ok, err := requireSyncConfirmation(c, store, cfg, database.SyncConfirmSync, c.Params("target"), c.Query("confirm"), impact); !ok {
+			return err
+		}
+		return c.SendStatus(fiber.StatusAccepted)
+	})
+
+	post := func(path string) (int, map[string]any) {
+		t.Helper()
+		resp, err := app.Test(httptest.NewRequest(fiber.MethodPost, path, nil))
+		if err != nil {
+			t.Fatalf("POST %s: %v", path, err)
+		}
+		var body map[string]any
+		_ = json.NewDecoder(resp.Body).Decode(&body)
+		return resp.StatusCode, body
+	}
+
+	status, body := post("/admin-1/servers")
+	if status != fiber.StatusPreconditionRequired || body["code"] != "CONFIRMATION_REQUIRED" {
+		t.Fatalf("first request = %d %v, want 428 CONFIRMATION_REQUIRED", status, body["code"])
+	}
+	token, _ := body["confirmationToken"].(string)
+	if token == "" {
+		t.Fatal("428 response carries no confirmation token")
+	}
+
+	for _, tt := range []struct {
+		name string
+		path string
+		want int
+	}{
+		{name: "other target", path: "/admin-1/full?confirm=" + token, want: fiber.StatusBadRequest},
+		{name: "other actor", path: "/admin-2/servers?confirm=" + token, want: fiber.StatusBadRequest},
+		{name: "matching token", path: "/admin-1/servers?confirm=" + token, want: fiber.StatusAccepted},
+		{name: "reused token", path: "/admin-1/servers?confirm=" + token, want: fiber.StatusBadRequest},
+	} {
+		if status, _ := post(tt.path); status != tt.want {
+			t.Errorf("%s: status = %d, want %d", tt.name, status, tt.want)
+		}
+	}
+
+	cfg.SyncConfirmDestructive = false
+	if status, _ := post("/admin-1/servers"); status != fiber.StatusAccepted {
+		t.Errorf("with confirmations off: status = %d, want %d", status, fiber.StatusAccepted)
+	}
+}
+
+func TestReleaseImpact(t *testing.T) {
+	active := database.SyncLog{Type: "full", Status: "RUNNING", StartedAt: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)}
+	got := releaseImpact(active).Summary
+	if !strings.Contains(got, "running full sync started 2026-01-02T03:04:05Z") {
+		t.Errorf("releaseImpact summary = %q", got)
+	}
+}
@@ -61,7 +61,7 @@ func (h *SyncStreamHandler) validateQueryToken(db *database.DB, token string) (s
 // set custom headers on EventSource connections.
 //
 // @Summary Stream sync progress (SSE)
-// @Description Streams live sync log updates as Server-Sent Events until the sync reaches a terminal state
+// @Description Streams live sync log updates as Server-Sent Events until the sync reaches a terminal state. Each update carries the running step's rolling rate and estimated seconds remaining under throughput.
 // @Tags Admin
 // @Produce text/event-stream
 // @Param id path string true "Sync log ID"
@@ -157,6 +157,8 @@ func (h *SyncStreamHandler) StreamSyncProgress(c *fiber.Ctx) error {
 				"status":      syncLog.Status,
 				"itemsTotal":  syncLog.ItemsTotal,
 				"itemsSynced": syncLog.ItemsSynced,
+				"steps":       syncLog.Steps,
+				"throughput":  syncLog.Throughput,
 				"metadata":    meta,
 			})
 
@@ -0,0 +1,342 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+
+	"github.com/nodebyte/backend/internal/auth"
+	"github.com/nodebyte/backend/internal/config"
+	"github.com/nodebyte/backend/internal/database"
+	"github.com/nodebyte/backend/internal/queue"
+)
+
+const (
+	// maxImportRows caps the number of data rows accepted in one import
+	maxImportRows = 5000
+	// importBatchSize is the number of users created per transaction
+	importBatchSize = 100
+)
+
+// Per-row import outcomes
+const (
+	importStatusCreated = "created"
+	importStatusSkipped = "skipped"
+	importStatusError   = "error"
+)
+
+// AdminUserImportHandler handles bulk user imports
+type AdminUserImportHandler struct {
+	db           *database.DB
+	queueManager *queue.Manager
+	cfg          *config.Config
+}
+
+// NewAdminUserImportHandler creates a new admin user import handler
+func NewAdminUserImportHandler(db *database.DB, queueManager *queue.Manager, cfg *config.Config) *AdminUserImportHandler {
+	return &AdminUserImportHandler{db: db, queueManager: queueManager, cfg: cfg}
+}
+
+// UserImportRowResult is the outcome of importing a single CSV row
+type UserImportRowResult struct {
+	Row     int    `json:"row"` // 1-based line number in the CSV, including the header
+	Email   string `json:"email,omitempty"`
+	Status  string `json:"status"`
+	UserID  string `json:"userId,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// importRow is a validated CSV row waiting to be inserted
+type importRow struct {
+	result *UserImportRowResult
+	user   database.ImportedUser
+}
+
+// ImportUsers creates users from a CSV file
+// @Summary Import users from CSV (admin)
+// @Description Creates users from a CSV with an email column and optional username, firstName, lastName and roles columns (multiple roles separated by ";"). Imported users have no password and must set one through the emailed password reset link. Existing emails are skipped and SUPER_ADMIN rows are rejected unless the caller is a system admin. Up to 5000 rows; users are created in transactions of 100.
+// @Tags Admin
+// @Accept multipart/form-data
+// @Accept text/csv
+// @Produce json
+// @Security BearerAuth
+// @Param file formData file false "CSV file (or send the CSV as the request body)"
+// @Param sendEmails query bool false "Email each created user a password setup link (default true)"
+// @Success 200 {object} SuccessResponse "Per-row import results"
+// @Failure 400 {object} ErrorResponse "Invalid CSV"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/users/import [post]
+func (h *AdminUserImportHandler) ImportUsers(c *fiber.Ctx) error {
+	data, err := importCSVData(c)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	header, records, err := parseImportCSV(data)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	defaultRole := h.defaultRole(c)
+	callerSystemAdmin, _ := c.Locals("isSystemAdmin").(bool)
+	results := make([]*UserImportRowResult, 0, len(records))
+	var pending []importRow
+	seen := make(map[string]bool, len(records))
+
+	for i, record := range records {
+		result := &UserImportRowResult{Row: i + 2}
+		results = append(results, result)
+
+		row, msg := parseImportRecord(header, record)
+		result.Email = row.Email
+		switch {
+		case msg != "":
+			result.Status = importStatusError
+			result.Message = msg
+			continue
+		case seen[row.Email]:
+			result.Status = importStatusSkipped
+			result.Message = "Duplicate email in file"
+			continue
+		}
+		seen[row.Email] = true
+
+		defaulted := len(row.Roles) == 0
+		if defaulted {
+			row.Roles = []string{defaultRole}
+		}
+		if msg := importRolesDenied(row.Roles, callerSystemAdmin); msg != "" {
+			result.Status = importStatusError
+			result.Message = msg
+			continue
+		}
+		if !defaulted {
+			unknown, err := h.db.UnknownRoles(c.Context(), row.Roles)
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to validate roles")
+				return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error": "Failed to validate roles",
+				})
+			}
+			if len(unknown) > 0 {
+				result.Status = importStatusError
+				result.Message = fmt.Sprintf("Invalid role: %s", strings.Join(unknown, ", "))
+				continue
+			}
+		}
+
+		pending = append(pending, importRow{result: result, user: row})
+	}
+
+	sendEmails := c.QueryBool("sendEmails", true)
+	for start := 0; start < len(pending); start += importBatchSize {
+		batch := pending[start:min(start+importBatchSize, len(pending))]
+		h.importBatch(c, batch, sendEmails)
+	}
+
+	summary := map[string]int{importStatusCreated: 0, importStatusSkipped: 0, importStatusError: 0}
+	for _, r := range results {
+		summary[r.Status]++
+	}
+
+	adminID, _ := c.Locals("userID").(string)
+	if err := h.db.CreateAdminAuditLog(c.Context(), database.AdminAuditEntry{
+		ActorID:    adminID,
+		Action:     database.AuditUsersImported,
+		TargetType: "user",
+		Details: map[string]interface{}{
+			"rows":    len(results),
+			"created": summary[importStatusCreated],
+			"skipped": summary[importStatusSkipped],
+			"errors":  summary[importStatusError],
+		},
+		IPAddress: c.IP(),
+		UserAgent: c.Get("User-Agent"),
+	}); err != nil {
+		log.Error().Err(err).Msg("Failed to write user import audit log")
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"summary": summary,
+		"results": results,
+	})
+}
+
+// importBatch creates one batch of users in a single transaction and queues
+// password setup emails for the ones that were created
+func (h *AdminUserImportHandler) importBatch(c *fiber.Ctx, batch []importRow, sendEmails bool) {
+	users := make([]database.ImportedUser, len(batch))
+	for i, row := range batch {
+		users[i] = row.user
+	}
+
+	created, err := h.db.CreateImportedUsers(c.Context(), users)
+	if err != nil {
+		log.Error().Err(err).Int("users", len(users)).Msg("Failed to import user batch")
+		for _, row := range batch {
+			row.result.Status = importStatusError
+			row.result.Message = "Failed to create user; batch rolled back"
+		}
+		return
+	}
+
+	resetTTL := h.cfg.TokenTTL(database.PasswordResetTokenType)
+	for _, row := range batch {
+		id, ok := created[row.user.Email]
+		if !ok {
+			row.result.Status = importStatusSkipped
+			row.result.Message = "User already exists"
+			continue
+		}
+		row.result.Status = importStatusCreated
+		row.result.UserID = id
+
+		if !sendEmails || h.queueManager == nil {
+			continue
+		}
+		token, err := h.db.StoreVerificationToken(c.Context(), id, database.PasswordResetTokenType, resetTTL)
+		if err != nil {
+			log.Error().Err(err).Str("user_id", id).Msg("Failed to generate password setup token")
+			row.result.Message = "Created, but the password setup email could not be sent"
+			continue
+		}
+		if _, err := h.queueManager.EnqueueEmail(queue.EmailPayload{
+			To:       row.user.Email,
+			Subject:  "Your NodeByte account is ready",
+			Template: "account-imported",
+			Data: map[string]string{
+				"name":      row.user.FirstName,
+				"token":     token,
+				"email":     row.user.Email,
+				"userId":    id,
+				"expiresIn": formatTokenTTL(resetTTL),
+			},
+		}); err != nil {
+			log.Error().Err(err).Str("user_id", id).Msg("Failed to queue password setup email")
+			row.result.Message = "Created, but the password setup email could not be sent"
+		}
+	}
+}
+
+// defaultRole returns the configured default role, falling back to MEMBER
+// when it is not in the role catalog
+func (h *AdminUserImportHandler) defaultRole(c *fiber.Ctx) string {
+	role := config.DefaultRole
+	if h.cfg != nil && h.cfg.DefaultRole != "" {
+		role = h.cfg.DefaultRole
+	}
+	if role == config.DefaultRole {
+		return role
+	}
+
+	unknown, err := h.db.UnknownRoles(c.Context(), []string{role})
+	if err != nil || len(unknown) > 0 {
+		return config.DefaultRole
+	}
+	return role
+}
+
+// importRolesDenied returns why the caller may not import a user with roles,
+// if they may not. SUPER_ADMIN makes the user a system admin, so only system
+// admins may hand it out.
+func importRolesDenied(roles []string, callerSystemAdmin bool) string {
+	if auth.HasSuperAdminRole(roles) && !callerSystemAdmin {
+		return "Only system admins can import SUPER_ADMIN users"
+	}
+	return ""
+}
+
+// importCSVData reads the CSV from a multipart "file" field or the raw body
+func importCSVData(c *fiber.Ctx) ([]byte, error) {
+	if fh, err := c.FormFile("file"); err == nil {
+		f, err := fh.Open()
+		if err != nil {
+			return nil, errors.New("Failed to read uploaded file")
+		}
+		defer f.Close()
+		return io.ReadAll(f)
+	}
+
+	body := c.Body()
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, errors.New("CSV file is required")
+	}
+	return body, nil
+}
+
+// parseImportCSV parses the CSV and maps lower-cased header names to column
+// indexes. An email column is required.
+func parseImportCSV(data []byte) (map[string]int, [][]string, error) {
+	reader := csv.NewReader(bytes.NewReader(bytes.TrimPrefix(data, []byte("\ufeff"))))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("Invalid CSV: %v", err)
+	}
+	if len(records) == 0 {
+		return nil, nil, errors.New("CSV file is empty")
+	}
+
+	header := make(map[string]int, len(records[0]))
+	for i, name := range records[0] {
+		header[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := header["email"]; !ok {
+		return nil, nil, errors.New("CSV header must include an email column")
+	}
+
+	rows := records[1:]
+	if len(rows) > maxImportRows {
+		return nil, nil, fmt.Errorf("CSV has %d rows; at most %d are allowed", len(rows), maxImportRows)
+	}
+	return header, rows, nil
+}
+
+// parseImportRecord builds a user from a CSV record, returning a message
+// describing why the row is invalid, if it is
+func parseImportRecord(header map[string]int, record []string) (database.ImportedUser, string) {
+	field := func(name string) string {
+		i, ok := header[strings.ToLower(name)]
+		if !ok || i >= len(record) {
+			return ""
+		}
+		return strings.TrimSpace(record[i])
+	}
+
+	user := database.ImportedUser{
+		Email:     strings.ToLower(field("email")),
+		Username:  field("username"),
+		FirstName: field("firstName"),
+		LastName:  field("lastName"),
+	}
+
+	if user.Email == "" {
+		return user, "Email is required"
+	}
+	if err := validateEmail(user.Email); err != nil {
+		return user, "Invalid email"
+	}
+	if user.Username == "" {
+		user.Username, _, _ = strings.Cut(user.Email, "@")
+	}
+
+	for _, role := range strings.FieldsFunc(field("roles"), func(r rune) bool { return r == ';' || r == '|' }) {
+		if role = database.NormalizeRoleName(role); role != "" {
+			user.Roles = append(user.Roles, role)
+		}
+	}
+	return user, ""
+}
@@ -0,0 +1,38 @@
+package handlers
+
+import "testing"
+
+func TestImportRolesDenied(t *testing.T) {
+	tests := []struct {
+		name        string
+		roles       []string
+		systemAdmin bool
+		denied      bool
+	}{
+		{name: "member by admin", roles: []string{"MEMBER"}, systemAdmin: false, denied: false},
+		{name: "super admin by admin", roles: []string{"MEMBER", "SUPER_ADMIN"}, systemAdmin: false, denied: true},
+		{name: "super admin by system admin", roles: []string{"SUPER_ADMIN"}, systemAdmin: true, denied: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := importRolesDenied(tt.roles, tt.systemAdmin) != ""; got != tt.denied {
+				t.Errorf("importRolesDenied(%v, %v) denied = %v, want %v", tt.roles, tt.systemAdmin, got, tt.denied)
+			}
+		})
+	}
+}
+
+func TestParseImportRecordRoles(t *testing.T) {
+	header := map[string]int{"email": 0, "roles": 1}
+	user, msg := parseImportRecord(header, []string{"Admin@Example.com", "super_admin; member"})
+	if msg != "" {
+		t.Fatalf("unexpected error: %s", msg)
+	}
+	if user.Email != "admin@example.com" || user.Username != "admin" {
+		t.Errorf("user = %+v", user)
+	}
+	if importRolesDenied(user.Roles, false) == "" {
+		t.Errorf("roles %v from the CSV were not denied to a non system admin", user.Roles)
+	}
+}
@@ -0,0 +1,86 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+
+	"github.com/nodebyte/backend/internal/database"
+)
+
+// MarkUserMigratedRequest sets a user's migrated flag
+type MarkUserMigratedRequest struct {
+	Migrated *bool `json:"migrated"` // defaults to true; false clears the flag
+	Force    bool  `json:"force"`    // mark a user who does not meet the criteria
+}
+
+// MarkUserMigrated sets or clears a user's migrated flag
+// @Summary Mark a user as migrated (admin)
+// @Description Sets a user's migrated flag, which the user stats count. A user counts as migrated once their account is linked to a panel account and their email is verified; the user sync flags such users automatically. Marking a user who does not meet both criteria requires force. Send migrated=false to clear the flag; the sync never clears it.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Param body body MarkUserMigratedRequest false "Migration state (defaults to migrated)"
+// @Success 200 {object} SuccessResponse "Updated migration state"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 409 {object} ErrorResponse "User does not meet the migration criteria"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/users/{id}/migrate [post]
+func (h *AdminUserHandler) MarkUserMigrated(c *fiber.Ctx) error {
+	var req MarkUserMigratedRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "Invalid request body"})
+		}
+	}
+	migrated := req.Migrated == nil || *req.Migrated
+
+	userID := c.Params("id")
+	state, err := h.db.GetUserMigrationState(c.Context(), userID)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID).Msg("Failed to fetch user migration state")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch user",
+		})
+	}
+	if state == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+	if migrated && !state.Eligible() && !req.Force {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":         "User is not linked to a panel account with a verified email; set force to mark them anyway",
+			"panelLinked":   state.PanelLinked,
+			"emailVerified": state.EmailVerified,
+		})
+	}
+
+	if _, err := h.db.SetUserMigrated(c.Context(), userID, migrated); err != nil {
+		log.Error().Err(err).Str("user_id", userID).Msg("Failed to set user migration state")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update user",
+		})
+	}
+
+	actorID, _ := c.Locals("userID").(string)
+	if err := h.db.CreateAdminAuditLog(c.Context(), database.AdminAuditEntry{
+		ActorID:    actorID,
+		Action:     database.AuditUserMigrationSet,
+		TargetType: "user",
+		TargetID:   userID,
+		Details:    map[string]interface{}{"migrated": migrated, "previous": state.IsMigrated, "forced": migrated && !state.Eligible()},
+		IPAddress:  c.IP(),
+		UserAgent:  c.Get("User-Agent"),
+	}); err != nil {
+		log.Error().Err(err).Str("user_id", userID).Msg("Failed to write user migration audit log")
+	}
+
+	state.IsMigrated = migrated
+	return c.JSON(fiber.Map{
+		"success":   true,
+		"migration": state,
+	})
+}
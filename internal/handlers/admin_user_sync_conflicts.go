@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+)
+
+// GetUserSyncConflicts lists panel users the user sync refused to merge
+// @Summary List user sync conflicts (admin)
+// @Description Lists panel users the user sync left unwritten because they collided with a local user: EMAIL_TAKEN when the email belongs to a local user linked to another panel account, EMAIL_CHANGED when the panel account is linked to a local user with another email. A resync of the local user (POST /api/admin/users/{id}/resync) applies an email change. Open conflicts only unless includeResolved is set.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param includeResolved query bool false "Include resolved conflicts"
+// @Param limit query int false "Page size (default 25)" Default(25) Minimum(1) Maximum(100)
+// @Param offset query int false "Offset for pagination (default 0)" Default(0) Minimum(0)
+// @Success 200 {object} SuccessResponse "Conflicts retrieved"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/users/sync-conflicts [get]
+func (h *AdminUserHandler) GetUserSyncConflicts(c *fiber.Ctx) error {
+	pagination := parsePagination(c, h.pageLimits)
+
+	conflicts, total, err := h.db.ListUserSyncConflicts(c.Context(), c.QueryBool("includeResolved"), pagination.Limit, pagination.Offset)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to list user sync conflicts")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch user sync conflicts",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success":   true,
+		"conflicts": conflicts,
+		"meta":      pagination.Meta(total),
+	})
+}
+
+// ResolveUserSyncConflict marks a user sync conflict as reviewed
+// @Summary Resolve a user sync conflict (admin)
+// @Description Marks an open conflict resolved once the accounts have been fixed. The sync records it again if the collision is still there on its next run.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path int true "Conflict ID"
+// @Success 200 {object} SuccessResponse "Conflict resolved"
+// @Failure 400 {object} ErrorResponse "Invalid conflict ID"
+// @Failure 404 {object} ErrorResponse "No open conflict with that ID"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/users/sync-conflicts/{id}/resolve [post]
+func (h *AdminUserHandler) ResolveUserSyncConflict(c *fiber.Ctx) error {
+	id, err := strconv.ParseInt(c.Params("id"), 10, 64)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid conflict ID",
+		})
+	}
+
+	actorID, _ := c.Locals("userID").(string)
+	resolved, err := h.db.ResolveUserSyncConflict(c.Context(), id, actorID)
+	if err != nil {
+		log.Error().Err(err).Int64("conflict_id", id).Msg("Failed to resolve user sync conflict")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to resolve conflict",
+		})
+	}
+	if !resolved {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "No open conflict with that ID",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"message": "Conflict resolved",
+	})
+}
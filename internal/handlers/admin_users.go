@@ -2,22 +2,39 @@ package handlers
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"strings"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+
+	"github.com/nodebyte/backend/internal/auth"
+	"github.com/nodebyte/backend/internal/config"
 	"github.com/nodebyte/backend/internal/database"
+	"github.com/nodebyte/backend/internal/queue"
 )
 
+// impersonationTokenTTL is how long an impersonation token stays valid
+const impersonationTokenTTL = 15 * time.Minute
+
+// overviewSessionLimit is how many recent sessions a user overview lists
+const overviewSessionLimit = 10
+
 // AdminUserHandler handles admin user operations
 type AdminUserHandler struct {
-	db *database.DB
+	db           *database.DB
+	jwtService   *auth.JWTService
+	queueManager *queue.Manager
+	cfg          *config.Config
+	pageLimits   PageLimits
 }
 
 // NewAdminUserHandler creates a new admin user handler
-func NewAdminUserHandler(db *database.DB) *AdminUserHandler {
-	return &AdminUserHandler{db: db}
+func NewAdminUserHandler(db *database.DB, jwtService *auth.JWTService, queueManager *queue.Manager, cfg *config.Config) *AdminUserHandler {
+	return &AdminUserHandler{db: db, jwtService: jwtService, queueManager: queueManager, cfg: cfg, pageLimits: configuredPageLimits(cfg)}
 }
 
 // AdminUserResponse represents a user for admin view
@@ -44,32 +61,22 @@ type AdminUserResponse struct {
 
 // GetUsersRequest represents pagination and filter parameters
 type GetUsersRequest struct {
-	Search   string `query:"search"`
-	Filter   string `query:"filter"` // all, migrated, active, admin, inactive
-	Sort     string `query:"sort"`   // email, created
-	Order    string `query:"order"`  // asc, desc
-	Page     int    `query:"page"`
-	PageSize int    `query:"pageSize"`
+	Search string `query:"search"`
+	Filter string `query:"filter"` // all, migrated, active, admin, inactive
+	Sort   string `query:"sort"`   // email, created
+	Order  string `query:"order"`  // asc, desc
 }
 
 // GetUsers returns paginated list of all users with filtering
 func (h *AdminUserHandler) GetUsers(c *fiber.Ctx) error {
 	// Parse query parameters
 	req := GetUsersRequest{
-		Search:   c.Query("search", ""),
-		Filter:   c.Query("filter", "all"),
-		Sort:     c.Query("sort", "created"),
-		Order:    c.Query("order", "desc"),
-		Page:     c.QueryInt("page", 1),
-		PageSize: c.QueryInt("pageSize", 25),
-	}
-
-	if req.Page < 1 {
-		req.Page = 1
-	}
-	if req.PageSize < 1 || req.PageSize > 100 {
-		req.PageSize = 25
+		Search: c.Query("search", ""),
+		Filter: c.Query("filter", "all"),
+		Sort:   c.Query("sort", "created"),
+		Order:  c.Query("order", "desc"),
 	}
+	pagination := parsePagination(c, h.pageLimits)
 
 	// Build base query with WHERE clause first
 	baseQuery := `WHERE 1=1`
@@ -99,7 +106,7 @@ func (h *AdminUserHandler) GetUsers(c *fiber.Ctx) error {
 			u.roles, u."isPterodactylAdmin", u."isVirtfusionAdmin", 
 			u."isSystemAdmin", u."isMigrated", u."isActive", u."emailVerified",
 			u."createdAt", u."updatedAt", u."lastLoginAt",
-			(SELECT COUNT(*) FROM servers WHERE "ownerId" = u.id) as server_count,
+			(SELECT COUNT(*) FROM servers WHERE "ownerId" = u.id AND "deletedAt" IS NULL) as server_count,
 			(SELECT COUNT(*) FROM sessions WHERE "userId" = u.id) as session_count
 		FROM users u
 		` + baseQuery
@@ -136,8 +143,7 @@ func (h *AdminUserHandler) GetUsers(c *fiber.Ctx) error {
 	}
 
 	// Apply pagination
-	offset := (req.Page - 1) * req.PageSize
-	query += fmt.Sprintf(` LIMIT %d OFFSET %d`, req.PageSize, offset)
+	query += fmt.Sprintf(` LIMIT %d OFFSET %d`, pagination.Limit, pagination.Offset)
 
 	// Execute query
 	rows, err := h.db.Pool.Query(context.Background(), query, args...)
@@ -206,18 +212,12 @@ func (h *AdminUserHandler) GetUsers(c *fiber.Ctx) error {
 		users = append(users, user)
 	}
 
-	// Calculate pagination info
-	totalPages := (totalCount + req.PageSize - 1) / req.PageSize
-
+	meta := pagination.Meta(totalCount)
 	return c.JSON(fiber.Map{
-		"success": true,
-		"users":   users,
-		"pagination": fiber.Map{
-			"page":       req.Page,
-			"pageSize":   req.PageSize,
-			"total":      totalCount,
-			"totalPages": totalPages,
-		},
+		"success":    true,
+		"users":      users,
+		"pagination": meta.pageBlock(),
+		"meta":       meta,
 	})
 }
 
@@ -242,36 +242,51 @@ func (h *AdminUserHandler) UpdateUserRoles(c *fiber.Ctx) error {
 		})
 	}
 
-	// Validate roles — must match the defined Role enum
-	validRoles := map[string]bool{
-		"MEMBER":        true,
-		"PARTNER":       true,
-		"SPONSOR":       true,
-		"TECH_TEAM":     true,
-		"SUPPORT_TEAM":  true,
-		"ADMINISTRATOR": true,
-		"SUPER_ADMIN":   true,
+	for i, role := range req.Roles {
+		req.Roles[i] = database.NormalizeRoleName(role)
 	}
 
-	for _, role := range req.Roles {
-		if !validRoles[role] {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": fmt.Sprintf("Invalid role: %s", role),
-			})
-		}
+	// Validate roles — must reference the role catalog
+	unknown, err := h.db.UnknownRoles(c.Context(), req.Roles)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to validate roles")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to validate roles",
+		})
+	}
+	if len(unknown) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": fmt.Sprintf("Invalid role: %s", strings.Join(unknown, ", ")),
+		})
 	}
 
-	// Sync isSystemAdmin: true when SUPER_ADMIN is in the roles list
-	isSuperAdmin := false
-	for _, r := range req.Roles {
-		if r == "SUPER_ADMIN" {
-			isSuperAdmin = true
-			break
+	// SUPER_ADMIN and roles that can edit the role catalog hand out every
+	// permission, so only system admins may assign them or change the roles
+	// of a system admin
+	isSuperAdmin := auth.HasSuperAdminRole(req.Roles)
+	if callerSystemAdmin, _ := c.Locals("isSystemAdmin").(bool); !callerSystemAdmin {
+		target, err := h.db.QueryUserByID(c.Context(), req.UserID)
+		if err != nil || target == nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "User not found",
+			})
+		}
+		granted, err := h.db.PermissionsForRoles(c.Context(), req.Roles)
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to resolve role permissions")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to validate roles",
+			})
+		}
+		if isSuperAdmin || target.IsSystemAdmin || auth.GrantsRoleManagement(granted) {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "System admin access required to assign these roles",
+			})
 		}
 	}
 
 	// Update user roles and keep isSystemAdmin in sync
-	_, err := h.db.Pool.Exec(context.Background(),
+	_, err = h.db.Pool.Exec(context.Background(),
 		`UPDATE users SET roles = $1, "isSystemAdmin" = $2, "updatedAt" = NOW() WHERE id = $3`,
 		req.Roles, isSuperAdmin, req.UserID,
 	)
@@ -289,3 +304,240 @@ func (h *AdminUserHandler) UpdateUserRoles(c *fiber.Ctx) error {
 		},
 	})
 }
+
+// ImpersonateUser issues a short-lived access token for another user
+// @Summary Impersonate user (admin)
+// @Description Issues a 15 minute, non-refreshable access token for the target user carrying an impersonatedBy claim. System admins only; the action is recorded in the admin audit log.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 200 {object} SuccessResponse "Impersonation token issued"
+// @Failure 400 {object} ErrorResponse "Cannot impersonate this user"
+// @Failure 403 {object} ErrorResponse "System admin required"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/users/{id}/impersonate [post]
+func (h *AdminUserHandler) ImpersonateUser(c *fiber.Ctx) error {
+	ctx := c.Context()
+	adminID, _ := c.Locals("userID").(string)
+	targetID := c.Params("id")
+
+	admin, err := h.db.QueryUserByID(ctx, adminID)
+	if err != nil || admin == nil || !admin.IsSystemAdmin {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "System admin access required",
+		})
+	}
+
+	if targetID == adminID {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Cannot impersonate yourself",
+		})
+	}
+
+	user, err := h.db.QueryUserByID(ctx, targetID)
+	if err != nil || user == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+	if user.IsSystemAdmin {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Cannot impersonate another system admin",
+		})
+	}
+	if !user.IsActive {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Cannot impersonate a disabled account",
+		})
+	}
+
+	claims := &auth.Claims{
+		UserID:             user.ID,
+		Email:              user.Email,
+		Username:           user.Username.String,
+		FirstName:          getStringPointer(user.FirstName),
+		LastName:           getStringPointer(user.LastName),
+		Roles:              user.Roles,
+		IsPterodactylAdmin: user.IsPterodactylAdmin,
+		IsVirtfusionAdmin:  user.IsVirtfusionAdmin,
+		IsSystemAdmin:      user.IsSystemAdmin,
+		PterodactylID:      getInt64Pointer(user.PterodactylID),
+		EmailVerified:      formatNullTime(user.EmailVerified),
+	}
+
+	token, tokenID, err := h.jwtService.GenerateImpersonationToken(claims, adminID, impersonationTokenTTL)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", user.ID).Msg("Failed to generate impersonation token")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to generate impersonation token",
+		})
+	}
+
+	expiresAt := time.Now().Add(impersonationTokenTTL)
+	if err := h.db.CreateImpersonationSession(ctx, tokenID, adminID, user.ID, expiresAt); err != nil {
+		log.Error().Err(err).Str("user_id", user.ID).Msg("Failed to create impersonation session")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to start impersonation",
+		})
+	}
+
+	// Impersonation must always be attributable - refuse to hand out the token unaudited
+	if err := h.db.CreateAdminAuditLog(ctx, database.AdminAuditEntry{
+		ActorID:    adminID,
+		Action:     database.AuditImpersonationStarted,
+		TargetType: "user",
+		TargetID:   user.ID,
+		Details:    map[string]interface{}{"sessionId": tokenID, "expiresAt": expiresAt},
+		IPAddress:  c.IP(),
+		UserAgent:  c.Get("User-Agent"),
+	}); err != nil {
+		log.Error().Err(err).Str("user_id", user.ID).Msg("Failed to write impersonation audit log")
+		h.db.EndImpersonationSession(ctx, tokenID)
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to start impersonation",
+		})
+	}
+
+	log.Warn().Str("admin_id", adminID).Str("user_id", user.ID).Str("session_id", tokenID).Msg("Admin started impersonating user")
+
+	return c.JSON(fiber.Map{
+		"data": fiber.Map{
+			"accessToken":    token,
+			"tokenType":      "Bearer",
+			"expiresIn":      int64(impersonationTokenTTL.Seconds()),
+			"expiresAt":      expiresAt,
+			"impersonatedBy": adminID,
+			"userId":         user.ID,
+		},
+	})
+}
+
+// GetUserOverview returns everything support needs about one user
+// @Summary Get user overview (admin)
+// @Description Returns the user's profile, owned servers with status and suspension, open ticket count, account balance, assigned plan and most recent login sessions in one call. Failed login attempts are not recorded; sessions and lastLoginAt show sign-in activity.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 200 {object} SuccessResponse "User overview"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/users/{id}/overview [get]
+func (h *AdminUserHandler) GetUserOverview(c *fiber.Ctx) error {
+	ctx := c.Context()
+	userID := c.Params("id")
+
+	profile, err := h.db.GetUserProfile(ctx, userID)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID).Msg("Failed to fetch user profile")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch user",
+		})
+	}
+	if profile == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+
+	servers, err := h.db.ListUserServers(ctx, userID)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID).Msg("Failed to fetch user servers")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch user servers",
+		})
+	}
+
+	openTickets, err := h.db.CountOpenTickets(ctx, userID)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID).Msg("Failed to count user tickets")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to count user tickets",
+		})
+	}
+
+	sessions, err := h.db.RecentUserSessions(ctx, userID, overviewSessionLimit)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID).Msg("Failed to fetch user sessions")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch user sessions",
+		})
+	}
+
+	plan, err := h.db.GetUserPlan(ctx, userID)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID).Msg("Failed to fetch user plan")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch user plan",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"success":        true,
+		"user":           profile,
+		"servers":        servers,
+		"openTickets":    openTickets,
+		"accountBalance": profile.AccountBalance,
+		"plan":           plan,
+		"sessions":       sessions,
+	})
+}
+
+// ResyncUser queues a refresh of a single user from the panel
+// @Summary Resync a user (admin)
+// @Description Queues a refresh of the user from their current panel record. The worker updates their panel admin flag and fills in profile names only where missing; an email change or a clash with another local user is recorded as a user sync conflict for review. Local servers the panel says the user owns are assigned to them; servers not synced yet are picked up by the next full sync.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "User ID"
+// @Success 202 {object} SuccessResponse "Resync queued"
+// @Failure 400 {object} ErrorResponse "User is not linked to a panel account"
+// @Failure 404 {object} ErrorResponse "User not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Failure 503 {object} ErrorResponse "Panel not configured"
+// @Router /api/admin/users/{id}/resync [post]
+func (h *AdminUserHandler) ResyncUser(c *fiber.Ctx) error {
+	if h.cfg.PterodactylURL == "" || h.cfg.PterodactylAPIKey == "" {
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Pterodactyl panel not configured",
+		})
+	}
+
+	userID := c.Params("id")
+	var pterodactylID *int
+	err := h.db.Pool.QueryRow(c.Context(),
+		`SELECT "pterodactylId" FROM users WHERE id = $1`, userID,
+	).Scan(&pterodactylID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "User not found",
+		})
+	}
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID).Msg("Failed to fetch user for resync")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch user",
+		})
+	}
+	if pterodactylID == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "User is not linked to a panel account",
+		})
+	}
+
+	info, err := h.queueManager.EnqueueUserResync(queue.UserResyncPayload{UserID: userID, PterodactylID: *pterodactylID})
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID).Msg("Failed to enqueue user resync")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to queue user resync",
+		})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"success": true,
+		"taskId":  info.ID,
+		"message": "User resync queued",
+	})
+}
@@ -13,75 +13,62 @@ import (
 	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
 
+	"github.com/nodebyte/backend/internal/config"
 	"github.com/nodebyte/backend/internal/database"
 )
 
 // AdminWebhooksHandler handles admin webhook management
 type AdminWebhooksHandler struct {
-	db *database.DB
+	db         *database.DB
+	pageLimits PageLimits
 }
 
 // NewAdminWebhooksHandler creates a new admin webhooks handler
-func NewAdminWebhooksHandler(db *database.DB) *AdminWebhooksHandler {
-	return &AdminWebhooksHandler{db: db}
+func NewAdminWebhooksHandler(db *database.DB, cfg *config.Config) *AdminWebhooksHandler {
+	return &AdminWebhooksHandler{db: db, pageLimits: configuredPageLimits(cfg)}
 }
 
-// DiscordWebhookDTO represents a Discord webhook
-type DiscordWebhookDTO struct {
-	ID            string     `json:"id"`
-	Name          string     `json:"name"`
-	WebhookURL    string     `json:"webhookUrl"`
-	Type          string     `json:"type"`
-	Scope         string     `json:"scope"`
-	Description   string     `json:"description"`
-	Enabled       bool       `json:"enabled"`
-	TestSuccessAt *time.Time `json:"testSuccessAt"`
-	CreatedAt     time.Time  `json:"createdAt"`
-}
+// webhookStatsWindow is how far back GET /api/admin/settings/webhooks
+// counts deliveries
+const webhookStatsWindow = 7 * 24 * time.Hour
 
-// GetWebhooks returns all discord webhooks
+// GetWebhooks returns a page of discord webhooks with their recent delivery stats
 // @Summary Get webhooks
-// @Description Returns list of configured Discord webhooks
+// @Description Returns a page of configured Discord webhooks, newest first, each with the number of successful and failed deliveries in the last 7 days, the time of the latest delivery and the latest failure's error
 // @Tags Admin Settings
 // @Produce json
+// @Param scope query string false "Filter by scope" Enums(ADMIN, USER, PUBLIC)
+// @Param type query string false "Filter by type" Enums(GAME_SERVER, VPS, SYSTEM, BILLING, SECURITY, SUPPORT, CUSTOM)
+// @Param limit query int false "Page size (default 25)" Default(25) Minimum(1) Maximum(100)
+// @Param offset query int false "Offset for pagination (default 0)" Default(0) Minimum(0)
 // @Success 200 {object} map[string]interface{} "Webhooks retrieved"
 // @Failure 401 {object} map[string]string "Unauthorized"
 // @Failure 500 {object} map[string]string "Internal error"
 // @Router /api/admin/settings/webhooks [get]
 // @Security Bearer
 func (h *AdminWebhooksHandler) GetWebhooks(c *fiber.Ctx) error {
-	query := `
-		SELECT id, name, "webhookUrl", type, scope, description, enabled, "testSuccessAt", "createdAt"
-		FROM discord_webhooks
-		ORDER BY "createdAt" DESC
-	`
+	pagination := parsePagination(c, h.pageLimits)
+	since := time.Now().Add(-webhookStatsWindow)
 
-	rows, err := h.db.Pool.Query(c.Context(), query)
+	webhooks, total, err := h.db.ListAdminWebhooks(c.Context(), c.Query("scope"), c.Query("type"), since, pagination.Limit, pagination.Offset)
 	if err != nil {
+		log.Error().Err(err).Msg("Failed to list webhooks")
 		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to fetch webhooks",
 		})
 	}
-	defer rows.Close()
-
-	var webhooks []DiscordWebhookDTO
-	for rows.Next() {
-		var wh DiscordWebhookDTO
-		if err := rows.Scan(&wh.ID, &wh.Name, &wh.WebhookURL, &wh.Type, &wh.Scope, &wh.Description, &wh.Enabled, &wh.TestSuccessAt, &wh.CreatedAt); err != nil {
-			continue
-		}
-		webhooks = append(webhooks, wh)
-	}
 
 	return c.JSON(fiber.Map{
-		"success":  true,
-		"webhooks": webhooks,
+		"success":    true,
+		"webhooks":   webhooks,
+		"statsSince": since,
+		"meta":       pagination.Meta(total),
 	})
 }
 
 // CreateWebhook creates a new Discord webhook
 // @Summary Create webhook
-// @Description Creates a new Discord webhook for notifications
+// @Description Creates a new Discord webhook for notifications. An optional messageTemplate (Go text/template syntax, e.g. "{{.name}} was suspended") replaces the default message text and receives the event data fields plus event.
 // @Tags Admin Settings
 // @Accept json
 // @Produce json
@@ -94,11 +81,12 @@ func (h *AdminWebhooksHandler) GetWebhooks(c *fiber.Ctx) error {
 // @Security Bearer
 func (h *AdminWebhooksHandler) CreateWebhook(c *fiber.Ctx) error {
 	var req struct {
-		Name        string `json:"name"`
-		WebhookURL  string `json:"webhookUrl"`
-		Type        string `json:"type"`
-		Scope       string `json:"scope"`
-		Description string `json:"description"`
+		Name            string `json:"name"`
+		WebhookURL      string `json:"webhookUrl"`
+		Type            string `json:"type"`
+		Scope           string `json:"scope"`
+		Description     string `json:"description"`
+		MessageTemplate string `json:"messageTemplate"`
 	}
 
 	if err := c.BodyParser(&req); err != nil {
@@ -123,6 +111,13 @@ func (h *AdminWebhooksHandler) CreateWebhook(c *fiber.Ctx) error {
 		})
 	}
 
+	if err := validateWebhookTemplate(req.MessageTemplate); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"success": false,
+			"error":   err.Error(),
+		})
+	}
+
 	// Set defaults
 	if req.Type == "" {
 		req.Type = "SYSTEM"
@@ -133,12 +128,12 @@ func (h *AdminWebhooksHandler) CreateWebhook(c *fiber.Ctx) error {
 
 	webhookID := uuid.New().String()
 	query := `
-		INSERT INTO discord_webhooks (id, name, "webhookUrl", type, scope, description, enabled, "createdAt", "updatedAt")
-		VALUES ($1, $2, $3, $4, $5, $6, true, NOW(), NOW())
+		INSERT INTO discord_webhooks (id, name, "webhookUrl", type, scope, description, "messageTemplate", enabled, "createdAt", "updatedAt")
+		VALUES ($1, $2, $3, $4, $5, $6, $7, true, NOW(), NOW())
 	`
 
 	_, err := h.db.Pool.Exec(c.Context(), query,
-		webhookID, req.Name, req.WebhookURL, req.Type, req.Scope, req.Description,
+		webhookID, req.Name, req.WebhookURL, req.Type, req.Scope, req.Description, database.NewNullString(req.MessageTemplate),
 	)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to create webhook")
@@ -151,18 +146,19 @@ func (h *AdminWebhooksHandler) CreateWebhook(c *fiber.Ctx) error {
 	return c.JSON(fiber.Map{
 		"success": true,
 		"webhook": fiber.Map{
-			"id":         webhookID,
-			"name":       req.Name,
-			"webhookUrl": req.WebhookURL,
-			"type":       req.Type,
-			"scope":      req.Scope,
+			"id":              webhookID,
+			"name":            req.Name,
+			"webhookUrl":      req.WebhookURL,
+			"type":            req.Type,
+			"scope":           req.Scope,
+			"messageTemplate": req.MessageTemplate,
 		},
 	})
 }
 
 // UpdateWebhook updates a Discord webhook
 // @Summary Update webhook
-// @Description Updates an existing Discord webhook
+// @Description Updates an existing Discord webhook. messageTemplate is validated before saving; send an empty string to go back to the default message text.
 // @Tags Admin Settings
 // @Accept json
 // @Produce json
@@ -175,12 +171,13 @@ func (h *AdminWebhooksHandler) CreateWebhook(c *fiber.Ctx) error {
 // @Security Bearer
 func (h *AdminWebhooksHandler) UpdateWebhook(c *fiber.Ctx) error {
 	var req struct {
-		ID          string `json:"id"`
-		Name        string `json:"name"`
-		WebhookURL  string `json:"webhookUrl"`
-		Type        string `json:"type"`
-		Description string `json:"description"`
-		Enabled     *bool  `json:"enabled"`
+		ID              string  `json:"id"`
+		Name            string  `json:"name"`
+		WebhookURL      string  `json:"webhookUrl"`
+		Type            string  `json:"type"`
+		Description     string  `json:"description"`
+		MessageTemplate *string `json:"messageTemplate"`
+		Enabled         *bool   `json:"enabled"`
 	}
 
 	if err := c.BodyParser(&req); err != nil {
@@ -200,7 +197,7 @@ func (h *AdminWebhooksHandler) UpdateWebhook(c *fiber.Ctx) error {
 	// Build dynamic update query
 	query := `UPDATE discord_webhooks SET "updatedAt" = NOW()`
 	args := []interface{}{}
-	paramCount := 1
+	paramCount := 0
 
 	if req.Name != "" {
 		paramCount++
@@ -232,6 +229,18 @@ func (h *AdminWebhooksHandler) UpdateWebhook(c *fiber.Ctx) error {
 		args = append(args, req.Description)
 	}
 
+	if req.MessageTemplate != nil {
+		if err := validateWebhookTemplate(*req.MessageTemplate); err != nil {
+			return c.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error":   err.Error(),
+			})
+		}
+		paramCount++
+		query += `, "messageTemplate" = $` + fmt.Sprintf("%d", paramCount)
+		args = append(args, database.NewNullString(*req.MessageTemplate))
+	}
+
 	if req.Enabled != nil {
 		paramCount++
 		query += `, enabled = $` + fmt.Sprintf("%d", paramCount)
@@ -397,6 +406,18 @@ func (h *AdminWebhooksHandler) TestWebhook(c *fiber.Ctx) error {
 
 // Helper functions
 
+// validateWebhookTemplate checks that a message template parses; an empty
+// template means the default message text
+func validateWebhookTemplate(text string) error {
+	if text == "" {
+		return nil
+	}
+	if _, err := database.ParseWebhookTemplate(text); err != nil {
+		return fmt.Errorf("invalid messageTemplate: %v", err)
+	}
+	return nil
+}
+
 // isValidDiscordWebhookURL validates if a URL is a valid Discord webhook URL.
 // Accepts discord.com directly or proxy URLs (e.g. gateway.nodebyte.host/proxy/discord/webhooks/...)
 // as long as the URL is HTTPS and the path contains "/webhooks/".
@@ -1,7 +1,7 @@
 // Package handlers provides HTTP handlers for the NodeByte API.
 //
 // @title NodeByte Backend API
-// @version 0.2.0
+// @version 0.3.0
 // @description Comprehensive API for managing game server infrastructure with Pterodactyl panel integration
 // @host localhost:8080
 // @basePath /
@@ -15,14 +15,23 @@
 package handlers
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/hibiken/asynq"
+	"github.com/jackc/pgx/v5"
 	"github.com/rs/zerolog/log"
 
+	"github.com/nodebyte/backend/internal/cache"
+	"github.com/nodebyte/backend/internal/config"
 	"github.com/nodebyte/backend/internal/database"
 	"github.com/nodebyte/backend/internal/queue"
 )
@@ -32,14 +41,16 @@ type SyncAPIHandler struct {
 	db           *database.DB
 	syncRepo     *database.SyncRepository
 	queueManager *queue.Manager
+	cfg          *config.Config
 }
 
 // NewSyncAPIHandler creates a new sync API handler
-func NewSyncAPIHandler(db *database.DB, queueManager *queue.Manager) *SyncAPIHandler {
+func NewSyncAPIHandler(db *database.DB, queueManager *queue.Manager, cfg *config.Config) *SyncAPIHandler {
 	return &SyncAPIHandler{
 		db:           db,
 		syncRepo:     database.NewSyncRepository(db),
 		queueManager: queueManager,
+		cfg:          cfg,
 	}
 }
 
@@ -47,17 +58,20 @@ func NewSyncAPIHandler(db *database.DB, queueManager *queue.Manager) *SyncAPIHan
 type TriggerFullSyncRequest struct {
 	SkipUsers   bool   `json:"skip_users"`
 	RequestedBy string `json:"requested_by"`
+	Confirm     string `json:"confirm"` // confirmation token, when SYNC_CONFIRM_DESTRUCTIVE is on
 }
 
 // TriggerFullSync triggers a full sync operation
 // @Summary Trigger full sync
-// @Description Initiates a complete synchronization of all resources (locations, nodes, allocations, nests, servers, databases, users)
+// @Description Initiates a complete synchronization of all resources (locations, nodes, allocations, nests, servers, databases, users). When SYNC_CONFIRM_DESTRUCTIVE is on, the first request answers 428 with a confirmationToken and an impact preview; repeat it with the token as confirm within two minutes to run the sync.
 // @Tags Sync
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
 // @Param payload body TriggerFullSyncRequest true "Sync request parameters"
 // @Success 202 {object} SuccessResponse "Sync queued successfully"
+// @Failure 400 {object} ErrorResponse "Invalid confirmation token"
+// @Failure 428 {object} ErrorResponse "Confirmation required; the response carries a token and the impact"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /api/v1/sync/full [post]
 func (h *SyncAPIHandler) TriggerFullSync(c *fiber.Ctx) error {
@@ -66,6 +80,12 @@ func (h *SyncAPIHandler) TriggerFullSync(c *fiber.Ctx) error {
 		// Ignore parse errors, use defaults
 	}
 
+	prunes := syncPrunes["full"]
+	impact := func(ctx context.Context) SyncImpact { return pruneImpact(ctx, h.db, h.cfg, prunes) }
+	if ok, err := requireSyncConfirmation(c, h.db, h.cfg, database.SyncConfirmSync, "full", req.Confirm, impact); !ok {
+		return err
+	}
+
 	// Create sync log
 	syncLog, err := h.syncRepo.CreateSyncLog(c.Context(), "full", "PENDING", map[string]interface{}{
 		"requested_by": req.RequestedBy,
@@ -111,12 +131,15 @@ func (h *SyncAPIHandler) TriggerFullSync(c *fiber.Ctx) error {
 
 // TriggerLocationsSync triggers a locations-only sync
 // @Summary Trigger locations sync
-// @Description Synchronizes only location data from Pterodactyl panel
+// @Description Synchronizes only location data from Pterodactyl panel. Stale locations are pruned, so when SYNC_CONFIRM_DESTRUCTIVE is on the first request answers 428 with a confirmationToken; repeat it with the token as confirm.
 // @Tags Sync
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
+// @Param payload body SyncConfirmRequest false "Confirmation token"
 // @Success 202 {object} SuccessResponse "Sync queued successfully"
+// @Failure 400 {object} ErrorResponse "Invalid confirmation token"
+// @Failure 428 {object} ErrorResponse "Confirmation required; the response carries a token and the impact"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /api/v1/sync/locations [post]
 func (h *SyncAPIHandler) TriggerLocationsSync(c *fiber.Ctx) error {
@@ -125,12 +148,15 @@ func (h *SyncAPIHandler) TriggerLocationsSync(c *fiber.Ctx) error {
 
 // TriggerNodesSync triggers a nodes-only sync
 // @Summary Trigger nodes sync
-// @Description Synchronizes only node data from Pterodactyl panel
+// @Description Synchronizes only node data from Pterodactyl panel. Stale nodes are pruned, so when SYNC_CONFIRM_DESTRUCTIVE is on the first request answers 428 with a confirmationToken; repeat it with the token as confirm.
 // @Tags Sync
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
+// @Param payload body SyncConfirmRequest false "Confirmation token"
 // @Success 202 {object} SuccessResponse "Sync queued successfully"
+// @Failure 400 {object} ErrorResponse "Invalid confirmation token"
+// @Failure 428 {object} ErrorResponse "Confirmation required; the response carries a token and the impact"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /api/v1/sync/nodes [post]
 func (h *SyncAPIHandler) TriggerNodesSync(c *fiber.Ctx) error {
@@ -139,12 +165,15 @@ func (h *SyncAPIHandler) TriggerNodesSync(c *fiber.Ctx) error {
 
 // TriggerServersSync triggers a servers-only sync
 // @Summary Trigger servers sync
-// @Description Synchronizes only server data from Pterodactyl panel
+// @Description Synchronizes only server data from Pterodactyl panel. Stale servers, nodes and locations are pruned, so when SYNC_CONFIRM_DESTRUCTIVE is on the first request answers 428 with a confirmationToken; repeat it with the token as confirm.
 // @Tags Sync
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
+// @Param payload body SyncConfirmRequest false "Confirmation token"
 // @Success 202 {object} SuccessResponse "Sync queued successfully"
+// @Failure 400 {object} ErrorResponse "Invalid confirmation token"
+// @Failure 428 {object} ErrorResponse "Confirmation required; the response carries a token and the impact"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /api/v1/sync/servers [post]
 func (h *SyncAPIHandler) TriggerServersSync(c *fiber.Ctx) error {
@@ -166,6 +195,23 @@ func (h *SyncAPIHandler) TriggerUsersSync(c *fiber.Ctx) error {
 }
 
 func (h *SyncAPIHandler) triggerPartialSync(c *fiber.Ctx, syncType, taskType string) error {
+	var req SyncConfirmRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Success: false,
+				Error:   "Invalid request body",
+			})
+		}
+	}
+
+	if prunes := syncPrunes[syncType]; len(prunes) > 0 {
+		impact := func(ctx context.Context) SyncImpact { return pruneImpact(ctx, h.db, h.cfg, prunes) }
+		if ok, err := requireSyncConfirmation(c, h.db, h.cfg, database.SyncConfirmSync, syncType, req.Confirm, impact); !ok {
+			return err
+		}
+	}
+
 	syncLog, err := h.syncRepo.CreateSyncLog(c.Context(), syncType, "PENDING", nil)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
@@ -229,14 +275,17 @@ func (h *SyncAPIHandler) triggerPartialSync(c *fiber.Ctx, syncType, taskType str
 
 // CancelSync cancels a running sync operation
 // @Summary Cancel sync operation
-// @Description Requests cancellation of a running sync by ID
+// @Description Requests cancellation of a running sync by ID. When SYNC_CONFIRM_DESTRUCTIVE is on, the first request answers 428 with a confirmationToken describing the sync; repeat it with the token as confirm to cancel.
 // @Tags Sync
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
 // @Param id path string true "Sync log ID"
+// @Param payload body SyncConfirmRequest false "Confirmation token"
 // @Success 200 {object} SuccessResponse "Cancellation requested"
-// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 400 {object} ErrorResponse "Invalid request or confirmation token"
+// @Failure 404 {object} ErrorResponse "Sync not found"
+// @Failure 428 {object} ErrorResponse "Confirmation required; the response carries a token and the impact"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /api/v1/sync/cancel/{id} [post]
 func (h *SyncAPIHandler) CancelSync(c *fiber.Ctx) error {
@@ -248,8 +297,31 @@ func (h *SyncAPIHandler) CancelSync(c *fiber.Ctx) error {
 		})
 	}
 
+	var req SyncConfirmRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Success: false,
+				Error:   "Invalid request body",
+			})
+		}
+	}
+
+	syncLog, err := h.syncRepo.GetSyncLog(c.Context(), id)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Sync log not found",
+		})
+	}
+
+	impact := func(context.Context) SyncImpact { return cancelImpact(*syncLog) }
+	if ok, err := requireSyncConfirmation(c, h.db, h.cfg, database.SyncConfirmCancel, syncLog.ID, req.Confirm, impact); !ok {
+		return err
+	}
+
 	// Update sync log to cancelling status
-	err := h.syncRepo.UpdateSyncLog(c.Context(), id, "cancelling", nil, nil, nil, nil)
+	err = h.syncRepo.UpdateSyncLog(c.Context(), id, "cancelling", nil, nil, nil, nil)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
 			Success: false,
@@ -265,7 +337,7 @@ func (h *SyncAPIHandler) CancelSync(c *fiber.Ctx) error {
 
 // GetSyncStatus gets the status of a sync operation
 // @Summary Get sync status
-// @Description Retrieves detailed status and metadata of a specific sync operation
+// @Description Retrieves detailed status, metadata and per-step breakdown of a specific sync operation. While the sync runs, throughput holds the current step's rolling rate and estimated seconds remaining.
 // @Tags Sync
 // @Accept json
 // @Produce json
@@ -284,16 +356,7 @@ func (h *SyncAPIHandler) GetSyncStatus(c *fiber.Ctx) error {
 		})
 	}
 
-	query := `
-		SELECT id, type, status, "itemsTotal", "itemsSynced", "itemsFailed", error, metadata, "startedAt", "completedAt"
-		FROM "sync_logs" WHERE id = $1
-	`
-
-	var log database.SyncLog
-	err := h.db.Pool.QueryRow(c.Context(), query, id).Scan(
-		&log.ID, &log.Type, &log.Status, &log.ItemsTotal, &log.ItemsSynced, &log.ItemsFailed, &log.Error, &log.Metadata,
-		&log.StartedAt, &log.CompletedAt,
-	)
+	log, err := h.syncRepo.GetSyncLog(c.Context(), id)
 	if err != nil {
 		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
 			Success: false,
@@ -307,6 +370,9 @@ func (h *SyncAPIHandler) GetSyncStatus(c *fiber.Ctx) error {
 	})
 }
 
+// syncLogPageLimits are the page sizes for the sync log lists
+var syncLogPageLimits = PageLimits{Default: 20, Max: 100}
+
 // GetSyncLogs gets sync logs with pagination
 // @Summary Get sync logs
 // @Description Retrieves paginated list of sync operation logs
@@ -321,53 +387,31 @@ func (h *SyncAPIHandler) GetSyncStatus(c *fiber.Ctx) error {
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /api/v1/sync/logs [get]
 func (h *SyncAPIHandler) GetSyncLogs(c *fiber.Ctx) error {
-	limit := c.QueryInt("limit", 20)
-	offset := c.QueryInt("offset", 0)
+	pagination := parsePagination(c, syncLogPageLimits)
 	syncType := c.Query("type")
 
-	query := `
-		SELECT id, type, status, "itemsTotal", "itemsSynced", "itemsFailed", error, metadata, "startedAt", "completedAt"
-		FROM "sync_logs"
-	`
-	args := []interface{}{}
-
-	if syncType != "" {
-		query += " WHERE type = $1"
-		args = append(args, syncType)
-	}
-
-	paramCount := len(args) + 1
-	query += " ORDER BY \"startedAt\" DESC LIMIT $" + strconv.Itoa(paramCount) + " OFFSET $" + strconv.Itoa(paramCount+1)
-	args = append(args, limit, offset)
-
-	rows, err := h.db.Pool.Query(c.Context(), query, args...)
+	logs, err := h.syncRepo.GetSyncLogs(c.Context(), pagination.Limit, pagination.Offset, syncType)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
 			Success: false,
 			Error:   "Failed to fetch sync logs",
 		})
 	}
-	defer rows.Close()
-
-	var logs []database.SyncLog
-	for rows.Next() {
-		var log database.SyncLog
-		err := rows.Scan(
-			&log.ID, &log.Type, &log.Status, &log.ItemsTotal, &log.ItemsSynced, &log.ItemsFailed, &log.Error, &log.Metadata,
-			&log.StartedAt, &log.CompletedAt,
-		)
-		if err != nil {
-			continue
-		}
-		logs = append(logs, log)
+	total, err := h.syncRepo.CountSyncLogs(c.Context(), syncType)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Failed to fetch sync logs",
+		})
 	}
 
 	return c.JSON(SuccessResponse{
 		Success: true,
 		Data: fiber.Map{
 			"logs":   logs,
-			"limit":  limit,
-			"offset": offset,
+			"limit":  pagination.Limit,
+			"offset": pagination.Offset,
+			"meta":   pagination.Meta(total),
 		},
 	})
 }
@@ -419,12 +463,16 @@ func (h *SyncAPIHandler) GetLatestSync(c *fiber.Ctx) error {
 
 // StatsHandler handles statistics API requests
 type StatsHandler struct {
-	db *database.DB
+	db         *database.DB
+	cache      *cache.Cache
+	cfg        *config.Config
+	refreshing sync.Map // cache keys with a background refresh in flight
 }
 
-// NewStatsHandler creates a new stats handler
-func NewStatsHandler(db *database.DB) *StatsHandler {
-	return &StatsHandler{db: db}
+// NewStatsHandler creates a new stats handler. cfg decides which internal
+// accounts and servers the public endpoints leave out.
+func NewStatsHandler(db *database.DB, responseCache *cache.Cache, cfg *config.Config) *StatsHandler {
+	return &StatsHandler{db: db, cache: responseCache, cfg: cfg}
 }
 
 // GetOverview returns an overview of system statistics
@@ -449,7 +497,7 @@ func (h *StatsHandler) GetOverview(c *fiber.Ctx) error {
 
 	queries := map[string]string{
 		"users":       "SELECT COUNT(*) FROM users",
-		"servers":     "SELECT COUNT(*) FROM servers",
+		"servers":     `SELECT COUNT(*) FROM servers WHERE "deletedAt" IS NULL`,
 		"nodes":       "SELECT COUNT(*) FROM nodes",
 		"locations":   "SELECT COUNT(*) FROM locations",
 		"eggs":        "SELECT COUNT(*) FROM eggs",
@@ -475,24 +523,58 @@ func (h *StatsHandler) GetOverview(c *fiber.Ctx) error {
 
 // GetServerStats returns server-specific statistics
 // @Summary Get server statistics
-// @Description Retrieves server count statistics grouped by status and node
+// @Description Retrieves server counts grouped by status, node and egg, optionally limited to one node, egg or owner. Eggs without matching servers are omitted from by_egg.
 // @Tags Stats
 // @Accept json
 // @Produce json
 // @Security ApiKeyAuth
+// @Param nodeId query int false "Only count servers on this node"
+// @Param eggId query int false "Only count servers using this egg"
+// @Param ownerId query string false "Only count servers owned by this user"
 // @Success 200 {object} SuccessResponse "Server statistics retrieved"
+// @Failure 400 {object} ErrorResponse "Invalid filter"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /api/v1/stats/servers [get]
 func (h *StatsHandler) GetServerStats(c *fiber.Ctx) error {
 	ctx := c.Context()
 
+	// Optional filters, applied to the servers table (aliased s) in every query
+	conds := []string{`s."deletedAt" IS NULL`}
+	var args []interface{}
+	for _, filter := range []struct{ param, column string }{
+		{"nodeId", `s."nodeId"`},
+		{"eggId", `s."eggId"`},
+	} {
+		value := c.Query(filter.param)
+		if value == "" {
+			continue
+		}
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Success: false,
+				Error:   filter.param + " must be an integer",
+			})
+		}
+		args = append(args, id)
+		conds = append(conds, fmt.Sprintf("%s = $%d", filter.column, len(args)))
+	}
+	if ownerID := c.Query("ownerId"); ownerID != "" {
+		args = append(args, ownerID)
+		conds = append(conds, fmt.Sprintf(`s."ownerId" = $%d`, len(args)))
+	}
+
+	where := "WHERE " + strings.Join(conds, " AND ")
+	joinFilter := " AND " + strings.Join(conds, " AND ")
+
 	// Servers by status
 	statusQuery := `
-		SELECT status, COUNT(*) as count 
-		FROM servers 
-		GROUP BY status
+		SELECT s.status, COUNT(*) as count
+		FROM servers s
+		` + where + `
+		GROUP BY s.status
 	`
-	rows, err := h.db.Pool.Query(ctx, statusQuery)
+	rows, err := h.db.Pool.Query(ctx, statusQuery, args...)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
 			Success: false,
@@ -511,14 +593,14 @@ func (h *StatsHandler) GetServerStats(c *fiber.Ctx) error {
 		byStatus[status] = count
 	}
 
-	// Servers by node
+	// Servers by node; every node is listed, with 0 when nothing matches
 	nodeQuery := `
-		SELECT n.name, COUNT(s.id) as count 
+		SELECT n.name, COUNT(s.id) as count
 		FROM nodes n
-		LEFT JOIN servers s ON s.node_id = n.id
+		LEFT JOIN servers s ON s."nodeId" = n.id` + joinFilter + `
 		GROUP BY n.id, n.name
 	`
-	nodeRows, err := h.db.Pool.Query(ctx, nodeQuery)
+	nodeRows, err := h.db.Pool.Query(ctx, nodeQuery, args...)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
 			Success: false,
@@ -537,11 +619,39 @@ func (h *StatsHandler) GetServerStats(c *fiber.Ctx) error {
 		byNode[name] = count
 	}
 
+	// Servers by egg; eggs sharing a name across nests are summed
+	eggQuery := `
+		SELECT e.name, COUNT(*) as count
+		FROM servers s
+		JOIN eggs e ON e.id = s."eggId"
+		` + where + `
+		GROUP BY e.id, e.name
+	`
+	eggRows, err := h.db.Pool.Query(ctx, eggQuery, args...)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Failed to fetch server stats by egg",
+		})
+	}
+	defer eggRows.Close()
+
+	byEgg := make(map[string]int)
+	for eggRows.Next() {
+		var name string
+		var count int
+		if err := eggRows.Scan(&name, &count); err != nil {
+			continue
+		}
+		byEgg[name] += count
+	}
+
 	return c.JSON(SuccessResponse{
 		Success: true,
 		Data: fiber.Map{
 			"by_status": byStatus,
 			"by_node":   byNode,
+			"by_egg":    byEgg,
 		},
 	})
 }
@@ -664,16 +774,55 @@ func (h *EmailAPIHandler) QueueEmail(c *fiber.Ctx) error {
 type WebhookAPIHandler struct {
 	db           *database.DB
 	queueManager *queue.Manager
+	cache        *cache.Cache
+	cfg          *config.Config
 }
 
 // NewWebhookAPIHandler creates a new webhook API handler
-func NewWebhookAPIHandler(db *database.DB, queueManager *queue.Manager) *WebhookAPIHandler {
+func NewWebhookAPIHandler(db *database.DB, queueManager *queue.Manager, responseCache *cache.Cache, cfg *config.Config) *WebhookAPIHandler {
 	return &WebhookAPIHandler{
 		db:           db,
 		queueManager: queueManager,
+		cache:        responseCache,
+		cfg:          cfg,
 	}
 }
 
+// claimWebhookDispatch reports whether event may be dispatched to webhookID
+// now. Within the event's debounce window only the first dispatch is
+// claimed; its time is kept in Redis until the window ends. Without Redis,
+// or if Redis fails, every dispatch is allowed. A claimed dispatch that is
+// not queued must be given back with releaseWebhookDispatch.
+func (h *WebhookAPIHandler) claimWebhookDispatch(ctx context.Context, event, webhookID string) bool {
+	window := h.cfg.WebhookDebounceWindow(event)
+	if window <= 0 {
+		return true
+	}
+
+	claimed, err := h.cache.SetNX(ctx, webhookDebounceKey(event, webhookID), time.Now().Unix(), window)
+	if err != nil {
+		log.Warn().Err(err).Str("event", event).Str("webhook_id", webhookID).Msg("Webhook debounce check failed; dispatching anyway")
+		return true
+	}
+	return claimed
+}
+
+// releaseWebhookDispatch gives back a claim from claimWebhookDispatch so the
+// next dispatch of event to webhookID is not debounced
+func (h *WebhookAPIHandler) releaseWebhookDispatch(ctx context.Context, event, webhookID string) {
+	if h.cfg.WebhookDebounceWindow(event) <= 0 {
+		return
+	}
+	if err := h.cache.Delete(ctx, webhookDebounceKey(event, webhookID)); err != nil {
+		log.Warn().Err(err).Str("event", event).Str("webhook_id", webhookID).Msg("Failed to release webhook debounce claim")
+	}
+}
+
+// webhookDebounceKey is the Redis key holding a webhook's debounce claim for event
+func webhookDebounceKey(event, webhookID string) string {
+	return "webhook:debounce:" + event + ":" + webhookID
+}
+
 // DispatchWebhookRequest represents a webhook dispatch request
 type DispatchWebhookRequest struct {
 	Event string                 `json:"event"`
@@ -682,7 +831,7 @@ type DispatchWebhookRequest struct {
 
 // DispatchWebhook dispatches a webhook to all applicable webhooks
 // @Summary Dispatch webhook
-// @Description Dispatches a webhook event to all configured webhooks that handle the event type
+// @Description Dispatches a webhook event to all configured webhooks that handle the event type. Events with a debounce window (WEBHOOK_DEBOUNCE) skip webhooks that already received the same event within the window; those are counted as skipped. Ticket events (support.ticket_created and ticket.reply) only go to SUPPORT webhooks. With data.ticketId they gain the ticket number, subject and a staff link in their data and email the other party: staff for new tickets and customer replies, the ticket owner for staff replies, which also write an in-app notification. data.replyId identifies the reply; internal replies are not sent to the owner.
 // @Tags Webhooks
 // @Accept json
 // @Produce json
@@ -708,8 +857,17 @@ func (h *WebhookAPIHandler) DispatchWebhook(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get all enabled webhooks
+	if req.Data == nil {
+		req.Data = map[string]interface{}{}
+	}
+
+	// Ticket events notify the other party and go to the support channel
 	query := `SELECT id FROM "discord_webhooks" WHERE enabled = true`
+	if isTicketEvent(req.Event) {
+		h.handleTicketEvent(c.Context(), req)
+		query += ` AND type = 'SUPPORT'`
+	}
+
 	rows, err := h.db.Pool.Query(c.Context(), query)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
@@ -720,12 +878,18 @@ func (h *WebhookAPIHandler) DispatchWebhook(c *fiber.Ctx) error {
 	defer rows.Close()
 
 	var taskIDs []string
+	skipped := 0
 	for rows.Next() {
 		var webhookID string
 		if err := rows.Scan(&webhookID); err != nil {
 			continue
 		}
 
+		if !h.claimWebhookDispatch(c.Context(), req.Event, webhookID) {
+			skipped++
+			continue
+		}
+
 		taskInfo, err := h.queueManager.EnqueueWebhook(queue.WebhookPayload{
 			WebhookID: webhookID,
 			Event:     req.Event,
@@ -733,6 +897,7 @@ func (h *WebhookAPIHandler) DispatchWebhook(c *fiber.Ctx) error {
 		})
 		if err != nil {
 			log.Warn().Err(err).Str("webhook_id", webhookID).Msg("Failed to queue webhook")
+			h.releaseWebhookDispatch(c.Context(), req.Event, webhookID)
 			continue
 		}
 		taskIDs = append(taskIDs, taskInfo.ID)
@@ -742,6 +907,7 @@ func (h *WebhookAPIHandler) DispatchWebhook(c *fiber.Ctx) error {
 		Success: true,
 		Data: fiber.Map{
 			"dispatched": len(taskIDs),
+			"skipped":    skipped,
 			"task_ids":   taskIDs,
 		},
 		Message: "Webhooks have been queued",
@@ -773,7 +939,7 @@ func (h *QueueHandler) GetStats(c *fiber.Ctx) error {
 	return c.JSON(SuccessResponse{
 		Success: true,
 		Data: fiber.Map{
-			"queues": []string{queue.QueueCritical, queue.QueueDefault, queue.QueueLow},
+			"queues": queue.Queues,
 			"note":   "Detailed stats coming soon",
 		},
 	})
@@ -795,7 +961,7 @@ func now() time.Time {
 
 // GetPublicStats handles GET /api/stats (public endpoint)
 // @Summary Get public statistics
-// @Description Retrieves publicly available system statistics (no authentication required)
+// @Description Retrieves publicly available system statistics (no authentication required). Internal servers and accounts are left out per the PUBLIC_STATS_* settings: admin accounts and the servers they own, servers with an excluded global tag (default "internal") and deactivated users. activeUsers counts users who have signed in.
 // @Tags Public
 // @Accept json
 // @Produce json
@@ -803,30 +969,32 @@ func now() time.Time {
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /api/stats [get]
 func (h *StatsHandler) GetPublicStats(c *fiber.Ctx) error {
-	ctx := c.Context()
+	return h.serveCached(c, publicStatsCacheKey, h.loadPublicStats)
+}
 
+// loadPublicStats runs the count queries behind GetPublicStats
+func (h *StatsHandler) loadPublicStats(ctx context.Context) fiber.Map {
 	var totalServers, totalUsers, totalAllocations, activeUsers int
 
 	// Get counts
-	h.db.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM servers").Scan(&totalServers)
-	h.db.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM users").Scan(&totalUsers)
+	totalServers = h.countPublicServers(ctx)
+	h.db.Pool.QueryRow(ctx, `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE u."lastLoginAt" IS NOT NULL) FROM users u
+		WHERE `+publicUserFilter, h.publicUserArgs()...,
+	).Scan(&totalUsers, &activeUsers)
 	h.db.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM allocations").Scan(&totalAllocations)
-	h.db.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM users WHERE last_login_at IS NOT NULL").Scan(&activeUsers)
 
-	return c.JSON(SuccessResponse{
-		Success: true,
-		Data: fiber.Map{
-			"totalServers":     totalServers,
-			"totalUsers":       totalUsers,
-			"activeUsers":      activeUsers,
-			"totalAllocations": totalAllocations,
-		},
-	})
+	return fiber.Map{
+		"totalServers":     totalServers,
+		"totalUsers":       totalUsers,
+		"activeUsers":      activeUsers,
+		"totalAllocations": totalAllocations,
+	}
 }
 
 // GetPanelCounts handles GET /api/panel/counts (public endpoint)
 // @Summary Get panel resource counts
-// @Description Retrieves counts of all manageable resources for the panel (no authentication required)
+// @Description Retrieves counts of all manageable resources for the panel (no authentication required). Server and user counts leave out internal servers and accounts like GET /api/stats does.
 // @Tags Public
 // @Accept json
 // @Produce json
@@ -834,28 +1002,137 @@ func (h *StatsHandler) GetPublicStats(c *fiber.Ctx) error {
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /api/panel/counts [get]
 func (h *StatsHandler) GetPanelCounts(c *fiber.Ctx) error {
-	ctx := c.Context()
+	return h.serveCached(c, panelCountsCacheKey, h.loadPanelCounts)
+}
 
+// loadPanelCounts runs the count queries behind GetPanelCounts
+func (h *StatsHandler) loadPanelCounts(ctx context.Context) fiber.Map {
 	var nodeCount, serverCount, userCount, allocationCount, nestCount int
 
 	h.db.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM nodes").Scan(&nodeCount)
-	h.db.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM servers").Scan(&serverCount)
-	h.db.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM users").Scan(&userCount)
+	serverCount = h.countPublicServers(ctx)
+	h.db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM users u WHERE `+publicUserFilter, h.publicUserArgs()...).Scan(&userCount)
 	h.db.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM allocations WHERE \"isAssigned\" = true").Scan(&allocationCount)
 	h.db.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM nests").Scan(&nestCount)
 
+	return fiber.Map{
+		"nodes":       nodeCount,
+		"servers":     serverCount,
+		"users":       userCount,
+		"allocations": allocationCount,
+		"nests":       nestCount,
+	}
+}
+
+// Filters applied to the public counts; see publicServerArgs and
+// publicUserArgs for their parameters
+const (
+	publicAdminUser = `(u."isSystemAdmin" OR u."isPterodactylAdmin" OR COALESCE(u.roles, '{}') && ARRAY['SUPER_ADMIN', 'ADMINISTRATOR'])`
+
+	publicServerFilter = `s."deletedAt" IS NULL
+		AND NOT EXISTS (
+			SELECT 1 FROM server_tags t
+			WHERE t."serverId" = s.id AND t."userId" IS NULL AND t.tag = ANY($1)
+		)
+		AND (NOT $2 OR NOT EXISTS (SELECT 1 FROM users u WHERE u.id = s."ownerId" AND ` + publicAdminUser + `))`
+
+	publicUserFilter = `(NOT $1 OR NOT ` + publicAdminUser + `) AND (NOT $2 OR u."isActive")`
+)
+
+// publicServerArgs returns the parameters of publicServerFilter: the global
+// tags marking internal servers and whether admin-owned servers are left out
+func (h *StatsHandler) publicServerArgs() []interface{} {
+	return []interface{}{h.cfg.PublicStatsExcludedTags, h.cfg.PublicStatsExcludeAdmins}
+}
+
+// publicUserArgs returns the parameters of publicUserFilter: whether admin
+// accounts and deactivated users are left out
+func (h *StatsHandler) publicUserArgs() []interface{} {
+	return []interface{}{h.cfg.PublicStatsExcludeAdmins, h.cfg.PublicStatsActiveUsersOnly}
+}
+
+// countPublicServers counts live servers other than internal ones
+func (h *StatsHandler) countPublicServers(ctx context.Context) int {
+	var count int
+	if err := h.db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM servers s WHERE `+publicServerFilter, h.publicServerArgs()...).Scan(&count); err != nil {
+		log.Warn().Err(err).Msg("Failed to count public servers")
+	}
+	return count
+}
+
+const (
+	publicStatsCacheKey = "cache:stats:public"
+	panelCountsCacheKey = "cache:stats:panel-counts"
+
+	// statsCacheStaleWindow is how long an expired entry may still be served
+	// while a background refresh replaces it
+	statsCacheStaleWindow = 5 * time.Minute
+)
+
+// cachedStats is the Redis representation of a cached stats response
+type cachedStats struct {
+	Data       fiber.Map `json:"data"`
+	FreshUntil time.Time `json:"freshUntil"`
+}
+
+// serveCached responds with the cached value for key, loading and caching it
+// on a miss. Expired entries are served as-is while a refresh runs in the
+// background. The X-Cache header reports HIT, STALE or MISS.
+func (h *StatsHandler) serveCached(c *fiber.Ctx, key string, load func(context.Context) fiber.Map) error {
+	var entry cachedStats
+	hit, err := h.cache.Get(c.Context(), key, &entry)
+	if err != nil {
+		log.Warn().Err(err).Str("key", key).Msg("Failed to read stats cache")
+	}
+
+	if hit {
+		if time.Now().Before(entry.FreshUntil) {
+			c.Set("X-Cache", "HIT")
+		} else {
+			c.Set("X-Cache", "STALE")
+			h.refreshInBackground(key, load)
+		}
+		return c.JSON(SuccessResponse{
+			Success: true,
+			Data:    entry.Data,
+		})
+	}
+
+	data := h.refreshCache(c.Context(), key, load)
+	c.Set("X-Cache", "MISS")
 	return c.JSON(SuccessResponse{
 		Success: true,
-		Data: fiber.Map{
-			"nodes":       nodeCount,
-			"servers":     serverCount,
-			"users":       userCount,
-			"allocations": allocationCount,
-			"nests":       nestCount,
-		},
+		Data:    data,
 	})
 }
 
+// refreshCache loads fresh data and stores it under key
+func (h *StatsHandler) refreshCache(ctx context.Context, key string, load func(context.Context) fiber.Map) fiber.Map {
+	data := load(ctx)
+	ttl := h.cfg.StatsCacheTTL()
+
+	entry := cachedStats{Data: data, FreshUntil: time.Now().Add(ttl)}
+	if err := h.cache.Set(ctx, key, entry, ttl+statsCacheStaleWindow); err != nil {
+		log.Warn().Err(err).Str("key", key).Msg("Failed to write stats cache")
+	}
+	return data
+}
+
+// refreshInBackground refreshes key unless a refresh is already running
+func (h *StatsHandler) refreshInBackground(key string, load func(context.Context) fiber.Map) {
+	if _, running := h.refreshing.LoadOrStore(key, struct{}{}); running {
+		return
+	}
+
+	go func() {
+		defer h.refreshing.Delete(key)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		h.refreshCache(ctx, key, load)
+	}()
+}
+
 // ============================================================================
 // PHASE 2: ADMIN SYNC CONTROL HANDLERS (Bearer Token Auth)
 // ============================================================================
@@ -865,14 +1142,16 @@ type AdminSyncHandler struct {
 	db           *database.DB
 	syncRepo     *database.SyncRepository
 	queueManager *queue.Manager
+	cfg          *config.Config
 }
 
 // NewAdminSyncHandler creates a new admin sync handler
-func NewAdminSyncHandler(db *database.DB, queueManager *queue.Manager) *AdminSyncHandler {
+func NewAdminSyncHandler(db *database.DB, queueManager *queue.Manager, cfg *config.Config) *AdminSyncHandler {
 	return &AdminSyncHandler{
 		db:           db,
 		syncRepo:     database.NewSyncRepository(db),
 		queueManager: queueManager,
+		cfg:          cfg,
 	}
 }
 
@@ -891,18 +1170,10 @@ func NewAdminSyncHandler(db *database.DB, queueManager *queue.Manager) *AdminSyn
 // @Router /api/admin/sync/logs [get]
 func (h *AdminSyncHandler) GetSyncLogs(c *fiber.Ctx) error {
 	ctx := c.Context()
-	limit := c.QueryInt("limit", 20)
-	offset := c.QueryInt("offset", 0)
+	pagination := parsePagination(c, syncLogPageLimits)
 	syncType := c.Query("type", "")
 
-	if limit > 100 {
-		limit = 100
-	}
-	if limit < 1 {
-		limit = 20
-	}
-
-	logs, err := h.syncRepo.GetSyncLogs(ctx, limit, offset, syncType)
+	logs, err := h.syncRepo.GetSyncLogs(ctx, pagination.Limit, pagination.Offset, syncType)
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to fetch sync logs")
 		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
@@ -910,12 +1181,55 @@ func (h *AdminSyncHandler) GetSyncLogs(c *fiber.Ctx) error {
 			Error:   "Failed to fetch sync logs",
 		})
 	}
+	total, err := h.syncRepo.CountSyncLogs(ctx, syncType)
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to count sync logs")
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Failed to fetch sync logs",
+		})
+	}
 
 	return c.JSON(fiber.Map{
 		"success": true,
 		"logs":    logs,
-		"limit":   limit,
-		"offset":  offset,
+		"limit":   pagination.Limit,
+		"offset":  pagination.Offset,
+		"meta":    pagination.Meta(total),
+	})
+}
+
+// GetSyncLogAdmin handles GET /api/admin/sync/logs/:id
+// @Summary Get sync log (admin)
+// @Description Retrieves one sync log with its per-step timeline and its metadata parsed into an object
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Sync log ID"
+// @Success 200 {object} SuccessResponse "Sync log retrieved"
+// @Failure 404 {object} ErrorResponse "Sync log not found"
+// @Router /api/admin/sync/logs/{id} [get]
+func (h *AdminSyncHandler) GetSyncLogAdmin(c *fiber.Ctx) error {
+	syncLog, err := h.syncRepo.GetSyncLog(c.Context(), c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Sync log not found",
+		})
+	}
+
+	// Metadata is stored as a JSON string; hand it back as an object
+	var metadata map[string]interface{}
+	if syncLog.Metadata != "" {
+		if err := json.Unmarshal([]byte(syncLog.Metadata), &metadata); err != nil {
+			log.Warn().Err(err).Str("sync_log_id", syncLog.ID).Msg("Sync log metadata is not valid JSON")
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"success":  true,
+		"log":      syncLog,
+		"metadata": metadata,
 	})
 }
 
@@ -956,7 +1270,7 @@ func (h *AdminSyncHandler) GetSyncStatusAdmin(c *fiber.Ctx) error {
 
 	h.db.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM users").Scan(&totalUsers)
 	h.db.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM users WHERE \"isMigrated\" = true").Scan(&migratedUsers)
-	h.db.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM servers").Scan(&totalServers)
+	h.db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM servers WHERE "deletedAt" IS NULL`).Scan(&totalServers)
 	h.db.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM nodes").Scan(&totalNodes)
 	h.db.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM locations").Scan(&totalLocations)
 	h.db.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM allocations").Scan(&totalAllocations)
@@ -989,19 +1303,21 @@ func (h *AdminSyncHandler) GetSyncStatusAdmin(c *fiber.Ctx) error {
 
 // TriggerSyncAdminRequest represents a sync trigger request from admin
 type TriggerSyncAdminRequest struct {
-	Type string `json:"type"`
+	Type    string `json:"type"`
+	Confirm string `json:"confirm"` // confirmation token, when SYNC_CONFIRM_DESTRUCTIVE is on
 }
 
 // TriggerSyncAdmin handles POST /api/admin/sync
 // @Summary Trigger sync (admin)
-// @Description Triggers a synchronization operation with specified type
+// @Description Triggers a synchronization operation with specified type. When SYNC_CONFIRM_DESTRUCTIVE is on, the types that prune stale data (full, locations, nodes, servers) first answer 428 with a confirmationToken and an impact preview; repeat the request with the token as confirm within two minutes to run the sync.
 // @Tags Admin
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param payload body TriggerSyncAdminRequest true "Sync trigger parameters"
 // @Success 202 {object} SuccessResponse "Sync queued successfully"
-// @Failure 400 {object} ErrorResponse "Invalid sync type"
+// @Failure 400 {object} ErrorResponse "Invalid sync type or confirmation token"
+// @Failure 428 {object} ErrorResponse "Confirmation required; the response carries a token and the impact"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /api/admin/sync [post]
 func (h *AdminSyncHandler) TriggerSyncAdmin(c *fiber.Ctx) error {
@@ -1018,23 +1334,31 @@ func (h *AdminSyncHandler) TriggerSyncAdmin(c *fiber.Ctx) error {
 
 	// Validate and map sync type
 	validTypes := map[string]bool{
-		"full":        true,
-		"locations":   true,
-		"nodes":       true,
-		"allocations": true,
-		"nests":       true,
-		"servers":     true,
-		"databases":   true,
-		"users":       true,
+		"full":          true,
+		"locations":     true,
+		"nodes":         true,
+		"allocations":   true,
+		"nests":         true,
+		"servers":       true,
+		"databases":     true,
+		"users":         true,
+		"server_states": true,
 	}
 
 	if !validTypes[syncType] {
 		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
 			Success: false,
-			Error:   "Invalid sync type. Valid types: full, locations, nodes, allocations, nests, servers, databases, users",
+			Error:   "Invalid sync type. Valid types: full, locations, nodes, allocations, nests, servers, databases, users, server_states",
 		})
 	}
 
+	if prunes := syncPrunes[syncType]; len(prunes) > 0 {
+		impact := func(ctx context.Context) SyncImpact { return pruneImpact(ctx, h.db, h.cfg, prunes) }
+		if ok, err := requireSyncConfirmation(c, h.db, h.cfg, database.SyncConfirmSync, syncType, req.Confirm, impact); !ok {
+			return err
+		}
+	}
+
 	// Create sync log
 	syncLog, err := h.syncRepo.CreateSyncLog(c.Context(), syncType, "PENDING", map[string]interface{}{
 		"requested_by": "admin",
@@ -1075,6 +1399,9 @@ func (h *AdminSyncHandler) TriggerSyncAdmin(c *fiber.Ctx) error {
 	case "users":
 		payload := queue.SyncPayload{SyncLogID: syncLog.ID}
 		taskInfo, err = h.queueManager.EnqueueSyncUsers(payload)
+	case "server_states":
+		payload := queue.SyncPayload{SyncLogID: syncLog.ID}
+		taskInfo, err = h.queueManager.EnqueueSyncServerStates(payload)
 	}
 
 	if err != nil {
@@ -1096,19 +1423,109 @@ func (h *AdminSyncHandler) TriggerSyncAdmin(c *fiber.Ctx) error {
 	})
 }
 
+// TriggerSyncBatchAdminRequest lists the sync types to run in order
+type TriggerSyncBatchAdminRequest struct {
+	Types   []string `json:"types"`
+	Confirm string   `json:"confirm"` // confirmation token, when SYNC_CONFIRM_DESTRUCTIVE is on
+}
+
+// TriggerSyncBatchAdmin handles POST /api/admin/sync/batch
+// @Summary Trigger batch sync (admin)
+// @Description Runs the given sync types in order under a single sync log, each starting after the previous one completes. The log's progress counts finished steps and the first failure stops the batch. Valid types: locations, nodes, allocations, nests, users, servers, databases, server_states; each may appear once. When SYNC_CONFIRM_DESTRUCTIVE is on, a batch with a locations, nodes or servers step first answers 428 with a confirmationToken and an impact preview; repeat the request with the token as confirm to run it.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param payload body TriggerSyncBatchAdminRequest true "Ordered sync types"
+// @Success 202 {object} SuccessResponse "Batch sync queued successfully"
+// @Failure 400 {object} ErrorResponse "Missing, unknown or repeated sync type, or invalid confirmation token"
+// @Failure 428 {object} ErrorResponse "Confirmation required; the response carries a token and the impact"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/sync/batch [post]
+func (h *AdminSyncHandler) TriggerSyncBatchAdmin(c *fiber.Ctx) error {
+	var req TriggerSyncBatchAdminRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Invalid request body",
+		})
+	}
+	if err := queue.ValidateBatchSteps(req.Types); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+	}
+
+	if prunes := batchPrunes(req.Types); len(prunes) > 0 {
+		impact := func(ctx context.Context) SyncImpact { return pruneImpact(ctx, h.db, h.cfg, prunes) }
+		if ok, err := requireSyncConfirmation(c, h.db, h.cfg, database.SyncConfirmBatch, strings.Join(req.Types, ","), req.Confirm, impact); !ok {
+			return err
+		}
+	}
+
+	syncLog, err := h.syncRepo.CreateSyncLog(c.Context(), "batch", "PENDING", map[string]interface{}{
+		"requested_by": "admin",
+		"batch_steps":  req.Types,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create sync log")
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Failed to create sync log",
+		})
+	}
+
+	taskInfo, err := h.queueManager.EnqueueSyncBatch(queue.SyncBatchPayload{
+		SyncLogID:   syncLog.ID,
+		RequestedBy: "admin",
+		Steps:       req.Types,
+	})
+	if err != nil {
+		log.Error().Err(err).Strs("types", req.Types).Msg("Failed to enqueue batch sync")
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Failed to enqueue sync",
+		})
+	}
+
+	log.Info().Str("sync_log_id", syncLog.ID).Strs("types", req.Types).Str("task_id", taskInfo.ID).Msg("Batch sync enqueued from admin")
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"success":     true,
+		"sync_log_id": syncLog.ID,
+		"task_id":     taskInfo.ID,
+		"status":      "PENDING",
+		"steps":       req.Types,
+		"message":     "Batch sync has been queued",
+	})
+}
+
 // CancelSyncAdmin handles POST /api/admin/sync/cancel
 // @Summary Cancel sync (admin)
-// @Description Requests cancellation of the currently running sync operation
+// @Description Requests cancellation of the currently running sync operation. When SYNC_CONFIRM_DESTRUCTIVE is on, the first request answers 428 with a confirmationToken describing the sync; repeat it with the token as confirm to cancel. The token is bound to that sync.
 // @Tags Admin
 // @Accept json
 // @Produce json
 // @Security BearerAuth
+// @Param payload body SyncConfirmRequest false "Confirmation token"
 // @Success 200 {object} SuccessResponse "Cancellation requested"
 // @Failure 404 {object} ErrorResponse "No active sync found"
-// @Failure 400 {object} ErrorResponse "Sync cannot be cancelled"
+// @Failure 400 {object} ErrorResponse "Sync cannot be cancelled or invalid confirmation token"
+// @Failure 428 {object} ErrorResponse "Confirmation required; the response carries a token and the impact"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /api/admin/sync/cancel [post]
 func (h *AdminSyncHandler) CancelSyncAdmin(c *fiber.Ctx) error {
+	var req SyncConfirmRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Success: false,
+				Error:   "Invalid request body",
+			})
+		}
+	}
+
 	// Get the latest sync that is in progress
 	logs, err := h.syncRepo.GetSyncLogs(c.Context(), 1, 0, "")
 	if err != nil || len(logs) == 0 {
@@ -1126,6 +1543,11 @@ func (h *AdminSyncHandler) CancelSyncAdmin(c *fiber.Ctx) error {
 		})
 	}
 
+	impact := func(context.Context) SyncImpact { return cancelImpact(latestLog) }
+	if ok, err := requireSyncConfirmation(c, h.db, h.cfg, database.SyncConfirmCancel, latestLog.ID, req.Confirm, impact); !ok {
+		return err
+	}
+
 	// Mark sync for cancellation - worker will check this and stop
 	err = h.syncRepo.MarkSyncCancelled(c.Context(), latestLog.ID)
 	if err != nil {
@@ -1145,6 +1567,517 @@ func (h *AdminSyncHandler) CancelSyncAdmin(c *fiber.Ctx) error {
 	})
 }
 
+// ResumeSyncAdmin handles POST /api/admin/sync/:id/resume
+// @Summary Resume failed sync (admin)
+// @Description Starts a new full sync from the step where the given sync failed, skipping the steps that already completed. When SYNC_CONFIRM_DESTRUCTIVE is on and the remaining steps prune stale data, the first request answers 428 with a confirmationToken and an impact preview; repeat it with the token as confirm to resume. The token is bound to the failed sync.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Failed sync log ID"
+// @Param payload body SyncConfirmRequest false "Confirmation token"
+// @Success 202 {object} SuccessResponse "Resumed sync queued"
+// @Failure 400 {object} ErrorResponse "Sync cannot be resumed or invalid confirmation token"
+// @Failure 404 {object} ErrorResponse "Sync not found"
+// @Failure 428 {object} ErrorResponse "Confirmation required; the response carries a token and the impact"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/sync/{id}/resume [post]
+func (h *AdminSyncHandler) ResumeSyncAdmin(c *fiber.Ctx) error {
+	syncLogID := c.Params("id")
+
+	var req SyncConfirmRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Success: false,
+				Error:   "Invalid request body",
+			})
+		}
+	}
+
+	original, err := h.syncRepo.GetSyncLog(c.Context(), syncLogID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Sync not found",
+		})
+	}
+
+	if original.Type != "full" {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Only full syncs can be resumed",
+		})
+	}
+
+	if original.Status != "FAILED" {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Only failed syncs can be resumed",
+		})
+	}
+
+	var metadata struct {
+		FailedStep string `json:"failed_step"`
+		SkipUsers  bool   `json:"skip_users"`
+	}
+	if original.Metadata != "" {
+		if err := json.Unmarshal([]byte(original.Metadata), &metadata); err != nil {
+			log.Error().Err(err).Str("sync_log_id", original.ID).Msg("Failed to decode failed sync metadata")
+			return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+				Success: false,
+				Error:   "Failed to read the failed sync's options",
+			})
+		}
+	}
+
+	if queue.FullSyncStepIndex(metadata.FailedStep) < 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Sync has no resumable failed step",
+		})
+	}
+
+	if prunes := batchPrunes(resumeSteps(metadata.FailedStep)); len(prunes) > 0 {
+		impact := func(ctx context.Context) SyncImpact { return pruneImpact(ctx, h.db, h.cfg, prunes) }
+		if ok, err := requireSyncConfirmation(c, h.db, h.cfg, database.SyncConfirmResume, original.ID, req.Confirm, impact); !ok {
+			return err
+		}
+	}
+
+	syncLog, err := h.syncRepo.CreateSyncLog(c.Context(), "full", "PENDING", map[string]interface{}{
+		"requested_by": "admin",
+		"resumed_from": original.ID,
+		"start_step":   metadata.FailedStep,
+		"skip_users":   metadata.SkipUsers,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to create sync log")
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Failed to create sync log",
+		})
+	}
+
+	taskInfo, err := h.queueManager.EnqueueSyncFull(queue.SyncFullPayload{
+		SyncLogID:   syncLog.ID,
+		RequestedBy: "admin",
+		SkipUsers:   metadata.SkipUsers,
+		StartStep:   metadata.FailedStep,
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to enqueue resumed sync")
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Failed to enqueue sync",
+		})
+	}
+
+	log.Info().
+		Str("sync_log_id", syncLog.ID).
+		Str("resumed_from", original.ID).
+		Str("start_step", metadata.FailedStep).
+		Str("task_id", taskInfo.ID).
+		Msg("Failed sync resumed from admin")
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"success":      true,
+		"sync_log_id":  syncLog.ID,
+		"task_id":      taskInfo.ID,
+		"resumed_from": original.ID,
+		"start_step":   metadata.FailedStep,
+		"status":       "PENDING",
+		"message":      "Sync has been queued from the failed step",
+	})
+}
+
+// NotifySyncAdmin handles POST /api/admin/sync/:id/notify
+// @Summary Re-send sync notification (admin)
+// @Description Re-sends the completion or failure notification of a finished sync to every enabled admin SYSTEM webhook through the retry queue, e.g. after a Discord outage lost the original
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Sync log ID"
+// @Success 202 {object} SuccessResponse "Notifications queued"
+// @Failure 400 {object} ErrorResponse "Sync has not finished or no webhooks are configured"
+// @Failure 404 {object} ErrorResponse "Sync not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/sync/{id}/notify [post]
+func (h *AdminSyncHandler) NotifySyncAdmin(c *fiber.Ctx) error {
+	syncLog, err := h.syncRepo.GetSyncLog(c.Context(), c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Sync not found",
+		})
+	}
+
+	switch syncLog.Status {
+	case "COMPLETED", "FAILED", "CANCELLED":
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Only finished syncs can be notified",
+		})
+	}
+
+	webhooks, err := h.db.GetSyncNotificationWebhooks(c.Context())
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch sync notification webhooks")
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Failed to fetch webhooks",
+		})
+	}
+	if len(webhooks) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Success: false,
+			Error:   "No enabled admin SYSTEM webhooks to notify",
+		})
+	}
+
+	taskIDs := []string{}
+	for _, webhook := range webhooks {
+		taskInfo, err := h.queueManager.EnqueueSyncResultWebhook(queue.SyncResultWebhookPayload{
+			SyncLogID: syncLog.ID,
+			WebhookID: webhook.ID,
+		})
+		if err != nil {
+			log.Error().Err(err).Str("webhook_id", webhook.ID).Msg("Failed to enqueue sync notification")
+			continue
+		}
+		taskIDs = append(taskIDs, taskInfo.ID)
+	}
+	if len(taskIDs) == 0 {
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Failed to enqueue notifications",
+		})
+	}
+
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"success":     true,
+		"sync_log_id": syncLog.ID,
+		"task_ids":    taskIDs,
+		"message":     "Sync notification has been queued",
+	})
+}
+
+// GetSyncChangesAdmin handles GET /api/admin/sync/:id/changes
+// @Summary Get sync changes (admin)
+// @Description Lists the servers, users, nodes and locations a sync run created, updated or deleted, with per-type counts. At most 10000 changes are stored per run; truncated is set when the cap was reached.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Sync log ID"
+// @Param entityType query string false "Filter by entity type" Enums(server, user, node, location)
+// @Param action query string false "Filter by action" Enums(created, updated, deleted)
+// @Param limit query int false "Limit results (default 100)" Default(100) Minimum(1) Maximum(500)
+// @Param offset query int false "Offset for pagination (default 0)" Default(0) Minimum(0)
+// @Success 200 {object} SuccessResponse "Sync changes retrieved"
+// @Failure 404 {object} ErrorResponse "Sync not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/sync/{id}/changes [get]
+func (h *AdminSyncHandler) GetSyncChangesAdmin(c *fiber.Ctx) error {
+	ctx := c.Context()
+	syncLogID := c.Params("id")
+
+	pagination := parsePagination(c, PageLimits{Default: 100, Max: 500})
+
+	if _, err := h.syncRepo.GetSyncLog(ctx, syncLogID); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Sync not found",
+		})
+	}
+
+	changes, total, err := h.syncRepo.GetSyncChanges(ctx, syncLogID, c.Query("entityType"), c.Query("action"), pagination.Limit, pagination.Offset)
+	if err != nil {
+		log.Error().Err(err).Str("sync_log_id", syncLogID).Msg("Failed to fetch sync changes")
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Failed to fetch sync changes",
+		})
+	}
+
+	summary, err := h.syncRepo.SummarizeSyncChanges(ctx, syncLogID)
+	if err != nil {
+		log.Error().Err(err).Str("sync_log_id", syncLogID).Msg("Failed to summarize sync changes")
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Failed to fetch sync changes",
+		})
+	}
+
+	stored := 0
+	for _, actions := range summary {
+		for _, n := range actions {
+			stored += n
+		}
+	}
+
+	return c.JSON(fiber.Map{
+		"success":   true,
+		"changes":   changes,
+		"summary":   summary,
+		"truncated": stored >= database.MaxSyncChangesPerRun,
+		"total":     total,
+		"limit":     pagination.Limit,
+		"offset":    pagination.Offset,
+		"meta":      pagination.Meta(total),
+	})
+}
+
+// SyncRunChangeCounts counts one run's changes to an entity type; Net is
+// Added minus Removed
+type SyncRunChangeCounts struct {
+	Added   int `json:"added"`
+	Removed int `json:"removed"`
+	Updated int `json:"updated"`
+	Net     int `json:"net"`
+}
+
+// SyncChangeComparison compares two runs' changes to one entity type;
+// Change is To minus From
+type SyncChangeComparison struct {
+	From   SyncRunChangeCounts `json:"from"`
+	To     SyncRunChangeCounts `json:"to"`
+	Change SyncRunChangeCounts `json:"change"`
+}
+
+// SyncCountComparison compares one item count between two runs
+type SyncCountComparison struct {
+	From   int `json:"from"`
+	To     int `json:"to"`
+	Change int `json:"change"`
+}
+
+// syncRunChangeCounts converts a SummarizeSyncChanges entry
+func syncRunChangeCounts(actions map[string]int) SyncRunChangeCounts {
+	added, removed := actions[database.SyncChangeCreated], actions[database.SyncChangeDeleted]
+	return SyncRunChangeCounts{Added: added, Removed: removed, Updated: actions[database.SyncChangeUpdated], Net: added - removed}
+}
+
+// compareSyncChanges compares two runs' change summaries for every entity
+// type either run changed
+func compareSyncChanges(from, to map[string]map[string]int) map[string]SyncChangeComparison {
+	result := make(map[string]SyncChangeComparison)
+	for _, summary := range []map[string]map[string]int{from, to} {
+		for entityType := range summary {
+			if _, done := result[entityType]; done {
+				continue
+			}
+			f, t := syncRunChangeCounts(from[entityType]), syncRunChangeCounts(to[entityType])
+			result[entityType] = SyncChangeComparison{
+				From: f,
+				To:   t,
+				Change: SyncRunChangeCounts{
+					Added:   t.Added - f.Added,
+					Removed: t.Removed - f.Removed,
+					Updated: t.Updated - f.Updated,
+					Net:     t.Net - f.Net,
+				},
+			}
+		}
+	}
+	return result
+}
+
+// CompareSyncsAdmin handles GET /api/admin/sync/compare
+// @Summary Compare two sync runs (admin)
+// @Description Compares two sync runs' item counts and, per entity type, the entities each run added, removed and updated, with the net change (added minus removed) and the difference between the runs (to minus from). truncated is set when either run hit the 10000 stored change cap, so its entity counts are incomplete.
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Param from query string true "Earlier sync log ID"
+// @Param to query string true "Later sync log ID"
+// @Success 200 {object} SuccessResponse "Sync comparison"
+// @Failure 400 {object} ErrorResponse "from and to are required"
+// @Failure 404 {object} ErrorResponse "Sync not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/sync/compare [get]
+func (h *AdminSyncHandler) CompareSyncsAdmin(c *fiber.Ctx) error {
+	ctx := c.Context()
+	fromID, toID := c.Query("from"), c.Query("to")
+	if fromID == "" || toID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Success: false,
+			Error:   "from and to sync log IDs are required",
+		})
+	}
+
+	var runs [2]*database.SyncLog
+	var summaries [2]map[string]map[string]int
+	truncated := false
+	for i, id := range []string{fromID, toID} {
+		syncLog, err := h.syncRepo.GetSyncLog(ctx, id)
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+				Success: false,
+				Error:   "Sync not found: " + id,
+			})
+		}
+		summary, err := h.syncRepo.SummarizeSyncChanges(ctx, id)
+		if err != nil {
+			log.Error().Err(err).Str("sync_log_id", id).Msg("Failed to summarize sync changes")
+			return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+				Success: false,
+				Error:   "Failed to fetch sync changes",
+			})
+		}
+
+		stored := 0
+		for _, actions := range summary {
+			for _, n := range actions {
+				stored += n
+			}
+		}
+		truncated = truncated || stored >= database.MaxSyncChangesPerRun
+		runs[i], summaries[i] = syncLog, summary
+	}
+
+	from, to := runs[0], runs[1]
+	count := func(f, t int) SyncCountComparison {
+		return SyncCountComparison{From: f, To: t, Change: t - f}
+	}
+
+	return c.JSON(fiber.Map{
+		"success": true,
+		"from":    from,
+		"to":      to,
+		"counts": fiber.Map{
+			"itemsTotal":  count(from.ItemsTotal, to.ItemsTotal),
+			"itemsSynced": count(from.ItemsSynced, to.ItemsSynced),
+			"itemsFailed": count(from.ItemsFailed, to.ItemsFailed),
+		},
+		"entities":  compareSyncChanges(summaries[0], summaries[1]),
+		"truncated": truncated,
+	})
+}
+
+// GetSyncLockAdmin handles GET /api/admin/sync/lock
+// @Summary Get sync lock status (admin)
+// @Description Shows the unfinished sync currently holding the sync lock, its age, and whether it exceeds the max sync duration
+// @Tags Admin
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} SuccessResponse "Lock status retrieved"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/sync/lock [get]
+func (h *AdminSyncHandler) GetSyncLockAdmin(c *fiber.Ctx) error {
+	maxAge := h.cfg.SyncMaxAge()
+
+	active, err := h.syncRepo.GetActiveSyncLog(c.Context())
+	if err == pgx.ErrNoRows {
+		return c.JSON(fiber.Map{
+			"success":            true,
+			"locked":             false,
+			"maxDurationMinutes": int(maxAge.Minutes()),
+		})
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch active sync")
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Failed to fetch sync lock status",
+		})
+	}
+
+	var metadata struct {
+		RequestedBy string `json:"requested_by"`
+		Step        string `json:"step"`
+	}
+	json.Unmarshal([]byte(active.Metadata), &metadata)
+
+	age := time.Since(active.StartedAt)
+	return c.JSON(fiber.Map{
+		"success": true,
+		"locked":  true,
+		"holder": fiber.Map{
+			"syncLogId":   active.ID,
+			"type":        active.Type,
+			"status":      active.Status,
+			"step":        metadata.Step,
+			"requestedBy": metadata.RequestedBy,
+			"startedAt":   active.StartedAt,
+		},
+		"ageSeconds":         int(age.Seconds()),
+		"maxDurationMinutes": int(maxAge.Minutes()),
+		"stale":              age > maxAge,
+	})
+}
+
+// ReleaseSyncLockAdmin handles POST /api/admin/sync/lock/release
+// @Summary Release sync lock (admin)
+// @Description Marks every unfinished sync as FAILED so new syncs are not blocked by a crashed worker. When SYNC_CONFIRM_DESTRUCTIVE is on, the first request answers 428 with a confirmationToken describing the sync holding the lock; repeat it with the token as confirm to release it.
+// @Tags Admin
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param payload body SyncConfirmRequest false "Confirmation token"
+// @Success 200 {object} SuccessResponse "Lock released"
+// @Failure 400 {object} ErrorResponse "Invalid confirmation token"
+// @Failure 404 {object} ErrorResponse "No sync holds the lock"
+// @Failure 428 {object} ErrorResponse "Confirmation required; the response carries a token and the impact"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/admin/sync/lock/release [post]
+func (h *AdminSyncHandler) ReleaseSyncLockAdmin(c *fiber.Ctx) error {
+	adminID, _ := c.Locals("userID").(string)
+
+	var req SyncConfirmRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Success: false,
+				Error:   "Invalid request body",
+			})
+		}
+	}
+
+	active, err := h.syncRepo.GetActiveSyncLog(c.Context())
+	if errors.Is(err, pgx.ErrNoRows) {
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Success: false,
+			Error:   "No sync holds the lock",
+		})
+	}
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to fetch the sync holding the lock")
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Failed to release sync lock",
+		})
+	}
+
+	impact := func(context.Context) SyncImpact { return releaseImpact(*active) }
+	if ok, err := requireSyncConfirmation(c, h.db, h.cfg, database.SyncConfirmRelease, active.ID, req.Confirm, impact); !ok {
+		return err
+	}
+
+	ids, err := h.syncRepo.FailActiveSyncs(c.Context(), time.Now(), "Sync lock released manually by an administrator")
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to release sync lock")
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Failed to release sync lock",
+		})
+	}
+	if len(ids) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Success: false,
+			Error:   "No sync holds the lock",
+		})
+	}
+
+	log.Warn().Strs("sync_log_ids", ids).Str("admin_id", adminID).Msg("Sync lock released by admin")
+
+	return c.JSON(fiber.Map{
+		"success":      true,
+		"sync_log_ids": ids,
+		"message":      "Sync lock released",
+	})
+}
+
 // GetSyncSettingsAdmin handles GET /api/admin/sync/settings
 // @Summary Get sync settings (admin)
 // @Description Retrieves current sync automation settings
@@ -1173,24 +2106,44 @@ func (h *AdminSyncHandler) GetSyncSettingsAdmin(c *fiber.Ctx) error {
 		autoSyncInterval = 3600
 	}
 
+	// Get auto sync cron (takes precedence over the interval when set)
+	autoSyncCron, _ := h.db.GetConfig(ctx, "auto_sync_cron")
+
 	return c.JSON(SuccessResponse{
 		Success: true,
 		Data: fiber.Map{
 			"autoSyncEnabled":  autoSyncEnabled == "true",
 			"autoSyncInterval": autoSyncInterval,
+			"autoSyncCron":     autoSyncCron,
+			"nextRun":          nextAutoSyncRun(autoSyncCron),
 		},
 	})
 }
 
+// nextAutoSyncRun returns the next run time for a cron schedule, or nil when
+// no valid cron is set
+func nextAutoSyncRun(spec string) *time.Time {
+	if spec == "" {
+		return nil
+	}
+	schedule, err := config.ParseAutoSyncCron(spec)
+	if err != nil {
+		return nil
+	}
+	next := schedule.Next(time.Now())
+	return &next
+}
+
 // UpdateSyncSettingsAdminRequest represents a request to update sync settings
 type UpdateSyncSettingsAdminRequest struct {
-	AutoSyncEnabled  *bool `json:"autoSyncEnabled,omitempty"`
-	AutoSyncInterval *int  `json:"autoSyncInterval,omitempty"`
+	AutoSyncEnabled  *bool   `json:"autoSyncEnabled,omitempty"`
+	AutoSyncInterval *int    `json:"autoSyncInterval,omitempty"`
+	AutoSyncCron     *string `json:"autoSyncCron,omitempty"` // 5-field cron; empty string switches back to interval mode
 }
 
 // UpdateSyncSettingsAdmin handles POST /api/admin/sync/settings
 // @Summary Update sync settings (admin)
-// @Description Updates sync automation settings (enabled status and interval)
+// @Description Updates sync automation settings (enabled status, interval, or cron schedule)
 // @Tags Admin
 // @Accept json
 // @Produce json
@@ -1244,11 +2197,36 @@ func (h *AdminSyncHandler) UpdateSyncSettingsAdmin(c *fiber.Ctx) error {
 		}
 	}
 
+	var nextRun *time.Time
+	if req.AutoSyncCron != nil {
+		cronSpec := strings.TrimSpace(*req.AutoSyncCron)
+		if cronSpec != "" {
+			if _, err := config.ParseAutoSyncCron(cronSpec); err != nil {
+				return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+					Success: false,
+					Error:   fmt.Sprintf("Invalid cron expression: %v", err),
+				})
+			}
+		}
+
+		if err := h.db.SetConfig(ctx, "auto_sync_cron", cronSpec); err != nil {
+			log.Error().Err(err).Msg("Failed to update auto_sync_cron")
+			return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+				Success: false,
+				Error:   "Failed to update settings",
+			})
+		}
+		nextRun = nextAutoSyncRun(cronSpec)
+	}
+
 	log.Info().Msg("Sync settings updated by admin")
 
 	return c.JSON(SuccessResponse{
 		Success: true,
 		Message: "Settings updated successfully",
+		Data: fiber.Map{
+			"nextRun": nextRun,
+		},
 	})
 }
 
@@ -1267,10 +2245,10 @@ func (h *StatsHandler) GetAdminStats(c *fiber.Ctx) error {
 
 	var totalServers, totalUsers, totalNodes, suspendedServers, totalAllocations, usedAllocations int
 
-	h.db.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM servers").Scan(&totalServers)
+	h.db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM servers WHERE "deletedAt" IS NULL`).Scan(&totalServers)
 	h.db.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM users").Scan(&totalUsers)
 	h.db.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM nodes").Scan(&totalNodes)
-	h.db.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM servers WHERE \"isSuspended\" = true").Scan(&suspendedServers)
+	h.db.Pool.QueryRow(ctx, `SELECT COUNT(*) FROM servers WHERE "isSuspended" = true AND "deletedAt" IS NULL`).Scan(&suspendedServers)
 	h.db.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM allocations").Scan(&totalAllocations)
 	h.db.Pool.QueryRow(ctx, "SELECT COUNT(*) FROM allocations WHERE \"isAssigned\" = true").Scan(&usedAllocations)
 
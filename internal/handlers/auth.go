@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"regexp"
 	"time"
 	"unicode"
@@ -11,6 +13,7 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"github.com/nodebyte/backend/internal/auth"
+	"github.com/nodebyte/backend/internal/config"
 	"github.com/nodebyte/backend/internal/database"
 	"github.com/nodebyte/backend/internal/queue"
 )
@@ -20,14 +23,16 @@ type AuthHandler struct {
 	db           *database.DB
 	queueManager *queue.Manager
 	jwtService   *auth.JWTService
+	cfg          *config.Config
 }
 
 // NewAuthHandler creates a new auth handler
-func NewAuthHandler(db *database.DB, queueManager *queue.Manager, jwtService *auth.JWTService) *AuthHandler {
+func NewAuthHandler(db *database.DB, queueManager *queue.Manager, jwtService *auth.JWTService, cfg *config.Config) *AuthHandler {
 	return &AuthHandler{
 		db:           db,
 		queueManager: queueManager,
 		jwtService:   jwtService,
+		cfg:          cfg,
 	}
 }
 
@@ -63,6 +68,7 @@ type UserData struct {
 	IsSystemAdmin      bool     `json:"isSystemAdmin"`
 	PterodactylID      *int     `json:"pterodactylId"`
 	EmailVerified      *string  `json:"emailVerified"`
+	ImpersonatedBy     string   `json:"impersonatedBy,omitempty"` // set when the session is an admin impersonation
 }
 
 // ValidatePassword checks if password meets requirements
@@ -120,6 +126,7 @@ func validateEmail(email string) error {
 // @Success 200 {object} AuthResponse "Login successful with JWT tokens"
 // @Failure 400 {object} AuthResponse "Invalid request"
 // @Failure 401 {object} AuthResponse "Invalid credentials or email not verified"
+// @Failure 403 {object} AuthResponse "Password reset required (imported account)"
 // @Failure 500 {object} AuthResponse "Internal server error"
 // @Router /api/v1/auth/login [post]
 func (h *AuthHandler) AuthenticateUser(c *fiber.Ctx) error {
@@ -155,6 +162,14 @@ func (h *AuthHandler) AuthenticateUser(c *fiber.Ctx) error {
 		})
 	}
 
+	// Imported accounts have no password until one is set via password reset
+	if user.PasswordResetRequired {
+		return c.Status(fiber.StatusForbidden).JSON(AuthResponse{
+			Success: false,
+			Error:   "password_reset_required",
+		})
+	}
+
 	// Verify password
 	if !user.VerifyPassword(req.Password) {
 		return c.Status(fiber.StatusUnauthorized).JSON(AuthResponse{
@@ -205,6 +220,7 @@ func (h *AuthHandler) AuthenticateUser(c *fiber.Ctx) error {
 			Error:   "session_creation_failed",
 		})
 	}
+	setRefreshTokenCookie(c, h.cfg, tokenPair.RefreshToken, expiresAt)
 
 	// Return user data with tokens
 	userData := &UserData{
@@ -240,17 +256,19 @@ type RegisterUserRequest struct {
 	Username        *string `json:"username,omitempty"`
 	FirstName       *string `json:"firstName,omitempty"`
 	LastName        *string `json:"lastName,omitempty"`
+	InviteCode      string  `json:"inviteCode,omitempty"` // required in invite-only mode
 }
 
 // RegisterUser handles user registration
 // @Summary User Registration
-// @Description Registers a new user account and sends verification email
+// @Description Registers a new user account and sends verification email. When invite-only mode is enabled, a valid inviteCode is required and one of its uses is consumed.
 // @Tags Authentication
 // @Accept json
 // @Produce json
 // @Param registration body RegisterUserRequest true "Registration details"
 // @Success 201 {object} AuthResponse "User registered successfully"
 // @Failure 400 {object} AuthResponse "Invalid request or validation error"
+// @Failure 403 {object} AuthResponse "Email domain not allowed or invite required/invalid"
 // @Failure 409 {object} AuthResponse "Email already exists"
 // @Failure 500 {object} AuthResponse "Internal server error"
 // @Router /api/v1/auth/register [post]
@@ -278,6 +296,13 @@ func (h *AuthHandler) RegisterUser(c *fiber.Ctx) error {
 		})
 	}
 
+	if !h.cfg.EmailDomainAllowed(req.Email) {
+		return c.Status(fiber.StatusForbidden).JSON(AuthResponse{
+			Success: false,
+			Error:   "email_domain_not_allowed",
+		})
+	}
+
 	if err := validatePassword(req.Password); err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(AuthResponse{
 			Success: false,
@@ -314,16 +339,46 @@ func (h *AuthHandler) RegisterUser(c *fiber.Ctx) error {
 		username = &parts
 	}
 
+	// Invite-only mode: consume one use of the invite before creating the user
+	var inviteID string
+	if h.cfg.InviteOnly {
+		if req.InviteCode == "" {
+			return c.Status(fiber.StatusForbidden).JSON(AuthResponse{
+				Success: false,
+				Error:   "invite_required",
+			})
+		}
+		inviteID, err = h.db.ConsumeInvite(c.Context(), req.InviteCode, req.Email)
+		if err != nil {
+			log.Error().Err(err).Str("email", req.Email).Msg("Failed to consume invite")
+			return c.Status(fiber.StatusInternalServerError).JSON(AuthResponse{
+				Success: false,
+				Error:   "server_error",
+			})
+		}
+		if inviteID == "" {
+			return c.Status(fiber.StatusForbidden).JSON(AuthResponse{
+				Success: false,
+				Error:   "invalid_invite",
+			})
+		}
+	}
+
 	// Create new user
 	user, err := h.db.CreateUser(c.Context(), &database.User{
 		Email:     req.Email,
 		Username:  database.NewNullString(*username),
 		FirstName: database.NewNullString(getPointerValue(req.FirstName)),
 		LastName:  database.NewNullString(getPointerValue(req.LastName)),
-		Roles:     []string{"MEMBER"},
+		Roles:     []string{h.defaultRole(c.Context())},
 	}, req.Password)
 
 	if err != nil {
+		if inviteID != "" {
+			if releaseErr := h.db.ReleaseInvite(c.Context(), inviteID); releaseErr != nil {
+				log.Error().Err(releaseErr).Str("invite_id", inviteID).Msg("Failed to release invite")
+			}
+		}
 		log.Error().Err(err).Str("email", req.Email).Msg("Failed to create user")
 		return c.Status(fiber.StatusInternalServerError).JSON(AuthResponse{
 			Success: false,
@@ -332,11 +387,12 @@ func (h *AuthHandler) RegisterUser(c *fiber.Ctx) error {
 	}
 
 	// Generate verification token
+	verifyTTL := h.cfg.TokenTTL(database.VerificationTokenType)
 	token, err := h.db.StoreVerificationToken(
 		c.Context(),
 		user.ID,
 		database.VerificationTokenType,
-		database.TokenExpiration,
+		verifyTTL,
 	)
 
 	if err != nil {
@@ -351,9 +407,10 @@ func (h *AuthHandler) RegisterUser(c *fiber.Ctx) error {
 			Subject:  "Verify your email",
 			Template: "verify-email",
 			Data: map[string]string{
-				"name":  getPointerValue(req.FirstName),
-				"token": token,
-				"email": user.Email,
+				"name":      getPointerValue(req.FirstName),
+				"token":     token,
+				"email":     user.Email,
+				"expiresIn": formatTokenTTL(verifyTTL),
 			},
 		})
 	}
@@ -415,6 +472,61 @@ func (h *AuthHandler) VerifyEmail(c *fiber.Ctx) error {
 	})
 }
 
+// ConfirmEmailChangeRequest represents an email change confirmation request
+type ConfirmEmailChangeRequest struct {
+	Token string `json:"token"`
+}
+
+// ConfirmEmailChange completes a pending email change
+// @Summary Confirm Email Change
+// @Description Swaps the user's email for the pending address using the token sent to that address
+// @Tags Authentication
+// @Accept json
+// @Produce json
+// @Param confirmation body ConfirmEmailChangeRequest true "Email change token"
+// @Success 200 {object} AuthResponse "Email changed successfully"
+// @Failure 400 {object} AuthResponse "Invalid or missing token"
+// @Failure 409 {object} AuthResponse "The new email now belongs to another user"
+// @Router /api/v1/auth/confirm-email-change [post]
+func (h *AuthHandler) ConfirmEmailChange(c *fiber.Ctx) error {
+	var req ConfirmEmailChangeRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(AuthResponse{
+			Success: false,
+			Error:   "invalid_request",
+		})
+	}
+
+	if req.Token == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(AuthResponse{
+			Success: false,
+			Error:   "missing_fields",
+		})
+	}
+
+	newEmail, err := h.db.ConfirmEmailChange(c.Context(), req.Token)
+	if errors.Is(err, database.ErrEmailTaken) {
+		return c.Status(fiber.StatusConflict).JSON(AuthResponse{
+			Success: false,
+			Error:   "email_exists",
+		})
+	}
+	if err != nil {
+		log.Warn().Err(err).Msg("Email change confirmation failed")
+		return c.Status(fiber.StatusBadRequest).JSON(AuthResponse{
+			Success: false,
+			Error:   "invalid_token",
+		})
+	}
+
+	log.Info().Str("email", newEmail).Msg("Email change confirmed")
+
+	return c.Status(fiber.StatusOK).JSON(AuthResponse{
+		Success: true,
+		Message: "Email changed successfully",
+	})
+}
+
 // ForgotPasswordRequest represents a forgot password request
 type ForgotPasswordRequest struct {
 	Email string `json:"email"`
@@ -459,11 +571,12 @@ func (h *AuthHandler) ForgotPassword(c *fiber.Ctx) error {
 	user, err := h.db.QueryUserByEmail(c.Context(), req.Email)
 	if err == nil && user != nil {
 		// Generate password reset token
+		resetTTL := h.cfg.TokenTTL(database.PasswordResetTokenType)
 		token, err := h.db.StoreVerificationToken(
 			c.Context(),
 			user.ID,
 			database.PasswordResetTokenType,
-			database.TokenExpiration,
+			resetTTL,
 		)
 
 		if err != nil {
@@ -475,9 +588,10 @@ func (h *AuthHandler) ForgotPassword(c *fiber.Ctx) error {
 				Subject:  "Reset your password",
 				Template: "reset-password",
 				Data: map[string]string{
-					"name":  user.FirstName.String,
-					"token": token,
-					"email": user.Email,
+					"name":      user.FirstName.String,
+					"token":     token,
+					"email":     user.Email,
+					"expiresIn": formatTokenTTL(resetTTL),
 				},
 			})
 			log.Info().Str("email", req.Email).Msg("Password reset requested")
@@ -633,6 +747,21 @@ func getPointerValue(p *string) string {
 	return *p
 }
 
+// formatTokenTTL renders a token lifetime for email copy, e.g. "30 minutes" or "24 hours"
+func formatTokenTTL(d time.Duration) string {
+	plural := func(n int, unit string) string {
+		if n == 1 {
+			return fmt.Sprintf("1 %s", unit)
+		}
+		return fmt.Sprintf("%d %ss", n, unit)
+	}
+
+	if d >= time.Hour && d%time.Hour == 0 {
+		return plural(int(d/time.Hour), "hour")
+	}
+	return plural(int(d/time.Minute), "minute")
+}
+
 // CheckEmailExistsRequest represents a check email request
 type CheckEmailRequest struct {
 	Email string `json:"email"`
@@ -724,6 +853,14 @@ func (h *AuthHandler) ValidateCredentials(c *fiber.Ctx) error {
 		})
 	}
 
+	// Imported accounts have no password until one is set via password reset
+	if user.PasswordResetRequired {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"success": false,
+			"error":   "password_reset_required",
+		})
+	}
+
 	// Verify password
 	if !user.VerifyPassword(req.Password) {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
@@ -807,12 +944,13 @@ func (h *AuthHandler) RequestMagicLink(c *fiber.Ctx) error {
 
 	// Always return success for security
 	if user != nil && err == nil {
-		// Generate magic link token (30 minute expiration)
+		// Generate magic link token (short-lived, 30 minutes by default)
+		magicLinkTTL := h.cfg.TokenTTL(database.MagicLinkTokenType)
 		token, err := h.db.StoreVerificationToken(
 			c.Context(),
 			user.ID,
 			database.MagicLinkTokenType,
-			database.MagicLinkExpiration,
+			magicLinkTTL,
 		)
 
 		if err != nil {
@@ -824,9 +962,10 @@ func (h *AuthHandler) RequestMagicLink(c *fiber.Ctx) error {
 				Subject:  "Your magic link",
 				Template: "magic-link",
 				Data: map[string]string{
-					"name":  user.FirstName.String,
-					"token": token,
-					"email": user.Email,
+					"name":      user.FirstName.String,
+					"token":     token,
+					"email":     user.Email,
+					"expiresIn": formatTokenTTL(magicLinkTTL),
 				},
 			})
 			log.Info().Str("email", req.Email).Msg("Magic link requested")
@@ -917,3 +1056,22 @@ func (h *AuthHandler) VerifyMagicLink(c *fiber.Ctx) error {
 		User:    userData,
 	})
 }
+
+// defaultRole returns the configured role for new registrations, falling back
+// to MEMBER when it is unset or missing from the role catalog
+func (h *AuthHandler) defaultRole(ctx context.Context) string {
+	role := config.DefaultRole
+	if h.cfg != nil && h.cfg.DefaultRole != "" {
+		role = h.cfg.DefaultRole
+	}
+	if role == config.DefaultRole {
+		return role
+	}
+
+	unknown, err := h.db.UnknownRoles(ctx, []string{role})
+	if err != nil || len(unknown) > 0 {
+		log.Warn().Err(err).Str("role", role).Msg("Configured default role is not in the role catalog; using MEMBER")
+		return config.DefaultRole
+	}
+	return role
+}
@@ -8,6 +8,7 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"github.com/nodebyte/backend/internal/auth"
+	"github.com/nodebyte/backend/internal/database"
 )
 
 // RefreshTokenRequest represents a token refresh request
@@ -17,11 +18,11 @@ type RefreshTokenRequest struct {
 
 // RefreshToken handles token refresh
 // @Summary Refresh Access Token
-// @Description Exchanges a valid refresh token for new access and refresh tokens
+// @Description Exchanges a valid refresh token, from the body or the refresh_token cookie, for new access and refresh tokens
 // @Tags Authentication
 // @Accept json
 // @Produce json
-// @Param refresh body RefreshTokenRequest true "Refresh token"
+// @Param refresh body RefreshTokenRequest false "Refresh token (falls back to the refresh_token cookie)"
 // @Success 200 {object} AuthResponse "New tokens generated"
 // @Failure 400 {object} AuthResponse "Missing refresh token"
 // @Failure 401 {object} AuthResponse "Invalid or expired refresh token"
@@ -29,11 +30,17 @@ type RefreshTokenRequest struct {
 // @Router /api/v1/auth/refresh [post]
 func (h *AuthHandler) RefreshToken(c *fiber.Ctx) error {
 	var req RefreshTokenRequest
-	if err := c.BodyParser(&req); err != nil {
-		return c.Status(fiber.StatusBadRequest).JSON(AuthResponse{
-			Success: false,
-			Error:   "invalid_request",
-		})
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(AuthResponse{
+				Success: false,
+				Error:   "invalid_request",
+			})
+		}
+	}
+	if req.RefreshToken == "" {
+		// Browser clients send the refresh token as a cookie
+		req.RefreshToken = c.Cookies(refreshTokenCookie)
 	}
 
 	if req.RefreshToken == "" {
@@ -106,6 +113,7 @@ func (h *AuthHandler) RefreshToken(c *fiber.Ctx) error {
 			Error:   "session_creation_failed",
 		})
 	}
+	setRefreshTokenCookie(c, h.cfg, tokenPair.RefreshToken, expiresAt)
 
 	log.Info().Str("userID", user.ID).Msg("Token refreshed")
 
@@ -126,7 +134,7 @@ type LogoutRequest struct {
 
 // Logout handles user logout
 // @Summary User Logout
-// @Description Invalidates refresh token and terminates user session
+// @Description Invalidates the refresh token (from the body or the refresh_token cookie), clears the cookie and terminates user session
 // @Tags Authentication
 // @Accept json
 // @Produce json
@@ -151,6 +159,10 @@ func (h *AuthHandler) Logout(c *fiber.Ctx) error {
 	if authHeader != "" {
 		token := strings.TrimPrefix(authHeader, "Bearer ")
 		claims, err := h.jwtService.ValidateAccessToken(token)
+		if err == nil && claims != nil && claims.ImpersonatedBy != "" {
+			// Logging out of an impersonation must not sign the real user out
+			return h.EndImpersonation(c)
+		}
 		if err == nil && claims != nil {
 			// Delete all user sessions
 			_ = h.db.DeleteUserSessions(c.Context(), claims.UserID)
@@ -158,6 +170,15 @@ func (h *AuthHandler) Logout(c *fiber.Ctx) error {
 		}
 	}
 
+	// The refresh token cookie belongs to the real user, so it is only
+	// revoked once impersonation has been ruled out
+	if cookieToken := c.Cookies(refreshTokenCookie); cookieToken != "" && cookieToken != req.RefreshToken {
+		if err := h.db.DeleteSession(c.Context(), cookieToken); err != nil {
+			log.Error().Err(err).Msg("Failed to delete session")
+		}
+	}
+	clearAuthCookie(c, h.cfg, refreshTokenCookie)
+
 	return c.Status(fiber.StatusOK).JSON(AuthResponse{
 		Success: true,
 		Message: "Logged out successfully",
@@ -192,6 +213,16 @@ func (h *AuthHandler) GetCurrentUser(c *fiber.Ctx) error {
 		})
 	}
 
+	// Ended or expired impersonation sessions no longer authenticate
+	if claims.ImpersonatedBy != "" {
+		if active, err := h.db.IsImpersonationSessionActive(c.Context(), claims.ID); err != nil || !active {
+			return c.Status(fiber.StatusUnauthorized).JSON(AuthResponse{
+				Success: false,
+				Error:   "impersonation_ended",
+			})
+		}
+	}
+
 	// Get fresh user data from database
 	user, err := h.db.QueryUserByID(c.Context(), claims.UserID)
 	if err != nil || user == nil {
@@ -221,6 +252,7 @@ func (h *AuthHandler) GetCurrentUser(c *fiber.Ctx) error {
 		IsSystemAdmin:      user.IsSystemAdmin,
 		PterodactylID:      getInt64Pointer(user.PterodactylID),
 		EmailVerified:      formatNullTime(user.EmailVerified),
+		ImpersonatedBy:     claims.ImpersonatedBy,
 	}
 
 	return c.Status(fiber.StatusOK).JSON(AuthResponse{
@@ -228,3 +260,67 @@ func (h *AuthHandler) GetCurrentUser(c *fiber.Ctx) error {
 		User:    userData,
 	})
 }
+
+// EndImpersonation ends the impersonation session of the presented token
+// @Summary End Impersonation
+// @Description Revokes an impersonation access token before it expires. The call must be made with the impersonation token itself.
+// @Tags Authentication
+// @Produce json
+// @Param Authorization header string true "Impersonation bearer token" example(Bearer eyJhbGc...)
+// @Success 200 {object} AuthResponse "Impersonation ended"
+// @Failure 400 {object} AuthResponse "Token is not an impersonation token"
+// @Failure 401 {object} AuthResponse "Missing or invalid token"
+// @Router /api/v1/auth/impersonation/end [post]
+func (h *AuthHandler) EndImpersonation(c *fiber.Ctx) error {
+	authHeader := c.Get("Authorization")
+	if authHeader == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(AuthResponse{
+			Success: false,
+			Error:   "missing_authorization",
+		})
+	}
+
+	claims, err := h.jwtService.ValidateAccessToken(strings.TrimPrefix(authHeader, "Bearer "))
+	if err != nil {
+		return c.Status(fiber.StatusUnauthorized).JSON(AuthResponse{
+			Success: false,
+			Error:   "invalid_token",
+		})
+	}
+
+	if claims.ImpersonatedBy == "" || claims.ID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(AuthResponse{
+			Success: false,
+			Error:   "not_impersonating",
+		})
+	}
+
+	ended, err := h.db.EndImpersonationSession(c.Context(), claims.ID)
+	if err != nil {
+		log.Error().Err(err).Str("session_id", claims.ID).Msg("Failed to end impersonation session")
+		return c.Status(fiber.StatusInternalServerError).JSON(AuthResponse{
+			Success: false,
+			Error:   "impersonation_end_failed",
+		})
+	}
+
+	if ended {
+		if err := h.db.CreateAdminAuditLog(c.Context(), database.AdminAuditEntry{
+			ActorID:    claims.ImpersonatedBy,
+			Action:     database.AuditImpersonationEnded,
+			TargetType: "user",
+			TargetID:   claims.UserID,
+			Details:    map[string]interface{}{"sessionId": claims.ID},
+			IPAddress:  c.IP(),
+			UserAgent:  c.Get("User-Agent"),
+		}); err != nil {
+			log.Error().Err(err).Str("session_id", claims.ID).Msg("Failed to write impersonation audit log")
+		}
+		log.Info().Str("admin_id", claims.ImpersonatedBy).Str("user_id", claims.UserID).Msg("Impersonation ended")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(AuthResponse{
+		Success: true,
+		Message: "Impersonation ended",
+	})
+}
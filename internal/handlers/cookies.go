@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/nodebyte/backend/internal/config"
+)
+
+// refreshTokenCookie holds the refresh token for browser clients, alongside
+// the token returned in the JSON body
+const refreshTokenCookie = "refresh_token"
+
+// setAuthCookie issues an auth cookie following the configured cookie policy.
+// Auth cookies are always HttpOnly; they are Secure when COOKIE_SECURE is set
+// or the request arrived over HTTPS (as reported by a trusted proxy).
+func setAuthCookie(c *fiber.Ctx, cfg *config.Config, name, value string, expires time.Time) {
+	c.Cookie(&fiber.Cookie{
+		Name:     name,
+		Value:    value,
+		Path:     "/",
+		Domain:   cfg.CookieDomain,
+		Expires:  expires,
+		Secure:   cfg.CookieSecure || c.Protocol() == "https",
+		HTTPOnly: true,
+		SameSite: cfg.CookieSameSite,
+	})
+}
+
+// setRefreshTokenCookie issues the refresh token cookie for a new session
+func setRefreshTokenCookie(c *fiber.Ctx, cfg *config.Config, token string, expires time.Time) {
+	setAuthCookie(c, cfg, refreshTokenCookie, token, expires)
+}
+
+// clearAuthCookie expires an auth cookie set by setAuthCookie
+func clearAuthCookie(c *fiber.Ctx, cfg *config.Config, name string) {
+	setAuthCookie(c, cfg, name, "", time.Unix(0, 0))
+}
@@ -2,11 +2,19 @@ package handlers
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/nodebyte/backend/internal/cache"
+	"github.com/nodebyte/backend/internal/config"
 	"github.com/nodebyte/backend/internal/database"
+	"github.com/nodebyte/backend/internal/panels"
 	"github.com/nodebyte/backend/internal/queue"
 	"github.com/rs/zerolog/log"
 	"golang.org/x/crypto/bcrypt"
@@ -16,11 +24,15 @@ import (
 type DashboardHandler struct {
 	db           *database.DB
 	queueManager *queue.Manager
+	pteroClient  *panels.PterodactylClient
+	cache        *cache.Cache
+	cfg          *config.Config
+	pageLimits   PageLimits
 }
 
 // NewDashboardHandler creates a new dashboard handler
-func NewDashboardHandler(db *database.DB, queueManager *queue.Manager) *DashboardHandler {
-	return &DashboardHandler{db: db, queueManager: queueManager}
+func NewDashboardHandler(db *database.DB, queueManager *queue.Manager, pteroClient *panels.PterodactylClient, responseCache *cache.Cache, cfg *config.Config) *DashboardHandler {
+	return &DashboardHandler{db: db, queueManager: queueManager, pteroClient: pteroClient, cache: responseCache, cfg: cfg, pageLimits: configuredPageLimits(cfg)}
 }
 
 // GetDashboardStats retrieves user-specific dashboard statistics
@@ -49,13 +61,13 @@ func (h *DashboardHandler) GetDashboardStats(c *fiber.Ctx) error {
 	// Get server counts for this user
 	var totalServers, onlineServers, offlineServers, suspendedServers int
 	h.db.Pool.QueryRow(ctx,
-		`SELECT COUNT(*) FROM servers WHERE "ownerId" = $1`, userID).Scan(&totalServers)
+		`SELECT COUNT(*) FROM servers WHERE "ownerId" = $1 AND "deletedAt" IS NULL`, userID).Scan(&totalServers)
 	h.db.Pool.QueryRow(ctx,
-		`SELECT COUNT(*) FROM servers WHERE "ownerId" = $1 AND status = 'RUNNING'`, userID).Scan(&onlineServers)
+		`SELECT COUNT(*) FROM servers WHERE "ownerId" = $1 AND "deletedAt" IS NULL AND status = 'RUNNING'`, userID).Scan(&onlineServers)
 	h.db.Pool.QueryRow(ctx,
-		`SELECT COUNT(*) FROM servers WHERE "ownerId" = $1 AND status = 'OFFLINE'`, userID).Scan(&offlineServers)
+		`SELECT COUNT(*) FROM servers WHERE "ownerId" = $1 AND "deletedAt" IS NULL AND status = 'OFFLINE'`, userID).Scan(&offlineServers)
 	h.db.Pool.QueryRow(ctx,
-		`SELECT COUNT(*) FROM servers WHERE "ownerId" = $1 AND "isSuspended" = true`, userID).Scan(&suspendedServers)
+		`SELECT COUNT(*) FROM servers WHERE "ownerId" = $1 AND "deletedAt" IS NULL AND "isSuspended" = true`, userID).Scan(&suspendedServers)
 
 	// Get recent servers
 	rows, err := h.db.Pool.Query(ctx, `
@@ -71,7 +83,7 @@ func (h *DashboardHandler) GetDashboardStats(c *fiber.Ctx) error {
 		FROM servers s
 		LEFT JOIN nodes n ON s."nodeId" = n.id
 		LEFT JOIN eggs e ON s."eggId" = e.id
-		WHERE s."ownerId" = $1
+		WHERE s."ownerId" = $1 AND s."deletedAt" IS NULL
 		ORDER BY s."updatedAt" DESC
 		LIMIT 6
 	`, userID)
@@ -171,9 +183,10 @@ func (h *DashboardHandler) GetDashboardStats(c *fiber.Ctx) error {
 // @Produce json
 // @Security BearerAuth
 // @Param page query int false "Page number" default(1)
-// @Param per_page query int false "Items per page" default(12)
+// @Param limit query int false "Items per page (per_page is also accepted)" default(25)
 // @Param search query string false "Search query"
 // @Param status query string false "Status filter"
+// @Param tag query string false "Only servers with this tag (own or global)"
 // @Success 200 {object} SuccessResponse "Servers retrieved"
 // @Failure 401 {object} ErrorResponse "Unauthorized"
 // @Failure 500 {object} ErrorResponse "Internal server error"
@@ -191,16 +204,10 @@ func (h *DashboardHandler) GetUserServers(c *fiber.Ctx) error {
 	}
 
 	// Parse query parameters
-	page := c.QueryInt("page", 1)
-	if page < 1 {
-		page = 1
-	}
-	perPage := c.QueryInt("per_page", 12)
-	if perPage < 1 || perPage > 50 {
-		perPage = 12
-	}
+	pagination := parsePagination(c, h.pageLimits)
 	search := c.Query("search", "")
 	statusFilter := c.Query("status", "")
+	tagFilter := database.NormalizeServerTag(c.Query("tag", ""))
 	viewAll := c.QueryBool("view_all", false)
 	isAdmin, _ := c.Locals("isAdmin").(bool)
 
@@ -209,11 +216,12 @@ func (h *DashboardHandler) GetUserServers(c *fiber.Ctx) error {
 	var args []interface{}
 	argIndex := 1
 
+	// Tombstoned servers (gone from the panel, awaiting purge) are never listed
 	if viewAll && isAdmin {
 		// Admin viewing all servers — no owner filter
-		whereClause = "TRUE"
+		whereClause = `s."deletedAt" IS NULL`
 	} else {
-		whereClause = `"ownerId" = $1`
+		whereClause = `s."deletedAt" IS NULL AND "ownerId" = $1`
 		args = append(args, userID)
 		argIndex = 2
 	}
@@ -243,19 +251,23 @@ func (h *DashboardHandler) GetUserServers(c *fiber.Ctx) error {
 		}
 	}
 
+	if tagFilter != "" {
+		whereClause += ` AND EXISTS (SELECT 1 FROM server_tags t WHERE t."serverId" = s.id AND t.tag = $` + fmt.Sprintf("%d", argIndex) +
+			` AND (t."userId" IS NULL OR t."userId" = $` + fmt.Sprintf("%d", argIndex+1) + `))`
+		args = append(args, tagFilter, userID)
+		argIndex += 2
+	}
+
 	// Get total count
 	var total int
 	countQuery := `SELECT COUNT(*) FROM servers s WHERE ` + whereClause
 	h.db.Pool.QueryRow(ctx, countQuery, args...).Scan(&total)
 
-	// Calculate pagination
-	offset := (page - 1) * perPage
-	totalPages := (total + perPage - 1) / perPage
-
 	// Get servers — always LEFT JOIN users so owner info is available
 	query := `
 		SELECT 
 			s.id, s.uuid, s.name, s.description, s.status, s."isSuspended",
+			s."suspensionReason", s."suspendedAt",
 			n.name as node_name,
 			e.name as egg_name,
 			s.memory, s.disk, s.cpu,
@@ -271,7 +283,7 @@ func (h *DashboardHandler) GetUserServers(c *fiber.Ctx) error {
 		ORDER BY s."updatedAt" DESC
 		LIMIT $` + fmt.Sprintf("%d", argIndex) + ` OFFSET $` + fmt.Sprintf("%d", argIndex+1)
 
-	args = append(args, perPage, offset)
+	args = append(args, pagination.Limit, pagination.Offset)
 	rows, err := h.db.Pool.Query(ctx, query, args...)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
@@ -288,17 +300,19 @@ func (h *DashboardHandler) GetUserServers(c *fiber.Ctx) error {
 	}
 
 	type Server struct {
-		ID          string       `json:"id"`
-		UUID        string       `json:"uuid"`
-		Name        string       `json:"name"`
-		Description string       `json:"description"`
-		Status      string       `json:"status"`
-		IsSuspended bool         `json:"isSuspended"`
-		Game        string       `json:"game"`
-		Node        string       `json:"node"`
-		IP          string       `json:"ip"`
-		Port        int          `json:"port"`
-		Owner       *ServerOwner `json:"owner,omitempty"`
+		ID          string                `json:"id"`
+		UUID        string                `json:"uuid"`
+		Name        string                `json:"name"`
+		Description string                `json:"description"`
+		Status      string                `json:"status"`
+		IsSuspended bool                  `json:"isSuspended"`
+		Suspension  *ServerSuspensionInfo `json:"suspension,omitempty"`
+		Game        string                `json:"game"`
+		Node        string                `json:"node"`
+		IP          string                `json:"ip"`
+		Port        int                   `json:"port"`
+		Owner       *ServerOwner          `json:"owner,omitempty"`
+		Tags        []string              `json:"tags"`
 		Resources   struct {
 			Memory struct {
 				Used  int `json:"used"`
@@ -322,8 +336,10 @@ func (h *DashboardHandler) GetUserServers(c *fiber.Ctx) error {
 		var description *string
 		var memory, disk, cpu int
 		var ownerID, ownerUsername, ownerEmail *string
+		var suspension database.ServerSuspension
 		err := rows.Scan(
 			&server.ID, &server.UUID, &server.Name, &description, &server.Status, &server.IsSuspended,
+			&suspension.Reason, &suspension.SuspendedAt,
 			&server.Node, &server.Game,
 			&memory, &disk, &cpu,
 			&server.IP, &server.Port, &server.CreatedAt,
@@ -336,6 +352,9 @@ func (h *DashboardHandler) GetUserServers(c *fiber.Ctx) error {
 		if description != nil {
 			server.Description = *description
 		}
+		if server.IsSuspended {
+			server.Suspension = newServerSuspensionInfo(suspension)
+		}
 		if ownerID != nil {
 			owner := &ServerOwner{ID: *ownerID}
 			if ownerUsername != nil {
@@ -358,14 +377,711 @@ func (h *DashboardHandler) GetUserServers(c *fiber.Ctx) error {
 		servers = append(servers, server)
 	}
 
+	// Attach the tags the user can see to each server
+	serverIDs := make([]string, len(servers))
+	for i := range servers {
+		serverIDs[i] = servers[i].ID
+	}
+	tags, err := h.db.TagsForServers(ctx, serverIDs, userID)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to fetch server tags")
+	}
+	for i := range servers {
+		servers[i].Tags = tags[servers[i].ID]
+		if servers[i].Tags == nil {
+			servers[i].Tags = []string{}
+		}
+	}
+
+	meta := pagination.Meta(total)
 	return c.JSON(fiber.Map{
 		"success": true,
 		"data":    servers,
 		"meta": fiber.Map{
-			"total":      total,
-			"page":       page,
-			"perPage":    perPage,
-			"totalPages": totalPages,
+			"page":       meta.Page,
+			"perPage":    meta.Limit,
+			"limit":      meta.Limit,
+			"offset":     meta.Offset,
+			"total":      meta.Total,
+			"totalPages": meta.TotalPages,
+		},
+	})
+}
+
+// ServerSuspensionInfo explains a suspension to the server owner
+type ServerSuspensionInfo struct {
+	Reason      *string    `json:"reason"`
+	SuspendedAt *time.Time `json:"suspendedAt"`
+	Message     string     `json:"message"`
+}
+
+// newServerSuspensionInfo builds the owner-facing view of a suspension
+func newServerSuspensionInfo(s database.ServerSuspension) *ServerSuspensionInfo {
+	message := "This server has been suspended. Please contact support for more information."
+	if s.Reason != nil && *s.Reason != "" {
+		message = "This server has been suspended. Please contact support if you have any questions. Reason: " + *s.Reason
+	}
+	return &ServerSuspensionInfo{
+		Reason:      s.Reason,
+		SuspendedAt: s.SuspendedAt,
+		Message:     message,
+	}
+}
+
+// GetServerActivity retrieves the panel activity log for one of the user's servers
+// @Summary Get server activity
+// @Description Retrieves paginated activity (power actions, file edits, etc.) recorded by the panel for a server owned by the authenticated user
+// @Tags Dashboard
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Server ID"
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page (per_page is also accepted)" default(25)
+// @Success 200 {object} SuccessResponse "Activity retrieved"
+// @Failure 400 {object} ErrorResponse "Server is not a panel server"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "Server not found"
+// @Failure 502 {object} ErrorResponse "Panel request failed"
+// @Router /api/v1/dashboard/servers/{id}/activity [get]
+func (h *DashboardHandler) GetServerActivity(c *fiber.Ctx) error {
+	ctx := c.Context()
+
+	userID, ok := c.Locals("userID").(string)
+	if !ok || userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{
+			Success: false,
+			Error:   "User not authenticated",
+		})
+	}
+	isAdmin, _ := c.Locals("isAdmin").(bool)
+
+	pagination := parsePagination(c, h.pageLimits)
+
+	// Ownership check - admins may view any server
+	var serverUUID *string
+	err := h.db.Pool.QueryRow(ctx,
+		`SELECT uuid FROM servers WHERE id = $1 AND ("ownerId" = $2 OR $3) AND "deletedAt" IS NULL`,
+		c.Params("id"), userID, isAdmin,
+	).Scan(&serverUUID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Server not found",
+		})
+	}
+	if serverUUID == nil || *serverUUID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Server has no panel activity log",
+		})
+	}
+
+	resp, err := h.pteroClient.GetServerActivity(ctx, *serverUUID, pagination.Page, pagination.Limit)
+	if err != nil {
+		log.Error().Err(err).Str("server_uuid", *serverUUID).Msg("Failed to fetch server activity")
+		return c.Status(fiber.StatusBadGateway).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Failed to fetch server activity",
+		})
+	}
+
+	var entries []panels.ClientActivityLog
+	if err := json.Unmarshal(resp.Data, &entries); err != nil {
+		log.Error().Err(err).Msg("Failed to parse server activity")
+		return c.Status(fiber.StatusBadGateway).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Failed to parse server activity",
+		})
+	}
+
+	activity := make([]fiber.Map, 0, len(entries))
+	for _, entry := range entries {
+		activity = append(activity, fiber.Map{
+			"id":          entry.Attributes.ID,
+			"event":       entry.Attributes.Event,
+			"description": entry.Attributes.Description,
+			"isApi":       entry.Attributes.IsAPI,
+			"ip":          entry.Attributes.IP,
+			"properties":  entry.Attributes.Properties,
+			"timestamp":   entry.Attributes.Timestamp,
+		})
+	}
+
+	return c.JSON(SuccessResponse{
+		Success: true,
+		Data: fiber.Map{
+			"activity": activity,
+			"pagination": fiber.Map{
+				"page":       resp.Meta.Pagination.CurrentPage,
+				"perPage":    resp.Meta.Pagination.PerPage,
+				"total":      resp.Meta.Pagination.Total,
+				"totalPages": resp.Meta.Pagination.TotalPages,
+			},
+			"meta": pagination.Meta(resp.Meta.Pagination.Total),
+		},
+	})
+}
+
+// ServerStartupVariable is an egg variable merged with the server's current value
+type ServerStartupVariable struct {
+	Name         string               `json:"name"`
+	Description  string               `json:"description"`
+	EnvVariable  string               `json:"envVariable"`
+	DefaultValue string               `json:"defaultValue"`
+	ServerValue  string               `json:"serverValue"`
+	IsViewable   bool                 `json:"isViewable"`
+	IsEditable   bool                 `json:"isEditable"`
+	Rules        string               `json:"rules"`
+	Validation   panels.VariableRules `json:"validation"`
+}
+
+// GetServerStartup returns the startup variables for a server the user owns
+// @Summary Get server startup variables
+// @Description Returns the server's egg variables merged with current values from the panel. Each variable is flagged as viewable/editable and includes parsed validation rules. Non-viewable variables are hidden from non-admins.
+// @Tags Dashboard
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Server ID"
+// @Success 200 {object} SuccessResponse "Startup variables retrieved"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "Server not found"
+// @Failure 502 {object} ErrorResponse "Panel request failed"
+// @Router /api/v1/dashboard/servers/{id}/startup [get]
+func (h *DashboardHandler) GetServerStartup(c *fiber.Ctx) error {
+	ctx := c.Context()
+
+	userID, ok := c.Locals("userID").(string)
+	if !ok || userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{
+			Success: false,
+			Error:   "User not authenticated",
+		})
+	}
+	isAdmin, _ := c.Locals("isAdmin").(bool)
+
+	// Ownership check - admins may view any server
+	var serverID string
+	var pterodactylID, eggID *int
+	err := h.db.Pool.QueryRow(ctx,
+		`SELECT id, "pterodactylId", "eggId" FROM servers WHERE id = $1 AND ("ownerId" = $2 OR $3) AND "deletedAt" IS NULL`,
+		c.Params("id"), userID, isAdmin,
+	).Scan(&serverID, &pterodactylID, &eggID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Server not found",
+		})
+	}
+	if pterodactylID == nil || eggID == nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Server is not managed by the panel",
+		})
+	}
+
+	detail, err := h.pteroClient.GetServerDetailWithIncludes(ctx, *pterodactylID, nil)
+	if err != nil {
+		log.Error().Err(err).Int("pterodactyl_id", *pterodactylID).Msg("Failed to fetch server detail")
+		return c.Status(fiber.StatusBadGateway).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Failed to fetch server startup",
+		})
+	}
+
+	rows, err := h.db.Pool.Query(ctx, `
+		SELECT name, COALESCE(description, ''), "envVariable", COALESCE("defaultValue", ''),
+			COALESCE("overrideUserViewable", "userViewable", true), COALESCE("overrideUserEditable", "userEditable", true), COALESCE(rules, '')
+		FROM egg_variables
+		WHERE "eggId" = $1
+		ORDER BY "displayOrder" NULLS LAST, id
+	`, *eggID)
+	if err != nil {
+		log.Error().Err(err).Int("egg_id", *eggID).Msg("Failed to fetch egg variables")
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Failed to fetch egg variables",
+		})
+	}
+	defer rows.Close()
+
+	environment := detail.Attributes.Container.Environment
+	variables := make([]ServerStartupVariable, 0)
+	for rows.Next() {
+		var v ServerStartupVariable
+		if err := rows.Scan(&v.Name, &v.Description, &v.EnvVariable, &v.DefaultValue, &v.IsViewable, &v.IsEditable, &v.Rules); err != nil {
+			log.Warn().Err(err).Msg("Failed to scan egg variable")
+			continue
+		}
+		if !v.IsViewable && !isAdmin {
+			continue
+		}
+
+		v.ServerValue = v.DefaultValue
+		if value, ok := environment[v.EnvVariable]; ok && value != nil {
+			v.ServerValue = fmt.Sprint(value)
+		}
+		v.Validation = panels.ParseVariableRules(v.Rules)
+		variables = append(variables, v)
+	}
+
+	return c.JSON(SuccessResponse{
+		Success: true,
+		Data: fiber.Map{
+			"startupCommand": detail.Attributes.Container.StartupCommand,
+			"dockerImage":    detail.Attributes.Container.Image,
+			"variables":      variables,
+		},
+	})
+}
+
+// maxUptimeRange is the longest history kept for uptime queries
+const maxUptimeRange = 90 * 24 * time.Hour
+
+// parseUptimeRange parses an uptime range such as "24h" or "7d"
+func parseUptimeRange(value string) (time.Duration, error) {
+	if value == "" {
+		return 24 * time.Hour, nil
+	}
+
+	var d time.Duration
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("invalid range %q", value)
+		}
+		d = time.Duration(n) * 24 * time.Hour
+	} else {
+		var err error
+		if d, err = time.ParseDuration(value); err != nil {
+			return 0, fmt.Errorf("invalid range %q", value)
+		}
+	}
+
+	if d <= 0 || d > maxUptimeRange {
+		return 0, fmt.Errorf("range must be between 1s and 90d")
+	}
+	return d, nil
+}
+
+// GetServerUptime returns uptime history for a server the user owns
+// @Summary Get server uptime
+// @Description Returns the server's uptime percentage and outage timeline over a range (e.g. 24h, 7d, 30d; max 90d), computed from recorded power state transitions. Time before the first recorded state is excluded from the percentage.
+// @Tags Dashboard
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Server ID"
+// @Param range query string false "Time range (default 24h)"
+// @Success 200 {object} SuccessResponse "Uptime retrieved"
+// @Failure 400 {object} ErrorResponse "Invalid range"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "Server not found"
+// @Router /api/v1/dashboard/servers/{id}/uptime [get]
+func (h *DashboardHandler) GetServerUptime(c *fiber.Ctx) error {
+	ctx := c.Context()
+
+	userID, ok := c.Locals("userID").(string)
+	if !ok || userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{
+			Success: false,
+			Error:   "User not authenticated",
+		})
+	}
+	isAdmin, _ := c.Locals("isAdmin").(bool)
+
+	window, err := parseUptimeRange(c.Query("range"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Success: false,
+			Error:   err.Error(),
+		})
+	}
+
+	// Ownership check - admins may view any server
+	var serverID string
+	err = h.db.Pool.QueryRow(ctx,
+		`SELECT id FROM servers WHERE id = $1 AND ("ownerId" = $2 OR $3) AND "deletedAt" IS NULL`,
+		c.Params("id"), userID, isAdmin,
+	).Scan(&serverID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Server not found",
+		})
+	}
+
+	to := time.Now().UTC()
+	from := to.Add(-window)
+	initialState, events, err := h.db.GetServerStatusEvents(ctx, serverID, from)
+	if err != nil {
+		log.Error().Err(err).Str("server_id", serverID).Msg("Failed to fetch server status events")
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Failed to fetch server uptime",
+		})
+	}
+
+	return c.JSON(SuccessResponse{
+		Success: true,
+		Data:    database.SummarizeUptime(initialState, events, from, to),
+	})
+}
+
+// GetResourceUsage returns allocated vs used resources across the user's servers
+// @Summary Get account resource usage
+// @Description Sums the memory, disk (MB) and CPU (%) limits of the servers the user owns and their latest usage snapshots from the server state sync. unlimited is set when a server has no limit for that resource; reportingServers counts servers with a snapshot and snapshotAt is the oldest one included.
+// @Tags Dashboard
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} SuccessResponse "Resource usage retrieved"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/v1/dashboard/usage [get]
+func (h *DashboardHandler) GetResourceUsage(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok || userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{
+			Success: false,
+			Error:   "User not authenticated",
+		})
+	}
+
+	usage, err := h.db.GetUserResourceUsage(c.Context(), userID)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID).Msg("Failed to fetch resource usage")
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Failed to fetch resource usage",
+		})
+	}
+
+	return c.JSON(SuccessResponse{
+		Success: true,
+		Data:    usage,
+	})
+}
+
+// serverTagPattern restricts tags to short lower-case labels such as "eu-west" or "production"
+var serverTagPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9 _.-]{0,31}$`)
+
+// maxServerTags caps how many tags one user (or the global set) may put on a server
+const maxServerTags = 20
+
+// ServerTagRequest represents a request to tag a server
+type ServerTagRequest struct {
+	Tag    string `json:"tag"`
+	Global bool   `json:"global,omitempty"` // admins only; visible to everyone who can see the server
+}
+
+// taggableServerID resolves the server a tag request refers to, enforcing
+// that the user owns it unless they are an admin
+func (h *DashboardHandler) taggableServerID(c *fiber.Ctx, userID string, isAdmin bool) (string, error) {
+	var serverID string
+	err := h.db.Pool.QueryRow(c.Context(),
+		`SELECT id FROM servers WHERE id = $1 AND ("ownerId" = $2 OR $3) AND "deletedAt" IS NULL`,
+		c.Params("id"), userID, isAdmin,
+	).Scan(&serverID)
+	return serverID, err
+}
+
+// GetServerTags lists the tags on a server
+// @Summary Get server tags
+// @Description Returns the caller's own tags on a server plus global tags set by admins
+// @Tags Dashboard
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Server ID"
+// @Success 200 {object} SuccessResponse "Tags retrieved"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "Server not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/v1/dashboard/servers/{id}/tags [get]
+func (h *DashboardHandler) GetServerTags(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok || userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{
+			Success: false,
+			Error:   "User not authenticated",
+		})
+	}
+	isAdmin, _ := c.Locals("isAdmin").(bool)
+
+	serverID, err := h.taggableServerID(c, userID, isAdmin)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Server not found",
+		})
+	}
+
+	tags, err := h.db.ListServerTags(c.Context(), serverID, userID)
+	if err != nil {
+		log.Error().Err(err).Str("server_id", serverID).Msg("Failed to fetch server tags")
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Failed to fetch server tags",
+		})
+	}
+
+	return c.JSON(SuccessResponse{
+		Success: true,
+		Data:    tags,
+	})
+}
+
+// AddServerTag tags a server
+// @Summary Add server tag
+// @Description Adds a tag to a server the user owns. Admins may tag any server and may set global=true to add a tag visible to everyone who can see the server.
+// @Tags Dashboard
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Server ID"
+// @Param body body ServerTagRequest true "Tag"
+// @Success 201 {object} SuccessResponse "Tag added"
+// @Failure 400 {object} ErrorResponse "Invalid tag or tag limit reached"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Global tags require admin"
+// @Failure 404 {object} ErrorResponse "Server not found"
+// @Failure 409 {object} ErrorResponse "Tag already exists"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/v1/dashboard/servers/{id}/tags [post]
+func (h *DashboardHandler) AddServerTag(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok || userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{
+			Success: false,
+			Error:   "User not authenticated",
+		})
+	}
+	isAdmin, _ := c.Locals("isAdmin").(bool)
+
+	var req ServerTagRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Invalid request body",
+		})
+	}
+	tag := database.NormalizeServerTag(req.Tag)
+	if !serverTagPattern.MatchString(tag) {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Tags must be 1-32 characters of letters, numbers, spaces, '.', '_' or '-'",
+		})
+	}
+	if req.Global && !isAdmin {
+		return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Only admins can add global tags",
+		})
+	}
+
+	serverID, err := h.taggableServerID(c, userID, isAdmin)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Server not found",
+		})
+	}
+
+	owner := userID
+	if req.Global {
+		owner = ""
+	}
+
+	count, err := h.db.CountServerTags(c.Context(), serverID, owner)
+	if err != nil {
+		log.Error().Err(err).Str("server_id", serverID).Msg("Failed to count server tags")
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Failed to add server tag",
+		})
+	}
+	if count >= maxServerTags {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Success: false,
+			Error:   fmt.Sprintf("A server can have at most %d tags", maxServerTags),
+		})
+	}
+
+	added, err := h.db.AddServerTag(c.Context(), serverID, owner, tag)
+	if err != nil {
+		log.Error().Err(err).Str("server_id", serverID).Msg("Failed to add server tag")
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Failed to add server tag",
+		})
+	}
+	if !added {
+		return c.Status(fiber.StatusConflict).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Tag already exists",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(SuccessResponse{
+		Success: true,
+		Data:    database.ServerTag{Tag: tag, Global: req.Global, CreatedAt: time.Now()},
+	})
+}
+
+// RemoveServerTag removes a tag from a server
+// @Summary Remove server tag
+// @Description Removes one of the user's tags from a server. Admins may pass global=true to remove a global tag.
+// @Tags Dashboard
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Server ID"
+// @Param tag path string true "Tag"
+// @Param global query bool false "Remove a global tag (admins only)"
+// @Success 200 {object} SuccessResponse "Tag removed"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 403 {object} ErrorResponse "Global tags require admin"
+// @Failure 404 {object} ErrorResponse "Server or tag not found"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/v1/dashboard/servers/{id}/tags/{tag} [delete]
+func (h *DashboardHandler) RemoveServerTag(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok || userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{
+			Success: false,
+			Error:   "User not authenticated",
+		})
+	}
+	isAdmin, _ := c.Locals("isAdmin").(bool)
+
+	global := c.QueryBool("global", false)
+	if global && !isAdmin {
+		return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Only admins can remove global tags",
+		})
+	}
+
+	serverID, err := h.taggableServerID(c, userID, isAdmin)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Server not found",
+		})
+	}
+
+	owner := userID
+	if global {
+		owner = ""
+	}
+
+	tag, _ := url.PathUnescape(c.Params("tag"))
+	removed, err := h.db.RemoveServerTag(c.Context(), serverID, owner, tag)
+	if err != nil {
+		log.Error().Err(err).Str("server_id", serverID).Msg("Failed to remove server tag")
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Failed to remove server tag",
+		})
+	}
+	if !removed {
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Tag not found",
+		})
+	}
+
+	return c.JSON(SuccessResponse{
+		Success: true,
+		Message: "Tag removed",
+	})
+}
+
+// ReinstallServerRequest represents a server reinstall request
+type ReinstallServerRequest struct {
+	Confirmation string `json:"confirmation"` // must match the server name
+}
+
+// ReinstallServer reinstalls a server the user owns
+// @Summary Reinstall server
+// @Description Re-runs the egg install script for a server owned by the user. Files on the server may be overwritten or lost. The request must include the server name as confirmation.
+// @Tags Dashboard
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Server ID"
+// @Param payload body ReinstallServerRequest true "Confirmation (server name)"
+// @Success 200 {object} SuccessResponse "Reinstall started"
+// @Failure 400 {object} ErrorResponse "Invalid request or confirmation mismatch"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "Server not found"
+// @Failure 502 {object} ErrorResponse "Panel request failed"
+// @Router /api/v1/dashboard/servers/{id}/reinstall [post]
+func (h *DashboardHandler) ReinstallServer(c *fiber.Ctx) error {
+	ctx := c.Context()
+
+	userID, ok := c.Locals("userID").(string)
+	if !ok || userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{
+			Success: false,
+			Error:   "User not authenticated",
+		})
+	}
+
+	var req ReinstallServerRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Invalid request body",
+		})
+	}
+
+	// Ownership check - only the owner may reinstall
+	var serverID, name string
+	var serverUUID *string
+	err := h.db.Pool.QueryRow(ctx,
+		`SELECT id, name, uuid FROM servers WHERE id = $1 AND "ownerId" = $2 AND "deletedAt" IS NULL`,
+		c.Params("id"), userID,
+	).Scan(&serverID, &name, &serverUUID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Server not found",
+		})
+	}
+	if serverUUID == nil || *serverUUID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Server is not managed by the panel",
+		})
+	}
+
+	if strings.TrimSpace(req.Confirmation) != name {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Confirmation does not match the server name",
+		})
+	}
+
+	if err := h.pteroClient.ReinstallServer(ctx, *serverUUID); err != nil {
+		log.Error().Err(err).Str("server_uuid", *serverUUID).Msg("Failed to reinstall server")
+		return c.Status(fiber.StatusBadGateway).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Failed to reinstall server",
+		})
+	}
+
+	if _, err := h.db.Pool.Exec(ctx,
+		`UPDATE servers SET status = 'installing', "updatedAt" = NOW() WHERE id = $1`,
+		serverID,
+	); err != nil {
+		log.Warn().Err(err).Str("server_id", serverID).Msg("Failed to update server status after reinstall")
+	}
+
+	log.Info().Str("server_id", serverID).Str("user_id", userID).Msg("Server reinstall started")
+
+	return c.JSON(SuccessResponse{
+		Success: true,
+		Message: "Reinstall started. Existing server files may be overwritten or lost.",
+		Data: fiber.Map{
+			"status": "installing",
 		},
 	})
 }
@@ -407,6 +1123,7 @@ func (h *DashboardHandler) GetUserAccount(c *fiber.Ctx) error {
 		AccountBalance float64  `json:"accountBalance"`
 		CreatedAt      string   `json:"createdAt"`
 		EmailVerified  bool     `json:"emailVerified"`
+		PendingEmail   *string  `json:"pendingEmail"`
 		LastLoginAt    *string  `json:"lastLoginAt"`
 		Roles          []string `json:"roles"`
 	}
@@ -415,14 +1132,14 @@ func (h *DashboardHandler) GetUserAccount(c *fiber.Ctx) error {
 		SELECT id, username, email, "firstName", "lastName",
 		       "phoneNumber", "companyName", "billingEmail",
 		       "avatarUrl", COALESCE("accountBalance", 0), "createdAt"::TEXT,
-		       "emailVerified" IS NOT NULL, "lastLoginAt"::TEXT, COALESCE(roles, '{}')
+		       "emailVerified" IS NOT NULL, "pendingEmail", "lastLoginAt"::TEXT, COALESCE(roles, '{}')
 		FROM users
 		WHERE id = $1
 	`, userID).Scan(
 		&user.ID, &user.Username, &user.Email, &user.FirstName, &user.LastName,
 		&user.PhoneNumber, &user.CompanyName, &user.BillingEmail,
 		&user.AvatarURL, &user.AccountBalance, &user.CreatedAt,
-		&user.EmailVerified, &user.LastLoginAt, &user.Roles,
+		&user.EmailVerified, &user.PendingEmail, &user.LastLoginAt, &user.Roles,
 	)
 
 	if err != nil {
@@ -493,11 +1210,21 @@ func (h *DashboardHandler) UpdateUserAccount(c *fiber.Ctx) error {
 		argIndex++
 	}
 	if req.Email != nil && *req.Email != "" {
-		updates = append(updates, fmt.Sprintf(`email = $%d`, argIndex))
-		args = append(args, *req.Email)
-		argIndex++
-		// Reset email verification since email changed
-		updates = append(updates, `"emailVerified" = NULL`)
+		// Email changes must go through the confirmation flow in RequestEmailChange
+		var currentEmail string
+		if err := h.db.Pool.QueryRow(ctx, `SELECT email FROM users WHERE id = $1`, userID).Scan(&currentEmail); err != nil {
+			log.Error().Err(err).Str("user_id", userID).Msg("Failed to fetch current email")
+			return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+				Success: false,
+				Error:   "Failed to update account",
+			})
+		}
+		if *req.Email != currentEmail {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Success: false,
+				Error:   "Use /api/v1/dashboard/account/change-email to change your email",
+			})
+		}
 	}
 	if req.FirstName != nil {
 		updates = append(updates, fmt.Sprintf(`"firstName" = $%d`, argIndex))
@@ -679,7 +1406,8 @@ func (h *DashboardHandler) ResendVerificationEmail(c *fiber.Ctx) error {
 	}
 
 	// Generate a fresh verification token
-	token, err := h.db.StoreVerificationToken(ctx, userID, database.VerificationTokenType, database.TokenExpiration)
+	verifyTTL := h.cfg.TokenTTL(database.VerificationTokenType)
+	token, err := h.db.StoreVerificationToken(ctx, userID, database.VerificationTokenType, verifyTTL)
 	if err != nil {
 		log.Error().Err(err).Str("userID", userID).Msg("Failed to generate verification token for resend")
 		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{Success: false, Error: "Failed to generate verification token"})
@@ -696,9 +1424,10 @@ func (h *DashboardHandler) ResendVerificationEmail(c *fiber.Ctx) error {
 			Subject:  "Verify your email",
 			Template: "verify-email",
 			Data: map[string]string{
-				"name":  name,
-				"token": token,
-				"email": email,
+				"name":      name,
+				"token":     token,
+				"email":     email,
+				"expiresIn": formatTokenTTL(verifyTTL),
 			},
 		})
 	}
@@ -707,18 +1436,19 @@ func (h *DashboardHandler) ResendVerificationEmail(c *fiber.Ctx) error {
 }
 
 // RequestEmailChange allows an authenticated user to request an email change.
-// The new email is set immediately and emailVerified is cleared; a verification
-// email is sent to the new address.
+// The new email is stored as pending and a confirmation token is sent to it;
+// users.email only changes once the token is confirmed.
 // @Summary Request email change
-// @Description Changes the user's email address. Requires current password for verification. Clears email verification status and sends a verification email to the new address.
+// @Description Starts an email change. Requires current password for verification. The current email stays active until the confirmation token sent to the new address is submitted to /api/v1/auth/confirm-email-change.
 // @Tags Dashboard
 // @Accept json
 // @Produce json
 // @Security BearerAuth
 // @Param payload body object true "Email change request" SchemaExample({"newEmail": "new@example.com", "currentPassword": "password123"})
-// @Success 200 {object} SuccessResponse "Email updated"
+// @Success 200 {object} SuccessResponse "Confirmation email sent"
 // @Failure 400 {object} ErrorResponse "Missing required fields"
 // @Failure 401 {object} ErrorResponse "Unauthorized or wrong password"
+// @Failure 403 {object} ErrorResponse "Email domain not allowed"
 // @Failure 409 {object} ErrorResponse "Email already in use"
 // @Failure 500 {object} ErrorResponse "Internal server error"
 // @Router /api/v1/dashboard/account/change-email [post]
@@ -737,6 +1467,9 @@ func (h *DashboardHandler) RequestEmailChange(c *fiber.Ctx) error {
 	if err := c.BodyParser(&req); err != nil || req.NewEmail == "" || req.CurrentPassword == "" {
 		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{Success: false, Error: "newEmail and currentPassword are required"})
 	}
+	if !h.cfg.EmailDomainAllowed(req.NewEmail) {
+		return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{Success: false, Error: "Email domain is not allowed", Code: "email_domain_not_allowed"})
+	}
 
 	// Verify current password
 	user, err := h.db.QueryUserByID(ctx, userID)
@@ -756,33 +1489,31 @@ func (h *DashboardHandler) RequestEmailChange(c *fiber.Ctx) error {
 		return c.Status(fiber.StatusConflict).JSON(ErrorResponse{Success: false, Error: "Email already in use"})
 	}
 
-	// Update email and clear verification
-	_, err = h.db.Pool.Exec(ctx,
-		`UPDATE users SET email = $1, "emailVerified" = NULL, "updatedAt" = NOW() WHERE id = $2`,
-		req.NewEmail, userID,
-	)
+	// Store the pending email; the current email stays active until confirmed
+	changeTTL := h.cfg.TokenTTL(database.EmailChangeTokenType)
+	token, err := h.db.StoreEmailChangeRequest(ctx, userID, req.NewEmail, changeTTL)
 	if err != nil {
-		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{Success: false, Error: "Failed to update email"})
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{Success: false, Error: "Failed to request email change"})
 	}
 
-	// Send verification email to new address
-	token, err := h.db.StoreVerificationToken(ctx, userID, database.VerificationTokenType, database.TokenExpiration)
-	if err == nil && h.queueManager != nil {
+	// Send confirmation token to the new address
+	if h.queueManager != nil {
 		name := ""
 		if user.FirstName.Valid {
 			name = user.FirstName.String
 		}
 		_, _ = h.queueManager.EnqueueEmail(queue.EmailPayload{
 			To:       req.NewEmail,
-			Subject:  "Verify your new email address",
-			Template: "verify-email",
+			Subject:  "Confirm your new email address",
+			Template: "confirm-email-change",
 			Data: map[string]string{
-				"name":  name,
-				"token": token,
-				"email": req.NewEmail,
+				"name":      name,
+				"token":     token,
+				"email":     req.NewEmail,
+				"expiresIn": formatTokenTTL(changeTTL),
 			},
 		})
 	}
 
-	return c.JSON(SuccessResponse{Success: true, Message: "Email updated. Please check your new email to verify."})
+	return c.JSON(SuccessResponse{Success: true, Message: "Please check your new email to confirm the change."})
 }
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"context"
+	"unicode/utf8"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+)
+
+// Pterodactyl subuser permissions checked by the file manager endpoints
+const (
+	permFileRead        = "file.read"
+	permFileReadContent = "file.read-content"
+)
+
+// fileAccessServerUUID returns the panel UUID of a server the user may use the
+// file manager on: servers they own, servers where they are a subuser holding
+// the given permission, or any server for admins
+func (h *DashboardHandler) fileAccessServerUUID(ctx context.Context, serverID, userID string, isAdmin bool, permission string) (string, error) {
+	var serverUUID *string
+	err := h.db.Pool.QueryRow(ctx, `
+		SELECT s.uuid FROM servers s
+		WHERE s.id = $1 AND (
+			$3 OR s."ownerId" = $2 OR EXISTS (
+				SELECT 1 FROM server_subusers su
+				WHERE su."serverId" = s.id AND su."userId" = $2
+				AND (su."isOwner" OR $4 = ANY(su.permissions))
+			)
+		)
+	`, serverID, userID, isAdmin, permission).Scan(&serverUUID)
+	if err != nil {
+		return "", err
+	}
+	if serverUUID == nil {
+		return "", nil
+	}
+	return *serverUUID, nil
+}
+
+// serverFileAccess resolves the server for a file manager request, writing an
+// error response and returning ok=false when the caller may not use it
+func (h *DashboardHandler) serverFileAccess(c *fiber.Ctx, permission string) (string, bool, error) {
+	userID, ok := c.Locals("userID").(string)
+	if !ok || userID == "" {
+		return "", false, c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{
+			Success: false,
+			Error:   "User not authenticated",
+		})
+	}
+	isAdmin, _ := c.Locals("isAdmin").(bool)
+
+	serverUUID, err := h.fileAccessServerUUID(c.Context(), c.Params("id"), userID, isAdmin, permission)
+	if err != nil {
+		return "", false, c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Server not found",
+		})
+	}
+	if serverUUID == "" {
+		return "", false, c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Server has no panel file manager",
+		})
+	}
+	return serverUUID, true, nil
+}
+
+// ListServerFiles lists a directory on one of the user's servers
+// @Summary List server files
+// @Description Lists a directory on a server through the panel file manager. Available to the owner, admins and subusers with the file.read permission.
+// @Tags Dashboard
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Server ID"
+// @Param directory query string false "Directory to list" default(/)
+// @Success 200 {object} SuccessResponse "Directory listing"
+// @Failure 400 {object} ErrorResponse "Server is not a panel server"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "Server not found"
+// @Failure 502 {object} ErrorResponse "Panel request failed"
+// @Router /api/v1/dashboard/servers/{id}/files [get]
+func (h *DashboardHandler) ListServerFiles(c *fiber.Ctx) error {
+	serverUUID, ok, err := h.serverFileAccess(c, permFileRead)
+	if !ok {
+		return err
+	}
+
+	directory := c.Query("directory", "/")
+	files, err := h.pteroClient.ListServerFiles(c.Context(), serverUUID, directory)
+	if err != nil {
+		log.Error().Err(err).Str("server_uuid", serverUUID).Str("directory", directory).Msg("Failed to list server files")
+		return c.Status(fiber.StatusBadGateway).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Failed to list server files",
+		})
+	}
+
+	entries := make([]fiber.Map, 0, len(files))
+	for _, f := range files {
+		entries = append(entries, fiber.Map{
+			"name":       f.Attributes.Name,
+			"mode":       f.Attributes.Mode,
+			"modeBits":   f.Attributes.ModeBits,
+			"size":       f.Attributes.Size,
+			"isFile":     f.Attributes.IsFile,
+			"isSymlink":  f.Attributes.IsSymlink,
+			"mimetype":   f.Attributes.Mimetype,
+			"createdAt":  f.Attributes.CreatedAt,
+			"modifiedAt": f.Attributes.ModifiedAt,
+		})
+	}
+
+	return c.JSON(SuccessResponse{
+		Success: true,
+		Data: fiber.Map{
+			"directory": directory,
+			"files":     entries,
+		},
+	})
+}
+
+// GetServerFileContents returns the contents of a file on one of the user's servers
+// @Summary Get server file contents
+// @Description Returns the contents of a text file on a server, capped at 5 MiB; truncated content ends on a whole character. Binary files are refused in favour of the download endpoint. Available to the owner, admins and subusers with the file.read-content permission.
+// @Tags Dashboard
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Server ID"
+// @Param file query string true "File path"
+// @Success 200 {object} SuccessResponse "File contents"
+// @Failure 400 {object} ErrorResponse "Missing file or not a text file"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "Server not found"
+// @Failure 502 {object} ErrorResponse "Panel request failed"
+// @Router /api/v1/dashboard/servers/{id}/files/contents [get]
+func (h *DashboardHandler) GetServerFileContents(c *fiber.Ctx) error {
+	file := c.Query("file")
+	if file == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Success: false,
+			Error:   "file is required",
+		})
+	}
+
+	serverUUID, ok, err := h.serverFileAccess(c, permFileReadContent)
+	if !ok {
+		return err
+	}
+
+	data, truncated, err := h.pteroClient.GetServerFileContents(c.Context(), serverUUID, file)
+	if err != nil {
+		log.Error().Err(err).Str("server_uuid", serverUUID).Str("file", file).Msg("Failed to fetch server file contents")
+		return c.Status(fiber.StatusBadGateway).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Failed to fetch file contents",
+		})
+	}
+	if truncated {
+		data = trimPartialRune(data)
+	}
+	if !utf8.Valid(data) {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Success: false,
+			Error:   "File is not a text file; use the download endpoint",
+		})
+	}
+
+	return c.JSON(SuccessResponse{
+		Success: true,
+		Data: fiber.Map{
+			"file":      file,
+			"content":   string(data),
+			"truncated": truncated,
+		},
+	})
+}
+
+// trimPartialRune drops a multi-byte character cut off at the end of data,
+// so truncated text still ends on a whole character
+func trimPartialRune(data []byte) []byte {
+	for i := 1; i < utf8.UTFMax && i <= len(data); i++ {
+		if tail := data[len(data)-i:]; utf8.RuneStart(tail[0]) {
+			if !utf8.FullRune(tail) {
+				return data[:len(data)-i]
+			}
+			break
+		}
+	}
+	return data
+}
+
+// GetServerFileDownload returns a signed download URL for a file on one of the user's servers
+// @Summary Get server file download URL
+// @Description Returns a one-time signed URL for downloading a file directly from the server's node. Available to the owner, admins and subusers with the file.read-content permission.
+// @Tags Dashboard
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Server ID"
+// @Param file query string true "File path"
+// @Success 200 {object} SuccessResponse "Signed download URL"
+// @Failure 400 {object} ErrorResponse "Missing file"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "Server not found"
+// @Failure 502 {object} ErrorResponse "Panel request failed"
+// @Router /api/v1/dashboard/servers/{id}/files/download [get]
+func (h *DashboardHandler) GetServerFileDownload(c *fiber.Ctx) error {
+	file := c.Query("file")
+	if file == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Success: false,
+			Error:   "file is required",
+		})
+	}
+
+	serverUUID, ok, err := h.serverFileAccess(c, permFileReadContent)
+	if !ok {
+		return err
+	}
+
+	url, err := h.pteroClient.GetServerFileDownloadURL(c.Context(), serverUUID, file)
+	if err != nil {
+		log.Error().Err(err).Str("server_uuid", serverUUID).Str("file", file).Msg("Failed to create server file download URL")
+		return c.Status(fiber.StatusBadGateway).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Failed to create download URL",
+		})
+	}
+
+	return c.JSON(SuccessResponse{
+		Success: true,
+		Data: fiber.Map{
+			"file": file,
+			"url":  url,
+		},
+	})
+}
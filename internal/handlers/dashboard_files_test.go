@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestTrimPartialRune(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want []byte
+	}{
+		{name: "empty", data: []byte{}, want: []byte{}},
+		{name: "ascii", data: []byte("hello"), want: []byte("hello")},
+		{name: "whole multi-byte rune", data: []byte("café"), want: []byte("café")},
+		{name: "cut two-byte rune", data: []byte("café")[:4], want: []byte("caf")},
+		{name: "cut three-byte rune", data: []byte("a€")[:3], want: []byte("a")},
+		{name: "cut four-byte rune", data: []byte("a\U0001F600")[:4], want: []byte("a")},
+		{name: "binary tail is kept", data: []byte{'a', 0xff}, want: []byte{'a', 0xff}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := trimPartialRune(tt.data); !bytes.Equal(got, tt.want) {
+				t.Errorf("trimPartialRune(%q) = %q, want %q", tt.data, got, tt.want)
+			}
+		})
+	}
+}
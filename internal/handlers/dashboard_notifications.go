@@ -0,0 +1,262 @@
+package handlers
+
+import (
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+
+	"github.com/nodebyte/backend/internal/database"
+)
+
+// NotificationPreferenceUpdate changes how one category is delivered
+type NotificationPreferenceUpdate struct {
+	Category string `json:"category"`
+	Email    bool   `json:"email"`
+	InApp    bool   `json:"inApp"`
+}
+
+// UpdateNotificationPreferencesRequest is the body of a preferences update;
+// categories not listed are left unchanged
+type UpdateNotificationPreferencesRequest struct {
+	Preferences []NotificationPreferenceUpdate `json:"preferences"`
+}
+
+// GetNotificationPreferences returns the caller's notification preferences
+// @Summary Get notification preferences
+// @Description Returns how the caller receives each notification category (billing, server_status, marketing), with defaults for categories they have not changed. Security emails are mandatory and always on.
+// @Tags Dashboard
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} SuccessResponse "Preferences retrieved"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/v1/dashboard/account/notifications [get]
+func (h *DashboardHandler) GetNotificationPreferences(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok || userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{
+			Success: false,
+			Error:   "User not authenticated",
+		})
+	}
+
+	prefs, err := h.db.GetNotificationPreferences(c.Context(), userID)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID).Msg("Failed to fetch notification preferences")
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Failed to fetch notification preferences",
+		})
+	}
+
+	return c.JSON(SuccessResponse{
+		Success: true,
+		Data:    fiber.Map{"preferences": prefs},
+	})
+}
+
+// UpdateNotificationPreferences changes the caller's notification preferences
+// @Summary Update notification preferences
+// @Description Sets email and in-app delivery for the listed categories and returns the full set. Security notifications cannot be turned off.
+// @Tags Dashboard
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body UpdateNotificationPreferencesRequest true "Preferences to change"
+// @Success 200 {object} SuccessResponse "Preferences updated"
+// @Failure 400 {object} ErrorResponse "Unknown or mandatory category"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/v1/dashboard/account/notifications [put]
+func (h *DashboardHandler) UpdateNotificationPreferences(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok || userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{
+			Success: false,
+			Error:   "User not authenticated",
+		})
+	}
+
+	var req UpdateNotificationPreferencesRequest
+	if err := c.BodyParser(&req); err != nil || len(req.Preferences) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Success: false,
+			Error:   "preferences must list at least one category",
+		})
+	}
+	// Check every entry first so a bad one changes nothing
+	for _, p := range req.Preferences {
+		category, ok := database.LookupNotificationCategory(p.Category)
+		if !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Success: false,
+				Error:   "Unknown notification category: " + p.Category,
+			})
+		}
+		if category.Mandatory && (!p.Email || !p.InApp) {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Success: false,
+				Error:   p.Category + " notifications cannot be turned off",
+			})
+		}
+	}
+
+	for _, p := range req.Preferences {
+		if err := h.db.SetNotificationPreference(c.Context(), userID, p.Category, p.Email, p.InApp); err != nil {
+			log.Error().Err(err).Str("user_id", userID).Str("category", p.Category).Msg("Failed to update notification preference")
+			return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+				Success: false,
+				Error:   "Failed to update notification preferences",
+			})
+		}
+	}
+
+	return h.GetNotificationPreferences(c)
+}
+
+// maxMarkReadNotifications caps the notification IDs in one mark-read request
+const maxMarkReadNotifications = 500
+
+// MarkNotificationsReadRequest lists the notifications to mark read; an empty
+// list marks all of them
+type MarkNotificationsReadRequest struct {
+	IDs []int64 `json:"ids"`
+}
+
+// GetNotifications lists the caller's in-app notifications
+// @Summary List notifications
+// @Description Returns the caller's in-app notifications, newest first, with the unread count for the notification bell
+// @Tags Dashboard
+// @Produce json
+// @Security BearerAuth
+// @Param page query int false "Page number" default(1)
+// @Param limit query int false "Items per page" default(25)
+// @Param unread query bool false "Only unread notifications"
+// @Success 200 {object} SuccessResponse "Notifications retrieved"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/v1/dashboard/notifications [get]
+func (h *DashboardHandler) GetNotifications(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok || userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{
+			Success: false,
+			Error:   "User not authenticated",
+		})
+	}
+	pagination := parsePagination(c, configuredPageLimits(h.cfg))
+
+	notifications, total, err := h.db.ListNotifications(c.Context(), userID, c.QueryBool("unread", false), pagination.Limit, pagination.Offset)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID).Msg("Failed to fetch notifications")
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Failed to fetch notifications",
+		})
+	}
+	unread, err := h.db.CountUnreadNotifications(c.Context(), userID)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID).Msg("Failed to count unread notifications")
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Failed to fetch notifications",
+		})
+	}
+
+	return c.JSON(SuccessResponse{
+		Success: true,
+		Data: fiber.Map{
+			"notifications": notifications,
+			"unread":        unread,
+			"meta":          pagination.Meta(total),
+		},
+	})
+}
+
+// GetUnreadNotificationCount returns how many notifications the caller has not read
+// @Summary Count unread notifications
+// @Description Returns the caller's unread notification count, for polling the notification bell
+// @Tags Dashboard
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} SuccessResponse "Unread count"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/v1/dashboard/notifications/count [get]
+func (h *DashboardHandler) GetUnreadNotificationCount(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok || userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{
+			Success: false,
+			Error:   "User not authenticated",
+		})
+	}
+
+	unread, err := h.db.CountUnreadNotifications(c.Context(), userID)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID).Msg("Failed to count unread notifications")
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Failed to count notifications",
+		})
+	}
+
+	return c.JSON(SuccessResponse{
+		Success: true,
+		Data:    fiber.Map{"unread": unread},
+	})
+}
+
+// MarkNotificationsRead marks the caller's notifications read
+// @Summary Mark notifications read
+// @Description Marks the listed notifications read, or all of the caller's notifications when ids is empty or omitted. IDs belonging to other users are ignored.
+// @Tags Dashboard
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body MarkNotificationsReadRequest false "Notifications to mark read"
+// @Success 200 {object} SuccessResponse "Notifications marked read"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/v1/dashboard/notifications/read [post]
+func (h *DashboardHandler) MarkNotificationsRead(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok || userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{
+			Success: false,
+			Error:   "User not authenticated",
+		})
+	}
+
+	var req MarkNotificationsReadRequest
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+				Success: false,
+				Error:   "Invalid request body",
+			})
+		}
+	}
+	if len(req.IDs) > maxMarkReadNotifications {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Success: false,
+			Error:   fmt.Sprintf("At most %d notifications can be marked at once", maxMarkReadNotifications),
+		})
+	}
+
+	marked, err := h.db.MarkNotificationsRead(c.Context(), userID, req.IDs)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID).Msg("Failed to mark notifications read")
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Failed to mark notifications read",
+		})
+	}
+
+	return c.JSON(SuccessResponse{
+		Success: true,
+		Data:    fiber.Map{"marked": marked},
+	})
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+)
+
+// GetPlan returns the user's plan limits and current usage
+// @Summary Get plan allowance
+// @Description Returns the user's plan (or the default plan) and what their servers use against it. plan is null when no limits apply; null limits are unlimited.
+// @Tags Dashboard
+// @Produce json
+// @Security BearerAuth
+// @Success 200 {object} SuccessResponse "Plan retrieved"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/v1/dashboard/plan [get]
+func (h *DashboardHandler) GetPlan(c *fiber.Ctx) error {
+	userID, ok := c.Locals("userID").(string)
+	if !ok || userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{
+			Success: false,
+			Error:   "User not authenticated",
+		})
+	}
+
+	plan, err := h.db.GetUserPlan(c.Context(), userID)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID).Msg("Failed to fetch user plan")
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Failed to fetch plan",
+		})
+	}
+	used, err := h.db.GetPlanUsage(c.Context(), userID)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID).Msg("Failed to fetch plan usage")
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Failed to fetch plan",
+		})
+	}
+
+	return c.JSON(SuccessResponse{
+		Success: true,
+		Data: fiber.Map{
+			"plan": plan,
+			"used": used,
+		},
+	})
+}
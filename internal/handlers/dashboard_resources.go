@@ -0,0 +1,322 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/errgroup"
+)
+
+// serverResourcesCacheTTL is how long live resource readings are reused,
+// absorbing repeated polls from server detail pages
+const serverResourcesCacheTTL = 5 * time.Second
+
+// ServerLiveResources is a server's current resource usage as reported by the panel
+type ServerLiveResources struct {
+	State          string    `json:"state"`
+	IsSuspended    bool      `json:"isSuspended"`
+	CPUAbsolute    float64   `json:"cpuAbsolute"` // percent of one core
+	MemoryBytes    int64     `json:"memoryBytes"`
+	DiskBytes      int64     `json:"diskBytes"`
+	NetworkRxBytes int64     `json:"networkRxBytes"`
+	NetworkTxBytes int64     `json:"networkTxBytes"`
+	UptimeMs       int64     `json:"uptimeMs"`
+	FetchedAt      time.Time `json:"fetchedAt"`
+}
+
+// liveResourcesFromPanel reads a Pterodactyl client API resources response
+func liveResourcesFromPanel(resources map[string]interface{}) ServerLiveResources {
+	attrs, _ := resources["attributes"].(map[string]interface{})
+	usage, _ := attrs["resources"].(map[string]interface{})
+
+	live := ServerLiveResources{}
+	live.State, _ = attrs["current_state"].(string)
+	live.IsSuspended, _ = attrs["is_suspended"].(bool)
+	live.CPUAbsolute, _ = usage["cpu_absolute"].(float64)
+	number := func(key string) int64 {
+		n, _ := usage[key].(float64)
+		return int64(n)
+	}
+	live.MemoryBytes = number("memory_bytes")
+	live.DiskBytes = number("disk_bytes")
+	live.NetworkRxBytes = number("network_rx_bytes")
+	live.NetworkTxBytes = number("network_tx_bytes")
+	live.UptimeMs = number("uptime")
+	return live
+}
+
+// GetServerResources returns live resource usage for a server the user owns
+// @Summary Get live server resources
+// @Description Returns the server's current state and CPU, memory, disk and network usage straight from the panel. Readings are cached per server for 5 seconds; cacheAge is the reading's age in seconds and the X-Cache header reports HIT or MISS. Nothing is stored; see /dashboard/usage for recorded snapshots.
+// @Tags Dashboard
+// @Produce json
+// @Security BearerAuth
+// @Param id path string true "Server ID"
+// @Success 200 {object} SuccessResponse "Resources retrieved"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 404 {object} ErrorResponse "Server not found"
+// @Failure 502 {object} ErrorResponse "Panel request failed"
+// @Router /api/v1/dashboard/servers/{id}/resources [get]
+func (h *DashboardHandler) GetServerResources(c *fiber.Ctx) error {
+	ctx := c.Context()
+
+	userID, ok := c.Locals("userID").(string)
+	if !ok || userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{
+			Success: false,
+			Error:   "User not authenticated",
+		})
+	}
+	isAdmin, _ := c.Locals("isAdmin").(bool)
+
+	// Ownership check - admins may view any server
+	var serverID string
+	var serverUUID *string
+	err := h.db.Pool.QueryRow(ctx,
+		`SELECT id, uuid FROM servers WHERE id = $1 AND ("ownerId" = $2 OR $3) AND "deletedAt" IS NULL`,
+		c.Params("id"), userID, isAdmin,
+	).Scan(&serverID, &serverUUID)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Server not found",
+		})
+	}
+	if serverUUID == nil || *serverUUID == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Server is not managed by the panel",
+		})
+	}
+
+	live, hit := h.cachedLiveResources(ctx, serverID)
+	if hit {
+		c.Set("X-Cache", "HIT")
+	} else {
+		live, err = h.fetchLiveResources(ctx, serverID, *serverUUID)
+		if err != nil {
+			log.Error().Err(err).Str("server_uuid", *serverUUID).Msg("Failed to fetch server resources")
+			return c.Status(fiber.StatusBadGateway).JSON(ErrorResponse{
+				Success: false,
+				Error:   "Failed to fetch server resources",
+			})
+		}
+		c.Set("X-Cache", "MISS")
+	}
+
+	return c.JSON(SuccessResponse{
+		Success: true,
+		Data: fiber.Map{
+			"resources": live,
+			"cacheAge":  time.Since(live.FetchedAt).Seconds(),
+		},
+	})
+}
+
+// cachedLiveResources returns a server's cached live reading, if any
+func (h *DashboardHandler) cachedLiveResources(ctx context.Context, serverID string) (ServerLiveResources, bool) {
+	var live ServerLiveResources
+	hit, err := h.cache.Get(ctx, serverResourcesCacheKey(serverID), &live)
+	if err != nil {
+		log.Warn().Err(err).Str("server_id", serverID).Msg("Failed to read cached server resources")
+	}
+	return live, hit
+}
+
+// fetchLiveResources reads a server's resources from the panel and caches them
+func (h *DashboardHandler) fetchLiveResources(ctx context.Context, serverID, serverUUID string) (ServerLiveResources, error) {
+	resources, err := h.pteroClient.GetServerResources(ctx, serverUUID)
+	if err != nil {
+		return ServerLiveResources{}, err
+	}
+
+	live := liveResourcesFromPanel(resources)
+	live.FetchedAt = time.Now()
+	if err := h.cache.Set(ctx, serverResourcesCacheKey(serverID), live, serverResourcesCacheTTL); err != nil {
+		log.Warn().Err(err).Str("server_id", serverID).Msg("Failed to cache server resources")
+	}
+	return live, nil
+}
+
+func serverResourcesCacheKey(serverID string) string {
+	return "server:resources:" + serverID
+}
+
+// Limits for bulk server status lookups
+const (
+	maxServerStatusIDs          = 100
+	serverStatusSnapshotMaxAge  = 5 * time.Minute // older snapshots are refreshed from the panel
+	serverStatusFetchConcurrent = 5
+	serverStatusFetchTimeout    = 5 * time.Second // past this, remaining servers keep their snapshot
+)
+
+// Sources of a bulk server status entry
+const (
+	ServerStatusFromCache    = "cache"    // live reading cached within the last few seconds
+	ServerStatusFromSnapshot = "snapshot" // recorded by the server state sync
+	ServerStatusFromLive     = "live"     // fetched from the panel for this request
+	ServerStatusFromStored   = "stored"   // no power state known; status column only
+)
+
+// ServerStatusRequest lists the servers to look up
+type ServerStatusRequest struct {
+	ServerIDs []string `json:"serverIds"`
+}
+
+// ServerStatusEntry is the latest known status of one server
+type ServerStatusEntry struct {
+	ServerID    string     `json:"serverId"`
+	Status      string     `json:"status"`
+	IsSuspended bool       `json:"isSuspended"`
+	State       *string    `json:"state"` // power state, e.g. running or offline
+	Source      string     `json:"source"`
+	UpdatedAt   *time.Time `json:"updatedAt"`
+}
+
+// GetServerStatuses returns the latest known status of several servers
+// @Summary Bulk server status
+// @Description Returns the status and power state of up to 100 servers in one call. Servers the user does not own (unless admin) or that do not exist are listed under notFound. Each state comes from a live reading cached in the last few seconds, a state snapshot under 5 minutes old, or a fresh panel fetch, reported in source; if the panel cannot be reached, or refreshing takes longer than 5 seconds, the older snapshot or stored status is returned instead.
+// @Tags Dashboard
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param body body ServerStatusRequest true "Server IDs"
+// @Success 200 {object} SuccessResponse "Statuses retrieved"
+// @Failure 400 {object} ErrorResponse "Invalid request"
+// @Failure 401 {object} ErrorResponse "Unauthorized"
+// @Failure 500 {object} ErrorResponse "Internal server error"
+// @Router /api/v1/dashboard/servers/status [post]
+func (h *DashboardHandler) GetServerStatuses(c *fiber.Ctx) error {
+	ctx := c.Context()
+
+	userID, ok := c.Locals("userID").(string)
+	if !ok || userID == "" {
+		return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{
+			Success: false,
+			Error:   "User not authenticated",
+		})
+	}
+	isAdmin, _ := c.Locals("isAdmin").(bool)
+
+	var req ServerStatusRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Invalid request body",
+		})
+	}
+
+	ids := make([]string, 0, len(req.ServerIDs))
+	seen := map[string]bool{}
+	for _, id := range req.ServerIDs {
+		if id != "" && !seen[id] {
+			seen[id] = true
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Success: false,
+			Error:   "serverIds is required",
+		})
+	}
+	if len(ids) > maxServerStatusIDs {
+		return c.Status(fiber.StatusBadRequest).JSON(ErrorResponse{
+			Success: false,
+			Error:   fmt.Sprintf("At most %d servers can be looked up at once", maxServerStatusIDs),
+		})
+	}
+
+	snapshots, err := h.db.GetServerStatusSnapshots(ctx, ids, userID, isAdmin)
+	if err != nil {
+		log.Error().Err(err).Str("user_id", userID).Msg("Failed to fetch server statuses")
+		return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Failed to fetch server statuses",
+		})
+	}
+
+	// Cache reads and panel fetches share one deadline; servers not refreshed
+	// by then are answered from their snapshot or stored status
+	fetchCtx, cancel := context.WithTimeout(ctx, serverStatusFetchTimeout)
+	defer cancel()
+
+	entries := make(map[string]*ServerStatusEntry, len(snapshots))
+	var g errgroup.Group
+	g.SetLimit(serverStatusFetchConcurrent)
+	for _, s := range snapshots {
+		entry := &ServerStatusEntry{
+			ServerID:    s.ServerID,
+			Status:      s.Status,
+			IsSuspended: s.IsSuspended,
+			State:       s.State,
+			Source:      ServerStatusFromStored,
+			UpdatedAt:   s.StateAt,
+		}
+		if s.State != nil {
+			entry.Source = ServerStatusFromSnapshot
+		}
+		entries[s.ServerID] = entry
+
+		if fetchCtx.Err() != nil {
+			continue
+		}
+		if live, hit := h.cachedLiveResources(fetchCtx, s.ServerID); hit {
+			entry.applyLive(live, ServerStatusFromCache)
+			continue
+		}
+		fresh := s.StateAt != nil && time.Since(*s.StateAt) < serverStatusSnapshotMaxAge
+		if fresh || s.UUID == nil || *s.UUID == "" {
+			continue
+		}
+
+		serverID, serverUUID := s.ServerID, *s.UUID
+		g.Go(func() error {
+			if fetchCtx.Err() != nil {
+				return nil
+			}
+			live, err := h.fetchLiveResources(fetchCtx, serverID, serverUUID)
+			if err != nil {
+				log.Warn().Err(err).Str("server_uuid", serverUUID).Msg("Failed to fetch server resources for status")
+				return nil
+			}
+			entry.applyLive(live, ServerStatusFromLive)
+			return nil
+		})
+	}
+	g.Wait()
+	if fetchCtx.Err() != nil {
+		log.Warn().Str("user_id", userID).Int("servers", len(ids)).Msg("Server status refresh timed out; answering the rest from snapshots")
+	}
+
+	servers := make([]ServerStatusEntry, 0, len(entries))
+	notFound := []string{}
+	for _, id := range ids {
+		if entry, ok := entries[id]; ok {
+			servers = append(servers, *entry)
+		} else {
+			notFound = append(notFound, id)
+		}
+	}
+
+	return c.JSON(SuccessResponse{
+		Success: true,
+		Data: fiber.Map{
+			"servers":  servers,
+			"notFound": notFound,
+		},
+	})
+}
+
+// applyLive replaces the entry's state with a live reading
+func (e *ServerStatusEntry) applyLive(live ServerLiveResources, source string) {
+	state := live.State
+	fetchedAt := live.FetchedAt
+	e.State = &state
+	e.IsSuspended = e.IsSuspended || live.IsSuspended
+	e.Source = source
+	e.UpdatedAt = &fetchedAt
+}
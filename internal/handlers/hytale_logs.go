@@ -2,7 +2,6 @@ package handlers
 
 import (
 	"net/http"
-	"strconv"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/rs/zerolog/log"
@@ -42,17 +41,10 @@ func (h *HytaleLogsHandler) GetHytaleLogs(c *fiber.Ctx) error {
 		})
 	}
 
-	limitStr := c.Query("limit", "100")
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit < 1 {
-		limit = 100
-	}
-	if limit > 1000 {
-		limit = 1000
-	}
+	pagination := parsePagination(c, PageLimits{Default: 100, Max: 1000})
 
 	auditRepo := database.NewHytaleAuditLogRepository(h.db)
-	logs, err := auditRepo.GetAuditLogs(c.Context(), accountID, limit)
+	logs, err := auditRepo.GetAuditLogs(c.Context(), accountID, pagination.Limit)
 	if err != nil {
 		log.Error().Err(err).Str("account_id", accountID).Msg("Failed to retrieve Hytale audit logs")
 		return c.Status(http.StatusInternalServerError).JSON(types.ErrorResponse{
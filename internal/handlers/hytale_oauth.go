@@ -1,13 +1,20 @@
 package handlers
 
 import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"time"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 	"github.com/rs/zerolog/log"
 
+	"github.com/nodebyte/backend/internal/cache"
+	"github.com/nodebyte/backend/internal/config"
 	"github.com/nodebyte/backend/internal/database"
 	"github.com/nodebyte/backend/internal/hytale"
 	"github.com/nodebyte/backend/internal/types"
@@ -18,22 +25,67 @@ type HytaleOAuthHandler struct {
 	db          *database.DB
 	oauthRepo   *database.HytaleOAuthRepository
 	oauthClient *hytale.OAuthClient
+	auditRepo   *database.HytaleAuditLogRepository
+	cache       *cache.Cache
+	env         *hytale.Environment
+	cfg         *config.Config
 }
 
-// NewHytaleOAuthHandler creates a new Hytale OAuth handler
-func NewHytaleOAuthHandler(db *database.DB, useStaging bool) *HytaleOAuthHandler {
+const (
+	// defaultDevicePollInterval is the device flow polling interval used when
+	// Hytale's device code response did not specify one (RFC 8628 default)
+	defaultDevicePollInterval = 5
+	// slowDownIncrement is added to a device code's interval each time its
+	// client polls too fast, as RFC 8628 requires
+	slowDownIncrement = 5
+	// defaultDeviceCodeTTL bounds how long poll state is kept when the
+	// device code lifetime is unknown
+	defaultDeviceCodeTTL = 15 * time.Minute
+)
+
+// devicePollState is the Redis record of a device code's polling interval
+type devicePollState struct {
+	Interval  int       `json:"interval"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// NewHytaleOAuthHandler creates a new Hytale OAuth handler. The cache holds
+// device code polling state; with a nil cache polling is not paced.
+func NewHytaleOAuthHandler(db *database.DB, env *hytale.Environment, pollCache *cache.Cache, cfg *config.Config) *HytaleOAuthHandler {
 	oauthClient := hytale.NewOAuthClient(&hytale.OAuthClientConfig{
-		ClientID:   "hytale-server",
-		UseStaging: useStaging,
+		ClientID:    "hytale-server",
+		Environment: env,
 	})
 
 	return &HytaleOAuthHandler{
 		db:          db,
 		oauthRepo:   database.NewHytaleOAuthRepository(db),
 		oauthClient: oauthClient,
+		auditRepo:   database.NewHytaleAuditLogRepository(db),
+		cache:       pollCache,
+		env:         env,
+		cfg:         cfg,
 	}
 }
 
+// GetStatus reports the Hytale environment in use
+// @Summary Get Hytale OAuth Status
+// @Description Reports whether the Hytale OAuth clients talk to the production or staging services, and how many minutes before expiry tokens and game sessions are refreshed. Admins can switch the environment at runtime in the admin settings.
+// @Tags Hytale OAuth
+// @Produce json
+// @Success 200 {object} types.HytaleStatusResponseDTO
+// @Router /api/v1/hytale/status [get]
+func (h *HytaleOAuthHandler) GetStatus(c *fiber.Ctx) error {
+	return c.JSON(types.HytaleStatusResponseDTO{
+		Success:     true,
+		Environment: h.env.Name(),
+		UseStaging:  h.env.Staging(),
+		Host:        h.env.Host(),
+
+		RefreshLeadMinutes: int(h.cfg.HytaleRefreshLead() / time.Minute),
+	})
+}
+
 // RequestDeviceCode initiates device code flow
 // @Summary Request Device Code
 // @Description Initiates OAuth 2.0 Device Code Flow for Hytale server authentication
@@ -77,6 +129,17 @@ func (h *HytaleOAuthHandler) RequestDeviceCode(c *fiber.Ctx) error {
 		Str("user_code", deviceResp.UserCode).
 		Msg("Device code requested")
 
+	h.audit(c, database.AuditDeviceCodeRequested, req.AccountID, "", nil)
+
+	interval := deviceResp.Interval
+	if interval < 1 {
+		interval = defaultDevicePollInterval
+	}
+	h.saveDevicePollState(c.Context(), deviceResp.DeviceCode, devicePollState{
+		Interval:  interval,
+		ExpiresAt: time.Now().Add(time.Duration(deviceResp.ExpiresIn) * time.Second),
+	})
+
 	return c.JSON(types.DeviceCodeResponseDTO{
 		Success:                 true,
 		DeviceCode:              deviceResp.DeviceCode,
@@ -90,13 +153,14 @@ func (h *HytaleOAuthHandler) RequestDeviceCode(c *fiber.Ctx) error {
 
 // PollToken polls for token after user authorization
 // @Summary Poll for Token
-// @Description Polls Hytale OAuth endpoint to obtain access token after user authorization
+// @Description Polls Hytale OAuth endpoint to obtain access token after user authorization. Clients must wait the device code's interval between polls; polling faster returns a slow_down error with the new, longer interval (RFC 8628). When the poll carries a bearer token, the account is recorded as that user's, which linking its game sessions to servers requires.
 // @Tags Hytale OAuth
 // @Accept json
 // @Produce json
 // @Param payload body types.PollTokenRequest true "Token polling request"
 // @Success 200 {object} types.TokenResponseDTO
-// @Failure 400 {object} types.ErrorResponse "Invalid request"
+// @Success 202 {object} types.TokenResponseDTO "authorization_pending, with the polling interval"
+// @Failure 400 {object} types.TokenResponseDTO "Invalid request, or slow_down with the new polling interval"
 // @Failure 500 {object} types.ErrorResponse "Internal server error"
 // @Router /api/v1/hytale/oauth/token [post]
 func (h *HytaleOAuthHandler) PollToken(c *fiber.Ctx) error {
@@ -123,6 +187,27 @@ func (h *HytaleOAuthHandler) PollToken(c *fiber.Ctx) error {
 		})
 	}
 
+	// Enforce the device code's polling interval so one aggressive client
+	// can't get the whole integration rate-limited by Hytale
+	pollState := h.devicePollState(c.Context(), req.DeviceCode)
+	allowed, err := h.cache.SetNX(c.Context(), devicePollKey(req.DeviceCode), true, time.Duration(pollState.Interval)*time.Second)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to check device code poll interval")
+	} else if !allowed {
+		pollState.Interval += slowDownIncrement
+		h.saveDevicePollState(c.Context(), req.DeviceCode, pollState)
+		// Restart the wait with the longer interval
+		if err := h.cache.Set(c.Context(), devicePollKey(req.DeviceCode), true, time.Duration(pollState.Interval)*time.Second); err != nil {
+			log.Warn().Err(err).Msg("Failed to reset device code poll interval")
+		}
+		return c.Status(http.StatusBadRequest).JSON(types.TokenResponseDTO{
+			Success:          false,
+			Error:            "slow_down",
+			ErrorDescription: fmt.Sprintf("Polling too fast; wait at least %d seconds between requests", pollState.Interval),
+			Interval:         pollState.Interval,
+		})
+	}
+
 	// Poll Hytale for token
 	tokenResp, err := h.oauthClient.PollToken(c.Context(), req.DeviceCode)
 	if err != nil {
@@ -139,6 +224,19 @@ func (h *HytaleOAuthHandler) PollToken(c *fiber.Ctx) error {
 			Success:          false,
 			Error:            "authorization_pending",
 			ErrorDescription: "User has not yet authorized the device",
+			Interval:         pollState.Interval,
+		})
+	}
+
+	// Hytale asked us to back off; slow this device code down too
+	if tokenResp.Error == "slow_down" {
+		pollState.Interval += slowDownIncrement
+		h.saveDevicePollState(c.Context(), req.DeviceCode, pollState)
+		return c.Status(http.StatusBadRequest).JSON(types.TokenResponseDTO{
+			Success:          false,
+			Error:            tokenResp.Error,
+			ErrorDescription: tokenResp.ErrorDescription,
+			Interval:         pollState.Interval,
 		})
 	}
 
@@ -148,6 +246,7 @@ func (h *HytaleOAuthHandler) PollToken(c *fiber.Ctx) error {
 			Str("error", tokenResp.Error).
 			Str("account_id", req.AccountID).
 			Msg("Token request failed")
+		h.audit(c, database.AuditAuthFailed, req.AccountID, "", map[string]string{"error": tokenResp.Error})
 		return c.Status(http.StatusBadRequest).JSON(types.TokenResponseDTO{
 			Success:          false,
 			Error:            tokenResp.Error,
@@ -173,10 +272,20 @@ func (h *HytaleOAuthHandler) PollToken(c *fiber.Ctx) error {
 		})
 	}
 
+	// Polling while signed in makes the account the user's, so they can
+	// link its game sessions to their servers
+	if userID, _ := c.Locals("userID").(string); userID != "" {
+		if err := h.oauthRepo.SetOAuthTokenOwner(c.Context(), req.AccountID, userID); err != nil {
+			log.Error().Err(err).Str("account_id", req.AccountID).Msg("Failed to record Hytale account owner")
+		}
+	}
+
 	log.Info().
 		Str("account_id", req.AccountID).
 		Msg("OAuth token obtained and stored")
 
+	h.audit(c, database.AuditTokenCreated, req.AccountID, "", nil)
+
 	return c.JSON(types.TokenResponseDTO{
 		Success:      true,
 		AccessToken:  tokenResp.AccessToken,
@@ -242,6 +351,7 @@ func (h *HytaleOAuthHandler) RefreshAccessToken(c *fiber.Ctx) error {
 			Str("error", tokenResp.Error).
 			Str("account_id", req.AccountID).
 			Msg("Token refresh failed")
+		h.audit(c, database.AuditAuthFailed, req.AccountID, "", map[string]string{"error": tokenResp.Error})
 		return c.Status(http.StatusBadRequest).JSON(types.TokenResponseDTO{
 			Success:          false,
 			Error:            tokenResp.Error,
@@ -266,6 +376,8 @@ func (h *HytaleOAuthHandler) RefreshAccessToken(c *fiber.Ctx) error {
 
 	log.Info().Str("account_id", req.AccountID).Msg("OAuth token refreshed")
 
+	h.audit(c, database.AuditTokenRefreshed, req.AccountID, "", nil)
+
 	return c.JSON(types.TokenResponseDTO{
 		Success:      true,
 		AccessToken:  tokenResp.AccessToken,
@@ -390,6 +502,8 @@ func (h *HytaleOAuthHandler) SelectProfile(c *fiber.Ctx) error {
 		Str("profile_uuid", req.ProfileUUID).
 		Msg("Profile selected")
 
+	h.audit(c, database.AuditProfileSelected, req.AccountID, req.ProfileUUID, nil)
+
 	return c.JSON(types.SuccessResponse{
 		Success: true,
 		Message: fmt.Sprintf("Profile %s selected", req.ProfileUUID),
@@ -479,6 +593,7 @@ func (h *HytaleOAuthHandler) CreateGameSession(c *fiber.Ctx) error {
 		ProfileUUID:   profileUUID,
 		SessionToken:  sessionResp.SessionToken,
 		IdentityToken: sessionResp.IdentityToken,
+		ExpiresAt:     sessionResp.Expiry(time.Now()),
 	}
 
 	// Link to server if provided
@@ -502,6 +617,8 @@ func (h *HytaleOAuthHandler) CreateGameSession(c *fiber.Ctx) error {
 		Str("profile_uuid", profileUUID).
 		Msg("Game session created")
 
+	h.audit(c, database.AuditSessionCreated, req.AccountID, profileUUID, nil)
+
 	return c.JSON(types.CreateGameSessionResponseDTO{
 		Success: true,
 		Session: types.GameSessionDTO{
@@ -590,7 +707,7 @@ func (h *HytaleOAuthHandler) RefreshGameSession(c *fiber.Ctx) error {
 		})
 	}
 
-	if err := h.oauthRepo.UpdateGameSessionTokens(c.Context(), req.AccountID, profileUUID, sessionResp.SessionToken, sessionResp.IdentityToken); err != nil {
+	if err := h.oauthRepo.UpdateGameSessionTokens(c.Context(), req.AccountID, profileUUID, sessionResp.SessionToken, sessionResp.IdentityToken, sessionResp.Expiry(time.Now())); err != nil {
 		log.Error().Err(err).
 			Str("account_id", req.AccountID).
 			Str("profile_uuid", profileUUID).
@@ -606,6 +723,8 @@ func (h *HytaleOAuthHandler) RefreshGameSession(c *fiber.Ctx) error {
 		Str("profile_uuid", profileUUID).
 		Msg("Game session refreshed")
 
+	h.audit(c, database.AuditSessionRefreshed, req.AccountID, profileUUID, nil)
+
 	return c.JSON(types.RefreshGameSessionResponseDTO{
 		Success: true,
 		Message: "Game session refreshed successfully",
@@ -703,8 +822,81 @@ func (h *HytaleOAuthHandler) TerminateGameSession(c *fiber.Ctx) error {
 		Str("profile_uuid", profileUUID).
 		Msg("Game session terminated")
 
+	h.audit(c, database.AuditSessionDeleted, req.AccountID, profileUUID, nil)
+
 	return c.JSON(types.TerminateGameSessionResponseDTO{
 		Success: true,
 		Message: "Game session terminated successfully",
 	})
 }
+
+// audit records a Hytale audit log entry with the request's IP address and
+// user agent. Failures are logged by the repository and do not fail the request.
+func (h *HytaleOAuthHandler) audit(c *fiber.Ctx, eventType database.AuditLogType, accountID, profileID string, details map[string]string) {
+	// account_id is a UUID column; skip values that could never be stored
+	if _, err := uuid.Parse(accountID); err != nil {
+		return
+	}
+
+	entry := &database.HytaleAuditLog{
+		AccountID: accountID,
+		EventType: eventType,
+	}
+	if profileID != "" {
+		entry.ProfileID = &profileID
+	}
+	if len(details) > 0 {
+		if b, err := json.Marshal(details); err == nil {
+			d := string(b)
+			entry.Details = &d
+		}
+	}
+	if ip := c.IP(); ip != "" {
+		entry.IPAddress = &ip
+	}
+	if ua := c.Get("User-Agent"); ua != "" {
+		entry.UserAgent = &ua
+	}
+
+	_ = h.auditRepo.LogEvent(c.Context(), entry)
+}
+
+// devicePollKey returns the Redis key holding a device code's last poll. The
+// code is hashed so it isn't stored in the clear.
+func devicePollKey(deviceCode string) string {
+	sum := sha256.Sum256([]byte(deviceCode))
+	return "hytale:device-poll:" + hex.EncodeToString(sum[:])
+}
+
+// devicePollStateKey returns the Redis key holding a device code's interval
+func devicePollStateKey(deviceCode string) string {
+	sum := sha256.Sum256([]byte(deviceCode))
+	return "hytale:device-code:" + hex.EncodeToString(sum[:])
+}
+
+// devicePollState returns the stored polling state for a device code,
+// falling back to the default interval
+func (h *HytaleOAuthHandler) devicePollState(ctx context.Context, deviceCode string) devicePollState {
+	state := devicePollState{
+		Interval:  defaultDevicePollInterval,
+		ExpiresAt: time.Now().Add(defaultDeviceCodeTTL),
+	}
+	if _, err := h.cache.Get(ctx, devicePollStateKey(deviceCode), &state); err != nil {
+		log.Warn().Err(err).Msg("Failed to read device code poll state")
+	}
+	if state.Interval < 1 {
+		state.Interval = defaultDevicePollInterval
+	}
+	return state
+}
+
+// saveDevicePollState stores a device code's polling state until the code expires
+func (h *HytaleOAuthHandler) saveDevicePollState(ctx context.Context, deviceCode string, state devicePollState) {
+	ttl := time.Until(state.ExpiresAt)
+	if ttl <= 0 {
+		ttl = defaultDeviceCodeTTL
+	}
+	if err := h.cache.Set(ctx, devicePollStateKey(deviceCode), state, ttl); err != nil {
+		log.Warn().Err(err).Msg("Failed to store device code poll state")
+	}
+}
@@ -0,0 +1,349 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+
+	"github.com/nodebyte/backend/internal/database"
+	"github.com/nodebyte/backend/internal/hytale"
+	"github.com/nodebyte/backend/internal/panels"
+	"github.com/nodebyte/backend/internal/types"
+)
+
+// HytaleServerLinkHandler links Hytale game sessions to Pterodactyl servers
+// so refreshed session tokens are pushed to the server environment
+type HytaleServerLinkHandler struct {
+	db          *database.DB
+	oauthRepo   *database.HytaleOAuthRepository
+	pteroClient *panels.PterodactylClient
+}
+
+// NewHytaleServerLinkHandler creates a new Hytale server link handler
+func NewHytaleServerLinkHandler(db *database.DB, pteroClient *panels.PterodactylClient) *HytaleServerLinkHandler {
+	return &HytaleServerLinkHandler{
+		db:          db,
+		oauthRepo:   database.NewHytaleOAuthRepository(db),
+		pteroClient: pteroClient,
+	}
+}
+
+// LinkServer links a Hytale game session to a server
+// @Summary Link Hytale Session to Server
+// @Description Links the game session for a Hytale account/profile to a server the caller owns and pushes the current session and identity tokens to the server environment. The caller must own the Hytale account too: it is theirs once they complete the device code flow while signed in. Any session previously linked to the server is unlinked. profile_uuid defaults to the account's selected profile.
+// @Tags Hytale
+// @Accept json
+// @Produce json
+// @Security BearerAuth
+// @Param serverId path string true "Server ID"
+// @Param payload body types.LinkServerRequest true "Session to link"
+// @Success 200 {object} types.LinkServerResponseDTO
+// @Failure 400 {object} types.ErrorResponse "Invalid request"
+// @Failure 401 {object} types.ErrorResponse "Unauthorized"
+// @Failure 403 {object} types.ErrorResponse "Hytale account belongs to another user"
+// @Failure 404 {object} types.ErrorResponse "Server or game session not found"
+// @Failure 409 {object} types.ErrorResponse "Session is linked to another user's server"
+// @Failure 500 {object} types.ErrorResponse "Internal server error"
+// @Router /api/v1/hytale/servers/{serverId}/link [post]
+func (h *HytaleServerLinkHandler) LinkServer(c *fiber.Ctx) error {
+	ctx := c.Context()
+	userID, ok := c.Locals("userID").(string)
+	if !ok || userID == "" {
+		return c.Status(http.StatusUnauthorized).JSON(types.ErrorResponse{
+			Success: false,
+			Error:   "User not authenticated",
+		})
+	}
+	isAdmin, _ := c.Locals("isAdmin").(bool)
+	serverID := c.Params("serverId")
+
+	var req types.LinkServerRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(http.StatusBadRequest).JSON(types.ErrorResponse{
+			Success: false,
+			Error:   "Invalid request format",
+		})
+	}
+
+	if req.AccountID == "" {
+		return c.Status(http.StatusBadRequest).JSON(types.ErrorResponse{
+			Success: false,
+			Error:   "account_id is required",
+		})
+	}
+
+	serverUUID, err := h.ownedServerUUID(ctx, serverID, userID, isAdmin)
+	if err != nil {
+		return c.Status(http.StatusNotFound).JSON(types.ErrorResponse{
+			Success: false,
+			Error:   "Server not found",
+		})
+	}
+
+	// Only the user who authorized the account may send its tokens to a server
+	if !isAdmin {
+		owned, err := h.oauthRepo.IsOAuthTokenOwner(ctx, req.AccountID, userID)
+		if err != nil {
+			log.Error().Err(err).Str("account_id", req.AccountID).Msg("Failed to check Hytale account owner")
+			return c.Status(http.StatusInternalServerError).JSON(types.ErrorResponse{
+				Success: false,
+				Error:   "Failed to link server",
+			})
+		}
+		if !owned {
+			return c.Status(http.StatusForbidden).JSON(types.ErrorResponse{
+				Success: false,
+				Error:   "Hytale account is not linked to your user; authorize it while signed in",
+			})
+		}
+	}
+
+	if req.ProfileUUID == "" {
+		token, err := h.oauthRepo.GetOAuthToken(ctx, req.AccountID)
+		if err != nil || !token.ProfileUUID.Valid || token.ProfileUUID.String == "" {
+			return c.Status(http.StatusBadRequest).JSON(types.ErrorResponse{
+				Success: false,
+				Error:   "profile_uuid is required",
+			})
+		}
+		req.ProfileUUID = token.ProfileUUID.String
+	}
+
+	session, err := h.oauthRepo.GetGameSession(ctx, req.AccountID, req.ProfileUUID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return c.Status(http.StatusNotFound).JSON(types.ErrorResponse{
+			Success: false,
+			Error:   "Game session not found",
+		})
+	}
+	if err != nil {
+		log.Error().Err(err).Str("account_id", req.AccountID).Msg("Failed to get game session")
+		return c.Status(http.StatusInternalServerError).JSON(types.ErrorResponse{
+			Success: false,
+			Error:   "Failed to link server",
+		})
+	}
+
+	// Don't let a user take over a session that feeds someone else's server
+	if session.ServerID.Valid && session.ServerID.String != "" && session.ServerID.String != serverID && !isAdmin {
+		if _, err := h.ownedServerUUID(ctx, session.ServerID.String, userID, false); err != nil {
+			return c.Status(http.StatusConflict).JSON(types.ErrorResponse{
+				Success: false,
+				Error:   "Game session is linked to another server",
+			})
+		}
+	}
+
+	session, err = h.oauthRepo.LinkGameSessionToServer(ctx, req.AccountID, req.ProfileUUID, serverID)
+	if err != nil || session == nil {
+		log.Error().Err(err).Str("server_id", serverID).Msg("Failed to link game session to server")
+		return c.Status(http.StatusInternalServerError).JSON(types.ErrorResponse{
+			Success: false,
+			Error:   "Failed to link server",
+		})
+	}
+
+	tokensPushed := h.pushTokens(ctx, serverUUID, session.SessionToken, session.IdentityToken)
+
+	log.Info().
+		Str("server_id", serverID).
+		Str("account_id", req.AccountID).
+		Str("profile_uuid", req.ProfileUUID).
+		Bool("tokens_pushed", tokensPushed).
+		Msg("Game session linked to server")
+
+	message := "Game session linked to server"
+	if !tokensPushed {
+		message = "Game session linked; tokens will be pushed on the next session refresh"
+	}
+
+	return c.JSON(types.LinkServerResponseDTO{
+		Success:      true,
+		ServerID:     serverID,
+		AccountID:    req.AccountID,
+		ProfileUUID:  req.ProfileUUID,
+		TokensPushed: tokensPushed,
+		Message:      message,
+	})
+}
+
+// UnlinkServer removes the game session link from a server
+// @Summary Unlink Hytale Session from Server
+// @Description Stops pushing Hytale session tokens to a server the caller owns and clears the token variables in the server environment
+// @Tags Hytale
+// @Produce json
+// @Security BearerAuth
+// @Param serverId path string true "Server ID"
+// @Success 200 {object} types.LinkServerResponseDTO
+// @Failure 401 {object} types.ErrorResponse "Unauthorized"
+// @Failure 404 {object} types.ErrorResponse "Server not found or not linked"
+// @Failure 500 {object} types.ErrorResponse "Internal server error"
+// @Router /api/v1/hytale/servers/{serverId}/link [delete]
+func (h *HytaleServerLinkHandler) UnlinkServer(c *fiber.Ctx) error {
+	ctx := c.Context()
+	userID, ok := c.Locals("userID").(string)
+	if !ok || userID == "" {
+		return c.Status(http.StatusUnauthorized).JSON(types.ErrorResponse{
+			Success: false,
+			Error:   "User not authenticated",
+		})
+	}
+	isAdmin, _ := c.Locals("isAdmin").(bool)
+	serverID := c.Params("serverId")
+
+	serverUUID, err := h.ownedServerUUID(ctx, serverID, userID, isAdmin)
+	if err != nil {
+		return c.Status(http.StatusNotFound).JSON(types.ErrorResponse{
+			Success: false,
+			Error:   "Server not found",
+		})
+	}
+
+	unlinked, err := h.oauthRepo.UnlinkServerGameSessions(ctx, serverID)
+	if err != nil {
+		log.Error().Err(err).Str("server_id", serverID).Msg("Failed to unlink game session from server")
+		return c.Status(http.StatusInternalServerError).JSON(types.ErrorResponse{
+			Success: false,
+			Error:   "Failed to unlink server",
+		})
+	}
+	if unlinked == 0 {
+		return c.Status(http.StatusNotFound).JSON(types.ErrorResponse{
+			Success: false,
+			Error:   "Server has no linked game session",
+		})
+	}
+
+	tokensCleared := h.pushTokens(ctx, serverUUID, "", "")
+
+	log.Info().Str("server_id", serverID).Msg("Game session unlinked from server")
+
+	return c.JSON(types.LinkServerResponseDTO{
+		Success:      true,
+		ServerID:     serverID,
+		TokensPushed: tokensCleared,
+		Message:      "Game session unlinked from server",
+	})
+}
+
+// PushTokens pushes the linked game session's tokens to a server
+// @Summary Push Hytale Tokens to Server
+// @Description Writes the current session and identity tokens of the game session linked to a server the caller owns into the server environment (HYTALE_SERVER_SESSION_TOKEN and HYTALE_SERVER_IDENTITY_TOKEN), so the server starts authenticated without waiting for the next refresh
+// @Tags Hytale
+// @Produce json
+// @Security BearerAuth
+// @Param serverId path string true "Server ID"
+// @Success 200 {object} types.LinkServerResponseDTO
+// @Failure 400 {object} types.ErrorResponse "Server is not managed by the panel"
+// @Failure 401 {object} types.ErrorResponse "Unauthorized"
+// @Failure 404 {object} types.ErrorResponse "Server not found or not linked"
+// @Failure 409 {object} types.ErrorResponse "Linked game session has expired"
+// @Failure 500 {object} types.ErrorResponse "Internal server error"
+// @Failure 502 {object} types.ErrorResponse "Panel request failed"
+// @Router /api/v1/hytale/servers/{serverId}/push-tokens [post]
+func (h *HytaleServerLinkHandler) PushTokens(c *fiber.Ctx) error {
+	ctx := c.Context()
+	userID, ok := c.Locals("userID").(string)
+	if !ok || userID == "" {
+		return c.Status(http.StatusUnauthorized).JSON(types.ErrorResponse{
+			Success: false,
+			Error:   "User not authenticated",
+		})
+	}
+	isAdmin, _ := c.Locals("isAdmin").(bool)
+	serverID := c.Params("serverId")
+
+	serverUUID, err := h.ownedServerUUID(ctx, serverID, userID, isAdmin)
+	if err != nil {
+		return c.Status(http.StatusNotFound).JSON(types.ErrorResponse{
+			Success: false,
+			Error:   "Server not found",
+		})
+	}
+	if serverUUID == "" || h.pteroClient == nil {
+		return c.Status(http.StatusBadRequest).JSON(types.ErrorResponse{
+			Success: false,
+			Error:   "Server is not managed by the panel",
+		})
+	}
+
+	session, err := h.oauthRepo.GetGameSessionByServer(ctx, serverID)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return c.Status(http.StatusNotFound).JSON(types.ErrorResponse{
+			Success: false,
+			Error:   "Server has no linked game session",
+		})
+	}
+	if err != nil {
+		log.Error().Err(err).Str("server_id", serverID).Msg("Failed to get linked game session")
+		return c.Status(http.StatusInternalServerError).JSON(types.ErrorResponse{
+			Success: false,
+			Error:   "Failed to push tokens",
+		})
+	}
+	if session.SessionToken == "" || session.IdentityToken == "" || !session.ExpiresAt.After(time.Now()) {
+		return c.Status(http.StatusConflict).JSON(types.ErrorResponse{
+			Success: false,
+			Error:   "Linked game session has expired; refresh it before pushing tokens",
+		})
+	}
+
+	if !h.pushTokens(ctx, serverUUID, session.SessionToken, session.IdentityToken) {
+		return c.Status(http.StatusBadGateway).JSON(types.ErrorResponse{
+			Success: false,
+			Error:   "Failed to push tokens to the server",
+		})
+	}
+
+	log.Info().
+		Str("server_id", serverID).
+		Str("account_id", session.AccountID).
+		Str("profile_uuid", session.ProfileUUID).
+		Msg("Game session tokens pushed to server")
+
+	return c.JSON(types.LinkServerResponseDTO{
+		Success:      true,
+		ServerID:     serverID,
+		AccountID:    session.AccountID,
+		ProfileUUID:  session.ProfileUUID,
+		TokensPushed: true,
+		Message:      "Tokens pushed to server",
+	})
+}
+
+// ownedServerUUID returns the panel UUID of a server owned by userID (or any
+// server for admins)
+func (h *HytaleServerLinkHandler) ownedServerUUID(ctx context.Context, serverID, userID string, isAdmin bool) (string, error) {
+	var serverUUID *string
+	err := h.db.Pool.QueryRow(ctx,
+		`SELECT uuid FROM servers WHERE id = $1 AND ("ownerId" = $2 OR $3) AND "deletedAt" IS NULL`,
+		serverID, userID, isAdmin,
+	).Scan(&serverUUID)
+	if err != nil {
+		return "", err
+	}
+	if serverUUID == nil {
+		return "", nil
+	}
+	return *serverUUID, nil
+}
+
+// pushTokens writes the session tokens to the server environment. Failures
+// are logged but not returned; the refresher pushes again on the next refresh.
+func (h *HytaleServerLinkHandler) pushTokens(ctx context.Context, serverUUID, sessionToken, identityToken string) bool {
+	if h.pteroClient == nil || serverUUID == "" {
+		return false
+	}
+
+	envVars := hytale.ServerTokenEnvironment(sessionToken, identityToken)
+	if err := h.pteroClient.UpdateServerEnvironment(ctx, serverUUID, envVars); err != nil {
+		log.Warn().Err(err).Str("server_uuid", serverUUID).Msg("Failed to push tokens to Pterodactyl server")
+		return false
+	}
+	return true
+}
@@ -1,10 +1,14 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/jackc/pgx/v5"
 	"github.com/rs/zerolog/log"
 
 	"github.com/nodebyte/backend/internal/database"
@@ -12,6 +16,13 @@ import (
 	"github.com/nodebyte/backend/internal/types"
 )
 
+const (
+	// maxLogBatchSize caps the number of log lines accepted in one request
+	maxLogBatchSize = 1000
+	// maxLogLineLength is the longest stored log line, in bytes
+	maxLogLineLength = 8192
+)
+
 // HytaleServerLogsHandler handles Hytale game server logs API requests
 type HytaleServerLogsHandler struct {
 	db *database.DB
@@ -72,14 +83,11 @@ func (h *HytaleServerLogsHandler) CreateServerLogs(c *fiber.Ctx) error {
 		Msg("Received logs from Wings")
 
 	// Convert request logs to database format
-	dbLogs := make([]string, 0, len(req.Logs))
-	for _, logEntry := range req.Logs {
-		dbLogs = append(dbLogs, logEntry.Line)
-	}
+	dbLogs := toServerLogEntries(req.Logs)
 
 	// Store logs in database
 	logsRepo := database.NewHytaleServerLogsRepository(h.db)
-	if err := logsRepo.SaveLogs(ctx, req.ServerUUID, req.AccountID, dbLogs); err != nil {
+	if _, err := logsRepo.SaveLogEntries(ctx, req.ServerUUID, req.AccountID, dbLogs); err != nil {
 		log.Error().Err(err).
 			Str("server_uuid", req.ServerUUID).
 			Msg("Failed to save server logs")
@@ -101,6 +109,99 @@ func (h *HytaleServerLogsHandler) CreateServerLogs(c *fiber.Ctx) error {
 	})
 }
 
+// IngestServerLogs stores a batch of log lines shipped by a game server
+// @Summary Ingest Hytale game server logs
+// @Description Stores leveled console log lines shipped by the Hytale egg. The server may be given by its ID or panel UUID. Up to 1000 lines per request; lines longer than 8 KiB are truncated. Requires the API key.
+// @Tags Hytale Logs
+// @Accept json
+// @Produce json
+// @Security ApiKeyAuth
+// @Param serverId path string true "Server ID or UUID"
+// @Param payload body types.IngestServerLogsRequest true "Log lines to store"
+// @Success 201 {object} types.SuccessResponse "Logs stored successfully"
+// @Failure 400 {object} types.ErrorResponse "Invalid request"
+// @Failure 401 {object} types.ErrorResponse "Invalid or missing API key"
+// @Failure 404 {object} types.ErrorResponse "Server not found"
+// @Failure 500 {object} types.ErrorResponse "Internal server error"
+// @Router /api/v1/hytale/servers/{serverId}/logs [post]
+func (h *HytaleServerLogsHandler) IngestServerLogs(c *fiber.Ctx) error {
+	span := sentry.StartSpan(c.Context(), "ingest_server_logs", "http")
+	defer span.Finish()
+	ctx := span.Context()
+
+	var req types.IngestServerLogsRequest
+	if err := c.BodyParser(&req); err != nil {
+		sentry.SetTag(c, "error_type", "invalid_request")
+		return c.Status(http.StatusBadRequest).JSON(types.ErrorResponse{
+			Success: false,
+			Error:   "Invalid request format",
+		})
+	}
+
+	if len(req.Logs) == 0 {
+		sentry.SetTag(c, "error_type", "empty_logs")
+		return c.Status(http.StatusBadRequest).JSON(types.ErrorResponse{
+			Success: false,
+			Error:   "At least one log entry is required",
+		})
+	}
+	if len(req.Logs) > maxLogBatchSize {
+		sentry.SetTag(c, "error_type", "batch_too_large")
+		return c.Status(http.StatusBadRequest).JSON(types.ErrorResponse{
+			Success: false,
+			Error:   fmt.Sprintf("At most %d log entries are allowed per request", maxLogBatchSize),
+		})
+	}
+
+	serverID := c.Params("serverId")
+	var serverUUID *string
+	err := h.db.Pool.QueryRow(ctx,
+		`SELECT uuid FROM servers WHERE id = $1 OR uuid = $1 LIMIT 1`, serverID,
+	).Scan(&serverUUID)
+	if err != nil || serverUUID == nil || *serverUUID == "" {
+		return c.Status(http.StatusNotFound).JSON(types.ErrorResponse{
+			Success: false,
+			Error:   "Server not found",
+		})
+	}
+
+	// Attribute the logs to the Hytale account linked to the server if the
+	// egg didn't say
+	accountID := req.AccountID
+	if accountID == "" {
+		if err := h.db.Pool.QueryRow(ctx,
+			`SELECT account_id::text FROM hytale_game_sessions
+			WHERE "serverId" = (SELECT id FROM servers WHERE uuid = $1)
+			ORDER BY updated_at DESC LIMIT 1`, *serverUUID,
+		).Scan(&accountID); err != nil && !errors.Is(err, pgx.ErrNoRows) {
+			log.Warn().Err(err).Str("server_uuid", *serverUUID).Msg("Failed to look up linked Hytale account")
+		}
+	}
+
+	logsRepo := database.NewHytaleServerLogsRepository(h.db)
+	saved, err := logsRepo.SaveLogEntries(ctx, *serverUUID, accountID, toServerLogEntries(req.Logs))
+	if err != nil {
+		log.Error().Err(err).
+			Str("server_uuid", *serverUUID).
+			Msg("Failed to save server logs")
+
+		sentry.CaptureErrorWithContext(c, err, http.StatusInternalServerError, "ingest_server_logs")
+		return c.Status(http.StatusInternalServerError).JSON(types.ErrorResponse{
+			Success: false,
+			Error:   "Failed to store logs",
+		})
+	}
+
+	sentry.SetTag(c, "log_count", strconv.FormatInt(saved, 10))
+	return c.Status(http.StatusCreated).JSON(types.SuccessResponse{
+		Success: true,
+		Data: map[string]interface{}{
+			"saved_count": saved,
+			"server_uuid": *serverUUID,
+		},
+	})
+}
+
 // GetHytaleServerLogs retrieves persistent logs for a game server
 
 // @Summary Get Hytale game server logs
@@ -123,8 +224,6 @@ func (h *HytaleServerLogsHandler) GetHytaleServerLogs(c *fiber.Ctx) error {
 
 	// Get query parameters
 	serverUUID := c.Query("server_uuid")
-	limitStr := c.Query("limit", "100")
-	offsetStr := c.Query("offset", "0")
 
 	// Validate required parameters
 	if serverUUID == "" {
@@ -135,20 +234,8 @@ func (h *HytaleServerLogsHandler) GetHytaleServerLogs(c *fiber.Ctx) error {
 		})
 	}
 
-	// Parse and validate limit
-	limit, err := strconv.Atoi(limitStr)
-	if err != nil || limit < 1 {
-		limit = 100
-	}
-	if limit > 1000 {
-		limit = 1000
-	}
-
-	// Parse and validate offset
-	offset, err := strconv.Atoi(offsetStr)
-	if err != nil || offset < 0 {
-		offset = 0
-	}
+	pagination := parsePagination(c, PageLimits{Default: 100, Max: maxLogBatchSize})
+	limit, offset := pagination.Limit, pagination.Offset
 
 	log.Info().
 		Str("server_uuid", serverUUID).
@@ -177,6 +264,7 @@ func (h *HytaleServerLogsHandler) GetHytaleServerLogs(c *fiber.Ctx) error {
 		logs = append(logs, types.ServerLog{
 			ID:        serverLog.ID,
 			Line:      serverLog.LogLine,
+			Level:     serverLog.Level,
 			Timestamp: serverLog.CreatedAt,
 		})
 	}
@@ -234,3 +322,24 @@ func (h *HytaleServerLogsHandler) GetHytaleServerLogsCount(c *fiber.Ctx) error {
 		},
 	})
 }
+
+// toServerLogEntries converts request log entries to the stored format,
+// truncating overlong lines
+func toServerLogEntries(entries []types.LogEntry) []database.HytaleServerLogEntry {
+	out := make([]database.HytaleServerLogEntry, 0, len(entries))
+	for _, e := range entries {
+		message := e.Line
+		if message == "" {
+			message = e.Message
+		}
+		if len(message) > maxLogLineLength {
+			message = strings.ToValidUTF8(message[:maxLogLineLength], "")
+		}
+		out = append(out, database.HytaleServerLogEntry{
+			Level:     e.Level,
+			Message:   message,
+			Timestamp: e.Timestamp,
+		})
+	}
+	return out
+}
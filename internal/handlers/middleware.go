@@ -1,14 +1,15 @@
 package handlers
 
 import (
-	"encoding/base64"
-	"encoding/json"
+	"crypto/subtle"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
 
 	"github.com/rs/zerolog/log"
 
+	"github.com/nodebyte/backend/internal/auth"
+	"github.com/nodebyte/backend/internal/config"
 	"github.com/nodebyte/backend/internal/database"
 )
 
@@ -26,17 +27,23 @@ type SuccessResponse struct {
 	Message string      `json:"message"`
 }
 
-// APIKeyMiddleware handles X-API-Key authentication
+// APIKeyMiddleware handles X-API-Key authentication. It accepts the keys
+// configured in the environment and active keys stored in api_keys, so keys
+// can be rotated or revoked per integration without downtime.
 type APIKeyMiddleware struct {
-	apiKey string
+	keys []config.APIKey
+	db   *database.DB
 }
 
-// NewAPIKeyMiddleware creates a new API key middleware
-func NewAPIKeyMiddleware(apiKey string) *APIKeyMiddleware {
-	return &APIKeyMiddleware{apiKey: apiKey}
+// NewAPIKeyMiddleware creates a new API key middleware. db may be nil to
+// accept only the configured keys.
+func NewAPIKeyMiddleware(keys []config.APIKey, db *database.DB) *APIKeyMiddleware {
+	return &APIKeyMiddleware{keys: keys, db: db}
 }
 
-// Handler returns the middleware handler
+// Handler returns the middleware handler. The matched key's label and scope
+// are stored in the apiKeyLabel and apiKeyScope locals (and its ID in
+// apiKeyID for stored keys).
 func (m *APIKeyMiddleware) Handler() fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		apiKey := c.Get("X-API-Key")
@@ -44,26 +51,109 @@ func (m *APIKeyMiddleware) Handler() fiber.Handler {
 			apiKey = c.Query("api_key")
 		}
 
-		if apiKey == "" || apiKey != m.apiKey {
-			return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{
-				Success: false,
-				Error:   "Invalid or missing API key",
-				Code:    "UNAUTHORIZED",
-			})
+		if apiKey != "" && m.authenticate(c, apiKey) {
+			m.audit(c)
+			return c.Next()
 		}
 
-		return c.Next()
+		return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Invalid or missing API key",
+			Code:    "UNAUTHORIZED",
+		})
+	}
+}
+
+// authenticate matches apiKey against the configured keys, then the stored
+// keys, and records which one was used
+func (m *APIKeyMiddleware) authenticate(c *fiber.Ctx, apiKey string) bool {
+	for _, k := range m.keys {
+		if subtle.ConstantTimeCompare([]byte(apiKey), []byte(k.Key)) == 1 {
+			c.Locals("apiKeyLabel", k.Label)
+			c.Locals("apiKeyScope", database.APIKeyScopeFull)
+			return true
+		}
 	}
+
+	if m.db == nil {
+		return false
+	}
+	key, err := m.db.UseAPIKey(c.Context(), apiKey)
+	if err != nil {
+		log.Error().Err(err).Str("path", c.Path()).Msg("Failed to look up API key")
+		return false
+	}
+	if key == nil {
+		return false
+	}
+
+	log.Debug().Str("api_key_id", key.ID).Str("label", key.Label).Str("path", c.Path()).Msg("API key authenticated")
+	c.Locals("apiKeyID", key.ID)
+	c.Locals("apiKeyLabel", key.Label)
+	c.Locals("apiKeyScope", key.Scope)
+	return true
+}
+
+// audit records which API key made a state-changing request. Reads are not
+// recorded; failures are logged but do not fail the request.
+func (m *APIKeyMiddleware) audit(c *fiber.Ctx) {
+	if m.db == nil {
+		return
+	}
+	switch c.Method() {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+		return
+	}
+
+	keyID, _ := c.Locals("apiKeyID").(string)
+	label, _ := c.Locals("apiKeyLabel").(string)
+	if err := m.db.CreateAdminAuditLog(c.Context(), database.AdminAuditEntry{
+		Action:     database.AuditAPIKeyUsed,
+		TargetType: "api_key",
+		TargetID:   keyID,
+		Details: map[string]interface{}{
+			"label":  label,
+			"method": c.Method(),
+			"path":   c.Path(),
+		},
+		IPAddress: c.IP(),
+		UserAgent: c.Get("User-Agent"),
+	}); err != nil {
+		log.Error().Err(err).Str("api_key_id", keyID).Str("label", label).Msg("Failed to write API key audit log")
+	}
+}
+
+// VerifyAPIKey confirms the caller's API key is valid
+// @Summary Verify API key
+// @Description Returns 200 when the X-API-Key header (or api_key query parameter) holds a valid backend API key, and reports the key's label and scope. Useful for checking integration setup without calling a real endpoint.
+// @Tags Auth
+// @Produce json
+// @Security ApiKeyAuth
+// @Success 200 {object} SuccessResponse "API key is valid"
+// @Failure 401 {object} ErrorResponse "Invalid or missing API key"
+// @Router /api/v1/auth/verify-api-key [get]
+func VerifyAPIKey(c *fiber.Ctx) error {
+	scope, _ := c.Locals("apiKeyScope").(string)
+	label, _ := c.Locals("apiKeyLabel").(string)
+	return c.JSON(SuccessResponse{
+		Success: true,
+		Data: fiber.Map{
+			"valid": true,
+			"label": label,
+			"scope": scope,
+		},
+	})
 }
 
 // BearerAuthMiddleware handles JWT Bearer token authentication
 type BearerAuthMiddleware struct {
-	db *database.DB
+	db         *database.DB
+	jwtService *auth.JWTService
 }
 
 // NewBearerAuthMiddleware creates a new Bearer auth middleware
-func NewBearerAuthMiddleware(db *database.DB) *BearerAuthMiddleware {
-	return &BearerAuthMiddleware{db: db}
+func NewBearerAuthMiddleware(db *database.DB, jwtService *auth.JWTService) *BearerAuthMiddleware {
+	return &BearerAuthMiddleware{db: db, jwtService: jwtService}
 }
 
 // Handler returns the middleware handler function
@@ -90,21 +180,9 @@ func (m *BearerAuthMiddleware) Handler() fiber.Handler {
 			})
 		}
 
-		token := parts[1]
-
-		// Decode JWT payload (without signature verification - we'll validate in DB)
-		// JWT format: header.payload.signature
-		tokenParts := strings.Split(token, ".")
-		if len(tokenParts) != 3 {
-			return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{
-				Success: false,
-				Error:   "Invalid token format",
-				Code:    "UNAUTHORIZED",
-			})
-		}
-
-		// Decode the payload (second part) using base64url (JWT uses raw/unpadded base64url)
-		decodedPayload, err := base64.RawURLEncoding.DecodeString(tokenParts[1])
+		// Verify the signature before trusting any claim; the impersonation
+		// session check below must only see claims this service issued
+		claims, err := m.jwtService.ValidateAccessToken(parts[1])
 		if err != nil {
 			return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{
 				Success: false,
@@ -113,31 +191,30 @@ func (m *BearerAuthMiddleware) Handler() fiber.Handler {
 			})
 		}
 
-		// Parse JSON payload
-		var claims map[string]interface{}
-		err = json.Unmarshal(decodedPayload, &claims)
-		if err != nil {
+		userID := claims.UserID
+		if userID == "" {
+			log.Error().Msg("Invalid token: failed to extract user ID from claims")
 			return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{
 				Success: false,
-				Error:   "Invalid token claims",
+				Error:   "Invalid token: missing user ID",
 				Code:    "UNAUTHORIZED",
 			})
 		}
 
-		// Get user ID from claims
-		userID, ok := claims["id"].(string)
-		if !ok || userID == "" {
-			log.Error().
-				Interface("claims", claims).
-				Msg("Invalid token: failed to extract user ID from claims")
-			return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{
-				Success: false,
-				Error:   "Invalid token: missing user ID",
-				Code:    "UNAUTHORIZED",
-			})
+		// Impersonation tokens are only honoured while their session is active
+		impersonatedBy := claims.ImpersonatedBy
+		if impersonatedBy != "" {
+			active, err := m.db.IsImpersonationSessionActive(c.Context(), claims.ID)
+			if err != nil || !active {
+				return c.Status(fiber.StatusUnauthorized).JSON(ErrorResponse{
+					Success: false,
+					Error:   "Impersonation session has ended",
+					Code:    "UNAUTHORIZED",
+				})
+			}
 		}
 
-		// Query database to verify user exists and check admin access
+		// Query database to verify user exists and resolve their roles
 		var isSystemAdmin bool
 		var roles []string
 		err = m.db.Pool.QueryRow(c.Context(),
@@ -153,28 +230,111 @@ func (m *BearerAuthMiddleware) Handler() fiber.Handler {
 			})
 		}
 
-		// Check admin access: isSystemAdmin flag OR SUPER_ADMIN/ADMINISTRATOR role
-		hasAdminRole := false
-		for _, r := range roles {
-			if r == "SUPER_ADMIN" || r == "ADMINISTRATOR" {
-				hasAdminRole = true
-				break
+		// Admin routes check individual permissions; isAdmin only lets
+		// ownership checks on user routes see every server
+		var granted []string
+		if !isSystemAdmin {
+			granted, err = m.db.PermissionsForRoles(c.Context(), roles)
+			if err != nil {
+				log.Error().Err(err).Str("user_id", userID).Msg("Failed to resolve role permissions")
+				return c.Status(fiber.StatusInternalServerError).JSON(ErrorResponse{
+					Success: false,
+					Error:   "Failed to resolve permissions",
+					Code:    "INTERNAL_ERROR",
+				})
 			}
 		}
 
-		if !isSystemAdmin && !hasAdminRole {
-			log.Warn().Str("user_id", userID).Strs("roles", roles).Msg("Non-admin user attempted admin access")
+		// Store user ID in context for handlers
+		c.Locals("userID", userID)
+		c.Locals("isAdmin", isSystemAdmin || auth.HasPermission(granted, auth.PermServersManage))
+		c.Locals("isSystemAdmin", isSystemAdmin)
+		c.Locals("roles", roles)
+		c.Locals("permissions", granted)
+		if impersonatedBy != "" {
+			c.Locals("impersonatedBy", impersonatedBy)
+		}
+
+		return c.Next()
+	}
+}
+
+// Optional returns a handler that authenticates the request like Handler
+// when it carries an Authorization header and lets it through anonymously
+// otherwise
+func (m *BearerAuthMiddleware) Optional() fiber.Handler {
+	authenticate := m.Handler()
+	return func(c *fiber.Ctx) error {
+		if c.Get("Authorization") == "" {
+			return c.Next()
+		}
+		return authenticate(c)
+	}
+}
+
+// RequirePermission returns a handler that rejects requests whose user lacks
+// perm. It must run after Handler, which resolves the user's permissions.
+// System admins are granted every permission.
+func (m *BearerAuthMiddleware) RequirePermission(perm string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if isSystemAdmin, _ := c.Locals("isSystemAdmin").(bool); isSystemAdmin {
+			return c.Next()
+		}
+
+		userID, _ := c.Locals("userID").(string)
+		roles, _ := c.Locals("roles").([]string)
+		granted, _ := c.Locals("permissions").([]string)
+
+		if !auth.HasPermission(granted, perm) {
+			log.Warn().Str("user_id", userID).Strs("roles", roles).Str("permission", perm).Msg("Permission denied")
 			return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
 				Success: false,
-				Error:   "Admin access required",
+				Error:   "Missing permission: " + perm,
 				Code:    "FORBIDDEN",
 			})
 		}
 
-		// Store user ID in context for handlers
-		c.Locals("userID", userID)
-		c.Locals("isAdmin", true)
-
 		return c.Next()
 	}
 }
+
+// RequireAdminAccess returns a handler that rejects users whose roles grant
+// no permissions at all, keeping customers out of the admin API before each
+// route checks its own permission. It must run after Handler.
+func (m *BearerAuthMiddleware) RequireAdminAccess() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		isSystemAdmin, _ := c.Locals("isSystemAdmin").(bool)
+		granted, _ := c.Locals("permissions").([]string)
+		if isSystemAdmin || len(granted) > 0 {
+			return c.Next()
+		}
+
+		userID, _ := c.Locals("userID").(string)
+		roles, _ := c.Locals("roles").([]string)
+		log.Warn().Str("user_id", userID).Strs("roles", roles).Msg("Non-admin user attempted admin access")
+		return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+			Success: false,
+			Error:   "Admin access required",
+			Code:    "FORBIDDEN",
+		})
+	}
+}
+
+// RequireSystemAdmin returns a handler that rejects requests from users
+// without the isSystemAdmin flag, whatever their role permissions. It must
+// run after Handler.
+func (m *BearerAuthMiddleware) RequireSystemAdmin() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if isSystemAdmin, _ := c.Locals("isSystemAdmin").(bool); isSystemAdmin {
+			return c.Next()
+		}
+
+		userID, _ := c.Locals("userID").(string)
+		log.Warn().Str("user_id", userID).Msg("System admin access denied")
+		return c.Status(fiber.StatusForbidden).JSON(ErrorResponse{
+			Success: false,
+			Error:   "System admin access required",
+			Code:    "FORBIDDEN",
+		})
+	}
+}
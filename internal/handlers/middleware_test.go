@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/nodebyte/backend/internal/auth"
+)
+
+func TestBearerAuthRejectsUnverifiedImpersonationTokens(t *testing.T) {
+	forged, _, err := auth.NewJWTService("other-secret").GenerateImpersonationToken(
+		&auth.Claims{UserID: "user-1"}, "admin-1", time.Minute)
+	if err != nil {
+		t.Fatalf("GenerateImpersonationToken: %v", err)
+	}
+
+	// A nil database makes any lookup past signature verification panic
+	m := NewBearerAuthMiddleware(nil, auth.NewJWTService("server-secret"))
+	app := fiber.New()
+	app.Get("/", m.Handler(), func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusOK) })
+
+	for name, token := range map[string]string{
+		"wrong signature": forged,
+		"unsigned":        "eyJhbGciOiJub25lIn0.eyJpZCI6InVzZXItMSIsImltcGVyc29uYXRlZEJ5IjoiYWRtaW4tMSJ9.",
+	} {
+		req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if resp.StatusCode != fiber.StatusUnauthorized {
+			t.Errorf("%s: status = %d, want %d", name, resp.StatusCode, fiber.StatusUnauthorized)
+		}
+		var body ErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatalf("%s: decode: %v", name, err)
+		}
+		if body.Error != "Invalid token" {
+			t.Errorf("%s: error = %q, want %q", name, body.Error, "Invalid token")
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/nodebyte/backend/internal/config"
+)
+
+// PageLimits are the default and largest page size a list endpoint accepts
+type PageLimits struct {
+	Default int
+	Max     int
+}
+
+// configuredPageLimits returns the list page sizes from the loaded config,
+// falling back to the built-in defaults when there is no config
+func configuredPageLimits(cfg *config.Config) PageLimits {
+	if cfg == nil {
+		return PageLimits{Default: config.DefaultPaginationLimit, Max: config.DefaultPaginationMaxLimit}
+	}
+	defaultLimit, maxLimit := cfg.PaginationLimits()
+	return PageLimits{Default: defaultLimit, Max: maxLimit}
+}
+
+// Pagination is a parsed page request
+type Pagination struct {
+	Page   int
+	Limit  int
+	Offset int
+}
+
+// PaginationMeta is the standard pagination block returned by list endpoints
+type PaginationMeta struct {
+	Page       int `json:"page"`
+	Limit      int `json:"limit"`
+	Offset     int `json:"offset"`
+	Total      int `json:"total"`
+	TotalPages int `json:"totalPages"`
+}
+
+// parsePagination reads the page size from the limit query parameter (or the
+// older pageSize and per_page names) and the position from offset or page.
+// Sizes below 1 use limits.Default and sizes above limits.Max are capped; a
+// zero limit falls back to the built-in defaults.
+func parsePagination(c *fiber.Ctx, limits PageLimits) Pagination {
+	maxLimit := limits.Max
+	if maxLimit <= 0 {
+		maxLimit = config.DefaultPaginationMaxLimit
+	}
+	defaultLimit := limits.Default
+	if defaultLimit <= 0 {
+		defaultLimit = config.DefaultPaginationLimit
+	}
+	defaultLimit = min(defaultLimit, maxLimit)
+
+	limit := defaultLimit
+	for _, name := range []string{"limit", "pageSize", "per_page"} {
+		if raw := c.Query(name); raw != "" {
+			if n, err := strconv.Atoi(raw); err == nil && n > 0 {
+				limit = min(n, maxLimit)
+			}
+			break
+		}
+	}
+
+	if raw := c.Query("offset"); raw != "" {
+		offset, _ := strconv.Atoi(raw)
+		offset = max(offset, 0)
+		return Pagination{Page: offset/limit + 1, Limit: limit, Offset: offset}
+	}
+
+	page := max(c.QueryInt("page", 1), 1)
+	return Pagination{Page: page, Limit: limit, Offset: (page - 1) * limit}
+}
+
+// Meta builds the response pagination block for a result set of total items
+func (p Pagination) Meta(total int) PaginationMeta {
+	return PaginationMeta{
+		Page:       p.Page,
+		Limit:      p.Limit,
+		Offset:     p.Offset,
+		Total:      total,
+		TotalPages: (total + p.Limit - 1) / p.Limit,
+	}
+}
+
+// pageBlock builds the pagination block the page-based admin lists returned
+// before meta, kept alongside it for existing clients
+func (m PaginationMeta) pageBlock() fiber.Map {
+	return fiber.Map{
+		"page":       m.Page,
+		"pageSize":   m.Limit,
+		"total":      m.Total,
+		"totalPages": m.TotalPages,
+	}
+}
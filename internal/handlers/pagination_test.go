@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestParsePagination(t *testing.T) {
+	limits := PageLimits{Default: 25, Max: 100}
+
+	tests := []struct {
+		name   string
+		query  string
+		limits PageLimits
+		want   Pagination
+	}{
+		{name: "defaults", query: "", limits: limits, want: Pagination{Page: 1, Limit: 25, Offset: 0}},
+		{name: "limit and page", query: "limit=10&page=3", limits: limits, want: Pagination{Page: 3, Limit: 10, Offset: 20}},
+		{name: "pageSize alias", query: "pageSize=40&page=2", limits: limits, want: Pagination{Page: 2, Limit: 40, Offset: 40}},
+		{name: "per_page alias", query: "per_page=5", limits: limits, want: Pagination{Page: 1, Limit: 5, Offset: 0}},
+		{name: "limit wins over aliases", query: "limit=10&pageSize=40", limits: limits, want: Pagination{Page: 1, Limit: 10, Offset: 0}},
+		{name: "oversized limit is capped", query: "limit=500", limits: limits, want: Pagination{Page: 1, Limit: 100, Offset: 0}},
+		{name: "zero limit uses default", query: "limit=0", limits: limits, want: Pagination{Page: 1, Limit: 25, Offset: 0}},
+		{name: "invalid limit uses default", query: "limit=abc", limits: limits, want: Pagination{Page: 1, Limit: 25, Offset: 0}},
+		{name: "offset sets the page", query: "limit=10&offset=35", limits: limits, want: Pagination{Page: 4, Limit: 10, Offset: 35}},
+		{name: "offset wins over page", query: "offset=50&page=9", limits: limits, want: Pagination{Page: 3, Limit: 25, Offset: 50}},
+		{name: "negative offset", query: "offset=-5", limits: limits, want: Pagination{Page: 1, Limit: 25, Offset: 0}},
+		{name: "page below one", query: "page=0", limits: limits, want: Pagination{Page: 1, Limit: 25, Offset: 0}},
+		{name: "endpoint limits", query: "", limits: PageLimits{Default: 12, Max: 50}, want: Pagination{Page: 1, Limit: 12, Offset: 0}},
+		{name: "endpoint max caps", query: "per_page=80", limits: PageLimits{Default: 12, Max: 50}, want: Pagination{Page: 1, Limit: 50, Offset: 0}},
+		{name: "default above max", query: "", limits: PageLimits{Default: 200, Max: 50}, want: Pagination{Page: 1, Limit: 50, Offset: 0}},
+		{name: "zero limits use built-in defaults", query: "limit=1000", limits: PageLimits{}, want: Pagination{Page: 1, Limit: 100, Offset: 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got Pagination
+			app := fiber.New()
+			app.Get("/", func(c *fiber.Ctx) error {
+				got = parsePagination(c, tt.limits)
+				return nil
+			})
+			if _, err := app.Test(httptest.NewRequest("GET", "/?"+tt.query, nil)); err != nil {
+				t.Fatalf("request: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("parsePagination(%q) = %+v, want %+v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaginationMeta(t *testing.T) {
+	meta := Pagination{Page: 2, Limit: 10, Offset: 10}.Meta(25)
+	want := PaginationMeta{Page: 2, Limit: 10, Offset: 10, Total: 25, TotalPages: 3}
+	if meta != want {
+		t.Errorf("Meta(25) = %+v, want %+v", meta, want)
+	}
+
+	block := meta.pageBlock()
+	if block["pageSize"] != 10 || block["totalPages"] != 3 || block["page"] != 2 || block["total"] != 25 {
+		t.Errorf("pageBlock() = %v", block)
+	}
+}
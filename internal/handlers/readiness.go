@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"sync/atomic"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/nodebyte/backend/internal/database"
+)
+
+// Readiness tracks whether the server should receive new traffic. It is
+// marked draining at the start of shutdown so load balancers stop routing to
+// the instance before it stops accepting connections.
+type Readiness struct {
+	draining atomic.Bool
+}
+
+// NewReadiness creates a readiness state that reports ready
+func NewReadiness() *Readiness {
+	return &Readiness{}
+}
+
+// Drain makes /ready report 503 from now on
+func (r *Readiness) Drain() {
+	r.draining.Store(true)
+}
+
+// Draining reports whether Drain has been called
+func (r *Readiness) Draining() bool {
+	return r.draining.Load()
+}
+
+// readinessCheck reports whether the instance should receive traffic
+// @Summary Readiness probe
+// @Description Returns 200 when the server can serve requests and 503 while it is shutting down (draining) or the database is unreachable. Point load balancer health checks here; /health reports service details.
+// @Tags Health
+// @Produce json
+// @Success 200 {object} object "Ready"
+// @Failure 503 {object} object "Draining or database unavailable"
+// @Router /ready [get]
+func readinessCheck(readiness *Readiness, db *database.DB) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if readiness.Draining() {
+			c.Set(fiber.HeaderConnection, "close")
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{"status": "draining"})
+		}
+		if err := db.HealthCheck(c.Context()); err != nil {
+			return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+				"status": "unavailable",
+				"error":  "database unreachable",
+			})
+		}
+		return c.JSON(fiber.Map{"status": "ready"})
+	}
+}
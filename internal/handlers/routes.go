@@ -6,14 +6,18 @@ import (
 	"github.com/gofiber/fiber/v2"
 
 	"github.com/nodebyte/backend/internal/auth"
+	"github.com/nodebyte/backend/internal/buildinfo"
+	"github.com/nodebyte/backend/internal/cache"
 	"github.com/nodebyte/backend/internal/config"
 	"github.com/nodebyte/backend/internal/database"
+	"github.com/nodebyte/backend/internal/hytale"
 	"github.com/nodebyte/backend/internal/middleware"
+	"github.com/nodebyte/backend/internal/panels"
 	"github.com/nodebyte/backend/internal/queue"
 )
 
 // SetupRoutes configures all API routes
-func SetupRoutes(app *fiber.App, db *database.DB, queueManager *queue.Manager, apiKeyMiddleware *APIKeyMiddleware, cfg *config.Config) {
+func SetupRoutes(app *fiber.App, db *database.DB, queueManager *queue.Manager, apiKeyMiddleware *APIKeyMiddleware, cfg *config.Config, responseCache *cache.Cache, apiLimiter *middleware.APIRateLimiter, hytaleEnv *hytale.Environment, readiness *Readiness) {
 	// Initialize JWT service
 	jwtSecret := os.Getenv("JWT_SECRET")
 	if jwtSecret == "" {
@@ -26,39 +30,33 @@ func SetupRoutes(app *fiber.App, db *database.DB, queueManager *queue.Manager, a
 
 	// Health check route (public - no authentication required)
 	app.Get("/health", healthCheck(db, queueManager))
+	app.Get("/ready", readinessCheck(readiness, db))
+	app.Get("/api/version", versionInfo(cfg))
 
 	// Public routes (no authentication required)
-	statsHandler := NewStatsHandler(db)
-	app.Get("/api/stats", statsHandler.GetPublicStats)
-	app.Get("/api/panel/counts", statsHandler.GetPanelCounts)
+	publicLimit := apiLimiter.Limit(config.RateLimitGroupPublic)
+	statsHandler := NewStatsHandler(db, responseCache, cfg)
+	app.Get("/api/stats", publicLimit, statsHandler.GetPublicStats)
+	app.Get("/api/panel/counts", publicLimit, statsHandler.GetPanelCounts)
 
 	// Auth routes (public - no authentication required)
-	authHandler := NewAuthHandler(db, queueManager, jwtService)
-	app.Post("/api/v1/auth/login", authHandler.AuthenticateUser)
-	app.Post("/api/v1/auth/register", authHandler.RegisterUser)
-	app.Post("/api/v1/auth/validate", authHandler.ValidateCredentials)
-	app.Post("/api/v1/auth/verify-email", authHandler.VerifyEmail)
-	app.Post("/api/v1/auth/forgot-password", authHandler.ForgotPassword)
-	app.Post("/api/v1/auth/reset-password", authHandler.ResetPassword)
-	app.Post("/api/v1/auth/magic-link", authHandler.RequestMagicLink)
-	app.Post("/api/v1/auth/magic-link/verify", authHandler.VerifyMagicLink)
-	app.Post("/api/v1/auth/refresh", authHandler.RefreshToken)
-	app.Post("/api/v1/auth/logout", authHandler.Logout)
-	app.Get("/api/v1/auth/me", authHandler.GetCurrentUser)
-	app.Get("/api/v1/auth/check-email", authHandler.CheckEmailExists)
-	app.Get("/api/v1/auth/users/:id", authHandler.GetUserByID)
+	authHandler := NewAuthHandler(db, queueManager, jwtService, cfg)
+	registerAuthRoutes(app, authHandler, apiLimiter.Limit(config.RateLimitGroupAuth), apiKeyMiddleware.Handler())
+
+	bearerAuth := NewBearerAuthMiddleware(db, jwtService)
 
 	// Hytale OAuth routes (public - no authentication required)
 	// Apply rate limiting to OAuth endpoints
-	hytaleOAuthHandler := NewHytaleOAuthHandler(db, cfg.HytaleUseStaging)
+	hytaleOAuthHandler := NewHytaleOAuthHandler(db, hytaleEnv, responseCache, cfg)
 
 	deviceCodeLimiter := middleware.NewRateLimiter(middleware.DeviceCodeRateLimit)
 	tokenPollLimiter := middleware.NewRateLimiter(middleware.TokenPollRateLimit)
 	tokenRefreshLimiter := middleware.NewRateLimiter(middleware.TokenRefreshRateLimit)
 	gameSessionLimiter := middleware.NewRateLimiter(middleware.GameSessionRateLimit)
 
+	app.Get("/api/v1/hytale/status", hytaleOAuthHandler.GetStatus)
 	app.Post("/api/v1/hytale/oauth/device-code", deviceCodeLimiter.Middleware(), hytaleOAuthHandler.RequestDeviceCode)
-	app.Post("/api/v1/hytale/oauth/token", tokenPollLimiter.Middleware(), hytaleOAuthHandler.PollToken)
+	app.Post("/api/v1/hytale/oauth/token", tokenPollLimiter.Middleware(), bearerAuth.Optional(), hytaleOAuthHandler.PollToken)
 	app.Post("/api/v1/hytale/oauth/refresh", tokenRefreshLimiter.Middleware(), hytaleOAuthHandler.RefreshAccessToken)
 	app.Post("/api/v1/hytale/oauth/profiles", gameSessionLimiter.Middleware(), hytaleOAuthHandler.GetProfiles)
 	app.Post("/api/v1/hytale/oauth/select-profile", gameSessionLimiter.Middleware(), hytaleOAuthHandler.SelectProfile)
@@ -67,12 +65,13 @@ func SetupRoutes(app *fiber.App, db *database.DB, queueManager *queue.Manager, a
 	app.Post("/api/v1/hytale/oauth/game-session/delete", gameSessionLimiter.Middleware(), hytaleOAuthHandler.TerminateGameSession)
 
 	hytaleLogsHandler := NewHytaleLogsHandler(db)
-	app.Get("/api/v1/hytale/logs", hytaleLogsHandler.GetHytaleLogs)
+	app.Get("/api/v1/hytale/logs", publicLimit, hytaleLogsHandler.GetHytaleLogs)
 
 	hytaleServerLogsHandler := NewHytaleServerLogsHandler(db)
-	app.Get("/api/v1/hytale/server-logs", hytaleServerLogsHandler.GetHytaleServerLogs)
-	app.Post("/api/v1/hytale/server-logs", hytaleServerLogsHandler.CreateServerLogs)
-	app.Get("/api/v1/hytale/server-logs/count", hytaleServerLogsHandler.GetHytaleServerLogsCount)
+	app.Get("/api/v1/hytale/server-logs", publicLimit, hytaleServerLogsHandler.GetHytaleServerLogs)
+	app.Post("/api/v1/hytale/server-logs", publicLimit, hytaleServerLogsHandler.CreateServerLogs)
+	app.Get("/api/v1/hytale/server-logs/count", publicLimit, hytaleServerLogsHandler.GetHytaleServerLogsCount)
+	app.Post("/api/v1/hytale/servers/:serverId/logs", apiKeyMiddleware.Handler(), hytaleServerLogsHandler.IngestServerLogs)
 
 	// SSE sync stream — MUST be registered before adminGroup is created.
 	// app.Group("/api/admin", mw) registers mw as a prefix-level Use() handler that
@@ -83,80 +82,181 @@ func SetupRoutes(app *fiber.App, db *database.DB, queueManager *queue.Manager, a
 	app.Get("/api/admin/sync/stream/:id", syncStreamHandler.StreamSyncProgress)
 
 	// Admin settings routes (require bearer token auth) - MUST BE BEFORE /api group
-	bearerAuth := NewBearerAuthMiddleware(db)
-	adminGroup := app.Group("/api/admin", bearerAuth.Handler())
+	adminGroup := app.Group("/api/admin", bearerAuth.Handler(), bearerAuth.RequireAdminAccess())
+	requirePermission := bearerAuth.RequirePermission
 
 	// Settings routes
-	settingsHandler := NewAdminSettingsHandler(db)
-	adminGroup.Get("/settings", settingsHandler.GetAdminSettings)
-	adminGroup.Post("/settings", settingsHandler.SaveAdminSettings)
-	adminGroup.Put("/settings", settingsHandler.ResetAdminSettings)
-	adminGroup.Post("/settings/test", settingsHandler.TestConnection)
+	settingsHandler := NewAdminSettingsHandler(db, hytaleEnv, cfg)
+	adminGroup.Get("/settings", requirePermission(auth.PermSettingsRead), settingsHandler.GetAdminSettings)
+	adminConfigHandler := NewAdminConfigHandler(cfg, db)
+	adminGroup.Get("/config/effective", bearerAuth.RequireSystemAdmin(), adminConfigHandler.GetEffectiveConfig)
+	adminGroup.Get("/log-level", bearerAuth.RequireSystemAdmin(), adminConfigHandler.GetLogLevel)
+	adminGroup.Post("/log-level", bearerAuth.RequireSystemAdmin(), adminConfigHandler.SetLogLevel)
+	adminGroup.Post("/settings", requirePermission(auth.PermSettingsWrite), settingsHandler.SaveAdminSettings)
+	adminGroup.Put("/settings", requirePermission(auth.PermSettingsWrite), settingsHandler.ResetAdminSettings)
+	adminGroup.Post("/settings/test", requirePermission(auth.PermSettingsWrite), settingsHandler.TestConnection)
 
 	// GitHub repositories routes
-	adminGroup.Get("/settings/repos", settingsHandler.GetRepositories)
-	adminGroup.Post("/settings/repos", settingsHandler.AddRepository)
-	adminGroup.Put("/settings/repos", settingsHandler.UpdateRepository)
-	adminGroup.Delete("/settings/repos", settingsHandler.DeleteRepository)
+	adminGroup.Get("/settings/repos", requirePermission(auth.PermSettingsRead), settingsHandler.GetRepositories)
+	adminGroup.Post("/settings/repos", requirePermission(auth.PermSettingsWrite), settingsHandler.AddRepository)
+	adminGroup.Put("/settings/repos", requirePermission(auth.PermSettingsWrite), settingsHandler.UpdateRepository)
+	adminGroup.Delete("/settings/repos", requirePermission(auth.PermSettingsWrite), settingsHandler.DeleteRepository)
 
 	// Webhooks routes
-	webhooksHandler := NewAdminWebhooksHandler(db)
-	adminGroup.Get("/settings/webhooks", webhooksHandler.GetWebhooks)
-	adminGroup.Post("/settings/webhooks", webhooksHandler.CreateWebhook)
-	adminGroup.Put("/settings/webhooks", webhooksHandler.UpdateWebhook)
-	adminGroup.Patch("/settings/webhooks", webhooksHandler.TestWebhook)
-	adminGroup.Delete("/settings/webhooks", webhooksHandler.DeleteWebhook)
+	webhooksHandler := NewAdminWebhooksHandler(db, cfg)
+	adminGroup.Get("/settings/webhooks", requirePermission(auth.PermWebhooksManage), webhooksHandler.GetWebhooks)
+	adminGroup.Post("/settings/webhooks", requirePermission(auth.PermWebhooksManage), webhooksHandler.CreateWebhook)
+	adminGroup.Put("/settings/webhooks", requirePermission(auth.PermWebhooksManage), webhooksHandler.UpdateWebhook)
+	adminGroup.Patch("/settings/webhooks", requirePermission(auth.PermWebhooksManage), webhooksHandler.TestWebhook)
+	adminGroup.Delete("/settings/webhooks", requirePermission(auth.PermWebhooksManage), webhooksHandler.DeleteWebhook)
+
+	var nodePteroClient *panels.PterodactylClient
+	if cfg.PterodactylURL != "" && cfg.PterodactylAPIKey != "" {
+		nodePteroClient = panels.NewPterodactylClient(cfg.PterodactylURL, cfg.PterodactylAPIKey, cfg.CFAccessClientID, cfg.CFAccessClientSecret)
+		nodePteroClient.SetConnectionPool(cfg.PanelConnectionPool())
+	}
 
 	// Admin user management routes
-	adminUserHandler := NewAdminUserHandler(db)
-	adminGroup.Get("/users", adminUserHandler.GetUsers)
-	adminGroup.Post("/users/roles", adminUserHandler.UpdateUserRoles)
+	adminUserHandler := NewAdminUserHandler(db, jwtService, queueManager, cfg)
+	adminPlanHandler := NewAdminPlanHandler(db)
+	adminGroup.Get("/users", requirePermission(auth.PermUsersRead), adminUserHandler.GetUsers)
+	adminGroup.Post("/users/roles", requirePermission(auth.PermUsersManage), adminUserHandler.UpdateUserRoles)
+	adminGroup.Post("/users/plan", requirePermission(auth.PermUsersManage), adminPlanHandler.SetUserPlan)
+	adminGroup.Post("/users/import", requirePermission(auth.PermUsersManage), NewAdminUserImportHandler(db, queueManager, cfg).ImportUsers)
+	adminGroup.Get("/users/sync-conflicts", requirePermission(auth.PermUsersRead), adminUserHandler.GetUserSyncConflicts)
+	adminGroup.Post("/users/sync-conflicts/:id/resolve", requirePermission(auth.PermUsersManage), adminUserHandler.ResolveUserSyncConflict)
+	adminGroup.Get("/users/:id/overview", requirePermission(auth.PermUsersRead), adminUserHandler.GetUserOverview)
+	adminGroup.Post("/users/:id/impersonate", requirePermission(auth.PermUsersImpersonate), adminUserHandler.ImpersonateUser)
+	adminGroup.Post("/users/:id/resync", requirePermission(auth.PermSyncTrigger), adminUserHandler.ResyncUser)
+	adminGroup.Post("/users/:id/migrate", requirePermission(auth.PermUsersManage), adminUserHandler.MarkUserMigrated)
+
+	// Admin role catalog routes
+	adminRoleHandler := NewAdminRoleHandler(db)
+	adminGroup.Get("/roles", requirePermission(auth.PermUsersRead), adminRoleHandler.GetRoles)
+	adminGroup.Post("/roles", requirePermission(auth.PermRolesManage), adminRoleHandler.CreateRole)
+	adminGroup.Put("/roles/:name", requirePermission(auth.PermRolesManage), adminRoleHandler.UpdateRole)
+	adminGroup.Delete("/roles/:name", requirePermission(auth.PermRolesManage), adminRoleHandler.DeleteRole)
+
+	// Admin registration invite routes
+	adminInviteHandler := NewAdminInviteHandler(db)
+	adminGroup.Get("/invites", requirePermission(auth.PermUsersRead), adminInviteHandler.GetInvites)
+	adminGroup.Post("/invites", requirePermission(auth.PermUsersManage), adminInviteHandler.CreateInvite)
+	adminGroup.Delete("/invites/:id", requirePermission(auth.PermUsersManage), adminInviteHandler.RevokeInvite)
+
+	// Admin plan catalog routes
+	adminGroup.Get("/plans", requirePermission(auth.PermUsersRead), adminPlanHandler.GetPlans)
+	adminGroup.Post("/plans", requirePermission(auth.PermSettingsWrite), adminPlanHandler.CreatePlan)
+
+	// Admin backend API key routes
+	adminAPIKeyHandler := NewAdminAPIKeyHandler(db)
+	adminGroup.Get("/api-keys", requirePermission(auth.PermSettingsRead), adminAPIKeyHandler.GetAPIKeys)
+	adminGroup.Post("/api-keys", requirePermission(auth.PermSettingsWrite), adminAPIKeyHandler.CreateAPIKey)
+	adminGroup.Delete("/api-keys/:id", requirePermission(auth.PermSettingsWrite), adminAPIKeyHandler.RevokeAPIKey)
 
 	// Admin server management routes
-	adminServerHandler := NewAdminServerHandler(db)
-	adminGroup.Get("/servers", adminServerHandler.GetServers)
+	adminServerHandler := NewAdminServerHandler(db, nodePteroClient, cfg)
+	adminGroup.Get("/servers", requirePermission(auth.PermServersRead), adminServerHandler.GetServers)
+	adminGroup.Post("/servers/:id/resync", requirePermission(auth.PermSyncTrigger), adminServerHandler.ResyncServer)
+	adminGroup.Get("/servers/:id/notes", requirePermission(auth.PermServersRead), adminServerHandler.GetServerNotes)
+	adminGroup.Put("/servers/:id/notes", requirePermission(auth.PermServersManage), adminServerHandler.UpdateServerNotes)
+	adminGroup.Post("/servers/suspend", requirePermission(auth.PermServersManage), adminServerHandler.SuspendServers)
+	adminGroup.Post("/servers/unsuspend", requirePermission(auth.PermServersManage), adminServerHandler.UnsuspendServers)
 
 	// Admin node/location routes
-	nodeHandler := NewAdminNodeHandler(db)
-	adminGroup.Get("/nodes", nodeHandler.GetNodes)
-	adminGroup.Get("/nodes/:id/allocations", nodeHandler.GetNodeAllocations)
-	adminGroup.Patch("/nodes/:id/maintenance", nodeHandler.ToggleNodeMaintenance)
-	adminGroup.Get("/locations", nodeHandler.GetLocations)
-	adminGroup.Get("/allocations", nodeHandler.GetAllAllocations)
+	nodeHandler := NewAdminNodeHandler(db, nodePteroClient, queueManager, cfg)
+	adminGroup.Get("/nodes", requirePermission(auth.PermNodesRead), nodeHandler.GetNodes)
+	adminGroup.Get("/nodes/:id/allocations", requirePermission(auth.PermNodesRead), nodeHandler.GetNodeAllocations)
+	adminGroup.Post("/nodes/:id/allocations/bulk", requirePermission(auth.PermNodesManage), nodeHandler.BulkCreateAllocations)
+	adminGroup.Post("/nodes/:id/maintenance", requirePermission(auth.PermNodesManage), nodeHandler.ToggleNodeMaintenance)
+	adminGroup.Patch("/nodes/:id/maintenance", requirePermission(auth.PermNodesManage), nodeHandler.ToggleNodeMaintenance)
+	adminGroup.Get("/locations", requirePermission(auth.PermNodesRead), nodeHandler.GetLocations)
+	adminGroup.Get("/allocations", requirePermission(auth.PermNodesRead), nodeHandler.GetAllAllocations)
 
 	// Admin egg/nest routes
-	eggHandler := NewAdminEggHandler(db)
-	adminGroup.Get("/nests", eggHandler.GetNests)
-	adminGroup.Get("/eggs", eggHandler.GetEggs)
+	eggHandler := NewAdminEggHandler(db, cfg)
+	adminGroup.Get("/nests", requirePermission(auth.PermEggsRead), eggHandler.GetNests)
+	adminGroup.Get("/eggs", requirePermission(auth.PermEggsRead), eggHandler.GetEggs)
+	adminGroup.Get("/eggs/:id", requirePermission(auth.PermEggsRead), eggHandler.GetEgg)
+	adminGroup.Get("/eggs/:id/variables", requirePermission(auth.PermEggsRead), eggHandler.GetEggVariables)
+	adminGroup.Patch("/eggs/:id/variables/:varId", requirePermission(auth.PermEggsManage), eggHandler.UpdateEggVariable)
+
+	// Admin Hytale routes
+	adminGroup.Get("/queues/dead-letter", requirePermission(auth.PermSettingsRead), NewAdminQueueHandler(queueManager).GetDeadLetterTasks)
+	adminGroup.Post("/email/preview", requirePermission(auth.PermSettingsRead), NewAdminEmailHandler().PreviewEmail)
+	adminGroup.Get("/hytale/accounts", requirePermission(auth.PermHytaleRead), NewAdminHytaleAccountsHandler(db).GetHytaleAccounts)
+	adminGroup.Get("/hytale/audit", requirePermission(auth.PermHytaleRead), NewAdminHytaleAuditHandler(db).GetHytaleAuditLogs)
+	adminGroup.Get("/hytale/servers/:serverId/logs", requirePermission(auth.PermHytaleRead), NewAdminHytaleServerLogsHandler(db).GetServerLogs)
+	adminGroup.Post("/hytale/push-all", requirePermission(auth.PermHytaleManage), NewAdminHytalePushHandler(queueManager, cfg).PushAllTokens)
 
 	// Admin sync routes
-	adminSyncHandler := NewAdminSyncHandler(db, queueManager)
-	adminGroup.Get("/sync", adminSyncHandler.GetSyncStatusAdmin)
-	adminGroup.Post("/sync", adminSyncHandler.TriggerSyncAdmin)
-	adminGroup.Post("/sync/cancel", adminSyncHandler.CancelSyncAdmin)
-	adminGroup.Get("/sync/logs", adminSyncHandler.GetSyncLogs)
-	adminGroup.Get("/sync/settings", adminSyncHandler.GetSyncSettingsAdmin)
-	adminGroup.Post("/sync/settings", adminSyncHandler.UpdateSyncSettingsAdmin)
+	adminSyncHandler := NewAdminSyncHandler(db, queueManager, cfg)
+	adminGroup.Get("/sync", requirePermission(auth.PermSyncRead), adminSyncHandler.GetSyncStatusAdmin)
+	adminGroup.Post("/sync", requirePermission(auth.PermSyncTrigger), adminSyncHandler.TriggerSyncAdmin)
+	adminGroup.Post("/sync/batch", requirePermission(auth.PermSyncTrigger), adminSyncHandler.TriggerSyncBatchAdmin)
+	adminGroup.Post("/sync/cancel", requirePermission(auth.PermSyncManage), adminSyncHandler.CancelSyncAdmin)
+	adminGroup.Get("/sync/lock", requirePermission(auth.PermSyncRead), adminSyncHandler.GetSyncLockAdmin)
+	adminGroup.Get("/sync/compare", requirePermission(auth.PermSyncRead), adminSyncHandler.CompareSyncsAdmin)
+	adminGroup.Post("/sync/lock/release", requirePermission(auth.PermSyncManage), adminSyncHandler.ReleaseSyncLockAdmin)
+	adminGroup.Post("/sync/:id/resume", requirePermission(auth.PermSyncTrigger), adminSyncHandler.ResumeSyncAdmin)
+	adminGroup.Post("/sync/:id/notify", requirePermission(auth.PermSyncManage), adminSyncHandler.NotifySyncAdmin)
+	adminGroup.Get("/sync/:id/changes", requirePermission(auth.PermSyncRead), adminSyncHandler.GetSyncChangesAdmin)
+	adminGroup.Get("/sync/logs", requirePermission(auth.PermSyncRead), adminSyncHandler.GetSyncLogs)
+	adminGroup.Get("/sync/logs/export", requirePermission(auth.PermSyncRead), adminSyncHandler.ExportSyncLogs)
+	adminGroup.Get("/sync/logs/:id", requirePermission(auth.PermSyncRead), adminSyncHandler.GetSyncLogAdmin)
+	adminGroup.Get("/sync/settings", requirePermission(auth.PermSyncRead), adminSyncHandler.GetSyncSettingsAdmin)
+	adminGroup.Post("/sync/settings", requirePermission(auth.PermSyncManage), adminSyncHandler.UpdateSyncSettingsAdmin)
 
 	// Admin stats routes (already exist)
-	adminGroup.Get("/stats", statsHandler.GetAdminStats)
+	adminGroup.Get("/stats", requirePermission(auth.PermStatsRead), statsHandler.GetAdminStats)
 
 	// Bearer-authenticated user routes (dashboard)
-	userRoutes := app.Group("/api/v1", bearerAuth.Handler())
-	dashboardHandler := NewDashboardHandler(db, queueManager)
+	userRoutes := app.Group("/api/v1", bearerAuth.Handler(), apiLimiter.Limit(config.RateLimitGroupDashboard))
+	dashboardPteroClient := panels.NewPterodactylClientWithClientKey(
+		cfg.PterodactylURL,
+		cfg.PterodactylAPIKey,
+		cfg.PterodactylClientAPIKey,
+		cfg.CFAccessClientID,
+		cfg.CFAccessClientSecret,
+	)
+	dashboardPteroClient.SetConnectionPool(cfg.PanelConnectionPool())
+	dashboardHandler := NewDashboardHandler(db, queueManager, dashboardPteroClient, responseCache, cfg)
 	userRoutes.Get("/dashboard/stats", dashboardHandler.GetDashboardStats)
 	userRoutes.Get("/dashboard/servers", dashboardHandler.GetUserServers)
+	userRoutes.Post("/dashboard/servers/status", dashboardHandler.GetServerStatuses)
+	userRoutes.Get("/dashboard/usage", dashboardHandler.GetResourceUsage)
+	userRoutes.Get("/dashboard/plan", dashboardHandler.GetPlan)
+	userRoutes.Get("/dashboard/servers/:id/activity", dashboardHandler.GetServerActivity)
+	userRoutes.Post("/dashboard/servers/:id/reinstall", dashboardHandler.ReinstallServer)
+	userRoutes.Get("/dashboard/servers/:id/startup", dashboardHandler.GetServerStartup)
+	userRoutes.Get("/dashboard/servers/:id/uptime", dashboardHandler.GetServerUptime)
+	userRoutes.Get("/dashboard/servers/:id/resources", dashboardHandler.GetServerResources)
+	userRoutes.Get("/dashboard/servers/:id/files", dashboardHandler.ListServerFiles)
+	userRoutes.Get("/dashboard/servers/:id/files/contents", dashboardHandler.GetServerFileContents)
+	userRoutes.Get("/dashboard/servers/:id/files/download", dashboardHandler.GetServerFileDownload)
+	userRoutes.Get("/dashboard/servers/:id/tags", dashboardHandler.GetServerTags)
+	userRoutes.Post("/dashboard/servers/:id/tags", dashboardHandler.AddServerTag)
+	userRoutes.Delete("/dashboard/servers/:id/tags/:tag", dashboardHandler.RemoveServerTag)
 	userRoutes.Get("/dashboard/account", dashboardHandler.GetUserAccount)
 	userRoutes.Put("/dashboard/account", dashboardHandler.UpdateUserAccount)
 	userRoutes.Put("/dashboard/account/password", dashboardHandler.ChangePassword)
 	userRoutes.Post("/dashboard/account/resend-verification", dashboardHandler.ResendVerificationEmail)
 	userRoutes.Post("/dashboard/account/change-email", dashboardHandler.RequestEmailChange)
+	userRoutes.Get("/dashboard/account/notifications", dashboardHandler.GetNotificationPreferences)
+	userRoutes.Put("/dashboard/account/notifications", dashboardHandler.UpdateNotificationPreferences)
+	userRoutes.Get("/dashboard/notifications", dashboardHandler.GetNotifications)
+	userRoutes.Get("/dashboard/notifications/count", dashboardHandler.GetUnreadNotificationCount)
+	userRoutes.Post("/dashboard/notifications/read", dashboardHandler.MarkNotificationsRead)
+
+	hytaleServerLinkHandler := NewHytaleServerLinkHandler(db, dashboardPteroClient)
+	userRoutes.Post("/hytale/servers/:serverId/link", hytaleServerLinkHandler.LinkServer)
+	userRoutes.Delete("/hytale/servers/:serverId/link", hytaleServerLinkHandler.UnlinkServer)
+	userRoutes.Post("/hytale/servers/:serverId/push-tokens", hytaleServerLinkHandler.PushTokens)
 
 	// Protected routes (require API key or bearer token) - AFTER admin routes
 	protected := app.Group("/api", apiKeyMiddleware.Handler())
 
 	// Sync routes
-	syncHandler := NewSyncAPIHandler(db, queueManager)
+	syncHandler := NewSyncAPIHandler(db, queueManager, cfg)
 	protected.Post("/v1/sync/full", syncHandler.TriggerFullSync)
 	protected.Post("/v1/sync/locations", syncHandler.TriggerLocationsSync)
 	protected.Post("/v1/sync/nodes", syncHandler.TriggerNodesSync)
@@ -178,7 +278,7 @@ func SetupRoutes(app *fiber.App, db *database.DB, queueManager *queue.Manager, a
 	protected.Post("/v1/email/queue", emailHandler.QueueEmail)
 
 	// Webhook routes
-	webhookHandler := NewWebhookAPIHandler(db, queueManager)
+	webhookHandler := NewWebhookAPIHandler(db, queueManager, responseCache, cfg)
 	protected.Post("/v1/webhook/dispatch", webhookHandler.DispatchWebhook)
 
 	// Queue routes
@@ -276,6 +376,29 @@ func SetupRoutes(app *fiber.App, db *database.DB, queueManager *queue.Manager, a
 	})
 }
 
+// registerAuthRoutes mounts the /api/v1/auth routes. The auth rate limit only
+// guards the credential routes; token-authenticated routes are left to their
+// own checks, and verify-api-key runs the limit after the key is resolved so
+// API key callers are exempted like on every other group.
+func registerAuthRoutes(app fiber.Router, h *AuthHandler, authLimit, apiKeyAuth fiber.Handler) {
+	app.Post("/api/v1/auth/login", authLimit, h.AuthenticateUser)
+	app.Post("/api/v1/auth/register", authLimit, h.RegisterUser)
+	app.Post("/api/v1/auth/validate", authLimit, h.ValidateCredentials)
+	app.Post("/api/v1/auth/verify-email", authLimit, h.VerifyEmail)
+	app.Post("/api/v1/auth/confirm-email-change", authLimit, h.ConfirmEmailChange)
+	app.Post("/api/v1/auth/forgot-password", authLimit, h.ForgotPassword)
+	app.Post("/api/v1/auth/reset-password", authLimit, h.ResetPassword)
+	app.Post("/api/v1/auth/magic-link", authLimit, h.RequestMagicLink)
+	app.Post("/api/v1/auth/magic-link/verify", authLimit, h.VerifyMagicLink)
+	app.Get("/api/v1/auth/check-email", authLimit, h.CheckEmailExists)
+	app.Post("/api/v1/auth/refresh", h.RefreshToken)
+	app.Post("/api/v1/auth/logout", h.Logout)
+	app.Post("/api/v1/auth/impersonation/end", h.EndImpersonation)
+	app.Get("/api/v1/auth/me", h.GetCurrentUser)
+	app.Get("/api/v1/auth/users/:id", h.GetUserByID)
+	app.Get("/api/v1/auth/verify-api-key", apiKeyAuth, authLimit, VerifyAPIKey)
+}
+
 // healthCheck returns a health check handler with worker monitoring
 func healthCheck(db *database.DB, queueManager *queue.Manager) fiber.Handler {
 	return func(c *fiber.Ctx) error {
@@ -322,7 +445,7 @@ func healthCheck(db *database.DB, queueManager *queue.Manager) fiber.Handler {
 				"database":  map[string]interface{}{"status": "disconnected", "error": errorMsg},
 				"workers":   queueStats,
 				"service":   "nodebyte-backend",
-				"version":   "1.0.0",
+				"version":   buildinfo.Version,
 				"timestamp": c.Get("Date"),
 				"checks":    checks,
 			})
@@ -331,10 +454,10 @@ func healthCheck(db *database.DB, queueManager *queue.Manager) fiber.Handler {
 		// All services healthy
 		return c.JSON(fiber.Map{
 			"status":    "healthy",
-			"database":  fiber.Map{"status": "connected"},
+			"database":  fiber.Map{"status": "connected", "slowQueries": database.SlowQueryCount()},
 			"workers":   queueStats,
 			"service":   "nodebyte-backend",
-			"version":   "1.0.0",
+			"version":   buildinfo.Version,
 			"timestamp": c.Get("Date"),
 			"checks": fiber.Map{
 				"database": "ok",
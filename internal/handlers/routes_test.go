@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestRegisterAuthRoutesLimitsCredentialRoutes(t *testing.T) {
+	limited := map[string]string{}
+	authLimit := func(c *fiber.Ctx) error {
+		label, _ := c.Locals("apiKeyLabel").(string)
+		limited[c.Method()+" "+c.Path()] = label
+		return c.SendStatus(fiber.StatusTooManyRequests)
+	}
+	apiKeyAuth := func(c *fiber.Ctx) error {
+		c.Locals("apiKeyLabel", "frontend")
+		return c.Next()
+	}
+
+	app := fiber.New()
+	registerAuthRoutes(app, &AuthHandler{}, authLimit, apiKeyAuth)
+
+	tests := []struct {
+		method  string
+		path    string
+		limited bool
+	}{
+		{fiber.MethodPost, "/api/v1/auth/login", true},
+		{fiber.MethodPost, "/api/v1/auth/register", true},
+		{fiber.MethodPost, "/api/v1/auth/validate", true},
+		{fiber.MethodPost, "/api/v1/auth/verify-email", true},
+		{fiber.MethodPost, "/api/v1/auth/confirm-email-change", true},
+		{fiber.MethodPost, "/api/v1/auth/forgot-password", true},
+		{fiber.MethodPost, "/api/v1/auth/reset-password", true},
+		{fiber.MethodPost, "/api/v1/auth/magic-link", true},
+		{fiber.MethodPost, "/api/v1/auth/magic-link/verify", true},
+		{fiber.MethodGet, "/api/v1/auth/check-email", true},
+		{fiber.MethodGet, "/api/v1/auth/verify-api-key", true},
+		{fiber.MethodGet, "/api/v1/auth/me", false},
+	}
+
+	for _, tt := range tests {
+		resp, err := app.Test(httptest.NewRequest(tt.method, tt.path, nil))
+		if err != nil {
+			t.Fatalf("%s %s: %v", tt.method, tt.path, err)
+		}
+		if got := resp.StatusCode == fiber.StatusTooManyRequests; got != tt.limited {
+			t.Errorf("%s %s limited = %v, want %v", tt.method, tt.path, got, tt.limited)
+		}
+	}
+
+	if label := limited["GET /api/v1/auth/verify-api-key"]; label != "frontend" {
+		t.Errorf("verify-api-key limit ran before API key auth, saw label %q", label)
+	}
+}
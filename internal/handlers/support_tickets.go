@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/nodebyte/backend/internal/database"
+	"github.com/nodebyte/backend/internal/queue"
+)
+
+// Support ticket events sent to POST /api/v1/webhook/dispatch. data.ticketId
+// names the ticket and, for replies, data.replyId names the reply.
+const (
+	EventTicketCreated = "support.ticket_created"
+	EventTicketReply   = "ticket.reply"
+)
+
+// isTicketEvent reports whether event is about a support ticket
+func isTicketEvent(event string) bool {
+	return event == EventTicketCreated || event == EventTicketReply
+}
+
+// handleTicketEvent adds the ticket's details and staff link to the event
+// data for the support channel, then emails the other party. New tickets and
+// customer replies go to the assignee, or the support inbox while unassigned;
+// staff replies go to the ticket owner, who also gets an in-app notification.
+// Failures are only logged.
+func (h *WebhookAPIHandler) handleTicketEvent(ctx context.Context, req DispatchWebhookRequest) {
+	ticketID, _ := req.Data["ticketId"].(string)
+	if ticketID == "" {
+		h.notifyLegacyTicketReply(ctx, req)
+		return
+	}
+
+	ticket, err := h.db.GetSupportTicketParties(ctx, ticketID)
+	if err != nil {
+		log.Error().Err(err).Str("ticket_id", ticketID).Msg("Failed to fetch support ticket")
+		return
+	}
+	if ticket == nil {
+		log.Warn().Str("ticket_id", ticketID).Str("event", req.Event).Msg("Ticket event for unknown ticket; skipping notifications")
+		return
+	}
+
+	req.Data["ticketNumber"] = ticket.TicketNumber
+	req.Data["subject"] = ticket.Title
+	req.Data["user"] = ticket.Owner.Email
+	req.Data["ticketUrl"] = h.cfg.TicketURL(ticket.ID, true)
+	if ticket.Priority != "" {
+		req.Data["priority"] = ticket.Priority
+	}
+
+	if req.Event == EventTicketCreated {
+		h.emailTicketStaff(ticket, "ticket-created", "New support ticket")
+		return
+	}
+
+	// Without a reply ID the event is a staff reply, as it was before replies
+	// were identified
+	fromOwner := false
+	if replyID, _ := req.Data["replyId"].(string); replyID != "" {
+		reply, err := h.db.GetSupportTicketReply(ctx, ticket.ID, replyID)
+		if err != nil {
+			log.Error().Err(err).Str("reply_id", replyID).Msg("Failed to fetch ticket reply")
+			return
+		}
+		if reply == nil {
+			log.Warn().Str("reply_id", replyID).Str("ticket_id", ticket.ID).Msg("Ticket reply not found; skipping notifications")
+			return
+		}
+		if reply.IsInternal {
+			return
+		}
+		fromOwner = reply.UserID == ticket.Owner.UserID
+	}
+
+	if fromOwner {
+		h.emailTicketStaff(ticket, "ticket-reply", "New reply on a support ticket")
+		if ticket.Assignee != nil {
+			h.notifyTicketReply(ctx, ticket.Assignee.UserID, ticket.Title)
+		}
+		return
+	}
+	h.emailTicket(ticket.Owner.Email, ticket.Owner.Name, ticket, false, "ticket-reply", "New reply on your support ticket")
+	h.notifyTicketReply(ctx, ticket.Owner.UserID, ticket.Title)
+}
+
+// emailTicketStaff emails the ticket's assignee, or the support inbox if the
+// ticket is unassigned. Nothing is sent when neither exists.
+func (h *WebhookAPIHandler) emailTicketStaff(ticket *database.SupportTicketParties, template, subject string) {
+	if ticket.Assignee != nil {
+		h.emailTicket(ticket.Assignee.Email, ticket.Assignee.Name, ticket, true, template, subject)
+		return
+	}
+	if h.cfg.SupportEmail == "" {
+		log.Debug().Str("ticket_id", ticket.ID).Msg("Ticket is unassigned and SUPPORT_EMAIL is not set; no staff email sent")
+		return
+	}
+	h.emailTicket(h.cfg.SupportEmail, "Support team", ticket, true, template, subject)
+}
+
+// emailTicket queues a ticket email to one recipient, linking to the staff or
+// owner view of the ticket. The email worker applies notification preferences.
+func (h *WebhookAPIHandler) emailTicket(to, name string, ticket *database.SupportTicketParties, staff bool, template, subject string) {
+	if h.queueManager == nil {
+		return
+	}
+	if name == "" {
+		name = "there"
+	}
+	if _, err := h.queueManager.EnqueueEmail(queue.EmailPayload{
+		To:       to,
+		Subject:  fmt.Sprintf("[#%s] %s", ticket.TicketNumber, subject),
+		Template: template,
+		Data: map[string]string{
+			"name":         name,
+			"ticketNumber": ticket.TicketNumber,
+			"subject":      ticket.Title,
+			"ticketUrl":    h.cfg.TicketURL(ticket.ID, staff),
+		},
+	}); err != nil {
+		log.Error().Err(err).Str("ticket_id", ticket.ID).Str("template", template).Msg("Failed to queue ticket email")
+	}
+}
+
+// notifyTicketReply writes the in-app notification for a ticket reply.
+// Failures are only logged.
+func (h *WebhookAPIHandler) notifyTicketReply(ctx context.Context, userID, subject string) {
+	body := "One of your support tickets has a new reply."
+	if subject != "" {
+		body = fmt.Sprintf("Your support ticket %q has a new reply.", subject)
+	}
+	if _, err := h.db.NotifyUsers(ctx, []string{userID}, database.NewNotification{
+		Category: database.NotificationSupport,
+		Type:     database.NotificationTicketReply,
+		Title:    "New ticket reply",
+		Body:     body,
+	}); err != nil {
+		log.Error().Err(err).Str("user_id", userID).Msg("Failed to write ticket reply notification")
+	}
+}
+
+// notifyLegacyTicketReply handles ticket.reply events that carry the owner's
+// userId and subject instead of a ticket ID; they only notify in-app
+func (h *WebhookAPIHandler) notifyLegacyTicketReply(ctx context.Context, req DispatchWebhookRequest) {
+	if req.Event != EventTicketReply {
+		return
+	}
+	userID, _ := req.Data["userId"].(string)
+	if userID == "" {
+		log.Warn().Str("event", req.Event).Msg("Event has no ticketId or userId; skipping notification")
+		return
+	}
+	subject, _ := req.Data["subject"].(string)
+	h.notifyTicketReply(ctx, userID, subject)
+}
@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"runtime"
+
+	"github.com/gofiber/fiber/v2"
+
+	"github.com/nodebyte/backend/internal/buildinfo"
+	"github.com/nodebyte/backend/internal/config"
+	"github.com/nodebyte/backend/internal/crypto"
+)
+
+// VersionResponse describes the running build
+type VersionResponse struct {
+	Version   string          `json:"version"`
+	Commit    string          `json:"commit"`
+	BuildTime string          `json:"buildTime"`
+	GoVersion string          `json:"goVersion"`
+	Features  map[string]bool `json:"features"` // whether optional integrations are configured
+}
+
+// versionInfo returns which build is running
+// @Summary Build version
+// @Description Returns the application version, git commit, build time and Go version, and whether encryption, Sentry and Redis are configured. Reports no settings values.
+// @Tags Health
+// @Produce json
+// @Success 200 {object} VersionResponse "Build information"
+// @Router /api/version [get]
+func versionInfo(cfg *config.Config) fiber.Handler {
+	// Nothing here changes while the process runs
+	build := buildinfo.Current()
+	_, encryptionErr := crypto.NewEncryptorFromEnv()
+	response := VersionResponse{
+		Version:   build.Version,
+		Commit:    build.Commit,
+		BuildTime: build.BuildTime,
+		GoVersion: runtime.Version(),
+		Features: map[string]bool{
+			"encryption": encryptionErr == nil,
+			"sentry":     cfg.SentryDSN != "",
+			"redis":      cfg.RedisURL != "",
+		},
+	}
+
+	return func(c *fiber.Ctx) error {
+		return c.JSON(response)
+	}
+}
@@ -0,0 +1,53 @@
+package hytale
+
+import "sync/atomic"
+
+// Environment names reported by Environment.Name
+const (
+	EnvironmentProduction = "production"
+	EnvironmentStaging    = "staging"
+)
+
+// Environment selects between Hytale's production and staging services. One
+// Environment is shared by every OAuth client so the mode can be switched at
+// runtime without rebuilding the clients. A nil Environment is production.
+type Environment struct {
+	staging atomic.Bool
+}
+
+// NewEnvironment creates an environment, starting in staging if staging is set
+func NewEnvironment(staging bool) *Environment {
+	e := &Environment{}
+	e.staging.Store(staging)
+	return e
+}
+
+// Staging reports whether the staging services are in use
+func (e *Environment) Staging() bool {
+	return e != nil && e.staging.Load()
+}
+
+// SetStaging switches between the staging and production services
+func (e *Environment) SetStaging(staging bool) {
+	e.staging.Store(staging)
+}
+
+// Name returns "staging" or "production"
+func (e *Environment) Name() string {
+	if e.Staging() {
+		return EnvironmentStaging
+	}
+	return EnvironmentProduction
+}
+
+// Host returns the base domain of the selected services
+func (e *Environment) Host() string {
+	return environmentHost(e.Staging())
+}
+
+func environmentHost(staging bool) string {
+	if staging {
+		return "arcanitegames.ca"
+	}
+	return "hytale.com"
+}
@@ -15,6 +15,9 @@ import (
 type OAuthClientConfig struct {
 	ClientID   string
 	UseStaging bool // If true, use arcanitegames.ca instead of hytale.com
+	// Environment, when set, selects staging or production at request time
+	// and takes precedence over UseStaging
+	Environment *Environment
 }
 
 // OAuthClient handles communication with Hytale OAuth endpoints
@@ -153,13 +156,17 @@ func (c *OAuthClient) RefreshToken(ctx context.Context, refreshToken string) (*T
 	return &tokenResp, nil
 }
 
+// host returns the base domain for the client's current environment
+func (c *OAuthClient) host() string {
+	if c.config.Environment != nil {
+		return c.config.Environment.Host()
+	}
+	return environmentHost(c.config.UseStaging)
+}
+
 // getOAuthEndpoint constructs the full OAuth endpoint URL
 func (c *OAuthClient) getOAuthEndpoint(path string) string {
-	host := "hytale.com"
-	if c.config.UseStaging {
-		host = "arcanitegames.ca"
-	}
-	return fmt.Sprintf("https://oauth.accounts.%s%s", host, path)
+	return fmt.Sprintf("https://oauth.accounts.%s%s", c.host(), path)
 }
 
 // GetProfilesResponse represents the response from /my-account/get-profiles
@@ -204,6 +211,9 @@ func (c *OAuthClient) GetProfiles(ctx context.Context, accessToken string) (*Get
 	return &profileResp, nil
 }
 
+// GameSessionTTL is how long a game session lasts when Hytale does not say
+const GameSessionTTL = time.Hour
+
 // GameSessionResponse represents the response from /game-session/new
 type GameSessionResponse struct {
 	SessionToken  string `json:"sessionToken"`
@@ -211,6 +221,15 @@ type GameSessionResponse struct {
 	ExpiresAt     string `json:"expiresAt"`
 }
 
+// Expiry returns when the session expires, assuming GameSessionTTL from now
+// if ExpiresAt is missing or not an RFC 3339 timestamp
+func (r *GameSessionResponse) Expiry(now time.Time) time.Time {
+	if expiresAt, err := time.Parse(time.RFC3339, r.ExpiresAt); err == nil {
+		return expiresAt
+	}
+	return now.Add(GameSessionTTL)
+}
+
 // CreateGameSession creates a new game session
 func (c *OAuthClient) CreateGameSession(ctx context.Context, accessToken string, profileUUID string) (*GameSessionResponse, error) {
 	endpoint := c.getSessionEndpoint("/game-session/new")
@@ -305,18 +324,10 @@ func (c *OAuthClient) TerminateGameSession(ctx context.Context, sessionToken str
 
 // getAccountDataEndpoint constructs the full account data endpoint URL
 func (c *OAuthClient) getAccountDataEndpoint(path string) string {
-	host := "hytale.com"
-	if c.config.UseStaging {
-		host = "arcanitegames.ca"
-	}
-	return fmt.Sprintf("https://account-data.%s%s", host, path)
+	return fmt.Sprintf("https://account-data.%s%s", c.host(), path)
 }
 
 // getSessionEndpoint constructs the full session endpoint URL
 func (c *OAuthClient) getSessionEndpoint(path string) string {
-	host := "hytale.com"
-	if c.config.UseStaging {
-		host = "arcanitegames.ca"
-	}
-	return fmt.Sprintf("https://sessions.%s%s", host, path)
+	return fmt.Sprintf("https://sessions.%s%s", c.host(), path)
 }
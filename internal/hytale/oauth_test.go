@@ -3,6 +3,7 @@ package hytale
 import (
 	"context"
 	"testing"
+	"time"
 )
 
 func TestNewOAuthClient(t *testing.T) {
@@ -173,3 +174,64 @@ func TestOAuthClientContext(t *testing.T) {
 		t.Errorf("context should be valid")
 	}
 }
+
+func TestOAuthClientEnvironmentSwitch(t *testing.T) {
+	env := NewEnvironment(false)
+	client := NewOAuthClient(&OAuthClientConfig{
+		ClientID:    "test",
+		UseStaging:  true, // ignored when an Environment is set
+		Environment: env,
+	})
+
+	if got := client.getOAuthEndpoint("/oauth2/token"); got != "https://oauth.accounts.hytale.com/oauth2/token" {
+		t.Errorf("expected production endpoint, got %s", got)
+	}
+
+	env.SetStaging(true)
+	if got := client.getSessionEndpoint("/game-session/new"); got != "https://sessions.arcanitegames.ca/game-session/new" {
+		t.Errorf("expected staging endpoint after switch, got %s", got)
+	}
+	if env.Name() != EnvironmentStaging {
+		t.Errorf("expected environment name %s, got %s", EnvironmentStaging, env.Name())
+	}
+
+	var nilEnv *Environment
+	if nilEnv.Staging() || nilEnv.Name() != EnvironmentProduction {
+		t.Errorf("expected nil environment to be production")
+	}
+}
+
+func TestGameSessionResponseExpiry(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		expiresAt string
+		expected  time.Time
+	}{
+		{
+			name:      "reported expiry",
+			expiresAt: "2026-01-01T12:30:00Z",
+			expected:  time.Date(2026, 1, 1, 12, 30, 0, 0, time.UTC),
+		},
+		{
+			name:      "missing expiry",
+			expiresAt: "",
+			expected:  now.Add(GameSessionTTL),
+		},
+		{
+			name:      "unparseable expiry",
+			expiresAt: "in an hour",
+			expected:  now.Add(GameSessionTTL),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &GameSessionResponse{ExpiresAt: tt.expiresAt}
+			if got := resp.Expiry(now); !got.Equal(tt.expected) {
+				t.Errorf("expected expiry %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
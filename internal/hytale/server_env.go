@@ -0,0 +1,17 @@
+package hytale
+
+// Server environment variables a Hytale dedicated server reads its session
+// tokens from, so it can start already authenticated
+const (
+	ServerSessionTokenEnv  = "HYTALE_SERVER_SESSION_TOKEN"
+	ServerIdentityTokenEnv = "HYTALE_SERVER_IDENTITY_TOKEN"
+)
+
+// ServerTokenEnvironment returns the environment variables that hand a game
+// session's tokens to a server. Empty tokens clear the variables.
+func ServerTokenEnvironment(sessionToken, identityToken string) map[string]string {
+	return map[string]string{
+		ServerSessionTokenEnv:  sessionToken,
+		ServerIdentityTokenEnv: identityToken,
+	}
+}
@@ -0,0 +1,191 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/hibiken/asynq"
+	"github.com/redis/go-redis/v9"
+	"github.com/rs/zerolog/log"
+
+	"github.com/nodebyte/backend/internal/types"
+)
+
+// apiRateLimitWindow is the period an API rate limit is expressed over; a
+// full bucket refills over one window
+const apiRateLimitWindow = time.Minute
+
+// bucketResult is the state of a token bucket after taking a token
+type bucketResult struct {
+	allowed    bool
+	remaining  int
+	retryAfter time.Duration // until the next token, when not allowed
+	resetAfter time.Duration // until the bucket is full again
+}
+
+// bucketStore takes tokens from buckets shared by every API instance
+type bucketStore interface {
+	take(ctx context.Context, key string, capacity int, window time.Duration) (bucketResult, error)
+}
+
+// APIRateLimiter applies per-route-group token bucket limits to API
+// requests, keyed by user ID once authenticated and by client IP otherwise.
+// Buckets live in Redis so limits hold across API instances. A nil
+// *APIRateLimiter lets every request through.
+type APIRateLimiter struct {
+	store  bucketStore
+	limits func(group string) int // requests per minute; 0 or less disables the group
+}
+
+// NewAPIRateLimiter creates a limiter storing buckets in Redis, using the
+// same connection settings as the queue. limits is read on every request and
+// returns a route group's requests per minute.
+func NewAPIRateLimiter(opt asynq.RedisClientOpt, limits func(group string) int) *APIRateLimiter {
+	client, ok := opt.MakeRedisClient().(redis.UniversalClient)
+	if !ok {
+		return nil
+	}
+	return &APIRateLimiter{store: &redisBucketStore{client: client}, limits: limits}
+}
+
+// Limit returns a middleware enforcing the group's limit. Requests made
+// with an API key or by a system admin are exempt, as are all requests when
+// Redis is unavailable.
+func (l *APIRateLimiter) Limit(group string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if l == nil || rateLimitExempt(c) {
+			return c.Next()
+		}
+		limit := l.limits(group)
+		if limit <= 0 {
+			return c.Next()
+		}
+
+		identifier := rateLimitIdentifier(c)
+		result, err := l.store.take(c.Context(), "ratelimit:"+group+":"+identifier, limit, apiRateLimitWindow)
+		if err != nil {
+			log.Warn().Err(err).Str("group", group).Msg("Rate limit check failed; allowing request")
+			return c.Next()
+		}
+
+		c.Set("X-RateLimit-Limit", strconv.Itoa(limit))
+		c.Set("X-RateLimit-Remaining", strconv.Itoa(result.remaining))
+		c.Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(result.resetAfter).Unix(), 10))
+
+		if !result.allowed {
+			retryAfter := int(math.Ceil(result.retryAfter.Seconds()))
+			if retryAfter < 1 {
+				retryAfter = 1
+			}
+			c.Set("Retry-After", strconv.Itoa(retryAfter))
+
+			log.Warn().
+				Str("identifier", identifier).
+				Str("group", group).
+				Str("endpoint", c.Path()).
+				Str("method", c.Method()).
+				Int("limit", limit).
+				Msg("API rate limit exceeded")
+
+			return c.Status(http.StatusTooManyRequests).JSON(types.RateLimitErrorResponse{
+				Success: false,
+				Error:   fmt.Sprintf("Rate limit exceeded. Maximum %d requests per minute. Retry after %d seconds.", limit, retryAfter),
+				Code:    "RATE_LIMITED",
+			})
+		}
+
+		return c.Next()
+	}
+}
+
+// Close releases the Redis connection
+func (l *APIRateLimiter) Close() error {
+	if l == nil {
+		return nil
+	}
+	if s, ok := l.store.(*redisBucketStore); ok {
+		return s.client.Close()
+	}
+	return nil
+}
+
+// rateLimitExempt reports whether the request was authenticated with an API
+// key or by a system admin
+func rateLimitExempt(c *fiber.Ctx) bool {
+	if label, _ := c.Locals("apiKeyLabel").(string); label != "" {
+		return true
+	}
+	isSystemAdmin, _ := c.Locals("isSystemAdmin").(bool)
+	return isSystemAdmin
+}
+
+// rateLimitIdentifier returns the bucket owner: the authenticated user, or
+// the client IP for anonymous requests
+func rateLimitIdentifier(c *fiber.Ctx) string {
+	if userID, _ := c.Locals("userID").(string); userID != "" {
+		return "user:" + userID
+	}
+	return "ip:" + c.IP()
+}
+
+// tokenBucketScript refills the bucket at KEYS[1] for the time elapsed and
+// takes a token if one is available. ARGV: capacity, refill per millisecond,
+// now in milliseconds. Returns allowed (0/1), tokens left (floored) and
+// milliseconds until the next token.
+var tokenBucketScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+
+local state = redis.call("HMGET", KEYS[1], "tokens", "ts")
+local tokens = tonumber(state[1]) or capacity
+local ts = tonumber(state[2]) or now
+if now > ts then
+	tokens = math.min(capacity, tokens + (now - ts) * rate)
+end
+
+local allowed = 0
+local wait = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	allowed = 1
+else
+	wait = math.ceil((1 - tokens) / rate)
+end
+
+redis.call("HSET", KEYS[1], "tokens", tostring(tokens), "ts", tostring(math.max(now, ts)))
+redis.call("PEXPIRE", KEYS[1], math.ceil(capacity / rate))
+return {allowed, math.floor(tokens), wait}
+`)
+
+// redisBucketStore keeps token buckets in Redis hashes
+type redisBucketStore struct {
+	client redis.UniversalClient
+}
+
+func (s *redisBucketStore) take(ctx context.Context, key string, capacity int, window time.Duration) (bucketResult, error) {
+	rate := float64(capacity) / float64(window.Milliseconds())
+	res, err := tokenBucketScript.Run(ctx, s.client, []string{key},
+		capacity, strconv.FormatFloat(rate, 'f', -1, 64), time.Now().UnixMilli(),
+	).Int64Slice()
+	if err != nil {
+		return bucketResult{}, err
+	}
+	if len(res) != 3 {
+		return bucketResult{}, fmt.Errorf("unexpected rate limit script result %v", res)
+	}
+
+	remaining := int(res[1])
+	missing := float64(capacity - remaining)
+	return bucketResult{
+		allowed:    res[0] == 1,
+		remaining:  remaining,
+		retryAfter: time.Duration(res[2]) * time.Millisecond,
+		resetAfter: time.Duration(missing / rate * float64(time.Millisecond)),
+	}, nil
+}
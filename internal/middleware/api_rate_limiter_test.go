@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// fakeBucketStore counts requests per key and rejects those over capacity
+type fakeBucketStore struct {
+	taken map[string]int
+	err   error
+}
+
+func (s *fakeBucketStore) take(_ context.Context, key string, capacity int, _ time.Duration) (bucketResult, error) {
+	if s.err != nil {
+		return bucketResult{}, s.err
+	}
+	if s.taken == nil {
+		s.taken = map[string]int{}
+	}
+	if s.taken[key] >= capacity {
+		return bucketResult{retryAfter: 1500 * time.Millisecond, resetAfter: time.Minute}, nil
+	}
+	s.taken[key]++
+	return bucketResult{allowed: true, remaining: capacity - s.taken[key]}, nil
+}
+
+func newRateLimitedApp(limiter *APIRateLimiter, locals map[string]interface{}) *fiber.App {
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		for k, v := range locals {
+			c.Locals(k, v)
+		}
+		return c.Next()
+	})
+	app.Get("/", limiter.Limit("dashboard"), func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+	return app
+}
+
+func TestAPIRateLimiterLimit(t *testing.T) {
+	store := &fakeBucketStore{}
+	limiter := &APIRateLimiter{store: store, limits: func(string) int { return 2 }}
+	app := newRateLimitedApp(limiter, map[string]interface{}{"userID": "user-1"})
+
+	for i := 0; i < 2; i++ {
+		resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+		if err != nil {
+			t.Fatalf("request %d: %v", i+1, err)
+		}
+		if resp.StatusCode != fiber.StatusOK {
+			t.Fatalf("request %d status = %d, want 200", i+1, resp.StatusCode)
+		}
+		if got := resp.Header.Get("X-RateLimit-Limit"); got != "2" {
+			t.Errorf("X-RateLimit-Limit = %q, want 2", got)
+		}
+	}
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != fiber.StatusTooManyRequests {
+		t.Fatalf("status = %d, want 429", resp.StatusCode)
+	}
+	if got := resp.Header.Get("Retry-After"); got != "2" {
+		t.Errorf("Retry-After = %q, want 2", got)
+	}
+	if got := resp.Header.Get("X-RateLimit-Remaining"); got != "0" {
+		t.Errorf("X-RateLimit-Remaining = %q, want 0", got)
+	}
+	if _, ok := store.taken["ratelimit:dashboard:user:user-1"]; !ok {
+		t.Errorf("expected bucket keyed by user, got %v", store.taken)
+	}
+}
+
+func TestAPIRateLimiterAnonymousKeyedByIP(t *testing.T) {
+	store := &fakeBucketStore{}
+	limiter := &APIRateLimiter{store: store, limits: func(string) int { return 5 }}
+	app := newRateLimitedApp(limiter, nil)
+
+	if _, err := app.Test(httptest.NewRequest("GET", "/", nil)); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.taken["ratelimit:dashboard:ip:0.0.0.0"]; !ok {
+		t.Errorf("expected bucket keyed by IP, got %v", store.taken)
+	}
+}
+
+func TestAPIRateLimiterPassThrough(t *testing.T) {
+	tests := []struct {
+		name    string
+		limiter *APIRateLimiter
+		locals  map[string]interface{}
+	}{
+		{name: "nil limiter", limiter: nil},
+		{
+			name:    "group disabled",
+			limiter: &APIRateLimiter{store: &fakeBucketStore{}, limits: func(string) int { return 0 }},
+		},
+		{
+			name:    "API key",
+			limiter: &APIRateLimiter{store: &fakeBucketStore{}, limits: func(string) int { return 1 }},
+			locals:  map[string]interface{}{"apiKeyLabel": "billing"},
+		},
+		{
+			name:    "system admin",
+			limiter: &APIRateLimiter{store: &fakeBucketStore{}, limits: func(string) int { return 1 }},
+			locals:  map[string]interface{}{"userID": "admin-1", "isSystemAdmin": true},
+		},
+		{
+			name:    "store unavailable",
+			limiter: &APIRateLimiter{store: &fakeBucketStore{err: errors.New("connection refused")}, limits: func(string) int { return 1 }},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := newRateLimitedApp(tt.limiter, tt.locals)
+			for i := 0; i < 3; i++ {
+				resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if resp.StatusCode != fiber.StatusOK {
+					t.Fatalf("request %d status = %d, want 200", i+1, resp.StatusCode)
+				}
+			}
+		})
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"strconv"
 	"strings"
 	"time"
 )
@@ -18,19 +20,96 @@ type PterodactylClient struct {
 	clientAPIKey     string
 	cfAccessClientID string
 	cfAccessSecret   string
+	perPage          int
 	httpClient       *http.Client
 }
 
+// DefaultPerPage is the page size used for paginated fetches. It is also the
+// maximum Pterodactyl accepts for per_page.
+const DefaultPerPage = 100
+
+// NormalizeBaseURL cleans up a panel base URL: surrounding whitespace and
+// trailing slashes are removed and https:// is assumed when no scheme is
+// given. It returns an error unless the result is an http(s) URL with a host.
+func NormalizeBaseURL(raw string) (string, error) {
+	normalized := strings.TrimSpace(raw)
+	if normalized == "" {
+		return "", fmt.Errorf("panel URL is empty")
+	}
+	if !strings.Contains(normalized, "://") {
+		normalized = "https://" + normalized
+	}
+	normalized = strings.TrimRight(normalized, "/")
+
+	u, err := url.Parse(normalized)
+	if err != nil {
+		return "", fmt.Errorf("panel URL %q is not a valid URL", raw)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", fmt.Errorf("panel URL %q must use http or https", raw)
+	}
+	if u.Host == "" {
+		return "", fmt.Errorf("panel URL %q has no host", raw)
+	}
+	return normalized, nil
+}
+
+// cleanBaseURL normalizes baseURL, falling back to trimming trailing slashes
+// when it is not a valid panel URL
+func cleanBaseURL(baseURL string) string {
+	if normalized, err := NormalizeBaseURL(baseURL); err == nil {
+		return normalized
+	}
+	return strings.TrimRight(baseURL, "/")
+}
+
+// maxDrainBytes caps how much of an unread response body is discarded to
+// keep its connection reusable; larger remainders close the connection
+const maxDrainBytes = 64 << 10
+
+// ConnectionPool controls how the client keeps idle panel connections
+type ConnectionPool struct {
+	MaxIdleConns        int           // across all hosts
+	MaxIdleConnsPerHost int           // per host; the panel is usually the only one
+	IdleConnTimeout     time.Duration // idle connections are closed after this long
+}
+
+// DefaultConnectionPool is used until SetConnectionPool is called
+var DefaultConnectionPool = ConnectionPool{
+	MaxIdleConns:        100,
+	MaxIdleConnsPerHost: 10,
+	IdleConnTimeout:     90 * time.Second,
+}
+
+// newTransport returns a transport based on http.DefaultTransport with the
+// pool's idle connection limits
+func newTransport(pool ConnectionPool) *http.Transport {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = pool.MaxIdleConns
+	transport.MaxIdleConnsPerHost = pool.MaxIdleConnsPerHost
+	transport.IdleConnTimeout = pool.IdleConnTimeout
+	return transport
+}
+
+// drainBody discards what is left of a response body, up to maxDrainBytes,
+// and closes it so the connection can go back to the idle pool
+func drainBody(body io.ReadCloser) {
+	io.Copy(io.Discard, io.LimitReader(body, maxDrainBytes))
+	body.Close()
+}
+
 // NewPterodactylClient creates a new Pterodactyl API client
 func NewPterodactylClient(baseURL, apiKey, cfClientID, cfSecret string) *PterodactylClient {
 	return &PterodactylClient{
-		baseURL:          baseURL,
+		baseURL:          cleanBaseURL(baseURL),
 		apiKey:           apiKey,
 		clientAPIKey:     "",
 		cfAccessClientID: cfClientID,
 		cfAccessSecret:   cfSecret,
+		perPage:          DefaultPerPage,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: newTransport(DefaultConnectionPool),
 		},
 	}
 }
@@ -38,17 +117,46 @@ func NewPterodactylClient(baseURL, apiKey, cfClientID, cfSecret string) *Pteroda
 // NewPterodactylClientWithClientKey creates a new Pterodactyl API client with both application and client API keys
 func NewPterodactylClientWithClientKey(baseURL, apiKey, clientAPIKey, cfClientID, cfSecret string) *PterodactylClient {
 	return &PterodactylClient{
-		baseURL:          baseURL,
+		baseURL:          cleanBaseURL(baseURL),
 		apiKey:           apiKey,
 		clientAPIKey:     clientAPIKey,
 		cfAccessClientID: cfClientID,
 		cfAccessSecret:   cfSecret,
+		perPage:          DefaultPerPage,
 		httpClient: &http.Client{
-			Timeout: 30 * time.Second,
+			Timeout:   30 * time.Second,
+			Transport: newTransport(DefaultConnectionPool),
 		},
 	}
 }
 
+// SetConnectionPool replaces the client's idle connection limits. Zero
+// fields keep the DefaultConnectionPool value. Idle connections of the
+// previous transport are closed.
+func (c *PterodactylClient) SetConnectionPool(pool ConnectionPool) {
+	if pool.MaxIdleConns <= 0 {
+		pool.MaxIdleConns = DefaultConnectionPool.MaxIdleConns
+	}
+	if pool.MaxIdleConnsPerHost <= 0 {
+		pool.MaxIdleConnsPerHost = DefaultConnectionPool.MaxIdleConnsPerHost
+	}
+	if pool.IdleConnTimeout <= 0 {
+		pool.IdleConnTimeout = DefaultConnectionPool.IdleConnTimeout
+	}
+
+	c.httpClient.CloseIdleConnections()
+	c.httpClient.Transport = newTransport(pool)
+}
+
+// SetPerPage sets the page size for paginated fetches. Values outside
+// 1..DefaultPerPage fall back to DefaultPerPage.
+func (c *PterodactylClient) SetPerPage(perPage int) {
+	if perPage < 1 || perPage > DefaultPerPage {
+		perPage = DefaultPerPage
+	}
+	c.perPage = perPage
+}
+
 // PaginatedResponse represents a paginated API response
 type PaginatedResponse struct {
 	Object string          `json:"object"`
@@ -236,6 +344,12 @@ type PteroUser struct {
 		CreatedAt  string `json:"created_at"`
 		UpdatedAt  string `json:"updated_at"`
 	} `json:"attributes"`
+	Relationships struct {
+		Servers struct {
+			Object string        `json:"object"`
+			Data   []PteroServer `json:"data"`
+		} `json:"servers"`
+	} `json:"relationships"`
 }
 
 // PteroDatabase represents a server database
@@ -283,6 +397,40 @@ type ClientSubuser struct {
 	} `json:"attributes"`
 }
 
+// ClientFileObject represents a file or directory from the Client API file manager
+type ClientFileObject struct {
+	Object     string `json:"object"`
+	Attributes struct {
+		Name       string `json:"name"`
+		Mode       string `json:"mode"`
+		ModeBits   string `json:"mode_bits"`
+		Size       int64  `json:"size"`
+		IsFile     bool   `json:"is_file"`
+		IsSymlink  bool   `json:"is_symlink"`
+		Mimetype   string `json:"mimetype"`
+		CreatedAt  string `json:"created_at"`
+		ModifiedAt string `json:"modified_at"`
+	} `json:"attributes"`
+}
+
+// MaxServerFileContentSize caps how much of a file GetServerFileContents reads
+const MaxServerFileContentSize = 5 << 20
+
+// ClientActivityLog represents a server activity log entry from Client API
+type ClientActivityLog struct {
+	Object     string `json:"object"`
+	Attributes struct {
+		ID          string          `json:"id"`
+		Batch       *string         `json:"batch"`
+		Event       string          `json:"event"`
+		IsAPI       bool            `json:"is_api"`
+		IP          string          `json:"ip"`
+		Description *string         `json:"description"`
+		Properties  json.RawMessage `json:"properties"`
+		Timestamp   string          `json:"timestamp"`
+	} `json:"attributes"`
+}
+
 // doRequest performs an HTTP request to the Pterodactyl API using the application API key
 func (c *PterodactylClient) doRequest(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
 	url := fmt.Sprintf("%s/api/application%s", c.baseURL, path)
@@ -362,7 +510,7 @@ func (c *PterodactylClient) GetLocations(ctx context.Context) ([]PteroLocation,
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	defer drainBody(resp.Body)
 
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
@@ -384,7 +532,7 @@ func (c *PterodactylClient) GetNodes(ctx context.Context) ([]PteroNode, error) {
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	defer drainBody(resp.Body)
 
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
@@ -407,7 +555,7 @@ func (c *PterodactylClient) GetNodeAllocations(ctx context.Context, nodeID int,
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	defer drainBody(resp.Body)
 
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
@@ -427,7 +575,7 @@ func (c *PterodactylClient) GetNests(ctx context.Context) ([]PteroNest, error) {
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	defer drainBody(resp.Body)
 
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
@@ -450,7 +598,7 @@ func (c *PterodactylClient) GetNestEggs(ctx context.Context, nestID int) ([]Pter
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	defer drainBody(resp.Body)
 
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
@@ -468,12 +616,15 @@ func (c *PterodactylClient) GetNestEggs(ctx context.Context, nestID int) ([]Pter
 
 // GetServers fetches servers with pagination
 func (c *PterodactylClient) GetServers(ctx context.Context, page int) (*PaginatedResponse, error) {
-	path := fmt.Sprintf("/servers?page=%d&per_page=50", page)
+	path, err := c.paginatedPath("/servers", page)
+	if err != nil {
+		return nil, err
+	}
 	resp, err := c.doRequest(ctx, "GET", path, nil)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	defer drainBody(resp.Body)
 
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
@@ -489,12 +640,15 @@ func (c *PterodactylClient) GetServers(ctx context.Context, page int) (*Paginate
 
 // GetUsers fetches users with pagination
 func (c *PterodactylClient) GetUsers(ctx context.Context, page int) (*PaginatedResponse, error) {
-	path := fmt.Sprintf("/users?page=%d&per_page=50", page)
+	path, err := c.paginatedPath("/users", page)
+	if err != nil {
+		return nil, err
+	}
 	resp, err := c.doRequest(ctx, "GET", path, nil)
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	defer drainBody(resp.Body)
 
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
@@ -508,6 +662,32 @@ func (c *PterodactylClient) GetUsers(ctx context.Context, page int) (*PaginatedR
 	return &result, nil
 }
 
+// GetUser fetches a single user, optionally including relationships such as
+// "servers"
+func (c *PterodactylClient) GetUser(ctx context.Context, userID int, includes []string) (*PteroUser, error) {
+	path := fmt.Sprintf("/users/%d", userID)
+	if len(includes) > 0 {
+		path += "?include=" + strings.Join(includes, ",")
+	}
+
+	resp, err := c.doRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer drainBody(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+
+	var result PteroUser
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
 // GetServerDatabases fetches databases for a specific server
 func (c *PterodactylClient) GetServerDatabases(ctx context.Context, serverID int) ([]PteroDatabase, error) {
 	path := fmt.Sprintf("/servers/%d/databases", serverID)
@@ -515,7 +695,7 @@ func (c *PterodactylClient) GetServerDatabases(ctx context.Context, serverID int
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	defer drainBody(resp.Body)
 
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
@@ -537,7 +717,7 @@ func (c *PterodactylClient) TestConnection(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	defer resp.Body.Close()
+	defer drainBody(resp.Body)
 
 	if resp.StatusCode != http.StatusOK {
 		return fmt.Errorf("connection test failed with status: %d", resp.StatusCode)
@@ -688,7 +868,7 @@ func (c *PterodactylClient) GetServerDatabasesWithHost(ctx context.Context, serv
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	defer drainBody(resp.Body)
 
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
@@ -712,7 +892,7 @@ func (c *PterodactylClient) GetServerResources(ctx context.Context, serverUUID s
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	defer drainBody(resp.Body)
 
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
@@ -737,21 +917,19 @@ func (c *PterodactylClient) GetServerDetailWithIncludes(ctx context.Context, ser
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	defer drainBody(resp.Body)
 
 	if resp.StatusCode != http.StatusOK {
 		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 	}
 
-	var result struct {
-		Object     string      `json:"object"`
-		Attributes PteroServer `json:"attributes"`
-	}
+	// The response body is the server object itself ({"object": "server", "attributes": {...}})
+	var result PteroServer
 	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
 		return nil, err
 	}
 
-	return &result.Attributes, nil
+	return &result, nil
 }
 
 // getAllWithPagination is a helper to fetch all pages and merge results
@@ -760,27 +938,25 @@ func (c *PterodactylClient) getAllWithPagination(ctx context.Context, path strin
 	page := 1
 
 	for {
-		// Add page param
-		separator := "?"
-		if string(path[len(path)-1]) == "?" {
-			separator = ""
-		} else if containsQueryParams(path) {
-			separator = "&"
+		fullPath, err := c.paginatedPath(path, page)
+		if err != nil {
+			return nil, err
 		}
-
-		fullPath := fmt.Sprintf("%s%spage=%d", path, separator, page)
 		resp, err := c.doRequest(ctx, "GET", fullPath, nil)
 		if err != nil {
 			return nil, err
 		}
-		defer resp.Body.Close()
 
+		// Close each page before fetching the next so a long pagination run
+		// does not hold a connection per page
 		if resp.StatusCode != http.StatusOK {
+			drainBody(resp.Body)
 			return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
 		}
 
 		var paginated PaginatedResponse
 		body, readErr := io.ReadAll(resp.Body)
+		drainBody(resp.Body)
 		if readErr != nil {
 			return nil, fmt.Errorf("failed to read response body: %w", readErr)
 		}
@@ -820,14 +996,20 @@ func (c *PterodactylClient) getAllWithPagination(ctx context.Context, path strin
 	return allItems, nil
 }
 
-// containsQueryParams checks if a path already has query parameters
-func containsQueryParams(path string) bool {
-	for _, c := range path {
-		if c == '?' {
-			return true
-		}
+// paginatedPath sets the page and per_page query params on path, preserving
+// any params it already carries
+func (c *PterodactylClient) paginatedPath(path string, page int) (string, error) {
+	u, err := url.Parse(path)
+	if err != nil {
+		return "", fmt.Errorf("invalid path %q: %w", path, err)
 	}
-	return false
+
+	q := u.Query()
+	q.Set("page", strconv.Itoa(page))
+	q.Set("per_page", strconv.Itoa(c.perPage))
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
 }
 
 // UpdateServerEnvironment updates environment variables for a server
@@ -843,7 +1025,7 @@ func (c *PterodactylClient) UpdateServerEnvironment(ctx context.Context, serverU
 	if err != nil {
 		return fmt.Errorf("failed to update server environment: %w", err)
 	}
-	defer resp.Body.Close()
+	defer drainBody(resp.Body)
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
 		body, _ := io.ReadAll(resp.Body)
@@ -863,7 +1045,7 @@ func (c *PterodactylClient) GetClientServers(ctx context.Context) ([]ClientServe
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	defer drainBody(resp.Body)
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
@@ -880,6 +1062,83 @@ func (c *PterodactylClient) GetClientServers(ctx context.Context) ([]ClientServe
 	return result.Data, nil
 }
 
+// GetServerActivity fetches a page of a server's activity log, newest first
+func (c *PterodactylClient) GetServerActivity(ctx context.Context, serverUUID string, page, perPage int) (*PaginatedResponse, error) {
+	if c.clientAPIKey == "" {
+		return nil, fmt.Errorf("client API key not configured")
+	}
+
+	query := url.Values{
+		"page":     {strconv.Itoa(page)},
+		"per_page": {strconv.Itoa(perPage)},
+		"sort":     {"-timestamp"},
+	}
+	path := fmt.Sprintf("/servers/%s/activity?%s", serverUUID, query.Encode())
+	resp, err := c.doClientRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer drainBody(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result PaginatedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
+// ReinstallServer triggers a reinstall of a server, re-running the egg's install script
+func (c *PterodactylClient) ReinstallServer(ctx context.Context, serverUUID string) error {
+	if c.clientAPIKey == "" {
+		return fmt.Errorf("client API key not configured")
+	}
+
+	path := fmt.Sprintf("/servers/%s/settings/reinstall", serverUUID)
+	resp, err := c.doClientRequest(ctx, "POST", path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to reinstall server: %w", err)
+	}
+	defer drainBody(resp.Body)
+
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to reinstall server: %d - %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
+
+// SuspendServer suspends a server, stopping it and blocking access to it
+func (c *PterodactylClient) SuspendServer(ctx context.Context, serverID int) error {
+	return c.setServerSuspended(ctx, serverID, "suspend")
+}
+
+// UnsuspendServer lifts a server's suspension
+func (c *PterodactylClient) UnsuspendServer(ctx context.Context, serverID int) error {
+	return c.setServerSuspended(ctx, serverID, "unsuspend")
+}
+
+func (c *PterodactylClient) setServerSuspended(ctx context.Context, serverID int, action string) error {
+	resp, err := c.doRequest(ctx, "POST", fmt.Sprintf("/servers/%d/%s", serverID, action), nil)
+	if err != nil {
+		return fmt.Errorf("failed to %s server: %w", action, err)
+	}
+	defer drainBody(resp.Body)
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to %s server: %d - %s", action, resp.StatusCode, string(body))
+	}
+
+	return nil
+}
+
 // GetServerSubusers fetches subusers for a specific server (requires owner or admin)
 func (c *PterodactylClient) GetServerSubusers(ctx context.Context, serverUUID string) ([]ClientSubuser, error) {
 	if c.clientAPIKey == "" {
@@ -891,7 +1150,7 @@ func (c *PterodactylClient) GetServerSubusers(ctx context.Context, serverUUID st
 	if err != nil {
 		return nil, err
 	}
-	defer resp.Body.Close()
+	defer drainBody(resp.Body)
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
@@ -907,3 +1166,204 @@ func (c *PterodactylClient) GetServerSubusers(ctx context.Context, serverUUID st
 
 	return result.Data, nil
 }
+
+// ListServerFiles lists the contents of a directory on a server
+func (c *PterodactylClient) ListServerFiles(ctx context.Context, serverUUID, directory string) ([]ClientFileObject, error) {
+	if c.clientAPIKey == "" {
+		return nil, fmt.Errorf("client API key not configured")
+	}
+	if directory == "" {
+		directory = "/"
+	}
+
+	path := fmt.Sprintf("/servers/%s/files/list?%s", serverUUID, url.Values{"directory": {directory}}.Encode())
+	resp, err := c.doClientRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer drainBody(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Data []ClientFileObject `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return result.Data, nil
+}
+
+// GetServerFileContents returns the raw contents of a file on a server, up to
+// MaxServerFileContentSize bytes. The boolean reports whether it was truncated.
+func (c *PterodactylClient) GetServerFileContents(ctx context.Context, serverUUID, file string) ([]byte, bool, error) {
+	if c.clientAPIKey == "" {
+		return nil, false, fmt.Errorf("client API key not configured")
+	}
+
+	path := fmt.Sprintf("/servers/%s/files/contents?%s", serverUUID, url.Values{"file": {file}}.Encode())
+	resp, err := c.doClientRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return nil, false, err
+	}
+	defer drainBody(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, false, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, MaxServerFileContentSize+1))
+	if err != nil {
+		return nil, false, err
+	}
+	if len(data) > MaxServerFileContentSize {
+		return data[:MaxServerFileContentSize], true, nil
+	}
+
+	return data, false, nil
+}
+
+// GetServerFileDownloadURL returns a one-time signed URL for downloading a
+// file directly from the server's node
+func (c *PterodactylClient) GetServerFileDownloadURL(ctx context.Context, serverUUID, file string) (string, error) {
+	if c.clientAPIKey == "" {
+		return "", fmt.Errorf("client API key not configured")
+	}
+
+	path := fmt.Sprintf("/servers/%s/files/download?%s", serverUUID, url.Values{"file": {file}}.Encode())
+	resp, err := c.doClientRequest(ctx, "GET", path, nil)
+	if err != nil {
+		return "", err
+	}
+	defer drainBody(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Attributes struct {
+			URL string `json:"url"`
+		} `json:"attributes"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+
+	return result.Attributes.URL, nil
+}
+
+// GetNode fetches a single node from Pterodactyl
+func (c *PterodactylClient) GetNode(ctx context.Context, nodeID int) (*PteroNode, error) {
+	resp, err := c.doRequest(ctx, "GET", fmt.Sprintf("/nodes/%d", nodeID), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer drainBody(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var node PteroNode
+	if err := json.NewDecoder(resp.Body).Decode(&node); err != nil {
+		return nil, err
+	}
+	return &node, nil
+}
+
+// SetNodeMaintenanceMode turns maintenance mode on or off for a node. The
+// panel validates the full node on update, so the current node is fetched
+// and sent back with only maintenance_mode changed.
+func (c *PterodactylClient) SetNodeMaintenanceMode(ctx context.Context, nodeID int, enabled bool) (*PteroNode, error) {
+	node, err := c.GetNode(ctx, nodeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch node: %w", err)
+	}
+
+	a := node.Attributes
+	body := map[string]interface{}{
+		"name":                a.Name,
+		"description":         a.Description,
+		"location_id":         a.LocationID,
+		"fqdn":                a.FQDN,
+		"scheme":              a.Scheme,
+		"behind_proxy":        a.BehindProxy,
+		"public":              a.Public,
+		"maintenance_mode":    enabled,
+		"memory":              a.Memory,
+		"memory_overallocate": a.MemoryOverallocate,
+		"disk":                a.Disk,
+		"disk_overallocate":   a.DiskOverallocate,
+		"upload_size":         a.UploadSize,
+		"daemon_listen":       a.DaemonListen,
+		"daemon_sftp":         a.DaemonSFTP,
+		"daemon_base":         a.DaemonBase,
+	}
+	bodyBytes, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	resp, err := c.doRequest(ctx, "PATCH", fmt.Sprintf("/nodes/%d", nodeID), bytes.NewBuffer(bodyBytes))
+	if err != nil {
+		return nil, fmt.Errorf("failed to update node: %w", err)
+	}
+	defer drainBody(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("failed to update node: %d - %s", resp.StatusCode, string(body))
+	}
+
+	var updated PteroNode
+	if err := json.NewDecoder(resp.Body).Decode(&updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// Port limits the panel enforces when creating allocations
+const (
+	MinAllocationPort    = 1024
+	MaxAllocationPort    = 65535
+	MaxAllocationPortSet = 1000 // ports in a single range entry
+)
+
+// CreateAllocationsRequest adds allocations to a node. Ports are single
+// ports ("25565") or inclusive ranges ("25565-25600").
+type CreateAllocationsRequest struct {
+	IP    string   `json:"ip"`
+	Alias string   `json:"alias,omitempty"`
+	Ports []string `json:"ports"`
+}
+
+// CreateAllocations creates allocations on a node. The panel creates all of
+// them or none, and does not return the new allocations; fetch them with
+// GetAllAllocationsForNode.
+func (c *PterodactylClient) CreateAllocations(ctx context.Context, nodeID int, req CreateAllocationsRequest) error {
+	bodyBytes, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request body: %w", err)
+	}
+
+	resp, err := c.doRequest(ctx, "POST", fmt.Sprintf("/nodes/%d/allocations", nodeID), bytes.NewBuffer(bodyBytes))
+	if err != nil {
+		return fmt.Errorf("failed to create allocations: %w", err)
+	}
+	defer drainBody(resp.Body)
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("failed to create allocations: %d - %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
@@ -0,0 +1,486 @@
+package panels
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestPaginatedPath(t *testing.T) {
+	client := NewPterodactylClient("https://panel.example.com", "key", "", "")
+
+	tests := []struct {
+		name    string
+		path    string
+		page    int
+		perPage int
+		want    string
+	}{
+		{
+			name:    "no query",
+			path:    "/locations",
+			page:    1,
+			perPage: 100,
+			want:    "/locations?page=1&per_page=100",
+		},
+		{
+			name:    "existing include param",
+			path:    "/nests/5/eggs?include=variables",
+			page:    2,
+			perPage: 100,
+			want:    "/nests/5/eggs?include=variables&page=2&per_page=100",
+		},
+		{
+			name:    "multiple params",
+			path:    "/servers?include=allocations,user&filter[name]=test",
+			page:    1,
+			perPage: 100,
+			want:    "/servers?filter%5Bname%5D=test&include=allocations%2Cuser&page=1&per_page=100",
+		},
+		{
+			name:    "trailing question mark",
+			path:    "/nodes?",
+			page:    4,
+			perPage: 100,
+			want:    "/nodes?page=4&per_page=100",
+		},
+		{
+			name:    "existing page param is replaced",
+			path:    "/users?page=9",
+			page:    2,
+			perPage: 100,
+			want:    "/users?page=2&per_page=100",
+		},
+		{
+			name:    "custom per page",
+			path:    "/servers",
+			page:    3,
+			perPage: 25,
+			want:    "/servers?page=3&per_page=25",
+		},
+		{
+			name:    "out of range per page falls back to default",
+			path:    "/users",
+			page:    1,
+			perPage: 500,
+			want:    "/users?page=1&per_page=100",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client.SetPerPage(tt.perPage)
+			got, err := client.paginatedPath(tt.path, tt.page)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("paginatedPath(%q, %d) = %q, want %q", tt.path, tt.page, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetAllWithPaginationQuery(t *testing.T) {
+	var seen []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = append(seen, r.URL.RawQuery)
+
+		q := r.URL.Query()
+		if q.Get("include") != "variables" {
+			t.Errorf("include param lost: %q", r.URL.RawQuery)
+		}
+		if q.Get("per_page") != "50" {
+			t.Errorf("per_page = %q, want 50", q.Get("per_page"))
+		}
+
+		resp := map[string]interface{}{
+			"object": "list",
+			"data":   []interface{}{map[string]interface{}{"object": "egg"}},
+			"meta": map[string]interface{}{
+				"pagination": map[string]interface{}{
+					"total":       2,
+					"count":       1,
+					"per_page":    50,
+					"total_pages": 2,
+				},
+			},
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer server.Close()
+
+	client := NewPterodactylClient(server.URL, "key", "", "")
+	client.SetPerPage(50)
+
+	items, err := client.getAllWithPagination(context.Background(), "/nests/1/eggs?include=variables", func(data json.RawMessage) (interface{}, error) {
+		return data, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(items) != 2 {
+		t.Errorf("got %d items, want 2", len(items))
+	}
+	if len(seen) != 2 {
+		t.Fatalf("got %d requests, want 2", len(seen))
+	}
+}
+
+func TestGetServerActivity(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/client/servers/abc-123/activity" {
+			t.Errorf("path = %q, want client activity path", r.URL.Path)
+		}
+		if r.Header.Get("Authorization") != "Bearer client-key" {
+			t.Errorf("client API key not used")
+		}
+		q := r.URL.Query()
+		if q.Get("page") != "2" || q.Get("per_page") != "10" {
+			t.Errorf("query = %q, want page=2 per_page=10", r.URL.RawQuery)
+		}
+
+		w.Write([]byte(`{
+			"object": "list",
+			"data": [{"object": "activity_log", "attributes": {"id": "1", "event": "server:power.start", "is_api": false, "ip": "127.0.0.1", "properties": [], "timestamp": "2026-01-01T00:00:00+00:00"}}],
+			"meta": {"pagination": {"total": 11, "count": 1, "per_page": 10, "current_page": 2, "total_pages": 2}}
+		}`))
+	}))
+	defer server.Close()
+
+	client := NewPterodactylClientWithClientKey(server.URL, "key", "client-key", "", "")
+	resp, err := client.GetServerActivity(context.Background(), "abc-123", 2, 10)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var entries []ClientActivityLog
+	if err := json.Unmarshal(resp.Data, &entries); err != nil {
+		t.Fatalf("failed to unmarshal activity: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Attributes.Event != "server:power.start" {
+		t.Errorf("unexpected entries: %+v", entries)
+	}
+	if resp.Meta.Pagination.Total != 11 {
+		t.Errorf("total = %d, want 11", resp.Meta.Pagination.Total)
+	}
+}
+
+func TestReinstallServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			t.Errorf("method = %s, want POST", r.Method)
+		}
+		if r.URL.Path != "/api/client/servers/abc-123/settings/reinstall" {
+			t.Errorf("path = %q, want client reinstall path", r.URL.Path)
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	client := NewPterodactylClientWithClientKey(server.URL, "key", "client-key", "", "")
+	if err := client.ReinstallServer(context.Background(), "abc-123"); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	noKey := NewPterodactylClient(server.URL, "key", "", "")
+	if err := noKey.ReinstallServer(context.Background(), "abc-123"); err == nil {
+		t.Error("expected error without client API key")
+	}
+}
+
+func TestGetUser(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/application/users/7" {
+			t.Errorf("path = %q, want application user path", r.URL.Path)
+		}
+		if got := r.URL.Query().Get("include"); got != "servers" {
+			t.Errorf("include = %q, want servers", got)
+		}
+		w.Write([]byte(`{
+			"object": "user",
+			"attributes": {"id": 7, "email": "jane@example.com", "username": "jane", "root_admin": true},
+			"relationships": {"servers": {"object": "list", "data": [
+				{"object": "server", "attributes": {"id": 12, "user": 7}},
+				{"object": "server", "attributes": {"id": 15, "user": 7}}
+			]}}
+		}`))
+	}))
+	defer server.Close()
+
+	client := NewPterodactylClient(server.URL, "key", "", "")
+	user, err := client.GetUser(context.Background(), 7, []string{"servers"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if user.Attributes.Email != "jane@example.com" || !user.Attributes.RootAdmin {
+		t.Errorf("unexpected attributes: %+v", user.Attributes)
+	}
+	servers := user.Relationships.Servers.Data
+	if len(servers) != 2 || servers[1].Attributes.ID != 15 {
+		t.Errorf("unexpected servers: %+v", servers)
+	}
+}
+
+func TestServerFileManager(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/client/servers/abc-123/files/list":
+			if got := r.URL.Query().Get("directory"); got != "/config dir" {
+				t.Errorf("directory = %q, want %q", got, "/config dir")
+			}
+			w.Write([]byte(`{
+				"object": "list",
+				"data": [{"object": "file_object", "attributes": {"name": "server.properties", "mode": "-rw-r--r--", "mode_bits": "644", "size": 1024, "is_file": true, "is_symlink": false, "mimetype": "text/plain", "created_at": "2026-01-01T00:00:00+00:00", "modified_at": "2026-01-01T00:00:00+00:00"}}]
+			}`))
+		case "/api/client/servers/abc-123/files/contents":
+			if got := r.URL.Query().Get("file"); got != "/server.properties" {
+				t.Errorf("file = %q, want /server.properties", got)
+			}
+			w.Write([]byte("motd=hello\n"))
+		case "/api/client/servers/abc-123/files/download":
+			w.Write([]byte(`{"object": "signed_url", "attributes": {"url": "https://node.example.com/download/file?token=abc"}}`))
+		default:
+			t.Errorf("unexpected path %q", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := NewPterodactylClientWithClientKey(server.URL, "key", "client-key", "", "")
+	ctx := context.Background()
+
+	files, err := client.ListServerFiles(ctx, "abc-123", "/config dir")
+	if err != nil {
+		t.Fatalf("ListServerFiles: %v", err)
+	}
+	if len(files) != 1 || files[0].Attributes.Name != "server.properties" || !files[0].Attributes.IsFile {
+		t.Errorf("unexpected files: %+v", files)
+	}
+
+	data, truncated, err := client.GetServerFileContents(ctx, "abc-123", "/server.properties")
+	if err != nil {
+		t.Fatalf("GetServerFileContents: %v", err)
+	}
+	if string(data) != "motd=hello\n" || truncated {
+		t.Errorf("contents = %q (truncated %v)", data, truncated)
+	}
+
+	url, err := client.GetServerFileDownloadURL(ctx, "abc-123", "/server.properties")
+	if err != nil {
+		t.Fatalf("GetServerFileDownloadURL: %v", err)
+	}
+	if url != "https://node.example.com/download/file?token=abc" {
+		t.Errorf("url = %q", url)
+	}
+
+	noKey := NewPterodactylClient(server.URL, "key", "", "")
+	if _, err := noKey.ListServerFiles(ctx, "abc-123", "/"); err == nil {
+		t.Error("expected error without client API key")
+	}
+}
+
+func TestSetNodeMaintenanceMode(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/application/nodes/7" {
+			t.Errorf("path = %q, want application node path", r.URL.Path)
+		}
+
+		switch r.Method {
+		case http.MethodGet:
+			w.Write([]byte(`{"object": "node", "attributes": {"id": 7, "name": "node-7", "location_id": 2, "fqdn": "n7.example.com", "scheme": "https", "memory": 4096, "disk": 10240, "daemon_listen": 8080, "daemon_sftp": 2022, "maintenance_mode": false}}`))
+		case http.MethodPatch:
+			var body map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Fatalf("failed to decode body: %v", err)
+			}
+			if body["maintenance_mode"] != true {
+				t.Errorf("maintenance_mode = %v, want true", body["maintenance_mode"])
+			}
+			if body["fqdn"] != "n7.example.com" || body["location_id"] != float64(2) {
+				t.Errorf("required node fields not carried over: %v", body)
+			}
+			w.Write([]byte(`{"object": "node", "attributes": {"id": 7, "name": "node-7", "maintenance_mode": true}}`))
+		default:
+			t.Errorf("unexpected method %s", r.Method)
+		}
+	}))
+	defer server.Close()
+
+	client := NewPterodactylClient(server.URL, "key", "", "")
+	node, err := client.SetNodeMaintenanceMode(context.Background(), 7, true)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !node.Attributes.MaintenanceMode {
+		t.Error("expected maintenance mode to be enabled")
+	}
+}
+
+func TestCreateAllocations(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/application/nodes/3/allocations" {
+			t.Errorf("request = %s %s, want POST node allocations path", r.Method, r.URL.Path)
+		}
+		var body CreateAllocationsRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			t.Fatalf("failed to decode body: %v", err)
+		}
+		if body.IP != "10.0.0.5" || strings.Join(body.Ports, ",") != "25565,25570-25580" {
+			t.Errorf("body = %+v", body)
+		}
+		if body.Alias == "bad" {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"errors":[{"detail":"port already allocated"}]}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewPterodactylClient(server.URL, "key", "", "")
+	req := CreateAllocationsRequest{IP: "10.0.0.5", Ports: []string{"25565", "25570-25580"}}
+	if err := client.CreateAllocations(context.Background(), 3, req); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	req.Alias = "bad"
+	err := client.CreateAllocations(context.Background(), 3, req)
+	if err == nil || !strings.Contains(err.Error(), "port already allocated") {
+		t.Errorf("err = %v, want panel error", err)
+	}
+}
+
+func TestNormalizeBaseURL(t *testing.T) {
+	tests := []struct {
+		name      string
+		raw       string
+		want      string
+		expectErr bool
+	}{
+		{name: "already clean", raw: "https://panel.example.com", want: "https://panel.example.com"},
+		{name: "trailing slash", raw: "https://panel.example.com/", want: "https://panel.example.com"},
+		{name: "several trailing slashes", raw: "https://panel.example.com//", want: "https://panel.example.com"},
+		{name: "subpath with trailing slash", raw: "http://example.com/panel/", want: "http://example.com/panel"},
+		{name: "no scheme", raw: "panel.example.com", want: "https://panel.example.com"},
+		{name: "no scheme with port and slash", raw: " panel.example.com:8443/ ", want: "https://panel.example.com:8443"},
+		{name: "empty", raw: "", expectErr: true},
+		{name: "unsupported scheme", raw: "ftp://panel.example.com", expectErr: true},
+		{name: "no host", raw: "https://", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := NormalizeBaseURL(tt.raw)
+			if tt.expectErr {
+				if err == nil {
+					t.Errorf("NormalizeBaseURL(%q) = %q, want error", tt.raw, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NormalizeBaseURL(%q) unexpected error: %v", tt.raw, err)
+			}
+			if got != tt.want {
+				t.Errorf("NormalizeBaseURL(%q) = %q, want %q", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientTrailingSlashBaseURL(t *testing.T) {
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"data":[],"meta":{"pagination":{"total":0,"count":0,"per_page":100,"current_page":1,"total_pages":1}}}`))
+	}))
+	defer server.Close()
+
+	client := NewPterodactylClient(server.URL+"/", "key", "", "")
+	if _, err := client.GetAllLocations(context.Background()); err != nil {
+		t.Fatalf("GetAllLocations() error: %v", err)
+	}
+	if gotPath != "/api/application/locations" {
+		t.Errorf("request path = %q, want /api/application/locations", gotPath)
+	}
+}
+
+func TestConnectionReuse(t *testing.T) {
+	var mu sync.Mutex
+	newConns := 0
+
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/api/application/nodes/1" {
+			// Trailing bytes the JSON decoder never reads
+			w.Write([]byte(`{"object": "node", "attributes": {"id": 1}}` + strings.Repeat(" ", 16<<10)))
+			return
+		}
+
+		page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"data": []interface{}{map[string]interface{}{"page": page}},
+			"meta": map[string]interface{}{
+				"pagination": map[string]interface{}{"total": 3, "total_pages": 3},
+			},
+		})
+	}))
+	server.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			mu.Lock()
+			newConns++
+			mu.Unlock()
+		}
+	}
+	server.Start()
+	defer server.Close()
+
+	client := NewPterodactylClient(server.URL, "key", "", "")
+	client.SetConnectionPool(ConnectionPool{MaxIdleConnsPerHost: 1})
+
+	for i := 0; i < 3; i++ {
+		if _, err := client.GetNode(context.Background(), 1); err != nil {
+			t.Fatalf("GetNode: %v", err)
+		}
+	}
+	items, err := client.getAllWithPagination(context.Background(), "/locations", func(data json.RawMessage) (interface{}, error) {
+		return data, nil
+	})
+	if err != nil {
+		t.Fatalf("getAllWithPagination: %v", err)
+	}
+	if len(items) != 3 {
+		t.Errorf("got %d items, want 3", len(items))
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if newConns != 1 {
+		t.Errorf("opened %d connections, want 1 reused connection", newConns)
+	}
+}
+
+func TestSetConnectionPool(t *testing.T) {
+	client := NewPterodactylClient("https://panel.example.com", "key", "", "")
+	client.SetConnectionPool(ConnectionPool{MaxIdleConnsPerHost: 4})
+
+	transport, ok := client.httpClient.Transport.(*http.Transport)
+	if !ok {
+		t.Fatalf("transport = %T, want *http.Transport", client.httpClient.Transport)
+	}
+	if transport.MaxIdleConnsPerHost != 4 {
+		t.Errorf("MaxIdleConnsPerHost = %d, want 4", transport.MaxIdleConnsPerHost)
+	}
+	if transport.MaxIdleConns != DefaultConnectionPool.MaxIdleConns {
+		t.Errorf("MaxIdleConns = %d, want default %d", transport.MaxIdleConns, DefaultConnectionPool.MaxIdleConns)
+	}
+	if transport.IdleConnTimeout != DefaultConnectionPool.IdleConnTimeout {
+		t.Errorf("IdleConnTimeout = %s, want default %s", transport.IdleConnTimeout, DefaultConnectionPool.IdleConnTimeout)
+	}
+}
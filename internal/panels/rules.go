@@ -0,0 +1,96 @@
+package panels
+
+import (
+	"strconv"
+	"strings"
+)
+
+// VariableRules is the parsed form of an egg variable's Laravel-style
+// validation rules (e.g. "required|string|max:20")
+type VariableRules struct {
+	Required bool     `json:"required"`
+	Nullable bool     `json:"nullable"`
+	Type     string   `json:"type,omitempty"` // string, integer, numeric or boolean
+	Min      *float64 `json:"min,omitempty"`
+	Max      *float64 `json:"max,omitempty"`
+	In       []string `json:"in,omitempty"`
+	Regex    string   `json:"regex,omitempty"`
+	Other    []string `json:"other,omitempty"` // rules not understood here, kept verbatim
+}
+
+// ParseVariableRules parses a pipe-separated egg variable rule string.
+// Unknown rules are kept in Other rather than dropped.
+func ParseVariableRules(rules string) VariableRules {
+	var parsed VariableRules
+
+	for _, rule := range splitRules(rules) {
+		name, arg, _ := strings.Cut(rule, ":")
+		switch name {
+		case "required":
+			parsed.Required = true
+		case "nullable":
+			parsed.Nullable = true
+		case "string", "integer", "numeric", "boolean":
+			parsed.Type = name
+		case "min", "max":
+			value, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				parsed.Other = append(parsed.Other, rule)
+				continue
+			}
+			if name == "min" {
+				parsed.Min = &value
+			} else {
+				parsed.Max = &value
+			}
+		case "between":
+			lo, hi, ok := strings.Cut(arg, ",")
+			minValue, errMin := strconv.ParseFloat(lo, 64)
+			maxValue, errMax := strconv.ParseFloat(hi, 64)
+			if !ok || errMin != nil || errMax != nil {
+				parsed.Other = append(parsed.Other, rule)
+				continue
+			}
+			parsed.Min, parsed.Max = &minValue, &maxValue
+		case "in":
+			parsed.In = strings.Split(arg, ",")
+		case "regex":
+			parsed.Regex = arg
+		default:
+			parsed.Other = append(parsed.Other, rule)
+		}
+	}
+
+	return parsed
+}
+
+// splitRules splits a rule string on "|", keeping regex patterns (which may
+// themselves contain "|") intact
+func splitRules(rules string) []string {
+	var result []string
+	for rules != "" {
+		if strings.HasPrefix(rules, "regex:") {
+			// A regex pattern is delimited, e.g. regex:/^(a|b)$/
+			if end := strings.LastIndex(rules, "/"); end > len("regex:") {
+				next := strings.Index(rules[end:], "|")
+				if next == -1 {
+					result = append(result, rules)
+					break
+				}
+				result = append(result, rules[:end+next])
+				rules = rules[end+next+1:]
+				continue
+			}
+		}
+
+		rule, rest, found := strings.Cut(rules, "|")
+		if rule = strings.TrimSpace(rule); rule != "" {
+			result = append(result, rule)
+		}
+		if !found {
+			break
+		}
+		rules = rest
+	}
+	return result
+}
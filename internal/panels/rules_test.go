@@ -0,0 +1,56 @@
+package panels
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseVariableRules(t *testing.T) {
+	floatPtr := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name  string
+		rules string
+		want  VariableRules
+	}{
+		{
+			name:  "empty",
+			rules: "",
+			want:  VariableRules{},
+		},
+		{
+			name:  "required string with max",
+			rules: "required|string|max:20",
+			want:  VariableRules{Required: true, Type: "string", Max: floatPtr(20)},
+		},
+		{
+			name:  "nullable integer between",
+			rules: "nullable|integer|between:1,65535",
+			want:  VariableRules{Nullable: true, Type: "integer", Min: floatPtr(1), Max: floatPtr(65535)},
+		},
+		{
+			name:  "in list",
+			rules: "required|string|in:vanilla,paper,spigot",
+			want:  VariableRules{Required: true, Type: "string", In: []string{"vanilla", "paper", "spigot"}},
+		},
+		{
+			name:  "regex containing pipe",
+			rules: "required|regex:/^(latest|[0-9.]+)$/|max:10",
+			want:  VariableRules{Required: true, Regex: "/^(latest|[0-9.]+)$/", Max: floatPtr(10)},
+		},
+		{
+			name:  "unknown rules kept",
+			rules: "required|alpha_dash|max:abc",
+			want:  VariableRules{Required: true, Other: []string{"alpha_dash", "max:abc"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ParseVariableRules(tt.rules)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseVariableRules(%q) = %+v, want %+v", tt.rules, got, tt.want)
+			}
+		})
+	}
+}
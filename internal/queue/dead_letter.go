@@ -0,0 +1,156 @@
+package queue
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hibiken/asynq"
+)
+
+// redactedValue replaces secret payload values in dead-letter listings
+const redactedValue = "[REDACTED]"
+
+// secretKeyParts marks payload keys whose values are never shown. Keys are
+// compared lowercased with "_" and "-" removed.
+var secretKeyParts = []string{"password", "secret", "token", "apikey", "authorization", "cookie", "credential", "privatekey"}
+
+// ErrNoInspector is returned when the manager was created without an inspector
+var ErrNoInspector = errors.New("queue inspector not configured")
+
+// DeadLetterTask is an archived task that exhausted its retries
+type DeadLetterTask struct {
+	ID           string      `json:"id"`
+	Queue        string      `json:"queue"`
+	Type         string      `json:"type"`
+	Payload      interface{} `json:"payload"` // secrets redacted; nil if not JSON
+	LastError    string      `json:"lastError"`
+	LastFailedAt time.Time   `json:"lastFailedAt"`
+	Retried      int         `json:"retried"`
+	MaxRetry     int         `json:"maxRetry"`
+}
+
+// DeadLetterGroup collects the dead-letter tasks of one task type
+type DeadLetterGroup struct {
+	Type         string           `json:"type"`
+	Count        int              `json:"count"`
+	LastError    string           `json:"lastError"` // error of the most recent failure
+	LastFailedAt time.Time        `json:"lastFailedAt"`
+	Tasks        []DeadLetterTask `json:"tasks"`
+}
+
+// SetInspector sets the Asynq inspector used for task lookups
+func (m *Manager) SetInspector(inspector *asynq.Inspector) {
+	m.inspector = inspector
+}
+
+// DeadLetterTasks lists archived tasks across every queue, newest failure
+// first, reading at most limit tasks from each queue
+func (m *Manager) DeadLetterTasks(limit int) ([]DeadLetterTask, error) {
+	if m.inspector == nil {
+		return nil, ErrNoInspector
+	}
+
+	queues, err := m.inspector.Queues()
+	if err != nil {
+		return nil, err
+	}
+
+	tasks := []DeadLetterTask{}
+	for _, name := range queues {
+		infos, err := m.inspector.ListArchivedTasks(name, asynq.PageSize(limit))
+		if errors.Is(err, asynq.ErrQueueNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, info := range infos {
+			tasks = append(tasks, DeadLetterTask{
+				ID:           info.ID,
+				Queue:        info.Queue,
+				Type:         info.Type,
+				Payload:      RedactPayload(info.Payload),
+				LastError:    info.LastErr,
+				LastFailedAt: info.LastFailedAt,
+				Retried:      info.Retried,
+				MaxRetry:     info.MaxRetry,
+			})
+		}
+	}
+
+	sort.Slice(tasks, func(i, j int) bool {
+		return tasks[i].LastFailedAt.After(tasks[j].LastFailedAt)
+	})
+	return tasks, nil
+}
+
+// GroupDeadLetterTasks groups tasks by type, largest group first. Tasks are
+// expected newest failure first, as returned by DeadLetterTasks.
+func GroupDeadLetterTasks(tasks []DeadLetterTask) []DeadLetterGroup {
+	index := map[string]int{}
+	groups := []DeadLetterGroup{}
+	for _, task := range tasks {
+		i, ok := index[task.Type]
+		if !ok {
+			i = len(groups)
+			index[task.Type] = i
+			groups = append(groups, DeadLetterGroup{
+				Type:         task.Type,
+				LastError:    task.LastError,
+				LastFailedAt: task.LastFailedAt,
+			})
+		}
+		groups[i].Count++
+		groups[i].Tasks = append(groups[i].Tasks, task)
+	}
+
+	sort.SliceStable(groups, func(i, j int) bool {
+		return groups[i].Count > groups[j].Count
+	})
+	return groups
+}
+
+// RedactPayload decodes a JSON task payload and replaces the values of
+// secret-looking keys, at any depth, with a placeholder. It returns nil if
+// the payload is not JSON.
+func RedactPayload(payload []byte) interface{} {
+	var decoded interface{}
+	if err := json.Unmarshal(payload, &decoded); err != nil {
+		return nil
+	}
+	return redact(decoded)
+}
+
+// redact replaces secret values in a decoded JSON value
+func redact(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, inner := range v {
+			if isSecretKey(key) {
+				v[key] = redactedValue
+			} else {
+				v[key] = redact(inner)
+			}
+		}
+	case []interface{}:
+		for i, inner := range v {
+			v[i] = redact(inner)
+		}
+	}
+	return value
+}
+
+// isSecretKey reports whether a payload key names a secret
+func isSecretKey(key string) bool {
+	normalized := strings.NewReplacer("_", "", "-", "").Replace(strings.ToLower(key))
+	for _, part := range secretKeyParts {
+		if strings.Contains(normalized, part) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,54 @@
+package queue
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRedactPayload(t *testing.T) {
+	payload := []byte(`{"to":"a@example.com","data":{"token":"abc","name":"Ann"},"headers":[{"Authorization":"Bearer x"}],"api_key":"k"}`)
+
+	got := RedactPayload(payload)
+	want := map[string]interface{}{
+		"to": "a@example.com",
+		"data": map[string]interface{}{
+			"token": redactedValue,
+			"name":  "Ann",
+		},
+		"headers": []interface{}{
+			map[string]interface{}{"Authorization": redactedValue},
+		},
+		"api_key": redactedValue,
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("RedactPayload() = %#v, want %#v", got, want)
+	}
+
+	if got := RedactPayload([]byte("not json")); got != nil {
+		t.Errorf("RedactPayload(non-JSON) = %#v, want nil", got)
+	}
+}
+
+func TestGroupDeadLetterTasks(t *testing.T) {
+	now := time.Now()
+	tasks := []DeadLetterTask{
+		{ID: "1", Type: TypeWebhookDiscord, LastError: "timeout", LastFailedAt: now},
+		{ID: "2", Type: TypeEmailSend, LastError: "template: missing key", LastFailedAt: now.Add(-time.Minute)},
+		{ID: "3", Type: TypeEmailSend, LastError: "older", LastFailedAt: now.Add(-time.Hour)},
+	}
+
+	groups := GroupDeadLetterTasks(tasks)
+	if len(groups) != 2 {
+		t.Fatalf("got %d groups, want 2", len(groups))
+	}
+	if groups[0].Type != TypeEmailSend || groups[0].Count != 2 {
+		t.Errorf("first group = %s (%d), want %s (2)", groups[0].Type, groups[0].Count, TypeEmailSend)
+	}
+	if groups[0].LastError != "template: missing key" {
+		t.Errorf("first group last error = %q, want the most recent failure", groups[0].LastError)
+	}
+	if groups[1].Type != TypeWebhookDiscord || groups[1].Count != 1 {
+		t.Errorf("second group = %s (%d), want %s (1)", groups[1].Type, groups[1].Count, TypeWebhookDiscord)
+	}
+}
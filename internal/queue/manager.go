@@ -2,6 +2,10 @@ package queue
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/hibiken/asynq"
@@ -18,40 +22,123 @@ const (
 	TypeSyncDatabases   = "sync:databases"
 	TypeSyncUsers       = "sync:users"
 
+	TypeSyncServerStates = "sync:server_states"
+	// TypeSyncBatch runs a chosen list of sync steps, in order, under one sync log
+	TypeSyncBatch = "sync:batch"
+	// TypeSyncUser refreshes a single user from the panel
+	TypeSyncUser = "sync:user"
+
 	TypeEmailSend = "email:send"
 	TypeEmailBulk = "email:bulk"
 
 	TypeWebhookDiscord = "webhook:discord"
 	TypeWebhookSlack   = "webhook:slack"
+	// TypeWebhookSyncResult re-sends a finished sync's result notification
+	TypeWebhookSyncResult = "webhook:sync_result"
 
 	TypeCleanupLogs = "cleanup:logs"
+
+	// TypeHytalePushTokens re-pushes Hytale session tokens to every linked server
+	TypeHytalePushTokens = "hytale:push_tokens"
 )
 
 // Queue names (for priority)
 const (
-	QueueCritical = "critical" // High priority (sync cancellations, urgent notifications)
-	QueueDefault  = "default"  // Normal priority (most sync operations)
-	QueueLow      = "low"      // Low priority (cleanup, non-urgent tasks)
+	QueueCritical = "critical" // High priority (transactional emails, webhooks)
+	QueueDefault  = "default"  // Normal priority
+	QueueLow      = "low"      // Low priority (syncs, cleanup, non-urgent tasks)
 )
 
+// Queues lists every queue the worker server processes, highest priority first
+var Queues = []string{QueueCritical, QueueDefault, QueueLow}
+
+// ValidQueue reports whether name is one of the worker server's queues
+func ValidQueue(name string) bool {
+	for _, q := range Queues {
+		if q == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Routing maps task groups to the queue they are enqueued on
+type Routing struct {
+	Email   string // email:* tasks
+	Webhook string // webhook:* tasks
+	Sync    string // sync:* tasks
+}
+
+// DefaultRouting keeps transactional emails and webhooks ahead of panel
+// syncs, so a long full sync never delays a password-reset email
+var DefaultRouting = Routing{
+	Email:   QueueCritical,
+	Webhook: QueueCritical,
+	Sync:    QueueLow,
+}
+
+// withDefaults replaces unknown or empty queue names with DefaultRouting's
+func (r Routing) withDefaults() Routing {
+	if !ValidQueue(r.Email) {
+		r.Email = DefaultRouting.Email
+	}
+	if !ValidQueue(r.Webhook) {
+		r.Webhook = DefaultRouting.Webhook
+	}
+	if !ValidQueue(r.Sync) {
+		r.Sync = DefaultRouting.Sync
+	}
+	return r
+}
+
 // Manager handles task enqueueing
 type Manager struct {
-	client *asynq.Client
+	client    *asynq.Client
+	inspector *asynq.Inspector
+	routing   Routing
+	options   TaskOptionSet
 }
 
-// Close shuts down the underlying Asynq client, releasing any open
+// Close shuts down the underlying Asynq client and inspector, releasing any open
 // Redis connections. It is safe to call multiple times and will noop if
 // the manager or client is nil.
 func (m *Manager) Close() error {
 	if m == nil || m.client == nil {
 		return nil
 	}
+	if m.inspector != nil {
+		_ = m.inspector.Close()
+	}
 	return m.client.Close()
 }
 
-// NewManager creates a new queue manager
+// NewManager creates a new queue manager using DefaultRouting
 func NewManager(client *asynq.Client) *Manager {
-	return &Manager{client: client}
+	return NewManagerWithRouting(client, DefaultRouting)
+}
+
+// NewManagerWithRouting creates a queue manager that enqueues each task group
+// on the queue given by routing. Unknown queue names fall back to DefaultRouting.
+func NewManagerWithRouting(client *asynq.Client, routing Routing) *Manager {
+	return &Manager{client: client, routing: routing.withDefaults()}
+}
+
+// SetTaskOptions overrides the retry, timeout, deadline and retention options
+// per task type. Task types missing from opts use DefaultTaskOptions.
+func (m *Manager) SetTaskOptions(opts TaskOptionSet) {
+	m.options = opts
+}
+
+// taskOptions returns the Asynq options for a task of taskType on queueName,
+// followed by extra
+func (m *Manager) taskOptions(taskType, queueName string, extra ...asynq.Option) []asynq.Option {
+	opts := append([]asynq.Option{asynq.Queue(queueName)}, m.options.For(taskType).options(time.Now())...)
+	return append(opts, extra...)
+}
+
+// Routing returns the queue each task group is enqueued on
+func (m *Manager) Routing() Routing {
+	return m.routing
 }
 
 // Client returns the underlying Asynq client for direct task enqueueing
@@ -59,11 +146,56 @@ func (m *Manager) Client() *asynq.Client {
 	return m.client
 }
 
+// FullSyncSteps lists the steps of a full sync in execution order. The
+// failed_step recorded on a failed sync is one of these names.
+var FullSyncSteps = []string{"locations", "nodes", "allocations", "nests", "users", "servers", "subusers"}
+
+// FullSyncStepIndex returns the position of step in FullSyncSteps, or -1 if
+// it is not a full sync step
+func FullSyncStepIndex(step string) int {
+	for i, s := range FullSyncSteps {
+		if s == step {
+			return i
+		}
+	}
+	return -1
+}
+
 // SyncFullPayload contains data for a full sync task
 type SyncFullPayload struct {
 	SyncLogID   string `json:"sync_log_id"`
 	RequestedBy string `json:"requested_by,omitempty"`
 	SkipUsers   bool   `json:"skip_users,omitempty"`
+	StartStep   string `json:"start_step,omitempty"` // Skip steps before this one (resume)
+}
+
+// BatchSyncSteps lists the sync types a batch sync can chain
+var BatchSyncSteps = []string{"locations", "nodes", "allocations", "nests", "users", "servers", "databases", "server_states"}
+
+// ValidateBatchSteps checks that steps is a non-empty list of distinct
+// BatchSyncSteps
+func ValidateBatchSteps(steps []string) error {
+	if len(steps) == 0 {
+		return errors.New("at least one sync type is required")
+	}
+	seen := make(map[string]bool, len(steps))
+	for _, step := range steps {
+		if !slices.Contains(BatchSyncSteps, step) {
+			return fmt.Errorf("invalid sync type %q; valid types: %s", step, strings.Join(BatchSyncSteps, ", "))
+		}
+		if seen[step] {
+			return fmt.Errorf("sync type %q is listed more than once", step)
+		}
+		seen[step] = true
+	}
+	return nil
+}
+
+// SyncBatchPayload contains data for a batch sync task
+type SyncBatchPayload struct {
+	SyncLogID   string   `json:"sync_log_id"`
+	RequestedBy string   `json:"requested_by,omitempty"`
+	Steps       []string `json:"steps"` // run in this order, each after the previous completes
 }
 
 // SyncPayload contains data for individual sync tasks
@@ -101,12 +233,26 @@ type SyncUsersPayload struct {
 	SyncLogID string `json:"sync_log_id"`
 }
 
+// UserResyncPayload identifies the local user refreshed by a sync:user task
+type UserResyncPayload struct {
+	UserID        string `json:"user_id"`
+	PterodactylID int    `json:"pterodactyl_id"`
+}
+
+// HytalePushTokensPayload records who requested a Hytale token push, for the audit log
+type HytalePushTokensPayload struct {
+	ActorID   string `json:"actor_id"`
+	IPAddress string `json:"ip_address,omitempty"`
+	UserAgent string `json:"user_agent,omitempty"`
+}
+
 // EmailPayload contains data for sending an email
 type EmailPayload struct {
 	To       string            `json:"to"`
 	Subject  string            `json:"subject"`
 	Template string            `json:"template"`
 	Data     map[string]string `json:"data,omitempty"`
+	Category string            `json:"category,omitempty"` // notification category; defaults to the template's
 }
 
 // WebhookPayload contains data for sending a webhook
@@ -116,6 +262,12 @@ type WebhookPayload struct {
 	Data      map[string]interface{} `json:"data"`
 }
 
+// SyncResultWebhookPayload identifies the sync whose result is sent to a webhook
+type SyncResultWebhookPayload struct {
+	SyncLogID string `json:"sync_log_id"`
+	WebhookID string `json:"webhook_id"`
+}
+
 // EnqueueSyncFull enqueues a full sync task
 func (m *Manager) EnqueueSyncFull(payload SyncFullPayload) (*asynq.TaskInfo, error) {
 	data, err := json.Marshal(payload)
@@ -124,15 +276,24 @@ func (m *Manager) EnqueueSyncFull(payload SyncFullPayload) (*asynq.TaskInfo, err
 	}
 
 	task := asynq.NewTask(TypeSyncFull, data,
-		asynq.Queue(QueueDefault),
-		asynq.MaxRetry(3),
-		asynq.Timeout(30*time.Minute),
-		asynq.Unique(10*time.Minute), // Prevent duplicate syncs
+		m.taskOptions(TypeSyncFull, m.routing.Sync, asynq.Unique(10*time.Minute))..., // Prevent duplicate syncs
 	)
 
 	return m.client.Enqueue(task)
 }
 
+// EnqueueSyncBatch enqueues a batch sync task
+func (m *Manager) EnqueueSyncBatch(payload SyncBatchPayload) (*asynq.TaskInfo, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	task := asynq.NewTask(TypeSyncBatch, data, m.taskOptions(TypeSyncBatch, m.routing.Sync)...)
+
+	return m.client.Enqueue(task)
+}
+
 // EnqueueSyncLocations enqueues a locations sync task
 func (m *Manager) EnqueueSyncLocations(payload SyncPayload) (*asynq.TaskInfo, error) {
 	data, err := json.Marshal(payload)
@@ -140,11 +301,7 @@ func (m *Manager) EnqueueSyncLocations(payload SyncPayload) (*asynq.TaskInfo, er
 		return nil, err
 	}
 
-	task := asynq.NewTask(TypeSyncLocations, data,
-		asynq.Queue(QueueDefault),
-		asynq.MaxRetry(3),
-		asynq.Timeout(5*time.Minute),
-	)
+	task := asynq.NewTask(TypeSyncLocations, data, m.taskOptions(TypeSyncLocations, m.routing.Sync)...)
 
 	return m.client.Enqueue(task)
 }
@@ -156,11 +313,7 @@ func (m *Manager) EnqueueSyncNodes(payload SyncPayload) (*asynq.TaskInfo, error)
 		return nil, err
 	}
 
-	task := asynq.NewTask(TypeSyncNodes, data,
-		asynq.Queue(QueueDefault),
-		asynq.MaxRetry(3),
-		asynq.Timeout(5*time.Minute),
-	)
+	task := asynq.NewTask(TypeSyncNodes, data, m.taskOptions(TypeSyncNodes, m.routing.Sync)...)
 
 	return m.client.Enqueue(task)
 }
@@ -172,11 +325,7 @@ func (m *Manager) EnqueueSyncServers(payload SyncPayload) (*asynq.TaskInfo, erro
 		return nil, err
 	}
 
-	task := asynq.NewTask(TypeSyncServers, data,
-		asynq.Queue(QueueDefault),
-		asynq.MaxRetry(3),
-		asynq.Timeout(15*time.Minute), // Servers can take longer
-	)
+	task := asynq.NewTask(TypeSyncServers, data, m.taskOptions(TypeSyncServers, m.routing.Sync)...)
 
 	return m.client.Enqueue(task)
 }
@@ -188,11 +337,7 @@ func (m *Manager) EnqueueSyncUsers(payload SyncPayload) (*asynq.TaskInfo, error)
 		return nil, err
 	}
 
-	task := asynq.NewTask(TypeSyncUsers, data,
-		asynq.Queue(QueueDefault),
-		asynq.MaxRetry(3),
-		asynq.Timeout(15*time.Minute),
-	)
+	task := asynq.NewTask(TypeSyncUsers, data, m.taskOptions(TypeSyncUsers, m.routing.Sync)...)
 
 	return m.client.Enqueue(task)
 }
@@ -203,11 +348,7 @@ func (m *Manager) EnqueueSyncAllocations(payload SyncPayload) (*asynq.TaskInfo,
 	if err != nil {
 		return nil, err
 	}
-	task := asynq.NewTask(TypeSyncAllocations, data,
-		asynq.Queue(QueueDefault),
-		asynq.MaxRetry(3),
-		asynq.Timeout(10*time.Minute),
-	)
+	task := asynq.NewTask(TypeSyncAllocations, data, m.taskOptions(TypeSyncAllocations, m.routing.Sync)...)
 	return m.client.Enqueue(task)
 }
 
@@ -217,11 +358,7 @@ func (m *Manager) EnqueueSyncNests(payload SyncPayload) (*asynq.TaskInfo, error)
 	if err != nil {
 		return nil, err
 	}
-	task := asynq.NewTask(TypeSyncNests, data,
-		asynq.Queue(QueueDefault),
-		asynq.MaxRetry(3),
-		asynq.Timeout(5*time.Minute),
-	)
+	task := asynq.NewTask(TypeSyncNests, data, m.taskOptions(TypeSyncNests, m.routing.Sync)...)
 	return m.client.Enqueue(task)
 }
 
@@ -231,14 +368,35 @@ func (m *Manager) EnqueueSyncDatabases(payload SyncPayload) (*asynq.TaskInfo, er
 	if err != nil {
 		return nil, err
 	}
-	task := asynq.NewTask(TypeSyncDatabases, data,
-		asynq.Queue(QueueDefault),
-		asynq.MaxRetry(3),
-		asynq.Timeout(15*time.Minute),
+	task := asynq.NewTask(TypeSyncDatabases, data, m.taskOptions(TypeSyncDatabases, m.routing.Sync)...)
+	return m.client.Enqueue(task)
+}
+
+// EnqueueSyncServerStates enqueues a server power state refresh. Identical
+// payloads are deduplicated so scheduled refreshes never pile up.
+func (m *Manager) EnqueueSyncServerStates(payload SyncPayload) (*asynq.TaskInfo, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+	task := asynq.NewTask(TypeSyncServerStates, data,
+		m.taskOptions(TypeSyncServerStates, m.routing.Sync, asynq.Unique(10*time.Minute))...,
 	)
 	return m.client.Enqueue(task)
 }
 
+// EnqueueUserResync enqueues a single-user resync from the panel
+func (m *Manager) EnqueueUserResync(payload UserResyncPayload) (*asynq.TaskInfo, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	task := asynq.NewTask(TypeSyncUser, data, m.taskOptions(TypeSyncUser, m.routing.Sync)...)
+
+	return m.client.Enqueue(task)
+}
+
 // EnqueueEmail enqueues an email send task
 func (m *Manager) EnqueueEmail(payload EmailPayload) (*asynq.TaskInfo, error) {
 	data, err := json.Marshal(payload)
@@ -246,11 +404,7 @@ func (m *Manager) EnqueueEmail(payload EmailPayload) (*asynq.TaskInfo, error) {
 		return nil, err
 	}
 
-	task := asynq.NewTask(TypeEmailSend, data,
-		asynq.Queue(QueueDefault),
-		asynq.MaxRetry(5),
-		asynq.Timeout(30*time.Second),
-	)
+	task := asynq.NewTask(TypeEmailSend, data, m.taskOptions(TypeEmailSend, m.routing.Email)...)
 
 	return m.client.Enqueue(task)
 }
@@ -262,11 +416,19 @@ func (m *Manager) EnqueueWebhook(payload WebhookPayload) (*asynq.TaskInfo, error
 		return nil, err
 	}
 
-	task := asynq.NewTask(TypeWebhookDiscord, data,
-		asynq.Queue(QueueDefault),
-		asynq.MaxRetry(3),
-		asynq.Timeout(10*time.Second),
-	)
+	task := asynq.NewTask(TypeWebhookDiscord, data, m.taskOptions(TypeWebhookDiscord, m.routing.Webhook)...)
+
+	return m.client.Enqueue(task)
+}
+
+// EnqueueSyncResultWebhook enqueues a sync result notification for one webhook
+func (m *Manager) EnqueueSyncResultWebhook(payload SyncResultWebhookPayload) (*asynq.TaskInfo, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	task := asynq.NewTask(TypeWebhookSyncResult, data, m.taskOptions(TypeWebhookSyncResult, m.routing.Webhook)...)
 
 	return m.client.Enqueue(task)
 }
@@ -275,10 +437,22 @@ func (m *Manager) EnqueueWebhook(payload WebhookPayload) (*asynq.TaskInfo, error
 func (m *Manager) EnqueueCleanupLogs(olderThanDays int) (*asynq.TaskInfo, error) {
 	data, _ := json.Marshal(map[string]int{"older_than_days": olderThanDays})
 
-	task := asynq.NewTask(TypeCleanupLogs, data,
-		asynq.Queue(QueueLow),
-		asynq.MaxRetry(1),
-		asynq.Timeout(5*time.Minute),
+	task := asynq.NewTask(TypeCleanupLogs, data, m.taskOptions(TypeCleanupLogs, QueueLow)...)
+
+	return m.client.Enqueue(task)
+}
+
+// EnqueueHytaleTokenPush enqueues a token push to every linked Hytale server.
+// Only one push may be queued or running at a time; a second request fails
+// with asynq.ErrDuplicateTask.
+func (m *Manager) EnqueueHytaleTokenPush(payload HytalePushTokensPayload) (*asynq.TaskInfo, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	task := asynq.NewTask(TypeHytalePushTokens, data,
+		m.taskOptions(TypeHytalePushTokens, QueueDefault, asynq.Unique(30*time.Minute))...,
 	)
 
 	return m.client.Enqueue(task)
@@ -286,8 +460,8 @@ func (m *Manager) EnqueueCleanupLogs(olderThanDays int) (*asynq.TaskInfo, error)
 
 // GetTaskInfo returns information about a specific task
 func (m *Manager) GetTaskInfo(queueName, taskID string) (*asynq.TaskInfo, error) {
-	inspector := asynq.NewInspector(asynq.RedisClientOpt{
-		Addr: "localhost:6379", // TODO: Get from config
-	})
-	return inspector.GetTaskInfo(queueName, taskID)
+	if m.inspector == nil {
+		return nil, ErrNoInspector
+	}
+	return m.inspector.GetTaskInfo(queueName, taskID)
 }
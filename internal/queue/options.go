@@ -0,0 +1,150 @@
+package queue
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hibiken/asynq"
+)
+
+// maxRetryDelay caps the exponential backoff between retries
+const maxRetryDelay = 24 * time.Hour
+
+// TaskOptions controls how a task type is run, retried and kept
+type TaskOptions struct {
+	MaxRetry  int           // retries after the first attempt (0 disables retries)
+	Timeout   time.Duration // limit for a single attempt
+	Deadline  time.Duration // time after enqueueing past which the task is no longer run (0 disables)
+	Retention time.Duration // how long a completed task is kept for inspection (0 deletes it)
+	Backoff   time.Duration // delay before the first retry, doubled for each later one (0 uses the Asynq default)
+}
+
+// DefaultTaskOptions holds the options used for task types without an override.
+// Emails retry five times over roughly an hour; a full sync is never retried
+// automatically since it is expensive and can be resumed from its failed step.
+var DefaultTaskOptions = TaskOptionSet{
+	TypeSyncFull:          {MaxRetry: 0, Timeout: 30 * time.Minute},
+	TypeSyncLocations:     {MaxRetry: 3, Timeout: 5 * time.Minute},
+	TypeSyncNodes:         {MaxRetry: 3, Timeout: 5 * time.Minute},
+	TypeSyncAllocations:   {MaxRetry: 3, Timeout: 10 * time.Minute},
+	TypeSyncNests:         {MaxRetry: 3, Timeout: 5 * time.Minute},
+	TypeSyncServers:       {MaxRetry: 3, Timeout: 15 * time.Minute}, // Servers can take longer
+	TypeSyncDatabases:     {MaxRetry: 3, Timeout: 15 * time.Minute},
+	TypeSyncUsers:         {MaxRetry: 3, Timeout: 15 * time.Minute},
+	TypeSyncServerStates:  {MaxRetry: 1, Timeout: 10 * time.Minute},
+	TypeSyncBatch:         {MaxRetry: 0, Timeout: 30 * time.Minute},
+	TypeSyncUser:          {MaxRetry: 3, Timeout: 2 * time.Minute},
+	TypeEmailSend:         {MaxRetry: 5, Timeout: 30 * time.Second, Backoff: 2 * time.Minute},
+	TypeWebhookDiscord:    {MaxRetry: 3, Timeout: 10 * time.Second},
+	TypeWebhookSyncResult: {MaxRetry: 5, Timeout: 10 * time.Second}, // ride out short Discord outages
+	TypeCleanupLogs:       {MaxRetry: 1, Timeout: 5 * time.Minute},
+	TypeHytalePushTokens:  {MaxRetry: 0, Timeout: 30 * time.Minute}, // a retry would re-push servers that already succeeded
+}
+
+// TaskOptionSet maps task types to their options
+type TaskOptionSet map[string]TaskOptions
+
+// For returns the options for taskType, falling back to DefaultTaskOptions
+func (s TaskOptionSet) For(taskType string) TaskOptions {
+	if opts, ok := s[taskType]; ok {
+		return opts
+	}
+	return DefaultTaskOptions[taskType]
+}
+
+// RetryDelay is an asynq.RetryDelayFunc that applies each task type's backoff.
+// n is the number of times the task has already been retried.
+func (s TaskOptionSet) RetryDelay(n int, err error, task *asynq.Task) time.Duration {
+	backoff := s.For(task.Type()).Backoff
+	if backoff <= 0 {
+		return asynq.DefaultRetryDelayFunc(n, err, task)
+	}
+
+	delay := backoff
+	for i := 0; i < n && delay < maxRetryDelay; i++ {
+		delay *= 2
+	}
+	if delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+	return delay
+}
+
+// options converts o into Asynq enqueue options
+func (o TaskOptions) options(now time.Time) []asynq.Option {
+	opts := []asynq.Option{asynq.MaxRetry(o.MaxRetry)}
+	if o.Timeout > 0 {
+		opts = append(opts, asynq.Timeout(o.Timeout))
+	}
+	if o.Deadline > 0 {
+		opts = append(opts, asynq.Deadline(now.Add(o.Deadline)))
+	}
+	if o.Retention > 0 {
+		opts = append(opts, asynq.Retention(o.Retention))
+	}
+	return opts
+}
+
+// ParseTaskOptions parses per-task overrides written as comma-separated
+// "<task type>.<field>=<value>" pairs, e.g.
+// "email:send.max_retry=8,sync:full.retention=24h". Fields are max_retry,
+// timeout, deadline, retention and backoff; durations use Go syntax. The
+// result holds every known task type with the overrides applied.
+func ParseTaskOptions(spec string) (TaskOptionSet, error) {
+	set := make(TaskOptionSet, len(DefaultTaskOptions))
+	for taskType, opts := range DefaultTaskOptions {
+		set[taskType] = opts
+	}
+
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		key, value, ok := strings.Cut(entry, "=")
+		dot := strings.LastIndex(key, ".")
+		if !ok || dot < 0 {
+			return nil, fmt.Errorf("invalid task option %q, expected <task type>.<field>=<value>", entry)
+		}
+		taskType, field := strings.TrimSpace(key[:dot]), strings.TrimSpace(key[dot+1:])
+		value = strings.TrimSpace(value)
+
+		opts, known := set[taskType]
+		if !known {
+			return nil, fmt.Errorf("unknown task type %q", taskType)
+		}
+
+		if field == "max_retry" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				return nil, fmt.Errorf("%s.max_retry must be a non-negative integer", taskType)
+			}
+			opts.MaxRetry = n
+			set[taskType] = opts
+			continue
+		}
+
+		d, err := time.ParseDuration(value)
+		if err != nil || d < 0 {
+			return nil, fmt.Errorf("%s.%s must be a non-negative duration", taskType, field)
+		}
+		switch field {
+		case "timeout":
+			opts.Timeout = d
+		case "deadline":
+			opts.Deadline = d
+		case "retention":
+			opts.Retention = d
+		case "backoff":
+			opts.Backoff = d
+		default:
+			return nil, fmt.Errorf("unknown task option field %q", field)
+		}
+		set[taskType] = opts
+	}
+
+	return set, nil
+}
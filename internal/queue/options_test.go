@@ -0,0 +1,110 @@
+package queue
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hibiken/asynq"
+)
+
+func TestParseTaskOptions(t *testing.T) {
+	tests := []struct {
+		spec      string
+		expectErr bool
+	}{
+		{spec: "", expectErr: false},
+		{spec: "email:send.max_retry=8", expectErr: false},
+		{spec: "email:send.backoff=5m, sync:full.retention=24h,sync:full.deadline=1h", expectErr: false},
+		{spec: "email:send.max_retry=-1", expectErr: true},
+		{spec: "email:send.timeout=soon", expectErr: true},
+		{spec: "email:send.priority=1", expectErr: true},
+		{spec: "sms:send.max_retry=1", expectErr: true},
+		{spec: "max_retry=1", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			_, err := ParseTaskOptions(tt.spec)
+			if tt.expectErr && err == nil {
+				t.Errorf("expected error for %q but got none", tt.spec)
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("unexpected error for %q: %v", tt.spec, err)
+			}
+		})
+	}
+}
+
+func TestParseTaskOptionsOverrides(t *testing.T) {
+	set, err := ParseTaskOptions("email:send.max_retry=8,email:send.retention=24h")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	email := set.For(TypeEmailSend)
+	want := DefaultTaskOptions[TypeEmailSend]
+	want.MaxRetry = 8
+	want.Retention = 24 * time.Hour
+	if email != want {
+		t.Errorf("email options = %+v, want %+v", email, want)
+	}
+
+	if got := set.For(TypeSyncFull); got != DefaultTaskOptions[TypeSyncFull] {
+		t.Errorf("sync:full options = %+v, want defaults", got)
+	}
+}
+
+func TestRetryDelay(t *testing.T) {
+	set := TaskOptionSet{
+		TypeEmailSend: {MaxRetry: 5, Backoff: 2 * time.Minute},
+		TypeSyncNodes: {MaxRetry: 3, Backoff: 12 * time.Hour},
+	}
+	email := asynq.NewTask(TypeEmailSend, nil)
+	nodes := asynq.NewTask(TypeSyncNodes, nil)
+
+	tests := []struct {
+		name string
+		task *asynq.Task
+		n    int
+		want time.Duration
+	}{
+		{name: "first retry", task: email, n: 0, want: 2 * time.Minute},
+		{name: "doubles", task: email, n: 3, want: 16 * time.Minute},
+		{name: "capped", task: nodes, n: 2, want: maxRetryDelay},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := set.RetryDelay(tt.n, nil, tt.task); got != tt.want {
+				t.Errorf("RetryDelay(%d) = %s, want %s", tt.n, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateBatchSteps(t *testing.T) {
+	tests := []struct {
+		name      string
+		steps     []string
+		expectErr bool
+	}{
+		{name: "empty", steps: nil, expectErr: true},
+		{name: "single", steps: []string{"servers"}, expectErr: false},
+		{name: "ordered chain", steps: []string{"users", "servers", "server_states"}, expectErr: false},
+		{name: "unknown type", steps: []string{"nodes", "eggs"}, expectErr: true},
+		{name: "full is not a step", steps: []string{"full"}, expectErr: true},
+		{name: "repeated type", steps: []string{"nodes", "servers", "nodes"}, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateBatchSteps(tt.steps)
+			if tt.expectErr && err == nil {
+				t.Errorf("expected error for %v but got none", tt.steps)
+			}
+			if !tt.expectErr && err != nil {
+				t.Errorf("unexpected error for %v: %v", tt.steps, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,68 @@
+package sentry
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+
+	"github.com/getsentry/sentry-go"
+	"github.com/gofiber/fiber/v2"
+	"github.com/rs/zerolog/log"
+)
+
+// RequestIDLocal is the fiber local the requestid middleware stores the
+// request ID under
+const RequestIDLocal = "requestid"
+
+// Recover returns a handler that turns a panic in a later handler into a 500
+// response carrying the request ID, so users can quote it to support, and
+// reports it to Sentry with the request method, path, request ID and
+// authenticated user ID. It must run after the requestid middleware and the
+// Sentry handler, when enabled.
+func Recover() fiber.Handler {
+	return func(c *fiber.Ctx) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				err = recovered(c, r)
+			}
+		}()
+		return c.Next()
+	}
+}
+
+// recovered reports a recovered panic and writes the 500 response
+func recovered(c *fiber.Ctx, r interface{}) error {
+	requestID, _ := c.Locals(RequestIDLocal).(string)
+	userID, _ := c.Locals("userID").(string)
+
+	log.Error().
+		Str("request_id", requestID).
+		Str("user_id", userID).
+		Str("method", c.Method()).
+		Str("path", c.Path()).
+		Str("panic", fmt.Sprint(r)).
+		Bytes("stack", debug.Stack()).
+		Msg("Recovered from panic in request handler")
+
+	hub := GetHubFromContext(c)
+	if hub == nil {
+		hub = sentry.CurrentHub().Clone()
+	}
+	hub.WithScope(func(scope *sentry.Scope) {
+		scope.SetTag("request_id", requestID)
+		scope.SetTag("http_method", c.Method())
+		scope.SetTag("route", c.Route().Path)
+		scope.SetExtra("request_path", c.Path())
+		if userID != "" {
+			scope.SetUser(sentry.User{ID: userID})
+		}
+		hub.RecoverWithContext(context.WithValue(context.Background(), sentry.RequestContextKey, c), r)
+	})
+
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"success":   false,
+		"error":     "Internal server error",
+		"code":      "INTERNAL_ERROR",
+		"requestId": requestID,
+	})
+}
@@ -0,0 +1,106 @@
+package sentry
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/getsentry/sentry-go"
+	sentryfiber "github.com/getsentry/sentry-go/fiber"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/requestid"
+)
+
+// recordingTransport keeps the events a client sends
+type recordingTransport struct {
+	mu     sync.Mutex
+	events []*sentry.Event
+}
+
+func (t *recordingTransport) Configure(sentry.ClientOptions)            {}
+func (t *recordingTransport) Flush(time.Duration) bool                  { return true }
+func (t *recordingTransport) FlushWithContext(ctx context.Context) bool { return true }
+func (t *recordingTransport) Close()                                    {}
+func (t *recordingTransport) SendEvent(event *sentry.Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.events = append(t.events, event)
+}
+
+func TestRecoverReportsPanic(t *testing.T) {
+	transport := &recordingTransport{}
+	client, err := sentry.NewClient(sentry.ClientOptions{Transport: transport})
+	if err != nil {
+		t.Fatalf("failed to create client: %v", err)
+	}
+	hub := sentry.NewHub(client, sentry.NewScope())
+
+	app := fiber.New()
+	app.Use(requestid.New())
+	app.Use(func(c *fiber.Ctx) error {
+		sentryfiber.SetHubOnContext(c, hub)
+		return c.Next()
+	})
+	app.Use(Recover())
+	app.Get("/servers/:id", func(c *fiber.Ctx) error {
+		c.Locals("userID", "user-1")
+		panic("boom")
+	})
+
+	req := httptest.NewRequest("GET", "/servers/42", nil)
+	req.Header.Set(fiber.HeaderXRequestID, "req-123")
+	resp, err := app.Test(req)
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != fiber.StatusInternalServerError {
+		t.Errorf("status = %d, want 500", resp.StatusCode)
+	}
+	var body struct {
+		Success   bool   `json:"success"`
+		RequestID string `json:"requestId"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("failed to decode body: %v", err)
+	}
+	if body.Success || body.RequestID != "req-123" {
+		t.Errorf("body = %+v, want failure with request ID req-123", body)
+	}
+
+	transport.mu.Lock()
+	defer transport.mu.Unlock()
+	if len(transport.events) != 1 {
+		t.Fatalf("captured %d events, want 1", len(transport.events))
+	}
+	event := transport.events[0]
+	if event.Tags["request_id"] != "req-123" || event.Tags["http_method"] != "GET" || event.Tags["route"] != "/servers/:id" {
+		t.Errorf("tags = %v", event.Tags)
+	}
+	if event.Extra["request_path"] != "/servers/42" {
+		t.Errorf("request_path = %v, want /servers/42", event.Extra["request_path"])
+	}
+	if event.User.ID != "user-1" {
+		t.Errorf("user ID = %q, want user-1", event.User.ID)
+	}
+}
+
+func TestRecoverPassesThrough(t *testing.T) {
+	app := fiber.New()
+	app.Use(Recover())
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	resp, err := app.Test(httptest.NewRequest("GET", "/", nil))
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if resp.StatusCode != fiber.StatusNoContent {
+		t.Errorf("status = %d, want 204", resp.StatusCode)
+	}
+}
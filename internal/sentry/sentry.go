@@ -11,7 +11,9 @@ import (
 	"github.com/rs/zerolog/log"
 )
 
-func InitSentry(dsn string, environment string, release string) (fiber.Handler, error) {
+// InitSentry initializes Sentry, tagging events with environment and release
+// and sampling tracesSampleRate (0-1) of transactions
+func InitSentry(dsn string, environment string, release string, tracesSampleRate float64) (fiber.Handler, error) {
 	if dsn == "" {
 		log.Warn().Msg("Sentry DSN not configured; error tracking disabled")
 		return nil, nil
@@ -21,7 +23,7 @@ func InitSentry(dsn string, environment string, release string) (fiber.Handler,
 		Dsn:              dsn,
 		Environment:      environment,
 		Release:          release,
-		TracesSampleRate: 0.1,
+		TracesSampleRate: tracesSampleRate,
 		EnableLogs:       true,
 		BeforeSend: func(event *sentry.Event, hint *sentry.EventHint) *sentry.Event {
 			if event.Level == sentry.LevelError || event.Level == sentry.LevelFatal {
@@ -33,7 +35,7 @@ func InitSentry(dsn string, environment string, release string) (fiber.Handler,
 			return event
 		},
 		TracesSampler: func(ctx sentry.SamplingContext) float64 {
-			return 0.1
+			return tracesSampleRate
 		},
 	})
 
@@ -41,7 +43,11 @@ func InitSentry(dsn string, environment string, release string) (fiber.Handler,
 		return nil, fmt.Errorf("failed to initialize Sentry: %w", err)
 	}
 
-	log.Info().Str("environment", environment).Msg("Sentry initialized for error tracking")
+	log.Info().
+		Str("environment", environment).
+		Str("release", release).
+		Float64("traces_sample_rate", tracesSampleRate).
+		Msg("Sentry initialized for error tracking")
 	handler := sentryfiber.New(sentryfiber.Options{
 		Repanic:         true,
 		WaitForDelivery: false,
@@ -26,6 +26,8 @@ type ServerLog struct {
 	ID int64 `json:"id" example:"1"`
 	// Log line content
 	Line string `json:"line" example:"[10:30:45] Server started successfully"`
+	// Log level (TRACE, DEBUG, INFO, WARN, ERROR, FATAL)
+	Level string `json:"level" example:"INFO"`
 	// When the log was created
 	Timestamp time.Time `json:"timestamp" example:"2026-01-14T10:30:45Z"`
 }
@@ -33,7 +35,11 @@ type ServerLog struct {
 // LogEntry represents a log line in a creation request
 type LogEntry struct {
 	// Log line content
-	Line string `json:"line" example:"[10:30:45] Server started successfully"`
+	Line string `json:"line,omitempty" example:"[10:30:45] Server started successfully"`
+	// Log line content; alternative to line
+	Message string `json:"message,omitempty" example:"Server started successfully"`
+	// Optional log level (defaults to INFO; Java names such as SEVERE and WARNING are accepted)
+	Level string `json:"level,omitempty" example:"INFO"`
 	// Optional timestamp (defaults to now)
 	Timestamp time.Time `json:"timestamp,omitempty" example:"2026-01-14T10:30:45Z"`
 }
@@ -47,3 +53,11 @@ type CreateServerLogsRequest struct {
 	// Array of log lines to store
 	Logs []LogEntry `json:"logs"`
 }
+
+// IngestServerLogsRequest represents a batch of log lines shipped by a server
+type IngestServerLogsRequest struct {
+	// Optional Hytale account ID (defaults to the account linked to the server)
+	AccountID string `json:"account_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440000"`
+	// Log lines to store, at most 1000 per request
+	Logs []LogEntry `json:"logs"`
+}
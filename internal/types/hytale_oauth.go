@@ -36,6 +36,8 @@ type TokenResponseDTO struct {
 	Scope            string `json:"scope,omitempty" example:"openid offline auth:server"`
 	Error            string `json:"error,omitempty" example:"authorization_pending"`
 	ErrorDescription string `json:"error_description,omitempty" example:"The user has not yet completed the authorization process"`
+	// Minimum seconds between polls; returned with authorization_pending and slow_down
+	Interval int `json:"interval,omitempty" example:"5"`
 }
 
 // RefreshTokenRequest represents a refresh token request
@@ -131,6 +133,39 @@ type TerminateGameSessionResponseDTO struct {
 	Error   string `json:"error,omitempty"`
 }
 
+// HytaleStatusResponseDTO reports which Hytale environment the OAuth clients use
+type HytaleStatusResponseDTO struct {
+	Success bool `json:"success" example:"true"`
+	// "production" or "staging"
+	Environment string `json:"environment" example:"production"`
+	UseStaging  bool   `json:"use_staging" example:"false"`
+	// Base domain of the Hytale services in use
+	Host string `json:"host" example:"hytale.com"`
+	// Minutes before expiry that tokens and game sessions are refreshed
+	RefreshLeadMinutes int `json:"refresh_lead_minutes" example:"15"`
+}
+
+// LinkServerRequest represents a request to link a game session to a server
+type LinkServerRequest struct {
+	// Account/Owner UUID from Hytale
+	AccountID string `json:"account_id" example:"550e8400-e29b-41d4-a716-446655440000"`
+	// Profile/character UUID whose game session is linked
+	ProfileUUID string `json:"profile_uuid" example:"550e8400-e29b-41d4-a716-446655440001"`
+}
+
+// LinkServerResponseDTO represents a link or unlink server response
+type LinkServerResponseDTO struct {
+	Success     bool   `json:"success" example:"true"`
+	ServerID    string `json:"server_id,omitempty" example:"srv_abc123"`
+	AccountID   string `json:"account_id,omitempty" example:"550e8400-e29b-41d4-a716-446655440000"`
+	ProfileUUID string `json:"profile_uuid,omitempty" example:"550e8400-e29b-41d4-a716-446655440001"`
+	// Whether the session tokens were written to the server environment;
+	// when false they are pushed on the next session refresh
+	TokensPushed bool   `json:"tokens_pushed" example:"true"`
+	Message      string `json:"message,omitempty" example:"Game session linked to server"`
+	Error        string `json:"error,omitempty"`
+}
+
 // GetHytaleLogsResponse represents a response containing Hytale audit logs
 type GetHytaleLogsResponse struct {
 	Success bool        `json:"success" example:"true"`
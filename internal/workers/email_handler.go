@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"html"
 	"net/http"
 	"time"
 
@@ -12,19 +13,22 @@ import (
 	"github.com/rs/zerolog/log"
 
 	"github.com/nodebyte/backend/internal/config"
+	"github.com/nodebyte/backend/internal/database"
 	"github.com/nodebyte/backend/internal/queue"
 )
 
 // EmailHandler handles email-related tasks
 type EmailHandler struct {
 	cfg        *config.Config
+	db         *database.DB
 	httpClient *http.Client
 }
 
 // NewEmailHandler creates a new email handler
-func NewEmailHandler(cfg *config.Config) *EmailHandler {
+func NewEmailHandler(cfg *config.Config, db *database.DB) *EmailHandler {
 	return &EmailHandler{
 		cfg: cfg,
+		db:  db,
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
@@ -38,6 +42,7 @@ type ResendEmailRequest struct {
 	Subject string   `json:"subject"`
 	HTML    string   `json:"html"`
 	Text    string   `json:"text,omitempty"`
+	ReplyTo string   `json:"reply_to,omitempty"`
 }
 
 // HandleSendEmail processes an email send task
@@ -53,15 +58,34 @@ func (h *EmailHandler) HandleSendEmail(ctx context.Context, task *asynq.Task) er
 		Str("template", payload.Template).
 		Msg("Sending email")
 
+	// Recipients can opt out of non-security categories
+	category := payload.Category
+	if category == "" {
+		if t, ok := LookupEmailTemplate(payload.Template); ok {
+			category = t.Category
+		}
+	}
+	if h.db != nil && category != "" {
+		allowed, err := h.db.EmailNotificationAllowed(ctx, payload.To, category)
+		if err != nil {
+			log.Warn().Err(err).Str("to", payload.To).Str("category", category).Msg("Failed to check notification preferences; sending anyway")
+		} else if !allowed {
+			log.Info().Str("to", payload.To).Str("category", category).Msg("Skipping email the recipient opted out of")
+			return nil
+		}
+	}
+
 	// Build HTML content based on template
-	htmlContent := h.buildEmailHTML(payload.Template, payload.Data)
+	htmlContent := buildEmailHTML(payload.Template, payload.Data)
 
 	// Prepare Resend API request
+	from, replyTo := h.cfg.EmailSender(payload.Template)
 	reqBody := ResendEmailRequest{
-		From:    h.cfg.EmailFrom,
+		From:    from,
 		To:      []string{payload.To},
 		Subject: payload.Subject,
 		HTML:    htmlContent,
+		ReplyTo: replyTo,
 	}
 
 	jsonBody, err := json.Marshal(reqBody)
@@ -97,7 +121,7 @@ func (h *EmailHandler) HandleSendEmail(ctx context.Context, task *asynq.Task) er
 }
 
 // buildEmailHTML builds HTML content for email templates
-func (h *EmailHandler) buildEmailHTML(template string, data map[string]string) string {
+func buildEmailHTML(template string, data map[string]string) string {
 	// Base email template
 	baseStyle := `
 		body { font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, sans-serif; line-height: 1.6; color: #333; }
@@ -120,9 +144,9 @@ func (h *EmailHandler) buildEmailHTML(template string, data map[string]string) s
 				<p>We received a request to reset your password. Click the button below to create a new password:</p>
 				<a href="%s" class="button">Reset Password</a>
 				<p>If you didn't request this, you can safely ignore this email.</p>
-				<p>This link will expire in 1 hour.</p>
+				<p>This link will expire in %s.</p>
 			</div>
-		`, data["name"], data["resetUrl"])
+		`, data["name"], data["resetUrl"], expiresIn(data, "24 hours"))
 
 	case "email-verification":
 		content = fmt.Sprintf(`
@@ -131,9 +155,22 @@ func (h *EmailHandler) buildEmailHTML(template string, data map[string]string) s
 				<p>Hello %s,</p>
 				<p>Thanks for signing up! Please verify your email address by clicking the button below:</p>
 				<a href="%s" class="button">Verify Email</a>
+				<p>This link will expire in %s.</p>
 				<p>If you didn't create an account, you can safely ignore this email.</p>
 			</div>
-		`, data["name"], data["verifyUrl"])
+		`, data["name"], data["verifyUrl"], expiresIn(data, "24 hours"))
+
+	case "confirm-email-change":
+		content = fmt.Sprintf(`
+			<div class="content">
+				<h2>Confirm Your New Email</h2>
+				<p>Hello %s,</p>
+				<p>We received a request to change your NodeByte account email to %s. Use the code below to confirm the change:</p>
+				<p><strong>%s</strong></p>
+				<p>This code will expire in %s. Your current email stays active until the change is confirmed.</p>
+				<p>If you didn't request this, you can safely ignore this email.</p>
+			</div>
+		`, data["name"], data["email"], data["token"], expiresIn(data, "24 hours"))
 
 	case "magic-link":
 		content = fmt.Sprintf(`
@@ -142,10 +179,71 @@ func (h *EmailHandler) buildEmailHTML(template string, data map[string]string) s
 				<p>Hello,</p>
 				<p>Click the button below to sign in to your account:</p>
 				<a href="%s" class="button">Sign In</a>
-				<p>This link will expire in 15 minutes.</p>
+				<p>This link will expire in %s.</p>
 				<p>If you didn't request this, you can safely ignore this email.</p>
 			</div>
-		`, data["magicLinkUrl"])
+		`, data["magicLinkUrl"], expiresIn(data, "30 minutes"))
+
+	case "account-imported":
+		content = fmt.Sprintf(`
+			<div class="content">
+				<h2>Your NodeByte Account Is Ready</h2>
+				<p>Hello %s,</p>
+				<p>An administrator has created a NodeByte account for %s. Before you can sign in, choose a password using the reset code below:</p>
+				<p><strong>%s</strong></p>
+				<p>This code will expire in %s. If it expires, use "Forgot password" on the login page to get a new one.</p>
+			</div>
+		`, data["name"], data["email"], data["token"], expiresIn(data, "24 hours"))
+
+	case "node-maintenance":
+		message := "The node hosting your servers is entering maintenance. They may be briefly unavailable while we work on it."
+		if data["maintenance"] == "false" {
+			message = "Maintenance on the node hosting your servers is complete. Everything should be back to normal."
+		}
+		content = fmt.Sprintf(`
+			<div class="content">
+				<h2>Node Maintenance</h2>
+				<p>Hello %s,</p>
+				<p>%s</p>
+				<p><strong>Node:</strong> %s</p>
+				<p><strong>Affected servers:</strong> %s</p>
+			</div>
+		`, data["name"], message, data["node"], data["servers"])
+
+	case "server-address-changed":
+		// Server names are chosen by customers, so they are escaped
+		content = fmt.Sprintf(`
+			<div class="content">
+				<h2>Server Address Changed</h2>
+				<p>Hello %s,</p>
+				<p>Your server <strong>%s</strong> has moved to a new address. Update any saved connections or DNS records that point to the old one.</p>
+				<p><strong>New address:</strong> %s</p>
+				<p><strong>Previous address:</strong> %s</p>
+			</div>
+		`, data["name"], html.EscapeString(data["server"]), data["address"], data["previousAddress"])
+
+	case "ticket-created":
+		// Ticket titles are written by customers, so they are escaped
+		content = fmt.Sprintf(`
+			<div class="content">
+				<h2>New Support Ticket</h2>
+				<p>Hello %s,</p>
+				<p>Ticket #%s has been opened and is waiting for a response.</p>
+				<p><strong>Subject:</strong> %s</p>
+				<a href="%s" class="button">View Ticket</a>
+			</div>
+		`, data["name"], data["ticketNumber"], html.EscapeString(data["subject"]), data["ticketUrl"])
+
+	case "ticket-reply":
+		content = fmt.Sprintf(`
+			<div class="content">
+				<h2>New Ticket Reply</h2>
+				<p>Hello %s,</p>
+				<p>There is a new reply on ticket #%s.</p>
+				<p><strong>Subject:</strong> %s</p>
+				<a href="%s" class="button">View Ticket</a>
+			</div>
+		`, data["name"], data["ticketNumber"], html.EscapeString(data["subject"]), data["ticketUrl"])
 
 	case "sync-complete":
 		content = fmt.Sprintf(`
@@ -189,3 +287,12 @@ func (h *EmailHandler) buildEmailHTML(template string, data map[string]string) s
 		</html>
 	`, baseStyle, content, time.Now().Year())
 }
+
+// expiresIn returns the token lifetime passed by the sender, or fallback for
+// emails queued before the lifetime was included
+func expiresIn(data map[string]string, fallback string) string {
+	if v := data["expiresIn"]; v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,85 @@
+package workers
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/nodebyte/backend/internal/database"
+)
+
+// EmailTemplate describes an email template rendered by the email worker
+type EmailTemplate struct {
+	Name     string   `json:"name"`
+	Subject  string   `json:"subject"`            // subject the app sends it with
+	Required []string `json:"required"`           // data keys the template renders
+	Category string   `json:"category,omitempty"` // notification category; empty for staff emails, which are always sent
+}
+
+// EmailTemplates lists every template buildEmailHTML renders. Unknown
+// template names fall back to rendering data["message"].
+var EmailTemplates = []EmailTemplate{
+	{Name: "password-reset", Subject: "Reset your password", Required: []string{"name", "resetUrl"}, Category: database.NotificationSecurity},
+	{Name: "email-verification", Subject: "Verify your email", Required: []string{"name", "verifyUrl"}, Category: database.NotificationSecurity},
+	{Name: "confirm-email-change", Subject: "Confirm your new email address", Required: []string{"name", "email", "token"}, Category: database.NotificationSecurity},
+	{Name: "magic-link", Subject: "Your magic link", Required: []string{"magicLinkUrl"}, Category: database.NotificationSecurity},
+	{Name: "account-imported", Subject: "Your NodeByte account is ready", Required: []string{"name", "email", "token"}, Category: database.NotificationSecurity},
+	{Name: "node-maintenance", Subject: "Scheduled maintenance on your server's node", Required: []string{"name", "node", "servers"}, Category: database.NotificationServerStatus},
+	{Name: "server-address-changed", Subject: "Your server's address has changed", Required: []string{"name", "server", "address", "previousAddress"}, Category: database.NotificationServerStatus},
+	{Name: "ticket-created", Subject: "New support ticket", Required: []string{"name", "ticketNumber", "subject", "ticketUrl"}, Category: database.NotificationSupport},
+	{Name: "ticket-reply", Subject: "New reply on your support ticket", Required: []string{"name", "ticketNumber", "subject", "ticketUrl"}, Category: database.NotificationSupport},
+	{Name: "sync-complete", Subject: "Sync completed", Required: []string{"syncType", "status", "duration"}},
+}
+
+// ErrUnknownEmailTemplate is returned when previewing a template that is not
+// in EmailTemplates
+var ErrUnknownEmailTemplate = errors.New("unknown email template")
+
+// EmailPreview is a rendered email that was not sent
+type EmailPreview struct {
+	Template string `json:"template"`
+	Subject  string `json:"subject"`
+	HTML     string `json:"html"`
+}
+
+// LookupEmailTemplate returns the registered template called name
+func LookupEmailTemplate(name string) (EmailTemplate, bool) {
+	for _, t := range EmailTemplates {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return EmailTemplate{}, false
+}
+
+// MissingData returns the required keys that are absent or blank in data
+func (t EmailTemplate) MissingData(data map[string]string) []string {
+	missing := []string{}
+	for _, key := range t.Required {
+		if strings.TrimSpace(data[key]) == "" {
+			missing = append(missing, key)
+		}
+	}
+	return missing
+}
+
+// PreviewEmail renders a registered template exactly as the worker would
+// send it. An empty subject uses the template's default.
+func PreviewEmail(name, subject string, data map[string]string) (*EmailPreview, error) {
+	t, ok := LookupEmailTemplate(name)
+	if !ok {
+		return nil, fmt.Errorf("%w %q", ErrUnknownEmailTemplate, name)
+	}
+	if missing := t.MissingData(data); len(missing) > 0 {
+		return nil, fmt.Errorf("missing data for %s: %s", name, strings.Join(missing, ", "))
+	}
+
+	if subject == "" {
+		subject = t.Subject
+	}
+	return &EmailPreview{
+		Template: name,
+		Subject:  subject,
+		HTML:     buildEmailHTML(name, data),
+	}, nil
+}
@@ -0,0 +1,68 @@
+package workers
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nodebyte/backend/internal/database"
+)
+
+func TestEmailTemplatesRenderRequiredData(t *testing.T) {
+	for _, tmpl := range EmailTemplates {
+		t.Run(tmpl.Name, func(t *testing.T) {
+			data := map[string]string{}
+			for _, key := range tmpl.Required {
+				data[key] = "value-of-" + key
+			}
+
+			preview, err := PreviewEmail(tmpl.Name, "", data)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if preview.Subject != tmpl.Subject {
+				t.Errorf("subject = %q, want %q", preview.Subject, tmpl.Subject)
+			}
+			for _, key := range tmpl.Required {
+				if !strings.Contains(preview.HTML, data[key]) {
+					t.Errorf("rendered HTML does not contain %s", key)
+				}
+			}
+		})
+	}
+}
+
+func TestPreviewEmailValidation(t *testing.T) {
+	if _, err := PreviewEmail("no-such-template", "", nil); !errors.Is(err, ErrUnknownEmailTemplate) {
+		t.Errorf("unknown template error = %v, want ErrUnknownEmailTemplate", err)
+	}
+
+	tmpl, _ := LookupEmailTemplate("password-reset")
+	missing := tmpl.MissingData(map[string]string{"name": "Ada", "resetUrl": " "})
+	if want := []string{"resetUrl"}; !reflect.DeepEqual(missing, want) {
+		t.Errorf("missing = %v, want %v", missing, want)
+	}
+	if _, err := PreviewEmail("password-reset", "", map[string]string{"name": "Ada"}); err == nil {
+		t.Error("expected error for missing data")
+	}
+}
+
+func TestEmailTemplateCategories(t *testing.T) {
+	for _, tmpl := range EmailTemplates {
+		if tmpl.Category == "" {
+			continue
+		}
+		if _, ok := database.LookupNotificationCategory(tmpl.Category); !ok {
+			t.Errorf("template %s has unknown category %q", tmpl.Name, tmpl.Category)
+		}
+	}
+
+	for _, name := range []string{"password-reset", "email-verification", "magic-link"} {
+		tmpl, _ := LookupEmailTemplate(name)
+		category, _ := database.LookupNotificationCategory(tmpl.Category)
+		if !category.Mandatory {
+			t.Errorf("template %s must be in a mandatory category, got %q", name, tmpl.Category)
+		}
+	}
+}
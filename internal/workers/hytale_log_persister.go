@@ -18,14 +18,13 @@ type HytaleLogPersister struct {
 	logsRepo    *database.HytaleServerLogsRepository
 	oauthRepo   *database.HytaleOAuthRepository
 	oauthClient *hytale.OAuthClient
-	useStaging  bool
 }
 
 // NewHytaleLogPersister creates a new log persister instance
-func NewHytaleLogPersister(db *database.DB, useStaging bool) *HytaleLogPersister {
+func NewHytaleLogPersister(db *database.DB, env *hytale.Environment) *HytaleLogPersister {
 	oauthClient := hytale.NewOAuthClient(&hytale.OAuthClientConfig{
-		ClientID:   "hytale-server",
-		UseStaging: useStaging,
+		ClientID:    "hytale-server",
+		Environment: env,
 	})
 
 	return &HytaleLogPersister{
@@ -33,7 +32,6 @@ func NewHytaleLogPersister(db *database.DB, useStaging bool) *HytaleLogPersister
 		logsRepo:    database.NewHytaleServerLogsRepository(db),
 		oauthRepo:   database.NewHytaleOAuthRepository(db),
 		oauthClient: oauthClient,
-		useStaging:  useStaging,
 	}
 }
 
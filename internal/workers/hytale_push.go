@@ -0,0 +1,137 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+
+	"github.com/nodebyte/backend/internal/database"
+	"github.com/nodebyte/backend/internal/hytale"
+	"github.com/nodebyte/backend/internal/panels"
+	"github.com/nodebyte/backend/internal/queue"
+)
+
+// hytalePushRate is how many token pushes per second are sent to the panel
+// when re-pushing to every linked server
+const hytalePushRate = 5
+
+// Outcomes of a token push to one server
+const (
+	hytalePushPushed  = "pushed"
+	hytalePushSkipped = "skipped"
+	hytalePushFailed  = "failed"
+)
+
+// HytalePushHandler re-pushes Hytale session tokens to linked servers
+type HytalePushHandler struct {
+	db          *database.DB
+	oauthRepo   *database.HytaleOAuthRepository
+	pteroClient *panels.PterodactylClient
+}
+
+// NewHytalePushHandler creates a new Hytale token push handler. pteroClient
+// must carry a client API key.
+func NewHytalePushHandler(db *database.DB, pteroClient *panels.PterodactylClient) *HytalePushHandler {
+	return &HytalePushHandler{
+		db:          db,
+		oauthRepo:   database.NewHytaleOAuthRepository(db),
+		pteroClient: pteroClient,
+	}
+}
+
+// HytalePushResult is the outcome of a token push to one linked server
+type HytalePushResult struct {
+	ServerID    string `json:"serverId"`
+	AccountID   string `json:"accountId"`
+	ProfileUUID string `json:"profileUuid"`
+	Status      string `json:"status"` // pushed, skipped or failed
+	Reason      string `json:"reason,omitempty"`
+}
+
+// HandlePushTokens writes current session tokens into the environment of
+// every server with a linked game session, at most hytalePushRate servers
+// per second. One server failing does not stop the others; each outcome is
+// logged and the totals and failures are recorded in the admin audit log.
+func (h *HytalePushHandler) HandlePushTokens(ctx context.Context, task *asynq.Task) error {
+	var payload queue.HytalePushTokensPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+
+	sessions, err := h.oauthRepo.ListLinkedGameSessions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to fetch linked game sessions: %w", err)
+	}
+
+	limiter := time.NewTicker(time.Second / hytalePushRate)
+	defer limiter.Stop()
+
+	failures := make([]HytalePushResult, 0)
+	counts := map[string]int{hytalePushPushed: 0, hytalePushSkipped: 0, hytalePushFailed: 0}
+	for _, session := range sessions {
+		result := HytalePushResult{
+			ServerID:    session.ServerID.String,
+			AccountID:   session.AccountID,
+			ProfileUUID: session.ProfileUUID,
+			Status:      hytalePushPushed,
+		}
+
+		switch {
+		case session.ServerUUID == "":
+			result.Status, result.Reason = hytalePushSkipped, "server is not managed by the panel"
+		case session.SessionToken == "" || session.IdentityToken == "" || !session.ExpiresAt.After(time.Now()):
+			result.Status, result.Reason = hytalePushSkipped, "game session has expired"
+		default:
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-limiter.C:
+			}
+			envVars := hytale.ServerTokenEnvironment(session.SessionToken, session.IdentityToken)
+			if err := h.pteroClient.UpdateServerEnvironment(ctx, session.ServerUUID, envVars); err != nil {
+				result.Status, result.Reason = hytalePushFailed, err.Error()
+			}
+		}
+
+		event := log.Info()
+		if result.Status == hytalePushFailed {
+			event = log.Warn()
+			failures = append(failures, result)
+		}
+		event.
+			Str("server_id", result.ServerID).
+			Str("account_id", result.AccountID).
+			Str("status", result.Status).
+			Str("reason", result.Reason).
+			Msg("Hytale token push")
+
+		counts[result.Status]++
+	}
+
+	if err := h.db.CreateAdminAuditLog(ctx, database.AdminAuditEntry{
+		ActorID:    payload.ActorID,
+		Action:     database.AuditHytaleTokensPushed,
+		TargetType: "hytale",
+		Details: map[string]interface{}{
+			"pushed":   counts[hytalePushPushed],
+			"skipped":  counts[hytalePushSkipped],
+			"failed":   counts[hytalePushFailed],
+			"failures": failures,
+		},
+		IPAddress: payload.IPAddress,
+		UserAgent: payload.UserAgent,
+	}); err != nil {
+		log.Error().Err(err).Msg("Failed to write Hytale token push audit log")
+	}
+
+	log.Info().
+		Int("pushed", counts[hytalePushPushed]).
+		Int("skipped", counts[hytalePushSkipped]).
+		Int("failed", counts[hytalePushFailed]).
+		Msg("Hytale token push finished")
+	return nil
+}
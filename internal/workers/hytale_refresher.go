@@ -7,6 +7,7 @@ import (
 
 	"github.com/rs/zerolog/log"
 
+	"github.com/nodebyte/backend/internal/config"
 	"github.com/nodebyte/backend/internal/database"
 	"github.com/nodebyte/backend/internal/hytale"
 	"github.com/nodebyte/backend/internal/panels"
@@ -19,13 +20,15 @@ type HytaleRefresher struct {
 	oauthRepo         *database.HytaleOAuthRepository
 	oauthClient       *hytale.OAuthClient
 	pterodactylClient *panels.PterodactylClient
+	cfg               *config.Config
 }
 
-// NewHytaleRefresher creates a new Hytale refresher
-func NewHytaleRefresher(db *database.DB, pteroClient *panels.PterodactylClient, useStaging bool) *HytaleRefresher {
+// NewHytaleRefresher creates a new Hytale refresher. Tokens and sessions are
+// refreshed cfg.HytaleRefreshLead() before they expire.
+func NewHytaleRefresher(db *database.DB, pteroClient *panels.PterodactylClient, env *hytale.Environment, cfg *config.Config) *HytaleRefresher {
 	oauthClient := hytale.NewOAuthClient(&hytale.OAuthClientConfig{
-		ClientID:   "hytale-server",
-		UseStaging: useStaging,
+		ClientID:    "hytale-server",
+		Environment: env,
 	})
 
 	return &HytaleRefresher{
@@ -33,6 +36,7 @@ func NewHytaleRefresher(db *database.DB, pteroClient *panels.PterodactylClient,
 		oauthRepo:         database.NewHytaleOAuthRepository(db),
 		oauthClient:       oauthClient,
 		pterodactylClient: pteroClient,
+		cfg:               cfg,
 	}
 }
 
@@ -45,8 +49,9 @@ func (r *HytaleRefresher) RefreshOAuthTokens(ctx context.Context) error {
 
 	log.Debug().Msg("Starting OAuth token refresh check")
 
-	// Get all tokens from database
-	tokens, err := r.oauthRepo.GetAllOAuthTokens(ctx)
+	// Get tokens expiring within the refresh lead time
+	lead := r.cfg.HytaleRefreshLead()
+	tokens, err := r.oauthRepo.GetOAuthTokensExpiringBefore(ctx, time.Now().Add(lead))
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to fetch OAuth tokens for refresh")
 		sentry.CaptureExceptionWithContext(ctx, err, "fetch_oauth_tokens")
@@ -54,30 +59,25 @@ func (r *HytaleRefresher) RefreshOAuthTokens(ctx context.Context) error {
 	}
 
 	if len(tokens) == 0 {
-		log.Debug().Msg("No OAuth tokens found to refresh")
+		log.Debug().Dur("lead", lead).Msg("No OAuth tokens due for refresh")
 		return nil
 	}
 
-	log.Debug().Int("token_count", len(tokens)).Msg("Checking OAuth tokens for refresh")
-
-	// Refresh tokens expiring in next 5 minutes
-	refreshThreshold := time.Now().Add(5 * time.Minute)
+	log.Debug().Int("token_count", len(tokens)).Dur("lead", lead).Msg("Refreshing expiring OAuth tokens")
 
 	for _, token := range tokens {
-		if token.AccessTokenExpiry.Before(refreshThreshold) {
-			log.Info().
-				Str("account_id", token.AccountID).
-				Time("expiry", token.AccessTokenExpiry).
-				Msg("Refreshing OAuth token")
+		log.Info().
+			Str("account_id", token.AccountID).
+			Time("expiry", token.AccessTokenExpiry).
+			Msg("Refreshing OAuth token")
 
-			if err := r.refreshSingleToken(ctx, token); err != nil {
-				log.Error().
-					Err(err).
-					Str("account_id", token.AccountID).
-					Msg("Failed to refresh OAuth token")
-				// Continue refreshing other tokens
-				continue
-			}
+		if err := r.refreshSingleToken(ctx, token); err != nil {
+			log.Error().
+				Err(err).
+				Str("account_id", token.AccountID).
+				Msg("Failed to refresh OAuth token")
+			// Continue refreshing other tokens
+			continue
 		}
 	}
 
@@ -121,8 +121,9 @@ func (r *HytaleRefresher) refreshSingleToken(ctx context.Context, token *databas
 	return nil
 }
 
-// RefreshGameSessions refreshes all game sessions that are expiring soon
-// Called by scheduler every 5 minutes (checks if session expires within 5 minutes)
+// RefreshGameSessions refreshes game sessions expiring within the refresh lead
+// time and pushes the new tokens to linked servers
+// Called by scheduler every 5 minutes
 func (r *HytaleRefresher) RefreshGameSessions(ctx context.Context) error {
 	tx := sentry.StartBackgroundTransaction(ctx, "worker.refresh_game_sessions")
 	defer tx.Finish()
@@ -130,8 +131,9 @@ func (r *HytaleRefresher) RefreshGameSessions(ctx context.Context) error {
 
 	log.Debug().Msg("Starting game session refresh check")
 
-	// Get all game sessions from database
-	sessions, err := r.oauthRepo.GetAllGameSessions(ctx)
+	// Get game sessions expiring within the refresh lead time
+	lead := r.cfg.HytaleRefreshLead()
+	sessions, err := r.oauthRepo.GetGameSessionsExpiringBefore(ctx, time.Now().Add(lead))
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to fetch game sessions for refresh")
 		sentry.CaptureExceptionWithContext(ctx, err, "fetch_game_sessions")
@@ -139,36 +141,27 @@ func (r *HytaleRefresher) RefreshGameSessions(ctx context.Context) error {
 	}
 
 	if len(sessions) == 0 {
-		log.Debug().Msg("No game sessions found to refresh")
+		log.Debug().Dur("lead", lead).Msg("No game sessions due for refresh")
 		return nil
 	}
 
-	log.Debug().Int("session_count", len(sessions)).Msg("Checking game sessions for refresh")
-
-	// Refresh sessions if they expire within next 5 minutes
-	// Game sessions expire in 1 hour, so refresh when created_at + 55 minutes has passed
-	now := time.Now()
+	log.Debug().Int("session_count", len(sessions)).Dur("lead", lead).Msg("Refreshing expiring game sessions")
 
 	for _, session := range sessions {
-		// Check if session is about to expire (within 5 minutes of the 1-hour mark)
-		createdAt := session.CreatedAt
-		expiryTime := createdAt.Add(55 * time.Minute)
-
-		if expiryTime.Before(now) || expiryTime.Equal(now) {
-			log.Info().
+		log.Info().
+			Str("account_id", session.AccountID).
+			Str("profile_uuid", session.ProfileUUID).
+			Time("expiry", session.ExpiresAt).
+			Msg("Refreshing game session")
+
+		if err := r.refreshSingleSession(ctx, session); err != nil {
+			log.Error().
+				Err(err).
 				Str("account_id", session.AccountID).
 				Str("profile_uuid", session.ProfileUUID).
-				Msg("Refreshing game session")
-
-			if err := r.refreshSingleSession(ctx, session); err != nil {
-				log.Error().
-					Err(err).
-					Str("account_id", session.AccountID).
-					Str("profile_uuid", session.ProfileUUID).
-					Msg("Failed to refresh game session")
-				// Continue refreshing other sessions
-				continue
-			}
+				Msg("Failed to refresh game session")
+			// Continue refreshing other sessions
+			continue
 		}
 	}
 
@@ -186,17 +179,14 @@ func (r *HytaleRefresher) refreshSingleSession(ctx context.Context, session *dat
 		return fmt.Errorf("failed to refresh session: %w", err)
 	}
 
-	if err := r.oauthRepo.UpdateGameSessionTokens(span.Context(), session.AccountID, session.ProfileUUID, sessionResp.SessionToken, sessionResp.IdentityToken); err != nil {
+	if err := r.oauthRepo.UpdateGameSessionTokens(span.Context(), session.AccountID, session.ProfileUUID, sessionResp.SessionToken, sessionResp.IdentityToken, sessionResp.Expiry(time.Now())); err != nil {
 		sentry.CaptureExceptionWithContext(span.Context(), err, "update_session_tokens")
 		return fmt.Errorf("failed to update session tokens: %w", err)
 	}
 
 	// Push updated tokens to Pterodactyl server if linked
 	if session.ServerID.Valid && session.ServerID.String != "" {
-		envVars := map[string]string{
-			"HYTALE_SESSION_TOKEN":  sessionResp.SessionToken,
-			"HYTALE_IDENTITY_TOKEN": sessionResp.IdentityToken,
-		}
+		envVars := hytale.ServerTokenEnvironment(sessionResp.SessionToken, sessionResp.IdentityToken)
 
 		// Get server UUID from database
 		var serverUUID string
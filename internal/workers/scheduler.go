@@ -3,6 +3,7 @@ package workers
 import (
 	"context"
 	"strconv"
+	"time"
 
 	"github.com/hibiken/asynq"
 	"github.com/robfig/cron/v3"
@@ -10,6 +11,7 @@ import (
 
 	"github.com/nodebyte/backend/internal/config"
 	"github.com/nodebyte/backend/internal/database"
+	"github.com/nodebyte/backend/internal/hytale"
 	"github.com/nodebyte/backend/internal/panels"
 	"github.com/nodebyte/backend/internal/queue"
 )
@@ -20,10 +22,12 @@ type Scheduler struct {
 	asynqClient *asynq.Client
 	cfg         *config.Config
 	db          *database.DB
+	hytaleEnv   *hytale.Environment
 }
 
-// NewScheduler creates a new scheduler
-func NewScheduler(db *database.DB, redisOpt asynq.RedisClientOpt, cfg *config.Config) *Scheduler {
+// NewScheduler creates a new scheduler. hytaleEnv is shared with the API so
+// Hytale staging/production switches apply to the refresh jobs too.
+func NewScheduler(db *database.DB, redisOpt asynq.RedisClientOpt, cfg *config.Config, hytaleEnv *hytale.Environment) *Scheduler {
 	asynqClient := asynq.NewClient(redisOpt)
 
 	return &Scheduler{
@@ -31,6 +35,7 @@ func NewScheduler(db *database.DB, redisOpt asynq.RedisClientOpt, cfg *config.Co
 		asynqClient: asynqClient,
 		cfg:         cfg,
 		db:          db,
+		hytaleEnv:   hytaleEnv,
 	}
 }
 
@@ -38,7 +43,8 @@ func NewScheduler(db *database.DB, redisOpt asynq.RedisClientOpt, cfg *config.Co
 func (s *Scheduler) Start() error {
 	log.Info().Msg("Starting scheduler")
 
-	queueManager := queue.NewManager(s.asynqClient)
+	queueManager := queue.NewManagerWithRouting(s.asynqClient, s.cfg.QueueRouting())
+	queueManager.SetTaskOptions(s.cfg.TaskOptions)
 	pteroClient := panels.NewPterodactylClientWithClientKey(
 		s.cfg.PterodactylURL,
 		s.cfg.PterodactylAPIKey,
@@ -46,8 +52,12 @@ func (s *Scheduler) Start() error {
 		s.cfg.CFAccessClientID,
 		s.cfg.CFAccessClientSecret,
 	)
-	hytaleRefresher := NewHytaleRefresher(s.db, pteroClient, s.cfg.HytaleUseStaging)
-	hytaleLogPersister := NewHytaleLogPersister(s.db, s.cfg.HytaleUseStaging)
+	pteroClient.SetPerPage(s.cfg.SyncPerPage)
+	pteroClient.SetConnectionPool(s.cfg.PanelConnectionPool())
+	hytaleRefresher := NewHytaleRefresher(s.db, pteroClient, s.hytaleEnv, s.cfg)
+	hytaleLogPersister := NewHytaleLogPersister(s.db, s.hytaleEnv)
+	syncJanitor := NewSyncJanitor(s.db, s.cfg.SyncMaxAge())
+	serverStatusPoller := NewServerStatusPoller(s.db, pteroClient, s.cfg)
 
 	// Auto-sync job (if enabled)
 	if s.cfg.AutoSyncEnabled {
@@ -56,9 +66,7 @@ func (s *Scheduler) Start() error {
 			interval = 1 // Minimum 1 second
 		}
 
-		// Config stores interval in seconds (e.g. 60 = 60 seconds, 3600 = 1 hour, 86400 = 24 hours)
-		cronSpec := "@every " + strconv.Itoa(interval) + "s"
-		_, err := s.cron.AddFunc(cronSpec, func() {
+		autoSync := func() {
 			log.Info().Msg("Triggering scheduled auto-sync")
 
 			// Create sync log and enqueue task
@@ -70,11 +78,29 @@ func (s *Scheduler) Start() error {
 			if err != nil {
 				log.Error().Err(err).Msg("Failed to enqueue auto-sync")
 			}
-		})
-		if err != nil {
-			log.Error().Err(err).Msg("Failed to schedule auto-sync job")
+		}
+
+		if s.cfg.AutoSyncCron != "" {
+			// Cron mode: e.g. "0 4 * * *" for a full sync at 4 AM daily
+			schedule, err := config.ParseAutoSyncCron(s.cfg.AutoSyncCron)
+			if err != nil {
+				log.Error().Err(err).Str("cron", s.cfg.AutoSyncCron).Msg("Invalid auto-sync cron expression")
+			} else {
+				s.cron.Schedule(schedule, cron.FuncJob(autoSync))
+				log.Info().
+					Str("cron", s.cfg.AutoSyncCron).
+					Time("next_run", schedule.Next(time.Now())).
+					Msg("Scheduled auto-sync job")
+			}
 		} else {
-			log.Info().Int("interval_seconds", interval).Msg("Scheduled auto-sync job")
+			// Config stores interval in seconds (e.g. 60 = 60 seconds, 3600 = 1 hour, 86400 = 24 hours)
+			cronSpec := "@every " + strconv.Itoa(interval) + "s"
+			_, err := s.cron.AddFunc(cronSpec, autoSync)
+			if err != nil {
+				log.Error().Err(err).Msg("Failed to schedule auto-sync job")
+			} else {
+				log.Info().Int("interval_seconds", interval).Msg("Scheduled auto-sync job")
+			}
 		}
 	}
 
@@ -88,10 +114,11 @@ func (s *Scheduler) Start() error {
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to schedule OAuth token refresh")
 	} else {
-		log.Info().Msg("Scheduled OAuth token refresh (every 5 minutes)")
+		log.Info().Dur("lead", s.cfg.HytaleRefreshLead()).Msg("Scheduled OAuth token refresh (every 5 minutes)")
 	}
 
-	// Game session refresh every 5 minutes (checks if within 5 min of expiry)
+	// Game session refresh every 5 minutes (refreshes sessions within the
+	// configured lead time of expiry)
 	_, err = s.cron.AddFunc("@every 5m", func() {
 		log.Debug().Msg("Running game session refresh")
 		if err := hytaleRefresher.RefreshGameSessions(context.Background()); err != nil {
@@ -101,7 +128,7 @@ func (s *Scheduler) Start() error {
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to schedule game session refresh")
 	} else {
-		log.Info().Msg("Scheduled game session refresh (every 5 minutes)")
+		log.Info().Dur("lead", s.cfg.HytaleRefreshLead()).Msg("Scheduled game session refresh (every 5 minutes)")
 	}
 
 	// Game session cleanup daily at 2 AM
@@ -130,17 +157,79 @@ func (s *Scheduler) Start() error {
 		log.Info().Msg("Scheduled Hytale server logs persistence (every 5 minutes)")
 	}
 
-	// Hytale server logs cleanup daily at 4 AM (keep 30 days)
+	// Hytale server logs cleanup daily at 4 AM (keep HYTALE_LOG_RETENTION_DAYS)
+	logRetentionDays := s.cfg.HytaleLogRetentionDays
+	if logRetentionDays < 1 {
+		logRetentionDays = 30
+	}
 	_, err = s.cron.AddFunc("0 0 4 * * *", func() {
 		log.Debug().Msg("Running Hytale logs cleanup")
-		if err := hytaleLogPersister.CleanupOldLogs(context.Background(), 30); err != nil {
+		if err := hytaleLogPersister.CleanupOldLogs(context.Background(), logRetentionDays); err != nil {
 			log.Error().Err(err).Msg("Failed to cleanup old Hytale logs")
 		}
 	})
 	if err != nil {
 		log.Error().Err(err).Msg("Failed to schedule Hytale logs cleanup")
 	} else {
-		log.Info().Msg("Scheduled Hytale server logs cleanup (daily at 4 AM)")
+		log.Info().Int("retention_days", logRetentionDays).Msg("Scheduled Hytale server logs cleanup (daily at 4 AM)")
+	}
+
+	// Stuck sync recovery every 5 minutes
+	_, err = s.cron.AddFunc("@every 5m", func() {
+		log.Debug().Msg("Running stuck sync recovery")
+		if err := syncJanitor.RecoverStuckSyncs(context.Background()); err != nil {
+			log.Error().Err(err).Msg("Failed to recover stuck syncs")
+		}
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to schedule stuck sync recovery")
+	} else {
+		log.Info().Dur("max_duration", s.cfg.SyncMaxAge()).Msg("Scheduled stuck sync recovery (every 5 minutes)")
+	}
+
+	// Server tombstone purge every hour
+	if s.cfg.SyncServerTombstones {
+		_, err = s.cron.AddFunc("@every 1h", func() {
+			if err := syncJanitor.PurgeServerTombstones(context.Background(), s.cfg.TombstoneGracePeriod(), s.cfg.SyncTombstoneMinMissing); err != nil {
+				log.Error().Err(err).Msg("Failed to purge server tombstones")
+			}
+		})
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to schedule server tombstone purge")
+		} else {
+			log.Info().
+				Dur("grace_period", s.cfg.TombstoneGracePeriod()).
+				Int("min_missing_syncs", s.cfg.SyncTombstoneMinMissing).
+				Msg("Scheduled server tombstone purge (every hour)")
+		}
+	}
+
+	// Server power state polling for uptime history, servers.status and
+	// resource snapshots (needs the client API key)
+	if s.cfg.ServerStatusPollInterval > 0 && s.cfg.PterodactylClientAPIKey != "" {
+		_, err = s.cron.AddFunc("@every "+strconv.Itoa(s.cfg.ServerStatusPollInterval)+"s", func() {
+			if err := serverStatusPoller.PollServerStatuses(context.Background()); err != nil {
+				log.Error().Err(err).Msg("Failed to poll server statuses")
+			}
+		})
+		if err != nil {
+			log.Error().Err(err).Msg("Failed to schedule server status polling")
+		} else {
+			log.Info().Int("interval_seconds", s.cfg.ServerStatusPollInterval).Msg("Scheduled server status polling")
+		}
+	}
+
+	// Server status event cleanup daily at 5 AM (keep 90 days)
+	_, err = s.cron.AddFunc("0 0 5 * * *", func() {
+		log.Debug().Msg("Running server status event cleanup")
+		if err := serverStatusPoller.CleanupOldEvents(context.Background()); err != nil {
+			log.Error().Err(err).Msg("Failed to cleanup old server status events")
+		}
+	})
+	if err != nil {
+		log.Error().Err(err).Msg("Failed to schedule server status event cleanup")
+	} else {
+		log.Info().Msg("Scheduled server status event cleanup (daily at 5 AM)")
 	}
 
 	// Daily log cleanup at 3 AM
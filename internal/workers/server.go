@@ -16,6 +16,7 @@ import (
 type Server struct {
 	server *asynq.Server
 	mux    *asynq.ServeMux
+	client *asynq.Client // lets handlers queue follow-up tasks
 }
 
 // NewServer creates a new worker server
@@ -31,6 +32,8 @@ func NewServer(redisOpt asynq.RedisClientOpt, db *database.DB, cfg *config.Confi
 				queue.QueueDefault:  3,
 				queue.QueueLow:      1,
 			},
+			// Per task type backoff between retries
+			RetryDelayFunc: cfg.TaskOptions.RetryDelay,
 			// Error handler
 			ErrorHandler: asynq.ErrorHandlerFunc(func(ctx context.Context, task *asynq.Task, err error) {
 				log.Error().
@@ -52,10 +55,18 @@ func NewServer(redisOpt asynq.RedisClientOpt, db *database.DB, cfg *config.Confi
 		cfg.CFAccessClientID,
 		cfg.CFAccessClientSecret,
 	)
+	pteroClient.SetPerPage(cfg.SyncPerPage)
+	pteroClient.SetConnectionPool(cfg.PanelConnectionPool())
+
+	client := asynq.NewClient(redisOpt)
+	queueManager := queue.NewManagerWithRouting(client, cfg.QueueRouting())
+	queueManager.SetTaskOptions(cfg.TaskOptions)
 
 	syncHandler := NewSyncHandler(db, pteroClient, cfg)
-	emailHandler := NewEmailHandler(cfg)
+	syncHandler.SetQueueManager(queueManager)
+	emailHandler := NewEmailHandler(cfg, db)
 	webhookHandler := NewWebhookHandler(db)
+	hytalePushHandler := NewHytalePushHandler(db, pteroClient)
 
 	// Setup task handlers
 	mux := asynq.NewServeMux()
@@ -69,19 +80,27 @@ func NewServer(redisOpt asynq.RedisClientOpt, db *database.DB, cfg *config.Confi
 	mux.HandleFunc(queue.TypeSyncServers, syncHandler.HandleSyncServers)
 	mux.HandleFunc(queue.TypeSyncDatabases, syncHandler.HandleSyncDatabases)
 	mux.HandleFunc(queue.TypeSyncUsers, syncHandler.HandleSyncUsers)
+	mux.HandleFunc(queue.TypeSyncServerStates, syncHandler.HandleSyncServerStates)
+	mux.HandleFunc(queue.TypeSyncBatch, syncHandler.HandleSyncBatch)
+	mux.HandleFunc(queue.TypeSyncUser, syncHandler.HandleUserResync)
 
 	// Email tasks
 	mux.HandleFunc(queue.TypeEmailSend, emailHandler.HandleSendEmail)
 
 	// Webhook tasks
 	mux.HandleFunc(queue.TypeWebhookDiscord, webhookHandler.HandleDiscordWebhook)
+	mux.HandleFunc(queue.TypeWebhookSyncResult, webhookHandler.HandleSyncResultWebhook)
 
 	// Cleanup tasks
 	mux.HandleFunc(queue.TypeCleanupLogs, syncHandler.HandleCleanupLogs)
 
+	// Hytale tasks
+	mux.HandleFunc(queue.TypeHytalePushTokens, hytalePushHandler.HandlePushTokens)
+
 	return &Server{
 		server: server,
 		mux:    mux,
+		client: client,
 	}
 }
 
@@ -95,6 +114,9 @@ func (s *Server) Start() error {
 func (s *Server) Stop() {
 	log.Info().Msg("Stopping Asynq worker server")
 	s.server.Shutdown()
+	if err := s.client.Close(); err != nil {
+		log.Warn().Err(err).Msg("Failed to close worker queue client")
+	}
 }
 
 // asynqLogger implements asynq.Logger interface
@@ -0,0 +1,101 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+)
+
+// ServerResyncResult reports what a single-server resync refreshed
+type ServerResyncResult struct {
+	ServerID    string `json:"serverId"` // local server ID
+	Created     bool   `json:"created"`  // the server had no local row
+	Allocations int    `json:"allocations"`
+	Databases   int    `json:"databases"`
+}
+
+// ResyncServer refreshes one panel server outside a full sync: its row, its
+// allocations and its databases. Allocations and databases the panel no
+// longer reports for the server are unlinked and removed respectively.
+func (h *SyncHandler) ResyncServer(ctx context.Context, pterodactylID int) (*ServerResyncResult, error) {
+	server, err := h.pteroClient.GetServerDetailWithIncludes(ctx, pterodactylID, []string{"allocations"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch server: %w", err)
+	}
+
+	// Keep the current owner if the panel user has not been synced yet
+	var ownerID *string
+	var id string
+	err = h.db.Pool.QueryRow(ctx, `SELECT id FROM users WHERE "pterodactylId" = $1`, server.Attributes.User).Scan(&id)
+	if err == nil {
+		ownerID = &id
+	} else if !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to look up server owner: %w", err)
+	}
+
+	localID, created, err := h.upsertServer(ctx, *server, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upsert server: %w", err)
+	}
+	result := &ServerResyncResult{ServerID: localID, Created: created}
+
+	allocationIDs := make([]int, 0, len(server.Relationships.Allocations.Data))
+	for _, alloc := range server.Relationships.Allocations.Data {
+		if _, err := h.db.Pool.Exec(ctx, `
+			INSERT INTO allocations (id, ip, port, alias, notes, "isAssigned", "nodeId", "serverId", "createdAt", "updatedAt")
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW(), NOW())
+			ON CONFLICT (id) DO UPDATE SET
+				ip = EXCLUDED.ip,
+				port = EXCLUDED.port,
+				alias = EXCLUDED.alias,
+				notes = EXCLUDED.notes,
+				"isAssigned" = EXCLUDED."isAssigned",
+				"nodeId" = EXCLUDED."nodeId",
+				"serverId" = EXCLUDED."serverId",
+				"updatedAt" = NOW()
+		`, alloc.Attributes.ID, alloc.Attributes.IP, alloc.Attributes.Port, alloc.Attributes.Alias,
+			alloc.Attributes.Notes, alloc.Attributes.Assigned, server.Attributes.Node, localID); err != nil {
+			return nil, fmt.Errorf("failed to upsert allocation %d: %w", alloc.Attributes.ID, err)
+		}
+		allocationIDs = append(allocationIDs, alloc.Attributes.ID)
+	}
+	if _, err := h.db.Pool.Exec(ctx,
+		`UPDATE allocations SET "serverId" = NULL, "updatedAt" = NOW() WHERE "serverId" = $1 AND NOT (id = ANY($2))`,
+		localID, allocationIDs,
+	); err != nil {
+		return nil, fmt.Errorf("failed to unlink stale allocations: %w", err)
+	}
+	result.Allocations = len(allocationIDs)
+
+	databases, err := h.pteroClient.GetServerDatabasesWithHost(ctx, pterodactylID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch server databases: %w", err)
+	}
+	databaseIDs := make([]int, 0, len(databases))
+	for _, db := range databases {
+		if err := h.upsertServerDatabase(ctx, localID, db); err != nil {
+			return nil, fmt.Errorf("failed to upsert database %d: %w", db.Attributes.ID, err)
+		}
+		databaseIDs = append(databaseIDs, db.Attributes.ID)
+	}
+	if _, err := h.db.Pool.Exec(ctx,
+		`DELETE FROM server_databases WHERE "serverId" = $1 AND NOT ("pterodactylId" = ANY($2))`,
+		localID, databaseIDs,
+	); err != nil {
+		return nil, fmt.Errorf("failed to delete stale databases: %w", err)
+	}
+	result.Databases = len(databaseIDs)
+
+	log.Info().
+		Int("pterodactyl_id", pterodactylID).
+		Str("server_id", localID).
+		Bool("created", created).
+		Int("allocations", result.Allocations).
+		Int("databases", result.Databases).
+		Msg("Resynced server")
+
+	return result, nil
+}
@@ -0,0 +1,224 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/nodebyte/backend/internal/config"
+	"github.com/nodebyte/backend/internal/database"
+	"github.com/nodebyte/backend/internal/panels"
+)
+
+// serverStatusRetention is how long status events are kept for uptime history
+const serverStatusRetention = 90 * 24 * time.Hour
+
+// serverStateSuspended is recorded for suspended servers, which are not polled
+const serverStateSuspended = "suspended"
+
+// serverPollMu keeps a single power state poll running at a time, whether it
+// was scheduled or requested as a server_states sync
+var serverPollMu sync.Mutex
+
+// ServerStatusPoller polls every panel server's power state through the
+// client API, rate limited to ServerStateSyncRate requests per second. Each
+// poll records state transitions for uptime history, refreshes servers.status
+// and stores the reported resource usage.
+type ServerStatusPoller struct {
+	db          *database.DB
+	pteroClient *panels.PterodactylClient
+	rate        int // client API requests per second
+	batchSize   int // status updates and snapshots written per batch
+}
+
+// NewServerStatusPoller creates a new server status poller
+func NewServerStatusPoller(db *database.DB, pteroClient *panels.PterodactylClient, cfg *config.Config) *ServerStatusPoller {
+	return &ServerStatusPoller{
+		db:          db,
+		pteroClient: pteroClient,
+		rate:        max(cfg.ServerStateSyncRate, 1),
+		batchSize:   max(cfg.ServerStateSyncBatchSize, 1),
+	}
+}
+
+// ServerPollResult summarizes one power state poll
+type ServerPollResult struct {
+	Servers int // servers polled
+	Changed int // state transitions recorded
+	Updated int // servers.status values changed
+	Failed  int // servers whose state could not be fetched
+}
+
+// PollServerStatuses runs a scheduled poll, skipping it while another poll
+// is still running on large fleets
+func (p *ServerStatusPoller) PollServerStatuses(ctx context.Context) error {
+	if !serverPollMu.TryLock() {
+		log.Debug().Msg("Previous server status poll still running; skipping")
+		return nil
+	}
+	defer serverPollMu.Unlock()
+
+	result, err := p.poll(ctx, nil)
+	if err != nil {
+		return err
+	}
+	log.Debug().Int("servers", result.Servers).Int("changed", result.Changed).Int("updated", result.Updated).Int("failed", result.Failed).Msg("Server status poll complete")
+	return nil
+}
+
+// Poll runs a poll once any running poll has finished. progress, if set, is
+// called after each written batch with the servers checked so far.
+func (p *ServerStatusPoller) Poll(ctx context.Context, progress func(checked int, result ServerPollResult)) (ServerPollResult, error) {
+	serverPollMu.Lock()
+	defer serverPollMu.Unlock()
+	return p.poll(ctx, progress)
+}
+
+func (p *ServerStatusPoller) poll(ctx context.Context, progress func(checked int, result ServerPollResult)) (ServerPollResult, error) {
+	var result ServerPollResult
+
+	lastStates, err := p.db.LatestServerStates(ctx)
+	if err != nil {
+		return result, err
+	}
+
+	rows, err := p.db.Pool.Query(ctx, `
+		SELECT id, uuid, COALESCE("isSuspended", false), COALESCE(status, '') FROM servers
+		WHERE uuid IS NOT NULL AND uuid != '' AND "deletedAt" IS NULL
+	`)
+	if err != nil {
+		return result, err
+	}
+
+	type panelServer struct {
+		id        string
+		uuid      string
+		suspended bool
+		status    string
+	}
+	var servers []panelServer
+	for rows.Next() {
+		var s panelServer
+		if err := rows.Scan(&s.id, &s.uuid, &s.suspended, &s.status); err != nil {
+			rows.Close()
+			return result, err
+		}
+		servers = append(servers, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return result, err
+	}
+	result.Servers = len(servers)
+
+	limiter := time.NewTicker(time.Second / time.Duration(p.rate))
+	defer limiter.Stop()
+
+	var ids, statuses []string
+	var snapshots []database.ServerResourceSnapshot
+	flush := func() error {
+		if err := p.db.UpsertServerResourceSnapshots(ctx, snapshots); err != nil {
+			return fmt.Errorf("failed to store resource snapshots: %w", err)
+		}
+		snapshots = snapshots[:0]
+
+		if len(ids) == 0 {
+			return nil
+		}
+		if _, err := p.db.Pool.Exec(ctx, `
+			UPDATE servers SET status = u.status
+			FROM unnest($1::text[], $2::text[]) AS u(id, status)
+			WHERE servers.id = u.id
+		`, ids, statuses); err != nil {
+			return fmt.Errorf("failed to update server statuses: %w", err)
+		}
+		result.Updated += len(ids)
+		ids, statuses = ids[:0], statuses[:0]
+		return nil
+	}
+
+	for i, s := range servers {
+		state := serverStateSuspended
+		if !s.suspended {
+			select {
+			case <-ctx.Done():
+				return result, ctx.Err()
+			case <-limiter.C:
+			}
+
+			resources, err := p.pteroClient.GetServerResources(ctx, s.uuid)
+			if err != nil {
+				// A failed fetch says nothing about the server itself; skip it
+				log.Debug().Err(err).Str("server_uuid", s.uuid).Msg("Failed to fetch server state")
+				result.Failed++
+				continue
+			}
+			state = currentStateFromResources(resources)
+
+			// Servers in a panel-side state such as installing are left to
+			// the full sync
+			if status := statusFromPowerState(state); status != "" {
+				if status != s.status && isPowerStatus(s.status) {
+					ids = append(ids, s.id)
+					statuses = append(statuses, status)
+				}
+				snapshots = append(snapshots, resourceSnapshotFromResources(s.id, state, resources))
+			}
+		}
+
+		if state != "" && state != lastStates[s.id] {
+			if err := p.db.RecordServerStatusEvent(ctx, s.id, lastStates[s.id], state); err != nil {
+				log.Error().Err(err).Str("server_id", s.id).Msg("Failed to record server status event")
+			} else {
+				result.Changed++
+			}
+		}
+
+		if len(snapshots) >= p.batchSize || i == len(servers)-1 {
+			if err := flush(); err != nil {
+				return result, err
+			}
+			if progress != nil {
+				progress(i+1, result)
+			}
+		}
+	}
+	if err := flush(); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// isPowerStatus reports whether a servers.status value reflects a power state
+// the poller may overwrite; other statuses are owned by the full sync
+func isPowerStatus(status string) bool {
+	switch status {
+	case "", "online", "offline", "starting", "stopping":
+		return true
+	}
+	return false
+}
+
+// CleanupOldEvents removes status events past the retention window
+func (p *ServerStatusPoller) CleanupOldEvents(ctx context.Context) error {
+	deleted, err := p.db.DeleteServerStatusEventsBefore(ctx, time.Now().Add(-serverStatusRetention))
+	if err != nil {
+		return err
+	}
+	log.Info().Int64("deleted", deleted).Msg("Cleaned up old server status events")
+	return nil
+}
+
+// currentStateFromResources extracts attributes.current_state from a client
+// API resources response
+func currentStateFromResources(resources map[string]interface{}) string {
+	attrs, ok := resources["attributes"].(map[string]interface{})
+	if !ok {
+		return ""
+	}
+	state, _ := attrs["current_state"].(string)
+	return state
+}
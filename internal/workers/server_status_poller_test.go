@@ -0,0 +1,42 @@
+package workers
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCurrentStateFromResources(t *testing.T) {
+	tests := []struct {
+		name    string
+		payload string
+		want    string
+	}{
+		{
+			name:    "running server",
+			payload: `{"object": "stats", "attributes": {"current_state": "running", "is_suspended": false}}`,
+			want:    "running",
+		},
+		{
+			name:    "offline server",
+			payload: `{"object": "stats", "attributes": {"current_state": "offline"}}`,
+			want:    "offline",
+		},
+		{
+			name:    "missing attributes",
+			payload: `{"object": "stats"}`,
+			want:    "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var resources map[string]interface{}
+			if err := json.Unmarshal([]byte(tt.payload), &resources); err != nil {
+				t.Fatalf("failed to decode payload: %v", err)
+			}
+			if got := currentStateFromResources(resources); got != tt.want {
+				t.Errorf("currentStateFromResources() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
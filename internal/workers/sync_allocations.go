@@ -0,0 +1,70 @@
+package workers
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+	"golang.org/x/sync/errgroup"
+
+	"github.com/nodebyte/backend/internal/panels"
+)
+
+// allocationFetchAttempts is how many times one node's allocations are
+// requested before the node is skipped for this sync
+const allocationFetchAttempts = 3
+
+// allocationFetchBackoff is the wait before the first retry; it doubles on
+// each further attempt
+var allocationFetchBackoff = 2 * time.Second
+
+// nodeAllocations is one node's allocations as fetched from the panel
+type nodeAllocations struct {
+	node        panels.PteroNode
+	allocations []panels.PteroAllocation
+	err         error
+}
+
+// fetchAllocations fetches the allocations of every node, up to
+// SyncAllocationConcurrency nodes at a time, and sends each node's result on
+// the returned channel as it completes. The channel is closed once every node
+// is done, so callers must drain it.
+func (h *SyncHandler) fetchAllocations(ctx context.Context, nodes []panels.PteroNode) <-chan nodeAllocations {
+	results := make(chan nodeAllocations)
+
+	var g errgroup.Group
+	g.SetLimit(max(h.cfg.SyncAllocationConcurrency, 1))
+	go func() {
+		for _, node := range nodes {
+			g.Go(func() error {
+				allocations, err := h.fetchNodeAllocations(ctx, node.Attributes.ID)
+				results <- nodeAllocations{node: node, allocations: allocations, err: err}
+				return nil
+			})
+		}
+		g.Wait()
+		close(results)
+	}()
+	return results
+}
+
+// fetchNodeAllocations fetches one node's allocations, backing off and
+// retrying when the panel fails or rate limits the request
+func (h *SyncHandler) fetchNodeAllocations(ctx context.Context, nodeID int) ([]panels.PteroAllocation, error) {
+	backoff := allocationFetchBackoff
+	for attempt := 1; ; attempt++ {
+		allocations, err := h.pteroClient.GetAllAllocationsForNode(ctx, nodeID)
+		if err == nil || attempt == allocationFetchAttempts {
+			return allocations, err
+		}
+		log.Debug().Err(err).Int("node_id", nodeID).Int("attempt", attempt).Dur("backoff", backoff).
+			Msg("Retrying allocation fetch")
+
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
@@ -0,0 +1,85 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nodebyte/backend/internal/config"
+	"github.com/nodebyte/backend/internal/panels"
+)
+
+func TestFetchAllocationsConcurrently(t *testing.T) {
+	allocationFetchBackoff = time.Millisecond
+	defer func() { allocationFetchBackoff = 2 * time.Second }()
+
+	var inFlight, maxInFlight atomic.Int32
+	var mu sync.Mutex
+	failures := map[string]int{"/api/application/nodes/3/allocations": 1, "/api/application/nodes/4/allocations": allocationFetchAttempts}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			m := maxInFlight.Load()
+			if n <= m || maxInFlight.CompareAndSwap(m, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		fail := failures[r.URL.Path] > 0
+		if fail {
+			failures[r.URL.Path]--
+		}
+		mu.Unlock()
+		if fail {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		var nodeID int
+		fmt.Sscanf(r.URL.Path, "/api/application/nodes/%d/allocations", &nodeID)
+		fmt.Fprintf(w, `{"object": "list", "data": [{"object": "allocation", "attributes": {"id": %d}}],
+			"meta": {"pagination": {"total": 1, "count": 1, "per_page": 100, "current_page": 1, "total_pages": 1}}}`, nodeID*100)
+	}))
+	defer server.Close()
+
+	h := &SyncHandler{
+		pteroClient: panels.NewPterodactylClient(server.URL, "key", "", ""),
+		cfg:         &config.Config{SyncAllocationConcurrency: 2},
+	}
+	nodes := make([]panels.PteroNode, 5)
+	for i := range nodes {
+		nodes[i].Attributes.ID = i + 1
+	}
+
+	got := map[int]nodeAllocations{}
+	for result := range h.fetchAllocations(context.Background(), nodes) {
+		got[result.node.Attributes.ID] = result
+	}
+
+	if len(got) != len(nodes) {
+		t.Fatalf("got results for %d nodes, want %d", len(got), len(nodes))
+	}
+	for id, result := range got {
+		if id == 4 {
+			if result.err == nil {
+				t.Error("node 4 failed every attempt but reported no error")
+			}
+			continue
+		}
+		if result.err != nil || len(result.allocations) != 1 || result.allocations[0].Attributes.ID != id*100 {
+			t.Errorf("node %d = %+v, want one allocation %d", id, result, id*100)
+		}
+	}
+	if m := maxInFlight.Load(); m > 2 {
+		t.Errorf("max concurrent requests = %d, want at most 2", m)
+	}
+}
@@ -0,0 +1,99 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+
+	"github.com/nodebyte/backend/internal/queue"
+	"github.com/nodebyte/backend/internal/sentry"
+)
+
+// runBatchStep runs one step of a batch sync
+func (h *SyncHandler) runBatchStep(ctx context.Context, syncLogID, step string, seen *seenPanelIDs) error {
+	switch step {
+	case "locations":
+		return h.syncLocations(ctx, syncLogID, seen)
+	case "nodes":
+		return h.syncNodes(ctx, syncLogID, seen)
+	case "allocations":
+		return h.syncAllocations(ctx, syncLogID)
+	case "nests":
+		return h.syncNestsAndEggs(ctx, syncLogID)
+	case "users":
+		return h.syncUsers(ctx, syncLogID)
+	case "servers":
+		if err := h.syncServers(ctx, syncLogID); err != nil {
+			return err
+		}
+		h.pruneStaleInfrastructure(ctx, syncLogID, seen)
+		return nil
+	case "databases":
+		return h.syncDatabases(ctx, syncLogID)
+	case "server_states":
+		return h.syncServerStates(ctx, syncLogID)
+	}
+	return fmt.Errorf("unknown sync type: %s", step)
+}
+
+// HandleSyncBatch runs the requested sync steps in order under one sync log.
+// Each step starts only after the previous one completes, the log's progress
+// counts finished steps, and the first failure stops the batch.
+func (h *SyncHandler) HandleSyncBatch(ctx context.Context, task *asynq.Task) error {
+	tx := sentry.StartBackgroundTransaction(ctx, "worker.batch_sync")
+	defer tx.Finish()
+	ctx = tx.Context()
+
+	var payload queue.SyncBatchPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		sentry.CaptureExceptionWithContext(ctx, err, "unmarshal_payload")
+		return fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+	if err := queue.ValidateBatchSteps(payload.Steps); err != nil {
+		return h.failSync(ctx, payload.SyncLogID, "starting", err)
+	}
+
+	log.Info().
+		Str("sync_log_id", payload.SyncLogID).
+		Str("requested_by", payload.RequestedBy).
+		Strs("steps", payload.Steps).
+		Msg("Starting batch sync")
+
+	startTime := time.Now()
+	h.syncRepo.UpdateSyncLog(ctx, payload.SyncLogID, "RUNNING", nil, nil, nil, map[string]interface{}{
+		"step":       "starting",
+		"started_at": time.Now().Unix(),
+	})
+
+	seen := &seenPanelIDs{}
+	for i, step := range payload.Steps {
+		if cancelled, _ := h.syncRepo.IsSyncCancelled(ctx, payload.SyncLogID); cancelled {
+			return h.cancelSync(ctx, payload.SyncLogID, fmt.Sprintf("Cancelled before %s sync", step))
+		}
+		h.updateProgress(ctx, payload.SyncLogID, step, i*100/len(payload.Steps))
+		if err := h.runBatchStep(ctx, payload.SyncLogID, step, seen); err != nil {
+			return h.failSync(ctx, payload.SyncLogID, step, err)
+		}
+		h.completeStep(ctx, payload.SyncLogID, step)
+	}
+
+	duration := time.Since(startTime)
+	h.updateProgress(ctx, payload.SyncLogID, "completed", 100)
+	h.syncRepo.UpdateSyncLog(ctx, payload.SyncLogID, "COMPLETED", nil, nil, nil, map[string]interface{}{
+		"completed_at": time.Now().Unix(),
+		"duration":     duration.Seconds(),
+	})
+
+	log.Info().
+		Str("sync_log_id", payload.SyncLogID).
+		Float64("duration_seconds", duration.Seconds()).
+		Msg("Batch sync completed")
+
+	go h.dispatchSyncWebhook(ctx, payload.SyncLogID, "COMPLETED", duration, nil)
+
+	return nil
+}
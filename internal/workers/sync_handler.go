@@ -1,19 +1,21 @@
 package workers
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strconv"
 	"strings"
 	"time"
 
 	"github.com/hibiken/asynq"
+	"github.com/jackc/pgx/v5"
 	"github.com/rs/zerolog/log"
 
 	"github.com/nodebyte/backend/internal/config"
 	"github.com/nodebyte/backend/internal/database"
+	"github.com/nodebyte/backend/internal/discord"
 	"github.com/nodebyte/backend/internal/panels"
 	"github.com/nodebyte/backend/internal/queue"
 	"github.com/nodebyte/backend/internal/sentry"
@@ -21,10 +23,11 @@ import (
 
 // SyncHandler handles sync-related tasks
 type SyncHandler struct {
-	db          *database.DB
-	syncRepo    *database.SyncRepository
-	pteroClient *panels.PterodactylClient
-	cfg         *config.Config
+	db           *database.DB
+	syncRepo     *database.SyncRepository
+	pteroClient  *panels.PterodactylClient
+	cfg          *config.Config
+	queueManager *queue.Manager // optional; emails are skipped without it
 }
 
 // NewSyncHandler creates a new sync handler
@@ -37,6 +40,12 @@ func NewSyncHandler(db *database.DB, pteroClient *panels.PterodactylClient, cfg
 	}
 }
 
+// SetQueueManager lets the handler queue emails, such as server address
+// change notices, from sync tasks
+func (h *SyncHandler) SetQueueManager(m *queue.Manager) {
+	h.queueManager = m
+}
+
 // HandleFullSync processes a full sync task
 func (h *SyncHandler) HandleFullSync(ctx context.Context, task *asynq.Task) error {
 	tx := sentry.StartBackgroundTransaction(ctx, "worker.full_sync")
@@ -52,6 +61,7 @@ func (h *SyncHandler) HandleFullSync(ctx context.Context, task *asynq.Task) erro
 	log.Info().
 		Str("sync_log_id", payload.SyncLogID).
 		Str("requested_by", payload.RequestedBy).
+		Str("start_step", payload.StartStep).
 		Msg("Starting full sync")
 
 	startTime := time.Now()
@@ -67,45 +77,70 @@ func (h *SyncHandler) HandleFullSync(ctx context.Context, task *asynq.Task) erro
 		cancelled, _ := h.syncRepo.IsSyncCancelled(ctx, payload.SyncLogID)
 		return cancelled
 	}
+	seen := &seenPanelIDs{}
 
-	// Step 1: Sync Locations
-	if checkCancelled() {
-		return h.cancelSync(ctx, payload.SyncLogID, "Cancelled before locations sync")
+	// When resuming, steps before StartStep already completed in the original sync
+	startIndex := 0
+	if payload.StartStep != "" {
+		startIndex = queue.FullSyncStepIndex(payload.StartStep)
+		if startIndex < 0 {
+			return h.failSync(ctx, payload.SyncLogID, "starting", fmt.Errorf("unknown start step: %s", payload.StartStep))
+		}
 	}
-	h.updateProgress(ctx, payload.SyncLogID, "locations", 0)
-	if err := h.syncLocations(ctx, payload.SyncLogID); err != nil {
-		return h.failSync(ctx, payload.SyncLogID, "locations", err)
+	runStep := func(step string) bool {
+		return queue.FullSyncStepIndex(step) >= startIndex
 	}
 
-	// Step 2: Sync Nodes
-	if checkCancelled() {
-		return h.cancelSync(ctx, payload.SyncLogID, "Cancelled before nodes sync")
+	// Step 1: Sync Locations
+	if runStep("locations") {
+		if checkCancelled() {
+			return h.cancelSync(ctx, payload.SyncLogID, "Cancelled before locations sync")
+		}
+		h.updateProgress(ctx, payload.SyncLogID, "locations", 0)
+		if err := h.syncLocations(ctx, payload.SyncLogID, seen); err != nil {
+			return h.failSync(ctx, payload.SyncLogID, "locations", err)
+		}
+		h.completeStep(ctx, payload.SyncLogID, "locations")
 	}
-	h.updateProgress(ctx, payload.SyncLogID, "nodes", 15)
-	if err := h.syncNodes(ctx, payload.SyncLogID); err != nil {
-		return h.failSync(ctx, payload.SyncLogID, "nodes", err)
+
+	// Step 2: Sync Nodes
+	if runStep("nodes") {
+		if checkCancelled() {
+			return h.cancelSync(ctx, payload.SyncLogID, "Cancelled before nodes sync")
+		}
+		h.updateProgress(ctx, payload.SyncLogID, "nodes", 15)
+		if err := h.syncNodes(ctx, payload.SyncLogID, seen); err != nil {
+			return h.failSync(ctx, payload.SyncLogID, "nodes", err)
+		}
+		h.completeStep(ctx, payload.SyncLogID, "nodes")
 	}
 
 	// Step 3: Sync Allocations
-	if checkCancelled() {
-		return h.cancelSync(ctx, payload.SyncLogID, "Cancelled before allocations sync")
-	}
-	h.updateProgress(ctx, payload.SyncLogID, "allocations", 30)
-	if err := h.syncAllocations(ctx, payload.SyncLogID); err != nil {
-		return h.failSync(ctx, payload.SyncLogID, "allocations", err)
+	if runStep("allocations") {
+		if checkCancelled() {
+			return h.cancelSync(ctx, payload.SyncLogID, "Cancelled before allocations sync")
+		}
+		h.updateProgress(ctx, payload.SyncLogID, "allocations", 30)
+		if err := h.syncAllocations(ctx, payload.SyncLogID); err != nil {
+			return h.failSync(ctx, payload.SyncLogID, "allocations", err)
+		}
+		h.completeStep(ctx, payload.SyncLogID, "allocations")
 	}
 
 	// Step 4: Sync Nests & Eggs
-	if checkCancelled() {
-		return h.cancelSync(ctx, payload.SyncLogID, "Cancelled before nests sync")
-	}
-	h.updateProgress(ctx, payload.SyncLogID, "nests", 45)
-	if err := h.syncNestsAndEggs(ctx, payload.SyncLogID); err != nil {
-		return h.failSync(ctx, payload.SyncLogID, "nests", err)
+	if runStep("nests") {
+		if checkCancelled() {
+			return h.cancelSync(ctx, payload.SyncLogID, "Cancelled before nests sync")
+		}
+		h.updateProgress(ctx, payload.SyncLogID, "nests", 45)
+		if err := h.syncNestsAndEggs(ctx, payload.SyncLogID); err != nil {
+			return h.failSync(ctx, payload.SyncLogID, "nests", err)
+		}
+		h.completeStep(ctx, payload.SyncLogID, "nests")
 	}
 
 	// Step 5: Sync Users — BEFORE servers so ownerId lookups succeed
-	if !payload.SkipUsers {
+	if !payload.SkipUsers && runStep("users") {
 		if checkCancelled() {
 			return h.cancelSync(ctx, payload.SyncLogID, "Cancelled before users sync")
 		}
@@ -113,15 +148,23 @@ func (h *SyncHandler) HandleFullSync(ctx context.Context, task *asynq.Task) erro
 		if err := h.syncUsers(ctx, payload.SyncLogID); err != nil {
 			return h.failSync(ctx, payload.SyncLogID, "users", err)
 		}
+		h.completeStep(ctx, payload.SyncLogID, "users")
 	}
 
 	// Step 6: Sync Servers — users now exist so ownerId FK resolves correctly
-	if checkCancelled() {
-		return h.cancelSync(ctx, payload.SyncLogID, "Cancelled before servers sync")
-	}
-	h.updateProgress(ctx, payload.SyncLogID, "servers", 75)
-	if err := h.syncServers(ctx, payload.SyncLogID); err != nil {
-		return h.failSync(ctx, payload.SyncLogID, "servers", err)
+	if runStep("servers") {
+		if checkCancelled() {
+			return h.cancelSync(ctx, payload.SyncLogID, "Cancelled before servers sync")
+		}
+		h.updateProgress(ctx, payload.SyncLogID, "servers", 75)
+		if err := h.syncServers(ctx, payload.SyncLogID); err != nil {
+			return h.failSync(ctx, payload.SyncLogID, "servers", err)
+		}
+		h.completeStep(ctx, payload.SyncLogID, "servers")
+
+		// Nodes and locations kept earlier because stale servers still
+		// referenced them can go now
+		h.pruneStaleInfrastructure(ctx, payload.SyncLogID, seen)
 	}
 
 	// Step 7: Sync Server Subusers (Client API - selective)
@@ -132,6 +175,9 @@ func (h *SyncHandler) HandleFullSync(ctx context.Context, task *asynq.Task) erro
 	if err := h.syncServerSubusers(ctx, payload.SyncLogID); err != nil {
 		log.Warn().Err(err).Msg("Subuser sync failed - continuing with full sync")
 		// Don't fail entire sync if subusers fail
+		h.failStep(ctx, payload.SyncLogID, "subusers", err)
+	} else {
+		h.completeStep(ctx, payload.SyncLogID, "subusers")
 	}
 
 	// Calculate duration
@@ -160,104 +206,78 @@ func (h *SyncHandler) dispatchSyncWebhook(ctx context.Context, syncLogID, status
 	// Create a new background context instead of using the task context which may be cancelled
 	bgCtx := context.Background()
 
-	// Get all enabled SYSTEM webhooks
-	query := `
-		SELECT "webhookUrl" 
-		FROM discord_webhooks 
-		WHERE enabled = true 
-		AND type = 'SYSTEM' 
-		AND scope = 'ADMIN'
-	`
+	errMsg := ""
+	if syncError != nil {
+		errMsg = syncError.Error()
+	}
+	message := h.syncResultMessage(bgCtx, syncLogID, status, duration, errMsg)
 
-	rows, err := h.db.Pool.Query(bgCtx, query)
+	webhooks, err := h.db.GetSyncNotificationWebhooks(bgCtx)
 	if err != nil {
 		log.Warn().Err(err).Msg("Failed to fetch webhooks for sync notification")
 		return
 	}
-	defer rows.Close()
 
-	var webhookURLs []string
-	for rows.Next() {
-		var url string
-		if err := rows.Scan(&url); err != nil {
-			continue
-		}
-		webhookURLs = append(webhookURLs, url)
+	urls := make([]string, 0, len(webhooks))
+	for _, webhook := range webhooks {
+		urls = append(urls, webhook.URL)
 	}
 
-	if len(webhookURLs) == 0 {
-		return
+	// Send to WEBHOOK_CONCURRENCY webhooks at a time; POST /api/admin/sync/{id}/notify re-sends lost ones
+	client := &http.Client{Timeout: 10 * time.Second}
+	result := discord.Broadcast(bgCtx, client, urls, message, h.cfg.WebhookConcurrency)
+	for _, err := range result.Failed {
+		log.Warn().Err(err).Str("sync_log_id", syncLogID).Msg("Failed to send sync webhook")
+	}
+	for _, webhook := range webhooks {
+		recordWebhookDelivery(bgCtx, h.db, webhook.ID, syncResultEvent(status), result.Failed[webhook.URL])
+	}
+	if len(urls) > 0 {
+		log.Info().
+			Str("sync_log_id", syncLogID).
+			Int("sent", result.Sent).
+			Int("failed", len(result.Failed)).
+			Msg("Sync webhooks dispatched")
 	}
+}
 
-	// Determine color and status text
-	color := 3066993 // Green
-	statusEmoji := "✅"
-	statusText := "Completed Successfully"
+// countsTowardFailureStreak reports whether a sync of syncType updates the
+// consecutive failure streak. Only full and batch syncs do: single-entity
+// syncs never reset it on success, so their failures would pile up.
+func countsTowardFailureStreak(syncType string) bool {
+	return syncType == "full" || syncType == "batch"
+}
 
-	if status == "FAILED" {
-		color = 15158332 // Red
-		statusEmoji = "❌"
-		statusText = "Failed"
-	} else if status == "CANCELLED" {
-		color = 16776960 // Yellow
-		statusEmoji = "⚠️"
-		statusText = "Cancelled"
+// syncResultMessage updates the consecutive failure streak and builds the
+// result notification, escalating a failure to an alert once the streak
+// reaches SyncFailureAlertThreshold
+func (h *SyncHandler) syncResultMessage(ctx context.Context, syncLogID, status string, duration time.Duration, errMsg string) discord.Message {
+	syncLog, err := h.syncRepo.GetSyncLog(ctx, syncLogID)
+	if err != nil {
+		log.Warn().Err(err).Str("sync_log_id", syncLogID).Msg("Failed to load sync log for failure streak")
+		return discord.SyncResultMessage(status, duration, errMsg)
 	}
-
-	fields := []map[string]interface{}{
-		{
-			"name":   "Status",
-			"value":  fmt.Sprintf("%s %s", statusEmoji, statusText),
-			"inline": true,
-		},
-		{
-			"name":   "Duration",
-			"value":  fmt.Sprintf("%.2f seconds", duration.Seconds()),
-			"inline": true,
-		},
+	if !countsTowardFailureStreak(syncLog.Type) {
+		return discord.SyncResultMessage(status, duration, errMsg)
 	}
 
-	if syncError != nil {
-		fields = append(fields, map[string]interface{}{
-			"name":   "Error",
-			"value":  syncError.Error(),
-			"inline": false,
-		})
-	}
-
-	// Prepare webhook payload
-	payload := map[string]interface{}{
-		"embeds": []map[string]interface{}{
-			{
-				"title":       "🔄 Sync Operation " + statusText,
-				"description": "Panel synchronization has " + strings.ToLower(statusText),
-				"color":       color,
-				"fields":      fields,
-				"timestamp":   time.Now().Format(time.RFC3339),
-				"footer": map[string]string{
-					"text": "NodeByte Sync System",
-				},
-			},
-		},
-	}
-
-	payloadBytes, _ := json.Marshal(payload)
-
-	// Send to all webhooks in parallel
-	for _, webhookURL := range webhookURLs {
-		go func(url string) {
-			resp, err := http.Post(url, "application/json", bytes.NewReader(payloadBytes)) // FIX: Use bytes.NewReader
-			if err != nil {
-				log.Warn().Err(err).Str("webhook_url", url).Msg("Failed to send sync webhook")
-				return
-			}
-			defer resp.Body.Close()
-
-			if resp.StatusCode != http.StatusNoContent {
-				log.Warn().Int("status", resp.StatusCode).Str("webhook_url", url).Msg("Webhook returned non-204 status")
-			}
-		}(webhookURL)
+	switch status {
+	case "COMPLETED":
+		if err := h.db.ResetSyncFailures(ctx); err != nil {
+			log.Warn().Err(err).Msg("Failed to reset sync failure streak")
+		}
+	case "FAILED":
+		streak, err := h.db.RecordSyncFailure(ctx, syncLogID)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to record sync failure streak")
+			break
+		}
+		if threshold := h.cfg.SyncFailureAlertThreshold; threshold > 0 && streak >= threshold {
+			log.Warn().Str("sync_log_id", syncLogID).Int("consecutive_failures", streak).Msg("Syncs failing repeatedly; sending escalated alert")
+			return discord.SyncFailureAlertMessage(duration, errMsg, streak, h.cfg.SyncFailureAlertMention)
+		}
 	}
+	return discord.SyncResultMessage(status, duration, errMsg)
 }
 
 // HandleSyncLocations syncs only locations
@@ -269,9 +289,10 @@ func (h *SyncHandler) HandleSyncLocations(ctx context.Context, task *asynq.Task)
 	h.syncRepo.UpdateSyncLog(ctx, payload.SyncLogID, "RUNNING", nil, nil, nil, map[string]interface{}{
 		"step": "locations", "lastUpdated": time.Now().Unix(),
 	})
-	if err := h.syncLocations(ctx, payload.SyncLogID); err != nil {
+	if err := h.syncLocations(ctx, payload.SyncLogID, nil); err != nil {
 		return h.failSync(ctx, payload.SyncLogID, "locations", err)
 	}
+	h.completeStep(ctx, payload.SyncLogID, "locations")
 	h.syncRepo.UpdateSyncLog(ctx, payload.SyncLogID, "COMPLETED", nil, nil, nil, map[string]interface{}{
 		"step": "locations", "completed_at": time.Now().Unix(), "lastUpdated": time.Now().Unix(),
 	})
@@ -287,9 +308,10 @@ func (h *SyncHandler) HandleSyncNodes(ctx context.Context, task *asynq.Task) err
 	h.syncRepo.UpdateSyncLog(ctx, payload.SyncLogID, "RUNNING", nil, nil, nil, map[string]interface{}{
 		"step": "nodes", "lastUpdated": time.Now().Unix(),
 	})
-	if err := h.syncNodes(ctx, payload.SyncLogID); err != nil {
+	if err := h.syncNodes(ctx, payload.SyncLogID, nil); err != nil {
 		return h.failSync(ctx, payload.SyncLogID, "nodes", err)
 	}
+	h.completeStep(ctx, payload.SyncLogID, "nodes")
 	h.syncRepo.UpdateSyncLog(ctx, payload.SyncLogID, "COMPLETED", nil, nil, nil, map[string]interface{}{
 		"step": "nodes", "completed_at": time.Now().Unix(), "lastUpdated": time.Now().Unix(),
 	})
@@ -308,6 +330,7 @@ func (h *SyncHandler) HandleSyncAllocations(ctx context.Context, task *asynq.Tas
 	if err := h.syncAllocations(ctx, payload.SyncLogID); err != nil {
 		return h.failSync(ctx, payload.SyncLogID, "allocations", err)
 	}
+	h.completeStep(ctx, payload.SyncLogID, "allocations")
 	h.syncRepo.UpdateSyncLog(ctx, payload.SyncLogID, "COMPLETED", nil, nil, nil, map[string]interface{}{
 		"step": "allocations", "completed_at": time.Now().Unix(), "lastUpdated": time.Now().Unix(),
 	})
@@ -326,6 +349,7 @@ func (h *SyncHandler) HandleSyncNests(ctx context.Context, task *asynq.Task) err
 	if err := h.syncNestsAndEggs(ctx, payload.SyncLogID); err != nil {
 		return h.failSync(ctx, payload.SyncLogID, "nests", err)
 	}
+	h.completeStep(ctx, payload.SyncLogID, "nests")
 	h.syncRepo.UpdateSyncLog(ctx, payload.SyncLogID, "COMPLETED", nil, nil, nil, map[string]interface{}{
 		"step": "nests", "completed_at": time.Now().Unix(), "lastUpdated": time.Now().Unix(),
 	})
@@ -344,6 +368,8 @@ func (h *SyncHandler) HandleSyncServers(ctx context.Context, task *asynq.Task) e
 	if err := h.syncServers(ctx, payload.SyncLogID); err != nil {
 		return h.failSync(ctx, payload.SyncLogID, "servers", err)
 	}
+	h.completeStep(ctx, payload.SyncLogID, "servers")
+	h.pruneStaleInfrastructure(ctx, payload.SyncLogID, nil)
 	h.syncRepo.UpdateSyncLog(ctx, payload.SyncLogID, "COMPLETED", nil, nil, nil, map[string]interface{}{
 		"step": "servers", "completed_at": time.Now().Unix(), "lastUpdated": time.Now().Unix(),
 	})
@@ -362,6 +388,7 @@ func (h *SyncHandler) HandleSyncDatabases(ctx context.Context, task *asynq.Task)
 	if err := h.syncDatabases(ctx, payload.SyncLogID); err != nil {
 		return h.failSync(ctx, payload.SyncLogID, "databases", err)
 	}
+	h.completeStep(ctx, payload.SyncLogID, "databases")
 	h.syncRepo.UpdateSyncLog(ctx, payload.SyncLogID, "COMPLETED", nil, nil, nil, map[string]interface{}{
 		"step": "databases", "completed_at": time.Now().Unix(), "lastUpdated": time.Now().Unix(),
 	})
@@ -380,12 +407,37 @@ func (h *SyncHandler) HandleSyncUsers(ctx context.Context, task *asynq.Task) err
 	if err := h.syncUsers(ctx, payload.SyncLogID); err != nil {
 		return h.failSync(ctx, payload.SyncLogID, "users", err)
 	}
+	h.completeStep(ctx, payload.SyncLogID, "users")
 	h.syncRepo.UpdateSyncLog(ctx, payload.SyncLogID, "COMPLETED", nil, nil, nil, map[string]interface{}{
 		"step": "users", "completed_at": time.Now().Unix(), "lastUpdated": time.Now().Unix(),
 	})
 	return nil
 }
 
+// HandleSyncServerStates refreshes servers.status from live power states.
+// Scheduled refreshes carry no sync log and only log their result.
+func (h *SyncHandler) HandleSyncServerStates(ctx context.Context, task *asynq.Task) error {
+	var payload queue.SyncPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+	if payload.SyncLogID == "" {
+		return h.syncServerStates(ctx, "")
+	}
+
+	h.syncRepo.UpdateSyncLog(ctx, payload.SyncLogID, "RUNNING", nil, nil, nil, map[string]interface{}{
+		"step": "server_states", "lastUpdated": time.Now().Unix(),
+	})
+	if err := h.syncServerStates(ctx, payload.SyncLogID); err != nil {
+		return h.failSync(ctx, payload.SyncLogID, "server_states", err)
+	}
+	h.completeStep(ctx, payload.SyncLogID, "server_states")
+	h.syncRepo.UpdateSyncLog(ctx, payload.SyncLogID, "COMPLETED", nil, nil, nil, map[string]interface{}{
+		"step": "server_states", "completed_at": time.Now().Unix(), "lastUpdated": time.Now().Unix(),
+	})
+	return nil
+}
+
 // HandleCleanupLogs cleans up old sync logs
 func (h *SyncHandler) HandleCleanupLogs(ctx context.Context, task *asynq.Task) error {
 	var payload struct {
@@ -413,12 +465,18 @@ func (h *SyncHandler) HandleCleanupLogs(ctx context.Context, task *asynq.Task) e
 		Int("older_than_days", days).
 		Msg("Cleaned up old sync logs")
 
+	deliveries, err := h.db.PruneWebhookDeliveries(ctx, cutoff)
+	if err != nil {
+		return fmt.Errorf("failed to cleanup webhook deliveries: %w", err)
+	}
+	log.Info().Int64("deleted", deliveries).Int("older_than_days", days).Msg("Cleaned up old webhook deliveries")
+
 	return nil
 }
 
 // Internal sync methods
 
-func (h *SyncHandler) syncLocations(ctx context.Context, syncLogID string) error {
+func (h *SyncHandler) syncLocations(ctx context.Context, syncLogID string, seen *seenPanelIDs) error {
 	log.Debug().Str("sync_log_id", syncLogID).Msg("Syncing locations")
 
 	locations, err := h.pteroClient.GetAllLocations(ctx)
@@ -428,6 +486,7 @@ func (h *SyncHandler) syncLocations(ctx context.Context, syncLogID string) error
 
 	h.updateDetailedProgress(ctx, syncLogID, "locations", len(locations), 0, fmt.Sprintf("Fetched %d locations from panel", len(locations)))
 
+	var changes []database.SyncChange
 	for i, loc := range locations {
 		query := `
 			INSERT INTO locations (id, "shortCode", description, "createdAt", "updatedAt")
@@ -436,14 +495,18 @@ func (h *SyncHandler) syncLocations(ctx context.Context, syncLogID string) error
 				"shortCode" = EXCLUDED."shortCode",
 				description = EXCLUDED.description,
 				"updatedAt" = NOW()
+			RETURNING (xmax = 0)
 		`
-		_, err := h.db.Pool.Exec(ctx, query,
+		var inserted bool
+		err := h.db.Pool.QueryRow(ctx, query,
 			loc.Attributes.ID,
 			loc.Attributes.ShortCode,
 			loc.Attributes.Long,
-		)
+		).Scan(&inserted)
 		if err != nil {
 			log.Warn().Err(err).Int("location_id", loc.Attributes.ID).Msg("Failed to upsert location")
+		} else if inserted {
+			changes = append(changes, newSyncChange("location", strconv.Itoa(loc.Attributes.ID), database.SyncChangeCreated, loc.Attributes.ShortCode))
 		}
 
 		// Update progress every 10 items or at end
@@ -454,25 +517,27 @@ func (h *SyncHandler) syncLocations(ctx context.Context, syncLogID string) error
 
 	// Remove stale locations no longer in the panel
 	if len(locations) > 0 {
-		ids := make([]interface{}, len(locations))
-		ph := make([]string, len(locations))
+		ids := make([]int, len(locations))
 		for i, loc := range locations {
 			ids[i] = loc.Attributes.ID
-			ph[i] = fmt.Sprintf("$%d", i+1)
 		}
-		if res, err := h.db.Pool.Exec(ctx, `DELETE FROM locations WHERE id NOT IN (`+strings.Join(ph, ",")+`)`, ids...); err != nil {
+		seen.setLocations(ids)
+		deleted, err := h.deleteStale(ctx, staleLocations, ids)
+		if err != nil {
 			log.Warn().Err(err).Msg("Failed to delete stale locations")
-		} else if res.RowsAffected() > 0 {
-			log.Info().Int64("deleted", res.RowsAffected()).Msg("Deleted stale locations")
+		} else if len(deleted) > 0 {
+			log.Info().Int("deleted", len(deleted)).Msg("Deleted stale locations")
 		}
+		changes = append(changes, deleted...)
 	}
+	h.recordChanges(ctx, syncLogID, changes)
 
 	log.Info().Int("count", len(locations)).Msg("Synced locations")
 	h.updateDetailedProgress(ctx, syncLogID, "locations", len(locations), len(locations), fmt.Sprintf("✓ Synced %d locations", len(locations)))
 	return nil
 }
 
-func (h *SyncHandler) syncNodes(ctx context.Context, syncLogID string) error {
+func (h *SyncHandler) syncNodes(ctx context.Context, syncLogID string, seen *seenPanelIDs) error {
 	log.Debug().Str("sync_log_id", syncLogID).Msg("Syncing nodes")
 
 	nodes, err := h.pteroClient.GetAllNodes(ctx)
@@ -482,6 +547,7 @@ func (h *SyncHandler) syncNodes(ctx context.Context, syncLogID string) error {
 
 	h.updateDetailedProgress(ctx, syncLogID, "nodes", len(nodes), 0, fmt.Sprintf("Fetched %d nodes from panel", len(nodes)))
 
+	var changes []database.SyncChange
 	for i, node := range nodes {
 		query := `
 			INSERT INTO nodes (
@@ -508,8 +574,10 @@ func (h *SyncHandler) syncNodes(ctx context.Context, syncLogID string) error {
 				"daemonBase" = EXCLUDED."daemonBase",
 				"locationId" = EXCLUDED."locationId",
 				"updatedAt" = NOW()
+			RETURNING (xmax = 0)
 		`
-		_, err := h.db.Pool.Exec(ctx, query,
+		var inserted bool
+		err := h.db.Pool.QueryRow(ctx, query,
 			node.Attributes.ID,
 			node.Attributes.UUID,
 			node.Attributes.Name,
@@ -528,9 +596,11 @@ func (h *SyncHandler) syncNodes(ctx context.Context, syncLogID string) error {
 			node.Attributes.DaemonSFTP,
 			node.Attributes.DaemonBase,
 			node.Attributes.LocationID,
-		)
+		).Scan(&inserted)
 		if err != nil {
 			log.Warn().Err(err).Int("node_id", node.Attributes.ID).Msg("Failed to upsert node")
+		} else if inserted {
+			changes = append(changes, newSyncChange("node", strconv.Itoa(node.Attributes.ID), database.SyncChangeCreated, node.Attributes.Name))
 		}
 
 		// Update progress every 5 items or at end
@@ -541,18 +611,20 @@ func (h *SyncHandler) syncNodes(ctx context.Context, syncLogID string) error {
 
 	// Remove stale nodes no longer in the panel
 	if len(nodes) > 0 {
-		ids := make([]interface{}, len(nodes))
-		ph := make([]string, len(nodes))
+		ids := make([]int, len(nodes))
 		for i, node := range nodes {
 			ids[i] = node.Attributes.ID
-			ph[i] = fmt.Sprintf("$%d", i+1)
 		}
-		if res, err := h.db.Pool.Exec(ctx, `DELETE FROM nodes WHERE id NOT IN (`+strings.Join(ph, ",")+`)`, ids...); err != nil {
+		seen.setNodes(ids)
+		deleted, err := h.deleteStale(ctx, staleNodes, ids)
+		if err != nil {
 			log.Warn().Err(err).Msg("Failed to delete stale nodes")
-		} else if res.RowsAffected() > 0 {
-			log.Info().Int64("deleted", res.RowsAffected()).Msg("Deleted stale nodes")
+		} else if len(deleted) > 0 {
+			log.Info().Int("deleted", len(deleted)).Msg("Deleted stale nodes")
 		}
+		changes = append(changes, deleted...)
 	}
+	h.recordChanges(ctx, syncLogID, changes)
 
 	log.Info().Int("count", len(nodes)).Msg("Synced nodes")
 	h.updateDetailedProgress(ctx, syncLogID, "nodes", len(nodes), len(nodes), fmt.Sprintf("✓ Synced %d nodes", len(nodes)))
@@ -572,17 +644,23 @@ func (h *SyncHandler) syncAllocations(ctx context.Context, syncLogID string) err
 
 	totalAllocations := 0
 	processedAllocations := 0
-	batchSize := 500                   // Insert 500 allocations at a time for better performance
-	allSeenAllocIDs := []interface{}{} // collect all allocation IDs for stale cleanup
-
-	for nodeIdx, node := range nodes {
-		allocations, err := h.pteroClient.GetAllAllocationsForNode(ctx, node.Attributes.ID)
-		if err != nil {
-			log.Warn().Err(err).Int("node_id", node.Attributes.ID).Msg("Failed to fetch allocations")
+	batchSize := 500           // Insert 500 allocations at a time for better performance
+	allSeenAllocIDs := []int{} // collect all allocation IDs for stale cleanup
+	fetchedNodeIDs := []int{}  // nodes whose allocations were listed in full
+	nodesDone := 0
+
+	// Nodes are fetched concurrently; their results are written here one at a
+	// time as they arrive
+	for result := range h.fetchAllocations(ctx, nodes) {
+		nodesDone++
+		node, allocations := result.node, result.allocations
+		if result.err != nil {
+			log.Warn().Err(result.err).Int("node_id", node.Attributes.ID).Msg("Failed to fetch allocations")
 			continue
 		}
+		fetchedNodeIDs = append(fetchedNodeIDs, node.Attributes.ID)
 
-		h.updateDetailedProgress(ctx, syncLogID, "allocations", 0, processedAllocations, fmt.Sprintf("Processing node %d/%d (%s): %d allocations", nodeIdx+1, len(nodes), node.Attributes.Name, len(allocations)))
+		h.updateDetailedProgress(ctx, syncLogID, "allocations", 0, processedAllocations, fmt.Sprintf("Processing node %d/%d (%s): %d allocations", nodesDone, len(nodes), node.Attributes.Name, len(allocations)))
 
 		// Batch insert allocations
 		for _, alloc := range allocations {
@@ -630,20 +708,17 @@ func (h *SyncHandler) syncAllocations(ctx context.Context, syncLogID string) err
 			processedAllocations += len(batch)
 
 			// Update every batch
-			h.updateDetailedProgress(ctx, syncLogID, "allocations", 0, processedAllocations, fmt.Sprintf("Synced %d allocations from node %d/%d...", processedAllocations, nodeIdx+1, len(nodes)))
+			h.updateDetailedProgress(ctx, syncLogID, "allocations", 0, processedAllocations, fmt.Sprintf("Synced %d allocations from node %d/%d...", processedAllocations, nodesDone, len(nodes)))
 		}
 	}
 
-	// Remove stale allocations no longer in the panel
-	if len(allSeenAllocIDs) > 0 {
-		ph := make([]string, len(allSeenAllocIDs))
-		for i := range allSeenAllocIDs {
-			ph[i] = fmt.Sprintf("$%d", i+1)
-		}
-		// Only delete allocations that belong to known panel nodes (nodeId IS NOT NULL)
+	// Remove stale allocations no longer in the panel. Only nodes whose
+	// allocations were fetched are considered, so a failed fetch never reads as
+	// an empty node; allocations of nodes gone from the panel go with the node.
+	if len(fetchedNodeIDs) > 0 {
 		if res, err := h.db.Pool.Exec(ctx,
-			`DELETE FROM allocations WHERE id NOT IN (`+strings.Join(ph, ",")+`)`,
-			allSeenAllocIDs...); err != nil {
+			`DELETE FROM allocations WHERE "nodeId" = ANY($1) AND NOT (id = ANY($2))`,
+			fetchedNodeIDs, allSeenAllocIDs); err != nil {
 			log.Warn().Err(err).Msg("Failed to delete stale allocations")
 		} else if res.RowsAffected() > 0 {
 			log.Info().Int64("deleted", res.RowsAffected()).Msg("Deleted stale allocations")
@@ -702,13 +777,15 @@ func (h *SyncHandler) syncNestsAndEggs(ctx context.Context, syncLogID string) er
 
 		for _, egg := range eggs {
 			eggQuery := `
-				INSERT INTO eggs (id, uuid, name, description, author, "panelType", "nestId", "createdAt", "updatedAt")
-				VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW())
+				INSERT INTO eggs (id, uuid, name, description, author, "dockerImage", startup, "panelType", "nestId", "createdAt", "updatedAt")
+				VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW(), NOW())
 				ON CONFLICT (id) DO UPDATE SET
 					uuid = EXCLUDED.uuid,
 					name = EXCLUDED.name,
 					description = EXCLUDED.description,
 					author = EXCLUDED.author,
+					"dockerImage" = EXCLUDED."dockerImage",
+					startup = EXCLUDED.startup,
 					"panelType" = EXCLUDED."panelType",
 					"nestId" = EXCLUDED."nestId",
 					"updatedAt" = NOW()
@@ -719,6 +796,8 @@ func (h *SyncHandler) syncNestsAndEggs(ctx context.Context, syncLogID string) er
 				egg.Attributes.Name,
 				egg.Attributes.Description,
 				egg.Attributes.Author,
+				egg.Attributes.DockerImage,
+				egg.Attributes.Startup,
 				"pterodactyl",
 				nest.Attributes.ID,
 			)
@@ -792,81 +871,46 @@ func (h *SyncHandler) syncServers(ctx context.Context, syncLogID string) error {
 
 	h.updateDetailedProgress(ctx, syncLogID, "servers", len(servers), 0, fmt.Sprintf("Fetched %d servers from panel", len(servers)))
 
+	// Merge local rows whose pterodactylId changed panel-side before upserting,
+	// otherwise the upsert conflicts on uuid and the old row is deleted as stale
+	if merged, err := h.reconcileServerIdentities(ctx, servers); err != nil {
+		log.Warn().Err(err).Msg("Failed to reconcile server identities")
+	} else if merged > 0 {
+		log.Info().Int("merged", merged).Msg("Reconciled servers with changed pterodactylId")
+	}
+
+	// Resolve owners from one query instead of a lookup per server
+	ownerIDs, err := h.loadUserIDsByPterodactylID(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to load server owners: %w", err)
+	}
+	log.Debug().Int("users", len(ownerIDs)).Int("owner_lookups_saved", len(servers)).Msg("Preloaded server owners")
+
+	// Snapshot existing servers so the change log can tell real updates apart
+	// from rows the upsert merely touched
+	before, err := h.loadServerFingerprints(ctx)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to snapshot servers; updates will not be recorded")
+	}
+
+	var changes []database.SyncChange
 	for i, server := range servers {
-		// Map status
-		status := "online"
-		if server.Attributes.Status != "" {
-			status = server.Attributes.Status
-		}
-		if server.Attributes.Suspended {
-			status = "suspended"
-		}
+		status := panelServerStatus(server)
 
 		// Look up local owner — pterodactylId may not exist yet (users not yet synced).
 		// We allow NULL here and reconcile during users sync.
 		var ownerID *string
-		_ = h.db.Pool.QueryRow(ctx,
-			`SELECT id FROM users WHERE "pterodactylId" = $1 LIMIT 1`,
-			server.Attributes.User,
-		).Scan(&ownerID)
+		if id, ok := ownerIDs[server.Attributes.User]; ok {
+			ownerID = &id
+		}
 
-		query := `
-			INSERT INTO servers (
-				id, "pterodactylId", uuid, "uuidShort", "externalId", "panelType",
-				name, description, status, "isSuspended",
-				"ownerId", "nodeId", "eggId", memory, disk, cpu,
-				"createdAt", "updatedAt"
-			) VALUES (
-				gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7, $8, $9,
-				$10,
-				$11, $12, $13, $14, $15, NOW(), NOW()
-			)
-			ON CONFLICT ("pterodactylId") DO UPDATE SET
-				uuid = EXCLUDED.uuid,
-				"uuidShort" = EXCLUDED."uuidShort",
-				name = EXCLUDED.name,
-				description = EXCLUDED.description,
-				status = EXCLUDED.status,
-				"isSuspended" = EXCLUDED."isSuspended",
-				"ownerId" = COALESCE(EXCLUDED."ownerId", servers."ownerId"),
-				"nodeId" = EXCLUDED."nodeId",
-				"eggId" = EXCLUDED."eggId",
-				memory = EXCLUDED.memory,
-				disk = EXCLUDED.disk,
-				cpu = EXCLUDED.cpu,
-				"updatedAt" = NOW()
-		`
-		_, err := h.db.Pool.Exec(ctx, query,
-			server.Attributes.ID,
-			server.Attributes.UUID,
-			server.Attributes.Identifier,
-			server.Attributes.ExternalID,
-			"pterodactyl",
-			server.Attributes.Name,
-			server.Attributes.Description,
-			status,
-			server.Attributes.Suspended,
-			ownerID,
-			server.Attributes.Node,
-			server.Attributes.Egg,
-			server.Attributes.Limits.Memory,
-			server.Attributes.Limits.Disk,
-			server.Attributes.Limits.CPU,
-		)
+		localID, inserted, err := h.upsertServer(ctx, server, ownerID)
 		if err != nil {
 			log.Warn().Err(err).Int("server_id", server.Attributes.ID).Msg("Failed to upsert server")
-		}
-
-		// Link allocations to this server if included in response
-		if len(server.Relationships.Allocations.Data) > 0 {
-			for _, alloc := range server.Relationships.Allocations.Data {
-				_, err := h.db.Pool.Exec(ctx,
-					`UPDATE allocations SET "serverId" = (SELECT id FROM servers WHERE "pterodactylId" = $1 LIMIT 1), "updatedAt" = NOW() WHERE id = $2`,
-					server.Attributes.ID, alloc.Attributes.ID)
-				if err != nil {
-					log.Warn().Err(err).Int("allocation_id", alloc.Attributes.ID).Msg("Failed to link allocation to server")
-				}
-			}
+		} else if inserted {
+			changes = append(changes, newSyncChange("server", localID, database.SyncChangeCreated, server.Attributes.Name))
+		} else if old, ok := before[server.Attributes.ID]; ok && old != serverFingerprintOf(server, storedServerStatus(status, old.status)) {
+			changes = append(changes, newSyncChange("server", localID, database.SyncChangeUpdated, server.Attributes.Name))
 		}
 
 		// Update progress every 25 servers
@@ -875,28 +919,425 @@ func (h *SyncHandler) syncServers(ctx context.Context, syncLogID string) error {
 		}
 	}
 
+	// Link allocations to their servers in one statement once all servers exist
+	serverPteroIDs, allocationIDs := collectAllocationLinks(servers)
+	if len(allocationIDs) > 0 {
+		if res, err := h.db.Pool.Exec(ctx, `
+			UPDATE allocations a SET "serverId" = s.id, "updatedAt" = NOW()
+			FROM unnest($1::int[], $2::int[]) AS l(server_ptero_id, allocation_id)
+			JOIN servers s ON s."pterodactylId" = l.server_ptero_id
+			WHERE a.id = l.allocation_id
+		`, serverPteroIDs, allocationIDs); err != nil {
+			log.Warn().Err(err).Int("allocations", len(allocationIDs)).Msg("Failed to link allocations to servers")
+		} else {
+			log.Debug().Int64("linked", res.RowsAffected()).Int("allocations", len(allocationIDs)).Msg("Linked allocations to servers")
+		}
+	}
+
+	// Tell owners about connection address changes, e.g. after a node migration
+	if addressChanges, err := h.updatePrimaryAddresses(ctx, servers); err != nil {
+		log.Warn().Err(err).Msg("Failed to update server addresses")
+	} else {
+		h.notifyAddressChanges(ctx, addressChanges)
+	}
+
 	// Remove stale panel servers no longer in Pterodactyl
 	if len(servers) > 0 {
-		ids := make([]interface{}, len(servers))
-		ph := make([]string, len(servers))
+		ids := make([]int, len(servers))
 		for i, srv := range servers {
 			ids[i] = srv.Attributes.ID
-			ph[i] = fmt.Sprintf("$%d", i+1)
 		}
-		if res, err := h.db.Pool.Exec(ctx,
-			`DELETE FROM servers WHERE "pterodactylId" IS NOT NULL AND "panelType" = 'pterodactyl' AND "pterodactylId" NOT IN (`+strings.Join(ph, ",")+`)`,
-			ids...); err != nil {
+		// Owners are read first since a hard delete removes them with the row
+		owners := h.staleServerOwners(ctx, ids)
+		var deleted []database.SyncChange
+		var err error
+		if h.cfg.SyncServerTombstones {
+			deleted, err = h.tombstoneStaleServers(ctx, ids)
+		} else {
+			deleted, err = h.deleteStale(ctx, staleServers, ids)
+		}
+		if err != nil {
 			log.Warn().Err(err).Msg("Failed to delete stale servers")
-		} else if res.RowsAffected() > 0 {
-			log.Info().Int64("deleted", res.RowsAffected()).Msg("Deleted stale servers")
+		} else if len(deleted) > 0 {
+			log.Info().Int("deleted", len(deleted)).Msg("Deleted stale servers")
 		}
+		h.notifyRemovedServers(ctx, deleted, owners)
+		changes = append(changes, deleted...)
 	}
+	h.recordChanges(ctx, syncLogID, changes)
 
 	log.Info().Int("count", len(servers)).Msg("Synced servers")
 	h.updateDetailedProgress(ctx, syncLogID, "servers", len(servers), len(servers), fmt.Sprintf("✓ Synced %d servers", len(servers)))
 	return nil
 }
 
+// panelServerStatus maps a panel server to the status stored locally, or ""
+// when the panel reports no state
+func panelServerStatus(server panels.PteroServer) string {
+	if server.Attributes.Suspended {
+		return "suspended"
+	}
+	// Running servers carry no panel status; their power state is left to
+	// the status poller
+	return server.Attributes.Status
+}
+
+// storedServerStatus returns the status a sync stores for a server with the
+// given panel status and current local status. Without a panel status a
+// power state from the status poller is kept; anything else, such as a
+// finished install or a lifted suspension, becomes online.
+func storedServerStatus(panelStatus, current string) string {
+	switch {
+	case panelStatus != "":
+		return panelStatus
+	case current != "" && isPowerStatus(current):
+		return current
+	default:
+		return "online"
+	}
+}
+
+// upsertServer stores a panel server, keeping the current owner when ownerID
+// is nil. It returns the local server ID and whether the row was created.
+// Columns the panel does not own, such as "adminNotes", must stay out of the
+// update so syncs never overwrite them.
+func (h *SyncHandler) upsertServer(ctx context.Context, server panels.PteroServer, ownerID *string) (string, bool, error) {
+	query := `
+		INSERT INTO servers (
+			id, "pterodactylId", uuid, "uuidShort", "externalId", "panelType",
+			name, description, status, "isSuspended", "suspendedAt",
+			"ownerId", "nodeId", "eggId", memory, disk, cpu,
+			"createdAt", "updatedAt"
+		) VALUES (
+			gen_random_uuid(), $1, $2, $3, $4, $5, $6, $7, COALESCE(NULLIF($8, ''), 'online'), $9,
+			CASE WHEN $9 THEN NOW() END,
+			$10,
+			$11, $12, $13, $14, $15, NOW(), NOW()
+		)
+		ON CONFLICT ("pterodactylId") DO UPDATE SET
+			uuid = EXCLUDED.uuid,
+			"uuidShort" = EXCLUDED."uuidShort",
+			name = EXCLUDED.name,
+			description = EXCLUDED.description,
+			status = CASE
+				WHEN $8 <> '' THEN $8
+				WHEN servers.status IN ('online', 'offline', 'starting', 'stopping') THEN servers.status
+				ELSE 'online'
+			END,
+			"isSuspended" = EXCLUDED."isSuspended",
+			"suspendedAt" = CASE WHEN EXCLUDED."isSuspended" THEN COALESCE(servers."suspendedAt", NOW()) END,
+			"suspensionReason" = CASE WHEN EXCLUDED."isSuspended" THEN servers."suspensionReason" END,
+			"ownerId" = COALESCE(EXCLUDED."ownerId", servers."ownerId"),
+			"nodeId" = EXCLUDED."nodeId",
+			"eggId" = EXCLUDED."eggId",
+			memory = EXCLUDED.memory,
+			disk = EXCLUDED.disk,
+			cpu = EXCLUDED.cpu,
+			"deletedAt" = NULL,
+			"missingSyncs" = 0,
+			"updatedAt" = NOW()
+		RETURNING id, (xmax = 0)
+	`
+	var localID string
+	var inserted bool
+	err := h.db.Pool.QueryRow(ctx, query,
+		server.Attributes.ID,
+		server.Attributes.UUID,
+		server.Attributes.Identifier,
+		server.Attributes.ExternalID,
+		"pterodactyl",
+		server.Attributes.Name,
+		server.Attributes.Description,
+		panelServerStatus(server),
+		server.Attributes.Suspended,
+		ownerID,
+		server.Attributes.Node,
+		server.Attributes.Egg,
+		server.Attributes.Limits.Memory,
+		server.Attributes.Limits.Disk,
+		server.Attributes.Limits.CPU,
+	).Scan(&localID, &inserted)
+	return localID, inserted, err
+}
+
+// serverFingerprint holds the synced server fields whose change is recorded
+// as an update in the sync change log
+type serverFingerprint struct {
+	name, status      string
+	suspended         bool
+	nodeID, eggID     int
+	memory, disk, cpu int64
+}
+
+// serverFingerprintOf builds the fingerprint a panel server will be stored with
+func serverFingerprintOf(server panels.PteroServer, status string) serverFingerprint {
+	return serverFingerprint{
+		name:      server.Attributes.Name,
+		status:    status,
+		suspended: server.Attributes.Suspended,
+		nodeID:    server.Attributes.Node,
+		eggID:     server.Attributes.Egg,
+		memory:    int64(server.Attributes.Limits.Memory),
+		disk:      int64(server.Attributes.Limits.Disk),
+		cpu:       int64(server.Attributes.Limits.CPU),
+	}
+}
+
+// loadServerFingerprints returns the current fingerprint of every panel
+// server, keyed by pterodactylId
+func (h *SyncHandler) loadServerFingerprints(ctx context.Context) (map[int]serverFingerprint, error) {
+	rows, err := h.db.Pool.Query(ctx, `
+		SELECT "pterodactylId", name, COALESCE(status, ''), COALESCE("isSuspended", false),
+			COALESCE("nodeId", 0), COALESCE("eggId", 0),
+			COALESCE(memory, 0), COALESCE(disk, 0), COALESCE(cpu, 0)
+		FROM servers
+		WHERE "pterodactylId" IS NOT NULL
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	fingerprints := make(map[int]serverFingerprint)
+	for rows.Next() {
+		var id int
+		var f serverFingerprint
+		if err := rows.Scan(&id, &f.name, &f.status, &f.suspended, &f.nodeID, &f.eggID, &f.memory, &f.disk, &f.cpu); err != nil {
+			return nil, err
+		}
+		fingerprints[id] = f
+	}
+	return fingerprints, rows.Err()
+}
+
+// newSyncChange builds a sync change log entry
+func newSyncChange(entityType, entityID, action, name string) database.SyncChange {
+	return database.SyncChange{EntityType: entityType, EntityID: entityID, Action: action, Name: name}
+}
+
+// recordChanges stores what a sync step changed. Failures are only logged;
+// the change log must never fail a sync.
+func (h *SyncHandler) recordChanges(ctx context.Context, syncLogID string, changes []database.SyncChange) {
+	if _, err := h.syncRepo.RecordSyncChanges(ctx, syncLogID, changes); err != nil {
+		log.Warn().Err(err).Str("sync_log_id", syncLogID).Int("changes", len(changes)).Msg("Failed to record sync changes")
+	}
+}
+
+// collectAllocationLinks returns parallel slices of server pterodactylIds and
+// the allocation ids assigned to them, as included in the servers response
+func collectAllocationLinks(servers []panels.PteroServer) (serverPteroIDs, allocationIDs []int) {
+	for _, server := range servers {
+		for _, alloc := range server.Relationships.Allocations.Data {
+			serverPteroIDs = append(serverPteroIDs, server.Attributes.ID)
+			allocationIDs = append(allocationIDs, alloc.Attributes.ID)
+		}
+	}
+	return serverPteroIDs, allocationIDs
+}
+
+// loadUserIDsByPterodactylID maps panel user ids to local user ids for every
+// user linked to the panel
+func (h *SyncHandler) loadUserIDsByPterodactylID(ctx context.Context) (map[int]string, error) {
+	rows, err := h.db.Pool.Query(ctx, `SELECT "pterodactylId", id FROM users WHERE "pterodactylId" IS NOT NULL`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	userIDs := make(map[int]string)
+	for rows.Next() {
+		var pterodactylID int
+		var id string
+		if err := rows.Scan(&pterodactylID, &id); err != nil {
+			return nil, err
+		}
+		if _, exists := userIDs[pterodactylID]; !exists {
+			userIDs[pterodactylID] = id
+		}
+	}
+	return userIDs, rows.Err()
+}
+
+// serverFKTables lists tables whose "serverId" references servers(id). For
+// unique tables, "serverId" together with uniqueCols (none when the table
+// holds one row per server) must stay unique when rows are repointed.
+var serverFKTables = []struct {
+	table      string
+	unique     bool
+	uniqueCols []string
+}{
+	{table: "allocations"},
+	{table: "server_variables"},
+	{table: "server_properties", unique: true, uniqueCols: []string{"key"}},
+	{table: "server_databases"},
+	{table: "server_backups"},
+	{table: "server_subusers", unique: true, uniqueCols: []string{"userId"}},
+	{table: "server_status_events"},
+	{table: "server_tags", unique: true, uniqueCols: []string{"userId", "tag"}},
+	{table: "server_resource_snapshots", unique: true},
+	{table: "invoice_items"},
+	{table: "support_tickets"},
+	{table: "hytale_game_sessions"},
+}
+
+// reconcileServerIdentities finds local servers that share a panel uuid but
+// carry a different pterodactylId (e.g. after a panel-side migration). For
+// each uuid the most recently updated row is kept and moved to the current
+// pterodactylId; related rows from the others are repointed to it and the
+// duplicates are deleted. Returns the number of rows merged away or updated.
+func (h *SyncHandler) reconcileServerIdentities(ctx context.Context, servers []panels.PteroServer) (int, error) {
+	panelIDs := make(map[string]int, len(servers))
+	uuids := make([]string, 0, len(servers))
+	for _, srv := range servers {
+		if srv.Attributes.UUID == "" {
+			continue
+		}
+		panelIDs[srv.Attributes.UUID] = srv.Attributes.ID
+		uuids = append(uuids, srv.Attributes.UUID)
+	}
+	if len(uuids) == 0 {
+		return 0, nil
+	}
+
+	rows, err := h.db.Pool.Query(ctx, `
+		SELECT id, uuid, "pterodactylId"
+		FROM servers
+		WHERE uuid = ANY($1)
+		ORDER BY uuid, "updatedAt" DESC
+	`, uuids)
+	if err != nil {
+		return 0, fmt.Errorf("failed to load servers by uuid: %w", err)
+	}
+
+	type localServer struct {
+		id            string
+		pterodactylID *int
+	}
+	byUUID := make(map[string][]localServer)
+	for rows.Next() {
+		var uuid string
+		var s localServer
+		if err := rows.Scan(&s.id, &uuid, &s.pterodactylID); err != nil {
+			rows.Close()
+			return 0, err
+		}
+		byUUID[uuid] = append(byUUID[uuid], s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
+	}
+
+	reconciled := 0
+	for uuid, locals := range byUUID {
+		panelID := panelIDs[uuid]
+		if len(locals) == 1 && locals[0].pterodactylID != nil && *locals[0].pterodactylID == panelID {
+			continue
+		}
+
+		// Rows are ordered newest first
+		keep := locals[0]
+		duplicates := make([]string, 0, len(locals)-1)
+		for _, s := range locals[1:] {
+			duplicates = append(duplicates, s.id)
+		}
+
+		if err := h.mergeServers(ctx, keep.id, duplicates, panelID); err != nil {
+			log.Warn().Err(err).Str("uuid", uuid).Str("server_id", keep.id).Msg("Failed to merge duplicate servers")
+			continue
+		}
+
+		oldID := 0
+		if keep.pterodactylID != nil {
+			oldID = *keep.pterodactylID
+		}
+		log.Info().
+			Str("uuid", uuid).
+			Str("kept_server_id", keep.id).
+			Strs("merged_server_ids", duplicates).
+			Int("old_pterodactyl_id", oldID).
+			Int("pterodactyl_id", panelID).
+			Msg("Merged servers sharing a uuid")
+		reconciled += len(locals)
+	}
+
+	return reconciled, nil
+}
+
+// mergeServers repoints related rows from each duplicate to keepID, deletes
+// the duplicates and sets keepID's pterodactylId, all in one transaction
+func (h *SyncHandler) mergeServers(ctx context.Context, keepID string, duplicates []string, pterodactylID int) error {
+	tx, err := h.db.Pool.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if len(duplicates) > 0 {
+		for _, fk := range serverFKTables {
+			// Optional tables (e.g. Hytale) may not exist on every install
+			var exists bool
+			if err := tx.QueryRow(ctx, `SELECT to_regclass($1) IS NOT NULL`, fk.table).Scan(&exists); err != nil {
+				return err
+			}
+			if !exists {
+				continue
+			}
+			table := pgx.Identifier{fk.table}.Sanitize()
+
+			if fk.unique {
+				// Drop rows the kept server already has, and all but one of
+				// the rows duplicates share, to avoid unique violations
+				match := ""
+				for _, name := range fk.uniqueCols {
+					col := pgx.Identifier{name}.Sanitize()
+					match += fmt.Sprintf(" AND o.%s IS NOT DISTINCT FROM d.%s", col, col)
+				}
+				_, err := tx.Exec(ctx, fmt.Sprintf(
+					`DELETE FROM %s d WHERE d."serverId" = ANY($1) AND EXISTS (
+						SELECT 1 FROM %s o
+						WHERE (o."serverId" = $2 OR (o."serverId" = ANY($1) AND o.ctid < d.ctid))%s
+					)`,
+					table, table, match,
+				), duplicates, keepID)
+				if err != nil {
+					return fmt.Errorf("failed to dedupe %s: %w", fk.table, err)
+				}
+			}
+
+			if _, err := tx.Exec(ctx, fmt.Sprintf(
+				`UPDATE %s SET "serverId" = $1 WHERE "serverId" = ANY($2)`, table,
+			), keepID, duplicates); err != nil {
+				return fmt.Errorf("failed to repoint %s: %w", fk.table, err)
+			}
+		}
+
+		// Admin notes are never synced, so carry a duplicate's over rather than
+		// lose them with the row
+		if _, err := tx.Exec(ctx, `
+			UPDATE servers SET ("adminNotes", "adminNotesUpdatedAt") = (
+				SELECT "adminNotes", "adminNotesUpdatedAt" FROM servers WHERE id = ANY($2) AND "adminNotes" IS NOT NULL LIMIT 1
+			)
+			WHERE id = $1 AND "adminNotes" IS NULL
+		`, keepID, duplicates); err != nil {
+			return fmt.Errorf("failed to carry over admin notes: %w", err)
+		}
+
+		if _, err := tx.Exec(ctx, `DELETE FROM servers WHERE id = ANY($1)`, duplicates); err != nil {
+			return fmt.Errorf("failed to delete duplicate servers: %w", err)
+		}
+	}
+
+	if _, err := tx.Exec(ctx,
+		`UPDATE servers SET "pterodactylId" = $1, "updatedAt" = NOW() WHERE id = $2`,
+		pterodactylID, keepID,
+	); err != nil {
+		return fmt.Errorf("failed to update pterodactylId: %w", err)
+	}
+
+	return tx.Commit(ctx)
+}
+
 func (h *SyncHandler) syncServerResources(ctx context.Context, syncLogID string) error {
 	log.Debug().Str("sync_log_id", syncLogID).Msg("Syncing detailed server resources (status, allocations, cpu usage, etc)")
 
@@ -947,6 +1388,71 @@ func (h *SyncHandler) syncServerResources(ctx context.Context, syncLogID string)
 	return nil
 }
 
+// serverPowerStatuses maps Wings power states to servers.status values
+var serverPowerStatuses = map[string]string{
+	"running":  "online",
+	"starting": "starting",
+	"stopping": "stopping",
+	"offline":  "offline",
+}
+
+// statusFromPowerState returns the servers.status value for a Wings power
+// state, or "" if the state is not recognised
+func statusFromPowerState(state string) string {
+	return serverPowerStatuses[state]
+}
+
+// syncServerStates runs a power state poll for a server_states sync,
+// refreshing servers.status and the resource snapshots without the full
+// server upsert. It waits for a scheduled poll that is already running.
+// syncLogID may be empty.
+func (h *SyncHandler) syncServerStates(ctx context.Context, syncLogID string) error {
+	if h.cfg.PterodactylClientAPIKey == "" {
+		return fmt.Errorf("server state sync needs the Pterodactyl client API key")
+	}
+
+	poller := NewServerStatusPoller(h.db, h.pteroClient, h.cfg)
+	var progress func(int, ServerPollResult)
+	if syncLogID != "" {
+		h.updateDetailedProgress(ctx, syncLogID, "server_states", 0, 0, "Checking server power states")
+		progress = func(checked int, result ServerPollResult) {
+			h.updateDetailedProgress(ctx, syncLogID, "server_states", result.Servers, checked, fmt.Sprintf("Checked %d/%d servers, %d status changes", checked, result.Servers, result.Updated))
+		}
+	}
+
+	result, err := poller.Poll(ctx, progress)
+	if err != nil {
+		return err
+	}
+	if result.Servers > 0 && result.Failed == result.Servers {
+		return fmt.Errorf("failed to fetch power state for all %d servers", result.Servers)
+	}
+
+	log.Info().Int("servers", result.Servers).Int("updated", result.Updated).Int("failed", result.Failed).Msg("Synced server power states")
+	if syncLogID != "" {
+		h.updateDetailedProgress(ctx, syncLogID, "server_states", result.Servers, result.Servers, fmt.Sprintf("✓ Updated %d of %d server statuses", result.Updated, result.Servers))
+	}
+	return nil
+}
+
+// resourceSnapshotFromResources reads the usage figures from a client API
+// resources response
+func resourceSnapshotFromResources(serverID, state string, resources map[string]interface{}) database.ServerResourceSnapshot {
+	snapshot := database.ServerResourceSnapshot{ServerID: serverID, State: state}
+	attrs, _ := resources["attributes"].(map[string]interface{})
+	usage, _ := attrs["resources"].(map[string]interface{})
+	if memory, ok := usage["memory_bytes"].(float64); ok {
+		snapshot.MemoryBytes = int64(memory)
+	}
+	if disk, ok := usage["disk_bytes"].(float64); ok {
+		snapshot.DiskBytes = int64(disk)
+	}
+	if cpu, ok := usage["cpu_absolute"].(float64); ok {
+		snapshot.CPUAbsolute = cpu
+	}
+	return snapshot
+}
+
 func (h *SyncHandler) syncDatabases(ctx context.Context, syncLogID string) error {
 	log.Debug().Str("sync_log_id", syncLogID).Msg("Syncing server databases")
 
@@ -987,25 +1493,7 @@ func (h *SyncHandler) syncDatabases(ctx context.Context, syncLogID string) error
 		h.updateDetailedProgress(ctx, syncLogID, "databases", 0, 0, fmt.Sprintf("Processing server %d/%d: %d databases", serverIdx+1, len(servers), len(databases)))
 
 		for _, db := range databases {
-			query := `
-				INSERT INTO server_databases (id, "pterodactylId", "serverId", "databaseName", username, host, "maxConnections", "createdAt", "updatedAt")
-				VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, NOW(), NOW())
-				ON CONFLICT ("pterodactylId") DO UPDATE SET
-					"databaseName" = EXCLUDED."databaseName",
-					username = EXCLUDED.username,
-					host = EXCLUDED.host,
-					"maxConnections" = EXCLUDED."maxConnections",
-					"updatedAt" = NOW()
-			`
-			_, err := h.db.Pool.Exec(ctx, query,
-				db.Attributes.ID,
-				server.ID,
-				db.Attributes.Database,
-				db.Attributes.Username,
-				db.Attributes.Host,
-				db.Attributes.MaxConnections,
-			)
-			if err != nil {
+			if err := h.upsertServerDatabase(ctx, server.ID, db); err != nil {
 				log.Warn().Err(err).Int("database_id", db.Attributes.ID).Msg("Failed to upsert database")
 			}
 			totalDatabases++
@@ -1017,6 +1505,29 @@ func (h *SyncHandler) syncDatabases(ctx context.Context, syncLogID string) error
 	return nil
 }
 
+// upsertServerDatabase stores a panel database of the local server serverID
+func (h *SyncHandler) upsertServerDatabase(ctx context.Context, serverID string, db panels.PteroDatabase) error {
+	query := `
+		INSERT INTO server_databases (id, "pterodactylId", "serverId", "databaseName", username, host, "maxConnections", "createdAt", "updatedAt")
+		VALUES (gen_random_uuid(), $1, $2, $3, $4, $5, $6, NOW(), NOW())
+		ON CONFLICT ("pterodactylId") DO UPDATE SET
+			"databaseName" = EXCLUDED."databaseName",
+			username = EXCLUDED.username,
+			host = EXCLUDED.host,
+			"maxConnections" = EXCLUDED."maxConnections",
+			"updatedAt" = NOW()
+	`
+	_, err := h.db.Pool.Exec(ctx, query,
+		db.Attributes.ID,
+		serverID,
+		db.Attributes.Database,
+		db.Attributes.Username,
+		db.Attributes.Host,
+		db.Attributes.MaxConnections,
+	)
+	return err
+}
+
 func (h *SyncHandler) syncUsers(ctx context.Context, syncLogID string) error {
 	log.Debug().Str("sync_log_id", syncLogID).Msg("Syncing users")
 
@@ -1030,45 +1541,31 @@ func (h *SyncHandler) syncUsers(ctx context.Context, syncLogID string) error {
 	}
 
 	totalPages := resp.Meta.Pagination.TotalPages
-	h.updateDetailedProgress(ctx, syncLogID, "users", totalPages*50, 0, fmt.Sprintf("Fetching %d users from %d pages", resp.Meta.Pagination.Total, totalPages))
+	h.updateDetailedProgress(ctx, syncLogID, "users", resp.Meta.Pagination.Total, 0, fmt.Sprintf("Fetching %d users from %d pages", resp.Meta.Pagination.Total, totalPages))
+
+	// storeUsers upserts one page of users; conflicting users are skipped
+	var changes []database.SyncChange
+	conflicts := 0
+	storeUsers := func(users []panels.PteroUser) {
+		for _, user := range users {
+			localID, inserted, conflict, err := h.upsertPanelUser(ctx, syncLogID, user)
+			if err != nil {
+				log.Warn().Err(err).Str("email", user.Attributes.Email).Msg("Failed to upsert user")
+			} else if conflict != nil {
+				conflicts++
+			} else if inserted {
+				changes = append(changes, newSyncChange("user", localID, database.SyncChangeCreated, user.Attributes.Email))
+			}
+			totalUsers++
+		}
+	}
 
 	// Process first page
 	var users []panels.PteroUser
 	if err := json.Unmarshal(resp.Data, &users); err != nil {
 		return fmt.Errorf("failed to unmarshal users: %w", err)
 	}
-
-	for _, user := range users {
-		// Upsert user - creates if not exists, updates pterodactyl fields if exists
-		query := `
-			INSERT INTO users (
-				id, email, username, "firstName", "lastName",
-				"pterodactylId", "isPterodactylAdmin",
-				"isMigrated", "isActive", "createdAt", "updatedAt"
-			) VALUES (
-				gen_random_uuid(), $1, $2, $3, $4, $5, $6, false, true, NOW(), NOW()
-			)
-			ON CONFLICT (email) DO UPDATE SET
-				"pterodactylId" = EXCLUDED."pterodactylId",
-				"isPterodactylAdmin" = EXCLUDED."isPterodactylAdmin",
-				username = COALESCE(users.username, EXCLUDED.username),
-				"firstName" = COALESCE(users."firstName", EXCLUDED."firstName"),
-				"lastName" = COALESCE(users."lastName", EXCLUDED."lastName"),
-				"updatedAt" = NOW()
-		`
-		_, err := h.db.Pool.Exec(ctx, query,
-			user.Attributes.Email,
-			user.Attributes.Username,
-			user.Attributes.FirstName,
-			user.Attributes.LastName,
-			user.Attributes.ID,
-			user.Attributes.RootAdmin,
-		)
-		if err != nil {
-			log.Warn().Err(err).Str("email", user.Attributes.Email).Msg("Failed to upsert user")
-		}
-		totalUsers++
-	}
+	storeUsers(users)
 
 	h.updateDetailedProgress(ctx, syncLogID, "users", resp.Meta.Pagination.Total, totalUsers, fmt.Sprintf("Processing page 1/%d (%d users)", totalPages, totalUsers))
 
@@ -1083,43 +1580,19 @@ func (h *SyncHandler) syncUsers(ctx context.Context, syncLogID string) error {
 		if err := json.Unmarshal(resp.Data, &users); err != nil {
 			return fmt.Errorf("failed to unmarshal users: %w", err)
 		}
-
-		for _, user := range users {
-			query := `
-				INSERT INTO users (
-					id, email, username, "firstName", "lastName",
-					"pterodactylId", "isPterodactylAdmin",
-					"isMigrated", "isActive", "createdAt", "updatedAt"
-				) VALUES (
-					gen_random_uuid(), $1, $2, $3, $4, $5, $6, false, true, NOW(), NOW()
-				)
-				ON CONFLICT (email) DO UPDATE SET
-					"pterodactylId" = EXCLUDED."pterodactylId",
-					"isPterodactylAdmin" = EXCLUDED."isPterodactylAdmin",
-					username = COALESCE(users.username, EXCLUDED.username),
-					"firstName" = COALESCE(users."firstName", EXCLUDED."firstName"),
-					"lastName" = COALESCE(users."lastName", EXCLUDED."lastName"),
-					"updatedAt" = NOW()
-			`
-			_, err := h.db.Pool.Exec(ctx, query,
-				user.Attributes.Email,
-				user.Attributes.Username,
-				user.Attributes.FirstName,
-				user.Attributes.LastName,
-				user.Attributes.ID,
-				user.Attributes.RootAdmin,
-			)
-			if err != nil {
-				log.Warn().Err(err).Str("email", user.Attributes.Email).Msg("Failed to upsert user")
-			}
-			totalUsers++
-		}
+		storeUsers(users)
 
 		h.updateDetailedProgress(ctx, syncLogID, "users", resp.Meta.Pagination.Total, totalUsers, fmt.Sprintf("Processing page %d/%d (%d/%d users)", page, totalPages, totalUsers, resp.Meta.Pagination.Total))
 	}
 
-	log.Info().Int("count", totalUsers).Msg("Synced users")
-	h.updateDetailedProgress(ctx, syncLogID, "users", totalUsers, totalUsers, fmt.Sprintf("✓ Synced %d users", totalUsers))
+	h.recordChanges(ctx, syncLogID, changes)
+
+	log.Info().Int("count", totalUsers).Int("conflicts", conflicts).Msg("Synced users")
+	message := fmt.Sprintf("✓ Synced %d users", totalUsers)
+	if conflicts > 0 {
+		message += fmt.Sprintf(" (%d conflicts left for review)", conflicts)
+	}
+	h.updateDetailedProgress(ctx, syncLogID, "users", totalUsers, totalUsers, message)
 	return nil
 }
 
@@ -1294,6 +1767,28 @@ func (h *SyncHandler) updateDetailedProgress(ctx context.Context, syncLogID, ste
 		"lastMessage":    lastMessage,
 		"lastUpdated":    time.Now().Unix(),
 	})
+	h.syncRepo.UpdateSyncStep(ctx, syncLogID, step, func(s *database.SyncStep) {
+		s.RecordProgress(itemsTotal, itemsProcessed, time.Now())
+	})
+}
+
+// completeStep marks a step as completed in the sync log's step breakdown
+func (h *SyncHandler) completeStep(ctx context.Context, syncLogID, step string) {
+	now := time.Now()
+	h.syncRepo.UpdateSyncStep(ctx, syncLogID, step, func(s *database.SyncStep) {
+		s.Status = "COMPLETED"
+		s.CompletedAt = &now
+	})
+}
+
+// failStep marks a step as failed in the sync log's step breakdown
+func (h *SyncHandler) failStep(ctx context.Context, syncLogID, step string, err error) {
+	now := time.Now()
+	h.syncRepo.UpdateSyncStep(ctx, syncLogID, step, func(s *database.SyncStep) {
+		s.Status = "FAILED"
+		s.CompletedAt = &now
+		s.Error = err.Error()
+	})
 }
 
 func (h *SyncHandler) failSync(ctx context.Context, syncLogID, step string, err error) error {
@@ -1302,6 +1797,7 @@ func (h *SyncHandler) failSync(ctx context.Context, syncLogID, step string, err
 		"failed_step": step,
 		"error":       err.Error(),
 	})
+	h.failStep(ctx, syncLogID, step, err)
 	// Dispatch failure webhook (non-blocking)
 	go h.dispatchSyncWebhook(ctx, syncLogID, "FAILED", duration, err)
 	return err
@@ -0,0 +1,140 @@
+package workers
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/nodebyte/backend/internal/database"
+	"github.com/nodebyte/backend/internal/panels"
+)
+
+func TestCollectAllocationLinks(t *testing.T) {
+	payload := `[
+		{"attributes": {"id": 10}, "relationships": {"allocations": {"data": [
+			{"attributes": {"id": 100}},
+			{"attributes": {"id": 101}}
+		]}}},
+		{"attributes": {"id": 11}, "relationships": {"allocations": {"data": []}}},
+		{"attributes": {"id": 12}, "relationships": {"allocations": {"data": [
+			{"attributes": {"id": 200}}
+		]}}}
+	]`
+
+	var servers []panels.PteroServer
+	if err := json.Unmarshal([]byte(payload), &servers); err != nil {
+		t.Fatalf("failed to decode servers: %v", err)
+	}
+
+	serverIDs, allocationIDs := collectAllocationLinks(servers)
+
+	wantServers := []int{10, 10, 12}
+	wantAllocations := []int{100, 101, 200}
+	if len(serverIDs) != len(wantServers) || len(allocationIDs) != len(wantAllocations) {
+		t.Fatalf("collectAllocationLinks() = %v, %v, want %v, %v", serverIDs, allocationIDs, wantServers, wantAllocations)
+	}
+	for i := range wantAllocations {
+		if serverIDs[i] != wantServers[i] || allocationIDs[i] != wantAllocations[i] {
+			t.Errorf("link %d = (%d, %d), want (%d, %d)", i, serverIDs[i], allocationIDs[i], wantServers[i], wantAllocations[i])
+		}
+	}
+}
+
+func TestCollectAllocationLinksEmpty(t *testing.T) {
+	serverIDs, allocationIDs := collectAllocationLinks(nil)
+	if len(serverIDs) != 0 || len(allocationIDs) != 0 {
+		t.Errorf("collectAllocationLinks(nil) = %v, %v, want empty", serverIDs, allocationIDs)
+	}
+}
+
+func TestServerFingerprintOf(t *testing.T) {
+	payload := `{"attributes": {"id": 10, "name": "smp", "suspended": false, "node": 3, "egg": 7,
+		"limits": {"memory": 4096, "disk": 20480, "cpu": 200}}}`
+
+	var server panels.PteroServer
+	if err := json.Unmarshal([]byte(payload), &server); err != nil {
+		t.Fatalf("failed to decode server: %v", err)
+	}
+
+	stored := serverFingerprint{name: "smp", status: "online", nodeID: 3, eggID: 7, memory: 4096, disk: 20480, cpu: 200}
+	if got := serverFingerprintOf(server, "online"); got != stored {
+		t.Errorf("serverFingerprintOf() = %+v, want %+v", got, stored)
+	}
+
+	server.Attributes.Limits.Memory = 8192
+	if serverFingerprintOf(server, "online") == stored {
+		t.Error("memory change not reflected in fingerprint")
+	}
+}
+
+func TestStatusFromPowerState(t *testing.T) {
+	tests := map[string]string{
+		"running":  "online",
+		"starting": "starting",
+		"stopping": "stopping",
+		"offline":  "offline",
+		"":         "",
+		"unknown":  "",
+	}
+	for state, want := range tests {
+		if got := statusFromPowerState(state); got != want {
+			t.Errorf("statusFromPowerState(%q) = %q, want %q", state, got, want)
+		}
+	}
+}
+
+func TestResourceSnapshotFromResources(t *testing.T) {
+	payload := `{"object": "stats", "attributes": {"current_state": "running", "is_suspended": false,
+		"resources": {"memory_bytes": 536870912, "cpu_absolute": 37.5, "disk_bytes": 1073741824, "uptime": 1000}}}`
+
+	var resources map[string]interface{}
+	if err := json.Unmarshal([]byte(payload), &resources); err != nil {
+		t.Fatalf("failed to decode resources: %v", err)
+	}
+
+	got := resourceSnapshotFromResources("srv-1", "running", resources)
+	want := database.ServerResourceSnapshot{ServerID: "srv-1", State: "running", MemoryBytes: 536870912, DiskBytes: 1073741824, CPUAbsolute: 37.5}
+	if got != want {
+		t.Errorf("resourceSnapshotFromResources() = %+v, want %+v", got, want)
+	}
+
+	if empty := resourceSnapshotFromResources("srv-1", "offline", nil); empty.MemoryBytes != 0 || empty.State != "offline" {
+		t.Errorf("resourceSnapshotFromResources(nil) = %+v, want zero usage", empty)
+	}
+}
+
+func TestStoredServerStatus(t *testing.T) {
+	tests := []struct {
+		panelStatus, current, want string
+	}{
+		{panelStatus: "installing", current: "offline", want: "installing"},
+		{panelStatus: "suspended", current: "online", want: "suspended"},
+		{panelStatus: "", current: "offline", want: "offline"},
+		{panelStatus: "", current: "starting", want: "starting"},
+		{panelStatus: "", current: "installing", want: "online"},
+		{panelStatus: "", current: "suspended", want: "online"},
+		{panelStatus: "", current: "", want: "online"},
+	}
+
+	for _, tt := range tests {
+		if got := storedServerStatus(tt.panelStatus, tt.current); got != tt.want {
+			t.Errorf("storedServerStatus(%q, %q) = %q, want %q", tt.panelStatus, tt.current, got, tt.want)
+		}
+	}
+}
+
+func TestCountsTowardFailureStreak(t *testing.T) {
+	tests := map[string]bool{
+		"full":          true,
+		"batch":         true,
+		"locations":     false,
+		"nodes":         false,
+		"servers":       false,
+		"users":         false,
+		"server_states": false,
+	}
+	for syncType, want := range tests {
+		if got := countsTowardFailureStreak(syncType); got != want {
+			t.Errorf("countsTowardFailureStreak(%q) = %v, want %v", syncType, got, want)
+		}
+	}
+}
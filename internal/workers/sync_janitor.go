@@ -0,0 +1,54 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/nodebyte/backend/internal/database"
+)
+
+// SyncJanitor releases the sync lock held by syncs whose worker died mid-run
+type SyncJanitor struct {
+	syncRepo *database.SyncRepository
+	maxAge   time.Duration
+}
+
+// NewSyncJanitor creates a janitor that fails syncs unfinished after maxAge
+func NewSyncJanitor(db *database.DB, maxAge time.Duration) *SyncJanitor {
+	return &SyncJanitor{
+		syncRepo: database.NewSyncRepository(db),
+		maxAge:   maxAge,
+	}
+}
+
+// RecoverStuckSyncs marks syncs that have been running longer than the max
+// duration as FAILED
+func (j *SyncJanitor) RecoverStuckSyncs(ctx context.Context) error {
+	reason := fmt.Sprintf("Sync exceeded max duration of %s and was marked failed (worker likely crashed)", j.maxAge)
+	ids, err := j.syncRepo.FailActiveSyncs(ctx, time.Now().Add(-j.maxAge), reason)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		log.Warn().Str("sync_log_id", id).Dur("max_duration", j.maxAge).Msg("Recovered stuck sync")
+	}
+	return nil
+}
+
+// PurgeServerTombstones deletes tombstoned servers that have stayed missing
+// from the panel for the grace period and at least minMissing syncs in a row
+func (j *SyncJanitor) PurgeServerTombstones(ctx context.Context, gracePeriod time.Duration, minMissing int) error {
+	ids, err := j.syncRepo.PurgeServerTombstones(ctx, time.Now().Add(-gracePeriod), minMissing)
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		log.Info().Str("server_id", id).Msg("Purged server tombstone")
+	}
+	return nil
+}
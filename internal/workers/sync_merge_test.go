@@ -0,0 +1,54 @@
+package workers
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var (
+	sqlCommentPattern      = regexp.MustCompile(`--[^\n]*`)
+	schemaTablePattern     = regexp.MustCompile(`(?i)^\s*(?:CREATE TABLE(?: IF NOT EXISTS)?|ALTER TABLE(?: IF EXISTS)?)\s+"?(\w+)"?`)
+	serverReferencePattern = regexp.MustCompile(`(?i)REFERENCES\s+servers\s*\(\s*id\s*\)`)
+)
+
+// TestServerFKTablesCoverSchemas fails when a schema adds a reference to
+// servers(id) that mergeServers would not repoint, since the rows would be
+// deleted or orphaned with the merged server
+func TestServerFKTablesCoverSchemas(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join("..", "..", "schemas", "*.sql"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no schema files found: %v", err)
+	}
+
+	referencing := map[string]string{}
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("read %s: %v", file, err)
+		}
+		sql := sqlCommentPattern.ReplaceAllString(string(data), "")
+		for _, stmt := range strings.Split(sql, ";") {
+			m := schemaTablePattern.FindStringSubmatch(stmt)
+			if m == nil || !serverReferencePattern.MatchString(stmt) {
+				continue
+			}
+			referencing[strings.ToLower(m[1])] = filepath.Base(file)
+		}
+	}
+	if len(referencing) == 0 {
+		t.Fatal("found no tables referencing servers(id)")
+	}
+
+	listed := map[string]bool{}
+	for _, fk := range serverFKTables {
+		listed[fk.table] = true
+	}
+	for table, file := range referencing {
+		if !listed[table] {
+			t.Errorf("%s (%s) references servers(id) but is missing from serverFKTables", table, file)
+		}
+	}
+}
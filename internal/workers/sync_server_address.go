@@ -0,0 +1,138 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+
+	"github.com/nodebyte/backend/internal/database"
+	"github.com/nodebyte/backend/internal/panels"
+	"github.com/nodebyte/backend/internal/queue"
+)
+
+// serverAddressChange is a server whose primary address moved during a sync
+type serverAddressChange struct {
+	serverID        string
+	name            string
+	ownerID         *string
+	previousAddress *string // nil the first time the address is recorded
+	address         string
+}
+
+// primaryAddress returns the host:port players connect to, taken from the
+// server's primary allocation and preferring its alias over the raw IP. It is
+// empty when the allocation was not included with the server.
+func primaryAddress(server panels.PteroServer) string {
+	for _, alloc := range server.Relationships.Allocations.Data {
+		if alloc.Attributes.ID != server.Attributes.Allocation {
+			continue
+		}
+		host := alloc.Attributes.Alias
+		if host == "" {
+			host = alloc.Attributes.IP
+		}
+		if host == "" {
+			return ""
+		}
+		return net.JoinHostPort(host, strconv.Itoa(alloc.Attributes.Port))
+	}
+	return ""
+}
+
+// updatePrimaryAddresses stores each server's primary address and returns the
+// servers whose address differs from the one stored before. Servers without
+// allocation data are left alone.
+func (h *SyncHandler) updatePrimaryAddresses(ctx context.Context, servers []panels.PteroServer) ([]serverAddressChange, error) {
+	var pteroIDs []int
+	var addresses []string
+	for _, server := range servers {
+		if addr := primaryAddress(server); addr != "" {
+			pteroIDs = append(pteroIDs, server.Attributes.ID)
+			addresses = append(addresses, addr)
+		}
+	}
+	if len(pteroIDs) == 0 {
+		return nil, nil
+	}
+
+	// The self-join reads the row as it was before the update
+	rows, err := h.db.Pool.Query(ctx, `
+		UPDATE servers s SET "primaryAddress" = l.address
+		FROM unnest($1::int[], $2::text[]) AS l(ptero_id, address)
+		JOIN servers old ON old."pterodactylId" = l.ptero_id
+		WHERE s.id = old.id AND s."primaryAddress" IS DISTINCT FROM l.address
+		RETURNING s.id, s.name, s."ownerId", old."primaryAddress", l.address
+	`, pteroIDs, addresses)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var changes []serverAddressChange
+	for rows.Next() {
+		var c serverAddressChange
+		if err := rows.Scan(&c.serverID, &c.name, &c.ownerID, &c.previousAddress, &c.address); err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	return changes, rows.Err()
+}
+
+// notifyAddressChanges tells owners their server's connection address changed,
+// in-app and by email, each subject to the owner's server status preferences.
+// Addresses recorded for the first time are not announced. Failures are only
+// logged.
+func (h *SyncHandler) notifyAddressChanges(ctx context.Context, changes []serverAddressChange) {
+	for _, c := range changes {
+		if c.previousAddress == nil || c.ownerID == nil {
+			continue
+		}
+		log.Info().
+			Str("server_id", c.serverID).
+			Str("previous_address", *c.previousAddress).
+			Str("address", c.address).
+			Msg("Server address changed")
+
+		if _, err := h.db.NotifyUsers(ctx, []string{*c.ownerID}, database.NewNotification{
+			Category: database.NotificationServerStatus,
+			Type:     database.NotificationServerAddressChanged,
+			Title:    "Server address changed",
+			Body:     fmt.Sprintf("Your server %q is now reachable at %s (previously %s). Update any saved connections.", c.name, c.address, *c.previousAddress),
+		}); err != nil {
+			log.Warn().Err(err).Str("server_id", c.serverID).Msg("Failed to write server address notification")
+		}
+
+		if h.queueManager == nil {
+			continue
+		}
+		var email, name string
+		err := h.db.Pool.QueryRow(ctx, `
+			SELECT email, COALESCE("firstName", '') FROM users WHERE id = $1 AND "isActive" = true
+		`, *c.ownerID).Scan(&email, &name)
+		if err != nil {
+			if !errors.Is(err, pgx.ErrNoRows) {
+				log.Warn().Err(err).Str("server_id", c.serverID).Msg("Failed to look up server owner for address email")
+			}
+			continue
+		}
+		if _, err := h.queueManager.EnqueueEmail(queue.EmailPayload{
+			To:       email,
+			Subject:  "Your server's address has changed",
+			Template: "server-address-changed",
+			Data: map[string]string{
+				"name":            name,
+				"server":          c.name,
+				"address":         c.address,
+				"previousAddress": *c.previousAddress,
+			},
+		}); err != nil {
+			log.Error().Err(err).Str("server_id", c.serverID).Msg("Failed to queue server address email")
+		}
+	}
+}
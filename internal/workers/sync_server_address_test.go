@@ -0,0 +1,48 @@
+package workers
+
+import (
+	"testing"
+
+	"github.com/nodebyte/backend/internal/panels"
+)
+
+func TestPrimaryAddress(t *testing.T) {
+	alloc := func(id int, ip, alias string, port int) panels.PteroAllocation {
+		var a panels.PteroAllocation
+		a.Attributes.ID = id
+		a.Attributes.IP = ip
+		a.Attributes.Alias = alias
+		a.Attributes.Port = port
+		return a
+	}
+
+	tests := []struct {
+		name    string
+		primary int
+		allocs  []panels.PteroAllocation
+		want    string
+	}{
+		{name: "no allocations", primary: 1, want: ""},
+		{name: "ip and port", primary: 1, allocs: []panels.PteroAllocation{alloc(1, "10.0.0.5", "", 25565)}, want: "10.0.0.5:25565"},
+		{name: "alias preferred", primary: 1, allocs: []panels.PteroAllocation{alloc(1, "10.0.0.5", "play.example.com", 25565)}, want: "play.example.com:25565"},
+		{
+			name:    "picks the primary allocation",
+			primary: 2,
+			allocs:  []panels.PteroAllocation{alloc(1, "10.0.0.5", "", 25565), alloc(2, "10.0.0.6", "", 25566)},
+			want:    "10.0.0.6:25566",
+		},
+		{name: "primary not included", primary: 3, allocs: []panels.PteroAllocation{alloc(1, "10.0.0.5", "", 25565)}, want: ""},
+		{name: "ipv6", primary: 1, allocs: []panels.PteroAllocation{alloc(1, "2001:db8::1", "", 25565)}, want: "[2001:db8::1]:25565"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var server panels.PteroServer
+			server.Attributes.Allocation = tt.primary
+			server.Relationships.Allocations.Data = tt.allocs
+			if got := primaryAddress(server); got != tt.want {
+				t.Errorf("primaryAddress() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,338 @@
+package workers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/rs/zerolog/log"
+
+	"github.com/nodebyte/backend/internal/database"
+)
+
+// foreignKeyViolation is the Postgres error code for a delete or update that
+// would break a foreign key
+const foreignKeyViolation = "23503"
+
+// staleReference is a child relation that keeps a stale row in place. Its
+// condition is evaluated against the candidate row aliased as t.
+type staleReference struct {
+	name      string
+	condition string
+}
+
+// staleDeletion describes how to remove rows the panel no longer reports
+// without removing a parent that other rows still reference
+type staleDeletion struct {
+	entityType string
+	table      string
+	keyColumn  string // column holding the panel ID
+	nameColumn string
+	scope      string // limits deletion to panel-managed rows, if set
+	references []staleReference
+}
+
+// Stale deletions run child to parent: servers, then allocations, nodes and
+// locations, so a parent is only removed once nothing points at it
+var (
+	staleServers = staleDeletion{
+		entityType: "server",
+		table:      "servers",
+		keyColumn:  `"pterodactylId"`,
+		nameColumn: "name",
+		scope:      `t."pterodactylId" IS NOT NULL AND t."panelType" = 'pterodactyl'`,
+	}
+	staleNodes = staleDeletion{
+		entityType: "node",
+		table:      "nodes",
+		keyColumn:  "id",
+		nameColumn: "name",
+		references: []staleReference{
+			{"servers", `EXISTS (SELECT 1 FROM servers s WHERE s."nodeId" = t.id)`},
+			{"assigned allocations", `EXISTS (SELECT 1 FROM allocations a WHERE a."nodeId" = t.id AND a."serverId" IS NOT NULL)`},
+		},
+	}
+	staleLocations = staleDeletion{
+		entityType: "location",
+		table:      "locations",
+		keyColumn:  "id",
+		nameColumn: `"shortCode"`,
+		references: []staleReference{
+			{"nodes", `EXISTS (SELECT 1 FROM nodes n WHERE n."locationId" = t.id)`},
+		},
+	}
+)
+
+// unreferenced returns a condition that holds when no reference keeps t alive
+func (d staleDeletion) unreferenced() string {
+	conds := make([]string, 0, len(d.references))
+	for _, ref := range d.references {
+		conds = append(conds, "NOT "+ref.condition)
+	}
+	if len(conds) == 0 {
+		return "TRUE"
+	}
+	return strings.Join(conds, " AND ")
+}
+
+// deleteStale removes rows whose panel IDs are not in seenIDs and returns them
+// as sync changes. Rows still referenced by children are logged and kept for a
+// later sync, and a delete the database rejects for breaking a foreign key is
+// skipped rather than failing the step.
+func (h *SyncHandler) deleteStale(ctx context.Context, d staleDeletion, seenIDs []int) ([]database.SyncChange, error) {
+	where := fmt.Sprintf("NOT (t.%s = ANY($1))", d.keyColumn)
+	if d.scope != "" {
+		where += " AND " + d.scope
+	}
+	referencedBy := "NULL::text"
+	if len(d.references) > 0 {
+		var b strings.Builder
+		b.WriteString("CASE")
+		for _, ref := range d.references {
+			fmt.Fprintf(&b, " WHEN %s THEN '%s'", ref.condition, ref.name)
+		}
+		b.WriteString(" END")
+		referencedBy = b.String()
+	}
+
+	rows, err := h.db.Pool.Query(ctx, fmt.Sprintf(
+		`SELECT t.id::text, %s FROM %s t WHERE %s`, referencedBy, d.table, where,
+	), seenIDs)
+	if err != nil {
+		return nil, err
+	}
+	var candidates []string
+	for rows.Next() {
+		var id string
+		var ref *string
+		if err := rows.Scan(&id, &ref); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if ref != nil {
+			log.Warn().Str("entity_type", d.entityType).Str("id", id).Str("referenced_by", *ref).
+				Msg("Skipping deletion of stale entity that is still referenced")
+			continue
+		}
+		candidates = append(candidates, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	// References are checked again in the delete in case they changed since
+	deleteQuery := fmt.Sprintf(
+		`DELETE FROM %s t WHERE t.id::text = ANY($1) AND %s RETURNING t.id::text, t.%s`,
+		d.table, d.unreferenced(), d.nameColumn,
+	)
+	deleted, err := h.deleteStaleRows(ctx, d.entityType, deleteQuery, candidates)
+	if !isForeignKeyViolation(err) {
+		return deleted, err
+	}
+
+	// A relation not listed in references blocked the batch; delete one at a
+	// time so only the blocked rows are kept
+	deleted = nil
+	for _, id := range candidates {
+		rowDeleted, err := h.deleteStaleRows(ctx, d.entityType, deleteQuery, []string{id})
+		if isForeignKeyViolation(err) {
+			log.Warn().Err(err).Str("entity_type", d.entityType).Str("id", id).
+				Msg("Skipping deletion of stale entity that would violate a foreign key")
+			continue
+		}
+		if err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, rowDeleted...)
+	}
+	return deleted, nil
+}
+
+// deleteStaleRows runs a DELETE ... RETURNING id, name statement and returns
+// the removed rows as sync changes
+func (h *SyncHandler) deleteStaleRows(ctx context.Context, entityType, query string, ids []string) ([]database.SyncChange, error) {
+	rows, err := h.db.Pool.Query(ctx, query, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var deleted []database.SyncChange
+	for rows.Next() {
+		var id string
+		var name *string
+		if err := rows.Scan(&id, &name); err != nil {
+			return nil, err
+		}
+		change := newSyncChange(entityType, id, database.SyncChangeDeleted, "")
+		if name != nil {
+			change.Name = *name
+		}
+		deleted = append(deleted, change)
+	}
+	return deleted, rows.Err()
+}
+
+// seenPanelIDs holds the node and location IDs the panel reported earlier in
+// the same sync, so stale cleanup after the servers step can reuse them. A
+// nil list was not fetched; a nil *seenPanelIDs records nothing.
+type seenPanelIDs struct {
+	nodes     []int
+	locations []int
+}
+
+func (s *seenPanelIDs) setNodes(ids []int) {
+	if s != nil {
+		s.nodes = ids
+	}
+}
+
+func (s *seenPanelIDs) setLocations(ids []int) {
+	if s != nil {
+		s.locations = ids
+	}
+}
+
+// pruneStaleInfrastructure retries node and location deletion once servers
+// have synced, since a node only becomes deletable after its stale servers
+// are gone. IDs in seen are reused; the rest are fetched from the panel.
+// Failures are logged; they never fail the sync.
+func (h *SyncHandler) pruneStaleInfrastructure(ctx context.Context, syncLogID string, seen *seenPanelIDs) {
+	if seen == nil {
+		seen = &seenPanelIDs{}
+	}
+	var changes []database.SyncChange
+
+	nodeIDs := seen.nodes
+	if nodeIDs == nil {
+		nodes, err := h.pteroClient.GetAllNodes(ctx)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to fetch nodes for stale cleanup")
+			return
+		}
+		nodeIDs = make([]int, len(nodes))
+		for i, node := range nodes {
+			nodeIDs[i] = node.Attributes.ID
+		}
+	}
+	if len(nodeIDs) > 0 {
+		deleted, err := h.deleteStale(ctx, staleNodes, nodeIDs)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to delete stale nodes")
+		}
+		changes = append(changes, deleted...)
+	}
+
+	locationIDs := seen.locations
+	if locationIDs == nil {
+		locations, err := h.pteroClient.GetAllLocations(ctx)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to fetch locations for stale cleanup")
+		} else {
+			locationIDs = make([]int, len(locations))
+			for i, loc := range locations {
+				locationIDs[i] = loc.Attributes.ID
+			}
+		}
+	}
+	if len(locationIDs) > 0 {
+		deleted, err := h.deleteStale(ctx, staleLocations, locationIDs)
+		if err != nil {
+			log.Warn().Err(err).Msg("Failed to delete stale locations")
+		}
+		changes = append(changes, deleted...)
+	}
+
+	if len(changes) > 0 {
+		log.Info().Int("deleted", len(changes)).Msg("Deleted stale nodes and locations after server sync")
+		h.recordChanges(ctx, syncLogID, changes)
+	}
+}
+
+// isForeignKeyViolation reports whether err is a Postgres foreign key violation
+func isForeignKeyViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == foreignKeyViolation
+}
+
+// tombstoneStaleServers marks panel servers missing from seenIDs deleted
+// instead of removing them, and counts another missed sync for each. Local
+// data stays until the janitor purges the server; only servers tombstoned by
+// this sync are returned as changes.
+func (h *SyncHandler) tombstoneStaleServers(ctx context.Context, seenIDs []int) ([]database.SyncChange, error) {
+	rows, err := h.db.Pool.Query(ctx, `
+		UPDATE servers t SET
+			"deletedAt" = COALESCE(t."deletedAt", NOW()),
+			"missingSyncs" = t."missingSyncs" + 1
+		WHERE NOT (t."pterodactylId" = ANY($1)) AND `+staleServers.scope+`
+		RETURNING t.id, t.name, t."missingSyncs"
+	`, seenIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var tombstoned []database.SyncChange
+	for rows.Next() {
+		var id, name string
+		var missing int
+		if err := rows.Scan(&id, &name, &missing); err != nil {
+			return nil, err
+		}
+		if missing == 1 {
+			tombstoned = append(tombstoned, newSyncChange("server", id, database.SyncChangeDeleted, name))
+		}
+	}
+	return tombstoned, rows.Err()
+}
+
+// staleServerOwners returns the owners of live panel servers missing from
+// seenIDs, keyed by server ID. Failures are logged and return no owners.
+func (h *SyncHandler) staleServerOwners(ctx context.Context, seenIDs []int) map[string]database.ServerOwnership {
+	owners := map[string]database.ServerOwnership{}
+	rows, err := h.db.Pool.Query(ctx, `
+		SELECT t.id, t.name, t."ownerId" FROM servers t
+		WHERE NOT (t."pterodactylId" = ANY($1)) AND `+staleServers.scope+`
+			AND t."ownerId" IS NOT NULL AND t."deletedAt" IS NULL
+	`, seenIDs)
+	if err != nil {
+		log.Warn().Err(err).Msg("Failed to fetch owners of stale servers")
+		return owners
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var o database.ServerOwnership
+		if err := rows.Scan(&o.ServerID, &o.Name, &o.OwnerID); err != nil {
+			log.Warn().Err(err).Msg("Failed to read owner of stale server")
+			return owners
+		}
+		owners[o.ServerID] = o
+	}
+	return owners
+}
+
+// notifyRemovedServers tells owners that the sync removed their server
+// because the panel no longer has it. Failures are only logged.
+func (h *SyncHandler) notifyRemovedServers(ctx context.Context, removed []database.SyncChange, owners map[string]database.ServerOwnership) {
+	for _, change := range removed {
+		o, ok := owners[change.EntityID]
+		if !ok {
+			continue
+		}
+		if _, err := h.db.NotifyUsers(ctx, []string{o.OwnerID}, database.NewNotification{
+			Category: database.NotificationServerStatus,
+			Type:     database.NotificationServerRemoved,
+			Title:    "Server removed",
+			Body:     fmt.Sprintf("Your server %q no longer exists in the panel and has been removed from your dashboard.", o.Name),
+		}); err != nil {
+			log.Warn().Err(err).Str("server_id", o.ServerID).Msg("Failed to write server removal notification")
+		}
+	}
+}
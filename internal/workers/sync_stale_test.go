@@ -0,0 +1,38 @@
+package workers
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestIsForeignKeyViolation(t *testing.T) {
+	fk := &pgconn.PgError{Code: foreignKeyViolation}
+	if !isForeignKeyViolation(fk) {
+		t.Error("foreign key violation not detected")
+	}
+	if !isForeignKeyViolation(fmt.Errorf("delete failed: %w", fk)) {
+		t.Error("wrapped foreign key violation not detected")
+	}
+	if isForeignKeyViolation(&pgconn.PgError{Code: "23505"}) {
+		t.Error("unique violation reported as foreign key violation")
+	}
+	if isForeignKeyViolation(errors.New("boom")) || isForeignKeyViolation(nil) {
+		t.Error("non-Postgres error reported as foreign key violation")
+	}
+}
+
+func TestStaleDeletionUnreferenced(t *testing.T) {
+	if got := staleServers.unreferenced(); got != "TRUE" {
+		t.Errorf("servers unreferenced = %q, want TRUE", got)
+	}
+	want := `NOT EXISTS (SELECT 1 FROM nodes n WHERE n."locationId" = t.id)`
+	if got := staleLocations.unreferenced(); got != want {
+		t.Errorf("locations unreferenced = %q, want %q", got, want)
+	}
+	if got := staleNodes.unreferenced(); got != "NOT "+staleNodes.references[0].condition+" AND NOT "+staleNodes.references[1].condition {
+		t.Errorf("nodes unreferenced = %q", got)
+	}
+}
@@ -0,0 +1,154 @@
+package workers
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/rs/zerolog/log"
+
+	"github.com/nodebyte/backend/internal/database"
+	"github.com/nodebyte/backend/internal/panels"
+)
+
+// localUserMatch is a local user sharing a panel user's email or panel ID
+type localUserMatch struct {
+	id            string
+	email         string
+	pterodactylID *int
+}
+
+// userSyncConflict returns the conflict between a panel user and the local
+// users sharing its email or panel ID, or nil if the user can be stored
+// without merging two panel accounts into one local user or giving one panel
+// account two local users
+func userSyncConflict(user panels.PteroUser, matches []localUserMatch) *database.UserSyncConflict {
+	email, panelID := user.Attributes.Email, user.Attributes.ID
+	for _, m := range matches {
+		if m.email == email && m.pterodactylID != nil && *m.pterodactylID != panelID {
+			return &database.UserSyncConflict{
+				Kind: database.UserConflictEmailTaken, UserID: m.id, Email: m.email,
+				LocalPterodactylID: m.pterodactylID, PanelEmail: email, PanelPterodactylID: panelID,
+			}
+		}
+	}
+	for _, m := range matches {
+		if m.email != email && m.pterodactylID != nil && *m.pterodactylID == panelID {
+			return &database.UserSyncConflict{
+				Kind: database.UserConflictEmailChanged, UserID: m.id, Email: m.email,
+				LocalPterodactylID: m.pterodactylID, PanelEmail: email, PanelPterodactylID: panelID,
+			}
+		}
+	}
+	return nil
+}
+
+// upsertPanelUser stores a panel user, matching local users by email. It
+// returns the local user ID and whether the row was created. A user that
+// conflicts with an existing local user is recorded for admin review and not
+// written; the returned conflict is then non-nil.
+func (h *SyncHandler) upsertPanelUser(ctx context.Context, syncLogID string, user panels.PteroUser) (string, bool, *database.UserSyncConflict, error) {
+	rows, err := h.db.Pool.Query(ctx,
+		`SELECT id, email, "pterodactylId" FROM users WHERE email = $1 OR "pterodactylId" = $2`,
+		user.Attributes.Email, user.Attributes.ID,
+	)
+	if err != nil {
+		return "", false, nil, err
+	}
+	var matches []localUserMatch
+	for rows.Next() {
+		var m localUserMatch
+		if err := rows.Scan(&m.id, &m.email, &m.pterodactylID); err != nil {
+			rows.Close()
+			return "", false, nil, err
+		}
+		matches = append(matches, m)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return "", false, nil, err
+	}
+
+	if conflict := userSyncConflict(user, matches); conflict != nil {
+		h.recordUserSyncConflict(ctx, syncLogID, conflict)
+		return "", false, conflict, nil
+	}
+
+	// The WHERE guard keeps a concurrent link to another panel account from
+	// being overwritten; such a row yields no result and is recorded as a
+	// conflict
+	var localID string
+	var inserted bool
+	err = h.db.Pool.QueryRow(ctx, `
+		INSERT INTO users (
+			id, email, username, "firstName", "lastName",
+			"pterodactylId", "isPterodactylAdmin",
+			"isMigrated", "isActive", "createdAt", "updatedAt"
+		) VALUES (
+			gen_random_uuid(), $1, $2, $3, $4, $5, $6, false, true, NOW(), NOW()
+		)
+		ON CONFLICT (email) DO UPDATE SET
+			"pterodactylId" = EXCLUDED."pterodactylId",
+			"isPterodactylAdmin" = EXCLUDED."isPterodactylAdmin",
+			username = COALESCE(users.username, EXCLUDED.username),
+			"firstName" = COALESCE(users."firstName", EXCLUDED."firstName"),
+			"lastName" = COALESCE(users."lastName", EXCLUDED."lastName"),
+			"updatedAt" = NOW()
+		WHERE users."pterodactylId" IS NULL OR users."pterodactylId" = EXCLUDED."pterodactylId"
+		RETURNING id, (xmax = 0)
+	`,
+		user.Attributes.Email,
+		user.Attributes.Username,
+		user.Attributes.FirstName,
+		user.Attributes.LastName,
+		user.Attributes.ID,
+		user.Attributes.RootAdmin,
+	).Scan(&localID, &inserted)
+	if errors.Is(err, pgx.ErrNoRows) {
+		conflict, err := h.emailTakenConflict(ctx, user)
+		if err != nil {
+			return "", false, nil, err
+		}
+		h.recordUserSyncConflict(ctx, syncLogID, conflict)
+		return "", false, conflict, nil
+	}
+	return localID, inserted, nil, err
+}
+
+// emailTakenConflict describes a panel user whose email was linked to
+// another panel account after the conflict check ran
+func (h *SyncHandler) emailTakenConflict(ctx context.Context, user panels.PteroUser) (*database.UserSyncConflict, error) {
+	var m localUserMatch
+	err := h.db.Pool.QueryRow(ctx,
+		`SELECT id, email, "pterodactylId" FROM users WHERE email = $1`,
+		user.Attributes.Email,
+	).Scan(&m.id, &m.email, &m.pterodactylID)
+	if err != nil {
+		return nil, err
+	}
+	if conflict := userSyncConflict(user, []localUserMatch{m}); conflict != nil {
+		return conflict, nil
+	}
+	return &database.UserSyncConflict{
+		Kind: database.UserConflictEmailTaken, UserID: m.id, Email: m.email,
+		LocalPterodactylID: m.pterodactylID, PanelEmail: user.Attributes.Email, PanelPterodactylID: user.Attributes.ID,
+	}, nil
+}
+
+// recordUserSyncConflict logs a panel user left unwritten and records it for
+// admin review; a failure to record is logged only
+func (h *SyncHandler) recordUserSyncConflict(ctx context.Context, syncLogID string, conflict *database.UserSyncConflict) {
+	if syncLogID != "" {
+		conflict.SyncLogID = &syncLogID
+	}
+	log.Warn().
+		Str("kind", conflict.Kind).
+		Str("user_id", conflict.UserID).
+		Str("email", conflict.Email).
+		Str("panel_email", conflict.PanelEmail).
+		Int("panel_pterodactyl_id", conflict.PanelPterodactylID).
+		Msg("Panel user conflicts with a local user; left for admin review")
+	if err := h.db.RecordUserSyncConflict(ctx, *conflict); err != nil {
+		log.Error().Err(err).Str("user_id", conflict.UserID).Msg("Failed to record user sync conflict")
+	}
+}
@@ -0,0 +1,65 @@
+package workers
+
+import (
+	"testing"
+
+	"github.com/nodebyte/backend/internal/database"
+	"github.com/nodebyte/backend/internal/panels"
+)
+
+func TestUserSyncConflict(t *testing.T) {
+	id := func(n int) *int { return &n }
+	var user panels.PteroUser
+	user.Attributes.ID = 7
+	user.Attributes.Email = "alex@example.com"
+
+	tests := []struct {
+		name     string
+		matches  []localUserMatch
+		wantKind string
+		wantUser string
+	}{
+		{name: "new user", matches: nil},
+		{name: "already linked", matches: []localUserMatch{{id: "a", email: "alex@example.com", pterodactylID: id(7)}}},
+		{name: "unlinked local user", matches: []localUserMatch{{id: "a", email: "alex@example.com"}}},
+		{
+			name:     "email linked to another panel account",
+			matches:  []localUserMatch{{id: "a", email: "alex@example.com", pterodactylID: id(9)}},
+			wantKind: database.UserConflictEmailTaken,
+			wantUser: "a",
+		},
+		{
+			name:     "panel email changed",
+			matches:  []localUserMatch{{id: "b", email: "old@example.com", pterodactylID: id(7)}},
+			wantKind: database.UserConflictEmailChanged,
+			wantUser: "b",
+		},
+		{
+			name: "email taken wins over email changed",
+			matches: []localUserMatch{
+				{id: "b", email: "old@example.com", pterodactylID: id(7)},
+				{id: "a", email: "alex@example.com", pterodactylID: id(9)},
+			},
+			wantKind: database.UserConflictEmailTaken,
+			wantUser: "a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conflict := userSyncConflict(user, tt.matches)
+			if tt.wantKind == "" {
+				if conflict != nil {
+					t.Errorf("got conflict %+v, want none", conflict)
+				}
+				return
+			}
+			if conflict == nil {
+				t.Fatalf("got no conflict, want %s", tt.wantKind)
+			}
+			if conflict.Kind != tt.wantKind || conflict.UserID != tt.wantUser || conflict.PanelPterodactylID != 7 || conflict.PanelEmail != user.Attributes.Email {
+				t.Errorf("got %+v, want %s for user %s", conflict, tt.wantKind, tt.wantUser)
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package workers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/hibiken/asynq"
+	"github.com/rs/zerolog/log"
+
+	"github.com/nodebyte/backend/internal/queue"
+)
+
+// UserResyncResult reports what a single-user resync refreshed
+type UserResyncResult struct {
+	UserID          string `json:"userId"`
+	PanelServers    int    `json:"panelServers"`    // servers the panel says the user owns
+	ServersAssigned int    `json:"serversAssigned"` // local servers whose owner changed to the user
+}
+
+// HandleUserResync refreshes the user named by a sync:user task. A panel
+// record that conflicts with another local user is recorded for admin review
+// and not retried.
+func (h *SyncHandler) HandleUserResync(ctx context.Context, task *asynq.Task) error {
+	var payload queue.UserResyncPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", err)
+	}
+
+	result, err := h.ResyncUser(ctx, payload.UserID, payload.PterodactylID)
+	if err != nil {
+		return err
+	}
+	if result == nil {
+		return fmt.Errorf("user %s conflicts with another local user: %w", payload.UserID, asynq.SkipRetry)
+	}
+	return nil
+}
+
+// ResyncUser refreshes one user from the panel outside a full sync. The panel
+// record is stored the way the user sync stores it: the admin flag follows the
+// panel, profile names are only filled in where missing, and an email change
+// or a clash with another local user is recorded for admin review and yields
+// a nil result. Local servers the panel says the user owns are then assigned
+// to them; servers not synced yet are left for the next full sync.
+func (h *SyncHandler) ResyncUser(ctx context.Context, userID string, pterodactylID int) (*UserResyncResult, error) {
+	user, err := h.pteroClient.GetUser(ctx, pterodactylID, []string{"servers"})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch user: %w", err)
+	}
+
+	localID, _, conflict, err := h.upsertPanelUser(ctx, "", *user)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+	if conflict != nil {
+		return nil, nil
+	}
+	if localID != userID {
+		// The panel account's email belongs to a different local user
+		log.Warn().
+			Int("pterodactyl_id", pterodactylID).
+			Str("user_id", userID).
+			Str("matched_user_id", localID).
+			Msg("Panel user resolved to a different local user; skipping server assignment")
+		return nil, nil
+	}
+	result := &UserResyncResult{UserID: userID}
+
+	serverIDs := make([]int, 0, len(user.Relationships.Servers.Data))
+	for _, server := range user.Relationships.Servers.Data {
+		serverIDs = append(serverIDs, server.Attributes.ID)
+	}
+	result.PanelServers = len(serverIDs)
+
+	tag, err := h.db.Pool.Exec(ctx, `
+		UPDATE servers SET "ownerId" = $1, "updatedAt" = NOW()
+		WHERE "pterodactylId" = ANY($2) AND "panelType" = 'pterodactyl'
+			AND "ownerId" IS DISTINCT FROM $1
+	`, userID, serverIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to assign servers: %w", err)
+	}
+	result.ServersAssigned = int(tag.RowsAffected())
+
+	log.Info().
+		Int("pterodactyl_id", pterodactylID).
+		Str("user_id", userID).
+		Int("panel_servers", result.PanelServers).
+		Int("servers_assigned", result.ServersAssigned).
+		Msg("Resynced user")
+
+	return result, nil
+}
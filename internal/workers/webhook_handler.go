@@ -6,12 +6,14 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/hibiken/asynq"
 	"github.com/rs/zerolog/log"
 
 	"github.com/nodebyte/backend/internal/database"
+	"github.com/nodebyte/backend/internal/discord"
 	"github.com/nodebyte/backend/internal/queue"
 	"github.com/nodebyte/backend/internal/sentry"
 )
@@ -32,46 +34,6 @@ func NewWebhookHandler(db *database.DB) *WebhookHandler {
 	}
 }
 
-// DiscordWebhookPayload represents a Discord webhook message
-type DiscordWebhookPayload struct {
-	Username  string         `json:"username,omitempty"`
-	AvatarURL string         `json:"avatar_url,omitempty"`
-	Content   string         `json:"content,omitempty"`
-	Embeds    []DiscordEmbed `json:"embeds,omitempty"`
-}
-
-// DiscordEmbed represents a Discord embed
-type DiscordEmbed struct {
-	Title       string              `json:"title,omitempty"`
-	Description string              `json:"description,omitempty"`
-	URL         string              `json:"url,omitempty"`
-	Color       int                 `json:"color,omitempty"`
-	Timestamp   string              `json:"timestamp,omitempty"`
-	Footer      *DiscordEmbedFooter `json:"footer,omitempty"`
-	Author      *DiscordEmbedAuthor `json:"author,omitempty"`
-	Fields      []DiscordEmbedField `json:"fields,omitempty"`
-}
-
-// DiscordEmbedFooter represents a Discord embed footer
-type DiscordEmbedFooter struct {
-	Text    string `json:"text,omitempty"`
-	IconURL string `json:"icon_url,omitempty"`
-}
-
-// DiscordEmbedAuthor represents a Discord embed author
-type DiscordEmbedAuthor struct {
-	Name    string `json:"name,omitempty"`
-	URL     string `json:"url,omitempty"`
-	IconURL string `json:"icon_url,omitempty"`
-}
-
-// DiscordEmbedField represents a Discord embed field
-type DiscordEmbedField struct {
-	Name   string `json:"name"`
-	Value  string `json:"value"`
-	Inline bool   `json:"inline,omitempty"`
-}
-
 // HandleDiscordWebhook processes a Discord webhook task
 func (h *WebhookHandler) HandleDiscordWebhook(ctx context.Context, task *asynq.Task) error {
 	tx := sentry.StartBackgroundTransaction(ctx, "worker.discord_webhook")
@@ -89,11 +51,11 @@ func (h *WebhookHandler) HandleDiscordWebhook(ctx context.Context, task *asynq.T
 		Str("event", payload.Event).
 		Msg("Processing Discord webhook")
 
-	// Get webhook URL from database
-	var webhookURL string
+	// Get webhook URL and message template from database
+	var webhookURL, messageTemplate string
 	var enabled bool
-	query := `SELECT url, enabled FROM discord_webhooks WHERE id = $1`
-	err := h.db.Pool.QueryRow(ctx, query, payload.WebhookID).Scan(&webhookURL, &enabled)
+	query := `SELECT "webhookUrl", enabled, COALESCE("messageTemplate", '') FROM discord_webhooks WHERE id = $1`
+	err := h.db.Pool.QueryRow(ctx, query, payload.WebhookID).Scan(&webhookURL, &enabled, &messageTemplate)
 	if err != nil {
 		sentry.CaptureExceptionWithContext(ctx, err, "fetch_webhook")
 		return fmt.Errorf("failed to get webhook: %w", err)
@@ -106,53 +68,128 @@ func (h *WebhookHandler) HandleDiscordWebhook(ctx context.Context, task *asynq.T
 
 	// Build Discord message based on event type
 	message := h.buildDiscordMessage(payload.Event, payload.Data)
+	if messageTemplate != "" {
+		text, err := renderWebhookTemplate(messageTemplate, payload.Event, payload.Data)
+		if err != nil {
+			// A broken template should not drop the notification; send the default text
+			log.Warn().Err(err).Str("webhook_id", payload.WebhookID).Msg("Failed to render webhook template")
+		} else if text != "" {
+			message.Embeds[0].Description = text
+		}
+	}
 
-	// Send to Discord
-	jsonBody, err := json.Marshal(message)
+	err = discord.Send(ctx, h.httpClient, webhookURL, message)
+	recordWebhookDelivery(ctx, h.db, payload.WebhookID, payload.Event, err)
 	if err != nil {
-		return fmt.Errorf("failed to marshal webhook payload: %w", err)
+		return err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, "POST", webhookURL, bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+	log.Info().
+		Str("webhook_id", payload.WebhookID).
+		Str("event", payload.Event).
+		Msg("Discord webhook sent successfully")
+
+	return nil
+}
+
+// HandleSyncResultWebhook re-sends a finished sync's result notification to
+// one webhook. Failures are returned so the task is retried.
+func (h *WebhookHandler) HandleSyncResultWebhook(ctx context.Context, task *asynq.Task) error {
+	var payload queue.SyncResultWebhookPayload
+	if err := json.Unmarshal(task.Payload(), &payload); err != nil {
+		return fmt.Errorf("failed to unmarshal payload: %w", err)
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	var webhookURL string
+	var enabled bool
+	err := h.db.Pool.QueryRow(ctx,
+		`SELECT "webhookUrl", enabled FROM discord_webhooks WHERE id = $1`,
+		payload.WebhookID,
+	).Scan(&webhookURL, &enabled)
+	if err != nil {
+		return fmt.Errorf("failed to get webhook: %w", err)
+	}
+	if !enabled {
+		log.Warn().Str("webhook_id", payload.WebhookID).Msg("Webhook is disabled, skipping")
+		return nil
+	}
 
-	resp, err := h.httpClient.Do(req)
+	syncLog, err := database.NewSyncRepository(h.db).GetSyncLog(ctx, payload.SyncLogID)
 	if err != nil {
-		return fmt.Errorf("failed to send webhook: %w", err)
+		return fmt.Errorf("failed to get sync log: %w", err)
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode >= 400 {
-		// Discord rate limiting - retry later
-		if resp.StatusCode == 429 {
-			return fmt.Errorf("rate limited by Discord")
-		}
-		return fmt.Errorf("Discord returned status %d", resp.StatusCode)
+	duration, syncErr := syncLog.Outcome()
+	err = discord.Send(ctx, h.httpClient, webhookURL, discord.SyncResultMessage(syncLog.Status, duration, syncErr))
+	recordWebhookDelivery(ctx, h.db, payload.WebhookID, syncResultEvent(syncLog.Status), err)
+	if err != nil {
+		return err
 	}
 
 	log.Info().
 		Str("webhook_id", payload.WebhookID).
-		Str("event", payload.Event).
-		Int("status", resp.StatusCode).
-		Msg("Discord webhook sent successfully")
-
+		Str("sync_log_id", payload.SyncLogID).
+		Str("status", syncLog.Status).
+		Msg("Sync result webhook sent")
 	return nil
 }
 
+// syncResultEvent names the webhook event for a sync result with the given
+// status, e.g. sync.completed
+func syncResultEvent(status string) string {
+	return "sync." + strings.ToLower(status)
+}
+
+// recordWebhookDelivery stores the outcome of a webhook send for the
+// delivery stats. Failures to record are only logged.
+func recordWebhookDelivery(ctx context.Context, db *database.DB, webhookID, event string, sendErr error) {
+	if err := db.RecordWebhookDelivery(ctx, webhookID, event, sendErr); err != nil {
+		log.Warn().Err(err).Str("webhook_id", webhookID).Msg("Failed to record webhook delivery")
+	}
+}
+
+// maxEmbedDescriptionLength is Discord's limit for an embed description
+const maxEmbedDescriptionLength = 4096
+
+// renderWebhookTemplate renders a webhook message template with the event
+// data. Fields missing from the data render as empty text.
+func renderWebhookTemplate(text, event string, data map[string]interface{}) (string, error) {
+	tmpl, err := database.ParseWebhookTemplate(text)
+	if err != nil {
+		return "", err
+	}
+
+	values := make(map[string]interface{}, len(data)+1)
+	for k, v := range data {
+		values[k] = v
+	}
+	if _, ok := values["event"]; !ok {
+		values["event"] = event
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, values); err != nil {
+		return "", err
+	}
+
+	// text/template prints missing map keys as "<no value>"
+	out := strings.TrimSpace(strings.ReplaceAll(buf.String(), "<no value>", ""))
+	if runes := []rune(out); len(runes) > maxEmbedDescriptionLength {
+		out = string(runes[:maxEmbedDescriptionLength])
+	}
+	return out, nil
+}
+
 // buildDiscordMessage creates a Discord message based on event type
-func (h *WebhookHandler) buildDiscordMessage(event string, data map[string]interface{}) DiscordWebhookPayload {
-	message := DiscordWebhookPayload{
+func (h *WebhookHandler) buildDiscordMessage(event string, data map[string]interface{}) discord.Message {
+	message := discord.Message{
 		Username:  "NodeByte",
 		AvatarURL: "https://nodebyte.host/logo.png",
 	}
 
-	var embed DiscordEmbed
+	var embed discord.Embed
 	embed.Timestamp = time.Now().UTC().Format(time.RFC3339)
-	embed.Footer = &DiscordEmbedFooter{
+	embed.Footer = &discord.EmbedFooter{
 		Text: "NodeByte Notifications",
 	}
 
@@ -162,7 +199,7 @@ func (h *WebhookHandler) buildDiscordMessage(event string, data map[string]inter
 		embed.Description = "A synchronization operation has started."
 		embed.Color = 0x3B82F6 // Blue
 		if syncType, ok := data["type"].(string); ok {
-			embed.Fields = append(embed.Fields, DiscordEmbedField{
+			embed.Fields = append(embed.Fields, discord.EmbedField{
 				Name:   "Type",
 				Value:  syncType,
 				Inline: true,
@@ -174,14 +211,14 @@ func (h *WebhookHandler) buildDiscordMessage(event string, data map[string]inter
 		embed.Description = "Synchronization completed successfully."
 		embed.Color = 0x22C55E // Green
 		if syncType, ok := data["type"].(string); ok {
-			embed.Fields = append(embed.Fields, DiscordEmbedField{
+			embed.Fields = append(embed.Fields, discord.EmbedField{
 				Name:   "Type",
 				Value:  syncType,
 				Inline: true,
 			})
 		}
 		if duration, ok := data["duration"].(string); ok {
-			embed.Fields = append(embed.Fields, DiscordEmbedField{
+			embed.Fields = append(embed.Fields, discord.EmbedField{
 				Name:   "Duration",
 				Value:  duration,
 				Inline: true,
@@ -193,7 +230,7 @@ func (h *WebhookHandler) buildDiscordMessage(event string, data map[string]inter
 		embed.Description = "A synchronization operation has failed."
 		embed.Color = 0xEF4444 // Red
 		if errorMsg, ok := data["error"].(string); ok {
-			embed.Fields = append(embed.Fields, DiscordEmbedField{
+			embed.Fields = append(embed.Fields, discord.EmbedField{
 				Name:  "Error",
 				Value: errorMsg,
 			})
@@ -204,7 +241,7 @@ func (h *WebhookHandler) buildDiscordMessage(event string, data map[string]inter
 		embed.Description = "A new user has registered on NodeByte."
 		embed.Color = 0x8B5CF6 // Purple
 		if email, ok := data["email"].(string); ok {
-			embed.Fields = append(embed.Fields, DiscordEmbedField{
+			embed.Fields = append(embed.Fields, discord.EmbedField{
 				Name:   "Email",
 				Value:  email,
 				Inline: true,
@@ -216,14 +253,14 @@ func (h *WebhookHandler) buildDiscordMessage(event string, data map[string]inter
 		embed.Description = "A new server has been created."
 		embed.Color = 0x6366F1 // Indigo
 		if name, ok := data["name"].(string); ok {
-			embed.Fields = append(embed.Fields, DiscordEmbedField{
+			embed.Fields = append(embed.Fields, discord.EmbedField{
 				Name:   "Name",
 				Value:  name,
 				Inline: true,
 			})
 		}
 		if owner, ok := data["owner"].(string); ok {
-			embed.Fields = append(embed.Fields, DiscordEmbedField{
+			embed.Fields = append(embed.Fields, discord.EmbedField{
 				Name:   "Owner",
 				Value:  owner,
 				Inline: true,
@@ -235,14 +272,14 @@ func (h *WebhookHandler) buildDiscordMessage(event string, data map[string]inter
 		embed.Description = "A server has been suspended."
 		embed.Color = 0xF59E0B // Amber
 		if name, ok := data["name"].(string); ok {
-			embed.Fields = append(embed.Fields, DiscordEmbedField{
+			embed.Fields = append(embed.Fields, discord.EmbedField{
 				Name:   "Name",
 				Value:  name,
 				Inline: true,
 			})
 		}
 		if reason, ok := data["reason"].(string); ok {
-			embed.Fields = append(embed.Fields, DiscordEmbedField{
+			embed.Fields = append(embed.Fields, discord.EmbedField{
 				Name:  "Reason",
 				Value: reason,
 			})
@@ -253,25 +290,38 @@ func (h *WebhookHandler) buildDiscordMessage(event string, data map[string]inter
 		embed.Description = "A new support ticket has been created."
 		embed.Color = 0x0EA5E9 // Sky
 		if subject, ok := data["subject"].(string); ok {
-			embed.Fields = append(embed.Fields, DiscordEmbedField{
+			embed.Fields = append(embed.Fields, discord.EmbedField{
 				Name:  "Subject",
 				Value: subject,
 			})
 		}
 		if user, ok := data["user"].(string); ok {
-			embed.Fields = append(embed.Fields, DiscordEmbedField{
+			embed.Fields = append(embed.Fields, discord.EmbedField{
 				Name:   "User",
 				Value:  user,
 				Inline: true,
 			})
 		}
 		if priority, ok := data["priority"].(string); ok {
-			embed.Fields = append(embed.Fields, DiscordEmbedField{
+			embed.Fields = append(embed.Fields, discord.EmbedField{
 				Name:   "Priority",
 				Value:  priority,
 				Inline: true,
 			})
 		}
+		addTicketFields(&embed, data)
+
+	case "ticket.reply":
+		embed.Title = "💬 Ticket Reply"
+		embed.Description = "A support ticket has a new reply."
+		embed.Color = 0x0EA5E9 // Sky
+		if subject, ok := data["subject"].(string); ok {
+			embed.Fields = append(embed.Fields, discord.EmbedField{
+				Name:  "Subject",
+				Value: subject,
+			})
+		}
+		addTicketFields(&embed, data)
 
 	default:
 		embed.Title = "📢 Notification"
@@ -279,6 +329,21 @@ func (h *WebhookHandler) buildDiscordMessage(event string, data map[string]inter
 		embed.Color = 0x6B7280 // Gray
 	}
 
-	message.Embeds = []DiscordEmbed{embed}
+	message.Embeds = []discord.Embed{embed}
 	return message
 }
+
+// addTicketFields adds the ticket number and a link to the ticket, when the
+// event data has them
+func addTicketFields(embed *discord.Embed, data map[string]interface{}) {
+	if number, ok := data["ticketNumber"].(string); ok && number != "" {
+		embed.Fields = append(embed.Fields, discord.EmbedField{
+			Name:   "Ticket",
+			Value:  "#" + number,
+			Inline: true,
+		})
+	}
+	if link, ok := data["ticketUrl"].(string); ok && link != "" {
+		embed.URL = link
+	}
+}
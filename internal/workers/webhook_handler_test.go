@@ -0,0 +1,61 @@
+package workers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRenderWebhookTemplate(t *testing.T) {
+	data := map[string]interface{}{"name": "smp", "reason": "unpaid invoice"}
+
+	got, err := renderWebhookTemplate("{{.event}}: {{.name}} suspended{{with .reason}} ({{.}}){{end}}", "server.suspended", data)
+	if err != nil {
+		t.Fatalf("renderWebhookTemplate() error = %v", err)
+	}
+	if want := "server.suspended: smp suspended (unpaid invoice)"; got != want {
+		t.Errorf("renderWebhookTemplate() = %q, want %q", got, want)
+	}
+}
+
+func TestRenderWebhookTemplateMissingField(t *testing.T) {
+	got, err := renderWebhookTemplate("Owner: {{.owner}}", "server.created", nil)
+	if err != nil {
+		t.Fatalf("renderWebhookTemplate() error = %v", err)
+	}
+	if got != "Owner:" {
+		t.Errorf("renderWebhookTemplate() = %q, want %q", got, "Owner:")
+	}
+}
+
+func TestRenderWebhookTemplateTruncates(t *testing.T) {
+	got, err := renderWebhookTemplate(`{{.text}}`, "custom", map[string]interface{}{"text": strings.Repeat("a", maxEmbedDescriptionLength+10)})
+	if err != nil {
+		t.Fatalf("renderWebhookTemplate() error = %v", err)
+	}
+	if len(got) != maxEmbedDescriptionLength {
+		t.Errorf("len(renderWebhookTemplate()) = %d, want %d", len(got), maxEmbedDescriptionLength)
+	}
+}
+
+func TestRenderWebhookTemplateInvalid(t *testing.T) {
+	if _, err := renderWebhookTemplate("{{.name", "custom", nil); err == nil {
+		t.Error("renderWebhookTemplate() with unterminated action succeeded, want error")
+	}
+}
+
+func TestBuildDiscordMessageTicketReply(t *testing.T) {
+	h := &WebhookHandler{}
+	msg := h.buildDiscordMessage("ticket.reply", map[string]interface{}{
+		"subject":      "Server will not start",
+		"ticketNumber": "1042",
+		"ticketUrl":    "https://nodebyte.host/admin/support/t1",
+	})
+
+	embed := msg.Embeds[0]
+	if embed.URL != "https://nodebyte.host/admin/support/t1" {
+		t.Errorf("embed URL = %q, want the ticket link", embed.URL)
+	}
+	if len(embed.Fields) != 2 || embed.Fields[1].Value != "#1042" {
+		t.Errorf("embed fields = %+v, want subject and ticket number", embed.Fields)
+	}
+}
@@ -21,7 +21,9 @@ CREATE TABLE IF NOT EXISTS users (
     
     "isMigrated" BOOLEAN DEFAULT false,
     "emailVerified" TIMESTAMP,
+    "pendingEmail" TEXT,
     "isActive" BOOLEAN DEFAULT true,
+    "passwordResetRequired" BOOLEAN DEFAULT false, -- set for imported users until they choose a password
     
     "avatarUrl" TEXT,
     "companyName" TEXT,
@@ -89,6 +89,8 @@ CREATE TABLE IF NOT EXISTS eggs (
     name TEXT NOT NULL,
     description TEXT,
     author TEXT,
+    "dockerImage" TEXT,
+    startup TEXT,
     
     "panelType" TEXT DEFAULT 'pterodactyl',
     
@@ -17,6 +17,9 @@ CREATE TABLE IF NOT EXISTS discord_webhooks (
     description TEXT,
     enabled BOOLEAN NOT NULL DEFAULT true,
 
+    -- Optional Go text/template for the message text; receives the event data
+    "messageTemplate" TEXT,
+
     "testSuccessAt" TIMESTAMP,
 
     "createdAt" TIMESTAMP NOT NULL DEFAULT NOW(),
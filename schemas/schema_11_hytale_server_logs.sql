@@ -6,6 +6,7 @@ CREATE TABLE hytale_server_logs (
     "serverUuid" TEXT NOT NULL,
     "accountId" VARCHAR(255) NOT NULL,
     "logLine" TEXT NOT NULL,
+    level VARCHAR(16) NOT NULL DEFAULT 'INFO',
     "logTimestamp" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
     "createdAt" TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
     
@@ -19,6 +20,7 @@ CREATE INDEX idx_hytale_server_logs_server_uuid ON hytale_server_logs("serverUui
 CREATE INDEX idx_hytale_server_logs_created_at ON hytale_server_logs("createdAt" DESC);
 CREATE INDEX idx_hytale_server_logs_server_created ON hytale_server_logs("serverUuid" DESC, "createdAt" DESC);
 CREATE INDEX idx_hytale_server_logs_account_id ON hytale_server_logs("accountId");
+CREATE INDEX idx_hytale_server_logs_server_level ON hytale_server_logs("serverUuid", level, "createdAt" DESC);
 
 -- Create a table to track log persistence state (last synced timestamp)
 CREATE TABLE hytale_log_sync_state (
@@ -0,0 +1,39 @@
+-- ============================================================================
+-- ADMIN AUDIT SCHEMA - Attributable administrator actions
+-- ============================================================================
+
+-- Admin audit log (impersonation and other sensitive admin actions)
+CREATE TABLE IF NOT EXISTS admin_audit_logs (
+    id TEXT PRIMARY KEY,
+
+    -- Who did what to which record
+    "actorId" TEXT REFERENCES users(id) ON DELETE SET NULL,
+    action TEXT NOT NULL, -- e.g. IMPERSONATION_STARTED, ROLE_CREATED
+    "targetType" TEXT,
+    "targetId" TEXT,
+    details JSONB DEFAULT '{}',
+
+    -- Request context
+    "ipAddress" TEXT,
+    "userAgent" TEXT,
+
+    "createdAt" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_admin_audit_actor_id ON admin_audit_logs("actorId");
+CREATE INDEX IF NOT EXISTS idx_admin_audit_action ON admin_audit_logs(action);
+CREATE INDEX IF NOT EXISTS idx_admin_audit_target ON admin_audit_logs("targetType", "targetId");
+CREATE INDEX IF NOT EXISTS idx_admin_audit_created_at ON admin_audit_logs("createdAt" DESC);
+
+-- Impersonation sessions (one per issued impersonation token, keyed by its jti)
+CREATE TABLE IF NOT EXISTS impersonation_sessions (
+    id TEXT PRIMARY KEY,
+    "adminId" TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    "userId" TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    "expiresAt" TIMESTAMP NOT NULL,
+    "endedAt" TIMESTAMP,
+    "createdAt" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_impersonation_sessions_admin_id ON impersonation_sessions("adminId");
+CREATE INDEX IF NOT EXISTS idx_impersonation_sessions_user_id ON impersonation_sessions("userId");
@@ -0,0 +1,40 @@
+-- ============================================================================
+-- ROLES SCHEMA - Catalog of assignable user roles
+-- ============================================================================
+
+-- Role catalog; users.roles entries must reference a row here
+CREATE TABLE IF NOT EXISTS roles (
+    name TEXT PRIMARY KEY, -- e.g. MEMBER, SUPPORT_TEAM
+    description TEXT,
+    permissions TEXT[] NOT NULL DEFAULT '{}', -- e.g. sync.trigger, users.*, *
+
+    -- Built-in roles cannot be deleted
+    "isSystem" BOOLEAN NOT NULL DEFAULT false,
+
+    "createdAt" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Seed the built-in roles
+INSERT INTO roles (name, description, "isSystem") VALUES
+    ('MEMBER', 'Default role for registered customers', true),
+    ('PARTNER', 'Partner organisation member', true),
+    ('SPONSOR', 'Sponsor of the project', true),
+    ('TECH_TEAM', 'Technical staff', true),
+    ('SUPPORT_TEAM', 'Customer support staff', true),
+    ('ADMINISTRATOR', 'Panel administrator', true),
+    ('SUPER_ADMIN', 'Full system access; grants isSystemAdmin', true)
+ON CONFLICT (name) DO NOTHING;
+
+-- Default permissions for the admin roles (only where none have been set)
+UPDATE roles SET permissions = ARRAY['*']
+WHERE name = 'SUPER_ADMIN' AND permissions = '{}';
+
+UPDATE roles SET permissions = ARRAY[
+    'sync.read', 'sync.trigger', 'sync.manage',
+    'users.read', 'users.manage', 'roles.manage',
+    'settings.read', 'settings.write', 'webhooks.manage',
+    'servers.read', 'servers.manage', 'nodes.read', 'nodes.manage', 'eggs.read', 'eggs.manage', 'stats.read',
+    'hytale.read', 'hytale.manage'
+]
+WHERE name = 'ADMINISTRATOR' AND permissions = '{}';
@@ -0,0 +1,15 @@
+-- ============================================================================
+-- SERVER STATUS EVENTS - Power state transitions for uptime history
+-- ============================================================================
+
+-- One row per observed power state change (e.g. running -> offline)
+CREATE TABLE IF NOT EXISTS server_status_events (
+    id BIGSERIAL PRIMARY KEY,
+    "serverId" TEXT NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+    "previousState" TEXT, -- NULL for the first observation of a server
+    state TEXT NOT NULL,  -- running, starting, stopping, offline, suspended
+    "createdAt" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_server_status_events_server_created ON server_status_events("serverId", "createdAt" DESC);
+CREATE INDEX IF NOT EXISTS idx_server_status_events_created_at ON server_status_events("createdAt");
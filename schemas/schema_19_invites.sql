@@ -0,0 +1,22 @@
+-- ============================================================================
+-- INVITES SCHEMA - Invite codes for invite-only registration
+-- ============================================================================
+
+CREATE TABLE IF NOT EXISTS invites (
+    id TEXT PRIMARY KEY,
+    code TEXT NOT NULL UNIQUE,
+
+    -- Optional restrictions
+    email TEXT, -- only this address may redeem the invite when set
+    "maxUses" INTEGER NOT NULL DEFAULT 1,
+    uses INTEGER NOT NULL DEFAULT 0,
+    "expiresAt" TIMESTAMP,
+    "revokedAt" TIMESTAMP,
+
+    note TEXT,
+    "createdBy" TEXT REFERENCES users(id) ON DELETE SET NULL,
+    "lastUsedAt" TIMESTAMP,
+    "createdAt" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_invites_created_at ON invites("createdAt" DESC);
@@ -0,0 +1,16 @@
+-- ============================================================================
+-- SERVER TAGS - User and global labels for organizing servers
+-- ============================================================================
+
+-- A tag on a server; "userId" is NULL for global tags managed by admins
+CREATE TABLE IF NOT EXISTS server_tags (
+    id BIGSERIAL PRIMARY KEY,
+    "serverId" TEXT NOT NULL REFERENCES servers(id) ON DELETE CASCADE,
+    "userId" TEXT REFERENCES users(id) ON DELETE CASCADE,
+    tag TEXT NOT NULL, -- lower-case, e.g. "production"
+    "createdAt" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_server_tags_unique ON server_tags("serverId", COALESCE("userId", ''), tag);
+CREATE INDEX IF NOT EXISTS idx_server_tags_tag ON server_tags(tag);
+CREATE INDEX IF NOT EXISTS idx_server_tags_user ON server_tags("userId");
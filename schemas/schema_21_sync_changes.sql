@@ -0,0 +1,16 @@
+-- ============================================================================
+-- SYNC CHANGES - Entities created, updated or deleted by each sync run
+-- ============================================================================
+
+-- Capped per sync run (see MaxSyncChangesPerRun); rows go away with their sync log
+CREATE TABLE IF NOT EXISTS sync_changes (
+    id BIGSERIAL PRIMARY KEY,
+    "syncLogId" TEXT NOT NULL REFERENCES sync_logs(id) ON DELETE CASCADE,
+    "entityType" TEXT NOT NULL, -- server, user, node, location
+    "entityId" TEXT NOT NULL,   -- local ID (panel ID for nodes and locations)
+    action TEXT NOT NULL,       -- created, updated, deleted
+    name TEXT,                  -- display name at the time of the change
+    "createdAt" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_sync_changes_sync_log ON sync_changes("syncLogId", "entityType", action);
@@ -0,0 +1,20 @@
+-- ============================================================================
+-- API KEYS SCHEMA - Labeled backend API keys for X-API-Key authentication
+-- ============================================================================
+
+-- Only a SHA-256 hash of each key is stored; the key itself is shown once
+-- when it is created. Keys from BACKEND_API_KEY and API_KEYS are accepted
+-- alongside these.
+CREATE TABLE IF NOT EXISTS api_keys (
+    id TEXT PRIMARY KEY,
+    label TEXT NOT NULL,
+    "keyHash" TEXT NOT NULL UNIQUE,
+    "keyPrefix" TEXT NOT NULL, -- first characters of the key, for identification
+    scope TEXT NOT NULL DEFAULT 'full',
+    "createdBy" TEXT REFERENCES users(id) ON DELETE SET NULL,
+    "lastUsedAt" TIMESTAMP,
+    "revokedAt" TIMESTAMP,
+    "createdAt" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_created_at ON api_keys("createdAt" DESC);
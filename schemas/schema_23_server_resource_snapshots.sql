@@ -0,0 +1,13 @@
+-- ============================================================================
+-- SERVER RESOURCE SNAPSHOTS - Latest live resource usage per server
+-- ============================================================================
+
+-- One row per server, overwritten by the server state sync
+CREATE TABLE IF NOT EXISTS server_resource_snapshots (
+    "serverId" TEXT PRIMARY KEY REFERENCES servers(id) ON DELETE CASCADE,
+    state TEXT NOT NULL,                  -- running, starting, stopping, offline
+    "memoryBytes" BIGINT NOT NULL DEFAULT 0,
+    "diskBytes" BIGINT NOT NULL DEFAULT 0,
+    "cpuAbsolute" DOUBLE PRECISION NOT NULL DEFAULT 0, -- % of one core, like the cpu limit
+    "updatedAt" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,27 @@
+-- ============================================================================
+-- PLANS - Per-user resource limits
+-- ============================================================================
+
+-- Plan catalog. A NULL limit means unlimited. Users without a plan fall back
+-- to the default plan, or have no limits when there is none.
+CREATE TABLE IF NOT EXISTS plans (
+    id TEXT PRIMARY KEY,
+    name TEXT NOT NULL UNIQUE,
+    description TEXT,
+
+    "maxServers" INTEGER,
+    "maxMemory" INTEGER,    -- MB across all servers
+    "maxDisk" INTEGER,      -- MB across all servers
+    "maxCpu" INTEGER,       -- % of one core across all servers
+
+    "isDefault" BOOLEAN NOT NULL DEFAULT false,
+
+    "createdAt" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "updatedAt" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- At most one default plan
+CREATE UNIQUE INDEX IF NOT EXISTS idx_plans_default ON plans("isDefault") WHERE "isDefault";
+
+ALTER TABLE users ADD COLUMN IF NOT EXISTS "planId" TEXT REFERENCES plans(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_users_plan_id ON users("planId");
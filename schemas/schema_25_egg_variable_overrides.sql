@@ -0,0 +1,9 @@
+-- ============================================================================
+-- EGG VARIABLE OVERRIDES - Local visibility and ordering of synced variables
+-- ============================================================================
+
+-- Set by admins and never touched by the panel sync. NULL keeps the synced
+-- value; variables without a display order are listed after ordered ones.
+ALTER TABLE egg_variables ADD COLUMN IF NOT EXISTS "overrideUserViewable" BOOLEAN;
+ALTER TABLE egg_variables ADD COLUMN IF NOT EXISTS "overrideUserEditable" BOOLEAN;
+ALTER TABLE egg_variables ADD COLUMN IF NOT EXISTS "displayOrder" INTEGER;
@@ -0,0 +1,9 @@
+-- ============================================================================
+-- SERVER SUSPENSION - Why and when a server was suspended
+-- ============================================================================
+
+-- Set when an admin suspends a server through the backend. The panel sync
+-- records the time for servers suspended elsewhere and clears both columns
+-- once a server is no longer suspended.
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS "suspensionReason" TEXT;
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS "suspendedAt" TIMESTAMP;
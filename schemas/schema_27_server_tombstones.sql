@@ -0,0 +1,12 @@
+-- ============================================================================
+-- SERVER TOMBSTONES - Servers missing from the panel, kept until purged
+-- ============================================================================
+
+-- With SYNC_SERVER_TOMBSTONES enabled, the panel sync marks servers it no
+-- longer sees deleted instead of removing them, and counts the syncs in a row
+-- that missed them. A server that reappears has both columns reset; the sync
+-- janitor purges tombstones once the grace period and sync count are reached.
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS "deletedAt" TIMESTAMP;
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS "missingSyncs" INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX IF NOT EXISTS idx_servers_deleted_at ON servers ("deletedAt") WHERE "deletedAt" IS NOT NULL;
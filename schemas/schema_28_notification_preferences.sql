@@ -0,0 +1,15 @@
+-- ============================================================================
+-- NOTIFICATION PREFERENCES - Per-user email and in-app opt-outs
+-- ============================================================================
+
+-- One row per user and category the user has changed; categories without a
+-- row use the defaults in database.NotificationCategories. Security emails
+-- are always sent and are never stored here.
+CREATE TABLE IF NOT EXISTS notification_preferences (
+    "userId" TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    category TEXT NOT NULL, -- billing, server_status, support, marketing
+    email BOOLEAN NOT NULL,
+    "inApp" BOOLEAN NOT NULL,
+    "updatedAt" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY ("userId", category)
+);
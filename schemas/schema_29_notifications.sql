@@ -0,0 +1,18 @@
+-- ============================================================================
+-- NOTIFICATIONS - In-app notifications behind the dashboard bell
+-- ============================================================================
+
+-- Written by backend events (server suspensions, servers removed by the panel
+-- sync, ticket replies) for users whose in-app preference allows them
+CREATE TABLE IF NOT EXISTS notifications (
+    id BIGSERIAL PRIMARY KEY,
+    "userId" TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    type TEXT NOT NULL, -- e.g. server_suspended, server_removed, ticket_reply
+    title TEXT NOT NULL,
+    body TEXT NOT NULL DEFAULT '',
+    read BOOLEAN NOT NULL DEFAULT false,
+    "createdAt" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications("userId", "createdAt" DESC);
+CREATE INDEX IF NOT EXISTS idx_notifications_unread ON notifications("userId") WHERE NOT read;
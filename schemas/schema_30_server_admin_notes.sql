@@ -0,0 +1,12 @@
+-- ============================================================================
+-- SERVER ADMIN NOTES - Internal annotations on servers
+-- ============================================================================
+
+-- Free-form notes admins keep on a server (e.g. "VIP customer, do not
+-- suspend"). Never shown to the server owner and never written by the panel
+-- sync, unlike description, which mirrors the panel.
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS "adminNotes" TEXT;
+
+-- When the notes were last edited. Kept apart from "updatedAt" so editing a
+-- note does not look like a change to the server itself.
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS "adminNotesUpdatedAt" TIMESTAMP;
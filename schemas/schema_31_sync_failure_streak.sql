@@ -0,0 +1,13 @@
+-- ============================================================================
+-- SYNC FAILURE STREAK - Consecutive failed syncs for escalated alerts
+-- ============================================================================
+
+-- A single row counting syncs that failed in a row. The worker increments it
+-- on each failure and resets it on success; once it reaches
+-- SYNC_FAILURE_ALERT_THRESHOLD the failure webhook is sent as an alert.
+CREATE TABLE IF NOT EXISTS sync_failure_streak (
+    id INTEGER PRIMARY KEY DEFAULT 1 CHECK (id = 1),
+    "consecutiveFailures" INTEGER NOT NULL DEFAULT 0,
+    "lastFailedSyncId" TEXT,
+    "updatedAt" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,18 @@
+-- ============================================================================
+-- WEBHOOK DELIVERIES - Outcome of each webhook send
+-- ============================================================================
+
+-- One row per attempt to deliver a notification to a Discord webhook, used for
+-- the per-webhook success and failure counts in GET /api/admin/webhooks.
+-- Pruned with old sync logs by the cleanup task.
+CREATE TABLE IF NOT EXISTS webhook_deliveries (
+    id BIGSERIAL PRIMARY KEY,
+    "webhookId" TEXT NOT NULL REFERENCES discord_webhooks(id) ON DELETE CASCADE,
+    event TEXT NOT NULL,
+    success BOOLEAN NOT NULL,
+    error TEXT,
+    "createdAt" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_webhook ON webhook_deliveries("webhookId", "createdAt" DESC);
+CREATE INDEX IF NOT EXISTS idx_webhook_deliveries_created ON webhook_deliveries("createdAt");
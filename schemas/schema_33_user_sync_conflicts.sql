@@ -0,0 +1,29 @@
+-- ============================================================================
+-- USER SYNC CONFLICTS - Panel users the user sync refused to merge
+-- ============================================================================
+
+-- The user sync matches panel users to local users by email. It records a
+-- conflict and leaves the local user untouched when:
+--   EMAIL_TAKEN   - the email belongs to a local user linked to a different
+--                   panel account
+--   EMAIL_CHANGED - the panel account is linked to a local user with another
+--                   email (storing it would create a second local user)
+-- One open row per conflict; later syncs only update lastSeenAt.
+CREATE TABLE IF NOT EXISTS user_sync_conflicts (
+    id BIGSERIAL PRIMARY KEY,
+    kind TEXT NOT NULL, -- EMAIL_TAKEN, EMAIL_CHANGED
+    "userId" TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    email TEXT NOT NULL,            -- the local user's email
+    "localPterodactylId" INTEGER,   -- the local user's panel account
+    "panelEmail" TEXT NOT NULL,
+    "panelPterodactylId" INTEGER NOT NULL,
+    "syncLogId" TEXT REFERENCES sync_logs(id) ON DELETE SET NULL, -- latest sync that hit it
+    "createdAt" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "lastSeenAt" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    "resolvedAt" TIMESTAMP,
+    "resolvedBy" TEXT REFERENCES users(id) ON DELETE SET NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_sync_conflicts_open
+    ON user_sync_conflicts(kind, "userId", "panelPterodactylId") WHERE "resolvedAt" IS NULL;
+CREATE INDEX IF NOT EXISTS idx_user_sync_conflicts_created ON user_sync_conflicts("createdAt" DESC);
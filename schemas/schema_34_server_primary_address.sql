@@ -0,0 +1,8 @@
+-- ============================================================================
+-- SERVER PRIMARY ADDRESS - Last synced connection address of each server
+-- ============================================================================
+
+-- host:port of the server's primary allocation as of the last sync, using the
+-- allocation alias when one is set. The sync compares it to the panel to tell
+-- owners when their connection address changes, e.g. after a node migration.
+ALTER TABLE servers ADD COLUMN IF NOT EXISTS "primaryAddress" TEXT;
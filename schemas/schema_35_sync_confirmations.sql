@@ -0,0 +1,20 @@
+-- ============================================================================
+-- SYNC CONFIRMATIONS - Tokens confirming destructive admin sync actions
+-- ============================================================================
+
+-- When SYNC_CONFIRM_DESTRUCTIVE is on, a sync that prunes stale data or a
+-- sync cancel first returns a short-lived token describing the impact, and
+-- only runs once the same admin sends it back for the same action. Tokens
+-- are stored hashed and work once.
+CREATE TABLE IF NOT EXISTS sync_confirmations (
+    id BIGSERIAL PRIMARY KEY,
+    "tokenHash" TEXT NOT NULL UNIQUE,
+    action TEXT NOT NULL,  -- sync, batch or cancel
+    target TEXT NOT NULL,  -- sync type, batch steps or sync log ID
+    "actorId" TEXT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    "expiresAt" TIMESTAMP NOT NULL,
+    "usedAt" TIMESTAMP,
+    "createdAt" TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_sync_confirmations_expires ON sync_confirmations("expiresAt");
@@ -0,0 +1,18 @@
+-- ============================================================================
+-- HYTALE ACCOUNT OWNERS - Which user authorized each Hytale account
+-- ============================================================================
+
+-- Set when the device code flow completes for a signed-in user. Only that
+-- user may link the account's game sessions to their servers.
+ALTER TABLE hytale_oauth_tokens
+ADD COLUMN IF NOT EXISTS "userId" TEXT REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_hytale_oauth_tokens_user_id ON hytale_oauth_tokens("userId");
+
+-- Server links belong in the "serverId" column from schema_13, which is
+-- removed with its server; carry over links written to the legacy column
+UPDATE hytale_game_sessions gs SET "serverId" = gs.server_id
+WHERE gs."serverId" IS NULL AND gs.server_id IS NOT NULL
+	AND EXISTS (SELECT 1 FROM servers s WHERE s.id = gs.server_id);
+
+UPDATE hytale_game_sessions SET server_id = NULL WHERE server_id IS NOT NULL;
@@ -0,0 +1,7 @@
+-- ============================================================================
+-- USER PENDING EMAIL - Email changes awaiting confirmation
+-- ============================================================================
+
+-- Email changes are held here until the confirmation token sent to the new
+-- address is used, so the current email stays active until then.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS "pendingEmail" TEXT;
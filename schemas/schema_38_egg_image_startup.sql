@@ -0,0 +1,8 @@
+-- ============================================================================
+-- EGG IMAGE AND STARTUP - Docker image and startup command of each egg
+-- ============================================================================
+
+-- Populated by the nests/eggs sync step and exposed to admins through the
+-- egg detail endpoint.
+ALTER TABLE eggs ADD COLUMN IF NOT EXISTS "dockerImage" TEXT;
+ALTER TABLE eggs ADD COLUMN IF NOT EXISTS startup TEXT;
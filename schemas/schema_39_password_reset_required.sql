@@ -0,0 +1,7 @@
+-- ============================================================================
+-- PASSWORD RESET REQUIRED - Users who must set a password before signing in
+-- ============================================================================
+
+-- Users created by the bulk CSV import have no password and must set one via
+-- the password reset flow before they can sign in with a password.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS "passwordResetRequired" BOOLEAN DEFAULT false;
@@ -0,0 +1,7 @@
+-- ============================================================================
+-- WEBHOOK TEMPLATES - Per-webhook message templates
+-- ============================================================================
+
+-- Optional Go text/template that replaces the default embed description; it
+-- receives the event data fields plus "event".
+ALTER TABLE discord_webhooks ADD COLUMN IF NOT EXISTS "messageTemplate" TEXT;
@@ -0,0 +1,13 @@
+-- ============================================================================
+-- HYTALE SERVER LOG LEVEL - Level of each shipped server log line
+-- ============================================================================
+
+-- Log lines shipped by the Hytale egg carry a level (INFO, WARN, ERROR, ...)
+-- so support can filter a server's logs down to warnings and errors.
+ALTER TABLE hytale_server_logs ADD COLUMN IF NOT EXISTS level VARCHAR(16) NOT NULL DEFAULT 'INFO';
+
+-- Match the admin log listing, newest log line first, with and without a
+-- level filter
+DROP INDEX IF EXISTS idx_hytale_server_logs_server_level;
+CREATE INDEX IF NOT EXISTS idx_hytale_server_logs_server_level_ts ON hytale_server_logs("serverUuid", level, "logTimestamp" DESC, id DESC);
+CREATE INDEX IF NOT EXISTS idx_hytale_server_logs_server_ts ON hytale_server_logs("serverUuid", "logTimestamp" DESC, id DESC);
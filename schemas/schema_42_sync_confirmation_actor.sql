@@ -0,0 +1,7 @@
+-- ============================================================================
+-- SYNC CONFIRMATION ACTOR - Confirmations issued to API keys
+-- ============================================================================
+
+-- The API-key full sync route also requires a confirmation token, issued to
+-- the key rather than to a user, so the actor is no longer a user ID
+ALTER TABLE sync_confirmations DROP CONSTRAINT IF EXISTS "sync_confirmations_actorId_fkey";
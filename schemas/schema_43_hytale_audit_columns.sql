@@ -0,0 +1,67 @@
+-- Migration: rename camelCase columns to snake_case in hytale_audit_logs
+-- Safe to run multiple times (checks column existence before renaming)
+
+-- Columns use snake_case like the other Hytale tables. Entries are no longer
+-- tied to a stored token: device code requests and auth failures happen
+-- before one exists, and the trail must survive token deletion.
+DO $$
+BEGIN
+    IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name='hytale_audit_logs' AND column_name='accountId') THEN
+        ALTER TABLE hytale_audit_logs RENAME COLUMN "accountId" TO account_id;
+    END IF;
+
+    IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name='hytale_audit_logs' AND column_name='profileId') THEN
+        ALTER TABLE hytale_audit_logs RENAME COLUMN "profileId" TO profile_id;
+    END IF;
+
+    IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name='hytale_audit_logs' AND column_name='eventType') THEN
+        ALTER TABLE hytale_audit_logs RENAME COLUMN "eventType" TO event_type;
+    END IF;
+
+    IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name='hytale_audit_logs' AND column_name='ipAddress') THEN
+        ALTER TABLE hytale_audit_logs RENAME COLUMN "ipAddress" TO ip_address;
+    END IF;
+
+    IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name='hytale_audit_logs' AND column_name='userAgent') THEN
+        ALTER TABLE hytale_audit_logs RENAME COLUMN "userAgent" TO user_agent;
+    END IF;
+
+    IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name='hytale_audit_logs' AND column_name='createdAt') THEN
+        ALTER TABLE hytale_audit_logs RENAME COLUMN "createdAt" TO created_at;
+    END IF;
+
+    -- Client addresses are stored as reported, which is not always a valid inet
+    IF EXISTS (SELECT 1 FROM information_schema.columns WHERE table_name='hytale_audit_logs' AND column_name='ip_address' AND data_type='inet') THEN
+        ALTER TABLE hytale_audit_logs ALTER COLUMN ip_address TYPE TEXT USING host(ip_address);
+    END IF;
+END $$;
+
+ALTER TABLE hytale_audit_logs DROP CONSTRAINT IF EXISTS "hytale_audit_logs_accountId_fkey";
+
+-- Recreate indexes using the new snake_case column names (DROP IF EXISTS first)
+DROP INDEX IF EXISTS idx_hytale_audit_account_id;
+DROP INDEX IF EXISTS idx_hytale_audit_event_type;
+DROP INDEX IF EXISTS idx_hytale_audit_created_at;
+DROP INDEX IF EXISTS idx_hytale_audit_account_created;
+
+CREATE INDEX IF NOT EXISTS idx_hytale_audit_account_id ON hytale_audit_logs(account_id);
+CREATE INDEX IF NOT EXISTS idx_hytale_audit_event_type ON hytale_audit_logs(event_type);
+CREATE INDEX IF NOT EXISTS idx_hytale_audit_created_at ON hytale_audit_logs(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_hytale_audit_account_created ON hytale_audit_logs(account_id, created_at DESC);
+
+-- Recreate the event type check with device code requests added
+ALTER TABLE hytale_audit_logs DROP CONSTRAINT IF EXISTS check_valid_event_type;
+ALTER TABLE hytale_audit_logs
+ADD CONSTRAINT check_valid_event_type CHECK (
+    event_type IN (
+        'DEVICE_CODE_REQUESTED',
+        'TOKEN_CREATED',
+        'TOKEN_REFRESHED',
+        'TOKEN_DELETED',
+        'AUTH_FAILED',
+        'SESSION_CREATED',
+        'SESSION_REFRESHED',
+        'SESSION_DELETED',
+        'PROFILE_SELECTED'
+    )
+);